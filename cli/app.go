@@ -19,11 +19,13 @@
 	"github.com/kopia/kopia/internal/gather"
 	"github.com/kopia/kopia/internal/passwordpersist"
 	"github.com/kopia/kopia/internal/releasable"
+	"github.com/kopia/kopia/notification"
 	"github.com/kopia/kopia/repo"
 	"github.com/kopia/kopia/repo/blob"
 	"github.com/kopia/kopia/repo/logging"
 	"github.com/kopia/kopia/repo/maintenance"
 	"github.com/kopia/kopia/snapshot/restore"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
 	"github.com/kopia/kopia/snapshot/snapshotmaintenance"
 )
 
@@ -81,6 +83,7 @@ type appServices interface {
 	directRepositoryReadAction(act func(ctx context.Context, rep repo.DirectRepository) error) func(ctx *kingpin.ParseContext) error
 	repositoryReaderAction(act func(ctx context.Context, rep repo.Repository) error) func(ctx *kingpin.ParseContext) error
 	repositoryWriterAction(act func(ctx context.Context, rep repo.RepositoryWriter) error) func(ctx *kingpin.ParseContext) error
+	repositoryWriterActionWithOptions(customize func(opt *repo.WriteSessionOptions), act func(ctx context.Context, rep repo.RepositoryWriter) error) func(ctx *kingpin.ParseContext) error
 	maybeRepositoryAction(act func(ctx context.Context, rep repo.Repository) error, mode repositoryAccessMode) func(ctx *kingpin.ParseContext) error
 	baseActionWithContext(act func(ctx context.Context) error) func(ctx *kingpin.ParseContext) error
 	openRepository(ctx context.Context, mustBeConnected bool) (repo.Repository, error)
@@ -88,10 +91,12 @@ type appServices interface {
 	repositoryConfigFileName() string
 	getProgress() *cliProgress
 	getRestoreProgress() restore.Progress
+	getVerifyProgress() snapshotfs.VerifierProgress
 
 	stdout() io.Writer
 	Stderr() io.Writer
 	stdin() io.Reader
+	askPass(out io.Writer, prompt string) (string, error)
 	onTerminate(callback func())
 	onRepositoryFatalError(callback func(err error))
 	enableTestOnlyFlags() bool
@@ -121,59 +126,70 @@ type App struct {
 	pf                            profileFlags
 	progress                      *cliProgress
 	restoreProgress               restore.Progress
+	verifyProgress                snapshotfs.VerifierProgress
 	initialUpdateCheckDelay       time.Duration
 	updateCheckInterval           time.Duration
 	updateAvailableNotifyInterval time.Duration
 	password                      string
 	configPath                    string
+	connectionProfile             string
 	traceStorage                  bool
 	keyRingEnabled                bool
 	persistCredentials            bool
 	disableInternalLog            bool
 	dumpAllocatorStats            bool
+	errorJSON                     bool
+	interactive                   bool
 	AdvancedCommands              string
 	cliStorageProviders           []StorageProvider
 	trackReleasable               []string
 
 	observability       observabilityFlags
+	memory              memoryFlags
 	upgradeOwnerID      string
 	doNotWaitForUpgrade bool
+	openTimeout         time.Duration
+	openReadOnly        bool
 
 	currentAction         string
 	onExitCallbacks       []func()
 	onFatalErrorCallbacks []func(err error)
 
 	// subcommands
-	blob        commandBlob
-	benchmark   commandBenchmark
-	cache       commandCache
-	content     commandContent
-	diff        commandDiff
-	index       commandIndex
-	list        commandList
-	server      commandServer
-	session     commandSession
-	policy      commandPolicy
-	restore     commandRestore
-	show        commandShow
-	snapshot    commandSnapshot
-	manifest    commandManifest
-	mount       commandMount
-	maintenance commandMaintenance
-	repository  commandRepository
-	logs        commandLogs
+	blob         commandBlob
+	benchmark    commandBenchmark
+	cache        commandCache
+	content      commandContent
+	diff         commandDiff
+	index        commandIndex
+	list         commandList
+	server       commandServer
+	session      commandSession
+	policy       commandPolicy
+	restore      commandRestore
+	show         commandShow
+	snapshot     commandSnapshot
+	manifest     commandManifest
+	mount        commandMount
+	maintenance  commandMaintenance
+	notification commandNotification
+	service      commandService
+	systemd      commandSystemd
+	repository   commandRepository
+	logs         commandLogs
 
 	// testability hooks
 	testonlyIgnoreMissingRequiredFeatures bool
 
 	isInProcessTest bool
-	exitWithError   func(err error) // os.Exit() with 1 or 0 based on err
+	exitWithError   func(err error) // os.Exit() with exit code based on err
 	stdinReader     io.Reader
 	stdoutWriter    io.Writer
 	stderrWriter    io.Writer
 	rootctx         context.Context //nolint:containedctx
 	loggerFactory   logging.LoggerFactory
 	simulatedCtrlC  chan bool
+	osServiceStop   <-chan struct{} // closed when running as an OS service which is being stopped
 	envNamePrefix   string
 }
 
@@ -194,6 +210,15 @@ func (c *App) getRestoreProgress() restore.Progress {
 	return c.restoreProgress
 }
 
+// SetVerifyProgress is used to set custom snapshot verification progress, purposed to be used in tests.
+func (c *App) SetVerifyProgress(p snapshotfs.VerifierProgress) {
+	c.verifyProgress = p
+}
+
+func (c *App) getVerifyProgress() snapshotfs.VerifierProgress {
+	return c.verifyProgress
+}
+
 func (c *App) stdin() io.Reader {
 	return c.stdinReader
 }
@@ -247,7 +272,9 @@ func (c *App) setup(app *kingpin.Application) {
 			c.currentAction = "unknown-action"
 		}
 
-		return nil
+		c.osServiceStop = osServiceStopRequested()
+
+		return validateConnectionProfileName(c.connectionProfile)
 	})
 
 	_ = app.Flag("help-full", "Show help for all commands, including hidden").Action(func(pc *kingpin.ParseContext) error {
@@ -263,6 +290,7 @@ func (c *App) setup(app *kingpin.Application) {
 	app.Flag("update-check-interval", "Interval between update checks").Default("168h").Hidden().Envar(c.EnvName("KOPIA_UPDATE_CHECK_INTERVAL")).DurationVar(&c.updateCheckInterval)
 	app.Flag("update-available-notify-interval", "Interval between update notifications").Default("1h").Hidden().Envar(c.EnvName("KOPIA_UPDATE_NOTIFY_INTERVAL")).DurationVar(&c.updateAvailableNotifyInterval)
 	app.Flag("config-file", "Specify the config file to use").Default("repository.config").Envar(c.EnvName("KOPIA_CONFIG_PATH")).StringVar(&c.configPath)
+	app.Flag("profile", "Use named connection profile stored in the profiles directory next to the config file").Envar(c.EnvName("KOPIA_PROFILE")).StringVar(&c.connectionProfile)
 	app.Flag("trace-storage", "Enables tracing of storage operations.").Default("true").Hidden().BoolVar(&c.traceStorage)
 	app.Flag("timezone", "Format time according to specified time zone (local, utc, original or time zone name)").Hidden().StringVar(&timeZone)
 	app.Flag("password", "Repository password.").Envar(c.EnvName("KOPIA_PASSWORD")).Short('p').StringVar(&c.password)
@@ -270,15 +298,20 @@ func (c *App) setup(app *kingpin.Application) {
 	app.Flag("disable-internal-log", "Disable internal log").Hidden().Envar(c.EnvName("KOPIA_DISABLE_INTERNAL_LOG")).BoolVar(&c.disableInternalLog)
 	app.Flag("advanced-commands", "Enable advanced (and potentially dangerous) commands.").Hidden().Envar(c.EnvName("KOPIA_ADVANCED_COMMANDS")).StringVar(&c.AdvancedCommands)
 	app.Flag("track-releasable", "Enable tracking of releasable resources.").Hidden().Envar(c.EnvName("KOPIA_TRACK_RELEASABLE")).StringsVar(&c.trackReleasable)
+	app.Flag("interactive", "Allow interactive prompts, use --no-interactive to fail instead of prompting.").Default("true").Envar(c.EnvName("KOPIA_INTERACTIVE")).BoolVar(&c.interactive)
+	app.Flag("error-json", "Print machine-readable error summary to stderr on failure.").Envar(c.EnvName("KOPIA_ERROR_JSON")).BoolVar(&c.errorJSON)
 	app.Flag("dump-allocator-stats", "Dump allocator stats at the end of execution.").Hidden().Envar(c.EnvName("KOPIA_DUMP_ALLOCATOR_STATS")).BoolVar(&c.dumpAllocatorStats)
 	app.Flag("upgrade-owner-id", "Repository format upgrade owner-id.").Hidden().Envar(c.EnvName("KOPIA_REPO_UPGRADE_OWNER_ID")).StringVar(&c.upgradeOwnerID)
 	app.Flag("upgrade-no-block", "Do not block when repository format upgrade is in progress, instead exit with a message.").Hidden().Default("false").Envar(c.EnvName("KOPIA_REPO_UPGRADE_NO_BLOCK")).BoolVar(&c.doNotWaitForUpgrade)
+	app.Flag("repository-open-timeout", "Maximum time to wait for the repository to open (0 = unlimited).").Default("0").Envar(c.EnvName("KOPIA_REPOSITORY_OPEN_TIMEOUT")).DurationVar(&c.openTimeout)
+	app.Flag("repository-read-only", "Open the repository in read-only mode, guaranteeing that no changes are written to storage.").Envar(c.EnvName("KOPIA_REPOSITORY_READ_ONLY")).BoolVar(&c.openReadOnly)
 
 	if c.enableTestOnlyFlags() {
 		app.Flag("ignore-missing-required-features", "Open repository despite missing features (VERY DANGEROUS, ONLY FOR TESTING)").Hidden().BoolVar(&c.testonlyIgnoreMissingRequiredFeatures)
 	}
 
 	c.observability.setup(c, app)
+	c.memory.setup(c, app)
 
 	c.setupOSSpecificKeychainFlags(c, app)
 
@@ -297,6 +330,10 @@ func (c *App) setup(app *kingpin.Application) {
 		rp.setup(c, app)
 	}
 
+	if vp, ok := c.verifyProgress.(*cliVerifyProgress); ok {
+		vp.setup(c, app)
+	}
+
 	c.blob.setup(c, app)
 	c.benchmark.setup(c, app)
 	c.cache.setup(c, app)
@@ -314,6 +351,9 @@ func (c *App) setup(app *kingpin.Application) {
 	c.policy.setup(c, app)
 	c.mount.setup(c, app)
 	c.maintenance.setup(c, app)
+	c.notification.setup(c, app)
+	c.service.setup(c, app)
+	c.systemd.setup(c, app)
 	c.repository.setup(c, app)
 }
 
@@ -327,6 +367,7 @@ func NewApp() *App {
 	return &App{
 		progress:        &cliProgress{},
 		restoreProgress: &cliRestoreProgress{},
+		verifyProgress:  &cliVerifyProgress{},
 		cliStorageProviders: []StorageProvider{
 			{"from-config", "the provided configuration file", func() StorageFlags { return &storageFromConfigFlags{} }},
 
@@ -335,7 +376,9 @@ func NewApp() *App {
 			{"filesystem", "a filesystem", func() StorageFlags { return &storageFilesystemFlags{} }},
 			{"gcs", "a Google Cloud Storage bucket", func() StorageFlags { return &storageGCSFlags{} }},
 			{"gdrive", "a Google Drive folder", func() StorageFlags { return &storageGDriveFlags{} }},
+			{"kopia-server", "a repository exposed by Kopia server", func() StorageFlags { return &storageKopiaServerFlags{} }},
 
+			{"plugin", "an external storage plugin", func() StorageFlags { return &storagePluginFlags{} }},
 			{"rclone", "a rclone-based provided", func() StorageFlags { return &storageRcloneFlags{} }},
 			{"s3", "an S3 bucket", func() StorageFlags { return &storageS3Flags{} }},
 			{"sftp", "an SFTP storage", func() StorageFlags { return &storageSFTPFlags{} }},
@@ -344,11 +387,7 @@ func NewApp() *App {
 
 		// testability hooks
 		exitWithError: func(err error) {
-			if err != nil {
-				os.Exit(1)
-			}
-
-			os.Exit(0)
+			os.Exit(ExitCodeForError(err))
 		},
 		stdoutWriter: colorable.NewColorableStdout(),
 		stderrWriter: colorable.NewColorableStderr(),
@@ -479,11 +518,23 @@ func (c *App) repositoryReaderAction(act func(ctx context.Context, rep repo.Repo
 }
 
 func (c *App) repositoryWriterAction(act func(ctx context.Context, rep repo.RepositoryWriter) error) func(ctx *kingpin.ParseContext) error {
+	return c.repositoryWriterActionWithOptions(nil, act)
+}
+
+// repositoryWriterActionWithOptions is like repositoryWriterAction but allows the command to customize
+// the write session options after its flags have been parsed.
+func (c *App) repositoryWriterActionWithOptions(customize func(opt *repo.WriteSessionOptions), act func(ctx context.Context, rep repo.RepositoryWriter) error) func(ctx *kingpin.ParseContext) error {
 	return c.maybeRepositoryAction(func(ctx context.Context, rep repo.Repository) error {
-		return repo.WriteSession(ctx, rep, repo.WriteSessionOptions{
+		opt := repo.WriteSessionOptions{
 			Purpose:  "cli:" + c.currentActionName(),
 			OnUpload: c.progress.UploadedBytes,
-		}, func(ctx context.Context, w repo.RepositoryWriter) error {
+		}
+
+		if customize != nil {
+			customize(&opt)
+		}
+
+		return repo.WriteSession(ctx, rep, opt, func(ctx context.Context, w repo.RepositoryWriter) error {
 			return act(ctx, w)
 		})
 	}, repositoryAccessMode{
@@ -502,6 +553,8 @@ func (c *App) runAppWithContext(command *kingpin.CmdClause, cb func(ctx context.
 		releasable.EnableTracking(releasable.ItemKind(r))
 	}
 
+	c.memory.apply(ctx)
+
 	if err := c.observability.startMetrics(ctx); err != nil {
 		return errors.Wrap(err, "unable to start metrics")
 	}
@@ -510,6 +563,7 @@ func (c *App) runAppWithContext(command *kingpin.CmdClause, cb func(ctx context.
 		tctx, span := tracer.Start(ctx, command.FullCommand(), trace.WithSpanKind(trace.SpanKindClient))
 		defer span.End()
 		defer c.runOnExit()
+		defer notification.Wait()
 
 		return cb(tctx)
 	}()
@@ -519,6 +573,11 @@ func (c *App) runAppWithContext(command *kingpin.CmdClause, cb func(ctx context.
 	if err != nil {
 		// print error in red
 		log(ctx).Errorf("%v", err.Error())
+
+		if c.errorJSON {
+			c.printErrorSummaryJSON(err)
+		}
+
 		c.exitWithError(err)
 	}
 
@@ -379,4 +379,70 @@ func (p *cliRestoreProgress) output(suffix string) {
 	p.out.printStderr("\r%v%v%v", line, extraSpaces, suffix)
 }
 
-var _ snapshotfs.UploadProgress = (*cliProgress)(nil)
+type cliVerifyProgress struct {
+	queuedCount    atomic.Int32
+	processedCount atomic.Int32
+	failedCount    atomic.Int32
+	readBytes      atomic.Int64
+
+	svc            appServices
+	outputThrottle timetrack.Throttle
+	outputMutex    sync.Mutex
+
+	// +checklocks:outputMutex
+	lastLineLength int
+}
+
+func (p *cliVerifyProgress) setup(svc appServices, _ *kingpin.Application) {
+	p.svc = svc
+}
+
+func (p *cliVerifyProgress) SetCounters(queuedCount, processedCount, failedCount int32, readBytes int64) {
+	p.queuedCount.Store(queuedCount)
+	p.processedCount.Store(processedCount)
+	p.failedCount.Store(failedCount)
+	p.readBytes.Store(readBytes)
+
+	if p.outputThrottle.ShouldOutput(p.svc.getProgress().progressUpdateInterval) {
+		p.output("")
+	}
+}
+
+func (p *cliVerifyProgress) Flush() {
+	p.outputThrottle.Reset()
+	p.output("\n")
+}
+
+func (p *cliVerifyProgress) output(suffix string) {
+	cp := p.svc.getProgress()
+	if !cp.enableProgress {
+		return
+	}
+
+	p.outputMutex.Lock()
+	defer p.outputMutex.Unlock()
+
+	var maybeFailed string
+
+	if failed := p.failedCount.Load(); failed > 0 {
+		maybeFailed = fmt.Sprintf(", %v failed", failed)
+	}
+
+	line := fmt.Sprintf("Verified %v of %v objects, read %v%v.",
+		p.processedCount.Load(), p.queuedCount.Load(), units.BytesString(p.readBytes.Load()), maybeFailed)
+
+	var extraSpaces string
+
+	if len(line) < p.lastLineLength {
+		// add extra spaces to wipe over previous line if it was longer than current
+		extraSpaces = strings.Repeat(" ", p.lastLineLength-len(line))
+	}
+
+	p.lastLineLength = len(line)
+	cp.out.printStderr("\r%v%v%v", line, extraSpaces, suffix)
+}
+
+var (
+	_ snapshotfs.UploadProgress   = (*cliProgress)(nil)
+	_ snapshotfs.VerifierProgress = (*cliVerifyProgress)(nil)
+)
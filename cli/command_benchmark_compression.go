@@ -236,6 +236,7 @@ func (c *commandBenchmarkCompression) runCompression(ctx context.Context, data [
 
 	c.sortResults(results)
 	c.printResults(results)
+	c.printRecommendations(results)
 
 	return nil
 }
@@ -353,6 +354,28 @@ func (c *commandBenchmarkCompression) printResults(results []compressionBechmark
 	}
 }
 
+func (c *commandBenchmarkCompression) printRecommendations(results []compressionBechmarkResult) {
+	if len(results) == 0 {
+		return
+	}
+
+	fastest, smallest := results[0], results[0]
+
+	for _, r := range results[1:] {
+		if r.throughput > fastest.throughput {
+			fastest = r
+		}
+
+		if r.compressedSize < smallest.compressedSize {
+			smallest = r
+		}
+	}
+
+	c.out.printStdout("------------------------------------------------------------------------------------------------\n")
+	c.out.printStdout("Fastest option for this data is: --compression=%s\n", fastest.compression)
+	c.out.printStdout("Smallest output for this data is: --compression=%s\n", smallest.compression)
+}
+
 func hashOf(b []byte) uint64 {
 	h := fnv.New64a()
 	h.Write(b)
@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	atunits "github.com/alecthomas/units"
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/snapshotbench"
+	"github.com/kopia/kopia/internal/units"
+	"github.com/kopia/kopia/repo/compression"
+)
+
+type commandBenchmarkSnapshot struct {
+	corpora     []string
+	dataSize    atunits.Base2Bytes
+	randSeed    int64
+	compression string
+	parallel    int
+	workDir     string
+
+	out textOutput
+}
+
+func (c *commandBenchmarkSnapshot) setup(svc appServices, parent commandParent) {
+	var corpusNames []string
+
+	for _, c := range snapshotbench.Corpora {
+		corpusNames = append(corpusNames, string(c))
+	}
+
+	compressionNames := []string{"none"}
+
+	for name := range compression.ByName {
+		compressionNames = append(compressionNames, string(name))
+	}
+
+	cmd := parent.Command("snapshot", "Run end-to-end snapshot and restore benchmarks using synthetic data")
+	cmd.Flag("corpus", "Synthetic dataset to use (default: all)").EnumsVar(&c.corpora, corpusNames...)
+	cmd.Flag("data-size", "Total size of each dataset").Default("256MB").BytesVar(&c.dataSize)
+	cmd.Flag("rand-seed", "Random seed").Default("42").Int64Var(&c.randSeed)
+	cmd.Flag("compression", "Compression algorithm to use").Default("none").EnumVar(&c.compression, compressionNames...)
+	cmd.Flag("parallel", "Restore parallelism").Default("8").IntVar(&c.parallel)
+	cmd.Flag("work-dir", "Directory for datasets, repositories and restored data (default: temporary directory)").StringVar(&c.workDir)
+	cmd.Action(svc.noRepositoryAction(c.run))
+	c.out.setup(svc)
+}
+
+func (c *commandBenchmarkSnapshot) run(ctx context.Context) error {
+	workDir := c.workDir
+	if workDir == "" {
+		d, err := os.MkdirTemp("", "kopia-benchmark")
+		if err != nil {
+			return errors.Wrap(err, "unable to create temporary directory")
+		}
+
+		defer os.RemoveAll(d) //nolint:errcheck
+
+		workDir = d
+	}
+
+	corpora := snapshotbench.Corpora
+	if len(c.corpora) > 0 {
+		corpora = nil
+
+		for _, n := range c.corpora {
+			corpora = append(corpora, snapshotbench.Corpus(n))
+		}
+	}
+
+	var results []snapshotbench.Result
+
+	for _, corpus := range corpora {
+		log(ctx).Infof("Benchmarking %v (%v)...", corpus, units.BytesString(int64(c.dataSize)))
+
+		res, err := snapshotbench.Run(ctx, filepath.Join(workDir, string(corpus)), snapshotbench.Options{
+			Corpus:          corpus,
+			TotalSize:       int64(c.dataSize),
+			Seed:            c.randSeed,
+			Compression:     compression.Name(c.compression),
+			RestoreParallel: c.parallel,
+		})
+		if err != nil {
+			return errors.Wrapf(err, "error benchmarking %v", corpus)
+		}
+
+		results = append(results, res)
+	}
+
+	c.out.printStdout("     %-22v %8v %12v %12v %12v %12v\n", "Corpus", "Files", "Size", "Stored", "Snapshot", "Restore")
+	c.out.printStdout("-----------------------------------------------------------------------------------------\n")
+
+	for i, r := range results {
+		c.out.printStdout("%3d. %-22v %8v %12v %12v %12v %12v\n",
+			i,
+			corpora[i],
+			r.FileCount,
+			units.BytesString(r.TotalBytes),
+			units.BytesString(r.RepositoryBytes),
+			units.BytesPerSecondsString(r.SnapshotThroughput()),
+			units.BytesPerSecondsString(r.RestoreThroughput()),
+		)
+	}
+
+	return nil
+}
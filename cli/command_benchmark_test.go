@@ -4,8 +4,11 @@
 	"bytes"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/stretchr/testify/require"
+
 	"github.com/kopia/kopia/internal/testutil"
 	"github.com/kopia/kopia/tests/testenv"
 )
@@ -55,6 +58,28 @@ func TestCommandBenchmarkCompression(t *testing.T) {
 	testFile := filepath.Join(testutil.TempDirectory(t), "testfile.txt")
 	os.WriteFile(testFile, bytes.Repeat([]byte{1, 2, 3, 4, 5, 6}, 10000), 0o600)
 
-	e.RunAndExpectSuccess(t, "benchmark", "compression", "--data-file", testFile, "--repeat=2", "--verify-stable", "--print-options")
+	out := e.RunAndExpectSuccess(t, "benchmark", "compression", "--data-file", testFile, "--repeat=2", "--verify-stable", "--print-options")
+	require.True(t, containsLineStartingWith(out, "Fastest option for this data is: --compression="), "missing recommendation: %v", out)
+	require.True(t, containsLineStartingWith(out, "Smallest output for this data is: --compression="), "missing recommendation: %v", out)
 	e.RunAndExpectSuccess(t, "benchmark", "compression", "--data-file", testFile, "--repeat=2", "--by-size")
 }
+
+func containsLineStartingWith(lines []string, prefix string) bool {
+	for _, l := range lines {
+		if strings.HasPrefix(l, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestCommandBenchmarkSnapshot(t *testing.T) {
+	t.Parallel()
+
+	runner := testenv.NewInProcRunner(t)
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, runner)
+
+	e.RunAndExpectSuccess(t, "benchmark", "snapshot", "--data-size=100KB", "--compression=zstd", "--work-dir", testutil.TempDirectory(t))
+	e.RunAndExpectSuccess(t, "benchmark", "snapshot", "--data-size=100KB", "--corpus=many-small-files")
+}
@@ -15,14 +15,31 @@ type commandBlobStats struct {
 	raw    bool
 	prefix string
 
+	jo  jsonOutput
 	out textOutput
 }
 
+// BlobStats is used to display blob statistics in JSON format.
+type BlobStats struct {
+	Count     int64             `json:"count"`
+	TotalSize int64             `json:"totalSize"`
+	Histogram []BlobStatsBucket `json:"histogram"`
+}
+
+// BlobStatsBucket represents a single bucket of blob size histogram.
+type BlobStatsBucket struct {
+	MinSize   int64 `json:"minSize"`
+	MaxSize   int64 `json:"maxSize"`
+	Count     int   `json:"count"`
+	TotalSize int64 `json:"totalSize"`
+}
+
 func (c *commandBlobStats) setup(svc appServices, parent commandParent) {
 	cmd := parent.Command("stats", "Blob statistics")
 	cmd.Flag("raw", "Raw numbers").Short('r').BoolVar(&c.raw)
 	cmd.Flag("prefix", "Blob name prefix").StringVar(&c.prefix)
 	cmd.Action(svc.directRepositoryReadAction(c.run))
+	c.jo.setup(svc, cmd)
 	c.out.setup(svc)
 }
 
@@ -62,6 +79,30 @@ func(b blob.Metadata) error {
 		return errors.Wrap(err, "error listing blobs")
 	}
 
+	if c.jo.jsonOutput {
+		result := BlobStats{
+			Count:     count,
+			TotalSize: totalSize,
+		}
+
+		var lastSize int64
+
+		for _, size := range sizeThresholds {
+			result.Histogram = append(result.Histogram, BlobStatsBucket{
+				MinSize:   lastSize,
+				MaxSize:   size,
+				Count:     countMap[size] - countMap[lastSize],
+				TotalSize: totalSizeOfContentsUnder[size] - totalSizeOfContentsUnder[lastSize],
+			})
+
+			lastSize = size
+		}
+
+		c.out.printStdout("%s\n", c.jo.jsonBytes(result))
+
+		return nil
+	}
+
 	sizeToString := units.BytesString
 	if c.raw {
 		sizeToString = func(l int64) string {
@@ -0,0 +1,36 @@
+package cli_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/cli"
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestBlobStatsJSON(t *testing.T) {
+	t.Parallel()
+
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	var bs cli.BlobStats
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "blob", "stats", "--json"), &bs)
+
+	require.Positive(t, bs.Count)
+	require.Positive(t, bs.TotalSize)
+	require.NotEmpty(t, bs.Histogram)
+
+	var histogramCount int
+
+	for _, b := range bs.Histogram {
+		histogramCount += b.Count
+	}
+
+	require.EqualValues(t, bs.Count, histogramCount)
+}
@@ -20,7 +20,7 @@ type commandCacheClear struct {
 
 func (c *commandCacheClear) setup(svc appServices, parent commandParent) {
 	cmd := parent.Command("clear", "Clears the cache")
-	cmd.Flag("partial", "Specifies the cache to clear").EnumVar(&c.partial, "contents", "indexes", "metadata", "own-writes", "blob-list")
+	cmd.Flag("partial", "Specifies the cache to clear").EnumVar(&c.partial, "contents", "indexes", "metadata", "own-writes", "blob-list", "blobs")
 	cmd.Action(svc.repositoryReaderAction(c.run))
 
 	c.svc = svc
@@ -50,6 +50,7 @@ func (c *commandCacheInfo) run(ctx context.Context, _ repo.Repository) error {
 		"contents":        opts.ContentCacheSizeBytes,
 		"metadata":        opts.MetadataCacheSizeBytes,
 		"server-contents": opts.ContentCacheSizeBytes,
+		"blobs":           opts.BlobCacheSizeBytes,
 	}
 
 	path2HardLimit := map[string]int64{
@@ -63,6 +64,7 @@ func (c *commandCacheInfo) run(ctx context.Context, _ repo.Repository) error {
 		"metadata":        opts.MinMetadataSweepAge.DurationOrDefault(content.DefaultMetadataCacheSweepAge),
 		"indexes":         opts.MinIndexSweepAge.DurationOrDefault(content.DefaultIndexCacheSweepAge),
 		"server-contents": opts.MinContentSweepAge.DurationOrDefault(content.DefaultDataCacheSweepAge),
+		"blobs":           opts.MinContentSweepAge.DurationOrDefault(content.DefaultDataCacheSweepAge),
 	}
 
 	for _, ent := range entries {
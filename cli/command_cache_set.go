@@ -21,6 +21,8 @@ type cacheSizeFlags struct {
 	metadataCacheSizeLimitMB int64
 	metadataMinSweepAge      time.Duration
 
+	blobCacheSizeMB int64
+
 	maxListCacheDuration time.Duration
 	indexMinSweepAge     time.Duration
 }
@@ -34,6 +36,7 @@ func (c *cacheSizeFlags) setup(cmd *kingpin.CmdClause) {
 	cmd.Flag("metadata-cache-size-mb", "Desired size of local metadata cache (soft limit)").PlaceHolder("MB").Int64Var(&c.metadataCacheSizeMB)
 	cmd.Flag("metadata-cache-size-limit-mb", "Maximum size of local metadata cache (hard limit)").PlaceHolder("MB").Int64Var(&c.metadataCacheSizeLimitMB)
 	cmd.Flag("metadata-min-sweep-age", "Minimal age of metadata cache item to be subject to sweeping").DurationVar(&c.metadataMinSweepAge)
+	cmd.Flag("blob-cache-size-mb", "Desired size of local cache of full pack blobs, 0 disables the cache (soft limit)").PlaceHolder("MB").Int64Var(&c.blobCacheSizeMB)
 	cmd.Flag("index-min-sweep-age", "Minimal age of index cache item to be subject to sweeping").DurationVar(&c.indexMinSweepAge)
 	cmd.Flag("max-list-cache-duration", "Duration of index cache").DurationVar(&c.maxListCacheDuration)
 }
@@ -57,6 +60,7 @@ func (c *commandCacheSetParams) setup(svc appServices, parent commandParent) {
 	c.contentCacheSizeMB = -1
 	c.metadataCacheSizeLimitMB = -1
 	c.metadataCacheSizeMB = -1
+	c.blobCacheSizeMB = -1
 	c.cacheSizeFlags.setup(cmd)
 
 	cmd.Flag("cache-directory", "Directory where to store cache files").StringVar(&c.directory)
@@ -107,6 +111,13 @@ func (c *commandCacheSetParams) run(ctx context.Context, _ repo.RepositoryWriter
 		changed++
 	}
 
+	if v := c.blobCacheSizeMB; v != -1 {
+		v *= 1e6 // convert MB to bytes
+		log(ctx).Infof("changing blob cache size to %v", units.BytesString(v))
+		opts.BlobCacheSizeBytes = v
+		changed++
+	}
+
 	if v := c.maxListCacheDuration; v != -1 {
 		log(ctx).Infof("changing list cache duration to %v", v)
 		opts.MaxListCacheDuration = content.DurationSeconds(v.Seconds())
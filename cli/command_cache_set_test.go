@@ -1,6 +1,7 @@
 package cli_test
 
 import (
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -48,6 +49,22 @@ func TestCacheSet(t *testing.T) {
 	require.Contains(t, mustGetLineContaining(t, out, "55s"), "blob-list")
 }
 
+func TestCacheSetBlobCacheSize(t *testing.T) {
+	env := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	env.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", env.RepoDir)
+
+	out := env.RunAndExpectSuccess(t, "cache", "info")
+	for _, l := range out {
+		require.NotContains(t, l, string(filepath.Separator)+"blobs:")
+	}
+
+	env.RunAndExpectSuccess(t, "cache", "set", "--blob-cache-size-mb=22")
+
+	out = env.RunAndExpectSuccess(t, "cache", "info")
+	require.Contains(t, mustGetLineContaining(t, out, "soft limit: 22 MB"), "blobs")
+}
+
 func mustGetLineContaining(t *testing.T, lines []string, containing string) string {
 	t.Helper()
 
@@ -15,6 +15,7 @@ type commandContentRewrite struct {
 	contentRewriteIDs           []string
 	contentRewriteParallelism   int
 	contentRewriteShortPacks    bool
+	contentRewriteDamaged       bool
 	contentRewriteFormatVersion int
 	contentRewritePackPrefix    string
 	contentRewriteDryRun        bool
@@ -30,6 +31,7 @@ func (c *commandContentRewrite) setup(svc appServices, parent commandParent) {
 	cmd.Flag("parallelism", "Number of parallel workers").Default("16").IntVar(&c.contentRewriteParallelism)
 
 	cmd.Flag("short", "Rewrite contents from short packs").BoolVar(&c.contentRewriteShortPacks)
+	cmd.Flag("damaged", "Rewrite contents with damaged error correction data (downloads all contents)").BoolVar(&c.contentRewriteDamaged)
 	cmd.Flag("format-version", "Rewrite contents using the provided format version").Default("-1").IntVar(&c.contentRewriteFormatVersion)
 	cmd.Flag("pack-prefix", "Only rewrite contents from pack blobs with a given prefix").StringVar(&c.contentRewritePackPrefix)
 	cmd.Flag("dry-run", "Do not actually rewrite, only print what would happen").Short('n').BoolVar(&c.contentRewriteDryRun)
@@ -56,6 +58,7 @@ func (c *commandContentRewrite) runContentRewriteCommand(ctx context.Context, re
 		PackPrefix:     blob.ID(c.contentRewritePackPrefix),
 		Parallel:       c.contentRewriteParallelism,
 		ShortPacks:     c.contentRewriteShortPacks,
+		Damaged:        c.contentRewriteDamaged,
 		DryRun:         c.contentRewriteDryRun,
 	}, c.contentRewriteSafety)
 }
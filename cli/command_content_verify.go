@@ -23,6 +23,37 @@ type commandContentVerify struct {
 	progressInterval            time.Duration
 
 	contentRange contentRangeFlags
+
+	jo  jsonOutput
+	out textOutput
+}
+
+// ContentVerifyFailure describes a content that failed verification.
+type ContentVerifyFailure struct {
+	ContentID content.ID `json:"contentID"`
+	BlobID    blob.ID    `json:"blobID"`
+	Reason    string     `json:"reason"`
+	Error     string     `json:"error"`
+}
+
+// ContentVerifyReport is used to display the results of content verification in JSON format.
+type ContentVerifyReport struct {
+	VerifiedCount int                    `json:"verifiedCount"`
+	ErrorCount    int                    `json:"errorCount"`
+	Failures      []ContentVerifyFailure `json:"failures"`
+}
+
+// content verification failure reasons.
+const (
+	contentVerifyMissingBlob = "missing-blob"
+	contentVerifyOutOfBounds = "out-of-bounds"
+	contentVerifyCorrupt     = "corrupt"
+	contentVerifyDamaged     = "damaged"
+)
+
+type contentVerifyError struct {
+	reason string
+	error
 }
 
 func (c *commandContentVerify) setup(svc appServices, parent commandParent) {
@@ -34,6 +65,8 @@ func (c *commandContentVerify) setup(svc appServices, parent commandParent) {
 	cmd.Flag("download-percent", "Download a percentage of files [0.0 .. 100.0]").Float64Var(&c.contentVerifyPercent)
 	cmd.Flag("progress-interval", "Progress output interval").Default("3s").DurationVar(&c.progressInterval)
 	c.contentRange.setup(cmd)
+	c.jo.setup(svc, cmd)
+	c.out.setup(svc)
 	cmd.Action(svc.directRepositoryReadAction(c.run))
 }
 
@@ -55,6 +88,9 @@ func (c *commandContentVerify) run(ctx context.Context, rep repo.DirectRepositor
 		successCount  atomic.Int32
 		errorCount    atomic.Int32
 		totalCount    atomic.Int32
+
+		failuresMutex sync.Mutex
+		failures      []ContentVerifyFailure
 	)
 
 	subctx, cancel := context.WithCancel(ctx)
@@ -90,6 +126,17 @@ func (c *commandContentVerify) run(ctx context.Context, rep repo.DirectRepositor
 		if err := c.contentVerify(ctx, rep.ContentReader(), ci, blobMap, downloadPercent); err != nil {
 			log(ctx).Errorf("error %v", err)
 			errorCount.Add(1)
+
+			var verr contentVerifyError
+
+			reason := contentVerifyCorrupt
+			if errors.As(err, &verr) {
+				reason = verr.reason
+			}
+
+			failuresMutex.Lock()
+			failures = append(failures, ContentVerifyFailure{ci.ContentID, ci.PackBlobID, reason, err.Error()})
+			failuresMutex.Unlock()
 		} else {
 			successCount.Add(1)
 		}
@@ -119,12 +166,20 @@ func (c *commandContentVerify) run(ctx context.Context, rep repo.DirectRepositor
 
 	log(ctx).Infof("Finished verifying %v contents, found %v errors.", verifiedCount.Load(), errorCount.Load())
 
+	if c.jo.jsonOutput {
+		c.out.printStdout("%s\n", c.jo.jsonBytes(ContentVerifyReport{
+			VerifiedCount: int(verifiedCount.Load()),
+			ErrorCount:    int(errorCount.Load()),
+			Failures:      failures,
+		}))
+	}
+
 	ec := errorCount.Load()
 	if ec == 0 {
 		return nil
 	}
 
-	return errors.Errorf("encountered %v errors", ec)
+	return errors.Wrapf(ErrVerificationFailed, "encountered %v errors", ec)
 }
 
 func (c *commandContentVerify) getTotalContentCount(ctx context.Context, rep repo.DirectRepository, totalCount *atomic.Int32) {
@@ -151,17 +206,23 @@ func (c *commandContentVerify) getTotalContentCount(ctx context.Context, rep rep
 func (c *commandContentVerify) contentVerify(ctx context.Context, r content.Reader, ci content.Info, blobMap map[blob.ID]blob.Metadata, downloadPercent float64) error {
 	bi, ok := blobMap[ci.PackBlobID]
 	if !ok {
-		return errors.Errorf("content %v depends on missing blob %v", ci.ContentID, ci.PackBlobID)
+		return contentVerifyError{contentVerifyMissingBlob, errors.Errorf("content %v depends on missing blob %v", ci.ContentID, ci.PackBlobID)}
 	}
 
 	if int64(ci.PackOffset+ci.PackedLength) > bi.Length {
-		return errors.Errorf("content %v out of bounds of its pack blob %v", ci.ContentID, ci.PackBlobID)
+		return contentVerifyError{contentVerifyOutOfBounds, errors.Errorf("content %v out of bounds of its pack blob %v", ci.ContentID, ci.PackBlobID)}
 	}
 
 	//nolint:gosec
 	if 100*rand.Float64() < downloadPercent {
-		if _, err := r.GetContent(ctx, ci.ContentID); err != nil {
-			return errors.Wrapf(err, "content %v is invalid", ci.ContentID)
+		// reads the content once, verifying both its contents and its error correction data.
+		n, err := r.DamagedShards(ctx, ci.ContentID)
+		if err != nil {
+			return contentVerifyError{contentVerifyCorrupt, errors.Wrapf(err, "content %v is invalid", ci.ContentID)}
+		}
+
+		if n > 0 {
+			return contentVerifyError{contentVerifyDamaged, errors.Errorf("content %v has %v damaged error correction shards, repair using 'kopia content rewrite --damaged'", ci.ContentID, n)}
 		}
 
 		return nil
@@ -9,7 +9,10 @@
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/kopia/kopia/cli"
 	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/content"
 	"github.com/kopia/kopia/tests/testenv"
 )
 
@@ -37,4 +40,64 @@ func (s *formatSpecificTestSuite) TestContentVerify(t *testing.T) {
 	mustGetLineContaining(t, verifyStderr, "missing blob "+blobIDToDelete)
 
 	env.RunAndExpectFailure(t, "content", "verify", "--full")
+
+	verifyStdout, _, err := env.Run(t, true, "content", "verify", "--json")
+	require.Error(t, err)
+
+	var report cli.ContentVerifyReport
+
+	testutil.MustParseJSONLines(t, verifyStdout, &report)
+	require.NotZero(t, report.ErrorCount)
+	require.Len(t, report.Failures, report.ErrorCount)
+
+	for _, f := range report.Failures {
+		require.Equal(t, blob.ID(blobIDToDelete), f.BlobID)
+		require.Equal(t, "missing-blob", f.Reason)
+	}
+}
+
+func TestContentVerifyAndRewriteDamaged(t *testing.T) {
+	env := testenv.NewCLITest(t, nil, testenv.NewInProcRunner(t))
+
+	dir := testutil.TempDirectory(t)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "file1.txt"), bytes.Repeat([]byte{1, 2, 3, 4, 5}, 30000), 0o600))
+
+	env.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--flat", "--path", env.RepoDir, "--ecc-overhead-percent=10")
+	env.RunAndExpectSuccess(t, "snapshot", "create", dir)
+
+	var contents []content.Info
+
+	testutil.MustParseJSONLines(t, env.RunAndExpectSuccess(t, "content", "list", "--json"), &contents)
+
+	// find the largest content stored in a 'p' pack and damage it.
+	var damaged content.Info
+
+	for _, ci := range contents {
+		if strings.HasPrefix(string(ci.PackBlobID), "p") && ci.PackedLength > damaged.PackedLength {
+			damaged = ci
+		}
+	}
+
+	require.NotEmpty(t, damaged.PackBlobID)
+
+	packFile := filepath.Join(env.RepoDir, string(damaged.PackBlobID)+".f")
+
+	packData, err := os.ReadFile(packFile)
+	require.NoError(t, err)
+
+	packData[damaged.PackOffset+damaged.PackedLength/2] ^= 0xff
+	require.NoError(t, os.WriteFile(packFile, packData, 0o600))
+
+	verifyStdout, _, err := env.Run(t, true, "content", "verify", "--full", "--json")
+	require.Error(t, err)
+
+	var report cli.ContentVerifyReport
+
+	testutil.MustParseJSONLines(t, verifyStdout, &report)
+	require.Equal(t, 1, report.ErrorCount)
+	require.Equal(t, damaged.ContentID, report.Failures[0].ContentID)
+	require.Equal(t, "damaged", report.Failures[0].Reason)
+
+	env.RunAndExpectSuccess(t, "content", "rewrite", "--damaged", "--safety=none")
+	env.RunAndExpectSuccess(t, "content", "verify", "--full")
 }
@@ -2,6 +2,7 @@
 
 import (
 	"context"
+	"sort"
 	"time"
 
 	"github.com/pkg/errors"
@@ -52,7 +53,12 @@ func (c *commandMaintenanceInfo) run(ctx context.Context, rep repo.DirectReposit
 		return nil
 	}
 
-	c.out.printStdout("Owner: %v\n", p.Owner)
+	if p.Owner == rep.ClientOptions().UsernameAtHost() {
+		c.out.printStdout("Owner: %v (this client)\n", p.Owner)
+	} else {
+		c.out.printStdout("Owner: %v\n", p.Owner)
+	}
+
 	c.out.printStdout("Quick Cycle:\n")
 	c.displayCycleInfo(&p.QuickCycle, s.NextQuickMaintenanceTime, rep)
 
@@ -74,10 +80,18 @@ func (c *commandMaintenanceInfo) run(ctx context.Context, rep repo.DirectReposit
 
 	c.out.printStdout("Recent Maintenance Runs:\n")
 
-	for run, timings := range s.Runs {
+	var taskNames []string
+
+	for run := range s.Runs {
+		taskNames = append(taskNames, string(run))
+	}
+
+	sort.Strings(taskNames)
+
+	for _, run := range taskNames {
 		c.out.printStdout("  %v:\n", run)
 
-		for _, t := range timings {
+		for _, t := range s.Runs[maintenance.TaskType(run)] {
 			var errInfo string
 			if t.Success {
 				errInfo = "SUCCESS"
@@ -3,6 +3,8 @@
 import (
 	"testing"
 
+	"github.com/stretchr/testify/require"
+
 	"github.com/kopia/kopia/cli"
 	"github.com/kopia/kopia/internal/testutil"
 	"github.com/kopia/kopia/tests/testenv"
@@ -17,6 +19,9 @@ func TestMaintenanceInfoSimple(t *testing.T) {
 	var mi cli.MaintenanceInfo
 
 	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
-	e.RunAndExpectSuccess(t, "maintenance", "info")
+	e.RunAndExpectSuccess(t, "maintenance", "run", "--full")
+
+	out := e.RunAndExpectSuccess(t, "maintenance", "info")
+	require.Contains(t, out[0], "(this client)")
 	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "maintenance", "info", "--json"), &mi)
 }
@@ -2,6 +2,7 @@
 
 import (
 	"context"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -13,14 +14,18 @@
 type commandMaintenanceRun struct {
 	maintenanceRunFull  bool
 	maintenanceRunForce bool
+	maintenanceDryRun   bool
 	safety              maintenance.SafetyParameters
+	gcMinContentAge     time.Duration
 }
 
 func (c *commandMaintenanceRun) setup(svc appServices, parent commandParent) {
 	cmd := parent.Command("run", "Run repository maintenance")
 	cmd.Flag("full", "Full maintenance").BoolVar(&c.maintenanceRunFull)
 	cmd.Flag("force", "Run maintenance even if not owned (unsafe)").Hidden().BoolVar(&c.maintenanceRunForce)
+	cmd.Flag("dry-run", "Do not modify the repository, only report what would be deleted or rewritten").Short('n').BoolVar(&c.maintenanceDryRun)
 	safetyFlagVar(cmd, &c.safety)
+	cmd.Flag("safety-gc-min-content-age", "Override the minimum age of unreferenced contents to be subject to garbage collection").DurationVar(&c.gcMinContentAge)
 
 	cmd.Action(svc.directRepositoryWriteAction(c.run))
 }
@@ -28,6 +33,10 @@ func (c *commandMaintenanceRun) setup(svc appServices, parent commandParent) {
 func (c *commandMaintenanceRun) run(ctx context.Context, rep repo.DirectRepositoryWriter) error {
 	mode := maintenance.ModeQuick
 
+	if c.gcMinContentAge > 0 {
+		c.safety.MinContentAgeSubjectToGC = c.gcMinContentAge
+	}
+
 	_, supportsEpochManager, err := rep.ContentManager().EpochManager(ctx)
 	if err != nil {
 		return errors.Wrap(err, "EpochManager")
@@ -37,6 +46,11 @@ func (c *commandMaintenanceRun) run(ctx context.Context, rep repo.DirectReposito
 		mode = maintenance.ModeFull
 	}
 
+	if c.maintenanceDryRun {
+		//nolint:wrapcheck
+		return snapshotmaintenance.Preview(ctx, rep, mode, c.safety)
+	}
+
 	//nolint:wrapcheck
 	return snapshotmaintenance.Run(ctx, rep, mode, c.maintenanceRunForce, c.safety)
 }
@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"context"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/format"
+	"github.com/kopia/kopia/repo/manifest"
+)
+
+type commandManifestExport struct {
+	outputFile      string
+	archivePassword string
+
+	svc appServices
+}
+
+func (c *commandManifestExport) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("export", "Export all manifest items to an encrypted archive")
+	cmd.Arg("file", "Archive file to write").Required().StringVar(&c.outputFile)
+	cmd.Flag("archive-password", "Password used to encrypt the archive").Envar(svc.EnvName("KOPIA_ARCHIVE_PASSWORD")).StringVar(&c.archivePassword)
+	cmd.Action(svc.directRepositoryReadAction(c.run))
+
+	c.svc = svc
+}
+
+func (c *commandManifestExport) run(ctx context.Context, rep repo.DirectRepository) error {
+	pass := c.archivePassword
+
+	if pass == "" {
+		p, err := askForChangedRepositoryPassword(c.svc)
+		if err != nil {
+			return err
+		}
+
+		pass = p
+	}
+
+	f, err := os.OpenFile(c.outputFile, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600) //nolint:gosec,mnd
+	if err != nil {
+		return errors.Wrap(err, "unable to create archive file")
+	}
+
+	n, err := rep.ExportManifests(ctx, f, manifest.ArchiveOptions{
+		Password:               pass,
+		KeyDerivationAlgorithm: format.DefaultKeyDerivationAlgorithm,
+	})
+	if err != nil {
+		f.Close()               //nolint:errcheck
+		os.Remove(c.outputFile) //nolint:errcheck
+
+		return errors.Wrap(err, "unable to export manifests")
+	}
+
+	if err := f.Close(); err != nil {
+		return errors.Wrap(err, "unable to close archive file")
+	}
+
+	log(ctx).Infof("Exported %v manifest items to %v.", n, c.outputFile)
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package cli_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestManifestExportImport(t *testing.T) {
+	t.Parallel()
+
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+	e.RunAndExpectSuccess(t, "snapshot", "create", testutil.TempDirectory(t))
+
+	archive := filepath.Join(testutil.TempDirectory(t), "manifests.json")
+
+	e.RunAndExpectSuccess(t, "manifest", "export", archive, "--archive-password=secret")
+	e.RunAndExpectFailure(t, "manifest", "export", archive, "--archive-password=secret")
+
+	// snapshots can't be imported into a repository which does not contain their data.
+	e.RunAndExpectSuccess(t, "repo", "disconnect")
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", testutil.TempDirectory(t))
+
+	e.RunAndExpectFailure(t, "manifest", "import", archive, "--archive-password=secret")
+	require.Empty(t, e.RunAndExpectSuccess(t, "snapshot", "list", "--all"))
+
+	// importing into the original repository does not duplicate items.
+	e.RunAndExpectSuccess(t, "repo", "disconnect")
+	e.RunAndExpectSuccess(t, "repo", "connect", "filesystem", "--path", e.RepoDir)
+
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	e.RunAndExpectFailure(t, "manifest", "import", archive, "--archive-password=wrong")
+	e.RunAndExpectSuccess(t, "manifest", "import", archive, "--archive-password=secret")
+	require.Len(t, e.RunAndExpectSuccess(t, "manifest", "list", "--filter=type:snapshot"), 1)
+}
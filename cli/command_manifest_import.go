@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/manifest"
+	"github.com/kopia/kopia/snapshot"
+)
+
+type commandManifestImport struct {
+	inputFile       string
+	archivePassword string
+
+	svc appServices
+}
+
+func (c *commandManifestImport) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("import", "Import manifest items from an archive produced by 'manifest export'")
+	cmd.Arg("file", "Archive file to read").Required().ExistingFileVar(&c.inputFile)
+	cmd.Flag("archive-password", "Password used to encrypt the archive").Envar(svc.EnvName("KOPIA_ARCHIVE_PASSWORD")).StringVar(&c.archivePassword)
+	cmd.Action(svc.directRepositoryWriteAction(c.run))
+
+	c.svc = svc
+}
+
+func (c *commandManifestImport) run(ctx context.Context, rep repo.DirectRepositoryWriter) error {
+	pass := c.archivePassword
+
+	if pass == "" {
+		p, err := c.svc.askPass(c.svc.stdout(), "Enter archive password: ")
+		if err != nil {
+			return errors.Wrap(err, "password entry")
+		}
+
+		pass = p
+	}
+
+	f, err := os.Open(c.inputFile)
+	if err != nil {
+		return errors.Wrap(err, "unable to open archive file")
+	}
+
+	defer f.Close() //nolint:errcheck
+
+	n, err := rep.ImportManifests(ctx, f, manifest.ArchiveOptions{
+		Password: pass,
+		VerifyEntry: func(ctx context.Context, md *manifest.EntryMetadata, payload json.RawMessage) error {
+			return verifyImportedManifest(ctx, rep, md, payload)
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "unable to import manifests")
+	}
+
+	log(ctx).Infof("Imported %v manifest items.", n)
+
+	return nil
+}
+
+// verifyImportedManifest ensures that the root object of an imported snapshot manifest exists
+// in the repository, so that import does not create snapshots pointing at missing data.
+func verifyImportedManifest(ctx context.Context, rep repo.Repository, md *manifest.EntryMetadata, payload json.RawMessage) error {
+	if md.Labels[manifest.TypeLabelKey] != snapshot.ManifestType {
+		return nil
+	}
+
+	var man snapshot.Manifest
+
+	if err := json.Unmarshal(payload, &man); err != nil {
+		return errors.Wrap(err, "invalid snapshot manifest")
+	}
+
+	if man.RootEntry == nil {
+		return errors.New("snapshot manifest has no root entry")
+	}
+
+	if _, err := rep.VerifyObject(ctx, man.RootObjectID()); err != nil {
+		return errors.Wrapf(err, "root object %v of snapshot is not present in the repository", man.RootObjectID())
+	}
+
+	return nil
+}
@@ -2,6 +2,7 @@
 
 import (
 	"context"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/skratchdot/open-golang/open"
@@ -24,6 +25,8 @@ type commandMount struct {
 	mountPreferWebDAV           bool
 	maxCachedEntries            int
 	maxCachedDirectories        int
+	unmountAttempts             int
+	unmountRetryInterval        time.Duration
 
 	svc appServices
 }
@@ -42,6 +45,8 @@ func (c *commandMount) setup(svc appServices, parent commandParent) {
 
 	cmd.Flag("max-cached-entries", "Limit the number of cached directory entries").Default("100000").IntVar(&c.maxCachedEntries)
 	cmd.Flag("max-cached-dirs", "Limit the number of cached directories").Default("100").IntVar(&c.maxCachedDirectories)
+	cmd.Flag("unmount-attempts", "Number of attempts to unmount a busy filesystem on exit").Default("10").Hidden().IntVar(&c.unmountAttempts)
+	cmd.Flag("unmount-retry-interval", "Time between attempts to unmount a busy filesystem on exit").Default("3s").Hidden().DurationVar(&c.unmountRetryInterval)
 
 	c.svc = svc
 	cmd.Action(svc.repositoryReaderAction(c.run))
@@ -111,10 +116,8 @@ func (c *commandMount) run(ctx context.Context, rep repo.Repository) error {
 	select {
 	case <-ctrlCPressed:
 		log(ctx).Info("Unmounting...")
-		// TODO: Consider lazy unmounting (-z) and polling till the filesystem is unmounted instead of failing with:
-		// "unmount error: exit status 1: fusermount: failed to unmount /tmp/kopia-mount719819963: Device or resource busy, try --help"
-		err := ctrl.Unmount(ctx)
-		if err != nil {
+
+		if err := c.unmountWithRetry(ctx, ctrl); err != nil {
 			return errors.Wrap(err, "unmount error")
 		}
 
@@ -129,3 +132,27 @@ func (c *commandMount) run(ctx context.Context, rep repo.Repository) error {
 
 	return nil
 }
+
+// unmountWithRetry attempts to unmount the filesystem, retrying for a while if the mount point
+// is still busy (for example "fusermount: failed to unmount ...: Device or resource busy").
+func (c *commandMount) unmountWithRetry(ctx context.Context, ctrl mount.Controller) error {
+	var err error
+
+	for attempt := range max(c.unmountAttempts, 1) {
+		if attempt > 0 {
+			log(ctx).Infof("Unable to unmount (%v), retrying in %v...", err, c.unmountRetryInterval)
+
+			select {
+			case <-ctrl.Done():
+				return nil
+			case <-time.After(c.unmountRetryInterval):
+			}
+		}
+
+		if err = ctrl.Unmount(ctx); err == nil {
+			return nil
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,11 @@
+package cli
+
+type commandNotification struct {
+	profile commandNotificationProfile
+}
+
+func (c *commandNotification) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("notification", "Notifications about snapshot outcomes")
+
+	c.profile.setup(svc, cmd)
+}
@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"context"
+	"time"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/notification/notifyprofile"
+	"github.com/kopia/kopia/notification/sender"
+	"github.com/kopia/kopia/notification/sender/command"
+	"github.com/kopia/kopia/notification/sender/email"
+	"github.com/kopia/kopia/notification/sender/webhook"
+	"github.com/kopia/kopia/repo"
+)
+
+type commandNotificationProfileConfigure struct {
+	webhook commandNotificationConfigureWebhook
+	email   commandNotificationConfigureEmail
+	command commandNotificationConfigureCommand
+}
+
+func (c *commandNotificationProfileConfigure) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("configure", "Configure notification profile")
+
+	c.webhook.setup(svc, cmd)
+	c.email.setup(svc, cmd)
+	c.command.setup(svc, cmd)
+}
+
+// notificationProfileCommonFlags are the flags shared by all notification delivery methods.
+type notificationProfileCommonFlags struct {
+	notificationProfileNameFlags
+
+	minSeverity string
+}
+
+func (c *notificationProfileCommonFlags) setup(cmd *kingpin.CmdClause) {
+	c.notificationProfileNameFlags.setup(cmd)
+
+	cmd.Flag("min-severity", "Minimum severity of notifications sent using this profile").Default(sender.SeveritySuccess.String()).EnumVar(&c.minSeverity, sender.SeverityNames()...)
+}
+
+func (c *notificationProfileCommonFlags) save(ctx context.Context, rep repo.RepositoryWriter, cfg *notifyprofile.Config) error {
+	sev, err := sender.ParseSeverity(c.minSeverity)
+	if err != nil {
+		return errors.Wrap(err, "invalid minimum severity")
+	}
+
+	cfg.ProfileName = c.profileName
+	cfg.MinSeverity = sev
+
+	return errors.Wrap(notifyprofile.SaveProfile(ctx, rep, cfg), "unable to save notification profile")
+}
+
+type commandNotificationConfigureWebhook struct {
+	common notificationProfileCommonFlags
+	opt    webhook.Options
+}
+
+func (c *commandNotificationConfigureWebhook) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("webhook", "Deliver notifications by sending HTTP requests to a URL")
+
+	c.common.setup(cmd)
+
+	cmd.Flag("endpoint", "Webhook URL").Required().StringVar(&c.opt.Endpoint)
+	cmd.Flag("method", "HTTP method").Default("POST").StringVar(&c.opt.Method)
+	cmd.Flag("format", "Payload format").Default(webhook.FormatJSON).EnumVar(&c.opt.Format, webhook.SupportedFormats()...)
+	cmd.Flag("http-header", "HTTP header to send (Name: value), can be specified multiple times").StringsVar(&c.opt.Headers)
+
+	cmd.Action(svc.repositoryWriterAction(c.run))
+}
+
+func (c *commandNotificationConfigureWebhook) run(ctx context.Context, rep repo.RepositoryWriter) error {
+	opt := c.opt
+
+	return c.common.save(ctx, rep, &notifyprofile.Config{Webhook: &opt})
+}
+
+type commandNotificationConfigureEmail struct {
+	common notificationProfileCommonFlags
+	opt    email.Options
+}
+
+func (c *commandNotificationConfigureEmail) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("email", "Deliver notifications by email")
+
+	c.common.setup(cmd)
+
+	cmd.Flag("smtp-server", "SMTP server").Required().StringVar(&c.opt.SMTPServer)
+	cmd.Flag("smtp-port", "SMTP port").Default("587").IntVar(&c.opt.SMTPPort)
+	cmd.Flag("smtp-username", "SMTP username").StringVar(&c.opt.SMTPUsername)
+	cmd.Flag("smtp-password", "SMTP password").Envar(svc.EnvName("KOPIA_SMTP_PASSWORD")).StringVar(&c.opt.SMTPPassword)
+	cmd.Flag("mail-from", "Sender address").Required().StringVar(&c.opt.From)
+	cmd.Flag("mail-to", "Comma-separated list of recipient addresses").Required().StringVar(&c.opt.To)
+
+	cmd.Action(svc.repositoryWriterAction(c.run))
+}
+
+func (c *commandNotificationConfigureEmail) run(ctx context.Context, rep repo.RepositoryWriter) error {
+	opt := c.opt
+
+	return c.common.save(ctx, rep, &notifyprofile.Config{Email: &opt})
+}
+
+type commandNotificationConfigureCommand struct {
+	common  notificationProfileCommonFlags
+	opt     command.Options
+	timeout time.Duration
+}
+
+func (c *commandNotificationConfigureCommand) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("command", "Deliver notifications by running a command, which receives the message body on standard input")
+
+	c.common.setup(cmd)
+
+	cmd.Flag("command", "Command to run").Required().StringVar(&c.opt.Command)
+	cmd.Flag("arg", "Command argument, can be specified multiple times").StringsVar(&c.opt.Arguments)
+	cmd.Flag("timeout", "Maximum time the command is allowed to run").Default("60s").DurationVar(&c.timeout)
+
+	cmd.Action(svc.repositoryWriterAction(c.run))
+}
+
+func (c *commandNotificationConfigureCommand) run(ctx context.Context, rep repo.RepositoryWriter) error {
+	opt := c.opt
+	opt.TimeoutSeconds = int(c.timeout.Seconds())
+
+	if !rep.ClientOptions().EnableActions {
+		log(ctx).Warnf("Command notifications will not be sent by this client until it is reconnected with --enable-actions.")
+	}
+
+	return c.common.save(ctx, rep, &notifyprofile.Config{Command: &opt})
+}
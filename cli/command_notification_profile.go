@@ -0,0 +1,130 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/notification/notifyprofile"
+	"github.com/kopia/kopia/notification/sender"
+	"github.com/kopia/kopia/repo"
+)
+
+type commandNotificationProfile struct {
+	configure commandNotificationProfileConfigure
+	list      commandNotificationProfileList
+	delete    commandNotificationProfileDelete
+	test      commandNotificationProfileTest
+}
+
+func (c *commandNotificationProfile) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("profile", "Manage notification profiles")
+
+	c.configure.setup(svc, cmd)
+	c.list.setup(svc, cmd)
+	c.delete.setup(svc, cmd)
+	c.test.setup(svc, cmd)
+}
+
+// notificationProfileNameFlags are the flags identifying a notification profile.
+type notificationProfileNameFlags struct {
+	profileName string
+}
+
+func (c *notificationProfileNameFlags) setup(cmd *kingpin.CmdClause) {
+	cmd.Flag("profile-name", "Notification profile name").Required().StringVar(&c.profileName)
+}
+
+type commandNotificationProfileList struct {
+	jo  jsonOutput
+	out textOutput
+}
+
+func (c *commandNotificationProfileList) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("list", "List notification profiles").Alias("ls")
+
+	c.jo.setup(svc, cmd)
+	c.out.setup(svc)
+	cmd.Action(svc.repositoryReaderAction(c.run))
+}
+
+func (c *commandNotificationProfileList) run(ctx context.Context, rep repo.Repository) error {
+	var jl jsonList
+
+	jl.begin(&c.jo)
+	defer jl.end()
+
+	profiles, err := notifyprofile.ListProfiles(ctx, rep)
+	if err != nil {
+		return errors.Wrap(err, "error listing notification profiles")
+	}
+
+	for _, p := range profiles {
+		if c.jo.jsonOutput {
+			jl.emit(p)
+			continue
+		}
+
+		summary := "(invalid)"
+
+		if s, err := p.Sender(); err == nil {
+			summary = s.Summary()
+		}
+
+		c.out.printStdout("Profile %q Minimum Severity: %v\n  %v\n", p.ProfileName, p.MinSeverity, summary)
+	}
+
+	return nil
+}
+
+type commandNotificationProfileDelete struct {
+	name notificationProfileNameFlags
+}
+
+func (c *commandNotificationProfileDelete) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("delete", "Delete notification profile").Alias("rm")
+
+	c.name.setup(cmd)
+	cmd.Action(svc.repositoryWriterAction(c.run))
+}
+
+func (c *commandNotificationProfileDelete) run(ctx context.Context, rep repo.RepositoryWriter) error {
+	return errors.Wrap(notifyprofile.DeleteProfile(ctx, rep, c.name.profileName), "unable to delete notification profile")
+}
+
+type commandNotificationProfileTest struct {
+	name notificationProfileNameFlags
+}
+
+func (c *commandNotificationProfileTest) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("test", "Send test notification")
+
+	c.name.setup(cmd)
+	cmd.Action(svc.repositoryReaderAction(c.run))
+}
+
+func (c *commandNotificationProfileTest) run(ctx context.Context, rep repo.Repository) error {
+	p, err := notifyprofile.GetProfile(ctx, rep, c.name.profileName)
+	if err != nil {
+		return errors.Wrap(err, "unable to get notification profile")
+	}
+
+	if p.RunsCommand() && !rep.ClientOptions().EnableActions {
+		return errors.New("command notifications are disabled for this client, reconnect with --enable-actions")
+	}
+
+	s, err := p.Sender()
+	if err != nil {
+		return errors.Wrap(err, "invalid notification profile")
+	}
+
+	log(ctx).Infof("Sending test notification using %v", s.Summary())
+
+	//nolint:wrapcheck
+	return s.Send(ctx, &sender.Message{
+		Subject:  "Test notification from Kopia",
+		Body:     "This is a test notification from Kopia.",
+		Severity: p.MinSeverity,
+	})
+}
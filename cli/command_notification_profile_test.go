@@ -0,0 +1,185 @@
+package cli_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/notification/notifyprofile"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestNotificationProfile(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu       sync.Mutex
+		subjects []string
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Subject string `json:"subject"`
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		mu.Lock()
+		subjects = append(subjects, payload.Subject)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+
+	require.Empty(t, e.RunAndExpectSuccess(t, "notification", "profile", "list"))
+
+	e.RunAndExpectSuccess(t, "notification", "profile", "configure", "webhook", "--profile-name=p1", "--endpoint="+srv.URL)
+	e.RunAndExpectFailure(t, "notification", "profile", "configure", "webhook", "--profile-name=p2", "--endpoint="+srv.URL, "--min-severity=no-such-severity")
+
+	var profiles []*notifyprofile.Config
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "notification", "profile", "list", "--json"), &profiles)
+	require.Len(t, profiles, 1)
+	require.Equal(t, "p1", profiles[0].ProfileName)
+	require.Equal(t, srv.URL, profiles[0].Webhook.Endpoint)
+
+	e.RunAndExpectSuccess(t, "notification", "profile", "test", "--profile-name=p1")
+
+	dir := testutil.TempDirectory(t)
+	e.RunAndExpectSuccess(t, "snapshot", "create", dir)
+
+	mu.Lock()
+	require.Len(t, subjects, 2)
+	require.Equal(t, "Test notification from Kopia", subjects[0])
+	require.Contains(t, subjects[1], "succeeded")
+	mu.Unlock()
+
+	// only report errors, successful snapshot does not send anything.
+	e.RunAndExpectSuccess(t, "notification", "profile", "configure", "webhook", "--profile-name=p1", "--endpoint="+srv.URL, "--min-severity=error")
+	e.RunAndExpectSuccess(t, "snapshot", "create", dir)
+
+	mu.Lock()
+	require.Len(t, subjects, 2)
+	mu.Unlock()
+
+	e.RunAndExpectSuccess(t, "notification", "profile", "delete", "--profile-name=p1")
+	e.RunAndExpectFailure(t, "notification", "profile", "delete", "--profile-name=p1")
+	e.RunAndExpectFailure(t, "notification", "profile", "test", "--profile-name=p1")
+}
+
+func TestNotificationProfilesPerSource(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu       sync.Mutex
+		received = map[string][]string{}
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Subject string `json:"subject"`
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		mu.Lock()
+		received[r.URL.Path] = append(received[r.URL.Path], payload.Subject)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+
+	e.RunAndExpectSuccess(t, "notification", "profile", "configure", "webhook", "--profile-name=p1", "--endpoint="+srv.URL+"/p1", "--min-severity=info")
+	e.RunAndExpectSuccess(t, "notification", "profile", "configure", "webhook", "--profile-name=p2", "--endpoint="+srv.URL+"/p2", "--min-severity=info")
+
+	dir1 := testutil.TempDirectory(t)
+	dir2 := testutil.TempDirectory(t)
+
+	e.RunAndExpectSuccess(t, "policy", "set", dir1, "--notification-profiles=p1")
+
+	lines := compressSpaces(e.RunAndExpectSuccess(t, "policy", "show", dir1))
+	require.Contains(t, lines, " Notification profiles: p1 (defined for this target)")
+
+	e.RunAndExpectSuccess(t, "snapshot", "create", dir1)
+
+	mu.Lock()
+	require.Len(t, received["/p1"], 2)
+	require.Contains(t, received["/p1"][0], "started")
+	require.Contains(t, received["/p1"][1], "succeeded")
+	require.Empty(t, received["/p2"])
+	mu.Unlock()
+
+	// sources without notification profiles in the policy notify all profiles.
+	e.RunAndExpectSuccess(t, "snapshot", "create", dir2)
+
+	mu.Lock()
+	require.Len(t, received["/p1"], 4)
+	require.Len(t, received["/p2"], 2)
+	mu.Unlock()
+
+	// notification profiles can be combined with manual snapshots.
+	e.RunAndExpectSuccess(t, "policy", "set", dir1, "--manual")
+	e.RunAndExpectSuccess(t, "policy", "set", dir1, "--notification-profiles=inherit")
+}
+
+func TestNotificationProfileCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires a POSIX shell")
+	}
+
+	t.Parallel()
+
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+
+	out := filepath.Join(testutil.TempDirectory(t), "out.txt")
+
+	e.RunAndExpectSuccess(t, "notification", "profile", "configure", "command", "--profile-name=cmd",
+		"--command=sh", "--arg=-c", `--arg=echo "$KOPIA_NOTIFICATION_SUBJECT" >> "$0"`, "--arg="+out, "--timeout=10s")
+
+	var profiles []*notifyprofile.Config
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "notification", "profile", "list", "--json"), &profiles)
+	require.Len(t, profiles, 1)
+	require.Equal(t, "sh", profiles[0].Command.Command)
+	require.Equal(t, 10, profiles[0].Command.TimeoutSeconds)
+
+	// running commands requires actions to be enabled for the client.
+	e.RunAndExpectFailure(t, "notification", "profile", "test", "--profile-name=cmd")
+
+	_, err := os.Stat(out)
+	require.True(t, os.IsNotExist(err))
+
+	e.RunAndExpectSuccess(t, "repo", "disconnect")
+	e.RunAndExpectSuccess(t, "repo", "connect", "filesystem", "--path", e.RepoDir, "--enable-actions")
+
+	e.RunAndExpectSuccess(t, "notification", "profile", "test", "--profile-name=cmd")
+
+	b, err := os.ReadFile(out)
+	require.NoError(t, err)
+	require.Equal(t, "Test notification from Kopia\n", string(b))
+}
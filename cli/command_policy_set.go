@@ -10,6 +10,8 @@
 	"github.com/kopia/kopia/internal/units"
 	"github.com/kopia/kopia/repo"
 	"github.com/kopia/kopia/repo/compression"
+	"github.com/kopia/kopia/repo/manifest"
+	"github.com/kopia/kopia/snapshot"
 	"github.com/kopia/kopia/snapshot/policy"
 )
 
@@ -63,29 +65,59 @@ func (c *commandPolicySet) run(ctx context.Context, rep repo.RepositoryWriter) e
 	}
 
 	for _, target := range targets {
-		p, err := policy.GetDefinedPolicy(ctx, rep, target)
+		if err := c.setPolicyForTarget(ctx, rep, target); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
 
-		switch {
-		case errors.Is(err, policy.ErrPolicyNotFound):
-			p = &policy.Policy{}
-		case err != nil:
+// setPolicyForTarget applies changes from flags to the policy defined for the target. When possible,
+// the policy is only replaced if it has not been modified concurrently by another client.
+func (c *commandPolicySet) setPolicyForTarget(ctx context.Context, rep repo.RepositoryWriter, target snapshot.SourceInfo) error {
+	md, err := rep.FindManifests(ctx, policy.LabelsForSource(target))
+	if err != nil {
+		return errors.Wrap(err, "could not find defined policy")
+	}
+
+	p := &policy.Policy{}
+
+	if len(md) > 0 {
+		p, err = policy.GetPolicyByID(ctx, rep, manifest.PickLatestID(md))
+		if err != nil {
 			return errors.Wrap(err, "could not get defined policy")
 		}
+	}
 
-		log(ctx).Infof("Setting policy for %v", target)
+	log(ctx).Infof("Setting policy for %v", target)
 
-		changeCount := 0
-		if err := c.setPolicyFromFlags(ctx, p, &changeCount); err != nil {
-			return err
-		}
+	changeCount := 0
+	if err := c.setPolicyFromFlags(ctx, p, &changeCount); err != nil {
+		return err
+	}
 
-		if changeCount == 0 {
-			return errors.New("no changes specified")
-		}
+	if changeCount == 0 {
+		return errors.New("no changes specified")
+	}
 
+	dw, ok := rep.(repo.DirectRepositoryWriter)
+	if !ok {
 		if err := policy.SetPolicy(ctx, rep, target, p); err != nil {
 			return errors.Wrapf(err, "can't save policy for %v", target)
 		}
+
+		return nil
+	}
+
+	var expected []manifest.ID
+
+	for _, m := range md {
+		expected = append(expected, m.ID)
+	}
+
+	if err := policy.SetPolicyIfUnchanged(ctx, dw, target, p, expected); err != nil {
+		return errors.Wrapf(err, "can't save policy for %v", target)
 	}
 
 	return nil
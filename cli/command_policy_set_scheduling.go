@@ -2,6 +2,7 @@
 
 import (
 	"context"
+	"net/url"
 	"strings"
 	"time"
 
@@ -17,6 +18,10 @@ type policySchedulingFlags struct {
 	policySetCron       string
 	policySetManual     bool
 	policySetRunMissed  string
+	policySetJitter     []time.Duration // not a list, just optional duration
+
+	policySetHealthCheckURL       string
+	policySetNotificationProfiles string
 }
 
 func (c *policySchedulingFlags) setup(cmd *kingpin.CmdClause) {
@@ -24,10 +29,19 @@ func (c *policySchedulingFlags) setup(cmd *kingpin.CmdClause) {
 	cmd.Flag("snapshot-time", "Comma-separated times of day when to take snapshot (HH:mm,HH:mm,...) or 'inherit' to remove override").StringsVar(&c.policySetTimesOfDay)
 	cmd.Flag("snapshot-time-crontab", "Semicolon-separated crontab-compatible expressions (or 'inherit')").StringVar(&c.policySetCron)
 	cmd.Flag("run-missed", "Run missed time-of-day or cron snapshots ('true', 'false', 'inherit')").EnumVar(&c.policySetRunMissed, booleanEnumValues...)
+	cmd.Flag("snapshot-jitter", "Maximum random delay of scheduled snapshots (0 disables)").DurationListVar(&c.policySetJitter)
 	cmd.Flag("manual", "Only create snapshots manually").BoolVar(&c.policySetManual)
+	cmd.Flag("notification-profiles", "Comma-separated names of notification profiles that receive events about this source (or 'inherit')").StringVar(&c.policySetNotificationProfiles)
+	cmd.Flag("health-check-url", "URL pinged when snapshots start, succeed or fail (healthchecks.io-compatible) or 'inherit'").StringVar(&c.policySetHealthCheckURL)
 }
 
 func (c *policySchedulingFlags) setSchedulingPolicyFromFlags(ctx context.Context, sp *policy.SchedulingPolicy, changeCount *int) error {
+	if err := c.setHealthCheckURLFromFlags(ctx, sp, changeCount); err != nil {
+		return err
+	}
+
+	c.setNotificationProfilesFromFlags(ctx, sp, changeCount)
+
 	if c.policySetManual {
 		return c.setManualFromFlags(ctx, sp, changeCount)
 	}
@@ -46,6 +60,15 @@ func (c *policySchedulingFlags) setScheduleFromFlags(ctx context.Context, sp *po
 		break
 	}
 
+	for _, jitter := range c.policySetJitter {
+		*changeCount++
+
+		sp.SetJitter(jitter)
+		log(ctx).Infof(" - setting snapshot jitter to %v", sp.Jitter())
+
+		break
+	}
+
 	if len(c.policySetTimesOfDay) > 0 {
 		var timesOfDay []policy.TimeOfDay
 
@@ -117,6 +140,60 @@ func (c *policySchedulingFlags) setRunMissedFromFlags(ctx context.Context, sp *p
 	return nil
 }
 
+func (c *policySchedulingFlags) setHealthCheckURLFromFlags(ctx context.Context, sp *policy.SchedulingPolicy, changeCount *int) error {
+	switch c.policySetHealthCheckURL {
+	case "":
+		return nil
+
+	case inheritPolicyString, defaultPolicyString:
+		*changeCount++
+
+		sp.HealthCheckURL = ""
+
+		log(ctx).Info(" - resetting health check URL to default")
+
+	default:
+		u, err := url.Parse(c.policySetHealthCheckURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return errors.Errorf("invalid health check URL %q, must be http or https", c.policySetHealthCheckURL)
+		}
+
+		*changeCount++
+
+		sp.HealthCheckURL = c.policySetHealthCheckURL
+
+		log(ctx).Infof(" - setting health check URL to %v", sp.HealthCheckURL)
+	}
+
+	return nil
+}
+
+func (c *policySchedulingFlags) setNotificationProfilesFromFlags(ctx context.Context, sp *policy.SchedulingPolicy, changeCount *int) {
+	if c.policySetNotificationProfiles == "" {
+		return
+	}
+
+	*changeCount++
+
+	if c.policySetNotificationProfiles == inheritPolicyString || c.policySetNotificationProfiles == defaultPolicyString {
+		sp.NotificationProfiles = nil
+
+		log(ctx).Info(" - resetting notification profiles to default")
+
+		return
+	}
+
+	sp.NotificationProfiles = nil
+
+	for _, name := range strings.Split(c.policySetNotificationProfiles, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			sp.NotificationProfiles = append(sp.NotificationProfiles, name)
+		}
+	}
+
+	log(ctx).Infof(" - setting notification profiles to %v", sp.NotificationProfiles)
+}
+
 // splitCronExpressions splits the provided string into a list of cron expressions.
 // Individual items are separated by semi-colons. As a special case, the string "inherit"
 // returns a nil slice.
@@ -142,7 +219,7 @@ func splitCronExpressions(expr string) []string {
 
 func (c *policySchedulingFlags) setManualFromFlags(ctx context.Context, sp *policy.SchedulingPolicy, changeCount *int) error {
 	// Cannot set both schedule and manual setting
-	if len(c.policySetInterval) > 0 || len(c.policySetTimesOfDay) > 0 || c.policySetCron != "" {
+	if len(c.policySetInterval) > 0 || len(c.policySetTimesOfDay) > 0 || c.policySetCron != "" || len(c.policySetJitter) > 0 {
 		return errors.New("cannot set manual field when scheduling snapshots")
 	}
 
@@ -171,6 +248,14 @@ func (c *policySchedulingFlags) setManualFromFlags(ctx context.Context, sp *poli
 		log(ctx).Info(" - resetting cron snapshot times to default\n")
 	}
 
+	if sp.JitterSeconds != 0 {
+		*changeCount++
+
+		sp.JitterSeconds = 0
+
+		log(ctx).Info(" - resetting snapshot jitter to default\n")
+	}
+
 	*changeCount++
 
 	sp.Manual = c.policySetManual
@@ -0,0 +1,81 @@
+package cli_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestSetHealthCheckURL(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu    sync.Mutex
+		pings []string
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		pings = append(pings, r.URL.Path)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+
+	td := testutil.TempDirectory(t)
+
+	e.RunAndExpectFailure(t, "policy", "set", td, "--health-check-url=ftp://example.com/abc")
+	e.RunAndExpectSuccess(t, "policy", "set", td, "--health-check-url="+srv.URL+"/abc")
+
+	lines := compressSpaces(e.RunAndExpectSuccess(t, "policy", "show", td))
+	require.Contains(t, lines, " Health check URL: "+srv.URL+"/abc (defined for this target)")
+
+	e.RunAndExpectSuccess(t, "snapshot", "create", td)
+
+	mu.Lock()
+	require.Equal(t, []string{"/abc/start", "/abc"}, pings)
+	mu.Unlock()
+
+	e.RunAndExpectSuccess(t, "policy", "set", td, "--health-check-url=inherit")
+	e.RunAndExpectSuccess(t, "snapshot", "create", td)
+
+	mu.Lock()
+	require.Len(t, pings, 2)
+	mu.Unlock()
+
+	// manual snapshots cannot be combined with other scheduling settings, including health checks.
+	e.RunAndExpectFailure(t, "policy", "set", td, "--manual", "--health-check-url="+srv.URL+"/abc")
+}
+
+func TestSetSnapshotJitter(t *testing.T) {
+	t.Parallel()
+
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+
+	td := testutil.TempDirectory(t)
+
+	e.RunAndExpectSuccess(t, "policy", "set", td, "--snapshot-interval=1h", "--snapshot-jitter=10m")
+
+	lines := compressSpaces(e.RunAndExpectSuccess(t, "policy", "show", td))
+	require.Contains(t, lines, " Snapshot jitter: 10m0s (defined for this target)")
+
+	e.RunAndExpectFailure(t, "policy", "set", td, "--manual", "--snapshot-jitter=5m")
+
+	e.RunAndExpectSuccess(t, "policy", "set", td, "--manual")
+
+	lines = compressSpaces(e.RunAndExpectSuccess(t, "policy", "show", td))
+	require.NotContains(t, lines, " Snapshot jitter: 10m0s (defined for this target)")
+}
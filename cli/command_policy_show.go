@@ -16,6 +16,8 @@
 
 type commandPolicyShow struct {
 	policyTargetFlags
+	effective bool
+
 	jo  jsonOutput
 	out textOutput
 }
@@ -23,6 +25,7 @@ type commandPolicyShow struct {
 func (c *commandPolicyShow) setup(svc appServices, parent commandParent) {
 	cmd := parent.Command("show", "Show snapshot policy.").Alias("get")
 	c.policyTargetFlags.setup(cmd)
+	cmd.Flag("effective", "Show effective policy merged with parent policies (use --no-effective to only show policy defined for the target)").Default("true").BoolVar(&c.effective)
 	c.jo.setup(svc, cmd)
 	c.out.setup(svc)
 	cmd.Action(svc.repositoryReaderAction(c.run))
@@ -35,6 +38,14 @@ func (c *commandPolicyShow) run(ctx context.Context, rep repo.Repository) error
 	}
 
 	for _, target := range targets {
+		if !c.effective {
+			if err := c.showDefinedPolicy(ctx, rep, target); err != nil {
+				return err
+			}
+
+			continue
+		}
+
 		effective, definition, _, err := policy.GetEffectivePolicy(ctx, rep, target)
 		if err != nil {
 			return errors.Wrapf(err, "can't get effective policy for %q", target)
@@ -50,6 +61,25 @@ func (c *commandPolicyShow) run(ctx context.Context, rep repo.Repository) error
 	return nil
 }
 
+func (c *commandPolicyShow) showDefinedPolicy(ctx context.Context, rep repo.Repository, target snapshot.SourceInfo) error {
+	defined, err := policy.GetDefinedPolicy(ctx, rep, target)
+	if errors.Is(err, policy.ErrPolicyNotFound) {
+		return errors.Errorf("no policy defined for %q", target)
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "can't get defined policy for %q", target)
+	}
+
+	if c.jo.jsonOutput {
+		c.out.printStdout("%s\n", c.jo.jsonBytes(defined))
+	} else {
+		c.out.printStdout("Policy defined for %v:\n%v\n", target, defined)
+	}
+
+	return nil
+}
+
 type policyTableRow struct {
 	name  string
 	value string
@@ -329,20 +359,38 @@ func appendSchedulingPolicyRows(rows []policyTableRow, p *policy.Policy, def *po
 		rows = append(rows, policyTableRow{"    None.", "", ""})
 	}
 
+	if p.SchedulingPolicy.Jitter() != 0 {
+		rows = append(rows, policyTableRow{"  Snapshot jitter:", p.SchedulingPolicy.Jitter().String(), definitionPointToString(p.Target(), def.SchedulingPolicy.JitterSeconds)})
+	}
+
 	rows = append(rows, policyTableRow{"  Manual snapshot:", boolToString(p.SchedulingPolicy.Manual), definitionPointToString(p.Target(), def.SchedulingPolicy.Manual)})
 
+	if p.SchedulingPolicy.HealthCheckURL != "" {
+		rows = append(rows, policyTableRow{"  Health check URL:", p.SchedulingPolicy.HealthCheckURL, definitionPointToString(p.Target(), def.SchedulingPolicy.HealthCheckURL)})
+	}
+
+	if len(p.SchedulingPolicy.NotificationProfiles) > 0 {
+		rows = append(rows, policyTableRow{"  Notification profiles:", strings.Join(p.SchedulingPolicy.NotificationProfiles, ", "), definitionPointToString(p.Target(), def.SchedulingPolicy.NotificationProfiles)})
+	}
+
 	return rows
 }
 
 func appendCompressionPolicyRows(rows []policyTableRow, p *policy.Policy, def *policy.Definition) []policyTableRow {
-	if p.CompressionPolicy.CompressorName == "" || p.CompressionPolicy.CompressorName == "none" {
+	if p.CompressionPolicy.CompressorName == "none" {
 		rows = append(rows, policyTableRow{"Compression disabled.", "", ""})
 		return rows
 	}
 
-	rows = append(rows,
-		policyTableRow{"Compression:", "", ""},
-		policyTableRow{"  Compressor:", string(p.CompressionPolicy.CompressorName), definitionPointToString(p.Target(), def.CompressionPolicy.CompressorName)})
+	if p.CompressionPolicy.CompressorName == "" {
+		rows = append(rows,
+			policyTableRow{"Compression:", "", ""},
+			policyTableRow{"  Compressor:", "repository default", ""})
+	} else {
+		rows = append(rows,
+			policyTableRow{"Compression:", "", ""},
+			policyTableRow{"  Compressor:", string(p.CompressionPolicy.CompressorName), definitionPointToString(p.Target(), def.CompressionPolicy.CompressorName)})
+	}
 
 	switch {
 	case len(p.CompressionPolicy.OnlyCompress) > 0:
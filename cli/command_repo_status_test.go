@@ -1,8 +1,11 @@
 package cli_test
 
 import (
+	"path/filepath"
 	"testing"
 
+	"github.com/stretchr/testify/require"
+
 	"github.com/kopia/kopia/cli"
 	"github.com/kopia/kopia/internal/testutil"
 	"github.com/kopia/kopia/tests/testenv"
@@ -18,6 +21,38 @@ func TestRepoStatusJSON(t *testing.T) {
 	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
 	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
 
-	e.RunAndExpectSuccess(t, "repo", "status")
+	out := e.RunAndExpectSuccess(t, "repo", "status")
+	require.True(t, containsLineStartingWith(out, "Cache usage:"), "missing cache usage: %v", out)
+
 	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "repo", "status", "--json"), &rs)
 }
+
+func TestRepoStatusWithProfile(t *testing.T) {
+	t.Parallel()
+
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	var rs1, rs2 cli.RepositoryStatus
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	otherRepoDir := testutil.TempDirectory(t)
+
+	e.RunAndExpectSuccess(t, "--profile=offsite", "repo", "create", "filesystem", "--path", otherRepoDir)
+	defer e.RunAndExpectSuccess(t, "--profile=offsite", "repo", "disconnect")
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "repo", "status", "--json"), &rs1)
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "--profile=offsite", "repo", "status", "--json"), &rs2)
+
+	require.Equal(t, filepath.Join(filepath.Dir(rs1.ConfigFile), "profiles", "offsite.config"), rs2.ConfigFile)
+	require.NotEqual(t, rs1.UniqueIDHex, rs2.UniqueIDHex)
+
+	// a profile named after the main config file does not alias it.
+	e.RunAndExpectFailure(t, "--profile=repository", "repo", "status")
+
+	for _, name := range []string{"..", "a/b", `a\b`, ".hidden", "a.config"} {
+		_, _, err := e.Run(t, true, "--profile="+name, "repo", "status")
+		require.ErrorContains(t, err, "invalid profile name", name)
+	}
+}
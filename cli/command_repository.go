@@ -1,6 +1,7 @@
 package cli
 
 type commandRepository struct {
+	auditLog         commandRepositoryAuditLog
 	connect          commandRepositoryConnect
 	create           commandRepositoryCreate
 	disconnect       commandRepositoryDisconnect
@@ -8,6 +9,8 @@ type commandRepository struct {
 	setClient        commandRepositorySetClient
 	setParameters    commandRepositorySetParameters
 	changePassword   commandRepositoryChangePassword
+	keySlot          commandRepositoryKeySlot
+	stats            commandRepositoryStats
 	status           commandRepositoryStatus
 	syncTo           commandRepositorySyncTo
 	throttle         commandRepositoryThrottle
@@ -18,16 +21,19 @@ type commandRepository struct {
 func (c *commandRepository) setup(svc advancedAppServices, parent commandParent) {
 	cmd := parent.Command("repository", "Commands to manipulate repository.").Alias("repo")
 
+	c.auditLog.setup(svc, cmd)
 	c.connect.setup(svc, cmd)
 	c.create.setup(svc, cmd)
 	c.disconnect.setup(svc, cmd)
 	c.repair.setup(svc, cmd)
 	c.setClient.setup(svc, cmd)
 	c.setParameters.setup(svc, cmd)
+	c.stats.setup(svc, cmd)
 	c.status.setup(svc, cmd)
 	c.syncTo.setup(svc, cmd)
 	c.throttle.setup(svc, cmd)
 	c.changePassword.setup(svc, cmd)
+	c.keySlot.setup(svc, cmd)
 	c.validateProvider.setup(svc, cmd)
 	c.upgrade.setup(svc, cmd)
 }
@@ -0,0 +1,17 @@
+package cli
+
+type commandRepositoryAuditLog struct {
+	enable commandRepositoryAuditLogEnable
+	list   commandRepositoryAuditLogList
+	verify commandRepositoryAuditLogVerify
+	prune  commandRepositoryAuditLogPrune
+}
+
+func (c *commandRepositoryAuditLog) setup(svc advancedAppServices, parent commandParent) {
+	cmd := parent.Command("audit-log", "Commands to manage the log of repository mutations stored in the repository")
+
+	c.enable.setup(svc, cmd)
+	c.list.setup(svc, cmd)
+	c.verify.setup(svc, cmd)
+	c.prune.setup(svc, cmd)
+}
@@ -0,0 +1,27 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+)
+
+type commandRepositoryAuditLogEnable struct{}
+
+func (c *commandRepositoryAuditLogEnable) setup(svc advancedAppServices, parent commandParent) {
+	cmd := parent.Command("enable", "Start recording manifest and credential changes in the audit log")
+
+	cmd.Action(svc.directRepositoryWriteAction(c.run))
+}
+
+func (c *commandRepositoryAuditLogEnable) run(ctx context.Context, rep repo.DirectRepositoryWriter) error {
+	if err := rep.AppendAuditRecord(ctx, repo.AuditActionEnable, "", ""); err != nil {
+		return errors.Wrap(err, "unable to enable audit log")
+	}
+
+	log(ctx).Info("Audit log enabled.")
+
+	return nil
+}
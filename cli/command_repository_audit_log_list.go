@@ -0,0 +1,41 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+)
+
+type commandRepositoryAuditLogList struct {
+	jo  jsonOutput
+	out textOutput
+}
+
+func (c *commandRepositoryAuditLogList) setup(svc advancedAppServices, parent commandParent) {
+	cmd := parent.Command("list", "List audit log records").Alias("ls")
+
+	c.jo.setup(svc, cmd)
+	c.out.setup(svc)
+	cmd.Action(svc.directRepositoryReadAction(c.run))
+}
+
+func (c *commandRepositoryAuditLogList) run(ctx context.Context, rep repo.DirectRepository) error {
+	records, err := repo.ReadAuditLog(ctx, rep)
+	if err != nil {
+		return errors.Wrap(err, "unable to read audit log")
+	}
+
+	if c.jo.jsonOutput {
+		c.out.printStdout("%s\n", c.jo.jsonBytes(records))
+
+		return nil
+	}
+
+	for _, ar := range records {
+		c.out.printStdout("%6v %v %-20v %-24v %v %v\n", ar.Seq, formatTimestamp(ar.Time), ar.User, ar.Action, ar.ItemType, ar.ItemID)
+	}
+
+	return nil
+}
@@ -0,0 +1,32 @@
+package cli
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+)
+
+type commandRepositoryAuditLogPrune struct {
+	olderThan time.Duration
+}
+
+func (c *commandRepositoryAuditLogPrune) setup(svc advancedAppServices, parent commandParent) {
+	cmd := parent.Command("prune", "Remove old audit log records")
+	cmd.Flag("older-than", "Remove records older than the provided age").Default("8760h").DurationVar(&c.olderThan)
+
+	cmd.Action(svc.directRepositoryWriteAction(c.run))
+}
+
+func (c *commandRepositoryAuditLogPrune) run(ctx context.Context, rep repo.DirectRepositoryWriter) error {
+	n, err := rep.PruneAuditLog(ctx, rep.Time().Add(-c.olderThan))
+	if err != nil {
+		return errors.Wrap(err, "unable to prune audit log")
+	}
+
+	log(ctx).Infof("Removed %v audit records.", n)
+
+	return nil
+}
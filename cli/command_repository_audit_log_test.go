@@ -0,0 +1,54 @@
+package cli_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestRepositoryAuditLog(t *testing.T) {
+	t.Parallel()
+
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	e.RunAndExpectSuccess(t, "repo", "audit-log", "enable")
+	e.RunAndExpectSuccess(t, "snapshot", "create", testutil.TempDirectory(t))
+	e.RunAndExpectSuccess(t, "repo", "key-slot", "add", "extra", "--new-password", "extra-password")
+
+	var records []*repo.AuditRecord
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "repo", "audit-log", "list", "--json"), &records)
+	require.GreaterOrEqual(t, len(records), 3)
+	require.Equal(t, repo.AuditActionEnable, records[0].Action)
+
+	var sawSnapshot bool
+
+	for _, ar := range records {
+		if ar.Action == repo.AuditActionPutManifest && ar.ItemType == "snapshot" {
+			sawSnapshot = true
+		}
+	}
+
+	require.True(t, sawSnapshot)
+
+	last := records[len(records)-1]
+	require.Equal(t, repo.AuditActionAddKeySlot, last.Action)
+	require.Equal(t, "extra", last.ItemID)
+
+	e.RunAndExpectSuccess(t, "repo", "audit-log", "verify")
+	e.RunAndExpectSuccess(t, "repo", "audit-log", "list")
+
+	// pruning keeps the log verifiable.
+	e.RunAndExpectSuccess(t, "repo", "audit-log", "prune", "--older-than=0s")
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "repo", "audit-log", "list", "--json"), &records)
+	require.Len(t, records, 2)
+	require.Equal(t, repo.AuditActionPrune, records[1].Action)
+	e.RunAndExpectSuccess(t, "repo", "audit-log", "verify")
+}
@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+)
+
+type commandRepositoryAuditLogVerify struct {
+	out textOutput
+}
+
+func (c *commandRepositoryAuditLogVerify) setup(svc advancedAppServices, parent commandParent) {
+	cmd := parent.Command("verify", "Verify that audit log records have not been tampered with")
+
+	c.out.setup(svc)
+	cmd.Action(svc.directRepositoryReadAction(c.run))
+}
+
+func (c *commandRepositoryAuditLogVerify) run(ctx context.Context, rep repo.DirectRepository) error {
+	records, err := repo.ReadAuditLog(ctx, rep)
+	if err != nil {
+		return errors.Wrap(err, "unable to read audit log")
+	}
+
+	forks, err := repo.VerifyAuditLog(rep, records)
+	if err != nil {
+		return errors.Wrap(err, "audit log verification failed")
+	}
+
+	for _, seq := range forks {
+		log(ctx).Warnf("Audit log forks at record %v, which happens when multiple clients write concurrently or a client was rolled back.", seq)
+	}
+
+	c.out.printStdout("Verified %v audit records.\n", len(records))
+
+	return nil
+}
@@ -6,10 +6,12 @@
 	"github.com/pkg/errors"
 
 	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/format"
 )
 
 type commandRepositoryChangePassword struct {
-	newPassword string
+	newPassword               string
+	newKeyDerivationAlgorithm string
 
 	svc advancedAppServices
 }
@@ -17,6 +19,7 @@ type commandRepositoryChangePassword struct {
 func (c *commandRepositoryChangePassword) setup(svc advancedAppServices, parent commandParent) {
 	cmd := parent.Command("change-password", "Change repository password")
 	cmd.Flag("new-password", "New password").Envar(svc.EnvName("KOPIA_NEW_PASSWORD")).StringVar(&c.newPassword)
+	cmd.Flag("new-key-derivation-algorithm", "Algorithm to derive the encryption key for the format block from the new password, parameters of scrypt-N-R-P and argon2id-TIME-MEMORY-THREADS can be tuned (default: keep current)").PlaceHolder("ALGORITHM").HintOptions(format.SupportedFormatBlobKeyDerivationAlgorithms()...).StringVar(&c.newKeyDerivationAlgorithm)
 
 	c.svc = svc
 	cmd.Action(svc.directRepositoryWriteAction(c.run))
@@ -26,7 +29,7 @@ func (c *commandRepositoryChangePassword) run(ctx context.Context, rep repo.Dire
 	var newPass string
 
 	if c.newPassword == "" {
-		n, err := askForChangedRepositoryPassword(c.svc.stdout())
+		n, err := askForChangedRepositoryPassword(c.svc)
 		if err != nil {
 			return err
 		}
@@ -36,7 +39,10 @@ func (c *commandRepositoryChangePassword) run(ctx context.Context, rep repo.Dire
 		newPass = c.newPassword
 	}
 
-	if err := rep.FormatManager().ChangePassword(ctx, newPass); err != nil {
+	if err := rep.FormatManager().ChangeCredentials(ctx, format.Credentials{
+		Password:               newPass,
+		KeyDerivationAlgorithm: c.newKeyDerivationAlgorithm,
+	}); err != nil {
 		return errors.Wrap(err, "unable to change password")
 	}
 
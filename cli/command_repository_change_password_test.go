@@ -3,6 +3,7 @@
 import (
 	"testing"
 
+	"github.com/kopia/kopia/internal/crypto"
 	"github.com/kopia/kopia/repo/format"
 	"github.com/kopia/kopia/tests/testenv"
 )
@@ -41,4 +42,16 @@ func (s *formatSpecificTestSuite) TestRepositoryChangePassword(t *testing.T) {
 	env3.Environment["KOPIA_PASSWORD"] = "newPass"
 
 	env3.RunAndExpectSuccess(t, "repo", "connect", "filesystem", "--path", env1.RepoDir, "--disable-repository-format-cache")
+
+	// change password again, switching key derivation algorithm.
+	env3.RunAndExpectSuccess(t, "repo", "change-password", "--new-password", "newPass2", "--new-key-derivation-algorithm", crypto.Pbkdf2Algorithm)
+
+	env3.Environment["KOPIA_PASSWORD"] = "newPass2"
+	env3.RunAndExpectSuccess(t, "snapshot", "ls")
+
+	env4 := testenv.NewCLITest(t, s.formatFlags, testenv.NewInProcRunner(t))
+	env4.Environment["KOPIA_PASSWORD"] = "newPass2"
+
+	env4.RunAndExpectSuccess(t, "repo", "connect", "filesystem", "--path", env1.RepoDir, "--disable-repository-format-cache")
+	env4.RunAndExpectSuccess(t, "snapshot", "ls")
 }
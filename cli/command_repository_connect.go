@@ -58,6 +58,8 @@ type connectOptions struct {
 
 	formatBlobCacheDuration time.Duration
 	disableFormatBlobCache  bool
+	listParallelism         int
+	passwordSource          string
 }
 
 func (c *connectOptions) setup(svc appServices, cmd *kingpin.CmdClause) {
@@ -79,6 +81,8 @@ func (c *connectOptions) setup(svc appServices, cmd *kingpin.CmdClause) {
 	cmd.Flag("enable-actions", "Allow snapshot actions").BoolVar(&c.connectEnableActions)
 	cmd.Flag("repository-format-cache-duration", "Duration of kopia.repository format blob cache").Hidden().DurationVar(&c.formatBlobCacheDuration)
 	cmd.Flag("disable-repository-format-cache", "Disable caching of kopia.repository format blob").Hidden().BoolVar(&c.disableFormatBlobCache)
+	cmd.Flag("blob-list-parallelism", "List pack and index blobs over hexadecimal prefixes in parallel").IntVar(&c.listParallelism)
+	cmd.Flag("password-source", "Where to read the repository password from when opening the repository: 'keyring', 'file:PATH' or 'env:NAME'").PlaceHolder("SOURCE").Envar(svc.EnvName("KOPIA_PASSWORD_SOURCE")).StringVar(&c.passwordSource)
 }
 
 func (c *connectOptions) getFormatBlobCacheDuration() time.Duration {
@@ -89,7 +93,16 @@ func (c *connectOptions) getFormatBlobCacheDuration() time.Duration {
 	return c.formatBlobCacheDuration
 }
 
-func (c *connectOptions) toRepoConnectOptions() *repo.ConnectOptions {
+func (c *connectOptions) toRepoConnectOptions() (*repo.ConnectOptions, error) {
+	passwordSource := c.passwordSource
+	if passwordSource != "" {
+		var err error
+
+		if passwordSource, err = passwordpersist.ResolveSource(passwordSource); err != nil {
+			return nil, errors.Wrap(err, "invalid --password-source")
+		}
+	}
+
 	return &repo.ConnectOptions{
 		CachingOptions: content.CachingOptions{
 			CacheDirectory:              c.connectCacheDirectory,
@@ -97,6 +110,7 @@ func (c *connectOptions) toRepoConnectOptions() *repo.ConnectOptions {
 			ContentCacheSizeLimitBytes:  c.contentCacheSizeLimitMB << 20,  //nolint:mnd
 			MetadataCacheSizeBytes:      c.metadataCacheSizeMB << 20,      //nolint:mnd
 			MetadataCacheSizeLimitBytes: c.metadataCacheSizeLimitMB << 20, //nolint:mnd
+			BlobCacheSizeBytes:          c.blobCacheSizeMB << 20,          //nolint:mnd
 			MaxListCacheDuration:        content.DurationSeconds(c.maxListCacheDuration.Seconds()),
 			MinContentSweepAge:          content.DurationSeconds(c.contentMinSweepAge.Seconds()),
 			MinMetadataSweepAge:         content.DurationSeconds(c.metadataMinSweepAge.Seconds()),
@@ -110,12 +124,30 @@ func (c *connectOptions) toRepoConnectOptions() *repo.ConnectOptions {
 			Description:             c.connectDescription,
 			EnableActions:           c.connectEnableActions,
 			FormatBlobCacheDuration: c.getFormatBlobCacheDuration(),
+			ListParallelism:         c.listParallelism,
+			PasswordSource:          passwordSource,
 		},
+	}, nil
+}
+
+// connectPasswordStrategy returns the strategy used to persist the password of a new connection.
+func (c *App) connectPasswordStrategy(co *connectOptions) (passwordpersist.Strategy, error) {
+	if co.passwordSource == "" {
+		return c.passwordPersistenceStrategy(), nil
 	}
+
+	s, err := passwordpersist.Source(co.passwordSource)
+
+	return s, errors.Wrap(err, "invalid --password-source")
 }
 
 func (c *App) runConnectCommandWithStorage(ctx context.Context, co *connectOptions, st blob.Storage) error {
-	pass, err := c.getPasswordFromFlags(ctx, false, false)
+	strategy, err := c.connectPasswordStrategy(co)
+	if err != nil {
+		return err
+	}
+
+	pass, err := c.getPasswordFromFlagsOrSource(ctx, strategy, co.passwordSource != "")
 	if err != nil {
 		return errors.Wrap(err, "getting password")
 	}
@@ -124,10 +156,20 @@ func (c *App) runConnectCommandWithStorage(ctx context.Context, co *connectOptio
 }
 
 func (c *App) runConnectCommandWithStorageAndPassword(ctx context.Context, co *connectOptions, st blob.Storage, password string) error {
+	strategy, err := c.connectPasswordStrategy(co)
+	if err != nil {
+		return err
+	}
+
+	opt, err := co.toRepoConnectOptions()
+	if err != nil {
+		return err
+	}
+
 	configFile := c.repositoryConfigFileName()
 	if err := passwordpersist.OnSuccess(
-		ctx, repo.Connect(ctx, configFile, st, password, co.toRepoConnectOptions()),
-		c.passwordPersistenceStrategy(), configFile, password); err != nil {
+		ctx, repo.Connect(ctx, configFile, st, password, opt),
+		strategy, configFile, password); err != nil {
 		return errors.Wrap(err, "error connecting to repository")
 	}
 
@@ -10,6 +10,7 @@
 
 	"github.com/kopia/kopia/repo"
 	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/blob/plugin"
 )
 
 type storageFromConfigFlags struct {
@@ -18,6 +19,8 @@ type storageFromConfigFlags struct {
 	connectFromTokenFile   string
 	connectFromTokenStdin  bool
 
+	allowTokenPluginExecution bool
+
 	sps StorageProviderServices
 }
 
@@ -26,6 +29,7 @@ func (c *storageFromConfigFlags) Setup(sps StorageProviderServices, cmd *kingpin
 	cmd.Flag("token", "Configuration token").StringVar(&c.connectFromConfigToken)
 	cmd.Flag("token-file", "Path to the configuration token file").StringVar(&c.connectFromTokenFile)
 	cmd.Flag("token-stdin", "Read configuration token from stdin").BoolVar(&c.connectFromTokenStdin)
+	cmd.Flag("allow-token-plugin-executable", "Allow the token to specify the storage plugin executable and environment").BoolVar(&c.allowTokenPluginExecution)
 
 	c.sps = sps
 }
@@ -76,6 +80,12 @@ func (c *storageFromConfigFlags) connectToStorageFromConfigToken(ctx context.Con
 		return nil, errors.Wrap(err, "invalid token")
 	}
 
+	if !c.allowTokenPluginExecution {
+		if err := plugin.VerifyTokenConnectionInfo(ci); err != nil {
+			return nil, errors.Wrap(err, "use --allow-token-plugin-executable if the token is trusted")
+		}
+	}
+
 	if pass != "" {
 		c.sps.setPasswordFromToken(pass)
 	}
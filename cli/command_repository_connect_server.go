@@ -2,6 +2,7 @@
 
 import (
 	"context"
+	"path/filepath"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -16,6 +17,8 @@ type commandRepositoryConnectServer struct {
 	connectAPIServerURL                              string
 	connectAPIServerCertFingerprint                  string
 	connectAPIServerLocalCacheKeyDerivationAlgorithm string
+	connectAPIServerClientCertFile                   string
+	connectAPIServerClientKeyFile                    string
 
 	svc advancedAppServices
 	out textOutput
@@ -29,6 +32,8 @@ func (c *commandRepositoryConnectServer) setup(svc advancedAppServices, parent c
 	cmd := parent.Command("server", "Connect to a repository API Server.")
 	cmd.Flag("url", "Server URL").Required().StringVar(&c.connectAPIServerURL)
 	cmd.Flag("server-cert-fingerprint", "Server certificate fingerprint").StringVar(&c.connectAPIServerCertFingerprint)
+	cmd.Flag("tls-client-cert", "Client certificate file (PEM) presented to servers requiring TLS client certificates").ExistingFileVar(&c.connectAPIServerClientCertFile)
+	cmd.Flag("tls-client-key", "Private key file (PEM) of the client certificate").ExistingFileVar(&c.connectAPIServerClientKeyFile)
 	//nolint:lll
 	cmd.Flag("local-cache-key-derivation-algorithm", "Key derivation algorithm used to derive the local cache encryption key").Hidden().Default(repo.DefaultServerRepoCacheKeyDerivationAlgorithm).EnumVar(&c.connectAPIServerLocalCacheKeyDerivationAlgorithm, repo.SupportedLocalCacheKeyDerivationAlgorithms()...)
 	cmd.Action(svc.noRepositoryAction(c.run))
@@ -40,14 +45,34 @@ func (c *commandRepositoryConnectServer) run(ctx context.Context) error {
 		localCacheKeyDerivationAlgorithm = repo.DefaultServerRepoCacheKeyDerivationAlgorithm
 	}
 
+	if (c.connectAPIServerClientCertFile == "") != (c.connectAPIServerClientKeyFile == "") {
+		return errors.New("--tls-client-cert and --tls-client-key must be specified together")
+	}
+
 	as := &repo.APIServerInfo{
 		BaseURL:                             strings.TrimSuffix(c.connectAPIServerURL, "/"),
 		TrustedServerCertificateFingerprint: strings.ToLower(c.connectAPIServerCertFingerprint),
 		LocalCacheKeyDerivationAlgorithm:    localCacheKeyDerivationAlgorithm,
 	}
 
+	// the files are referenced from the configuration, so they must not depend on the current directory.
+	if c.connectAPIServerClientCertFile != "" {
+		var err error
+
+		if as.ClientCertificateFile, err = filepath.Abs(c.connectAPIServerClientCertFile); err != nil {
+			return errors.Wrap(err, "client certificate path")
+		}
+
+		if as.ClientKeyFile, err = filepath.Abs(c.connectAPIServerClientKeyFile); err != nil {
+			return errors.Wrap(err, "client key path")
+		}
+	}
+
 	configFile := c.svc.repositoryConfigFileName()
-	opt := c.co.toRepoConnectOptions()
+	opt, err := c.co.toRepoConnectOptions()
+	if err != nil {
+		return err
+	}
 
 	u := opt.Username
 	if u == "" {
@@ -2,13 +2,16 @@
 
 import (
 	"context"
+	"sort"
 	"time"
 
 	"github.com/alecthomas/kingpin/v2"
 	"github.com/pkg/errors"
 
+	"github.com/kopia/kopia/internal/cryptobackend"
 	"github.com/kopia/kopia/repo"
 	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/compression"
 	"github.com/kopia/kopia/repo/ecc"
 	"github.com/kopia/kopia/repo/encryption"
 	"github.com/kopia/kopia/repo/format"
@@ -17,6 +20,12 @@
 	"github.com/kopia/kopia/snapshot/policy"
 )
 
+// fastestBlockHash is a special value of --block-hash that selects the fastest hash algorithm by benchmarking.
+const fastestBlockHash = "fastest"
+
+// autoAlgorithm is a special value of --block-hash and --encryption that selects the algorithm based on hardware crypto support.
+const autoAlgorithm = "auto"
+
 const runValidationNote = `NOTE: To validate that your provider is compatible with Kopia, please run:
 
 $ kopia repository validate-provider
@@ -29,11 +38,14 @@ type commandRepositoryCreate struct {
 	createBlockECCFormat              string
 	createBlockECCOverheadPercent     int
 	createBlockKeyDerivationAlgorithm string
+	createFormatBlockEncryption       string
 	createSplitter                    string
+	createDefaultCompression          string
 	createOnly                        bool
 	createFormatVersion               int
 	retentionMode                     string
 	retentionPeriod                   time.Duration
+	maxBlobSizeMB                     int64
 
 	co  connectOptions
 	svc advancedAppServices
@@ -43,17 +55,20 @@ type commandRepositoryCreate struct {
 func (c *commandRepositoryCreate) setup(svc advancedAppServices, parent commandParent) {
 	cmd := parent.Command("create", "Create new repository in a specified location.")
 
-	cmd.Flag("block-hash", "Content hash algorithm.").PlaceHolder("ALGO").Default(hashing.DefaultAlgorithm).EnumVar(&c.createBlockHashFormat, hashing.SupportedAlgorithms()...)
-	cmd.Flag("encryption", "Content encryption algorithm.").PlaceHolder("ALGO").Default(encryption.DefaultAlgorithm).EnumVar(&c.createBlockEncryptionFormat, encryption.SupportedAlgorithms(false)...)
+	cmd.Flag("block-hash", "Content hash algorithm, use '"+fastestBlockHash+"' to pick the fastest one on this machine by benchmarking or '"+autoAlgorithm+"' to pick one based on hardware crypto support.").PlaceHolder("ALGO").Default(hashing.DefaultAlgorithm).EnumVar(&c.createBlockHashFormat, append(hashing.SupportedAlgorithms(), fastestBlockHash, autoAlgorithm)...)
+	cmd.Flag("encryption", "Content encryption algorithm, use '"+autoAlgorithm+"' to pick one based on hardware crypto support.").PlaceHolder("ALGO").Default(encryption.DefaultAlgorithm).EnumVar(&c.createBlockEncryptionFormat, append(encryption.SupportedAlgorithms(false), autoAlgorithm)...)
 	cmd.Flag("ecc", "[EXPERIMENTAL] Error correction algorithm.").PlaceHolder("ALGO").Default(ecc.DefaultAlgorithm).EnumVar(&c.createBlockECCFormat, ecc.SupportedAlgorithms()...)
 	cmd.Flag("ecc-overhead-percent", "[EXPERIMENTAL] How much space overhead can be used for error correction, in percentage. Use 0 to disable ECC.").Default("0").IntVar(&c.createBlockECCOverheadPercent)
-	cmd.Flag("object-splitter", "The splitter to use for new objects in the repository").Default(splitter.DefaultAlgorithm).EnumVar(&c.createSplitter, splitter.SupportedAlgorithms()...)
+	cmd.Flag("object-splitter", "The splitter to use for new objects in the repository, either predefined or DYNAMIC-<avg>-<BUZHASH|RABINKARP>-<min>-<max>").Default(splitter.DefaultAlgorithm).HintOptions(splitter.SupportedAlgorithms()...).StringVar(&c.createSplitter)
+	cmd.Flag("default-compression", "Compression algorithm for objects written without one specified by policy").Default("none").EnumVar(&c.createDefaultCompression, supportedDefaultCompressionAlgorithms()...)
 	cmd.Flag("create-only", "Create repository, but don't connect to it.").Short('c').BoolVar(&c.createOnly)
 	cmd.Flag("format-version", "Force a particular repository format version (1, 2 or 3, 0==default)").IntVar(&c.createFormatVersion)
 	cmd.Flag("retention-mode", "Set the blob retention-mode for supported storage backends.").EnumVar(&c.retentionMode, blob.Governance.String(), blob.Compliance.String())
 	cmd.Flag("retention-period", "Set the blob retention-period for supported storage backends.").DurationVar(&c.retentionPeriod)
+	cmd.Flag("max-blob-size-mb", "Split blobs larger than the given size into multiple chunks, for storage backends that limit object sizes (0 = unlimited).").PlaceHolder("MB").Int64Var(&c.maxBlobSizeMB)
 	//nolint:lll
-	cmd.Flag("format-block-key-derivation-algorithm", "Algorithm to derive the encryption key for the format block from the repository password").Default(format.DefaultKeyDerivationAlgorithm).EnumVar(&c.createBlockKeyDerivationAlgorithm, format.SupportedFormatBlobKeyDerivationAlgorithms()...)
+	cmd.Flag("format-block-key-derivation-algorithm", "Algorithm to derive the encryption key for the format block from the repository password, parameters of scrypt-N-R-P and argon2id-TIME-MEMORY-THREADS can be tuned").Default(format.DefaultKeyDerivationAlgorithm).HintOptions(format.SupportedFormatBlobKeyDerivationAlgorithms()...).StringVar(&c.createBlockKeyDerivationAlgorithm)
+	cmd.Flag("format-block-encryption", "Algorithm used to encrypt the format block").PlaceHolder("ALGO").Default(format.DefaultFormatEncryption).EnumVar(&c.createFormatBlockEncryption, format.SupportedFormatEncryptionAlgorithms()...)
 
 	c.co.setup(svc, cmd)
 	c.svc = svc
@@ -78,6 +93,17 @@ func (c *commandRepositoryCreate) setup(svc advancedAppServices, parent commandP
 }
 
 func (c *commandRepositoryCreate) newRepositoryOptionsFromFlags() *repo.NewRepositoryOptions {
+	switch c.createBlockHashFormat {
+	case fastestBlockHash:
+		c.createBlockHashFormat = cryptobackend.FastestHashAlgorithm()
+	case autoAlgorithm:
+		c.createBlockHashFormat = cryptobackend.HashAlgorithm()
+	}
+
+	if c.createBlockEncryptionFormat == autoAlgorithm {
+		c.createBlockEncryptionFormat = cryptobackend.EncryptionAlgorithm()
+	}
+
 	return &repo.NewRepositoryOptions{
 		BlockFormat: format.ContentFormat{
 			MutableParameters: format.MutableParameters{
@@ -90,12 +116,15 @@ func (c *commandRepositoryCreate) newRepositoryOptionsFromFlags() *repo.NewRepos
 		},
 
 		ObjectFormat: format.ObjectFormat{
-			Splitter: c.createSplitter,
+			Splitter:           c.createSplitter,
+			DefaultCompression: compression.Name(c.createDefaultCompression),
 		},
 
 		RetentionMode:                     blob.RetentionMode(c.retentionMode),
 		RetentionPeriod:                   c.retentionPeriod,
+		MaxBlobSize:                       c.maxBlobSizeMB << 20, //nolint:mnd
 		FormatBlockKeyDerivationAlgorithm: c.createBlockKeyDerivationAlgorithm,
+		FormatBlockEncryptionAlgorithm:    c.createFormatBlockEncryption,
 	}
 }
 
@@ -135,12 +164,14 @@ func (c *commandRepositoryCreate) runCreateCommandWithStorage(ctx context.Contex
 	log(ctx).Infof("  block hash:          %v", options.BlockFormat.Hash)
 	log(ctx).Infof("  encryption:          %v", options.BlockFormat.Encryption)
 	log(ctx).Infof("  key derivation:      %v", options.FormatBlockKeyDerivationAlgorithm)
+	log(ctx).Infof("  format encryption:   %v", options.FormatBlockEncryptionAlgorithm)
 
 	if options.BlockFormat.ECC != "" && options.BlockFormat.ECCOverheadPercent > 0 {
 		log(ctx).Infof("  ecc:                 %v with %v%% overhead", options.BlockFormat.ECC, options.BlockFormat.ECCOverheadPercent)
 	}
 
 	log(ctx).Infof("  splitter:            %v", options.ObjectFormat.Splitter)
+	log(ctx).Infof("  compression:         %v", options.ObjectFormat.DefaultCompression)
 
 	if err := repo.Initialize(ctx, st, options, pass); err != nil {
 		return errors.Wrap(err, "cannot initialize repository")
@@ -194,3 +225,17 @@ func (c *commandRepositoryCreate) populateRepository(ctx context.Context, passwo
 		return nil
 	})
 }
+
+func supportedDefaultCompressionAlgorithms() []string {
+	var res []string
+
+	for name := range compression.ByName {
+		if !compression.IsDeprecated[name] {
+			res = append(res, string(name))
+		}
+	}
+
+	sort.Strings(res)
+
+	return append([]string{"none"}, res...)
+}
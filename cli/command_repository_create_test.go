@@ -6,9 +6,15 @@
 	"strings"
 	"testing"
 
+	"github.com/kopia/kopia/cli"
+	"github.com/kopia/kopia/internal/cryptobackend"
+	"github.com/kopia/kopia/internal/gather"
+	"github.com/kopia/kopia/internal/testutil"
 	"github.com/kopia/kopia/repo"
 	"github.com/kopia/kopia/repo/blob"
 	"github.com/kopia/kopia/repo/blob/filesystem"
+	"github.com/kopia/kopia/repo/encryption"
+	"github.com/kopia/kopia/repo/hashing"
 	"github.com/kopia/kopia/tests/testenv"
 
 	"github.com/stretchr/testify/require"
@@ -61,3 +67,38 @@ func TestRepositoryCreateWithConfigFromStdin(t *testing.T) {
 
 	env.RunAndExpectSuccess(t, "repo", "create", "from-config", "--token-stdin")
 }
+
+func TestRepositoryCreateWithFastestBlockHash(t *testing.T) {
+	env := testenv.NewCLITest(t, nil, testenv.NewInProcRunner(t))
+
+	env.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", env.RepoDir, "--block-hash", "fastest")
+
+	var rs cli.RepositoryStatus
+
+	testutil.MustParseJSONLines(t, env.RunAndExpectSuccess(t, "repo", "status", "--json"), &rs)
+	require.Contains(t, hashing.SupportedAlgorithms(), rs.ContentFormat.Hash)
+
+	hf, err := hashing.CreateHashFunc(&rs.ContentFormat)
+	require.NoError(t, err)
+	require.Len(t, hf(nil, gather.FromSlice([]byte{1, 2, 3})), 16)
+}
+
+func TestRepositoryCreateDefaultAndAutoAlgorithms(t *testing.T) {
+	env := testenv.NewCLITest(t, nil, testenv.NewInProcRunner(t))
+
+	env.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", env.RepoDir)
+
+	var rs cli.RepositoryStatus
+
+	testutil.MustParseJSONLines(t, env.RunAndExpectSuccess(t, "repo", "status", "--json"), &rs)
+	require.Equal(t, hashing.DefaultAlgorithm, rs.ContentFormat.Hash)
+	require.Equal(t, encryption.DefaultAlgorithm, rs.ContentFormat.Encryption)
+
+	env2 := testenv.NewCLITest(t, nil, testenv.NewInProcRunner(t))
+
+	env2.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", env2.RepoDir, "--block-hash", "auto", "--encryption", "auto")
+
+	testutil.MustParseJSONLines(t, env2.RunAndExpectSuccess(t, "repo", "status", "--json"), &rs)
+	require.Equal(t, cryptobackend.HashAlgorithm(), rs.ContentFormat.Hash)
+	require.Equal(t, cryptobackend.EncryptionAlgorithm(), rs.ContentFormat.Encryption)
+}
@@ -0,0 +1,17 @@
+package cli
+
+type commandRepositoryKeySlot struct {
+	add         commandRepositoryKeySlotAdd
+	list        commandRepositoryKeySlotList
+	remove      commandRepositoryKeySlotRemove
+	setPassword commandRepositoryKeySlotSetPassword
+}
+
+func (c *commandRepositoryKeySlot) setup(svc advancedAppServices, parent commandParent) {
+	cmd := parent.Command("key-slot", "Commands to manage additional passwords that can open the repository")
+
+	c.add.setup(svc, cmd)
+	c.list.setup(svc, cmd)
+	c.remove.setup(svc, cmd)
+	c.setPassword.setup(svc, cmd)
+}
@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+)
+
+type commandRepositoryKeySlotAdd struct {
+	name        string
+	newPassword string
+
+	svc advancedAppServices
+}
+
+func (c *commandRepositoryKeySlotAdd) setup(svc advancedAppServices, parent commandParent) {
+	cmd := parent.Command("add", "Add a key slot that allows the repository to be opened with a separate password")
+	cmd.Arg("name", "Key slot name").Required().StringVar(&c.name)
+	cmd.Flag("new-password", "Password for the key slot").Envar(svc.EnvName("KOPIA_NEW_PASSWORD")).StringVar(&c.newPassword)
+
+	c.svc = svc
+	cmd.Action(svc.directRepositoryWriteAction(c.run))
+}
+
+func (c *commandRepositoryKeySlotAdd) run(ctx context.Context, rep repo.DirectRepositoryWriter) error {
+	newPass := c.newPassword
+
+	if newPass == "" {
+		n, err := askForChangedRepositoryPassword(c.svc)
+		if err != nil {
+			return err
+		}
+
+		newPass = n
+	}
+
+	if err := rep.FormatManager().AddKeySlot(ctx, c.name, newPass); err != nil {
+		return errors.Wrap(err, "unable to add key slot")
+	}
+
+	log(ctx).Infof("Added key slot %q.", c.name)
+
+	return nil
+}
@@ -0,0 +1,26 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/kopia/kopia/repo"
+)
+
+type commandRepositoryKeySlotList struct {
+	out textOutput
+}
+
+func (c *commandRepositoryKeySlotList) setup(svc advancedAppServices, parent commandParent) {
+	cmd := parent.Command("list", "List key slots").Alias("ls")
+
+	c.out.setup(svc)
+	cmd.Action(svc.directRepositoryReadAction(c.run))
+}
+
+func (c *commandRepositoryKeySlotList) run(ctx context.Context, rep repo.DirectRepository) error {
+	for _, name := range rep.FormatManager().KeySlotNames() {
+		c.out.printStdout("%v\n", name)
+	}
+
+	return nil
+}
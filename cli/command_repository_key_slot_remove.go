@@ -0,0 +1,31 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+)
+
+type commandRepositoryKeySlotRemove struct {
+	name string
+}
+
+func (c *commandRepositoryKeySlotRemove) setup(svc advancedAppServices, parent commandParent) {
+	cmd := parent.Command("remove", "Remove a key slot").Alias("rm").Alias("delete")
+	cmd.Arg("name", "Key slot name").Required().StringVar(&c.name)
+
+	cmd.Action(svc.directRepositoryWriteAction(c.run))
+}
+
+func (c *commandRepositoryKeySlotRemove) run(ctx context.Context, rep repo.DirectRepositoryWriter) error {
+	if err := rep.FormatManager().RemoveKeySlot(ctx, c.name); err != nil {
+		return errors.Wrap(err, "unable to remove key slot")
+	}
+
+	log(ctx).Infof("Removed key slot %q.", c.name)
+	log(ctx).Warnf("The password of the removed key slot can no longer open the repository, but encryption keys are not rotated. Anyone who previously had access may have retained the keys.")
+
+	return nil
+}
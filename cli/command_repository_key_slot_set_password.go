@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+)
+
+type commandRepositoryKeySlotSetPassword struct {
+	name        string
+	oldPassword string
+	newPassword string
+
+	svc advancedAppServices
+}
+
+func (c *commandRepositoryKeySlotSetPassword) setup(svc advancedAppServices, parent commandParent) {
+	cmd := parent.Command("set-password", "Change the password of a key slot")
+	cmd.Arg("name", "Key slot name").Required().StringVar(&c.name)
+	cmd.Flag("old-password", "Current password of the key slot").Envar(svc.EnvName("KOPIA_OLD_PASSWORD")).StringVar(&c.oldPassword)
+	cmd.Flag("new-password", "New password for the key slot").Envar(svc.EnvName("KOPIA_NEW_PASSWORD")).StringVar(&c.newPassword)
+
+	c.svc = svc
+	cmd.Action(svc.directRepositoryWriteAction(c.run))
+}
+
+func (c *commandRepositoryKeySlotSetPassword) run(ctx context.Context, rep repo.DirectRepositoryWriter) error {
+	oldPass := c.oldPassword
+
+	if oldPass == "" {
+		p, err := c.svc.askPass(c.svc.stdout(), "Enter current password of the key slot: ")
+		if err != nil {
+			return errors.Wrap(err, "password entry")
+		}
+
+		oldPass = p
+	}
+
+	newPass := c.newPassword
+
+	if newPass == "" {
+		n, err := askForChangedRepositoryPassword(c.svc)
+		if err != nil {
+			return err
+		}
+
+		newPass = n
+	}
+
+	if err := rep.FormatManager().ChangeKeySlotPassword(ctx, c.name, oldPass, newPass); err != nil {
+		return errors.Wrap(err, "unable to change key slot password")
+	}
+
+	log(ctx).Infof("Changed password of key slot %q.", c.name)
+
+	return nil
+}
@@ -19,6 +19,7 @@ type commandRepositorySetClient struct {
 
 	formatBlobCacheDuration time.Duration
 	disableFormatBlobCache  bool
+	listParallelism         int
 
 	svc appServices
 }
@@ -34,6 +35,7 @@ func (c *commandRepositorySetClient) setup(svc appServices, parent commandParent
 	cmd.Flag("hostname", "Change hostname").StringsVar(&c.repoClientOptionsHostname)
 	cmd.Flag("repository-format-cache-duration", "Duration of kopia.repository format blob cache").DurationVar(&c.formatBlobCacheDuration)
 	cmd.Flag("disable-repository-format-cache", "Disable caching of kopia.repository format blob").BoolVar(&c.disableFormatBlobCache)
+	cmd.Flag("blob-list-parallelism", "List pack and index blobs over hexadecimal prefixes in parallel (1 disables)").IntVar(&c.listParallelism)
 	cmd.Action(svc.repositoryReaderAction(c.run))
 
 	c.svc = svc
@@ -112,6 +114,13 @@ func (c *commandRepositorySetClient) run(ctx context.Context, rep repo.Repositor
 		log(ctx).Info("Disabling format blob cache")
 	}
 
+	if v := c.listParallelism; v != 0 {
+		opt.ListParallelism = v
+		anyChange = true
+
+		log(ctx).Infof("Setting list parallelism to %v", v)
+	}
+
 	if !anyChange {
 		return errors.Errorf("no changes")
 	}
@@ -20,6 +20,7 @@ type commandRepositorySetParameters struct {
 	indexFormatVersion int
 	retentionMode      string
 	retentionPeriod    time.Duration
+	maxBlobSizeMB      int64
 
 	epochRefreshFrequency    time.Duration
 	epochMinDuration         time.Duration
@@ -45,6 +46,7 @@ func (c *commandRepositorySetParameters) setup(svc appServices, parent commandPa
 	cmd.Flag("index-version", "Set version of index format used for writing").IntVar(&c.indexFormatVersion)
 	cmd.Flag("retention-mode", "Set the blob retention-mode for supported storage backends.").EnumVar(&c.retentionMode, "none", blob.Governance.String(), blob.Compliance.String())
 	cmd.Flag("retention-period", "Set the blob retention-period for supported storage backends.").DurationVar(&c.retentionPeriod)
+	cmd.Flag("max-blob-size-mb", "Split blobs larger than the given size into multiple chunks. Once enabled, splitting can't be disabled.").PlaceHolder("MB").Int64Var(&c.maxBlobSizeMB)
 
 	cmd.Flag("upgrade", "Upgrade repository to the latest stable format").BoolVar(&c.upgradeRepositoryFormat)
 
@@ -217,6 +219,8 @@ func (c *commandRepositorySetParameters) run(ctx context.Context, rep repo.Direc
 		c.setDurationParameter(ctx, c.retentionPeriod, "storage backend blob retention period", &blobcfg.RetentionPeriod, &anyChange)
 	}
 
+	c.setInt64SizeMBParameter(ctx, c.maxBlobSizeMB, "maximum blob size", &blobcfg.MaxBlobSize, &anyChange)
+
 	c.setDurationParameter(ctx, c.epochMinDuration, "minimum epoch duration", &mp.EpochParameters.MinEpochDuration, &anyChange)
 	c.setDurationParameter(ctx, c.epochRefreshFrequency, "epoch refresh frequency", &mp.EpochParameters.EpochRefreshFrequency, &anyChange)
 	c.setDurationParameter(ctx, c.epochCleanupSafetyMargin, "epoch cleanup safety margin", &mp.EpochParameters.CleanupSafetyMargin, &anyChange)
@@ -226,6 +230,7 @@ func (c *commandRepositorySetParameters) run(ctx context.Context, rep repo.Direc
 	c.setIntParameter(ctx, c.epochCheckpointFrequency, "epoch checkpoint frequency", &mp.EpochParameters.FullCheckpointFrequency, &anyChange)
 
 	requiredFeatures = c.addRemoveUpdateRequiredFeatures(requiredFeatures, &anyChange)
+	requiredFeatures = blobcfg.WithRequiredFeatures(requiredFeatures)
 
 	if !anyChange {
 		log(ctx).Info("no changes")
@@ -52,6 +52,22 @@ func (s *formatSpecificTestSuite) TestRepositorySetParameters(t *testing.T) {
 	require.Contains(t, out, "Max pack length:     46.1 MB")
 }
 
+func (s *formatSpecificTestSuite) TestRepositorySetParametersMaxBlobSize(t *testing.T) {
+	env := s.setupInMemoryRepo(t)
+
+	out := env.RunAndExpectSuccess(t, "repository", "status")
+	require.NotContains(t, out, "Max blob size:")
+
+	env.RunAndExpectFailure(t, "repository", "set-parameters", "--max-blob-size-mb=-1")
+
+	env.RunAndExpectSuccess(t, "repository", "set-parameters", "--max-blob-size-mb=5")
+	out = env.RunAndExpectSuccess(t, "repository", "status")
+	require.Contains(t, out, "Max blob size:           5.2 MB")
+	require.Contains(t, out, "Required Features:   split-blobs")
+
+	env.RunAndExpectSuccess(t, "snapshot", "create", env.RepoDir)
+}
+
 func (s *formatSpecificTestSuite) TestRepositorySetParametersRetention(t *testing.T) {
 	env := s.setupInMemoryRepo(t)
 
@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/units"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/snapshot/snapshotreport"
+)
+
+type commandRepositoryStats struct {
+	snapshotSizes bool
+	raw           bool
+
+	jo  jsonOutput
+	out textOutput
+}
+
+func (c *commandRepositoryStats) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("stats", "Display repository storage usage statistics.")
+	cmd.Flag("snapshot-sizes", "Compute the amount of data attributable to each snapshot (slow)").BoolVar(&c.snapshotSizes)
+	cmd.Flag("raw", "Raw numbers").Short('r').BoolVar(&c.raw)
+	cmd.Action(svc.directRepositoryReadAction(c.run))
+
+	c.jo.setup(svc, cmd)
+	c.out.setup(svc)
+}
+
+func (c *commandRepositoryStats) run(ctx context.Context, rep repo.DirectRepository) error {
+	st, err := snapshotreport.ComputeRepositoryStats(ctx, rep, snapshotreport.RepositoryStatsOptions{
+		SnapshotSizes: c.snapshotSizes,
+	})
+	if err != nil {
+		return errors.Wrap(err, "unable to compute repository statistics")
+	}
+
+	if c.jo.jsonOutput {
+		c.out.printStdout("%s\n", c.jo.jsonBytes(st))
+
+		return nil
+	}
+
+	sizeToString := units.BytesString
+	if c.raw {
+		sizeToString = func(l int64) string {
+			return strconv.FormatInt(l, 10)
+		}
+	}
+
+	c.out.printStdout("Blobs:               %v (%v)\n", st.BlobCount, sizeToString(st.BlobBytes))
+	c.out.printStdout("Unique contents:     %v (%v, stored as %v)\n", st.ContentCount, sizeToString(st.UniqueBytes), sizeToString(st.StoredContentBytes))
+	c.out.printStdout("Snapshots:           %v (%v total file size)\n", st.SnapshotCount, sizeToString(st.LogicalBytes))
+	c.out.printStdout("Deduplication ratio: %.2f\n", st.DeduplicationRatio)
+	c.out.printStdout("Compression ratio:   %.2f\n", st.CompressionRatio)
+
+	c.out.printStdout("\nContent size histogram:\n\n")
+
+	for _, b := range st.ContentSizeHistogram {
+		c.out.printStdout("%9v between %v and %v (total %v)\n",
+			b.Count,
+			sizeToString(b.MinSize),
+			sizeToString(b.MaxSize),
+			sizeToString(b.TotalSize),
+		)
+	}
+
+	if len(st.Growth) > 0 {
+		c.out.printStdout("\nGrowth by month:\n\n")
+
+		for _, g := range st.Growth {
+			c.out.printStdout("  %v  added %10v  total %10v\n", g.Month.Format("2006-01"), sizeToString(g.AddedBytes), sizeToString(g.TotalBytes))
+		}
+	}
+
+	if len(st.Snapshots) > 0 {
+		c.out.printStdout("\nSnapshot sizes:\n\n")
+
+		for _, s := range st.Snapshots {
+			c.out.printStdout("  %v %v  size %v  new data %v (stored %v)\n",
+				s.Source,
+				formatTimestamp(s.StartTime),
+				sizeToString(s.LogicalBytes),
+				sizeToString(s.NewOriginalBytes),
+				sizeToString(s.NewStoredBytes),
+			)
+		}
+	}
+
+	return nil
+}
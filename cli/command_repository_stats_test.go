@@ -0,0 +1,62 @@
+package cli_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/snapshot/snapshotreport"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestRepositoryStats(t *testing.T) {
+	t.Parallel()
+
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	srcdir := testutil.TempDirectory(t)
+
+	require.NoError(t, os.WriteFile(filepath.Join(srcdir, "file1"), []byte{1, 2, 3}, 0o600))
+	e.RunAndExpectSuccess(t, "snapshot", "create", srcdir)
+
+	require.NoError(t, os.WriteFile(filepath.Join(srcdir, "file2"), []byte{4, 5, 6, 7}, 0o600))
+	e.RunAndExpectSuccess(t, "snapshot", "create", srcdir)
+
+	var st snapshotreport.RepositoryStats
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "repo", "stats", "--json", "--snapshot-sizes"), &st)
+
+	require.Positive(t, st.BlobCount)
+	require.Positive(t, st.BlobBytes)
+	require.Positive(t, st.ContentCount)
+	require.Positive(t, st.UniqueBytes)
+	require.Positive(t, st.StoredContentBytes)
+	require.Equal(t, 2, st.SnapshotCount)
+	require.EqualValues(t, 3+7, st.LogicalBytes)
+	require.NotEmpty(t, st.Growth)
+	require.Equal(t, st.StoredContentBytes, st.Growth[len(st.Growth)-1].TotalBytes)
+	require.Len(t, st.Snapshots, 2)
+
+	var histogramCount int64
+
+	for _, b := range st.ContentSizeHistogram {
+		histogramCount += b.Count
+	}
+
+	require.Equal(t, st.ContentCount, histogramCount)
+
+	// snapshot sizes are only computed on request.
+	var st2 snapshotreport.RepositoryStats
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "repo", "stats", "--json"), &st2)
+	require.Equal(t, st.ContentCount, st2.ContentCount)
+	require.Empty(t, st2.Snapshots)
+
+	e.RunAndExpectSuccess(t, "repo", "stats", "--snapshot-sizes")
+}
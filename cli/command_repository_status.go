@@ -11,6 +11,7 @@
 
 	"github.com/pkg/errors"
 
+	"github.com/kopia/kopia/internal/cryptobackend"
 	"github.com/kopia/kopia/internal/scrubber"
 	"github.com/kopia/kopia/internal/units"
 	"github.com/kopia/kopia/repo"
@@ -120,12 +121,36 @@ func (c *commandRepositoryStatus) dumpUpgradeStatus(ctx context.Context, dr repo
 	return nil
 }
 
+func (c *commandRepositoryStatus) dumpCacheStatus(ctx context.Context) {
+	opts, err := repo.GetCachingOptions(ctx, c.svc.repositoryConfigFileName())
+	if err != nil || opts.CacheDirectory == "" {
+		c.out.printStdout("Cache directory:     none\n")
+		return
+	}
+
+	c.out.printStdout("Cache directory:     %v\n", opts.CacheDirectory)
+
+	fileCount, totalFileSize, err := scanCacheDir(opts.CacheDirectory)
+	if err != nil {
+		c.out.printStdout("Cache usage:         unknown (%v)\n", err)
+		return
+	}
+
+	c.out.printStdout("Cache usage:         %v files %v\n", fileCount, units.BytesString(totalFileSize))
+}
+
 func (c *commandRepositoryStatus) dumpRetentionStatus(ctx context.Context, dr repo.DirectRepository) {
-	if blobcfg, _ := dr.FormatManager().BlobCfgBlob(ctx); blobcfg.IsRetentionEnabled() {
+	blobcfg, _ := dr.FormatManager().BlobCfgBlob(ctx)
+
+	if blobcfg.IsRetentionEnabled() {
 		c.out.printStdout("\n")
 		c.out.printStdout("Blob retention mode:     %s\n", blobcfg.RetentionMode)
 		c.out.printStdout("Blob retention period:   %s\n", blobcfg.RetentionPeriod)
 	}
+
+	if blobcfg.MaxBlobSize > 0 {
+		c.out.printStdout("Max blob size:           %v\n", units.BytesString(blobcfg.MaxBlobSize))
+	}
 }
 
 //nolint:funlen,gocyclo
@@ -148,6 +173,8 @@ func (c *commandRepositoryStatus) run(ctx context.Context, rep repo.Repository)
 		c.out.printStdout("Format blob cache:   disabled\n")
 	}
 
+	c.dumpCacheStatus(ctx)
+
 	dr, isDr := rep.(repo.DirectRepository)
 	if !isDr {
 		return nil
@@ -161,6 +188,7 @@ func (c *commandRepositoryStatus) run(ctx context.Context, rep repo.Repository)
 	switch cp, err := dr.BlobVolume().GetCapacity(ctx); {
 	case err == nil:
 		c.out.printStdout("Storage capacity:    %v\n", units.BytesString(int64(cp.SizeB)))
+		c.out.printStdout("Storage used:        %v\n", units.BytesString(int64(cp.UsedB())))
 		c.out.printStdout("Storage available:   %v\n", units.BytesString(int64(cp.FreeB)))
 	case errors.Is(err, blob.ErrNotAVolume):
 		c.out.printStdout("Storage capacity:    unbounded\n")
@@ -183,6 +211,9 @@ func (c *commandRepositoryStatus) run(ctx context.Context, rep repo.Repository)
 	c.out.printStdout("Unique ID:           %x\n", dr.UniqueID())
 	c.out.printStdout("Hash:                %v\n", contentFormat.GetHashFunction())
 	c.out.printStdout("Encryption:          %v\n", contentFormat.GetEncryptionAlgorithm())
+
+	cb := cryptobackend.Detect()
+	c.out.printStdout("Hardware crypto:     AES=%v SHA-256=%v (%v, auto selects %v, %v)\n", cb.HardwareAES, cb.HardwareSHA256, cb.Architecture, cb.HashAlgorithm, cb.EncryptionAlgorithm)
 	c.out.printStdout("Splitter:            %v\n", dr.ObjectFormat().Splitter)
 	c.out.printStdout("Format version:      %v\n", mp.Version)
 	c.out.printStdout("Content compression: %v\n", mp.IndexVersion >= index.Version2)
@@ -2,8 +2,10 @@
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
+	"os"
 	"strings"
 	"sync"
 	"time"
@@ -18,6 +20,7 @@
 	"github.com/kopia/kopia/internal/units"
 	"github.com/kopia/kopia/repo"
 	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/blob/plugin"
 	"github.com/kopia/kopia/repo/format"
 )
 
@@ -30,10 +33,15 @@ type commandRepositorySyncTo struct {
 	repositorySyncParallelism          int
 	repositorySyncDestinationMustExist bool
 	repositorySyncTimes                bool
+	repositorySyncWatchInterval        time.Duration
+	repositorySyncReplicaTokenFiles    []string
+	repositorySyncCompareContents      bool
+	repositorySyncAllowReplicaPlugins  bool
 
 	lastSyncProgress  string
 	syncProgressMutex sync.Mutex
 
+	svc advancedAppServices
 	out textOutput
 }
 
@@ -45,8 +53,13 @@ func (c *commandRepositorySyncTo) setup(svc advancedAppServices, parent commandP
 	cmd.Flag("parallel", "Copy parallelism.").Default("1").IntVar(&c.repositorySyncParallelism)
 	cmd.Flag("must-exist", "Fail if destination does not have repository format blob.").BoolVar(&c.repositorySyncDestinationMustExist)
 	cmd.Flag("times", "Synchronize blob times if supported.").BoolVar(&c.repositorySyncTimes)
+	cmd.Flag("watch-interval", "Keep running and synchronize repeatedly at the provided interval until interrupted.").DurationVar(&c.repositorySyncWatchInterval)
+	cmd.Flag("replica-token-file", "Path to the configuration token file of an additional destination to synchronize (can be repeated).").StringsVar(&c.repositorySyncReplicaTokenFiles)
+	cmd.Flag("compare-contents", "In watch mode, compare the contents of destination BLOBs which are not older than the source instead of only their lengths.").BoolVar(&c.repositorySyncCompareContents)
+	cmd.Flag("allow-replica-token-plugin-executable", "Allow replica token files to specify the storage plugin executable and environment.").BoolVar(&c.repositorySyncAllowReplicaPlugins)
 
 	c.out.setup(svc)
+	c.svc = svc
 
 	for _, prov := range svc.storageProviders() {
 		// Set up 'sync-to' subcommand
@@ -72,7 +85,30 @@ func (c *commandRepositorySyncTo) setup(svc advancedAppServices, parent commandP
 					return errors.Errorf("sync only supports directly-connected repositories")
 				}
 
-				return c.runSyncWithStorage(ctx, dr.BlobReader(), st)
+				dsts, err := c.openReplicas(ctx)
+				if err != nil {
+					return err
+				}
+
+				dsts = append([]blob.Storage{st}, dsts...)
+
+				defer func() {
+					for _, dst := range dsts {
+						dst.Close(ctx) //nolint:errcheck
+					}
+				}()
+
+				if c.repositorySyncWatchInterval > 0 {
+					return c.runSyncContinuously(ctx, dr.BlobReader(), dsts)
+				}
+
+				for _, dst := range dsts {
+					if _, err := c.runSyncWithStorage(ctx, dr.BlobReader(), dst, nil); err != nil {
+						return err
+					}
+				}
+
+				return nil
 			})
 		})
 	}
@@ -80,7 +116,138 @@ func (c *commandRepositorySyncTo) setup(svc advancedAppServices, parent commandP
 
 const syncProgressInterval = 300 * time.Millisecond
 
-func (c *commandRepositorySyncTo) runSyncWithStorage(ctx context.Context, src blob.Reader, dst blob.Storage) error {
+var (
+	errSyncDifferentRepository = errors.New("destination repository contains incompatible data")
+	errSyncConflict            = errors.New("destination contains conflicting BLOBs")
+)
+
+// syncLag describes how far behind the destination was compared to the source.
+// syncBlobPair holds the metadata of the same BLOB in the source and destination.
+type syncBlobPair struct {
+	src blob.Metadata
+	dst blob.Metadata
+}
+
+type syncLag struct {
+	blobs int
+	bytes int64
+}
+
+// syncVerifiedBlobs tracks destination BLOBs known to have the same contents as the source,
+// keyed by BLOB ID. An entry is only trusted as long as the destination metadata is unchanged.
+type syncVerifiedBlobs struct {
+	mu sync.Mutex
+	// +checklocks:mu
+	blobs map[blob.ID]blob.Metadata
+}
+
+func (v *syncVerifiedBlobs) isVerified(dstmd blob.Metadata) bool {
+	if v == nil {
+		return false
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	m, ok := v.blobs[dstmd.BlobID]
+
+	return ok && m.Length == dstmd.Length && m.Timestamp.Equal(dstmd.Timestamp)
+}
+
+func (v *syncVerifiedBlobs) add(dstmd blob.Metadata) {
+	if v == nil {
+		return
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.blobs[dstmd.BlobID] = dstmd
+}
+
+func (c *commandRepositorySyncTo) openReplicas(ctx context.Context) ([]blob.Storage, error) {
+	var result []blob.Storage
+
+	for _, fname := range c.repositorySyncReplicaTokenFiles {
+		tokenData, err := os.ReadFile(fname) //nolint:gosec
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to open replica token file")
+		}
+
+		ci, _, err := repo.DecodeToken(strings.TrimSpace(string(tokenData)))
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid replica token in %v", fname)
+		}
+
+		if !c.repositorySyncAllowReplicaPlugins {
+			if err := plugin.VerifyTokenConnectionInfo(ci); err != nil {
+				return nil, errors.Wrapf(err, "replica token in %v", fname)
+			}
+		}
+
+		st, err := blob.NewStorage(ctx, ci, false)
+		if err != nil {
+			return nil, errors.Wrapf(err, "can't connect to replica storage in %v", fname)
+		}
+
+		result = append(result, st)
+	}
+
+	return result, nil
+}
+
+// runSyncContinuously keeps synchronizing all destinations at regular intervals until interrupted,
+// reporting how far each destination was behind the source on each pass. Failed passes are
+// logged and retried on the next interval, except when a destination holds a different repository.
+//
+// Destination BLOBs which are not older than the source are compared by length, or by contents
+// with --compare-contents. Each BLOB is only compared once as long as its metadata does not change.
+func (c *commandRepositorySyncTo) runSyncContinuously(ctx context.Context, src blob.Reader, dsts []blob.Storage) error {
+	stop := make(chan struct{})
+	c.svc.onTerminate(func() { close(stop) })
+
+	verified := make([]*syncVerifiedBlobs, len(dsts))
+	for i := range verified {
+		verified[i] = &syncVerifiedBlobs{blobs: map[blob.ID]blob.Metadata{}}
+	}
+
+	for pass := 1; ; pass++ {
+		for i, dst := range dsts {
+			lag, err := c.runSyncWithStorage(ctx, src, dst, verified[i])
+
+			switch {
+			case errors.Is(err, errSyncDifferentRepository):
+				return errors.Wrap(err, dst.DisplayName())
+
+			case errors.Is(err, errSyncConflict):
+				log(ctx).Warnf("Synchronization pass %v of %v finished with conflicts: %v", pass, dst.DisplayName(), err)
+
+			case err != nil:
+				log(ctx).Errorf("Synchronization pass %v of %v failed: %v", pass, dst.DisplayName(), err)
+
+			default:
+				log(ctx).Infof("Synchronization pass %v of %v finished, destination was behind by %v BLOBs (%v).", pass, dst.DisplayName(), lag.blobs, units.BytesString(lag.bytes))
+			}
+		}
+
+		log(ctx).Infof("Next synchronization in %v.", c.repositorySyncWatchInterval)
+
+		select {
+		case <-stop:
+			log(ctx).Info("Synchronization stopped.")
+			return nil
+
+		case <-ctx.Done():
+			return errors.Wrap(ctx.Err(), "synchronization canceled")
+
+		case <-time.After(c.repositorySyncWatchInterval):
+		}
+	}
+}
+
+// runSyncWithStorage performs a single synchronization pass. When verified is not nil, destination BLOBs
+// which are not older than the source are compared with the source and reported as conflicts if different.
+func (c *commandRepositorySyncTo) runSyncWithStorage(ctx context.Context, src blob.Reader, dst blob.Storage, verified *syncVerifiedBlobs) (syncLag, error) {
 	log(ctx).Info("Synchronizing repositories:")
 	log(ctx).Infof("  Source:      %v", src.DisplayName())
 	log(ctx).Infof("  Destination: %v", dst.DisplayName())
@@ -90,7 +257,7 @@ func (c *commandRepositorySyncTo) runSyncWithStorage(ctx context.Context, src bl
 	}
 
 	if err := c.ensureRepositoriesHaveSameFormatBlob(ctx, src, dst); err != nil {
-		return err
+		return syncLag{}, err
 	}
 
 	log(ctx).Info("Looking for BLOBs to synchronize...")
@@ -107,11 +274,13 @@ func (c *commandRepositorySyncTo) runSyncWithStorage(ctx context.Context, src bl
 
 		srcBlobs     int
 		totalSrcSize int64
+
+		blobsToCompare []syncBlobPair
 	)
 
 	dstMetadata, err := c.listDestinationBlobs(ctx, dst)
 	if err != nil {
-		return err
+		return syncLag{}, err
 	}
 
 	c.beginSyncProgress()
@@ -129,6 +298,10 @@ func (c *commandRepositorySyncTo) runSyncWithStorage(ctx context.Context, src bl
 		case srcmd.Timestamp.After(dstmd.Timestamp) && c.repositorySyncUpdate:
 			blobsToCopy = append(blobsToCopy, srcmd)
 			totalCopyBytes += srcmd.Length
+		case verified != nil && !srcmd.Timestamp.After(dstmd.Timestamp) && !verified.isVerified(dstmd):
+			// destination has a version of the blob that is not older than the source and
+			// was not verified before, it may have been written to the destination directly.
+			blobsToCompare = append(blobsToCompare, syncBlobPair{srcmd, dstmd})
 		default:
 			inSyncBlobs++
 			inSyncBytes += srcmd.Length
@@ -139,11 +312,23 @@ func (c *commandRepositorySyncTo) runSyncWithStorage(ctx context.Context, src bl
 
 		return nil
 	}); err != nil {
-		return errors.Wrap(err, "error listing blobs")
+		return syncLag{}, errors.Wrap(err, "error listing blobs")
 	}
 
 	c.finishSyncProcess()
 
+	conflictingBlobs, err := c.compareSyncedBlobs(ctx, src, dst, blobsToCompare, verified)
+	if err != nil {
+		return syncLag{}, err
+	}
+
+	for _, p := range blobsToCompare {
+		if verified.isVerified(p.dst) {
+			inSyncBlobs++
+			inSyncBytes += p.dst.Length
+		}
+	}
+
 	if c.repositorySyncDelete {
 		for _, dstmd := range dstMetadata {
 			// found in dst, not in src since we were deleting from dst as we found a match.
@@ -158,19 +343,93 @@ func (c *commandRepositorySyncTo) runSyncWithStorage(ctx context.Context, src bl
 		inSyncBlobs, units.BytesString(inSyncBytes),
 	)
 
+	if conflictingBlobs > 0 {
+		log(ctx).Warnf("  Found %v conflicting BLOBs", conflictingBlobs)
+	}
+
+	lag := syncLag{len(blobsToCopy), totalCopyBytes}
+
 	if c.repositorySyncDryRun {
-		return nil
+		return lag, nil
 	}
 
 	log(ctx).Info("Copying...")
 
 	c.beginSyncProgress()
 
-	finalErr := c.runSyncBlobs(ctx, src, dst, blobsToCopy, blobsToDelete, totalCopyBytes)
+	finalErr := c.runSyncBlobs(ctx, src, dst, blobsToCopy, blobsToDelete, totalCopyBytes, verified)
 
 	c.finishSyncProcess()
 
-	return finalErr
+	if finalErr == nil && conflictingBlobs > 0 {
+		finalErr = errors.Wrapf(errSyncConflict, "%v BLOBs differ", conflictingBlobs)
+	}
+
+	return lag, finalErr
+}
+
+// compareSyncedBlobs compares the provided destination BLOBs with the source and returns the number
+// of BLOBs that differ. BLOBs are compared using the listed lengths and only downloaded from both sides
+// when --compare-contents is set. Identical BLOBs are marked as verified.
+func (c *commandRepositorySyncTo) compareSyncedBlobs(ctx context.Context, src blob.Reader, dst blob.Storage, pairs []syncBlobPair, verified *syncVerifiedBlobs) (int, error) {
+	conflicting := 0
+
+	for _, p := range pairs {
+		dstmd := p.dst
+
+		if p.src.Length != dstmd.Length {
+			conflicting++
+
+			log(ctx).Warnf("BLOB %v differs between source and destination (%v vs %v bytes) but destination is not older, not overwriting.", dstmd.BlobID, p.src.Length, dstmd.Length)
+
+			continue
+		}
+
+		if !c.repositorySyncCompareContents {
+			verified.add(dstmd)
+			continue
+		}
+
+		srcHash, err := syncBlobHash(ctx, src, dstmd.BlobID)
+		if err != nil {
+			return 0, errors.Wrap(err, "error reading source")
+		}
+
+		dstHash, err := syncBlobHash(ctx, dst, dstmd.BlobID)
+		if err != nil {
+			return 0, errors.Wrap(err, "error reading destination")
+		}
+
+		if srcHash != dstHash {
+			conflicting++
+
+			log(ctx).Warnf("BLOB %v differs between source and destination but destination is not older, not overwriting.", dstmd.BlobID)
+
+			continue
+		}
+
+		verified.add(dstmd)
+	}
+
+	return conflicting, nil
+}
+
+func syncBlobHash(ctx context.Context, st blob.Reader, id blob.ID) ([sha256.Size]byte, error) {
+	var data gather.WriteBuffer
+	defer data.Close()
+
+	if err := st.GetBlob(ctx, id, 0, -1, &data); err != nil {
+		return [sha256.Size]byte{}, errors.Wrapf(err, "error reading blob '%v'", id)
+	}
+
+	h := sha256.New()
+	data.Bytes().WriteTo(h) //nolint:errcheck
+
+	var result [sha256.Size]byte
+
+	copy(result[:], h.Sum(nil))
+
+	return result, nil
 }
 
 func (c *commandRepositorySyncTo) listDestinationBlobs(ctx context.Context, dst blob.Storage) (map[blob.ID]blob.Metadata, error) {
@@ -218,7 +477,7 @@ func (c *commandRepositorySyncTo) finishSyncProcess() {
 	c.out.printStderr("\r%v\n", c.lastSyncProgress)
 }
 
-func (c *commandRepositorySyncTo) runSyncBlobs(ctx context.Context, src blob.Reader, dst blob.Storage, blobsToCopy, blobsToDelete []blob.Metadata, totalBytes int64) error {
+func (c *commandRepositorySyncTo) runSyncBlobs(ctx context.Context, src blob.Reader, dst blob.Storage, blobsToCopy, blobsToDelete []blob.Metadata, totalBytes int64, verified *syncVerifiedBlobs) error {
 	eg, ctx := errgroup.WithContext(ctx)
 	copyCh := sliceToChannel(ctx, blobsToCopy)
 	deleteCh := sliceToChannel(ctx, blobsToDelete)
@@ -234,7 +493,7 @@ func (c *commandRepositorySyncTo) runSyncBlobs(ctx context.Context, src blob.Rea
 			for m := range copyCh {
 				log(ctx).Debugf("[%v] Copying %v (%v bytes)...\n", workerID, m.BlobID, m.Length)
 
-				if err := c.syncCopyBlob(ctx, m, src, dst); err != nil {
+				if err := c.syncCopyBlob(ctx, m, src, dst, verified); err != nil {
 					return errors.Wrapf(err, "error copying %v", m.BlobID)
 				}
 
@@ -293,7 +552,7 @@ func sliceToChannel(ctx context.Context, md []blob.Metadata) chan blob.Metadata
 	return ch
 }
 
-func (c *commandRepositorySyncTo) syncCopyBlob(ctx context.Context, m blob.Metadata, src blob.Reader, dst blob.Storage) error {
+func (c *commandRepositorySyncTo) syncCopyBlob(ctx context.Context, m blob.Metadata, src blob.Reader, dst blob.Storage, verified *syncVerifiedBlobs) error {
 	var data gather.WriteBuffer
 	defer data.Close()
 
@@ -306,7 +565,9 @@ func (c *commandRepositorySyncTo) syncCopyBlob(ctx context.Context, m blob.Metad
 		return errors.Wrapf(err, "error reading blob '%v' from source", m.BlobID)
 	}
 
-	opt := blob.PutOptions{}
+	var modTime time.Time
+
+	opt := blob.PutOptions{GetModTime: &modTime}
 	if c.repositorySyncTimes {
 		opt.SetModTime = m.Timestamp
 	}
@@ -328,6 +589,10 @@ func (c *commandRepositorySyncTo) syncCopyBlob(ctx context.Context, m blob.Metad
 		}
 	}
 
+	if !modTime.IsZero() {
+		verified.add(blob.Metadata{BlobID: m.BlobID, Length: int64(data.Length()), Timestamp: modTime})
+	}
+
 	return nil
 }
 
@@ -379,7 +644,7 @@ func (c *commandRepositorySyncTo) ensureRepositoriesHaveSameFormatBlob(ctx conte
 		return nil
 	}
 
-	return errors.Errorf("destination repository contains incompatible data")
+	return errSyncDifferentRepository
 }
 
 func parseUniqueID(r gather.Bytes) (string, error) {
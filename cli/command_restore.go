@@ -105,6 +105,7 @@ type commandRestore struct {
 	restoreTargetPaths            []string
 	restoreOverwriteDirectories   bool
 	restoreOverwriteFiles         bool
+	restoreRenameExistingFiles    bool
 	restoreOverwriteSymlinks      bool
 	restoreWriteSparseFiles       bool
 	restoreConsistentAttributes   bool
@@ -120,6 +121,8 @@ type commandRestore struct {
 	restoreShallowAtDepth         int32
 	minSizeForPlaceholder         int32
 	snapshotTime                  string
+	restoreInclude                []string
+	restoreExclude                []string
 
 	restores []restoreSourceTarget
 
@@ -134,6 +137,7 @@ func (c *commandRestore) setup(svc appServices, parent commandParent) {
 	cmd.Arg("sources", restoreCommandSourcePathHelp).Required().StringsVar(&c.restoreTargetPaths)
 	cmd.Flag("overwrite-directories", "Overwrite existing directories").Default("true").BoolVar(&c.restoreOverwriteDirectories)
 	cmd.Flag("overwrite-files", "Specifies whether or not to overwrite already existing files").Default("true").BoolVar(&c.restoreOverwriteFiles)
+	cmd.Flag("rename-existing-files", "Restore files that already exist under a new name, leaving existing files intact").BoolVar(&c.restoreRenameExistingFiles)
 	cmd.Flag("overwrite-symlinks", "Specifies whether or not to overwrite already existing symlinks").Default("true").BoolVar(&c.restoreOverwriteSymlinks)
 	cmd.Flag("write-sparse-files", "When doing a restore, attempt to write files sparsely-allocating the minimum amount of disk space needed.").Default("false").BoolVar(&c.restoreWriteSparseFiles)
 	cmd.Flag("consistent-attributes", "When multiple snapshots match, fail if they have inconsistent attributes").Envar(svc.EnvName("KOPIA_RESTORE_CONSISTENT_ATTRIBUTES")).BoolVar(&c.restoreConsistentAttributes)
@@ -148,6 +152,8 @@ func (c *commandRestore) setup(svc appServices, parent commandParent) {
 	cmd.Flag("skip-existing", "Skip files and symlinks that exist in the output").BoolVar(&c.restoreIncremental)
 	cmd.Flag("shallow", "Shallow restore the directory hierarchy starting at this level (default is to deep restore the entire hierarchy.)").Int32Var(&c.restoreShallowAtDepth)
 	cmd.Flag("shallow-minsize", "When doing a shallow restore, write actual files instead of placeholders smaller than this size.").Int32Var(&c.minSizeForPlaceholder)
+	cmd.Flag("include", "Only restore entries matching the given pattern (.gitignore syntax, relative to the restore root)").StringsVar(&c.restoreInclude)
+	cmd.Flag("exclude", "Do not restore entries matching the given pattern (.gitignore syntax, relative to the restore root)").StringsVar(&c.restoreExclude)
 	cmd.Flag("snapshot-time", "When using a path as the source, use the latest snapshot available before this date. Default is latest").Default("latest").StringVar(&c.snapshotTime)
 	cmd.Action(svc.repositoryReaderAction(c.run))
 }
@@ -253,6 +259,7 @@ func (c *commandRestore) restoreOutput(ctx context.Context, rep repo.Repository)
 			TargetPath:             targetpath,
 			OverwriteDirectories:   c.restoreOverwriteDirectories,
 			OverwriteFiles:         c.restoreOverwriteFiles,
+			RenameExistingFiles:    c.restoreRenameExistingFiles,
 			OverwriteSymlinks:      c.restoreOverwriteSymlinks,
 			IgnorePermissionErrors: c.restoreIgnorePermissionErrors,
 			WriteFilesAtomically:   c.restoreWriteFilesAtomically,
@@ -415,6 +422,8 @@ func (c *commandRestore) run(ctx context.Context, rep repo.Repository) error {
 			IgnoreErrors:           c.restoreIgnoreErrors,
 			RestoreDirEntryAtDepth: c.restoreShallowAtDepth,
 			MinSizeForPlaceholder:  c.minSizeForPlaceholder,
+			Include:                c.restoreInclude,
+			Exclude:                c.restoreExclude,
 			ProgressCallback:       progressCallback,
 		})
 		if err != nil {
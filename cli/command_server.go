@@ -1,6 +1,8 @@
 package cli
 
 import (
+	"crypto/tls"
+
 	"github.com/alecthomas/kingpin/v2"
 	"github.com/pkg/errors"
 
@@ -20,6 +22,9 @@ type commandServer struct {
 	throttle commandServerThrottle
 	upload   commandServerUpload
 	shutdown commandServerShutdown
+
+	snapshots commandServerSnapshots
+	tasks     commandServerTasks
 }
 
 type serverFlags struct {
@@ -39,6 +44,8 @@ type serverClientFlags struct {
 	serverUsername        string
 	serverPassword        string
 	serverCertFingerprint string
+	serverClientCertFile  string
+	serverClientKeyFile   string
 }
 
 func (c *serverClientFlags) setup(svc appServices, cmd *kingpin.CmdClause) {
@@ -53,6 +60,8 @@ func (c *serverClientFlags) setup(svc appServices, cmd *kingpin.CmdClause) {
 	cmd.Flag("server-password", "Server control password").Hidden().StringVar(&c.serverPassword)
 
 	cmd.Flag("server-cert-fingerprint", "Server certificate fingerprint").PlaceHolder("SHA256-FINGERPRINT").Envar(svc.EnvName("KOPIA_SERVER_CERT_FINGERPRINT")).StringVar(&c.serverCertFingerprint)
+	cmd.Flag("tls-client-cert", "Client certificate file (PEM) presented to servers requiring TLS client certificates").Envar(svc.EnvName("KOPIA_SERVER_TLS_CLIENT_CERT")).ExistingFileVar(&c.serverClientCertFile)
+	cmd.Flag("tls-client-key", "Private key file (PEM) of the client certificate").Envar(svc.EnvName("KOPIA_SERVER_TLS_CLIENT_KEY")).ExistingFileVar(&c.serverClientKeyFile)
 }
 
 func (c *commandServer) setup(svc advancedAppServices, parent commandParent) {
@@ -72,6 +81,8 @@ func (c *commandServer) setup(svc advancedAppServices, parent commandParent) {
 	c.pause.setup(svc, cmd)
 	c.resume.setup(svc, cmd)
 	c.throttle.setup(svc, cmd)
+	c.snapshots.setup(svc, cmd)
+	c.tasks.setup(svc, cmd)
 }
 
 func (c *serverClientFlags) serverAPIClientOptions() (apiclient.Options, error) {
@@ -79,10 +90,25 @@ func (c *serverClientFlags) serverAPIClientOptions() (apiclient.Options, error)
 		return apiclient.Options{}, errors.Errorf("missing server address")
 	}
 
-	return apiclient.Options{
+	if (c.serverClientCertFile == "") != (c.serverClientKeyFile == "") {
+		return apiclient.Options{}, errors.New("--tls-client-cert and --tls-client-key must be specified together")
+	}
+
+	opts := apiclient.Options{
 		BaseURL:                             c.serverAddress,
 		Username:                            c.serverUsername,
 		Password:                            c.serverPassword,
 		TrustedServerCertificateFingerprint: c.serverCertFingerprint,
-	}, nil
+	}
+
+	if c.serverClientCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.serverClientCertFile, c.serverClientKeyFile)
+		if err != nil {
+			return apiclient.Options{}, errors.Wrap(err, "unable to load client certificate")
+		}
+
+		opts.ClientCertificates = []tls.Certificate{cert}
+	}
+
+	return opts, nil
 }
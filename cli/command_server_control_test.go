@@ -7,7 +7,9 @@
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/kopia/kopia/internal/serverapi"
 	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/internal/uitask"
 	"github.com/kopia/kopia/repo/blob/throttling"
 	"github.com/kopia/kopia/tests/testenv"
 )
@@ -80,11 +82,33 @@ func TestServerControl(t *testing.T) {
 
 	env.RunAndExpectSuccess(t, "server", "flush", "--address", sp.BaseURL, "--server-control-password", sp.ServerControlPassword)
 
+	// list snapshots of a source managed by the server
+	require.Len(t, env.RunAndExpectSuccess(t, "server", "snapshots", "--address", sp.BaseURL, "--server-control-password", sp.ServerControlPassword, dir1), 1)
+
+	var snaps []*serverapi.Snapshot
+
+	testutil.MustParseJSONLines(t, env.RunAndExpectSuccess(t, "server", "snapshots", "--address", sp.BaseURL, "--server-control-password", sp.ServerControlPassword, dir2, "--json"), &snaps)
+	require.Len(t, snaps, 1)
+
+	// remote sources are not managed by the server
+	env.RunAndExpectFailure(t, "server", "snapshots", "--address", sp.BaseURL, "--server-control-password", sp.ServerControlPassword, dir0)
+
 	// trigger server snapshot
 	env.RunAndExpectSuccess(t, "server", "snapshot", "--address", sp.BaseURL, "--server-control-password", sp.ServerControlPassword, "--all")
 	env.RunAndExpectSuccess(t, "server", "snapshot", "--address", sp.BaseURL, "--server-control-password", sp.ServerControlPassword, dir1)
 	env.RunAndExpectFailure(t, "server", "snapshot", "--address", sp.BaseURL, "--server-control-password", sp.ServerControlPassword, "no-such-dir")
 
+	// follow progress of the snapshot task until completion
+	var tasks []uitask.Info
+
+	require.Eventually(t, func() bool {
+		testutil.MustParseJSONLines(t, env.RunAndExpectSuccess(t, "server", "tasks", "list", "--address", sp.BaseURL, "--server-control-password", sp.ServerControlPassword, "--json"), &tasks)
+		return len(tasks) > 0
+	}, waitTimeout, pollFrequency)
+
+	env.RunAndExpectSuccess(t, "server", "tasks", "watch", "--address", sp.BaseURL, "--server-control-password", sp.ServerControlPassword, tasks[0].TaskID)
+	env.RunAndExpectFailure(t, "server", "tasks", "watch", "--address", sp.BaseURL, "--server-control-password", sp.ServerControlPassword, "no-such-task")
+
 	// neither dir nor --all specified
 	env.RunAndExpectFailure(t, "server", "snapshot", "--address", sp.BaseURL, "--server-control-password", sp.ServerControlPassword)
 
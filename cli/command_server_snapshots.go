@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"context"
+	"net/url"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/apiclient"
+	"github.com/kopia/kopia/internal/serverapi"
+	"github.com/kopia/kopia/internal/units"
+)
+
+type commandServerSnapshots struct {
+	sf serverClientFlags
+
+	source string
+	all    bool
+
+	jo  jsonOutput
+	out textOutput
+}
+
+func (c *commandServerSnapshots) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("snapshots", "List snapshots of a source managed by the server")
+
+	cmd.Arg("source", "Source path managed by server").Required().StringVar(&c.source)
+	cmd.Flag("all", "Include identical snapshots").BoolVar(&c.all)
+
+	c.sf.setup(svc, cmd)
+	c.jo.setup(svc, cmd)
+	c.out.setup(svc)
+
+	cmd.Action(svc.serverAction(&c.sf, c.run))
+}
+
+func (c *commandServerSnapshots) run(ctx context.Context, cli *apiclient.KopiaAPIClient) error {
+	absPath, err := filepath.Abs(c.source)
+	if err != nil {
+		return errors.Wrap(err, "unable to determine absolute path")
+	}
+
+	var sources serverapi.SourcesResponse
+	if err := cli.Get(ctx, "control/sources", nil, &sources); err != nil {
+		return errors.Wrap(err, "unable to list sources")
+	}
+
+	for _, src := range sources.Sources {
+		if src.Source.Path != absPath || src.Status == "REMOTE" {
+			continue
+		}
+
+		uv := url.Values{}
+		uv.Set("userName", src.Source.UserName)
+		uv.Set("host", src.Source.Host)
+		uv.Set("path", src.Source.Path)
+
+		if c.all {
+			uv.Set("all", "1")
+		}
+
+		var resp serverapi.SnapshotsResponse
+		if err := cli.Get(ctx, "control/snapshots?"+uv.Encode(), nil, &resp); err != nil {
+			return errors.Wrap(err, "unable to list snapshots")
+		}
+
+		if c.jo.jsonOutput {
+			c.out.printStdout("%s\n", c.jo.jsonBytes(resp.Snapshots))
+			return nil
+		}
+
+		for _, s := range resp.Snapshots {
+			var totalSize int64
+
+			if s.Summary != nil {
+				totalSize = s.Summary.TotalFileSize
+			}
+
+			c.out.printStdout("%v %v %v\n", formatTimestamp(s.StartTime.ToTime()), s.RootEntry, units.BytesString(totalSize))
+		}
+
+		return nil
+	}
+
+	return errors.Errorf("source %v is not managed by the server", absPath)
+}
@@ -8,6 +8,7 @@
 	"io"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
@@ -17,7 +18,9 @@
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	htpasswd "github.com/tg123/go-htpasswd"
+	"golang.org/x/crypto/acme/autocert"
 
+	"github.com/kopia/kopia/internal/auditlog"
 	"github.com/kopia/kopia/internal/auth"
 	"github.com/kopia/kopia/internal/ctxutil"
 	"github.com/kopia/kopia/internal/server"
@@ -34,6 +37,7 @@ type commandServerStart struct {
 	serverStartUI         bool
 	serverStartGRPC       bool
 	serverStartControlAPI bool
+	serverStartBlobProxy  bool
 
 	serverStartRefreshInterval time.Duration
 	serverStartInsecure        bool
@@ -58,12 +62,19 @@ type commandServerStart struct {
 	serverStartTLSGenerateCertValidDays int
 	serverStartTLSGenerateCertNames     []string
 	serverStartTLSPrintFullServerCert   bool
+	serverStartTLSClientCAFile          string
+	serverStartTLSACMEDomains           []string
+	serverStartTLSACMEEmail             string
+	serverStartTLSACMECacheDir          string
+	serverStartTLSACMEDirectoryURL      string
+	serverStartTLSACMEHTTPAddress       string
 	uiTitlePrefix                       string
 	uiPreferencesFile                   string
 	asyncRepoConnect                    bool
 	persistentLogs                      bool
 	debugScheduler                      bool
 	minMaintenanceInterval              time.Duration
+	takeMaintenanceOwnership            bool
 
 	shutdownGracePeriod time.Duration
 
@@ -71,6 +82,14 @@ type commandServerStart struct {
 
 	disableCSRFTokenChecks bool // disable CSRF token checks - used for development/debugging only
 
+	auditLogFile       string
+	auditSyslog        bool
+	auditSyslogAddress string
+	auditJournald      bool
+
+	storageNotificationToken     string
+	storageNotificationKeyPrefix string
+
 	sf  serverFlags
 	svc advancedAppServices
 	out textOutput
@@ -83,6 +102,7 @@ func (c *commandServerStart) setup(svc advancedAppServices, parent commandParent
 
 	cmd.Flag("grpc", "Start the GRPC server").Default("true").BoolVar(&c.serverStartGRPC)
 	cmd.Flag("control-api", "Start the control API").Default("true").BoolVar(&c.serverStartControlAPI)
+	cmd.Flag("blob-proxy", "Expose repository storage to server control user for use with 'kopia-server' storage").BoolVar(&c.serverStartBlobProxy)
 
 	cmd.Flag("refresh-interval", "Frequency for refreshing repository status").Default("4h").DurationVar(&c.serverStartRefreshInterval)
 	cmd.Flag("insecure", "Allow insecure configurations (do not use in production)").Hidden().BoolVar(&c.serverStartInsecure)
@@ -99,6 +119,9 @@ func (c *commandServerStart) setup(svc advancedAppServices, parent commandParent
 	cmd.Flag("auth-cookie-signing-key", "Force particular auth cookie signing key").Envar(svc.EnvName("KOPIA_AUTH_COOKIE_SIGNING_KEY")).Hidden().StringVar(&c.serverAuthCookieSingingKey)
 	cmd.Flag("log-scheduler", "Enable logging of scheduler actions").Hidden().Default("true").BoolVar(&c.debugScheduler)
 	cmd.Flag("min-maintenance-interval", "Minimum maintenance interval").Hidden().Default("60s").DurationVar(&c.minMaintenanceInterval)
+	cmd.Flag("storage-notification-token", "Accept storage change notifications (S3 events, SNS, GCS Pub/Sub push) at /api/v1/storage-notifications?token=<TOKEN>").PlaceHolder("TOKEN").Envar(svc.EnvName("KOPIA_STORAGE_NOTIFICATION_TOKEN")).StringVar(&c.storageNotificationToken)
+	cmd.Flag("storage-notification-key-prefix", "Prefix of object keys of repository blobs in storage change notifications").StringVar(&c.storageNotificationKeyPrefix)
+	cmd.Flag("take-maintenance-ownership", "Make the server the owner of repository maintenance, so that it's scheduled centrally instead of by clients").BoolVar(&c.takeMaintenanceOwnership)
 
 	cmd.Flag("shutdown-on-stdin", "Shut down the server when stdin handle has closed.").Hidden().BoolVar(&c.serverStartShutdownWhenStdinClosed)
 
@@ -109,6 +132,12 @@ func (c *commandServerStart) setup(svc advancedAppServices, parent commandParent
 	cmd.Flag("tls-generate-cert-valid-days", "How long should the TLS certificate be valid").Default("3650").Hidden().IntVar(&c.serverStartTLSGenerateCertValidDays)
 	cmd.Flag("tls-generate-cert-name", "Host names/IP addresses to generate TLS certificate for").Default("127.0.0.1").Hidden().StringsVar(&c.serverStartTLSGenerateCertNames)
 	cmd.Flag("tls-print-server-cert", "Print server certificate").Hidden().BoolVar(&c.serverStartTLSPrintFullServerCert)
+	cmd.Flag("tls-client-ca-file", "Require clients to present TLS certificate signed by one of the CAs in the provided PEM file").ExistingFileVar(&c.serverStartTLSClientCAFile)
+	cmd.Flag("tls-acme-domain", "Automatically obtain and renew TLS certificate for the provided domain using ACME (Let's Encrypt)").StringsVar(&c.serverStartTLSACMEDomains)
+	cmd.Flag("tls-acme-email", "Contact e-mail address for the ACME account").StringVar(&c.serverStartTLSACMEEmail)
+	cmd.Flag("tls-acme-cache-dir", "Directory where ACME account and certificates are stored (defaults to 'acme' next to the config file)").StringVar(&c.serverStartTLSACMECacheDir)
+	cmd.Flag("tls-acme-directory-url", "ACME directory URL").Default(autocert.DefaultACMEDirectory).StringVar(&c.serverStartTLSACMEDirectoryURL)
+	cmd.Flag("tls-acme-http-address", "Additional address to answer ACME HTTP-01 challenges on, e.g. ':80' (TLS-ALPN-01 challenges are always answered on the server address)").StringVar(&c.serverStartTLSACMEHTTPAddress)
 
 	cmd.Flag("async-repo-connect", "Connect to repository asynchronously").Hidden().BoolVar(&c.asyncRepoConnect)
 	cmd.Flag("persistent-logs", "Persist logs in a file").Default("true").BoolVar(&c.persistentLogs)
@@ -120,6 +149,11 @@ func (c *commandServerStart) setup(svc advancedAppServices, parent commandParent
 
 	cmd.Flag("shutdown-grace-period", "Grace period for shutting down the server").Default("5s").DurationVar(&c.shutdownGracePeriod)
 
+	cmd.Flag("audit-log-file", "Append audit events as JSON lines to the provided file").StringVar(&c.auditLogFile)
+	cmd.Flag("audit-syslog", "Send audit events to syslog").BoolVar(&c.auditSyslog)
+	cmd.Flag("audit-syslog-address", "Address of remote syslog server to send audit events to, e.g. udp://host:514 (defaults to local syslog)").StringVar(&c.auditSyslogAddress)
+	cmd.Flag("audit-journald", "Send audit events to systemd journal").BoolVar(&c.auditJournald)
+
 	c.sf.setup(svc, cmd)
 	c.co.setup(svc, cmd)
 	c.svc = svc
@@ -134,6 +168,16 @@ func (c *commandServerStart) serverStartOptions(ctx context.Context) (*server.Op
 		return nil, errors.Wrap(err, "unable to initialize authentication")
 	}
 
+	auditLog, err := c.auditLogger()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to initialize audit log")
+	}
+
+	connectOptions, err := c.co.toRepoConnectOptions()
+	if err != nil {
+		return nil, err
+	}
+
 	uiPreferencesFile := c.uiPreferencesFile
 	if uiPreferencesFile == "" {
 		uiPreferencesFile = filepath.Join(filepath.Dir(c.svc.repositoryConfigFileName()), "ui-preferences.json")
@@ -141,7 +185,7 @@ func (c *commandServerStart) serverStartOptions(ctx context.Context) (*server.Op
 
 	return &server.Options{
 		ConfigFile:           c.svc.repositoryConfigFileName(),
-		ConnectOptions:       c.co.toRepoConnectOptions(),
+		ConnectOptions:       connectOptions,
 		RefreshInterval:      c.serverStartRefreshInterval,
 		MaxConcurrency:       c.serverStartMaxConcurrency,
 		Authenticator:        authn,
@@ -155,12 +199,67 @@ func (c *commandServerStart) serverStartOptions(ctx context.Context) (*server.Op
 		UITitlePrefix:        c.uiTitlePrefix,
 		PersistentLogs:       c.persistentLogs,
 
-		DebugScheduler:         c.debugScheduler,
-		MinMaintenanceInterval: c.minMaintenanceInterval,
-		DisableCSRFTokenChecks: c.disableCSRFTokenChecks,
+		DebugScheduler:           c.debugScheduler,
+		MinMaintenanceInterval:   c.minMaintenanceInterval,
+		TakeMaintenanceOwnership: c.takeMaintenanceOwnership,
+		DisableCSRFTokenChecks:   c.disableCSRFTokenChecks,
+		AuditLog:                 auditLog,
+
+		StorageNotificationToken:     c.storageNotificationToken,
+		StorageNotificationKeyPrefix: c.storageNotificationKeyPrefix,
 	}, nil
 }
 
+// auditLogger returns audit logger writing to destinations selected by the command-line flags
+// or nil if auditing is not enabled.
+func (c *commandServerStart) auditLogger() (*auditlog.Logger, error) {
+	var sinks []auditlog.Sink
+
+	if c.auditLogFile != "" {
+		s, err := auditlog.NewFileSink(c.auditLogFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "file")
+		}
+
+		sinks = append(sinks, s)
+	}
+
+	if c.auditSyslog || c.auditSyslogAddress != "" {
+		var network, address string
+
+		if c.auditSyslogAddress != "" {
+			u, err := url.Parse(c.auditSyslogAddress)
+			if err != nil || u.Scheme == "" || u.Host == "" {
+				return nil, errors.Errorf("invalid syslog address %q, expected udp://host:port or tcp://host:port", c.auditSyslogAddress)
+			}
+
+			network, address = u.Scheme, u.Host
+		}
+
+		s, err := auditlog.NewSyslogSink(network, address)
+		if err != nil {
+			return nil, errors.Wrap(err, "syslog")
+		}
+
+		sinks = append(sinks, s)
+	}
+
+	if c.auditJournald {
+		s, err := auditlog.NewJournaldSink()
+		if err != nil {
+			return nil, errors.Wrap(err, "journald")
+		}
+
+		sinks = append(sinks, s)
+	}
+
+	if len(sinks) == 0 {
+		return nil, nil
+	}
+
+	return auditlog.NewLogger(sinks...), nil
+}
+
 func (c *commandServerStart) initRepositoryPossiblyAsync(ctx context.Context, srv *server.Server) error {
 	initialize := func(ctx context.Context) (repo.Repository, error) {
 		return c.svc.openRepository(ctx, false)
@@ -179,11 +278,17 @@ func (c *commandServerStart) initRepositoryPossiblyAsync(ctx context.Context, sr
 }
 
 func (c *commandServerStart) run(ctx context.Context) error {
+	if err := c.validateTLSFlags(); err != nil {
+		return err
+	}
+
 	opts, err := c.serverStartOptions(ctx)
 	if err != nil {
 		return err
 	}
 
+	defer opts.AuditLog.Close() //nolint:errcheck
+
 	srv, err := server.New(ctx, opts)
 	if err != nil {
 		return errors.Wrap(err, "unable to initialize server")
@@ -201,6 +306,8 @@ func (c *commandServerStart) run(ctx context.Context) error {
 		},
 	}
 
+	httpServer.RegisterOnShutdown(srv.CloseEventStreams)
+
 	srv.OnShutdown = func(ctx context.Context) error {
 		ctx2, cancel := context.WithTimeout(ctx, c.shutdownGracePeriod)
 		defer cancel()
@@ -257,11 +364,15 @@ func (c *commandServerStart) run(ctx context.Context) error {
 
 	onExternalConfigReloadRequest(srv.Refresh)
 
+	defer startSystemdWatchdog(ctx, srv.CheckLiveness)()
+
 	err = c.startServerWithOptionalTLS(ctx, httpServer)
 	if !errors.Is(err, http.ErrServerClosed) {
 		return err
 	}
 
+	srv.WaitForSessions()
+
 	return errors.Wrap(srv.SetRepository(ctx, nil), "error setting active repository")
 }
 
@@ -278,6 +389,14 @@ func (c *commandServerStart) setupHandlers(srv *server.Server, m *mux.Router) {
 		srv.SetupControlAPIHandlers(m)
 	}
 
+	if c.serverStartBlobProxy {
+		srv.SetupBlobProxyHandlers(m)
+	}
+
+	if c.storageNotificationToken != "" {
+		srv.SetupStorageNotificationHandlers(m)
+	}
+
 	if c.serverStartUI {
 		srv.SetupHTMLUIAPIHandlers(m)
 
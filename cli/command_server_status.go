@@ -39,6 +39,11 @@ func (c *commandServerStatus) runServerStatus(ctx context.Context, cli *apiclien
 			continue
 		}
 
+		if src.NextSnapshotTime != nil {
+			c.out.printStdout("%v: %v (next snapshot at %v)\n", src.Status, src.Source, formatTimestamp(*src.NextSnapshotTime))
+			continue
+		}
+
 		c.out.printStdout("%v: %v\n", src.Status, src.Source)
 	}
 
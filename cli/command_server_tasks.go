@@ -0,0 +1,186 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/apiclient"
+	"github.com/kopia/kopia/internal/serverapi"
+	"github.com/kopia/kopia/internal/uitask"
+)
+
+// maxServerSentEventSize is the maximum size of a single line of the server event stream.
+const maxServerSentEventSize = 16 << 20
+
+type commandServerTasks struct {
+	list   commandServerTasksList
+	watch  commandServerTasksWatch
+	cancel commandServerTasksCancel
+}
+
+func (c *commandServerTasks) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("tasks", "Manage tasks running on the server")
+
+	c.list.setup(svc, cmd)
+	c.watch.setup(svc, cmd)
+	c.cancel.setup(svc, cmd)
+}
+
+type commandServerTasksList struct {
+	sf serverClientFlags
+
+	jo  jsonOutput
+	out textOutput
+}
+
+func (c *commandServerTasksList) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("list", "List tasks running on the server").Alias("ls")
+
+	c.sf.setup(svc, cmd)
+	c.jo.setup(svc, cmd)
+	c.out.setup(svc)
+
+	cmd.Action(svc.serverAction(&c.sf, c.run))
+}
+
+func (c *commandServerTasksList) run(ctx context.Context, cli *apiclient.KopiaAPIClient) error {
+	var resp serverapi.TaskListResponse
+	if err := cli.Get(ctx, "control/tasks", nil, &resp); err != nil {
+		return errors.Wrap(err, "unable to list tasks")
+	}
+
+	sort.Slice(resp.Tasks, func(i, j int) bool {
+		return resp.Tasks[i].StartTime.Before(resp.Tasks[j].StartTime)
+	})
+
+	if c.jo.jsonOutput {
+		c.out.printStdout("%s\n", c.jo.jsonBytes(resp.Tasks))
+		return nil
+	}
+
+	for _, t := range resp.Tasks {
+		c.out.printStdout("%v %v %-10v %v %v\n", t.TaskID, formatTimestamp(t.StartTime), t.Status, t.Kind, t.Description)
+	}
+
+	return nil
+}
+
+type commandServerTasksWatch struct {
+	sf serverClientFlags
+
+	taskID string
+
+	jo  jsonOutput
+	out textOutput
+}
+
+func (c *commandServerTasksWatch) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("watch", "Print progress of a task, as streamed by the server, until it finishes")
+	cmd.Arg("id", "Task ID").Required().StringVar(&c.taskID)
+
+	c.sf.setup(svc, cmd)
+	c.jo.setup(svc, cmd)
+	c.out.setup(svc)
+
+	cmd.Action(svc.serverAction(&c.sf, c.run))
+}
+
+func (c *commandServerTasksWatch) run(ctx context.Context, cli *apiclient.KopiaAPIClient) error {
+	// progress updates are pushed by the server over its event stream, subscribe before fetching
+	// the current state of the task, so that updates made in between are not missed.
+	body, err := cli.GetStream(ctx, "control/events")
+	if err != nil {
+		return errors.Wrap(err, "unable to open event stream")
+	}
+
+	defer body.Close() //nolint:errcheck
+
+	var t uitask.Info
+	if err := cli.Get(ctx, "control/tasks/"+url.PathEscape(c.taskID), nil, &t); err != nil {
+		return errors.Wrap(err, "unable to get task")
+	}
+
+	c.printTask(t)
+
+	if t.Status.IsFinished() {
+		return taskResult(t)
+	}
+
+	last := t
+
+	s := bufio.NewScanner(body)
+	s.Buffer(nil, maxServerSentEventSize)
+
+	for s.Scan() {
+		data, ok := strings.CutPrefix(s.Text(), "data: ")
+		if !ok {
+			continue
+		}
+
+		var ev serverapi.Event
+		if err := json.Unmarshal([]byte(data), &ev); err != nil {
+			return errors.Wrap(err, "invalid event")
+		}
+
+		if ev.Type != serverapi.EventTypeTask || ev.Task == nil || ev.Task.TaskID != c.taskID {
+			continue
+		}
+
+		if ev.Task.Status != last.Status || ev.Task.ProgressInfo != last.ProgressInfo {
+			c.printTask(*ev.Task)
+		}
+
+		if ev.Task.Status.IsFinished() {
+			return taskResult(*ev.Task)
+		}
+
+		last = *ev.Task
+	}
+
+	if err := s.Err(); err != nil {
+		return errors.Wrap(err, "error reading event stream")
+	}
+
+	return errors.New("event stream ended before the task finished")
+}
+
+func (c *commandServerTasksWatch) printTask(t uitask.Info) {
+	if c.jo.jsonOutput {
+		c.out.printStdout("%s\n", c.jo.jsonBytes(t))
+	} else {
+		c.out.printStdout("%v %v\n", t.Status, t.ProgressInfo)
+	}
+}
+
+func taskResult(t uitask.Info) error {
+	if t.Status == uitask.StatusFailed {
+		return errors.Errorf("task failed: %v", t.ErrorMessage)
+	}
+
+	return nil
+}
+
+type commandServerTasksCancel struct {
+	sf serverClientFlags
+
+	taskID string
+}
+
+func (c *commandServerTasksCancel) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("cancel", "Cancel a task running on the server")
+	cmd.Arg("id", "Task ID").Required().StringVar(&c.taskID)
+
+	c.sf.setup(svc, cmd)
+
+	cmd.Action(svc.serverAction(&c.sf, c.run))
+}
+
+func (c *commandServerTasksCancel) run(ctx context.Context, cli *apiclient.KopiaAPIClient) error {
+	return errors.Wrap(cli.Post(ctx, "control/tasks/"+url.PathEscape(c.taskID)+"/cancel", &serverapi.Empty{}, &serverapi.Empty{}), "unable to cancel task")
+}
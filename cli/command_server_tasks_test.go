@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/apiclient"
+	"github.com/kopia/kopia/internal/serverapi"
+	"github.com/kopia/kopia/internal/testlogging"
+	"github.com/kopia/kopia/internal/uitask"
+)
+
+func TestServerTasksWatchFollowsEventStream(t *testing.T) {
+	writeEvent := func(w http.ResponseWriter, ev serverapi.Event) {
+		b, err := json.Marshal(ev)
+		require.NoError(t, err)
+
+		fmt.Fprintf(w, "event: %v\ndata: %s\n\n", ev.Type, b) //nolint:errcheck
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/control/tasks/t1", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(uitask.Info{TaskID: "t1", Status: uitask.StatusRunning}) //nolint:errcheck
+	})
+	mux.HandleFunc("/api/v1/control/events", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, ": keep-alive\n\n") //nolint:errcheck
+		writeEvent(w, serverapi.Event{Type: serverapi.EventTypeTask, Task: &uitask.Info{TaskID: "other", Status: uitask.StatusFailed}})
+		writeEvent(w, serverapi.Event{Type: serverapi.EventTypeTask, Task: &uitask.Info{TaskID: "t1", Status: uitask.StatusRunning, ProgressInfo: "half way"}})
+		writeEvent(w, serverapi.Event{Type: serverapi.EventTypeTask, Task: &uitask.Info{TaskID: "t1", Status: uitask.StatusFailed, ErrorMessage: "boom"}})
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	cli, err := apiclient.NewKopiaAPIClient(apiclient.Options{BaseURL: srv.URL})
+	require.NoError(t, err)
+
+	c := &commandServerTasksWatch{taskID: "t1"}
+
+	require.ErrorContains(t, c.run(testlogging.Context(t), cli), "task failed: boom")
+}
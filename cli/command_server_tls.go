@@ -14,12 +14,17 @@
 	"net"
 	"net/http"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/pkg/errors"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
 
 	"github.com/coreos/go-systemd/v22/activation"
+	"github.com/coreos/go-systemd/v22/daemon"
 
 	"github.com/kopia/kopia/internal/tlsutil"
 )
@@ -67,6 +72,9 @@ func (c *commandServerStart) startServerWithOptionalTLS(ctx context.Context, htt
 
 	httpServer.Addr = l.Addr().String()
 
+	notifySystemd(ctx, daemon.SdNotifyReady)
+	defer notifySystemd(ctx, daemon.SdNotifyStopping)
+
 	return c.startServerWithOptionalTLSAndListener(ctx, httpServer, l)
 }
 
@@ -106,6 +114,119 @@ func (c *commandServerStart) maybeGenerateTLS(ctx context.Context) error {
 	return nil
 }
 
+// applyClientCertificateAuth configures the provided TLS config to require client certificates
+// signed by CAs from the file passed in --tls-client-ca-file.
+func (c *commandServerStart) applyClientCertificateAuth(tc *tls.Config) (*tls.Config, error) {
+	if c.serverStartTLSClientCAFile == "" {
+		return tc, nil
+	}
+
+	//nolint:gosec
+	pemData, err := os.ReadFile(c.serverStartTLSClientCAFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read client CA file")
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pemData) {
+		return nil, errors.Errorf("no certificates found in %v", c.serverStartTLSClientCAFile)
+	}
+
+	if tc == nil {
+		tc = &tls.Config{MinVersion: tls.VersionTLS13}
+	}
+
+	tc.ClientCAs = pool
+	tc.ClientAuth = tls.RequireAndVerifyClientCert
+
+	return tc, nil
+}
+
+// allowACMEChallengeWithoutClientCert makes the provided TLS config skip client certificate
+// verification for TLS-ALPN-01 challenge connections, which come from the ACME CA and
+// do not present a client certificate.
+func allowACMEChallengeWithoutClientCert(tc *tls.Config) {
+	if tc.ClientAuth == tls.NoClientCert {
+		return
+	}
+
+	challengeConfig := tc.Clone()
+	challengeConfig.ClientAuth = tls.NoClientCert
+	challengeConfig.ClientCAs = nil
+	challengeConfig.NextProtos = []string{acme.ALPNProto}
+
+	tc.GetConfigForClient = func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+		if slices.Contains(hello.SupportedProtos, acme.ALPNProto) {
+			return challengeConfig, nil
+		}
+
+		return nil, nil //nolint:nilnil
+	}
+}
+
+// startACMEServer serves TLS using certificates automatically obtained and renewed by ACME.
+func (c *commandServerStart) startACMEServer(ctx context.Context, httpServer *http.Server, listener net.Listener, udsPfx string) error {
+	cacheDir := c.serverStartTLSACMECacheDir
+	if cacheDir == "" {
+		cacheDir = filepath.Join(filepath.Dir(c.svc.repositoryConfigFileName()), "acme")
+	}
+
+	m := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(c.serverStartTLSACMEDomains...),
+		Cache:      autocert.DirCache(cacheDir),
+		Email:      c.serverStartTLSACMEEmail,
+		Client:     &acme.Client{DirectoryURL: c.serverStartTLSACMEDirectoryURL},
+	}
+
+	acmeConfig := m.TLSConfig()
+	acmeConfig.MinVersion = tls.VersionTLS13
+
+	tc, err := c.applyClientCertificateAuth(acmeConfig)
+	if err != nil {
+		return err
+	}
+
+	allowACMEChallengeWithoutClientCert(tc)
+
+	httpServer.TLSConfig = tc
+
+	if c.serverStartTLSACMEHTTPAddress != "" {
+		challengeServer := &http.Server{
+			ReadHeaderTimeout: 15 * time.Second, //nolint:mnd
+			Addr:              c.serverStartTLSACMEHTTPAddress,
+			Handler:           m.HTTPHandler(nil),
+		}
+
+		go func() {
+			if err := challengeServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log(ctx).Errorf("ACME HTTP challenge server error: %v", err)
+			}
+		}()
+
+		defer challengeServer.Close() //nolint:errcheck
+	}
+
+	log(ctx).Infof("Obtaining TLS certificates for %v from %v", strings.Join(c.serverStartTLSACMEDomains, ", "), c.serverStartTLSACMEDirectoryURL)
+
+	fmt.Fprintf(c.out.stderr(), "SERVER ADDRESS: %shttps://%v\n", udsPfx, httpServer.Addr) //nolint:errcheck
+	c.showServerUIPrompt(ctx)
+
+	return errors.Wrap(httpServer.ServeTLS(listener, "", ""), "error starting TLS server")
+}
+
+func (c *commandServerStart) validateTLSFlags() error {
+	if len(c.serverStartTLSACMEDomains) > 0 && (c.serverStartTLSCertFile != "" || c.serverStartTLSKeyFile != "" || c.serverStartTLSGenerateCert) {
+		return errors.Errorf("--tls-acme-domain can't be combined with --tls-cert-file, --tls-key-file or --tls-generate-cert")
+	}
+
+	if c.serverStartTLSClientCAFile != "" && len(c.serverStartTLSACMEDomains) == 0 && !c.serverStartTLSGenerateCert && (c.serverStartTLSCertFile == "" || c.serverStartTLSKeyFile == "") {
+		return errors.Errorf("--tls-client-ca-file requires TLS to be configured")
+	}
+
+	return nil
+}
+
 func (c *commandServerStart) startServerWithOptionalTLSAndListener(ctx context.Context, httpServer *http.Server, listener net.Listener) error {
 	if err := c.maybeGenerateTLS(ctx); err != nil {
 		return err
@@ -117,8 +238,18 @@ func (c *commandServerStart) startServerWithOptionalTLSAndListener(ctx context.C
 	}
 
 	switch {
+	case len(c.serverStartTLSACMEDomains) > 0:
+		return c.startACMEServer(ctx, httpServer, listener, udsPfx)
+
 	case c.serverStartTLSCertFile != "" && c.serverStartTLSKeyFile != "":
 		// PEM files provided
+		tc, err := c.applyClientCertificateAuth(nil)
+		if err != nil {
+			return err
+		}
+
+		httpServer.TLSConfig = tc
+
 		fmt.Fprintf(c.out.stderr(), "SERVER ADDRESS: %shttps://%v\n", udsPfx, httpServer.Addr) //nolint:errcheck
 		c.showServerUIPrompt(ctx)
 
@@ -131,7 +262,7 @@ func (c *commandServerStart) startServerWithOptionalTLSAndListener(ctx context.C
 			return errors.Wrap(err, "unable to generate server cert")
 		}
 
-		httpServer.TLSConfig = &tls.Config{
+		tc, err := c.applyClientCertificateAuth(&tls.Config{
 			MinVersion: tls.VersionTLS13,
 			Certificates: []tls.Certificate{
 				{
@@ -139,8 +270,13 @@ func (c *commandServerStart) startServerWithOptionalTLSAndListener(ctx context.C
 					PrivateKey:  key,
 				},
 			},
+		})
+		if err != nil {
+			return err
 		}
 
+		httpServer.TLSConfig = tc
+
 		fingerprint := sha256.Sum256(cert.Raw)
 		fmt.Fprintf(c.out.stderr(), "SERVER CERT SHA256: %v\n", hex.EncodeToString(fingerprint[:])) //nolint:errcheck
 
@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/acme"
+)
+
+func TestAllowACMEChallengeWithoutClientCert(t *testing.T) {
+	tc := &tls.Config{
+		MinVersion: tls.VersionTLS13,
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  x509.NewCertPool(),
+		NextProtos: []string{"h2", "http/1.1", acme.ALPNProto},
+	}
+
+	allowACMEChallengeWithoutClientCert(tc)
+
+	require.Equal(t, tls.RequireAndVerifyClientCert, tc.ClientAuth)
+
+	// regular connections use the original config.
+	cfg, err := tc.GetConfigForClient(&tls.ClientHelloInfo{SupportedProtos: []string{"h2"}})
+	require.NoError(t, err)
+	require.Nil(t, cfg)
+
+	// challenge connections are not asked for a client certificate.
+	cfg, err = tc.GetConfigForClient(&tls.ClientHelloInfo{SupportedProtos: []string{acme.ALPNProto}})
+	require.NoError(t, err)
+	require.Equal(t, tls.NoClientCert, cfg.ClientAuth)
+	require.Nil(t, cfg.ClientCAs)
+	require.Equal(t, []string{acme.ALPNProto}, cfg.NextProtos)
+
+	// nothing changes without client certificate authentication.
+	tc2 := &tls.Config{MinVersion: tls.VersionTLS13}
+	allowACMEChallengeWithoutClientCert(tc2)
+	require.Nil(t, tc2.GetConfigForClient)
+}
@@ -0,0 +1,263 @@
+package cli
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/ospath"
+)
+
+const (
+	defaultServiceName = "kopia-server"
+
+	// environment variable holding the password of the account specified with --run-as-user.
+	serviceAccountPasswordEnv = "KOPIA_SERVICE_PASSWORD"
+
+	// environment variable through which the service receives the generated server password.
+	serviceServerPasswordEnv = "KOPIA_SERVER_PASSWORD"
+)
+
+type commandService struct {
+	install   commandServiceInstall
+	uninstall commandServiceControl
+	start     commandServiceControl
+	stop      commandServiceControl
+}
+
+func (c *commandService) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("service", "Manage Kopia server running as a native Windows service or macOS launchd agent")
+
+	c.install.setup(svc, cmd)
+	c.uninstall.setup(svc, cmd, "uninstall", "Stop and remove the service", uninstallOSService)
+	c.start.setup(svc, cmd, "start", "Start the service", startOSService)
+	c.stop.setup(svc, cmd, "stop", "Stop the service", stopOSService)
+}
+
+// serviceDefinition describes the service to be registered with the OS.
+type serviceDefinition struct {
+	name       string
+	executable string
+	args       []string
+	logDir     string
+
+	// environment variables of the service, used to keep secrets out of the command line.
+	env map[string]string
+
+	// Windows only: account the service runs as, LocalSystem when empty.
+	user     string
+	password string
+}
+
+var validServiceName = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+
+func validateServiceName(name string) error {
+	if !validServiceName.MatchString(name) {
+		return errors.Errorf("invalid service name %q", name)
+	}
+
+	return nil
+}
+
+type commandServiceInstall struct {
+	name       string
+	address    string
+	logDir     string
+	serverArgs []string
+	user       string
+	start      bool
+
+	// password of the server UI user generated by definition(), empty when provided using --server-arg.
+	generatedPassword string
+
+	svc appServices
+}
+
+func (c *commandServiceInstall) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("install", "Register Kopia server as a service started automatically and restarted on failure")
+	cmd.Flag("name", "Service name").Default(defaultServiceName).StringVar(&c.name)
+	cmd.Flag("address", "Server address").Default("http://127.0.0.1:51515").StringVar(&c.address)
+	cmd.Flag("service-log-dir", "Directory where the service writes its logs").Default(ospath.LogsDir()).StringVar(&c.logDir)
+	cmd.Flag("server-arg", "Additional argument passed to 'kopia server start', can be specified multiple times").StringsVar(&c.serverArgs)
+	cmd.Flag("run-as-user", "Account to run the service as (Windows only, defaults to LocalSystem). The password is read from "+svc.EnvName(serviceAccountPasswordEnv)+" or prompted for").StringVar(&c.user)
+	cmd.Flag("start", "Start the service after installing").Default("true").BoolVar(&c.start)
+
+	c.svc = svc
+	cmd.Action(svc.noRepositoryAction(c.run))
+}
+
+// definition returns the service definition invoking 'kopia server start' using the current executable and config file.
+func (c *commandServiceInstall) definition() (*serviceDefinition, error) {
+	if err := validateServiceName(c.name); err != nil {
+		return nil, err
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to determine executable path")
+	}
+
+	args := []string{
+		"--config-file=" + c.svc.repositoryConfigFileName(),
+		"--log-dir=" + c.logDir,
+		"server", "start",
+		"--address=" + c.address,
+	}
+
+	// the server refuses to start without TLS or a password, unless those are configured using --server-arg,
+	// serve plain HTTP protected by a generated password, but only when it's not reachable from the network.
+	if !c.hasServerArg("--tls-cert-file", "--tls-generate-cert", "--tls-acme-domain", "--insecure") {
+		if !isLocalServerAddress(c.address) {
+			return nil, errors.Errorf("address %v is reachable from the network, configure TLS using --server-arg", c.address)
+		}
+
+		args = append(args, "--insecure")
+	}
+
+	if !c.hasServerArg("--server-password", "--random-password", "--without-password", "--htpasswd-file") {
+		b := make([]byte, serverRandomPasswordLength)
+		if _, err := io.ReadFull(rand.Reader, b); err != nil {
+			return nil, errors.Wrap(err, "unable to generate server password")
+		}
+
+		c.generatedPassword = hex.EncodeToString(b)
+	}
+
+	def := &serviceDefinition{
+		name:       c.name,
+		executable: exe,
+		args:       append(args, c.serverArgs...),
+		logDir:     c.logDir,
+		user:       c.user,
+	}
+
+	// the password is passed through the environment, which unlike the command line isn't visible to other users.
+	if c.generatedPassword != "" {
+		def.env = map[string]string{
+			c.svc.EnvName(serviceServerPasswordEnv): c.generatedPassword,
+		}
+	}
+
+	return def, nil
+}
+
+// isLocalServerAddress returns true if the server address is a unix socket or a loopback address.
+func isLocalServerAddress(addr string) bool {
+	addr = stripProtocol(addr)
+
+	if strings.HasPrefix(addr, "unix:") {
+		return true
+	}
+
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+
+	if host == "localhost" {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+
+	return ip != nil && ip.IsLoopback()
+}
+
+// hasServerArg returns true if any of the provided flags was passed using --server-arg.
+func (c *commandServiceInstall) hasServerArg(flags ...string) bool {
+	for _, a := range c.serverArgs {
+		for _, f := range flags {
+			if a == f || strings.HasPrefix(a, f+"=") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// accountPassword returns the password of the account the service runs as, passwords are not accepted
+// as flags to keep them out of the shell history and process list.
+func (c *commandServiceInstall) accountPassword() (string, error) {
+	if c.user == "" {
+		return "", nil
+	}
+
+	if p, ok := os.LookupEnv(c.svc.EnvName(serviceAccountPasswordEnv)); ok {
+		return p, nil
+	}
+
+	//nolint:wrapcheck
+	return c.svc.askPass(c.svc.Stderr(), "Enter password for "+c.user+": ")
+}
+
+func (c *commandServiceInstall) run(ctx context.Context) error {
+	def, err := c.definition()
+	if err != nil {
+		return err
+	}
+
+	if def.password, err = c.accountPassword(); err != nil {
+		return err
+	}
+
+	//nolint:mnd
+	if err := os.MkdirAll(c.logDir, 0o700); err != nil {
+		return errors.Wrap(err, "unable to create log directory")
+	}
+
+	if err := installOSService(ctx, def); err != nil {
+		return errors.Wrap(err, "unable to install service")
+	}
+
+	log(ctx).Infof("Installed service %v.", def.name)
+
+	if c.generatedPassword != "" {
+		// print it to the stderr bypassing any log file, like 'server start --random-password'.
+		fmt.Fprintln(c.svc.Stderr(), "SERVER PASSWORD:", c.generatedPassword) //nolint:errcheck
+	}
+
+	if !c.start {
+		return nil
+	}
+
+	return errors.Wrap(startOSService(ctx, def.name), "unable to start service")
+}
+
+// commandServiceControl is a command performing a single operation on the named service.
+type commandServiceControl struct {
+	name string
+	verb string
+	op   func(ctx context.Context, name string) error
+}
+
+func (c *commandServiceControl) setup(svc appServices, parent commandParent, verb, help string, op func(ctx context.Context, name string) error) {
+	cmd := parent.Command(verb, help)
+	cmd.Flag("name", "Service name").Default(defaultServiceName).StringVar(&c.name)
+
+	c.verb = verb
+	c.op = op
+	cmd.Action(svc.noRepositoryAction(c.run))
+}
+
+func (c *commandServiceControl) run(ctx context.Context) error {
+	if err := validateServiceName(c.name); err != nil {
+		return err
+	}
+
+	if err := c.op(ctx, c.name); err != nil {
+		return errors.Wrapf(err, "unable to %v service", c.verb)
+	}
+
+	log(ctx).Infof("Service %v: %v succeeded.", c.name, c.verb)
+
+	return nil
+}
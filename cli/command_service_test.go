@@ -0,0 +1,159 @@
+package cli
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testlogging"
+	"github.com/kopia/kopia/internal/testutil"
+)
+
+const serviceTestEnvPrefix = "TSVC_"
+
+func runServiceTestCommand(t *testing.T, args ...string) (stderr io.Reader, wait func() error, interrupt func(os.Signal)) {
+	t.Helper()
+
+	a := NewApp()
+	a.SetEnvNamePrefixForTesting(serviceTestEnvPrefix)
+
+	kp := kingpin.New("test", "test")
+
+	// --log-dir is provided by internal/logfile, which is attached in main and can't be imported here.
+	kp.Flag("log-dir", "Log directory").String()
+
+	stdout, stderr, wait, interrupt := a.RunSubcommand(testlogging.Context(t), kp, nil, args)
+
+	go io.Copy(io.Discard, stdout) //nolint:errcheck
+
+	return stderr, wait, interrupt
+}
+
+func TestServiceInstallDefinitionStartsServer(t *testing.T) {
+	configFile := filepath.Join(testutil.TempDirectory(t), "repository.config")
+
+	t.Setenv(serviceTestEnvPrefix+"KOPIA_PASSWORD", "password")
+	t.Setenv(serviceTestEnvPrefix+"KOPIA_CHECK_FOR_UPDATES", "false")
+
+	stderr, wait, _ := runServiceTestCommand(t, "--config-file="+configFile, "--no-use-keyring",
+		"repo", "create", "filesystem", "--path", testutil.TempDirectory(t))
+
+	go io.Copy(io.Discard, stderr) //nolint:errcheck
+
+	require.NoError(t, wait())
+
+	// the service does not get the password through the environment, only from the persisted credentials.
+	os.Unsetenv(serviceTestEnvPrefix + "KOPIA_PASSWORD") //nolint:errcheck
+
+	svc := NewApp()
+	svc.SetEnvNamePrefixForTesting(serviceTestEnvPrefix)
+	svc.configPath = configFile
+
+	c := &commandServiceInstall{
+		name:    "kopia-server",
+		address: "http://127.0.0.1:0",
+		logDir:  testutil.TempDirectory(t),
+		svc:     svc,
+	}
+
+	def, err := c.definition()
+	require.NoError(t, err)
+	require.NotEmpty(t, c.generatedPassword)
+	require.Contains(t, def.args, "--insecure")
+
+	// the generated password is passed through the environment and never appears on the command line.
+	require.Equal(t, map[string]string{serviceTestEnvPrefix + "KOPIA_SERVER_PASSWORD": c.generatedPassword}, def.env)
+
+	for _, a := range def.args {
+		require.NotContains(t, a, c.generatedPassword)
+	}
+
+	for k, v := range def.env {
+		t.Setenv(k, v)
+	}
+
+	stderr, wait, interrupt := runServiceTestCommand(t, def.args...)
+
+	scanner := bufio.NewScanner(stderr)
+	started := false
+
+	var output []string
+
+	for !started && scanner.Scan() {
+		output = append(output, scanner.Text())
+		started = strings.HasPrefix(scanner.Text(), "SERVER ADDRESS: ")
+	}
+
+	go io.Copy(io.Discard, stderr) //nolint:errcheck
+
+	require.True(t, started, "server did not start: %v", strings.Join(output, "\n"))
+
+	interrupt(os.Interrupt)
+	require.NoError(t, wait())
+}
+
+func TestServiceInstallDefinitionKeepsServerArgs(t *testing.T) {
+	svc := NewApp()
+	svc.configPath = filepath.Join(testutil.TempDirectory(t), "repository.config")
+
+	c := &commandServiceInstall{
+		name:       "kopia-server",
+		address:    "https://0.0.0.0:51515",
+		logDir:     testutil.TempDirectory(t),
+		serverArgs: []string{"--tls-generate-cert", "--htpasswd-file=/etc/kopia/htpasswd"},
+		svc:        svc,
+	}
+
+	def, err := c.definition()
+	require.NoError(t, err)
+	require.Empty(t, c.generatedPassword)
+	require.Empty(t, def.env)
+	require.NotContains(t, def.args, "--insecure")
+
+	for _, a := range def.args {
+		require.False(t, strings.HasPrefix(a, "--server-password"), a)
+	}
+}
+
+func TestServiceInstallDefinitionRequiresTLSForNetworkAddress(t *testing.T) {
+	svc := NewApp()
+	svc.configPath = filepath.Join(testutil.TempDirectory(t), "repository.config")
+
+	cases := []struct {
+		address string
+		wantErr bool
+	}{
+		{"http://127.0.0.1:51515", false},
+		{"http://localhost:51515", false},
+		{"http://[::1]:51515", false},
+		{"unix:/tmp/kopia.sock", false},
+		{"http://0.0.0.0:51515", true},
+		{"http://:51515", true},
+		{"http://192.168.1.10:51515", true},
+		{"http://example.com:51515", true},
+	}
+
+	for _, tc := range cases {
+		c := &commandServiceInstall{
+			name:    "kopia-server",
+			address: tc.address,
+			logDir:  testutil.TempDirectory(t),
+			svc:     svc,
+		}
+
+		def, err := c.definition()
+		if tc.wantErr {
+			require.Error(t, err, tc.address)
+			continue
+		}
+
+		require.NoError(t, err, tc.address)
+		require.Contains(t, def.args, "--insecure", tc.address)
+	}
+}
@@ -11,6 +11,7 @@ type commandSnapshot struct {
 	list        commandSnapshotList
 	migrate     commandSnapshotMigrate
 	pin         commandSnapshotPin
+	report      commandSnapshotReport
 	restore     commandSnapshotRestore
 	verify      commandSnapshotVerify
 }
@@ -27,6 +28,7 @@ func (c *commandSnapshot) setup(svc advancedAppServices, parent commandParent) {
 	c.list.setup(svc, cmd)
 	c.migrate.setup(svc, cmd)
 	c.pin.setup(svc, cmd)
+	c.report.setup(svc, cmd)
 	c.restore.setup(svc, cmd)
 	c.verify.setup(svc, cmd)
 }
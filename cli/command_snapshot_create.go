@@ -2,7 +2,6 @@
 
 import (
 	"context"
-	"fmt"
 	"io"
 	"path/filepath"
 	"strings"
@@ -12,6 +11,8 @@
 
 	"github.com/kopia/kopia/fs"
 	"github.com/kopia/kopia/fs/virtualfs"
+	"github.com/kopia/kopia/notification"
+	"github.com/kopia/kopia/notification/healthcheck"
 	"github.com/kopia/kopia/repo"
 	"github.com/kopia/kopia/snapshot"
 	"github.com/kopia/kopia/snapshot/policy"
@@ -30,7 +31,9 @@ type commandSnapshotCreate struct {
 	snapshotCreateCheckpointInterval      time.Duration
 	snapshotCreateFailFast                bool
 	snapshotCreateForceHash               float64
+	snapshotCreateForceRehash             bool
 	snapshotCreateParallelUploads         int
+	snapshotCreateAsyncPackUploads        int
 	snapshotCreateStartTime               string
 	snapshotCreateEndTime                 string
 	snapshotCreateForceEnableActions      bool
@@ -61,7 +64,9 @@ func (c *commandSnapshotCreate) setup(svc appServices, parent commandParent) {
 	cmd.Flag("description", "Free-form snapshot description.").StringVar(&c.snapshotCreateDescription)
 	cmd.Flag("fail-fast", "Fail fast when creating snapshot.").Envar(svc.EnvName("KOPIA_SNAPSHOT_FAIL_FAST")).BoolVar(&c.snapshotCreateFailFast)
 	cmd.Flag("force-hash", "Force hashing of source files for a given percentage of files [0.0 .. 100.0]").Default("0").Float64Var(&c.snapshotCreateForceHash)
+	cmd.Flag("force-rehash", "Re-read and hash all source files, ignoring metadata of previous snapshots (same as --force-hash=100)").BoolVar(&c.snapshotCreateForceRehash)
 	cmd.Flag("parallel", "Upload N files in parallel").PlaceHolder("N").Default("0").IntVar(&c.snapshotCreateParallelUploads)
+	cmd.Flag("async-pack-uploads", "Upload up to N full packs in the background while continuing to hash files").PlaceHolder("N").Default("0").IntVar(&c.snapshotCreateAsyncPackUploads)
 	cmd.Flag("start-time", "Override snapshot start timestamp.").StringVar(&c.snapshotCreateStartTime)
 	cmd.Flag("end-time", "Override snapshot end timestamp.").StringVar(&c.snapshotCreateEndTime)
 	cmd.Flag("force-enable-actions", "Enable snapshot actions even if globally disabled on this client").Hidden().BoolVar(&c.snapshotCreateForceEnableActions)
@@ -82,7 +87,9 @@ func (c *commandSnapshotCreate) setup(svc appServices, parent commandParent) {
 	c.out.setup(svc)
 
 	c.svc = svc
-	cmd.Action(svc.repositoryWriterAction(c.run))
+	cmd.Action(svc.repositoryWriterActionWithOptions(func(opt *repo.WriteSessionOptions) {
+		opt.AsyncPackWrites = c.snapshotCreateAsyncPackUploads
+	}, c.run))
 }
 
 //nolint:gocyclo
@@ -120,7 +127,7 @@ func (c *commandSnapshotCreate) run(ctx context.Context, rep repo.RepositoryWrit
 
 	u := c.setupUploader(rep)
 
-	var finalErrors []string
+	var finalErrors []error
 
 	tags, err := getTags(c.snapshotCreateTags)
 	if err != nil {
@@ -135,14 +142,25 @@ func (c *commandSnapshotCreate) run(ctx context.Context, rep repo.RepositoryWrit
 
 		fsEntry, sourceInfo, setManual, err := c.getContentToSnapshot(ctx, snapshotDir, rep)
 		if err != nil {
-			finalErrors = append(finalErrors, fmt.Sprintf("failed to prepare source: %s", err))
+			finalErrors = append(finalErrors, errors.Wrap(err, "failed to prepare source"))
+			continue
 		}
 
-		if err := c.snapshotSingleSource(ctx, fsEntry, setManual, rep, u, sourceInfo, tags); err != nil {
-			finalErrors = append(finalErrors, err.Error())
+		hcURL := healthCheckURL(ctx, rep, sourceInfo)
+		healthcheck.Start(ctx, hcURL)
+		notification.SendForSource(ctx, rep, sourceInfo, notification.SnapshotStarted(sourceInfo))
+
+		serr := c.snapshotSingleSource(ctx, fsEntry, setManual, rep, u, sourceInfo, tags)
+		if serr != nil {
+			finalErrors = append(finalErrors, serr)
 		}
+
+		healthcheck.Finish(ctx, hcURL, serr)
+		notification.SendForSource(ctx, rep, sourceInfo, notification.SnapshotResult(sourceInfo, serr))
 	}
 
+	notification.CheckFreeSpace(ctx, rep)
+
 	// ensure we flush at least once in the session to properly close all pending buffers,
 	// otherwise the session will be reported as memory leak.
 	// by default the wrapper function does not flush on errors, which is what we want to do always.
@@ -157,10 +175,24 @@ func (c *commandSnapshotCreate) run(ctx context.Context, rep repo.RepositoryWrit
 	}
 
 	if len(finalErrors) == 1 {
-		return errors.New(finalErrors[0])
+		if len(sources) == 1 {
+			return finalErrors[0]
+		}
+
+		return withSentinel(ErrPartialFailure, finalErrors[0])
+	}
+
+	var messages []string
+	for _, e := range finalErrors {
+		messages = append(messages, e.Error())
 	}
 
-	return errors.Errorf("encountered %v errors:\n%v", len(finalErrors), strings.Join(finalErrors, "\n"))
+	if len(finalErrors) < len(sources) {
+		// some sources were snapshotted successfully.
+		return errors.Wrapf(ErrPartialFailure, "encountered %v errors:\n%v", len(finalErrors), strings.Join(messages, "\n"))
+	}
+
+	return errors.Errorf("encountered %v errors:\n%v", len(finalErrors), strings.Join(messages, "\n"))
 }
 
 func getTags(tagStrings []string) (map[string]string, error) {
@@ -236,6 +268,9 @@ func (c *commandSnapshotCreate) setupUploader(rep repo.RepositoryWriter) *snapsh
 	c.svc.onTerminate(u.Cancel)
 
 	u.ForceHashPercentage = c.snapshotCreateForceHash
+	if c.snapshotCreateForceRehash {
+		u.ForceHashPercentage = 100 //nolint:mnd
+	}
 	u.ParallelUploads = c.snapshotCreateParallelUploads
 
 	u.FailFast = c.snapshotCreateFailFast
@@ -363,13 +398,24 @@ func (c *commandSnapshotCreate) reportSnapshotStatus(ctx context.Context, manife
 		}
 
 		if ds.FatalErrorCount > 0 {
-			return errors.Errorf("Found %v fatal error(s) while snapshotting %v.", ds.FatalErrorCount, sourceInfo) //nolint:revive
+			return errors.Wrapf(ErrPartialFailure, "Found %v fatal error(s) while snapshotting %v.", ds.FatalErrorCount, sourceInfo) //nolint:revive
 		}
 	}
 
 	return nil
 }
 
+// healthCheckURL returns the health check URL from the effective policy of the provided source, if any.
+func healthCheckURL(ctx context.Context, rep repo.Repository, sourceInfo snapshot.SourceInfo) string {
+	pol, _, _, err := policy.GetEffectivePolicy(ctx, rep, sourceInfo)
+	if err != nil {
+		log(ctx).Debugf("unable to get effective policy for %v: %v", sourceInfo, err)
+		return ""
+	}
+
+	return pol.SchedulingPolicy.HealthCheckURL
+}
+
 // findPreviousSnapshotManifest returns the list of previous snapshots for a given source, including
 // last complete snapshot and possibly some number of incomplete snapshots following it.
 func findPreviousSnapshotManifest(ctx context.Context, rep repo.Repository, sourceInfo snapshot.SourceInfo, noLaterThan *fs.UTCTimestamp) ([]*snapshot.Manifest, error) {
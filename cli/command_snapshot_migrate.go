@@ -8,6 +8,7 @@
 	"github.com/pkg/errors"
 
 	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/internal/units"
 	"github.com/kopia/kopia/repo"
 	"github.com/kopia/kopia/snapshot"
 	"github.com/kopia/kopia/snapshot/policy"
@@ -23,6 +24,7 @@ type commandSnapshotMigrate struct {
 	migrateLatestOnly        bool
 	migrateParallel          int
 	applyIgnoreRules         bool
+	migrateDryRun            bool
 
 	svc advancedAppServices
 	out textOutput
@@ -38,6 +40,7 @@ func (c *commandSnapshotMigrate) setup(svc advancedAppServices, parent commandPa
 	cmd.Flag("latest-only", "Only migrate the latest snapshot").BoolVar(&c.migrateLatestOnly)
 	cmd.Flag("parallel", "Number of sources to migrate in parallel").Default("1").IntVar(&c.migrateParallel)
 	cmd.Flag("apply-ignore-rules", "When migrating also apply current ignore rules").BoolVar(&c.applyIgnoreRules)
+	cmd.Flag("dry-run", "Do not actually migrate, only print what would happen").Short('n').BoolVar(&c.migrateDryRun)
 	cmd.Action(svc.repositoryWriterAction(c.run))
 
 	c.svc = svc
@@ -200,6 +203,11 @@ func (c *commandSnapshotMigrate) migrateSinglePolicy(ctx context.Context, source
 		return errors.Wrapf(err, "unable to migrate policy for %v", si)
 	}
 
+	if c.migrateDryRun {
+		log(ctx).Infof("would migrate policy for %v", si)
+		return nil
+	}
+
 	log(ctx).Infof("migrating policy for %v", si)
 
 	return errors.Wrap(policy.SetPolicy(ctx, destRepo, si, pol), "error setting policy")
@@ -269,6 +277,11 @@ func (c *commandSnapshotMigrate) migrateSingleSourceSnapshot(ctx context.Context
 		return nil
 	}
 
+	if c.migrateDryRun {
+		log(ctx).Infof("would migrate snapshot %v of %v at %v (%v files, %v)", m.ID, s, formatTimestamp(m.StartTime.ToTime()), m.Stats.TotalFileCount, units.BytesString(m.Stats.TotalFileSize))
+		return nil
+	}
+
 	log(ctx).Infof("migrating snapshot of %v at %v", s, formatTimestamp(m.StartTime.ToTime()))
 
 	previous, err := findPreviousSnapshotManifest(ctx, destRepo, m.Source, &m.StartTime)
@@ -0,0 +1,139 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/units"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/snapshotreport"
+)
+
+type commandSnapshotReport struct {
+	sources      []string
+	from         string
+	to           string
+	format       string
+	outputFile   string
+	storageStats bool
+
+	out textOutput
+}
+
+func (c *commandSnapshotReport) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("report", "Summarize snapshot history of each source over a time range.")
+	cmd.Arg("source", "Sources to include in the report (defaults to all sources).").StringsVar(&c.sources)
+	cmd.Flag("from", "Include snapshots started at or after the given time (RFC3339 or YYYY-MM-DD).").StringVar(&c.from)
+	cmd.Flag("to", "Include snapshots started before the given time (RFC3339 or YYYY-MM-DD).").StringVar(&c.to)
+	cmd.Flag("format", "Report format").Default("text").EnumVar(&c.format, "text", "csv", "json")
+	cmd.Flag("output", "Write report to the provided file instead of stdout.").StringVar(&c.outputFile)
+	cmd.Flag("storage-stats", "Compute the amount of new data added by snapshots (slow)").BoolVar(&c.storageStats)
+	c.out.setup(svc)
+	cmd.Action(svc.repositoryReaderAction(c.run))
+}
+
+func (c *commandSnapshotReport) run(ctx context.Context, rep repo.Repository) error {
+	opt := snapshotreport.Options{
+		StorageStats: c.storageStats,
+	}
+
+	var err error
+
+	if opt.StartTime, err = parseReportTime(c.from); err != nil {
+		return errors.Wrap(err, "invalid --from")
+	}
+
+	if opt.EndTime, err = parseReportTime(c.to); err != nil {
+		return errors.Wrap(err, "invalid --to")
+	}
+
+	for _, s := range c.sources {
+		src, err := snapshot.ParseSourceInfo(s, rep.ClientOptions().Hostname, rep.ClientOptions().Username)
+		if err != nil {
+			return errors.Wrapf(err, "unable to parse %q", s)
+		}
+
+		opt.Sources = append(opt.Sources, src)
+	}
+
+	summaries, err := snapshotreport.Generate(ctx, rep, opt)
+	if err != nil {
+		return errors.Wrap(err, "unable to generate report")
+	}
+
+	if c.outputFile == "" {
+		return c.writeReport(c.out.stdout(), summaries)
+	}
+
+	f, err := os.Create(c.outputFile)
+	if err != nil {
+		return errors.Wrap(err, "unable to create output file")
+	}
+
+	if err := c.writeReport(f, summaries); err != nil {
+		f.Close() //nolint:errcheck
+
+		return err
+	}
+
+	// the report may not have been fully written until the file is closed.
+	return errors.Wrap(f.Close(), "unable to close output file")
+}
+
+func (c *commandSnapshotReport) writeReport(w io.Writer, summaries []*snapshotreport.SourceSummary) error {
+	switch c.format {
+	case "csv":
+		return errors.Wrap(snapshotreport.WriteCSV(w, summaries), "error writing report")
+
+	case "json":
+		return errors.Wrap(snapshotreport.WriteJSON(w, summaries), "error writing report")
+
+	default:
+		return writeSnapshotReportText(w, summaries)
+	}
+}
+
+func writeSnapshotReportText(w io.Writer, summaries []*snapshotreport.SourceSummary) error {
+	if len(summaries) == 0 {
+		_, err := io.WriteString(w, "No snapshots found.\n")
+		return errors.Wrap(err, "error writing report")
+	}
+
+	for _, s := range summaries {
+		newData := ""
+		if s.NewBytes != nil {
+			newData = " new:" + units.BytesString(*s.NewBytes)
+		}
+
+		//nolint:errcheck
+		fmt.Fprintf(w, "%v\n  snapshots:%v failures:%v file-errors:%v first:%v last:%v\n  duration total:%v avg:%v max:%v last-size:%v%v\n",
+			s.Source,
+			s.SnapshotCount, s.FailureCount, s.FileErrorCount,
+			formatTimestamp(s.FirstSnapshotTime), formatTimestamp(s.LastSnapshotTime),
+			s.TotalDuration.Round(time.Second), s.AverageDuration.Round(time.Second), s.MaxDuration.Round(time.Second),
+			units.BytesString(s.LastSnapshotSize), newData)
+	}
+
+	return nil
+}
+
+// parseReportTime parses the provided time in RFC3339 or YYYY-MM-DD format (in local time zone).
+func parseReportTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+
+	t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+
+	return t, errors.Wrapf(err, "unable to parse time %q", s)
+}
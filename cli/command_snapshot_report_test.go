@@ -0,0 +1,83 @@
+package cli_test
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/snapshot/snapshotreport"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestSnapshotReport(t *testing.T) {
+	t.Parallel()
+
+	runner := testenv.NewInProcRunner(t)
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, runner)
+
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+
+	srcdir1 := testutil.TempDirectory(t)
+	srcdir2 := testutil.TempDirectory(t)
+
+	require.NoError(t, os.WriteFile(filepath.Join(srcdir1, "file1"), []byte{1, 2, 3}, 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(srcdir2, "file1"), []byte{1, 2, 3, 4, 5}, 0o600))
+
+	e.RunAndExpectSuccess(t, "snapshot", "create", srcdir1)
+	e.RunAndExpectSuccess(t, "snapshot", "create", srcdir1)
+	e.RunAndExpectSuccess(t, "snapshot", "create", srcdir2)
+
+	var summaries []*snapshotreport.SourceSummary
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "snapshot", "report", "--format=json", "--storage-stats"), &summaries)
+	require.Len(t, summaries, 2)
+
+	byPath := map[string]*snapshotreport.SourceSummary{}
+	for _, s := range summaries {
+		byPath[s.Source.Path] = s
+	}
+
+	s1 := byPath[srcdir1]
+	require.NotNil(t, s1)
+	require.Equal(t, 2, s1.SnapshotCount)
+	require.Equal(t, 0, s1.FailureCount)
+	require.EqualValues(t, 3, s1.LastSnapshotSize)
+	require.NotNil(t, s1.NewBytes)
+	require.Positive(t, *s1.NewBytes)
+
+	s2 := byPath[srcdir2]
+	require.NotNil(t, s2)
+	require.Equal(t, 1, s2.SnapshotCount)
+	require.EqualValues(t, 5, s2.LastSnapshotSize)
+
+	// single source, CSV written to a file.
+	outFile := filepath.Join(testutil.TempDirectory(t), "report.csv")
+	e.RunAndExpectSuccess(t, "snapshot", "report", srcdir1, "--format=csv", "--output", outFile)
+
+	f, err := os.Open(outFile)
+	require.NoError(t, err)
+
+	defer f.Close()
+
+	records, err := csv.NewReader(f).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	require.Equal(t, "path", records[0][2])
+	require.Equal(t, srcdir1, records[1][2])
+	require.Equal(t, "2", records[1][3])
+
+	// time range in the future has no snapshots.
+	lines := e.RunAndExpectSuccess(t, "snapshot", "report", "--from=2999-01-01")
+	require.Equal(t, []string{"No snapshots found."}, lines)
+
+	lines = e.RunAndExpectSuccess(t, "snapshot", "report", "--to=2999-01-01")
+	require.True(t, strings.HasSuffix(lines[0], srcdir1) || strings.HasSuffix(lines[0], srcdir2), lines[0])
+
+	e.RunAndExpectFailure(t, "snapshot", "report", "--from=yesterday-ish")
+}
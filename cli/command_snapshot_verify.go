@@ -7,6 +7,7 @@
 
 	"github.com/pkg/errors"
 
+	"github.com/kopia/kopia/notification"
 	"github.com/kopia/kopia/repo"
 	"github.com/kopia/kopia/repo/blob"
 	"github.com/kopia/kopia/repo/manifest"
@@ -25,6 +26,8 @@ type commandSnapshotVerify struct {
 
 	fileQueueLength int
 	fileParallelism int
+
+	svc appServices
 }
 
 func (c *commandSnapshotVerify) setup(svc appServices, parent commandParent) {
@@ -41,6 +44,8 @@ func (c *commandSnapshotVerify) setup(svc appServices, parent commandParent) {
 	cmd.Flag("file-parallelism", "Parallelism for file verification").IntVar(&c.fileParallelism)
 	cmd.Flag("verify-files-percent", "Randomly verify a percentage of files by downloading them [0.0 .. 100.0]").Default("0").Float64Var(&c.verifyCommandFilesPercent)
 	cmd.Action(svc.repositoryReaderAction(c.run))
+
+	c.svc = svc
 }
 
 func (c *commandSnapshotVerify) run(ctx context.Context, rep repo.Repository) error {
@@ -57,6 +62,7 @@ func (c *commandSnapshotVerify) run(ctx context.Context, rep repo.Repository) er
 		FileQueueLength:    c.fileQueueLength,
 		Parallelism:        c.fileParallelism,
 		MaxErrors:          c.verifyCommandErrorThreshold,
+		Progress:           c.svc.getVerifyProgress(),
 	}
 
 	if dr, ok := rep.(repo.DirectRepository); ok {
@@ -71,54 +77,79 @@ func (c *commandSnapshotVerify) run(ctx context.Context, rep repo.Repository) er
 	v := snapshotfs.NewVerifier(ctx, rep, opts)
 	defer v.ShowFinalStats(ctx)
 
-	//nolint:wrapcheck
-	return v.InParallel(ctx, func(tw *snapshotfs.TreeWalker) error {
-		manifests, err := c.loadSourceManifests(ctx, rep, c.verifyCommandSources)
-		if err != nil {
+	// only errors reported by the tree walker after all roots have been enqueued indicate
+	// verification failures, other errors (invalid arguments, storage failures) are returned as-is.
+	enqueued := false
+
+	err := v.InParallel(ctx, func(tw *snapshotfs.TreeWalker) error {
+		if err := c.enqueueRoots(ctx, rep, tw); err != nil {
 			return err
 		}
 
-		for _, man := range manifests {
-			rootPath := fmt.Sprintf("%v@%v", man.Source, formatTimestamp(man.StartTime.ToTime()))
+		enqueued = true
 
-			if man.RootEntry == nil {
-				continue
-			}
+		return nil
+	})
+	if err != nil {
+		if !enqueued || ctx.Err() != nil {
+			//nolint:wrapcheck
+			return err
+		}
 
-			root, err := snapshotfs.SnapshotRoot(rep, man)
-			if err != nil {
-				return errors.Wrapf(err, "unable to get snapshot root: %q", rootPath)
-			}
+		notification.Send(ctx, rep, notification.VerificationFailed(err))
+
+		return withSentinel(ErrVerificationFailed, err)
+	}
+
+	return nil
+}
+
+func (c *commandSnapshotVerify) enqueueRoots(ctx context.Context, rep repo.Repository, tw *snapshotfs.TreeWalker) error {
+	manifests, err := c.loadSourceManifests(ctx, rep, c.verifyCommandSources)
+	if err != nil {
+		return err
+	}
+
+	for _, man := range manifests {
+		rootPath := fmt.Sprintf("%v@%v", man.Source, formatTimestamp(man.StartTime.ToTime()))
 
-			// ignore error now, return aggregate error at a higher level.
-			//nolint:errcheck
-			tw.Process(ctx, root, rootPath)
+		if man.RootEntry == nil {
+			continue
 		}
 
-		for _, oidStr := range c.verifyCommandDirObjectIDs {
-			oid, err := snapshotfs.ParseObjectIDWithPath(ctx, rep, oidStr)
-			if err != nil {
-				return errors.Wrapf(err, "unable to parse: %q", oidStr)
-			}
+		root, err := snapshotfs.SnapshotRoot(rep, man)
+		if err != nil {
+			return errors.Wrapf(err, "unable to get snapshot root: %q", rootPath)
+		}
+
+		// ignore error now, return aggregate error at a higher level.
+		//nolint:errcheck
+		tw.Process(ctx, root, rootPath)
+	}
 
-			// ignore error now, return aggregate error at a higher level.
-			//nolint:errcheck
-			tw.Process(ctx, snapshotfs.DirectoryEntry(rep, oid, nil), oidStr)
+	for _, oidStr := range c.verifyCommandDirObjectIDs {
+		oid, err := snapshotfs.ParseObjectIDWithPath(ctx, rep, oidStr)
+		if err != nil {
+			return errors.Wrapf(err, "unable to parse: %q", oidStr)
 		}
 
-		for _, oidStr := range c.verifyCommandFileObjectIDs {
-			oid, err := snapshotfs.ParseObjectIDWithPath(ctx, rep, oidStr)
-			if err != nil {
-				return errors.Wrapf(err, "unable to parse %q", oidStr)
-			}
+		// ignore error now, return aggregate error at a higher level.
+		//nolint:errcheck
+		tw.Process(ctx, snapshotfs.DirectoryEntry(rep, oid, nil), oidStr)
+	}
 
-			// ignore error now, return aggregate error at a higher level.
-			//nolint:errcheck
-			tw.Process(ctx, snapshotfs.AutoDetectEntryFromObjectID(ctx, rep, oid, oidStr), oidStr)
+	for _, oidStr := range c.verifyCommandFileObjectIDs {
+		oid, err := snapshotfs.ParseObjectIDWithPath(ctx, rep, oidStr)
+		if err != nil {
+			return errors.Wrapf(err, "unable to parse %q", oidStr)
 		}
 
-		return nil
-	})
+		// ignore error now, return aggregate error at a higher level.
+		//nolint:errcheck
+		tw.Process(ctx, snapshotfs.AutoDetectEntryFromObjectID(ctx, rep, oid, oidStr), oidStr)
+	}
+
+	return nil
 }
 
 func (c *commandSnapshotVerify) loadSourceManifests(ctx context.Context, rep repo.Repository, sources []string) ([]*snapshot.Manifest, error) {
@@ -0,0 +1,231 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/pkg/errors"
+)
+
+const defaultSystemdOnCalendar = "daily"
+
+type commandSystemd struct {
+	snapshotUnits commandSystemdSnapshotUnits
+	serverUnits   commandSystemdServerUnits
+}
+
+func (c *commandSystemd) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("systemd", "Generate systemd unit files for running Kopia as a service")
+
+	c.snapshotUnits.setup(svc, cmd)
+	c.serverUnits.setup(svc, cmd)
+}
+
+// systemdUnit is a generated systemd unit file.
+type systemdUnit struct {
+	name    string
+	content string
+}
+
+// systemdUnitWriter writes generated units to stdout or to the output directory.
+type systemdUnitWriter struct {
+	outputDir string
+
+	svc appServices
+	out textOutput
+}
+
+func (c *systemdUnitWriter) setup(svc appServices, cmd *kingpin.CmdClause) {
+	cmd.Flag("output-dir", "Write unit files to the provided directory instead of stdout").StringVar(&c.outputDir)
+
+	c.svc = svc
+	c.out.setup(svc)
+}
+
+func (c *systemdUnitWriter) write(ctx context.Context, units []systemdUnit) error {
+	for _, u := range units {
+		if c.outputDir == "" {
+			c.out.printStdout("# %v\n%v\n", u.name, u.content)
+			continue
+		}
+
+		fname := filepath.Join(c.outputDir, u.name)
+
+		//nolint:gosec,mnd
+		if err := os.WriteFile(fname, []byte(u.content), 0o644); err != nil {
+			return errors.Wrap(err, "unable to write unit file")
+		}
+
+		log(ctx).Infof("Wrote %v", fname)
+	}
+
+	return nil
+}
+
+// execStart returns ExecStart= command line invoking the current executable with the provided arguments.
+func (c *systemdUnitWriter) execStart(args ...string) (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", errors.Wrap(err, "unable to determine executable path")
+	}
+
+	parts := []string{systemdQuote(exe), "--config-file=" + systemdQuote(c.svc.repositoryConfigFileName())}
+
+	for _, a := range args {
+		parts = append(parts, systemdQuote(a))
+	}
+
+	return strings.Join(parts, " "), nil
+}
+
+// systemdQuote quotes the argument for use in ExecStart= if necessary.
+func systemdQuote(s string) string {
+	if !strings.ContainsAny(s, " \t\"'\\;$%") {
+		return s
+	}
+
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `$$`, `%`, `%%`)
+
+	return `"` + r.Replace(s) + `"`
+}
+
+var systemdUnitNameInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9_.]+`)
+
+type commandSystemdSnapshotUnits struct {
+	source     string
+	onCalendar string
+	unitName   string
+
+	w systemdUnitWriter
+}
+
+func (c *commandSystemdSnapshotUnits) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("snapshot-timer", "Generate service and timer units that periodically snapshot the provided source")
+	cmd.Arg("source", "Source directory").Required().StringVar(&c.source)
+	cmd.Flag("on-calendar", "Timer schedule in systemd.time(7) calendar event format").Default(defaultSystemdOnCalendar).StringVar(&c.onCalendar)
+	cmd.Flag("unit-name", "Unit name (without suffix), derived from the source path by default").StringVar(&c.unitName)
+
+	c.w.setup(svc, cmd)
+	cmd.Action(svc.noRepositoryAction(c.run))
+}
+
+func (c *commandSystemdSnapshotUnits) run(ctx context.Context) error {
+	src, err := filepath.Abs(c.source)
+	if err != nil {
+		return errors.Wrap(err, "unable to determine absolute path")
+	}
+
+	name := c.unitName
+	if name == "" {
+		name = "kopia-snapshot-" + strings.Trim(systemdUnitNameInvalidChars.ReplaceAllString(src, "-"), "-")
+	}
+
+	execStart, err := c.w.execStart("snapshot", "create", src)
+	if err != nil {
+		return err
+	}
+
+	return c.w.write(ctx, []systemdUnit{
+		{
+			name: name + ".service",
+			content: fmt.Sprintf(`[Unit]
+Description=Kopia snapshot of %v
+Wants=network-online.target
+After=network-online.target
+
+[Service]
+Type=oneshot
+ExecStart=%v
+`, src, execStart),
+		},
+		{
+			name: name + ".timer",
+			content: fmt.Sprintf(`[Unit]
+Description=Periodic Kopia snapshot of %v
+
+[Timer]
+OnCalendar=%v
+Persistent=true
+
+[Install]
+WantedBy=timers.target
+`, src, c.onCalendar),
+		},
+	})
+}
+
+type commandSystemdServerUnits struct {
+	address          string
+	socketActivation bool
+	unitName         string
+	serverArgs       []string
+
+	w systemdUnitWriter
+}
+
+func (c *commandSystemdServerUnits) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("server", "Generate service unit running Kopia server, optionally socket-activated")
+	cmd.Flag("address", "Server address").Default("http://127.0.0.1:51515").StringVar(&c.address)
+	cmd.Flag("socket-activation", "Generate socket unit and start server on first connection").BoolVar(&c.socketActivation)
+	cmd.Flag("unit-name", "Unit name (without suffix)").Default("kopia-server").StringVar(&c.unitName)
+	cmd.Flag("server-arg", "Additional argument passed to 'kopia server start', can be specified multiple times").StringsVar(&c.serverArgs)
+
+	c.w.setup(svc, cmd)
+	cmd.Action(svc.noRepositoryAction(c.run))
+}
+
+func (c *commandSystemdServerUnits) run(ctx context.Context) error {
+	args := []string{"server", "start"}
+
+	if !c.socketActivation {
+		args = append(args, "--address="+c.address)
+	}
+
+	execStart, err := c.w.execStart(append(args, c.serverArgs...)...)
+	if err != nil {
+		return err
+	}
+
+	units := []systemdUnit{
+		{
+			name: c.unitName + ".service",
+			content: fmt.Sprintf(`[Unit]
+Description=Kopia server
+Wants=network-online.target
+After=network-online.target
+
+[Service]
+Type=notify
+ExecStart=%v
+ExecReload=/bin/kill -HUP $MAINPID
+Restart=on-failure
+WatchdogSec=5min
+
+[Install]
+WantedBy=default.target
+`, execStart),
+		},
+	}
+
+	if c.socketActivation {
+		units = append(units, systemdUnit{
+			name: c.unitName + ".socket",
+			content: fmt.Sprintf(`[Unit]
+Description=Kopia server socket
+
+[Socket]
+ListenStream=%v
+
+[Install]
+WantedBy=sockets.target
+`, stripProtocol(c.address)),
+		})
+	}
+
+	return c.w.write(ctx, units)
+}
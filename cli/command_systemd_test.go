@@ -0,0 +1,65 @@
+package cli_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestSystemdSnapshotTimer(t *testing.T) {
+	t.Parallel()
+
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	src := filepath.Join(testutil.TempDirectory(t), "some dir")
+	outDir := testutil.TempDirectory(t)
+
+	e.RunAndExpectSuccess(t, "systemd", "snapshot-timer", src, "--on-calendar=hourly", "--output-dir", outDir)
+
+	entries, err := os.ReadDir(outDir)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	var service, timer string
+
+	for _, ent := range entries {
+		b, err := os.ReadFile(filepath.Join(outDir, ent.Name()))
+		require.NoError(t, err)
+
+		require.True(t, strings.HasPrefix(ent.Name(), "kopia-snapshot-"), ent.Name())
+		require.NotContains(t, ent.Name(), " ")
+
+		switch filepath.Ext(ent.Name()) {
+		case ".service":
+			service = string(b)
+		case ".timer":
+			timer = string(b)
+		}
+	}
+
+	require.Contains(t, service, "Type=oneshot")
+	require.Contains(t, service, `snapshot create "`+src+`"`)
+	require.Contains(t, timer, "OnCalendar=hourly")
+}
+
+func TestSystemdServer(t *testing.T) {
+	t.Parallel()
+
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	lines := e.RunAndExpectSuccess(t, "systemd", "server", "--server-arg=--insecure")
+	require.Contains(t, lines, "# kopia-server.service")
+	require.Contains(t, lines, "Type=notify")
+	require.NotContains(t, lines, "# kopia-server.socket")
+
+	lines = e.RunAndExpectSuccess(t, "systemd", "server", "--socket-activation", "--address=http://127.0.0.1:12345", "--unit-name=my-kopia")
+	require.Contains(t, lines, "# my-kopia.service")
+	require.Contains(t, lines, "# my-kopia.socket")
+	require.Contains(t, lines, "ListenStream=127.0.0.1:12345")
+}
@@ -5,6 +5,7 @@
 	"encoding/base64"
 
 	"github.com/alecthomas/kingpin/v2"
+	"github.com/alecthomas/units"
 	"github.com/pkg/errors"
 
 	"github.com/kopia/kopia/internal/user"
@@ -17,9 +18,13 @@ type commandServerUserAddSet struct {
 	userSetPassword              string
 	userSetPasswordHashAlgorithm string
 	userSetPasswordHash          string
+	userSetStorageQuota          string
+	userSetMaxUploadSpeed        string
 
 	isNew bool // true == 'add', false == 'update'
-	out   textOutput
+
+	svc appServices
+	out textOutput
 }
 
 func (c *commandServerUserAddSet) setup(svc appServices, parent commandParent, isNew bool) {
@@ -37,9 +42,12 @@ func (c *commandServerUserAddSet) setup(svc appServices, parent commandParent, i
 	cmd.Flag("user-password", "Password").StringVar(&c.userSetPassword)
 	cmd.Flag("user-password-hash", "Password hash").StringVar(&c.userSetPasswordHash)
 	cmd.Flag("user-password-hashing-algorithm", "[Experimental] Password hashing algorithm").Hidden().Default(user.DefaultPasswordHashingAlgorithm).EnumVar(&c.userSetPasswordHashAlgorithm, user.PasswordHashingAlgorithms()...)
+	cmd.Flag("storage-quota", "Maximum amount of data the user may store through the server, e.g. 100GB or 'unlimited'").StringVar(&c.userSetStorageQuota)
+	cmd.Flag("max-upload-speed", "Maximum upload speed through the server in bytes per second, e.g. 10MB or 'unlimited'").StringVar(&c.userSetMaxUploadSpeed)
 	cmd.Arg("username", "Username").Required().StringVar(&c.userSetName)
 	cmd.Action(svc.repositoryWriterAction(c.runServerUserAddSet))
 
+	c.svc = svc
 	c.out.setup(svc)
 }
 
@@ -95,13 +103,21 @@ func (c *commandServerUserAddSet) runServerUserAddSet(ctx context.Context, rep r
 		changed = true
 	}
 
+	if err := setUserLimitBytes(ctx, "storage quota", &up.StorageQuotaBytes, c.userSetStorageQuota, &changed); err != nil {
+		return err
+	}
+
+	if err := setUserLimitBytes(ctx, "max upload speed", &up.MaxUploadBytesPerSecond, c.userSetMaxUploadSpeed, &changed); err != nil {
+		return err
+	}
+
 	if up.PasswordHash == nil || c.userAskPassword {
-		pwd, err := askPass(c.out.stdout(), "Enter new password for user "+username+": ")
+		pwd, err := c.svc.askPass(c.out.stdout(), "Enter new password for user "+username+": ")
 		if err != nil {
 			return errors.Wrap(err, "error asking for password")
 		}
 
-		pwd2, err := askPass(c.out.stdout(), "Re-enter new password for verification: ")
+		pwd2, err := c.svc.askPass(c.out.stdout(), "Re-enter new password for verification: ")
 		if err != nil {
 			return errors.Wrap(err, "error asking for password")
 		}
@@ -132,3 +148,31 @@ func (c *commandServerUserAddSet) runServerUserAddSet(ctx context.Context, rep r
 
 	return nil
 }
+
+func setUserLimitBytes(ctx context.Context, desc string, val *int64, str string, changed *bool) error {
+	if str == "" {
+		// not changed
+		return nil
+	}
+
+	*changed = true
+
+	if str == "unlimited" || str == "-" {
+		log(ctx).Infof("Setting %v to unlimited.", desc)
+
+		*val = 0
+
+		return nil
+	}
+
+	v, err := units.ParseBase2Bytes(str)
+	if err != nil || v < 0 {
+		return errors.Errorf("can't parse the %v %q", desc, str)
+	}
+
+	log(ctx).Infof("Setting %v to %v.", desc, v)
+
+	*val = int64(v)
+
+	return nil
+}
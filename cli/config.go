@@ -7,6 +7,7 @@
 	"os"
 	"os/signal"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"syscall"
 
@@ -43,6 +44,7 @@ func (c *App) onTerminate(f func()) {
 			}
 
 		case <-s:
+		case <-c.osServiceStop:
 		}
 		f()
 	}()
@@ -78,6 +80,8 @@ func (c *App) optionsFromFlags(ctx context.Context) *repo.Options {
 		DisableInternalLog:  c.disableInternalLog,
 		UpgradeOwnerID:      c.upgradeOwnerID,
 		DoNotWaitForUpgrade: c.doNotWaitForUpgrade,
+		OpenTimeout:         c.openTimeout,
+		ReadOnly:            c.openReadOnly,
 
 		// when a fatal error is encountered in the repository, run all registered callbacks
 		// and exit the program.
@@ -95,14 +99,34 @@ func (c *App) optionsFromFlags(ctx context.Context) *repo.Options {
 	}
 }
 
+const connectionProfilesDir = "profiles"
+
+var validConnectionProfileName = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_-]*$`)
+
+func validateConnectionProfileName(name string) error {
+	if name != "" && !validConnectionProfileName.MatchString(name) {
+		return errors.Errorf("invalid profile name %q, must consist of letters, digits, '-' and '_'", name)
+	}
+
+	return nil
+}
+
 func (c *App) repositoryConfigFileName() string {
-	if filepath.Base(c.configPath) != c.configPath {
-		return c.configPath
+	configPath := c.configPath
+
+	if filepath.Base(configPath) == configPath {
+		// bare filename specified without any directory (absolute or relative)
+		// resolve against OS-specific directory.
+		configPath = filepath.Join(ospath.ConfigDir(), configPath)
+	}
+
+	if c.connectionProfile != "" {
+		// named profiles live in a subdirectory next to the main config file, one file per profile,
+		// so that they never alias the main config file or each other.
+		return filepath.Join(filepath.Dir(configPath), connectionProfilesDir, c.connectionProfile+".config")
 	}
 
-	// bare filename specified without any directory (absolute or relative)
-	// resolve against OS-specific directory.
-	return filepath.Join(ospath.ConfigDir(), c.configPath)
+	return configPath
 }
 
 func resolveSymlink(path string) (string, error) {
@@ -0,0 +1,125 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"syscall"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/blob"
+)
+
+// Process exit codes returned by kopia, these are stable and can be relied upon by scripts.
+const (
+	ExitCodeSuccess                     = 0
+	ExitCodeGenericError                = 1
+	ExitCodeInvalidCredentials          = 2
+	ExitCodeRepositoryNotInitialized    = 3
+	ExitCodeRepositoryUpgradeInProgress = 4
+	ExitCodePartialFailure              = 5
+	ExitCodeVerificationFailed          = 6
+	ExitCodeStorageUnreachable          = 7
+	ExitCodeNonInteractive              = 8
+)
+
+var (
+	// ErrPartialFailure is returned when a command completed but some of its work failed,
+	// for example when a snapshot was created with fatal errors or only some sources were snapshotted.
+	ErrPartialFailure = errors.New("completed with errors")
+
+	// ErrVerificationFailed is returned when verification of snapshots or contents found problems.
+	ErrVerificationFailed = errors.New("verification failed")
+)
+
+// sentinelError attaches a sentinel error recognized by ExitCodeForError to an error, preserving its chain.
+type sentinelError struct {
+	sentinel error
+	err      error
+}
+
+func (e sentinelError) Error() string {
+	return e.err.Error() + ": " + e.sentinel.Error()
+}
+
+func (e sentinelError) Unwrap() error {
+	return e.err
+}
+
+func (e sentinelError) Is(target error) bool {
+	return target == e.sentinel //nolint:errorlint
+}
+
+// withSentinel returns an error which matches both the provided sentinel and err using errors.Is().
+func withSentinel(sentinel, err error) error {
+	return sentinelError{sentinel, err}
+}
+
+// ExitCodeForError returns the process exit code that corresponds to the provided error.
+func ExitCodeForError(err error) int {
+	switch {
+	case err == nil:
+		return ExitCodeSuccess
+	case errors.Is(err, repo.ErrInvalidPassword), errors.Is(err, blob.ErrInvalidCredentials):
+		return ExitCodeInvalidCredentials
+	case errors.Is(err, repo.ErrRepositoryNotInitialized):
+		return ExitCodeRepositoryNotInitialized
+	case errors.Is(err, repo.ErrRepositoryUnavailableDueToUpgradeInProgress):
+		return ExitCodeRepositoryUpgradeInProgress
+	case errors.Is(err, ErrPartialFailure):
+		return ExitCodePartialFailure
+	case errors.Is(err, ErrVerificationFailed):
+		return ExitCodeVerificationFailed
+	case errors.Is(err, ErrNonInteractive):
+		return ExitCodeNonInteractive
+	case isStorageUnreachable(err):
+		return ExitCodeStorageUnreachable
+	default:
+		return ExitCodeGenericError
+	}
+}
+
+// isStorageUnreachable returns true if the error indicates that the storage or server could not be reached.
+// Other timeouts, such as context.DeadlineExceeded, are not treated as connectivity errors.
+func isStorageUnreachable(err error) bool {
+	var (
+		dnsErr *net.DNSError
+		opErr  *net.OpError
+	)
+
+	switch {
+	case errors.Is(err, repo.ErrOpenTimeout):
+		return true
+	case errors.Is(err, syscall.ECONNREFUSED), errors.Is(err, syscall.EHOSTUNREACH), errors.Is(err, syscall.ENETUNREACH):
+		return true
+	case errors.As(err, &dnsErr):
+		return true
+	case errors.As(err, &opErr):
+		// also matches dial errors wrapped in *url.Error by HTTP clients.
+		return opErr.Op == "dial"
+	default:
+		return false
+	}
+}
+
+// ErrorSummary is a machine-readable summary of a failed command printed when --error-json is set.
+type ErrorSummary struct {
+	Command  string `json:"command"`
+	Error    string `json:"error"`
+	ExitCode int    `json:"exitCode"`
+}
+
+func (c *App) printErrorSummaryJSON(err error) {
+	b, merr := json.Marshal(ErrorSummary{
+		Command:  c.currentActionName(),
+		Error:    err.Error(),
+		ExitCode: ExitCodeForError(err),
+	})
+	if merr != nil {
+		return
+	}
+
+	fmt.Fprintf(c.Stderr(), "%s\n", b) //nolint:errcheck
+}
@@ -0,0 +1,22 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/repo/blob"
+)
+
+func TestWithSentinelPreservesCause(t *testing.T) {
+	cause := errors.Wrap(blob.ErrBlobNotFound, "unable to read object")
+
+	err := withSentinel(ErrVerificationFailed, cause)
+
+	require.ErrorIs(t, err, ErrVerificationFailed)
+	require.ErrorIs(t, err, blob.ErrBlobNotFound)
+	require.NotErrorIs(t, err, ErrPartialFailure)
+	require.Equal(t, "unable to read object: BLOB not found: verification failed", err.Error())
+	require.Equal(t, ExitCodeVerificationFailed, ExitCodeForError(err))
+}
@@ -0,0 +1,107 @@
+package cli_test
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/cli"
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestExitCodeForError(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		err  error
+		want int
+	}{
+		{nil, cli.ExitCodeSuccess},
+		{errors.New("some error"), cli.ExitCodeGenericError},
+		{repo.ErrInvalidPassword, cli.ExitCodeInvalidCredentials},
+		{errors.Wrap(repo.ErrInvalidPassword, "open repository"), cli.ExitCodeInvalidCredentials},
+		{errors.Wrap(blob.ErrInvalidCredentials, "list blobs"), cli.ExitCodeInvalidCredentials},
+		{errors.Wrap(repo.ErrRepositoryNotInitialized, "connect"), cli.ExitCodeRepositoryNotInitialized},
+		{repo.ErrRepositoryUnavailableDueToUpgradeInProgress, cli.ExitCodeRepositoryUpgradeInProgress},
+		{errors.Wrap(cli.ErrPartialFailure, "snapshot"), cli.ExitCodePartialFailure},
+		{errors.Wrap(cli.ErrVerificationFailed, "encountered 3 errors"), cli.ExitCodeVerificationFailed},
+		{errors.Wrap(cli.ErrNonInteractive, "unable to prompt"), cli.ExitCodeNonInteractive},
+		{errors.Wrap(repo.ErrOpenTimeout, "open"), cli.ExitCodeStorageUnreachable},
+		{errors.Wrap(&net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}, "list blobs"), cli.ExitCodeStorageUnreachable},
+		{errors.Wrap(&url.Error{Op: "Get", URL: "https://example.com", Err: &net.OpError{Op: "dial", Net: "tcp", Err: os.ErrDeadlineExceeded}}, "list blobs"), cli.ExitCodeStorageUnreachable},
+		{errors.Wrap(&net.DNSError{Err: "no such host", Name: "example.com", IsNotFound: true}, "list blobs"), cli.ExitCodeStorageUnreachable},
+		{errors.Wrap(&net.OpError{Op: "read", Net: "tcp", Err: os.ErrDeadlineExceeded}, "get blob"), cli.ExitCodeGenericError},
+		{errors.Wrap(context.DeadlineExceeded, "snapshot"), cli.ExitCodeGenericError},
+		{errors.Wrap(os.ErrDeadlineExceeded, "read"), cli.ExitCodeGenericError},
+	}
+
+	for _, tc := range cases {
+		require.Equal(t, tc.want, cli.ExitCodeForError(tc.err), "%v", tc.err)
+	}
+}
+
+func TestErrorJSON(t *testing.T) {
+	t.Parallel()
+
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+	e.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	_, stderr := e.RunAndExpectFailure(t, "repo", "connect", "filesystem", "--path", e.RepoDir, "--password=wrong-password", "--error-json")
+	require.NotEmpty(t, stderr)
+
+	var es cli.ErrorSummary
+
+	require.NoError(t, json.Unmarshal([]byte(stderr[len(stderr)-1]), &es))
+	require.Equal(t, "repository connect filesystem", es.Command)
+	require.Equal(t, cli.ExitCodeInvalidCredentials, es.ExitCode)
+	require.NotEmpty(t, es.Error)
+}
+
+func TestSnapshotCreatePartialFailure(t *testing.T) {
+	t.Parallel()
+
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+
+	// a source which can't be prepared is reported once and does not fail the other sources.
+	_, stderr := e.RunAndExpectFailure(t, "snapshot", "create", testutil.TempDirectory(t), filepath.Join(testutil.TempDirectory(t), "no-such-dir"), "--error-json")
+	require.NotEmpty(t, stderr)
+
+	var es cli.ErrorSummary
+
+	require.NoError(t, json.Unmarshal([]byte(stderr[len(stderr)-1]), &es))
+	require.Equal(t, cli.ExitCodePartialFailure, es.ExitCode)
+	require.Contains(t, es.Error, "failed to prepare source")
+}
+
+func TestSnapshotVerifyInvalidArgument(t *testing.T) {
+	t.Parallel()
+
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+
+	// errors unrelated to the verification itself are not reported as verification failures.
+	_, stderr := e.RunAndExpectFailure(t, "snapshot", "verify", "--file-id=no-such-object", "--error-json")
+	require.NotEmpty(t, stderr)
+
+	var es cli.ErrorSummary
+
+	require.NoError(t, json.Unmarshal([]byte(stderr[len(stderr)-1]), &es))
+	require.Equal(t, cli.ExitCodeGenericError, es.ExitCode)
+	require.Contains(t, es.Error, "unable to parse")
+}
@@ -0,0 +1,41 @@
+package cli
+
+import (
+	"context"
+	"runtime/debug"
+
+	"github.com/alecthomas/kingpin/v2"
+
+	"github.com/kopia/kopia/internal/gather"
+	"github.com/kopia/kopia/internal/iocopy"
+	"github.com/kopia/kopia/internal/units"
+)
+
+// fraction of the memory limit that can be retained by buffer pools.
+const freeListBudgetDivisor = 4
+
+type memoryFlags struct {
+	memoryLimitMB  int64
+	ioBufferSizeKB int
+}
+
+func (c *memoryFlags) setup(svc appServices, app *kingpin.Application) {
+	app.Flag("memory-limit-mb", "Soft limit on the memory used by Kopia, also limits memory retained by buffer pools (0 = unlimited)").Envar(svc.EnvName("KOPIA_MEMORY_LIMIT_MB")).Int64Var(&c.memoryLimitMB)
+	app.Flag("io-buffer-size-kb", "Size of buffers used when copying file data").Hidden().Envar(svc.EnvName("KOPIA_IO_BUFFER_SIZE_KB")).IntVar(&c.ioBufferSizeKB)
+}
+
+// apply applies memory-related settings to the current process.
+func (c *memoryFlags) apply(ctx context.Context) {
+	if c.memoryLimitMB > 0 {
+		limit := c.memoryLimitMB << 20 //nolint:mnd
+
+		debug.SetMemoryLimit(limit)
+		gather.SetFreeListBudget(limit / freeListBudgetDivisor)
+
+		log(ctx).Debugf("memory limit set to %v", units.BytesString(limit))
+	}
+
+	if c.ioBufferSizeKB > 0 {
+		iocopy.SetBufferSize(c.ioBufferSizeKB << 10) //nolint:mnd
+	}
+}
@@ -11,16 +11,22 @@
 	"golang.org/x/term"
 
 	"github.com/kopia/kopia/internal/passwordpersist"
+	"github.com/kopia/kopia/repo"
 )
 
-func askForNewRepositoryPassword(out io.Writer) (string, error) {
+// ErrNonInteractive is returned when a command needs to prompt the user while interactive prompts are disabled.
+var ErrNonInteractive = errors.New("interactive prompts are disabled")
+
+func askForNewRepositoryPassword(svc appServices) (string, error) {
+	out := svc.stdout()
+
 	for {
-		p1, err := askPass(out, "Enter password to create new repository: ")
+		p1, err := svc.askPass(out, "Enter password to create new repository: ")
 		if err != nil {
 			return "", errors.Wrap(err, "password entry")
 		}
 
-		p2, err := askPass(out, "Re-enter password for verification: ")
+		p2, err := svc.askPass(out, "Re-enter password for verification: ")
 		if err != nil {
 			return "", errors.Wrap(err, "password verification")
 		}
@@ -33,28 +39,32 @@ func askForNewRepositoryPassword(out io.Writer) (string, error) {
 	}
 }
 
-func askForChangedRepositoryPassword(out io.Writer) (string, error) {
+func askForChangedRepositoryPassword(svc appServices) (string, error) {
+	out := svc.stdout()
+
 	for {
-		p1, err := askPass(out, "Enter new password: ")
+		p1, err := svc.askPass(out, "Enter new password: ")
 		if err != nil {
 			return "", errors.Wrap(err, "password entry")
 		}
 
-		p2, err := askPass(out, "Re-enter password for verification: ")
+		p2, err := svc.askPass(out, "Re-enter password for verification: ")
 		if err != nil {
 			return "", errors.Wrap(err, "password verification")
 		}
 
 		if p1 != p2 {
-			fmt.Println("Passwords don't match!")
+			fmt.Fprintln(out, "Passwords don't match!") //nolint:errcheck
 		} else {
 			return p1, nil
 		}
 	}
 }
 
-func askForExistingRepositoryPassword(out io.Writer) (string, error) {
-	p1, err := askPass(out, "Enter password to open repository: ")
+func askForExistingRepositoryPassword(svc appServices) (string, error) {
+	out := svc.stdout()
+
+	p1, err := svc.askPass(out, "Enter password to open repository: ")
 	if err != nil {
 		return "", err
 	}
@@ -75,10 +85,30 @@ func (c *App) getPasswordFromFlags(ctx context.Context, isCreate, allowPersisten
 		return strings.TrimSpace(c.password), nil
 	case isCreate:
 		// this is a new repository, ask for password
-		return askForNewRepositoryPassword(c.stdoutWriter)
+		return askForNewRepositoryPassword(c)
 	case allowPersistent:
-		// try fetching the password from persistent storage specific to the configuration file.
-		pass, err := c.passwordPersistenceStrategy().GetPassword(ctx, c.repositoryConfigFileName())
+		strategy, err := c.persistentPasswordStrategy()
+		if err != nil {
+			return "", err
+		}
+
+		return c.getPasswordFromFlagsOrSource(ctx, strategy, true)
+	}
+
+	// fall back to asking for existing password
+	return askForExistingRepositoryPassword(c)
+}
+
+// getPasswordFromFlagsOrSource returns the password provided via flags or, if useStrategy is set, fetched
+// using the provided strategy, falling back to asking for the existing password.
+func (c *App) getPasswordFromFlagsOrSource(ctx context.Context, strategy passwordpersist.Strategy, useStrategy bool) (string, error) {
+	if c.password != "" {
+		// password provided via --password flag or KOPIA_PASSWORD environment variable
+		return strings.TrimSpace(c.password), nil
+	}
+
+	if useStrategy {
+		pass, err := strategy.GetPassword(ctx, c.repositoryConfigFileName())
 		if err == nil {
 			return pass, nil
 		}
@@ -89,11 +119,34 @@ func (c *App) getPasswordFromFlags(ctx context.Context, isCreate, allowPersisten
 	}
 
 	// fall back to asking for existing password
-	return askForExistingRepositoryPassword(c.stdoutWriter)
+	return askForExistingRepositoryPassword(c)
+}
+
+// persistentPasswordStrategy returns the strategy used to fetch the password of the connected repository,
+// which is the password source specified in the configuration file or the password persistence strategy.
+func (c *App) persistentPasswordStrategy() (passwordpersist.Strategy, error) {
+	lc, err := repo.LoadConfigFromFile(c.repositoryConfigFileName())
+	if err != nil || lc.PasswordSource == "" {
+		//nolint:nilerr
+		return c.passwordPersistenceStrategy(), nil
+	}
+
+	s, err := passwordpersist.Source(lc.PasswordSource)
+
+	return s, errors.Wrap(err, "invalid password source in configuration file")
+}
+
+// askPass presents a given prompt and asks the user for password, unless interactive prompts are disabled.
+func (c *App) askPass(out io.Writer, prompt string) (string, error) {
+	if !c.interactive {
+		return "", errors.Wrapf(ErrNonInteractive, "unable to prompt %q", strings.TrimSpace(prompt))
+	}
+
+	return readPasswordFromTerminal(out, prompt)
 }
 
-// askPass presents a given prompt and asks the user for password.
-func askPass(out io.Writer, prompt string) (string, error) {
+// readPasswordFromTerminal presents a given prompt and reads the password from the terminal.
+func readPasswordFromTerminal(out io.Writer, prompt string) (string, error) {
 	for range 5 {
 		fmt.Fprint(out, prompt) //nolint:errcheck
 
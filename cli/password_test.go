@@ -0,0 +1,45 @@
+package cli_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/cli"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestNoInteractive(t *testing.T) {
+	t.Parallel()
+
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	delete(e.Environment, "KOPIA_PASSWORD")
+
+	_, stderr := e.RunAndExpectFailure(t, "repo", "create", "filesystem", "--path", e.RepoDir, "--no-interactive")
+	require.Contains(t, strings.Join(stderr, "\n"), cli.ErrNonInteractive.Error())
+}
+
+func TestPasswordSourceKeyFile(t *testing.T) {
+	t.Parallel()
+
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+
+	keyFile := filepath.Join(t.TempDir(), "repo.key")
+	require.NoError(t, os.WriteFile(keyFile, []byte(testenv.TestRepoPassword+"\n"), 0o600))
+
+	e2 := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+	delete(e2.Environment, "KOPIA_PASSWORD")
+
+	e2.RunAndExpectFailure(t, "repo", "connect", "filesystem", "--path", e.RepoDir, "--password-source", "no-such-source", "--no-interactive")
+	e2.RunAndExpectSuccess(t, "repo", "connect", "filesystem", "--path", e.RepoDir, "--password-source", "file:"+keyFile, "--no-interactive")
+	e2.RunAndExpectSuccess(t, "snapshot", "ls", "--no-interactive")
+
+	// the password is read from the key file each time the repository is opened.
+	require.NoError(t, os.WriteFile(keyFile, []byte("wrong-password"), 0o600))
+	e2.RunAndExpectFailure(t, "snapshot", "ls", "--no-interactive")
+}
@@ -0,0 +1,33 @@
+package cli_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestRepositoryReadOnlyFlag(t *testing.T) {
+	t.Parallel()
+
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	srcdir := testutil.TempDirectory(t)
+
+	e.RunAndExpectSuccess(t, "snapshot", "create", srcdir)
+
+	snapshots := e.RunAndExpectSuccess(t, "snapshot", "list", srcdir)
+
+	require.Equal(t, snapshots, e.RunAndExpectSuccess(t, "--repository-read-only", "snapshot", "list", srcdir))
+	e.RunAndExpectSuccess(t, "--repository-read-only", "snapshot", "verify")
+	e.RunAndExpectFailure(t, "--repository-read-only", "snapshot", "create", srcdir)
+
+	// read-only mode only applies to a single invocation.
+	e.RunAndExpectSuccess(t, "snapshot", "create", srcdir)
+	require.Len(t, e.RunAndExpectSuccess(t, "snapshot", "list", srcdir), len(snapshots)+1)
+}
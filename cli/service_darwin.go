@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+func launchdPlistPath(name string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.Wrap(err, "unable to determine home directory")
+	}
+
+	return filepath.Join(home, "Library", "LaunchAgents", launchdLabel(name)+".plist"), nil
+}
+
+func launchdDomain() string {
+	return "gui/" + strconv.Itoa(os.Getuid())
+}
+
+func launchctl(ctx context.Context, args ...string) error {
+	out, err := exec.CommandContext(ctx, "launchctl", args...).CombinedOutput()
+	if err != nil {
+		return errors.Wrapf(err, "launchctl %v: %s", args[0], out)
+	}
+
+	return nil
+}
+
+func installOSService(ctx context.Context, def *serviceDefinition) error {
+	fname, err := launchdPlistPath(def.name)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(fname); err == nil {
+		return errors.Errorf("service %v already exists", def.name)
+	}
+
+	//nolint:mnd
+	if err := os.MkdirAll(filepath.Dir(fname), 0o755); err != nil {
+		return errors.Wrap(err, "unable to create LaunchAgents directory")
+	}
+
+	// the definition may contain the server password, so it's only readable by the owner.
+	//nolint:mnd
+	if err := os.WriteFile(fname, []byte(launchdPlist(def)), 0o600); err != nil {
+		return errors.Wrap(err, "unable to write launchd agent definition")
+	}
+
+	log(ctx).Debugf("wrote %v", fname)
+
+	return nil
+}
+
+func uninstallOSService(ctx context.Context, name string) error {
+	fname, err := launchdPlistPath(name)
+	if err != nil {
+		return err
+	}
+
+	// the agent may not be loaded, ignore errors.
+	_ = launchctl(ctx, "bootout", launchdDomain()+"/"+launchdLabel(name))
+
+	return errors.Wrap(os.Remove(fname), "unable to remove launchd agent definition")
+}
+
+func startOSService(ctx context.Context, name string) error {
+	fname, err := launchdPlistPath(name)
+	if err != nil {
+		return err
+	}
+
+	// load the agent unless already loaded, which starts it because of RunAtLoad.
+	if launchctl(ctx, "print", launchdDomain()+"/"+launchdLabel(name)) != nil {
+		return launchctl(ctx, "bootstrap", launchdDomain(), fname)
+	}
+
+	return launchctl(ctx, "kickstart", launchdDomain()+"/"+launchdLabel(name))
+}
+
+func stopOSService(ctx context.Context, name string) error {
+	// unloading the agent prevents KeepAlive from restarting it until it's started again or the user logs in.
+	return launchctl(ctx, "bootout", launchdDomain()+"/"+launchdLabel(name))
+}
@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"path/filepath"
+	"sort"
+)
+
+const (
+	launchdLabelPrefix      = "io.kopia."
+	launchdThrottleInterval = 60 // seconds between restarts after a failure
+)
+
+func launchdLabel(name string) string {
+	return launchdLabelPrefix + name
+}
+
+// launchdPlist returns launchd agent definition which starts the service at login,
+// restarts it when it exits with an error and redirects its output to the log directory.
+func launchdPlist(def *serviceDefinition) string {
+	var args bytes.Buffer
+
+	for _, a := range append([]string{def.executable}, def.args...) {
+		fmt.Fprintf(&args, "\t\t<string>%v</string>\n", xmlEscape(a))
+	}
+
+	var env bytes.Buffer
+
+	if len(def.env) > 0 {
+		var names []string
+
+		for k := range def.env {
+			names = append(names, k)
+		}
+
+		sort.Strings(names)
+
+		env.WriteString("\t<key>EnvironmentVariables</key>\n\t<dict>\n")
+
+		for _, k := range names {
+			fmt.Fprintf(&env, "\t\t<key>%v</key>\n\t\t<string>%v</string>\n", xmlEscape(k), xmlEscape(def.env[k]))
+		}
+
+		env.WriteString("\t</dict>\n")
+	}
+
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>%v</string>
+	<key>ProgramArguments</key>
+	<array>
+%v	</array>
+%v	<key>RunAtLoad</key>
+	<true/>
+	<key>KeepAlive</key>
+	<dict>
+		<key>SuccessfulExit</key>
+		<false/>
+	</dict>
+	<key>ThrottleInterval</key>
+	<integer>%v</integer>
+	<key>ProcessType</key>
+	<string>Background</string>
+	<key>StandardOutPath</key>
+	<string>%v</string>
+	<key>StandardErrorPath</key>
+	<string>%v</string>
+</dict>
+</plist>
+`,
+		xmlEscape(launchdLabel(def.name)),
+		args.String(),
+		env.String(),
+		launchdThrottleInterval,
+		xmlEscape(filepath.Join(def.logDir, def.name+".stdout.log")),
+		xmlEscape(filepath.Join(def.logDir, def.name+".stderr.log")))
+}
+
+func xmlEscape(s string) string {
+	var b bytes.Buffer
+
+	xml.EscapeText(&b, []byte(s)) //nolint:errcheck
+
+	return b.String()
+}
@@ -0,0 +1,45 @@
+package cli
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLaunchdPlist(t *testing.T) {
+	p := launchdPlist(&serviceDefinition{
+		name:       "kopia-server",
+		executable: "/Applications/Kopia & Co/kopia",
+		args:       []string{"--config-file=/Users/me/repository.config", "server", "start", "--server-arg=<x>"},
+		logDir:     "/Users/me/Library/Logs/kopia",
+		env:        map[string]string{"KOPIA_SERVER_PASSWORD": "p<w>d"},
+	})
+
+	// must be well-formed XML.
+	d := xml.NewDecoder(strings.NewReader(p))
+	for {
+		_, err := d.Token()
+		if err != nil {
+			require.Equal(t, "EOF", err.Error())
+			break
+		}
+	}
+
+	require.Contains(t, p, "<string>io.kopia.kopia-server</string>")
+	require.Contains(t, p, "<string>/Applications/Kopia &amp; Co/kopia</string>")
+	require.Contains(t, p, "<string>--server-arg=&lt;x&gt;</string>")
+	require.Contains(t, p, "<string>/Users/me/Library/Logs/kopia/kopia-server.stderr.log</string>")
+	require.Contains(t, p, "<key>SuccessfulExit</key>\n\t\t<false/>")
+	require.Contains(t, p, "<key>EnvironmentVariables</key>\n\t<dict>\n\t\t<key>KOPIA_SERVER_PASSWORD</key>\n\t\t<string>p&lt;w&gt;d</string>\n\t</dict>")
+	require.NotContains(t, launchdPlist(&serviceDefinition{name: "kopia-server"}), "EnvironmentVariables")
+}
+
+func TestValidateServiceName(t *testing.T) {
+	require.NoError(t, validateServiceName("kopia-server"))
+	require.NoError(t, validateServiceName("kopia_2.server"))
+	require.Error(t, validateServiceName(""))
+	require.Error(t, validateServiceName("../evil"))
+	require.Error(t, validateServiceName("with space"))
+}
@@ -0,0 +1,9 @@
+//go:build !windows
+
+package cli
+
+// osServiceStopRequested returns nil since outside of Windows services are stopped
+// using SIGTERM (launchd, systemd), which is already handled by onTerminate().
+func osServiceStopRequested() <-chan struct{} {
+	return nil
+}
@@ -0,0 +1,27 @@
+//go:build !windows && !darwin
+
+package cli
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+var errServiceNotSupported = errors.New("services are only supported on Windows and macOS, use 'kopia systemd server' instead")
+
+func installOSService(_ context.Context, _ *serviceDefinition) error {
+	return errServiceNotSupported
+}
+
+func uninstallOSService(_ context.Context, _ string) error {
+	return errServiceNotSupported
+}
+
+func startOSService(_ context.Context, _ string) error {
+	return errServiceNotSupported
+}
+
+func stopOSService(_ context.Context, _ string) error {
+	return errServiceNotSupported
+}
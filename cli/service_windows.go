@@ -0,0 +1,220 @@
+package cli
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/windows/registry"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/eventlog"
+	"golang.org/x/sys/windows/svc/mgr"
+
+	"github.com/kopia/kopia/internal/clock"
+)
+
+const (
+	windowsServiceRestartDelay     = 1 * time.Minute
+	windowsServiceFailureResetTime = 24 * time.Hour
+	windowsServiceStopTimeout      = 1 * time.Minute
+	windowsServiceStopPollInterval = 300 * time.Millisecond
+)
+
+func withServiceManager(f func(m *mgr.Mgr) error) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return errors.Wrap(err, "unable to connect to service manager")
+	}
+
+	defer m.Disconnect() //nolint:errcheck
+
+	return f(m)
+}
+
+func withService(name string, f func(s *mgr.Service) error) error {
+	return withServiceManager(func(m *mgr.Mgr) error {
+		s, err := m.OpenService(name)
+		if err != nil {
+			return errors.Wrapf(err, "unable to open service %v", name)
+		}
+
+		defer s.Close() //nolint:errcheck
+
+		return f(s)
+	})
+}
+
+func installOSService(ctx context.Context, def *serviceDefinition) error {
+	return withServiceManager(func(m *mgr.Mgr) error {
+		if s, err := m.OpenService(def.name); err == nil {
+			s.Close() //nolint:errcheck
+			return errors.Errorf("service %v already exists", def.name)
+		}
+
+		s, err := m.CreateService(def.name, def.executable, mgr.Config{
+			DisplayName:      "Kopia (" + def.name + ")",
+			Description:      "Kopia server performing scheduled snapshots and maintenance.",
+			StartType:        mgr.StartAutomatic,
+			DelayedAutoStart: true,
+			ServiceStartName: def.user,
+			Password:         def.password,
+		}, def.args...)
+		if err != nil {
+			return errors.Wrap(err, "unable to create service")
+		}
+
+		defer s.Close() //nolint:errcheck
+
+		if err := setServiceEnvironment(def.name, def.env); err != nil {
+			return err
+		}
+
+		// restart the service after a delay whenever it fails, resetting the failure count after a day.
+		if err := s.SetRecoveryActions([]mgr.RecoveryAction{
+			{Type: mgr.ServiceRestart, Delay: windowsServiceRestartDelay},
+			{Type: mgr.ServiceRestart, Delay: windowsServiceRestartDelay},
+			{Type: mgr.ServiceRestart, Delay: windowsServiceRestartDelay},
+		}, uint32(windowsServiceFailureResetTime/time.Second)); err != nil {
+			return errors.Wrap(err, "unable to set recovery actions")
+		}
+
+		if err := s.SetRecoveryActionsOnNonCrashFailures(true); err != nil {
+			return errors.Wrap(err, "unable to set recovery actions")
+		}
+
+		if err := eventlog.InstallAsEventCreate(def.name, eventlog.Error|eventlog.Warning|eventlog.Info); err != nil {
+			log(ctx).Warnf("unable to register event log source: %v", err)
+		}
+
+		return nil
+	})
+}
+
+// setServiceEnvironment sets environment variables of the service process, which the service control manager
+// reads from the Environment value of the service registry key.
+func setServiceEnvironment(name string, env map[string]string) error {
+	if len(env) == 0 {
+		return nil
+	}
+
+	var values []string
+
+	for k, v := range env {
+		values = append(values, k+"="+v)
+	}
+
+	sort.Strings(values)
+
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE, `SYSTEM\CurrentControlSet\Services\`+name, registry.SET_VALUE)
+	if err != nil {
+		return errors.Wrap(err, "unable to open service registry key")
+	}
+
+	defer k.Close() //nolint:errcheck
+
+	return errors.Wrap(k.SetStringsValue("Environment", values), "unable to set service environment")
+}
+
+func uninstallOSService(ctx context.Context, name string) error {
+	if err := stopOSService(ctx, name); err != nil {
+		log(ctx).Debugf("unable to stop service: %v", err)
+	}
+
+	if err := withService(name, func(s *mgr.Service) error {
+		return errors.Wrap(s.Delete(), "unable to delete service")
+	}); err != nil {
+		return err
+	}
+
+	if err := eventlog.Remove(name); err != nil {
+		log(ctx).Debugf("unable to remove event log source: %v", err)
+	}
+
+	return nil
+}
+
+func startOSService(_ context.Context, name string) error {
+	return withService(name, func(s *mgr.Service) error {
+		return errors.Wrap(s.Start(), "unable to start service")
+	})
+}
+
+func stopOSService(ctx context.Context, name string) error {
+	return withService(name, func(s *mgr.Service) error {
+		st, err := s.Control(svc.Stop)
+		if err != nil {
+			return errors.Wrap(err, "unable to stop service")
+		}
+
+		deadline := clock.Now().Add(windowsServiceStopTimeout)
+
+		for st.State != svc.Stopped {
+			if clock.Now().After(deadline) {
+				return errors.Errorf("timed out waiting for service to stop")
+			}
+
+			if !clock.SleepInterruptibly(ctx, windowsServiceStopPollInterval) {
+				return ctx.Err()
+			}
+
+			if st, err = s.Query(); err != nil {
+				return errors.Wrap(err, "unable to query service status")
+			}
+		}
+
+		return nil
+	})
+}
+
+// windowsService reports the process to the service control manager as running and signals
+// when the service is asked to stop.
+type windowsService struct {
+	stop chan struct{}
+}
+
+func (w windowsService) Execute(_ []string, r <-chan svc.ChangeRequest, changes chan<- svc.Status) (bool, uint32) {
+	changes <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+
+	for c := range r {
+		switch c.Cmd {
+		case svc.Interrogate:
+			changes <- c.CurrentStatus
+
+		case svc.Stop, svc.Shutdown:
+			changes <- svc.Status{State: svc.StopPending}
+			close(w.stop)
+
+			return false, 0
+
+		default:
+		}
+	}
+
+	return false, 0
+}
+
+//nolint:gochecknoglobals
+var (
+	windowsServiceOnce sync.Once
+	windowsServiceStop chan struct{}
+)
+
+// osServiceStopRequested connects to the service control manager when running as a Windows service
+// and returns a channel closed when the service is asked to stop, or nil otherwise.
+func osServiceStopRequested() <-chan struct{} {
+	windowsServiceOnce.Do(func() {
+		isService, err := svc.IsWindowsService()
+		if err != nil || !isService {
+			return
+		}
+
+		windowsServiceStop = make(chan struct{})
+
+		// service name is ignored for services running in their own process.
+		go svc.Run("", windowsService{windowsServiceStop}) //nolint:errcheck
+	})
+
+	return windowsServiceStop
+}
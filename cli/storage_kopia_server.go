@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"context"
+	"os"
+
+	"github.com/alecthomas/kingpin/v2"
+
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/blob/kopiaserver"
+)
+
+type storageKopiaServerFlags struct {
+	options kopiaserver.Options
+
+	svc StorageProviderServices
+}
+
+func (c *storageKopiaServerFlags) Setup(svc StorageProviderServices, cmd *kingpin.CmdClause) {
+	c.svc = svc
+
+	cmd.Flag("url", "URL of Kopia server started with --blob-proxy").Required().StringVar(&c.options.URL)
+	cmd.Flag("server-username", "Server control username").Envar(svc.EnvName("KOPIA_SERVER_USERNAME")).StringVar(&c.options.Username)
+	cmd.Flag("server-password", "Server control password").Envar(svc.EnvName("KOPIA_SERVER_PASSWORD")).StringVar(&c.options.Password)
+	cmd.Flag("server-cert-fingerprint", "Trust only the server certificate with the given SHA256 fingerprint").StringVar(&c.options.TrustedServerCertificateFingerprint)
+
+	commonThrottlingFlags(cmd, &c.options.Limits)
+}
+
+func (c *storageKopiaServerFlags) Connect(ctx context.Context, isCreate bool, formatVersion int) (blob.Storage, error) {
+	_ = formatVersion
+
+	opt := c.options
+
+	if opt.Username != "" && opt.Password == "" {
+		pass, err := c.svc.askPass(os.Stdout, "Enter server control password: ")
+		if err != nil {
+			return nil, err
+		}
+
+		opt.Password = pass
+	}
+
+	//nolint:wrapcheck
+	return kopiaserver.New(ctx, &opt, isCreate)
+}
@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/blob/plugin"
+)
+
+type storagePluginFlags struct {
+	opt        plugin.Options
+	configJSON string
+	configFile string
+}
+
+func (c *storagePluginFlags) Setup(_ StorageProviderServices, cmd *kingpin.CmdClause) {
+	cmd.Flag("name", "Plugin name (the executable is "+plugin.ExecutablePrefix+"<name> found in PATH)").Required().StringVar(&c.opt.Name)
+	cmd.Flag("executable", "Explicit path to the plugin executable").StringVar(&c.opt.Executable)
+	cmd.Flag("plugin-args", "Pass additional parameters to the plugin").StringsVar(&c.opt.Args)
+	cmd.Flag("plugin-env", "Pass additional environment (key=value) to the plugin").StringsVar(&c.opt.Env)
+	cmd.Flag("plugin-config", "Plugin-specific configuration (JSON)").StringVar(&c.configJSON)
+	cmd.Flag("plugin-config-file", "File containing plugin-specific configuration (JSON)").ExistingFileVar(&c.configFile)
+	cmd.Flag("plugin-startup-timeout", "Time in seconds to wait for the plugin to initialize").IntVar(&c.opt.StartupTimeout)
+}
+
+func (c *storagePluginFlags) Connect(ctx context.Context, isCreate bool, _ int) (blob.Storage, error) {
+	cfg := []byte(c.configJSON)
+
+	if c.configFile != "" {
+		if c.configJSON != "" {
+			return nil, errors.New("only one of --plugin-config and --plugin-config-file can be specified")
+		}
+
+		b, err := os.ReadFile(c.configFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to read plugin config file")
+		}
+
+		cfg = b
+	}
+
+	if len(cfg) > 0 {
+		if !json.Valid(cfg) {
+			return nil, errors.New("plugin configuration must be valid JSON")
+		}
+
+		c.opt.Config = cfg
+	}
+
+	//nolint:wrapcheck
+	return plugin.New(ctx, &c.opt, isCreate)
+}
@@ -17,6 +17,7 @@ type StorageProviderServices interface {
 	setPasswordFromToken(pwd string)
 	storageProviders() []StorageProvider
 	stdin() io.Reader
+	askPass(out io.Writer, prompt string) (string, error)
 }
 
 // StorageFlags is implemented by cli storage providers which need to support a
@@ -17,18 +17,22 @@ type storageS3Flags struct {
 	s3options       s3.Options
 	rootCaPemBase64 string
 	rootCaPemPath   string
+
+	multipartThresholdMB int64
 }
 
 func (c *storageS3Flags) Setup(svc StorageProviderServices, cmd *kingpin.CmdClause) {
 	cmd.Flag("bucket", "Name of the S3 bucket").Required().StringVar(&c.s3options.BucketName)
 	cmd.Flag("endpoint", "Endpoint to use").Default("s3.amazonaws.com").StringVar(&c.s3options.Endpoint)
 	cmd.Flag("region", "S3 Region").Default("").StringVar(&c.s3options.Region)
-	cmd.Flag("access-key", "Access key ID (overrides AWS_ACCESS_KEY_ID environment variable)").Required().Envar(svc.EnvName("AWS_ACCESS_KEY_ID")).StringVar(&c.s3options.AccessKeyID)
-	cmd.Flag("secret-access-key", "Secret access key (overrides AWS_SECRET_ACCESS_KEY environment variable)").Required().Envar(svc.EnvName("AWS_SECRET_ACCESS_KEY")).StringVar(&c.s3options.SecretAccessKey)
+	cmd.Flag("access-key", "Access key ID (overrides AWS_ACCESS_KEY_ID environment variable), when not provided credentials are read from the shared AWS credentials file or instance metadata").Envar(svc.EnvName("AWS_ACCESS_KEY_ID")).StringVar(&c.s3options.AccessKeyID)
+	cmd.Flag("secret-access-key", "Secret access key (overrides AWS_SECRET_ACCESS_KEY environment variable)").Envar(svc.EnvName("AWS_SECRET_ACCESS_KEY")).StringVar(&c.s3options.SecretAccessKey)
 	cmd.Flag("session-token", "Session token (overrides AWS_SESSION_TOKEN environment variable)").Envar(svc.EnvName("AWS_SESSION_TOKEN")).StringVar(&c.s3options.SessionToken)
 	cmd.Flag("prefix", "Prefix to use for objects in the bucket. Put trailing slash (/) if you want to use prefix as directory. e.g my-backup-dir/ would put repository contents inside my-backup-dir directory").StringVar(&c.s3options.Prefix)
 	cmd.Flag("disable-tls", "Disable TLS security (HTTPS)").BoolVar(&c.s3options.DoNotUseTLS)
 	cmd.Flag("disable-tls-verification", "Disable TLS (HTTPS) certificate verification").BoolVar(&c.s3options.DoNotVerifyTLS)
+	cmd.Flag("path-style", "Use path-style bucket addressing, required by some S3-compatible servers").BoolVar(&c.s3options.UsePathStyle)
+	cmd.Flag("multipart-threshold-mb", "Upload blobs larger than the given size using multipart uploads (default: only when required)").PlaceHolder("MB").Int64Var(&c.multipartThresholdMB)
 
 	commonThrottlingFlags(cmd, &c.s3options.Limits)
 
@@ -86,6 +90,8 @@ func (c *storageS3Flags) Connect(ctx context.Context, isCreate bool, formatVersi
 		return nil, errors.New("Cannot specify a 'point-in-time' option when creating a repository")
 	}
 
+	c.s3options.MultipartUploadThreshold = c.multipartThresholdMB << 20 //nolint:mnd
+
 	//nolint:wrapcheck
 	return s3.New(ctx, &c.s3options, isCreate)
 }
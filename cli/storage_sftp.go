@@ -18,7 +18,7 @@ type storageSFTPFlags struct {
 	embedCredentials bool
 }
 
-func (c *storageSFTPFlags) Setup(_ StorageProviderServices, cmd *kingpin.CmdClause) {
+func (c *storageSFTPFlags) Setup(svc StorageProviderServices, cmd *kingpin.CmdClause) {
 	cmd.Flag("path", "Path to the repository in the SFTP/SSH server").Required().StringVar(&c.options.Path)
 	cmd.Flag("host", "SFTP/SSH server hostname").Required().StringVar(&c.options.Host)
 	cmd.Flag("port", "SFTP/SSH server port").Default("22").IntVar(&c.options.Port)
@@ -28,6 +28,7 @@ func (c *storageSFTPFlags) Setup(_ StorageProviderServices, cmd *kingpin.CmdClau
 	cmd.Flag("sftp-password", "SFTP/SSH server password").StringVar(&c.options.Password)
 	cmd.Flag("keyfile", "path to private key file for SFTP/SSH server").StringVar(&c.options.Keyfile)
 	cmd.Flag("key-data", "private key data").StringVar(&c.options.KeyData)
+	cmd.Flag("key-passphrase", "passphrase of an encrypted private key").Envar(svc.EnvName("KOPIA_SFTP_KEY_PASSPHRASE")).StringVar(&c.options.KeyPassphrase)
 
 	// one of those 2 must be provided
 	cmd.Flag("known-hosts", "path to known_hosts file").StringVar(&c.options.KnownHostsFile)
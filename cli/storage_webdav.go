@@ -5,6 +5,7 @@
 	"os"
 
 	"github.com/alecthomas/kingpin/v2"
+	"github.com/pkg/errors"
 
 	"github.com/kopia/kopia/repo/blob"
 	"github.com/kopia/kopia/repo/blob/webdav"
@@ -13,15 +14,22 @@
 type storageWebDAVFlags struct {
 	options     webdav.Options
 	connectFlat bool
+	rootCaPath  string
+
+	svc StorageProviderServices
 }
 
 func (c *storageWebDAVFlags) Setup(svc StorageProviderServices, cmd *kingpin.CmdClause) {
+	c.svc = svc
+
 	cmd.Flag("url", "URL of WebDAV server").Required().StringVar(&c.options.URL)
 	cmd.Flag("flat", "Use flat directory structure").BoolVar(&c.connectFlat)
 	cmd.Flag("webdav-username", "WebDAV username").Envar(svc.EnvName("KOPIA_WEBDAV_USERNAME")).StringVar(&c.options.Username)
 	cmd.Flag("webdav-password", "WebDAV password").Envar(svc.EnvName("KOPIA_WEBDAV_PASSWORD")).StringVar(&c.options.Password)
 	cmd.Flag("list-parallelism", "Set list parallelism").Hidden().IntVar(&c.options.ListParallelism)
 	cmd.Flag("atomic-writes", "Assume WebDAV provider implements atomic writes").BoolVar(&c.options.AtomicWrites)
+	cmd.Flag("server-cert-fingerprint", "Trust only the server certificate with the given SHA256 fingerprint").StringVar(&c.options.TrustedServerCertificateFingerprint)
+	cmd.Flag("root-ca-pem-path", "Certificate authority file path").StringVar(&c.rootCaPath)
 
 	commonThrottlingFlags(cmd, &c.options.Limits)
 }
@@ -30,7 +38,7 @@ func (c *storageWebDAVFlags) Connect(ctx context.Context, isCreate bool, formatV
 	wo := c.options
 
 	if wo.Username != "" && wo.Password == "" {
-		pass, err := askPass(os.Stdout, "Enter WebDAV password: ")
+		pass, err := c.svc.askPass(os.Stdout, "Enter WebDAV password: ")
 		if err != nil {
 			return nil, err
 		}
@@ -38,6 +46,15 @@ func (c *storageWebDAVFlags) Connect(ctx context.Context, isCreate bool, formatV
 		wo.Password = pass
 	}
 
+	if c.rootCaPath != "" {
+		data, err := os.ReadFile(c.rootCaPath) //#nosec
+		if err != nil {
+			return nil, errors.Wrapf(err, "error opening root-ca-pem-path %v", c.rootCaPath)
+		}
+
+		wo.RootCA = data
+	}
+
 	wo.DirectoryShards = initialDirectoryShards(c.connectFlat, formatVersion)
 
 	//nolint:wrapcheck
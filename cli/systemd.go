@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"context"
+	"time"
+
+	"github.com/coreos/go-systemd/v22/daemon"
+)
+
+// notifySystemd sends the provided state to systemd when running as a Type=notify service,
+// it is a no-op otherwise.
+func notifySystemd(ctx context.Context, state string) {
+	if _, err := daemon.SdNotify(false, state); err != nil {
+		log(ctx).Debugf("unable to notify systemd: %v", err)
+	}
+}
+
+// startSystemdWatchdog periodically pings systemd watchdog when it has been enabled for the service
+// (WatchdogSec=). Each ping is only sent after the provided liveness check succeeds, so a process that
+// stops making progress is restarted by systemd. Returns a function that stops the pings.
+func startSystemdWatchdog(ctx context.Context, checkLiveness func(ctx context.Context) error) func() {
+	interval, err := daemon.SdWatchdogEnabled(false)
+	if err != nil || interval == 0 {
+		return func() {}
+	}
+
+	// ping twice per watchdog interval as recommended by sd_watchdog_enabled(3).
+	t := time.NewTicker(interval / 2) //nolint:mnd
+	done := make(chan struct{})
+
+	go func() {
+		defer t.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+
+			case <-t.C:
+				if err := checkLiveness(ctx); err != nil {
+					log(ctx).Warnf("liveness check failed, not notifying systemd watchdog: %v", err)
+					continue
+				}
+
+				notifySystemd(ctx, daemon.SdNotifyWatchdog)
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+	}
+}
@@ -0,0 +1,58 @@
+//go:build linux
+
+package cli
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testlogging"
+	"github.com/kopia/kopia/internal/testutil"
+)
+
+func TestSystemdWatchdogPingsOnlyWhenAlive(t *testing.T) {
+	sockPath := filepath.Join(testutil.TempDirectory(t), "notify.sock")
+
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: sockPath, Net: "unixgram"})
+	require.NoError(t, err)
+
+	defer conn.Close()
+
+	t.Setenv("NOTIFY_SOCKET", sockPath)
+	t.Setenv("WATCHDOG_USEC", "20000")
+
+	var alive atomic.Bool
+
+	ctx := testlogging.Context(t)
+
+	stop := startSystemdWatchdog(ctx, func(ctx context.Context) error {
+		if !alive.Load() {
+			return errors.New("not alive")
+		}
+
+		return nil
+	})
+	defer stop()
+
+	buf := make([]byte, 100)
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(100*time.Millisecond))) //nolint:forbidigo
+
+	_, err = conn.Read(buf)
+	require.Error(t, err, "unexpected watchdog ping while not alive")
+
+	alive.Store(true)
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second))) //nolint:forbidigo
+
+	n, err := conn.Read(buf)
+	require.NoError(t, err)
+	require.Equal(t, "WATCHDOG=1", string(buf[:n]))
+}
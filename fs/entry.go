@@ -114,6 +114,19 @@ type DirectoryWithSummary interface {
 	Summary(ctx context.Context) (*DirectorySummary, error)
 }
 
+// EntryWithExtendedAttributes is optionally implemented by entries that support extended attributes.
+type EntryWithExtendedAttributes interface {
+	// ExtendedAttributes returns extended attributes of the entry or nil if the filesystem does not support them.
+	ExtendedAttributes() (map[string][]byte, error)
+}
+
+// EntryWithSecurityDescriptor is optionally implemented by entries that support Windows security descriptors.
+type EntryWithSecurityDescriptor interface {
+	// SecurityDescriptor returns the owner, group and access control list of the entry in SDDL format
+	// or an empty string if the filesystem does not support them.
+	SecurityDescriptor() (string, error)
+}
+
 // ErrorEntry represents entry in a Directory that had encountered an error or is unknown/unsupported (ErrUnknown).
 type ErrorEntry interface {
 	Entry
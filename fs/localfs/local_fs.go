@@ -9,6 +9,7 @@
 	"github.com/pkg/errors"
 
 	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/internal/atomicfile"
 )
 
 const numEntriesToRead = 100 // number of directory entries to read in one shot
@@ -22,6 +23,7 @@ type filesystemEntry struct {
 	device     fs.DeviceInfo
 
 	prefix string
+	osName string // name of the entry on the filesystem, if different from normalized name
 }
 
 func (e *filesystemEntry) Name() string {
@@ -49,6 +51,10 @@ func (e *filesystemEntry) Sys() interface{} {
 }
 
 func (e *filesystemEntry) fullPath() string {
+	if e.osName != "" {
+		return e.prefix + e.osName
+	}
+
 	return e.prefix + e.Name()
 }
 
@@ -104,7 +110,7 @@ func (f *fileWithMetadata) Entry() (fs.Entry, error) {
 }
 
 func (fsf *filesystemFile) Open(ctx context.Context) (fs.Reader, error) {
-	f, err := os.Open(fsf.fullPath())
+	f, err := os.Open(atomicfile.MaybePrefixLongFilenameOnWindows(fsf.fullPath()))
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to open local file")
 	}
@@ -114,7 +120,7 @@ func (fsf *filesystemFile) Open(ctx context.Context) (fs.Reader, error) {
 
 func (fsl *filesystemSymlink) Readlink(ctx context.Context) (string, error) {
 	//nolint:wrapcheck
-	return os.Readlink(fsl.fullPath())
+	return os.Readlink(atomicfile.MaybePrefixLongFilenameOnWindows(fsl.fullPath()))
 }
 
 func (e *filesystemErrorEntry) ErrorInfo() error {
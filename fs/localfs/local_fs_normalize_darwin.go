@@ -0,0 +1,15 @@
+package localfs
+
+import (
+	"os"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// platformSpecificNormalizeName converts file names to NFC, since HFS+ stores names
+// in decomposed form (NFD) while most other systems, including APFS, preserve the form
+// used when the file was created. Normalizing ensures that the same name is recorded
+// in snapshots regardless of the filesystem it was read from.
+func platformSpecificNormalizeName(fi os.FileInfo, prefix, name string) string {
+	return normalizeNameUnlessCollides(fi, prefix, name, norm.NFC.String)
+}
@@ -0,0 +1,10 @@
+//go:build !darwin
+// +build !darwin
+
+package localfs
+
+import "os"
+
+func platformSpecificNormalizeName(_ os.FileInfo, _, name string) string {
+	return name
+}
@@ -12,6 +12,7 @@
 	"github.com/pkg/errors"
 
 	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/internal/atomicfile"
 )
 
 type filesystemDirectoryIterator struct {
@@ -66,7 +67,7 @@ func (it *filesystemDirectoryIterator) Close() {
 func (fsd *filesystemDirectory) Iterate(ctx context.Context) (fs.DirectoryIterator, error) {
 	fullPath := fsd.fullPath()
 
-	f, direrr := os.Open(fullPath) //nolint:gosec
+	f, direrr := os.Open(atomicfile.MaybePrefixLongFilenameOnWindows(fullPath)) //nolint:gosec
 	if direrr != nil {
 		return nil, errors.Wrap(direrr, "unable to read directory")
 	}
@@ -79,7 +80,7 @@ func (fsd *filesystemDirectory) Iterate(ctx context.Context) (fs.DirectoryIterat
 func (fsd *filesystemDirectory) Child(ctx context.Context, name string) (fs.Entry, error) {
 	fullPath := fsd.fullPath()
 
-	st, err := os.Lstat(filepath.Join(fullPath, name))
+	st, err := os.Lstat(atomicfile.MaybePrefixLongFilenameOnWindows(filepath.Join(fullPath, name)))
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil, fs.ErrEntryNotFound
@@ -92,7 +93,7 @@ func (fsd *filesystemDirectory) Child(ctx context.Context, name string) (fs.Entr
 }
 
 func toDirEntryOrNil(dirEntry os.DirEntry, prefix string) (fs.Entry, error) {
-	fi, err := os.Lstat(prefix + dirEntry.Name())
+	fi, err := os.Lstat(atomicfile.MaybePrefixLongFilenameOnWindows(prefix + dirEntry.Name()))
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil, nil
@@ -109,7 +110,7 @@ func toDirEntryOrNil(dirEntry os.DirEntry, prefix string) (fs.Entry, error) {
 func NewEntry(path string) (fs.Entry, error) {
 	path = filepath.Clean(path)
 
-	fi, err := os.Lstat(path)
+	fi, err := os.Lstat(atomicfile.MaybePrefixLongFilenameOnWindows(path))
 	if err != nil {
 		// Paths such as `\\?\GLOBALROOT\Device\HarddiskVolumeShadowCopy01`
 		// cause os.Lstat to fail with "Incorrect function" error unless they
@@ -162,13 +163,40 @@ func entryFromDirEntry(fi os.FileInfo, prefix string) fs.Entry {
 var _ os.FileInfo = (*filesystemEntry)(nil)
 
 func newEntry(fi os.FileInfo, prefix string) filesystemEntry {
+	name := TrimShallowSuffix(fi.Name())
+
+	var osName string
+
+	if n := platformSpecificNormalizeName(fi, prefix, name); n != name {
+		osName = name
+		name = n
+	}
+
 	return filesystemEntry{
-		TrimShallowSuffix(fi.Name()),
+		name,
 		fi.Size(),
 		fi.ModTime().UnixNano(),
 		fi.Mode(),
 		platformSpecificOwnerInfo(fi),
 		platformSpecificDeviceInfo(fi),
 		prefix,
+		osName,
+	}
+}
+
+// normalizeNameUnlessCollides returns the normalized name of the provided entry unless a different
+// file exists under the normalized name in the same directory, which is possible on filesystems
+// that don't normalize names. In that case the original name is kept so that both entries remain
+// distinct in the snapshot.
+func normalizeNameUnlessCollides(fi os.FileInfo, prefix, name string, normalize func(string) string) string {
+	n := normalize(name)
+	if n == name {
+		return name
 	}
+
+	if other, err := os.Lstat(atomicfile.MaybePrefixLongFilenameOnWindows(prefix + n)); err == nil && !os.SameFile(fi, other) {
+		return name
+	}
+
+	return n
 }
@@ -0,0 +1,9 @@
+//go:build !windows
+// +build !windows
+
+package localfs
+
+// SecurityDescriptor returns an empty string since security descriptors are only supported on Windows.
+func (e *filesystemEntry) SecurityDescriptor() (string, error) {
+	return "", nil
+}
@@ -0,0 +1,27 @@
+package localfs
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/windows"
+
+	"github.com/kopia/kopia/internal/atomicfile"
+)
+
+const securityDescriptorInfo = windows.OWNER_SECURITY_INFORMATION | windows.GROUP_SECURITY_INFORMATION | windows.DACL_SECURITY_INFORMATION
+
+// SecurityDescriptor returns the owner, group and DACL of the entry in SDDL format.
+// Symbolic links and junctions are not followed and report no security descriptor.
+func (e *filesystemEntry) SecurityDescriptor() (string, error) {
+	if e.mode&os.ModeSymlink != 0 {
+		return "", nil
+	}
+
+	sd, err := windows.GetNamedSecurityInfo(atomicfile.MaybePrefixLongFilenameOnWindows(e.fullPath()), windows.SE_FILE_OBJECT, securityDescriptorInfo)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to get security descriptor")
+	}
+
+	return sd.String(), nil
+}
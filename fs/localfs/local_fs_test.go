@@ -3,6 +3,7 @@
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -10,6 +11,7 @@
 
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/text/unicode/norm"
 
 	"github.com/kopia/kopia/fs"
 	"github.com/kopia/kopia/internal/clock"
@@ -267,6 +269,68 @@ func TestLocalFilesystemPath(t *testing.T) {
 	}
 }
 
+func TestUnicodeNormalization(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	testDir := testutil.TempDirectory(t)
+
+	// "é" in decomposed form (NFD)
+	nfdName := "e\u0301.txt"
+
+	require.NoError(t, os.WriteFile(filepath.Join(testDir, nfdName), []byte("hello"), 0o600))
+
+	dir, err := Directory(testDir)
+	require.NoError(t, err)
+
+	entries, err := fs.GetAllEntries(ctx, dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	f, ok := entries[0].(fs.File)
+	require.True(t, ok)
+
+	if runtime.GOOS == "darwin" {
+		require.Equal(t, "\u00e9.txt", f.Name())
+	} else {
+		require.Equal(t, nfdName, f.Name())
+	}
+
+	// the file must be readable regardless of the name used in the snapshot.
+	r, err := f.Open(ctx)
+	require.NoError(t, err)
+
+	defer r.Close()
+
+	b := make([]byte, 5)
+	_, err = io.ReadFull(r, b)
+	require.NoError(t, err)
+	require.Equal(t, "hello", string(b))
+}
+
+func TestNormalizeNameUnlessCollides(t *testing.T) {
+	testDir := testutil.TempDirectory(t)
+	prefix := testDir + string(filepath.Separator)
+
+	nfdName := "e\u0301.txt"
+	nfcName := "\u00e9.txt"
+
+	require.NoError(t, os.WriteFile(filepath.Join(testDir, nfdName), []byte("hello"), 0o600))
+
+	nfdInfo, err := os.Lstat(filepath.Join(testDir, nfdName))
+	require.NoError(t, err)
+
+	require.Equal(t, nfcName, normalizeNameUnlessCollides(nfdInfo, prefix, nfdName, norm.NFC.String))
+
+	if _, err := os.Lstat(filepath.Join(testDir, nfcName)); err == nil {
+		t.Skip("filesystem does not preserve unicode normalization")
+	}
+
+	// create a different file whose name is the normalized form of the first one.
+	require.NoError(t, os.WriteFile(filepath.Join(testDir, nfcName), []byte("world"), 0o600))
+
+	require.Equal(t, nfdName, normalizeNameUnlessCollides(nfdInfo, prefix, nfdName, norm.NFC.String))
+}
+
 func TestDirPrefix(t *testing.T) {
 	cases := map[string]string{
 		"foo":      "",
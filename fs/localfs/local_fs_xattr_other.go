@@ -0,0 +1,9 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package localfs
+
+// ExtendedAttributes returns nil since extended attributes are not supported on this platform.
+func (e *filesystemEntry) ExtendedAttributes() (map[string][]byte, error) {
+	return nil, nil
+}
@@ -0,0 +1,82 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package localfs
+
+import (
+	"bytes"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+)
+
+// ExtendedAttributes returns extended attributes of the entry, without following symbolic links.
+func (e *filesystemEntry) ExtendedAttributes() (map[string][]byte, error) {
+	path := e.fullPath()
+
+	names, err := readXattrBuffer(func(buf []byte) (int, error) {
+		return unix.Llistxattr(path, buf)
+	})
+	if err != nil {
+		if isXattrNotSupported(err) {
+			return nil, nil
+		}
+
+		return nil, errors.Wrap(err, "unable to list extended attributes")
+	}
+
+	var result map[string][]byte
+
+	for _, name := range bytes.Split(names, []byte{0}) {
+		if len(name) == 0 {
+			continue
+		}
+
+		value, err := readXattrBuffer(func(buf []byte) (int, error) {
+			return unix.Lgetxattr(path, string(name), buf)
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to read extended attribute %q", name)
+		}
+
+		if result == nil {
+			result = map[string][]byte{}
+		}
+
+		result[string(name)] = value
+	}
+
+	return result, nil
+}
+
+// readXattrBuffer invokes the provided function first to determine the required buffer size
+// and then to read the data, retrying if the data has grown in the meantime.
+func readXattrBuffer(f func(buf []byte) (int, error)) ([]byte, error) {
+	for {
+		sz, err := f(nil)
+		if err != nil {
+			return nil, err
+		}
+
+		if sz == 0 {
+			return nil, nil
+		}
+
+		buf := make([]byte, sz)
+
+		n, err := f(buf)
+		if errors.Is(err, unix.ERANGE) {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		return buf[:n], nil
+	}
+}
+
+func isXattrNotSupported(err error) bool {
+	return errors.Is(err, unix.ENOTSUP) || errors.Is(err, unix.EOPNOTSUPP)
+}
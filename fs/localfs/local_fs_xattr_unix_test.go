@@ -0,0 +1,48 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package localfs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/internal/testutil"
+)
+
+func TestExtendedAttributes(t *testing.T) {
+	dir := testutil.TempDirectory(t)
+	fname := filepath.Join(dir, "file1")
+
+	require.NoError(t, os.WriteFile(fname, []byte{1, 2, 3}, 0o600))
+
+	if err := unix.Setxattr(fname, "user.kopia-test", []byte("some-value"), 0); err != nil {
+		if isXattrNotSupported(err) || errors.Is(err, unix.EPERM) {
+			t.Skipf("extended attributes not supported: %v", err)
+		}
+
+		require.NoError(t, err)
+	}
+
+	e, err := NewEntry(fname)
+	require.NoError(t, err)
+
+	xe, ok := e.(fs.EntryWithExtendedAttributes)
+	require.True(t, ok)
+
+	attrs, err := xe.ExtendedAttributes()
+	require.NoError(t, err)
+	require.Equal(t, []byte("some-value"), attrs["user.kopia-test"])
+
+	require.NoError(t, unix.Removexattr(fname, "user.kopia-test"))
+
+	attrs, err = xe.ExtendedAttributes()
+	require.NoError(t, err)
+	require.NotContains(t, attrs, "user.kopia-test")
+}
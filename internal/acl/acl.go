@@ -2,6 +2,7 @@
 
 import (
 	"fmt"
+	"path"
 	"sort"
 	"strings"
 
@@ -44,14 +45,30 @@ func (r TargetRule) String() string {
 	return strings.Join(predicates, ",")
 }
 
+// PathPrefixWildcard is a suffix of a "path" label value which makes the rule match
+// the path itself and all paths nested under it, such as "/home/OWN_USER/*".
+const PathPrefixWildcard = "/*"
+
 // matches returns true if a given subject rule matches the given target
 // for the provided username & hostname. The rule can use
 // OwnUser / OwnHost placeholders.
 func (r TargetRule) matches(target map[string]string, username, hostname string) bool {
 	for k, v := range r {
+		if k == snapshot.PathLabel && (!isPlaceholderSafeInPath(v, OwnUser, username) || !isPlaceholderSafeInPath(v, OwnHost, hostname)) {
+			return false
+		}
+
 		v = strings.ReplaceAll(v, OwnUser, username)
 		v = strings.ReplaceAll(v, OwnHost, hostname)
 
+		if k == snapshot.PathLabel && strings.HasSuffix(v, PathPrefixWildcard) {
+			if !pathHasPrefix(target[k], strings.TrimSuffix(v, PathPrefixWildcard)) {
+				return false
+			}
+
+			continue
+		}
+
 		if target[k] != v {
 			return false
 		}
@@ -60,6 +77,64 @@ func (r TargetRule) matches(target map[string]string, username, hostname string)
 	return true
 }
 
+// isPlaceholderSafeInPath returns false if the path template uses the placeholder and substituting
+// the value would change the structure of the path, such as user "..@host" turning "/home/OWN_USER/*" into "/*".
+func isPlaceholderSafeInPath(template, placeholder, value string) bool {
+	if !strings.Contains(template, placeholder) {
+		return true
+	}
+
+	return value != "" && value != "." && value != ".." && !strings.ContainsAny(value, "/\\")
+}
+
+// pathHasPrefix returns true if the path is equal to the prefix or nested under it.
+// Both are cleaned before comparing, paths that still refer to a parent directory never match.
+func pathHasPrefix(p, prefix string) bool {
+	p, ok := cleanPath(p)
+	if !ok {
+		return false
+	}
+
+	// "/*" matches all absolute paths.
+	if prefix == "" {
+		prefix = "/"
+	}
+
+	prefix, ok = cleanPath(prefix)
+	if !ok {
+		return false
+	}
+
+	if p == prefix {
+		return true
+	}
+
+	// prefix must end on a path component boundary.
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	return strings.HasPrefix(p, prefix)
+}
+
+// cleanPath returns the path using forward slashes with redundant elements removed,
+// or false if it's empty or contains ".." elements that can't be resolved.
+func cleanPath(p string) (string, bool) {
+	if p == "" {
+		return "", false
+	}
+
+	p = path.Clean(strings.ReplaceAll(p, "\\", "/"))
+
+	for _, e := range strings.Split(p, "/") {
+		if e == ".." {
+			return "", false
+		}
+	}
+
+	return p, true
+}
+
 // Entry defines access control list entry stored in a manifest which grants the given
 // user certain level of access to a target.
 type Entry struct {
@@ -79,6 +154,18 @@ func nonEmptyString(v string) error {
 	return nil
 }
 
+func pathPattern(v string) error {
+	if err := nonEmptyString(v); err != nil {
+		return err
+	}
+
+	if strings.Contains(strings.TrimSuffix(v, PathPrefixWildcard), "*") {
+		return errors.Errorf("wildcard is only supported as a trailing '%v'", PathPrefixWildcard)
+	}
+
+	return nil
+}
+
 func oneOf(allowed ...string) valueValidatorFunc {
 	return func(v string) error {
 		for _, a := range allowed {
@@ -97,7 +184,7 @@ func oneOf(allowed ...string) valueValidatorFunc {
 	policy.ManifestType: {
 		policy.HostnameLabel: nonEmptyString,
 		policy.UsernameLabel: nonEmptyString,
-		policy.PathLabel:     nonEmptyString,
+		policy.PathLabel:     pathPattern,
 		policy.PolicyTypeLabel: oneOf(
 			policy.PolicyTypeGlobal,
 			policy.PolicyTypeHost,
@@ -108,7 +195,7 @@ func oneOf(allowed ...string) valueValidatorFunc {
 	snapshot.ManifestType: {
 		snapshot.HostnameLabel: nonEmptyString,
 		snapshot.UsernameLabel: nonEmptyString,
-		snapshot.PathLabel:     nonEmptyString,
+		snapshot.PathLabel:     pathPattern,
 	},
 	user.ManifestType: {
 		user.UsernameAtHostnameLabel: nonEmptyString,
@@ -133,6 +133,193 @@ func TestEffectivePermissions(t *testing.T) {
 			},
 			want: acl.AccessLevelAppend,
 		},
+		// path prefix
+		{
+			entries: []*acl.Entry{
+				{
+					Target: acl.TargetRule{
+						manifest.TypeLabelKey: snapshot.ManifestType,
+						snapshot.PathLabel:    "/home/" + acl.OwnUser + "/*",
+					},
+					User:   "*@*",
+					Access: acl.AccessLevelAppend,
+				},
+			},
+			target: map[string]string{
+				manifest.TypeLabelKey: snapshot.ManifestType,
+				snapshot.PathLabel:    "/home/" + actualUser + "/docs",
+			},
+			want: acl.AccessLevelAppend,
+		},
+		{
+			entries: []*acl.Entry{
+				{
+					Target: acl.TargetRule{
+						manifest.TypeLabelKey: snapshot.ManifestType,
+						snapshot.PathLabel:    "/home/" + acl.OwnUser + "/*",
+					},
+					User:   "*@*",
+					Access: acl.AccessLevelAppend,
+				},
+			},
+			target: map[string]string{
+				manifest.TypeLabelKey: snapshot.ManifestType,
+				snapshot.PathLabel:    "/home/" + actualUser,
+			},
+			want: acl.AccessLevelAppend,
+		},
+		{
+			entries: []*acl.Entry{
+				{
+					Target: acl.TargetRule{
+						manifest.TypeLabelKey: snapshot.ManifestType,
+						snapshot.PathLabel:    "/home/" + acl.OwnUser + "/*",
+					},
+					User:   "*@*",
+					Access: acl.AccessLevelAppend,
+				},
+			},
+			target: map[string]string{
+				manifest.TypeLabelKey: snapshot.ManifestType,
+				snapshot.PathLabel:    "/home/" + actualUser + "2/docs", // not a component boundary
+			},
+			want: acl.AccessLevelNone,
+		},
+		{
+			entries: []*acl.Entry{
+				{
+					Target: acl.TargetRule{
+						manifest.TypeLabelKey: snapshot.ManifestType,
+						snapshot.PathLabel:    "/home/" + acl.OwnUser + "/*",
+					},
+					User:   "*@*",
+					Access: acl.AccessLevelAppend,
+				},
+			},
+			target: map[string]string{
+				manifest.TypeLabelKey: snapshot.ManifestType,
+			},
+			want: acl.AccessLevelNone,
+		},
+		{
+			entries: []*acl.Entry{
+				{
+					Target: acl.TargetRule{
+						manifest.TypeLabelKey: snapshot.ManifestType,
+						snapshot.PathLabel:    "/home/" + acl.OwnUser + "/*",
+					},
+					User:   "*@*",
+					Access: acl.AccessLevelAppend,
+				},
+			},
+			target: map[string]string{
+				manifest.TypeLabelKey: snapshot.ManifestType,
+				snapshot.PathLabel:    "/home/" + actualUser + "/../secret", // escapes the prefix
+			},
+			want: acl.AccessLevelNone,
+		},
+		{
+			entries: []*acl.Entry{
+				{
+					Target: acl.TargetRule{
+						manifest.TypeLabelKey: snapshot.ManifestType,
+						snapshot.PathLabel:    "/home/" + acl.OwnUser + "/*",
+					},
+					User:   "*@*",
+					Access: acl.AccessLevelAppend,
+				},
+			},
+			target: map[string]string{
+				manifest.TypeLabelKey: snapshot.ManifestType,
+				snapshot.PathLabel:    "/home/" + actualUser + "/docs/../../secret",
+			},
+			want: acl.AccessLevelNone,
+		},
+		{
+			entries: []*acl.Entry{
+				{
+					Target: acl.TargetRule{
+						manifest.TypeLabelKey: snapshot.ManifestType,
+						snapshot.PathLabel:    "/home/" + acl.OwnUser + "/*",
+					},
+					User:   "*@*",
+					Access: acl.AccessLevelAppend,
+				},
+			},
+			target: map[string]string{
+				manifest.TypeLabelKey: snapshot.ManifestType,
+				snapshot.PathLabel:    "/home/" + actualUser + "/./docs/",
+			},
+			want: acl.AccessLevelAppend,
+		},
+		{
+			entries: []*acl.Entry{
+				{
+					Target: acl.TargetRule{
+						manifest.TypeLabelKey: snapshot.ManifestType,
+						snapshot.PathLabel:    "home/" + acl.OwnUser + "/*",
+					},
+					User:   "*@*",
+					Access: acl.AccessLevelAppend,
+				},
+			},
+			target: map[string]string{
+				manifest.TypeLabelKey: snapshot.ManifestType,
+				snapshot.PathLabel:    "home/" + actualUser + "/../../../home/" + actualUser, // unresolvable parent reference
+			},
+			want: acl.AccessLevelNone,
+		},
+		{
+			entries: []*acl.Entry{
+				{
+					Target: acl.TargetRule{
+						manifest.TypeLabelKey: snapshot.ManifestType,
+						snapshot.PathLabel:    `C:\Users\` + acl.OwnUser + "/*",
+					},
+					User:   "*@*",
+					Access: acl.AccessLevelAppend,
+				},
+			},
+			target: map[string]string{
+				manifest.TypeLabelKey: snapshot.ManifestType,
+				snapshot.PathLabel:    `C:\Users\` + actualUser + `\Documents`,
+			},
+			want: acl.AccessLevelAppend,
+		},
+		{
+			entries: []*acl.Entry{
+				{
+					Target: acl.TargetRule{
+						manifest.TypeLabelKey: snapshot.ManifestType,
+						snapshot.PathLabel:    `C:\Users\` + acl.OwnUser + "/*",
+					},
+					User:   "*@*",
+					Access: acl.AccessLevelAppend,
+				},
+			},
+			target: map[string]string{
+				manifest.TypeLabelKey: snapshot.ManifestType,
+				snapshot.PathLabel:    `C:\Users\` + actualUser + `\..\Other`,
+			},
+			want: acl.AccessLevelNone,
+		},
+		{
+			entries: []*acl.Entry{
+				{
+					Target: acl.TargetRule{
+						manifest.TypeLabelKey: snapshot.ManifestType,
+						snapshot.PathLabel:    "/*",
+					},
+					User:   "*@*",
+					Access: acl.AccessLevelAppend,
+				},
+			},
+			target: map[string]string{
+				manifest.TypeLabelKey: snapshot.ManifestType,
+				snapshot.PathLabel:    "/etc",
+			},
+			want: acl.AccessLevelAppend,
+		},
 	}
 
 	for _, tc := range cases {
@@ -149,6 +336,32 @@ func TestEffectivePermissions(t *testing.T) {
 	}
 }
 
+func TestEffectivePermissionsDotUserNames(t *testing.T) {
+	entries := []*acl.Entry{
+		{
+			Target: acl.TargetRule{
+				manifest.TypeLabelKey: snapshot.ManifestType,
+				snapshot.PathLabel:    "/home/" + acl.OwnUser + "/*",
+			},
+			User:   "*@*",
+			Access: acl.AccessLevelFull,
+		},
+	}
+
+	for _, username := range []string{"..", "."} {
+		for _, p := range []string{"/", "/etc", "/home", "/home/bob"} {
+			target := map[string]string{
+				manifest.TypeLabelKey: snapshot.ManifestType,
+				snapshot.PathLabel:    p,
+			}
+
+			if got := acl.EffectivePermissions(username, actualHostname, target, entries); got != acl.AccessLevelNone {
+				t.Errorf("invalid access level for user %q and path %q: %v, want %v", username, p, got, acl.AccessLevelNone)
+			}
+		}
+	}
+}
+
 func TestLoadEntries(t *testing.T) {
 	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
 
@@ -318,6 +531,28 @@ func TestACLEntryValidation(t *testing.T) {
 			},
 			WantErr: "",
 		},
+		{
+			Entry: &acl.Entry{
+				User: "foo@bar",
+				Target: acl.TargetRule{
+					"type": "snapshot",
+					"path": "/home/*",
+				},
+				Access: acl.AccessLevelFull,
+			},
+			WantErr: "",
+		},
+		{
+			Entry: &acl.Entry{
+				User: "foo@bar",
+				Target: acl.TargetRule{
+					"type": "snapshot",
+					"path": "/home/*/docs",
+				},
+				Access: acl.AccessLevelFull,
+			},
+			WantErr: "invalid label 'path=/home/*/docs' for type 'snapshot': wildcard is only supported as a trailing '/*'",
+		},
 		{
 			Entry: &acl.Entry{
 				User: "foo@bar@baz",
@@ -4,6 +4,7 @@
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -89,15 +90,52 @@ func (c *KopiaAPIClient) actualURL(suffix string) string {
 	return c.BaseURL + "/api/v1/" + suffix
 }
 
-func (c *KopiaAPIClient) runRequest(ctx context.Context, method, url string, notFoundError error, reqPayload, respPayload interface{}) error {
-	payload, contentType, err := requestReader(reqPayload)
+// GetStream performs HTTP GET on a URL with the specified suffix and returns the body of a successful
+// response without buffering it in memory. The caller must close the returned reader.
+func (c *KopiaAPIClient) GetStream(ctx context.Context, urlSuffix string) (io.ReadCloser, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, c.actualURL(urlSuffix), nil, "")
 	if err != nil {
-		return errors.Wrap(err, "error getting reader")
+		return nil, err
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "error running http request")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close() //nolint:errcheck
+
+		return nil, HTTPStatusError{resp.StatusCode, respToErrorMessage(resp)}
+	}
+
+	return resp.Body, nil
+}
+
+// PutStream performs HTTP PUT on a URL with the specified suffix, streaming the binary request body
+// of the provided length from the reader and decodes the response onto respPayload.
+func (c *KopiaAPIClient) PutStream(ctx context.Context, urlSuffix string, body io.Reader, length int64, respPayload interface{}) error {
+	req, err := c.newRequest(ctx, http.MethodPut, c.actualURL(urlSuffix), body, "application/octet-stream")
+	if err != nil {
+		return err
+	}
+
+	req.ContentLength = length
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "error running http request")
 	}
 
-	req, err := http.NewRequestWithContext(ctx, method, url, payload)
+	defer resp.Body.Close() //nolint:errcheck
+
+	return decodeResponse(resp, respPayload)
+}
+
+func (c *KopiaAPIClient) newRequest(ctx context.Context, method, url string, body io.Reader, contentType string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
-		return errors.Wrap(err, "error creating request")
+		return nil, errors.Wrap(err, "error creating request")
 	}
 
 	if c.CSRFToken != "" {
@@ -108,6 +146,20 @@ func (c *KopiaAPIClient) runRequest(ctx context.Context, method, url string, not
 		req.Header.Set("Content-Type", contentType)
 	}
 
+	return req, nil
+}
+
+func (c *KopiaAPIClient) runRequest(ctx context.Context, method, url string, notFoundError error, reqPayload, respPayload interface{}) error {
+	payload, contentType, err := requestReader(reqPayload)
+	if err != nil {
+		return errors.Wrap(err, "error getting reader")
+	}
+
+	req, err := c.newRequest(ctx, method, url, payload, contentType)
+	if err != nil {
+		return err
+	}
+
 	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
 		return errors.Wrap(err, "error running http request")
@@ -202,6 +254,9 @@ type Options struct {
 
 	TrustedServerCertificateFingerprint string
 
+	// ClientCertificates are presented to servers requiring TLS client certificate authentication.
+	ClientCertificates []tls.Certificate
+
 	LogRequests bool
 }
 
@@ -216,6 +271,22 @@ func NewKopiaAPIClient(options Options) (*KopiaAPIClient, error) {
 		transport = http.DefaultTransport
 	}
 
+	if len(options.ClientCertificates) > 0 {
+		tp, ok := transport.(*http.Transport)
+		if !ok {
+			return nil, errors.Errorf("client certificates are not supported with transport %T", transport)
+		}
+
+		tp = tp.Clone()
+
+		if tp.TLSClientConfig == nil {
+			tp.TLSClientConfig = &tls.Config{} //nolint:gosec
+		}
+
+		tp.TLSClientConfig.Certificates = options.ClientCertificates
+		transport = tp
+	}
+
 	uri := options.BaseURL
 
 	if strings.HasPrefix(options.BaseURL, "unix+https://") || strings.HasPrefix(options.BaseURL, "unix+http://") {
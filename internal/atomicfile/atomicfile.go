@@ -16,17 +16,23 @@
 const maxPathLength = 240
 
 // MaybePrefixLongFilenameOnWindows prefixes the given filename with \\?\ on Windows
-// if the filename is longer than 260 characters, which is required to be able to
+// if the filename is longer than 260 characters or contains a path component that
+// Windows would otherwise reject or silently alter (reserved device names such as CON or
+// NUL and names ending with a dot or space), which is required to be able to
 // use some low-level Windows APIs.
 // Because long file names have certain limitations:
 // - we must replace forward slashes with backslashes.
 // - dummy path element (\.\) must be removed.
+// - UNC paths (\\server\share) must be prefixed with \\?\UNC\ instead.
 //
 // Relative paths are always limited to a total of MAX_PATH characters:
 // https://learn.microsoft.com/en-us/windows/win32/fileio/maximum-file-path-limitation
 func MaybePrefixLongFilenameOnWindows(fname string) string {
-	if runtime.GOOS != "windows" || len(fname) < maxPathLength ||
-		fname[:4] == `\\?\` || !ospath.IsAbs(fname) {
+	if runtime.GOOS != "windows" || strings.HasPrefix(fname, `\\?\`) || !ospath.IsAbs(fname) {
+		return fname
+	}
+
+	if len(fname) < maxPathLength && !hasReservedPathComponent(fname) {
 		return fname
 	}
 
@@ -41,9 +47,52 @@ func MaybePrefixLongFilenameOnWindows(fname string) string {
 		fixed = fixed2
 	}
 
+	if strings.HasPrefix(fixed, `\\`) {
+		return `\\?\UNC\` + fixed[2:]
+	}
+
 	return `\\?\` + fixed
 }
 
+// hasReservedPathComponent returns true if any element of the provided path is a name
+// that can only be accessed on Windows using \\?\ paths.
+func hasReservedPathComponent(fname string) bool {
+	for _, p := range strings.FieldsFunc(fname, func(r rune) bool { return r == '/' || r == '\\' }) {
+		if IsReservedWindowsName(p) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsReservedWindowsName returns true if the provided file name is a reserved device name
+// (CON, PRN, AUX, NUL, COM0-9, LPT0-9, with or without an extension) or ends with a dot
+// or space, which Windows strips when the name is not accessed using \\?\ paths.
+func IsReservedWindowsName(name string) bool {
+	if name == "." || name == ".." || name == "" {
+		return false
+	}
+
+	if strings.HasSuffix(name, ".") || strings.HasSuffix(name, " ") {
+		return true
+	}
+
+	base, _, _ := strings.Cut(name, ".")
+	base = strings.ToUpper(strings.TrimRight(base, " "))
+
+	switch base {
+	case "CON", "PRN", "AUX", "NUL":
+		return true
+	}
+
+	if len(base) == 4 && (strings.HasPrefix(base, "COM") || strings.HasPrefix(base, "LPT")) {
+		return base[3] >= '0' && base[3] <= '9'
+	}
+
+	return false
+}
+
 // Write is a wrapper around atomic.WriteFile that handles long file names on Windows.
 func Write(filename string, r io.Reader) error {
 	//nolint:wrapcheck
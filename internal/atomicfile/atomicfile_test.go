@@ -28,6 +28,14 @@ func TestMaybePrefixLongFilenameOnWindows(t *testing.T) {
 		{"C:\\" + veryLongSegment + "/.\\foo", "\\\\?\\C:\\" + veryLongSegment + "\\foo"},
 		{"C:\\" + veryLongSegment + "\\./foo", "\\\\?\\C:\\" + veryLongSegment + "\\foo"},
 		{"\\\\?\\C:\\" + veryLongSegment + "\\foo", "\\\\?\\C:\\" + veryLongSegment + "\\foo"},
+		{"\\\\server\\share\\" + veryLongSegment + "\\foo", "\\\\?\\UNC\\server\\share\\" + veryLongSegment + "\\foo"},
+
+		// reserved names
+		{"C:\\dir\\CON", "\\\\?\\C:\\dir\\CON"},
+		{"C:\\aux.txt\\foo", "\\\\?\\C:\\aux.txt\\foo"},
+		{"C:\\dir\\trailing.", "\\\\?\\C:\\dir\\trailing."},
+		{"\\\\server\\share\\nul", "\\\\?\\UNC\\server\\share\\nul"},
+		{"C:\\dir\\console.txt", "C:\\dir\\console.txt"},
 
 		// relative
 		{veryLongSegment + "\\foo", veryLongSegment + "\\foo"},
@@ -42,3 +50,32 @@ func TestMaybePrefixLongFilenameOnWindows(t *testing.T) {
 		}
 	}
 }
+
+func TestIsReservedWindowsName(t *testing.T) {
+	cases := map[string]bool{
+		"CON":         true,
+		"con":         true,
+		"Con.txt":     true,
+		"nul.tar.gz":  true,
+		"AUX":         true,
+		"prn":         true,
+		"COM1":        true,
+		"lpt9.log":    true,
+		"trailing.":   true,
+		"trailing ":   true,
+		"COM":         false,
+		"COM10":       false,
+		"console":     false,
+		"connect.txt": false,
+		"file.txt":    false,
+		".":           false,
+		"..":          false,
+		"":            false,
+	}
+
+	for name, want := range cases {
+		if got := IsReservedWindowsName(name); got != want {
+			t.Errorf("invalid result for %q: got %v, want %v", name, got, want)
+		}
+	}
+}
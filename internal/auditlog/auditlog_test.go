@@ -0,0 +1,65 @@
+package auditlog_test
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/auditlog"
+	"github.com/kopia/kopia/internal/testlogging"
+	"github.com/kopia/kopia/internal/testutil"
+)
+
+func TestFileSink(t *testing.T) {
+	ctx := testlogging.Context(t)
+	fname := filepath.Join(testutil.TempDirectory(t), "audit.json")
+
+	fs, err := auditlog.NewFileSink(fname)
+	require.NoError(t, err)
+
+	l := auditlog.NewLogger(fs)
+	l.Emit(ctx, auditlog.Event{Action: auditlog.ActionConnect, User: "foo@bar", Remote: "127.0.0.1:1234"})
+	l.Emit(ctx, auditlog.Event{Action: auditlog.ActionSnapshot, Source: "foo@bar:/tmp", Error: auditlog.ErrorString(errors.New("some error"))})
+	require.NoError(t, l.Close())
+
+	// events are appended when the file is reopened.
+	fs, err = auditlog.NewFileSink(fname)
+	require.NoError(t, err)
+
+	l = auditlog.NewLogger(fs)
+	l.Emit(ctx, auditlog.Event{Action: auditlog.ActionMaintenance})
+	require.NoError(t, l.Close())
+
+	f, err := os.Open(fname)
+	require.NoError(t, err)
+
+	defer f.Close()
+
+	var events []auditlog.Event
+
+	for dec := json.NewDecoder(f); dec.More(); {
+		var ev auditlog.Event
+
+		require.NoError(t, dec.Decode(&ev))
+
+		events = append(events, ev)
+	}
+
+	require.Len(t, events, 3)
+	require.Equal(t, auditlog.ActionConnect, events[0].Action)
+	require.Equal(t, "foo@bar", events[0].User)
+	require.False(t, events[0].Time.IsZero())
+	require.Equal(t, "some error", events[1].Error)
+	require.Equal(t, auditlog.ActionMaintenance, events[2].Action)
+}
+
+func TestNilLogger(t *testing.T) {
+	var l *auditlog.Logger
+
+	l.Emit(testlogging.Context(t), auditlog.Event{Action: auditlog.ActionConnect})
+	require.NoError(t, l.Close())
+}
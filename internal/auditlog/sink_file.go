@@ -0,0 +1,43 @@
+package auditlog
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+type fileSink struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+func (s *fileSink) WriteEvent(ev *Event) error {
+	b, err := json.Marshal(ev)
+	if err != nil {
+		return errors.Wrap(err, "unable to marshal audit event")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err = s.f.Write(append(b, '\n'))
+
+	return errors.Wrap(err, "unable to write audit event")
+}
+
+func (s *fileSink) Close() error {
+	return errors.Wrap(s.f.Close(), "unable to close audit log file")
+}
+
+// NewFileSink returns a Sink that appends events as JSON lines to the provided file.
+func NewFileSink(filename string) (Sink, error) {
+	//nolint:gosec
+	f, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to open audit log file")
+	}
+
+	return &fileSink{f: f}, nil
+}
@@ -0,0 +1,46 @@
+package auditlog
+
+import (
+	"github.com/coreos/go-systemd/v22/journal"
+	"github.com/pkg/errors"
+)
+
+type journaldSink struct{}
+
+func (journaldSink) WriteEvent(ev *Event) error {
+	pri := journal.PriInfo
+	if ev.Error != "" || ev.Action == ActionAccessDenied {
+		pri = journal.PriWarning
+	}
+
+	vars := map[string]string{
+		"SYSLOG_IDENTIFIER":   "kopia",
+		"KOPIA_AUDIT_ACTION":  ev.Action,
+		"KOPIA_AUDIT_USER":    ev.User,
+		"KOPIA_AUDIT_REMOTE":  ev.Remote,
+		"KOPIA_AUDIT_SOURCE":  ev.Source,
+		"KOPIA_AUDIT_DETAILS": ev.Details,
+		"KOPIA_AUDIT_ERROR":   ev.Error,
+	}
+
+	for k, v := range vars {
+		if v == "" {
+			delete(vars, k)
+		}
+	}
+
+	return errors.Wrap(journal.Send(ev.summary(), pri, vars), "unable to send audit event to journald")
+}
+
+func (journaldSink) Close() error {
+	return nil
+}
+
+// NewJournaldSink returns a Sink that sends events to the systemd journal.
+func NewJournaldSink() (Sink, error) {
+	if !journal.Enabled() {
+		return nil, errors.New("systemd journal is not available")
+	}
+
+	return journaldSink{}, nil
+}
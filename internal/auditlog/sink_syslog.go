@@ -0,0 +1,36 @@
+//go:build !windows && !plan9
+
+package auditlog
+
+import (
+	"log/syslog"
+
+	"github.com/pkg/errors"
+)
+
+type syslogSink struct {
+	w *syslog.Writer
+}
+
+func (s syslogSink) WriteEvent(ev *Event) error {
+	if ev.Error != "" || ev.Action == ActionAccessDenied {
+		return errors.Wrap(s.w.Warning(ev.summary()), "unable to send audit event to syslog")
+	}
+
+	return errors.Wrap(s.w.Info(ev.summary()), "unable to send audit event to syslog")
+}
+
+func (s syslogSink) Close() error {
+	return errors.Wrap(s.w.Close(), "unable to close syslog")
+}
+
+// NewSyslogSink returns a Sink that sends events to the syslog server at the provided address
+// (network "udp", "tcp" or "unix") or to the local syslog daemon when address is empty.
+func NewSyslogSink(network, address string) (Sink, error) {
+	w, err := syslog.Dial(network, address, syslog.LOG_INFO|syslog.LOG_AUTH, "kopia")
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to connect to syslog")
+	}
+
+	return syslogSink{w}, nil
+}
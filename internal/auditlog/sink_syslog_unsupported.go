@@ -0,0 +1,12 @@
+//go:build windows || plan9
+
+package auditlog
+
+import (
+	"github.com/pkg/errors"
+)
+
+// NewSyslogSink is not supported on this platform.
+func NewSyslogSink(network, address string) (Sink, error) {
+	return nil, errors.New("syslog is not supported on this platform")
+}
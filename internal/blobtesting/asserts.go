@@ -78,8 +78,8 @@ func AssertGetBlob(ctx context.Context, t *testing.T, s blob.Storage, blobID blo
 		return
 	}
 
-	if v := b.ToByteSlice(); !bytes.Equal(v, expected[len(expected)-int(half):]) {
-		t.Fatalf("GetBlob(%v) returned %x, but expected %x", blobID, v, expected[len(expected)-int(half):])
+	if v := b.ToByteSlice(); !bytes.Equal(v, expected[half:]) {
+		t.Fatalf("GetBlob(%v) returned %x, but expected %x", blobID, v, expected[half:])
 	}
 
 	AssertInvalidOffsetLength(ctx, t, s, blobID, -3, 1)
@@ -0,0 +1,90 @@
+package cache
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/gather"
+	"github.com/kopia/kopia/internal/impossible"
+	"github.com/kopia/kopia/repo/blob"
+)
+
+// blobCachingStorage is a blob.Storage wrapper that keeps full copies of immutable blobs
+// in a persistent LRU cache.
+type blobCachingStorage struct {
+	blob.Storage
+	pc       *PersistentCache
+	prefixes []blob.ID
+}
+
+func (s *blobCachingStorage) isCached(id blob.ID) bool {
+	for _, p := range s.prefixes {
+		if strings.HasPrefix(string(id), string(p)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *blobCachingStorage) GetBlob(ctx context.Context, id blob.ID, offset, length int64, output blob.OutputBuffer) error {
+	if !s.isCached(id) {
+		//nolint:wrapcheck
+		return s.Storage.GetBlob(ctx, id, offset, length, output)
+	}
+
+	var tmp gather.WriteBuffer
+	defer tmp.Close()
+
+	// always read full blob from the cache, so that out-of-range requests can be detected.
+	if err := s.pc.GetOrLoad(ctx, BlobIDCacheKey(id), func(output *gather.WriteBuffer) error {
+		//nolint:wrapcheck
+		return s.Storage.GetBlob(ctx, id, 0, -1, output)
+	}, &tmp); err != nil {
+		//nolint:wrapcheck
+		return err
+	}
+
+	output.Reset()
+
+	if length < 0 && offset == 0 {
+		_, err := tmp.Bytes().WriteTo(output)
+
+		return errors.Wrap(err, "error copying cached blob")
+	}
+
+	if length < 0 {
+		length = int64(tmp.Length()) - offset
+	}
+
+	if offset < 0 || length < 0 || offset+length > int64(tmp.Length()) {
+		return errors.Wrapf(blob.ErrInvalidRange, "invalid (offset=%v,length=%v) for blob %q of size %v", offset, length, id, tmp.Length())
+	}
+
+	impossible.PanicOnError(tmp.AppendSectionTo(output, int(offset), int(length)))
+
+	return nil
+}
+
+func (s *blobCachingStorage) DeleteBlob(ctx context.Context, id blob.ID) error {
+	if s.isCached(id) {
+		s.pc.deleteInvalidBlob(ctx, BlobIDCacheKey(id))
+	}
+
+	//nolint:wrapcheck
+	return s.Storage.DeleteBlob(ctx, id)
+}
+
+// NewBlobCachingWrapper returns a blob.Storage wrapper that caches full contents of blobs with the
+// provided prefixes in the persistent cache. Only immutable blobs should be cached this way, since
+// modifications made by other clients are not detected. The size of the cache is bounded by
+// the sweep settings of the provided cache.
+func NewBlobCachingWrapper(st blob.Storage, pc *PersistentCache, prefixes []blob.ID) blob.Storage {
+	if pc == nil || len(prefixes) == 0 {
+		return st
+	}
+
+	return &blobCachingStorage{st, pc, prefixes}
+}
@@ -0,0 +1,68 @@
+package cache_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/blobtesting"
+	"github.com/kopia/kopia/internal/cache"
+	"github.com/kopia/kopia/internal/cacheprot"
+	"github.com/kopia/kopia/internal/clock"
+	"github.com/kopia/kopia/internal/gather"
+	"github.com/kopia/kopia/internal/testlogging"
+	"github.com/kopia/kopia/repo/blob"
+)
+
+func TestBlobCachingWrapper(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	data := blobtesting.DataMap{}
+	st := blobtesting.NewMapStorage(data, nil, nil)
+
+	require.NoError(t, st.PutBlob(ctx, "p1", gather.FromSlice([]byte{1, 2, 3, 4, 5}), blob.PutOptions{}))
+	require.NoError(t, st.PutBlob(ctx, "x1", gather.FromSlice([]byte{1, 2, 3}), blob.PutOptions{}))
+
+	cs := blobtesting.NewMapStorage(blobtesting.DataMap{}, nil, nil).(cache.Storage)
+
+	pc, err := cache.NewPersistentCache(ctx, "testing", cs, cacheprot.ChecksumProtection([]byte{1, 2, 3}), cache.SweepSettings{
+		MaxSizeBytes:   1000,
+		TouchThreshold: cache.DefaultTouchThreshold,
+	}, nil, clock.Now)
+	require.NoError(t, err)
+
+	defer pc.Close(ctx)
+
+	cst := cache.NewBlobCachingWrapper(st, pc, []blob.ID{"p"})
+
+	var tmp gather.WriteBuffer
+	defer tmp.Close()
+
+	require.NoError(t, cst.GetBlob(ctx, "p1", 1, 3, &tmp))
+	require.Equal(t, []byte{2, 3, 4}, tmp.ToByteSlice())
+
+	require.NoError(t, cst.GetBlob(ctx, "x1", 0, -1, &tmp))
+	require.Equal(t, []byte{1, 2, 3}, tmp.ToByteSlice())
+
+	// modify underlying blobs behind the wrapper's back.
+	data["p1"] = []byte{9, 9, 9, 9, 9}
+	data["x1"] = []byte{9, 9, 9}
+
+	// cached blob is served from the cache, both in full and partially.
+	require.NoError(t, cst.GetBlob(ctx, "p1", 0, -1, &tmp))
+	require.Equal(t, []byte{1, 2, 3, 4, 5}, tmp.ToByteSlice())
+
+	require.NoError(t, cst.GetBlob(ctx, "p1", 3, 2, &tmp))
+	require.Equal(t, []byte{4, 5}, tmp.ToByteSlice())
+
+	// blobs not matching the prefixes are not cached.
+	require.NoError(t, cst.GetBlob(ctx, "x1", 0, -1, &tmp))
+	require.Equal(t, []byte{9, 9, 9}, tmp.ToByteSlice())
+
+	// deleting a blob removes it from the cache.
+	require.NoError(t, cst.DeleteBlob(ctx, "p1"))
+	require.ErrorIs(t, cst.GetBlob(ctx, "p1", 0, -1, &tmp), blob.ErrBlobNotFound)
+
+	require.NoError(t, st.PutBlob(ctx, "p2", gather.FromSlice([]byte{1, 2, 3}), blob.PutOptions{}))
+	require.ErrorIs(t, cst.GetBlob(ctx, "p2", 2, 5, &tmp), blob.ErrInvalidRange)
+}
@@ -16,6 +16,15 @@
 	purposeAuthData = []byte("CHECKSUM")
 )
 
+var (
+	// ErrPayloadTooShort is returned when the encrypted payload is too short to hold the nonce and authentication tag.
+	ErrPayloadTooShort = errors.New("invalid encrypted payload, too short")
+
+	// ErrDecryptionFailed is returned when the encrypted payload fails authentication, which is typically
+	// caused by invalid credentials or corrupted data.
+	ErrDecryptionFailed = errors.New("unable to decrypt repository blob, invalid credentials?")
+)
+
 func initCrypto(masterKey, salt []byte) (cipher.AEAD, []byte, error) {
 	aesKey := DeriveKeyFromMasterKey(masterKey, salt, purposeAESKey, 32)     //nolint:mnd
 	authData := DeriveKeyFromMasterKey(masterKey, salt, purposeAuthData, 32) //nolint:mnd
@@ -40,6 +49,11 @@ func EncryptAes256Gcm(data, masterKey, salt []byte) ([]byte, error) {
 		return nil, errors.Wrap(err, "unable to initialize crypto")
 	}
 
+	return sealWithRandomNonce(aead, authData, data)
+}
+
+// sealWithRandomNonce encrypts data using the provided AEAD and returns a random nonce followed by the ciphertext.
+func sealWithRandomNonce(aead cipher.AEAD, authData, data []byte) ([]byte, error) {
 	nonceLength := aead.NonceSize()
 	noncePlusContentLength := nonceLength + len(data)
 	cipherText := make([]byte, noncePlusContentLength+aead.Overhead())
@@ -56,24 +70,31 @@ func EncryptAes256Gcm(data, masterKey, salt []byte) ([]byte, error) {
 	return data, nil
 }
 
-// DecryptAes256Gcm encrypts data with AES 256 GCM.
+// DecryptAes256Gcm decrypts data with AES 256 GCM. It returns ErrPayloadTooShort or ErrDecryptionFailed
+// when the payload is truncated or cannot be authenticated, respectively.
 func DecryptAes256Gcm(data, masterKey, salt []byte) ([]byte, error) {
 	aead, authData, err := initCrypto(masterKey, salt)
 	if err != nil {
 		return nil, errors.Wrap(err, "cannot initialize cipher")
 	}
 
-	data = append([]byte(nil), data...)
-	if len(data) < aead.NonceSize() {
-		return nil, errors.New("invalid encrypted payload, too short")
+	return openWithNonce(aead, authData, data)
+}
+
+// openWithNonce decrypts data produced by sealWithRandomNonce.
+func openWithNonce(aead cipher.AEAD, authData, data []byte) ([]byte, error) {
+	if len(data) < aead.NonceSize()+aead.Overhead() {
+		return nil, errors.Wrapf(ErrPayloadTooShort, "got %v bytes", len(data))
 	}
 
+	data = append([]byte(nil), data...)
+
 	nonce := data[0:aead.NonceSize()]
 	payload := data[aead.NonceSize():]
 
 	plainText, err := aead.Open(payload[:0], nonce, payload, authData)
 	if err != nil {
-		return nil, errors.New("unable to decrypt repository blob, invalid credentials?")
+		return nil, ErrDecryptionFailed
 	}
 
 	return plainText, nil
@@ -0,0 +1,54 @@
+package crypto_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/crypto"
+)
+
+func TestDecryptAes256Gcm(t *testing.T) {
+	plainText := []byte("some plain text")
+
+	cipherText, err := crypto.EncryptAes256Gcm(plainText, TestMasterKey, TestSalt)
+	require.NoError(t, err)
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		got, err := crypto.DecryptAes256Gcm(cipherText, TestMasterKey, TestSalt)
+		require.NoError(t, err)
+		require.Equal(t, plainText, got)
+	})
+
+	t.Run("RejectsTruncatedPayload", func(t *testing.T) {
+		// nonce and tag are 12+16 bytes, anything shorter cannot be valid.
+		for _, n := range []int{0, 1, 12, 27} {
+			_, err := crypto.DecryptAes256Gcm(cipherText[:n], TestMasterKey, TestSalt)
+			require.ErrorIs(t, err, crypto.ErrPayloadTooShort)
+		}
+
+		_, err := crypto.DecryptAes256Gcm(cipherText[:28], TestMasterKey, TestSalt)
+		require.ErrorIs(t, err, crypto.ErrDecryptionFailed)
+	})
+
+	t.Run("RejectsCorruptPayload", func(t *testing.T) {
+		corrupt := append([]byte(nil), cipherText...)
+		corrupt[len(corrupt)/2] ^= 1
+
+		_, err := crypto.DecryptAes256Gcm(corrupt, TestMasterKey, TestSalt)
+		require.ErrorIs(t, err, crypto.ErrDecryptionFailed)
+	})
+
+	t.Run("RejectsWrongKey", func(t *testing.T) {
+		_, err := crypto.DecryptAes256Gcm(cipherText, []byte("some other key"), TestSalt)
+		require.ErrorIs(t, err, crypto.ErrDecryptionFailed)
+	})
+
+	t.Run("DoesNotModifyInput", func(t *testing.T) {
+		orig := append([]byte(nil), cipherText...)
+
+		_, err := crypto.DecryptAes256Gcm(cipherText, TestMasterKey, TestSalt)
+		require.NoError(t, err)
+		require.Equal(t, orig, cipherText)
+	})
+}
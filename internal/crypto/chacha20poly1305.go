@@ -0,0 +1,44 @@
+package crypto
+
+import (
+	"crypto/cipher"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+//nolint:gochecknoglobals
+var purposeChaCha20Key = []byte("CHACHA20")
+
+func initChaCha20Poly1305(masterKey, salt []byte) (cipher.AEAD, []byte, error) {
+	key := DeriveKeyFromMasterKey(masterKey, salt, purposeChaCha20Key, chacha20poly1305.KeySize)
+	authData := DeriveKeyFromMasterKey(masterKey, salt, purposeAuthData, 32) //nolint:mnd
+
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "cannot create cipher")
+	}
+
+	return aead, authData, nil
+}
+
+// EncryptChaCha20Poly1305 encrypts data with ChaCha20-Poly1305.
+func EncryptChaCha20Poly1305(data, masterKey, salt []byte) ([]byte, error) {
+	aead, authData, err := initChaCha20Poly1305(masterKey, salt)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to initialize crypto")
+	}
+
+	return sealWithRandomNonce(aead, authData, data)
+}
+
+// DecryptChaCha20Poly1305 decrypts data with ChaCha20-Poly1305. It returns ErrPayloadTooShort or ErrDecryptionFailed
+// when the payload is truncated or cannot be authenticated, respectively.
+func DecryptChaCha20Poly1305(data, masterKey, salt []byte) ([]byte, error) {
+	aead, authData, err := initChaCha20Poly1305(masterKey, salt)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot initialize cipher")
+	}
+
+	return openWithNonce(aead, authData, data)
+}
@@ -0,0 +1,36 @@
+package crypto_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/crypto"
+)
+
+func TestDecryptChaCha20Poly1305(t *testing.T) {
+	plainText := []byte("some plain text")
+
+	cipherText, err := crypto.EncryptChaCha20Poly1305(plainText, TestMasterKey, TestSalt)
+	require.NoError(t, err)
+
+	got, err := crypto.DecryptChaCha20Poly1305(cipherText, TestMasterKey, TestSalt)
+	require.NoError(t, err)
+	require.Equal(t, plainText, got)
+
+	_, err = crypto.DecryptChaCha20Poly1305(cipherText[:27], TestMasterKey, TestSalt)
+	require.ErrorIs(t, err, crypto.ErrPayloadTooShort)
+
+	corrupt := append([]byte(nil), cipherText...)
+	corrupt[len(corrupt)/2] ^= 1
+
+	_, err = crypto.DecryptChaCha20Poly1305(corrupt, TestMasterKey, TestSalt)
+	require.ErrorIs(t, err, crypto.ErrDecryptionFailed)
+
+	_, err = crypto.DecryptChaCha20Poly1305(cipherText, []byte("some other key"), TestSalt)
+	require.ErrorIs(t, err, crypto.ErrDecryptionFailed)
+
+	// ciphertexts are not interchangeable between algorithms.
+	_, err = crypto.DecryptAes256Gcm(cipherText, TestMasterKey, TestSalt)
+	require.ErrorIs(t, err, crypto.ErrDecryptionFailed)
+}
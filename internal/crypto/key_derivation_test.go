@@ -36,3 +36,77 @@ func TestDeriveKeyFromMasterKey(t *testing.T) {
 		})
 	})
 }
+
+func TestDeriveKeyFromPasswordWithParams(t *testing.T) {
+	salt := []byte("0123456789012345")
+
+	for _, alg := range []string{
+		crypto.ScryptAlgorithm,
+		crypto.Pbkdf2Algorithm,
+		crypto.Argon2idAlgorithm,
+		crypto.ScryptAlgorithmWithParams(16384, 8, 2),
+		crypto.Argon2idAlgorithmWithParams(1, 8192, 1),
+	} {
+		require.NoError(t, crypto.ValidatePBKeyDerivationAlgorithm(alg), alg)
+
+		k1, err := crypto.DeriveKeyFromPassword("some-password", salt, 32, alg)
+		require.NoError(t, err, alg)
+		require.Len(t, k1, 32)
+
+		k2, err := crypto.DeriveKeyFromPassword("some-password", salt, 32, alg)
+		require.NoError(t, err, alg)
+		require.Equal(t, k1, k2, alg)
+
+		k3, err := crypto.DeriveKeyFromPassword("other-password", salt, 32, alg)
+		require.NoError(t, err, alg)
+		require.NotEqual(t, k1, k3, alg)
+	}
+
+	// parameterized names with equal parameters produce the same key as registered ones.
+	k1, err := crypto.DeriveKeyFromPassword("some-password", salt, 32, crypto.ScryptAlgorithm)
+	require.NoError(t, err)
+
+	k2, err := crypto.DeriveKeyFromPassword("some-password", salt, 32, crypto.ScryptAlgorithmWithParams(65536, 8, 1))
+	require.NoError(t, err)
+	require.Equal(t, k1, k2)
+
+	// different parameters produce different keys.
+	k3, err := crypto.DeriveKeyFromPassword("some-password", salt, 32, crypto.ScryptAlgorithmWithParams(16384, 8, 1))
+	require.NoError(t, err)
+	require.NotEqual(t, k1, k3)
+
+	for _, alg := range []string{
+		"",
+		"no-such-algorithm",
+		"scrypt",
+		"scrypt-65536-8",
+		"scrypt-65536-8-1-1",
+		"scrypt-65535-8-1",
+		"scrypt-1024-8-1",
+		"scrypt-8388608-8-1",
+		"scrypt-4194304-8-1",
+		"scrypt-1048576-16-1",
+		"scrypt-65536-0-1",
+		"scrypt-65536-x-1",
+		"argon2id-0-65536-4",
+		"argon2id-3-1024-4",
+		"argon2id-3-65536-256",
+		"argon2id-3-1048577-4",
+		"pbkdf2-sha256-1000-1",
+	} {
+		require.Error(t, crypto.ValidatePBKeyDerivationAlgorithm(alg), alg)
+
+		_, err := crypto.DeriveKeyFromPassword("some-password", salt, 32, alg)
+		require.Error(t, err, alg)
+	}
+
+	// scrypt memory usage (128*N*r bytes) is capped at 1 GiB.
+	require.NoError(t, crypto.ValidatePBKeyDerivationAlgorithm("scrypt-1048576-8-1"))
+	require.NoError(t, crypto.ValidatePBKeyDerivationAlgorithm("scrypt-4194304-2-1"))
+
+	// argon2id memory is capped at 1 GiB as well.
+	require.NoError(t, crypto.ValidatePBKeyDerivationAlgorithm("argon2id-1-1048576-4"))
+
+	_, err = crypto.DeriveKeyFromPassword("some-password", []byte("short"), 32, crypto.Argon2idAlgorithm)
+	require.ErrorContains(t, err, "salt size")
+}
@@ -0,0 +1,44 @@
+package crypto
+
+import (
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	// Argon2idAlgorithm is the registration name for the default argon2id algorithm instance,
+	// using 3 passes over 64 MiB of memory with 4 threads.
+	Argon2idAlgorithm = "argon2id-3-65536-4"
+
+	// The recommended minimum size for a salt to be used for argon2id.
+	argon2idMinSaltLength = 16 // 128 bits
+)
+
+func init() {
+	registerPBKeyDeriver(Argon2idAlgorithm, &argon2idKeyDeriver{
+		time:          3,         //nolint:mnd
+		memoryKiB:     64 * 1024, //nolint:mnd
+		threads:       4,         //nolint:mnd
+		minSaltLength: argon2idMinSaltLength,
+	})
+}
+
+type argon2idKeyDeriver struct {
+	// time is the number of passes over the memory.
+	time uint32
+	// memoryKiB is the amount of memory used, in KiB.
+	memoryKiB uint32
+	// threads is the degree of parallelism.
+	threads uint8
+
+	minSaltLength int
+}
+
+func (s *argon2idKeyDeriver) deriveKeyFromPassword(password string, salt []byte, keySize int) ([]byte, error) {
+	if len(salt) < s.minSaltLength {
+		return nil, errors.Errorf("required salt size is at least %d bytes", s.minSaltLength)
+	}
+
+	//nolint:gosec
+	return argon2.IDKey([]byte(password), salt, s.time, s.memoryKiB, s.threads, uint32(keySize)), nil
+}
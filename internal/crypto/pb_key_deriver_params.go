@@ -0,0 +1,112 @@
+package crypto
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Bounds on tunable key derivation parameters. Lower bounds prevent accidentally weak settings,
+// upper bounds prevent a malicious format blob from exhausting memory or CPU.
+const (
+	scryptMinN           = 1 << 14
+	scryptMaxN           = 1 << 22
+	scryptMaxR           = 32
+	scryptMaxP           = 16
+	scryptMaxMemoryBytes = 1 << 30 // scrypt uses 128*N*r bytes of memory
+	argon2idMaxTime      = 100
+	argon2idMinMemoryKiB = 8 * 1024
+	argon2idMaxMemoryKiB = 1024 * 1024 // 1 GiB, same as scrypt
+	argon2idMaxThreads   = 255
+	numTunableParams     = 3
+)
+
+// ScryptAlgorithmWithParams returns the name of the scrypt algorithm with the provided
+// CPU/memory cost (n), block size (r) and parallelization (p) parameters.
+func ScryptAlgorithmWithParams(n, r, p int) string {
+	return fmt.Sprintf("scrypt-%v-%v-%v", n, r, p)
+}
+
+// Argon2idAlgorithmWithParams returns the name of the argon2id algorithm with the provided
+// number of passes, memory size in KiB and number of threads.
+func Argon2idAlgorithmWithParams(time, memoryKiB, threads int) string {
+	return fmt.Sprintf("argon2id-%v-%v-%v", time, memoryKiB, threads)
+}
+
+// ValidatePBKeyDerivationAlgorithm returns an error if the provided password-based key derivation
+// algorithm is neither registered nor a valid parameterized scrypt or argon2id algorithm name.
+func ValidatePBKeyDerivationAlgorithm(algorithm string) error {
+	_, err := getPBKeyDeriver(algorithm)
+
+	return err
+}
+
+func getPBKeyDeriver(algorithm string) (passwordBasedKeyDeriver, error) {
+	if kd, ok := keyDerivers[algorithm]; ok {
+		return kd, nil
+	}
+
+	return parsePBKeyDeriver(algorithm)
+}
+
+// parsePBKeyDeriver parses algorithm names of the form 'scrypt-N-R-P' or 'argon2id-TIME-MEMORY-THREADS'.
+func parsePBKeyDeriver(algorithm string) (passwordBasedKeyDeriver, error) {
+	kind, rest, _ := strings.Cut(algorithm, "-")
+
+	var params []int
+
+	for _, s := range strings.Split(rest, "-") {
+		v, err := strconv.Atoi(s)
+		if err != nil || v <= 0 {
+			return nil, unsupportedPBKeyDerivationAlgorithm(algorithm)
+		}
+
+		params = append(params, v)
+	}
+
+	if len(params) != numTunableParams {
+		return nil, unsupportedPBKeyDerivationAlgorithm(algorithm)
+	}
+
+	switch kind {
+	case "scrypt":
+		n, r, p := params[0], params[1], params[2]
+
+		if n < scryptMinN || n > scryptMaxN || n&(n-1) != 0 {
+			return nil, errors.Errorf("invalid scrypt cost parameter %v, must be a power of two between %v and %v", n, scryptMinN, scryptMaxN)
+		}
+
+		if r > scryptMaxR || p > scryptMaxP {
+			return nil, errors.Errorf("invalid scrypt parameters r=%v p=%v, maximum values are r=%v p=%v", r, p, scryptMaxR, scryptMaxP)
+		}
+
+		if int64(128)*int64(n)*int64(r) > scryptMaxMemoryBytes {
+			return nil, errors.Errorf("invalid scrypt parameters N=%v r=%v, would require more than %v bytes of memory", n, r, scryptMaxMemoryBytes)
+		}
+
+		return &scryptKeyDeriver{n: n, r: r, p: p, minSaltLength: scryptMinSaltLength}, nil
+
+	case "argon2id":
+		t, m, thr := params[0], params[1], params[2]
+
+		if t > argon2idMaxTime || m < argon2idMinMemoryKiB || m > argon2idMaxMemoryKiB || thr > argon2idMaxThreads {
+			return nil, errors.Errorf("invalid argon2id parameters time=%v memory=%v threads=%v", t, m, thr)
+		}
+
+		return &argon2idKeyDeriver{
+			time:          uint32(t),  //nolint:gosec
+			memoryKiB:     uint32(m),  //nolint:gosec
+			threads:       uint8(thr), //nolint:gosec
+			minSaltLength: argon2idMinSaltLength,
+		}, nil
+
+	default:
+		return nil, unsupportedPBKeyDerivationAlgorithm(algorithm)
+	}
+}
+
+func unsupportedPBKeyDerivationAlgorithm(algorithm string) error {
+	return errors.Errorf("unsupported key derivation algorithm: %v, supported algorithms %v or %v", algorithm, supportedPBKeyDerivationAlgorithms(), "scrypt-N-R-P, argon2id-TIME-MEMORY-THREADS")
+}
@@ -2,8 +2,6 @@
 
 import (
 	"fmt"
-
-	"github.com/pkg/errors"
 )
 
 // passwordBasedKeyDeriver is an interface that contains methods for deriving a key from a password.
@@ -24,10 +22,12 @@ func registerPBKeyDeriver(name string, keyDeriver passwordBasedKeyDeriver) {
 }
 
 // DeriveKeyFromPassword derives encryption key using the provided password and per-repository unique ID.
+// In addition to registered algorithms, parameterized scrypt and argon2id names are accepted, see
+// ScryptAlgorithmWithParams() and Argon2idAlgorithmWithParams().
 func DeriveKeyFromPassword(password string, salt []byte, keySize int, algorithm string) ([]byte, error) {
-	kd, ok := keyDerivers[algorithm]
-	if !ok {
-		return nil, errors.Errorf("unsupported key derivation algorithm: %v, supported algorithms %v", algorithm, supportedPBKeyDerivationAlgorithms())
+	kd, err := getPBKeyDeriver(algorithm)
+	if err != nil {
+		return nil, err
 	}
 
 	//nolint:wrapcheck
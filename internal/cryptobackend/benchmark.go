@@ -0,0 +1,52 @@
+package cryptobackend
+
+import (
+	"time"
+
+	"github.com/kopia/kopia/internal/gather"
+	"github.com/kopia/kopia/internal/timetrack"
+	"github.com/kopia/kopia/repo/hashing"
+)
+
+const (
+	benchmarkBlockSize   = 64 << 10
+	benchmarkBlockCount  = 64
+	benchmarkDigestBytes = 16
+)
+
+// benchmarkHashParameters implements hashing.Parameters for benchmarking purposes.
+type benchmarkHashParameters string
+
+func (p benchmarkHashParameters) GetHashFunction() string { return string(p) }
+func (p benchmarkHashParameters) GetHmacSecret() []byte   { return make([]byte, benchmarkDigestBytes) }
+
+// FastestHashAlgorithm measures the throughput of registered hash algorithms producing 128-bit digests,
+// which is the size used by default, and returns the fastest one on the current machine.
+func FastestHashAlgorithm() string {
+	var (
+		best         = HashAlgorithm()
+		bestDuration time.Duration
+		out          [hashing.MaxHashSize]byte
+	)
+
+	data := gather.FromSlice(make([]byte, benchmarkBlockSize))
+
+	for _, name := range hashing.SupportedAlgorithms() {
+		hf, err := hashing.CreateHashFunc(benchmarkHashParameters(name))
+		if err != nil || len(hf(out[:0], data)) != benchmarkDigestBytes {
+			continue
+		}
+
+		t := timetrack.StartTimer()
+
+		for range benchmarkBlockCount {
+			hf(out[:0], data)
+		}
+
+		if dur := t.Elapsed(); bestDuration == 0 || dur < bestDuration {
+			best, bestDuration = name, dur
+		}
+	}
+
+	return best
+}
@@ -0,0 +1,69 @@
+// Package cryptobackend detects hardware acceleration of cryptographic primitives and selects the
+// hash and encryption algorithms that are expected to perform best on the current machine.
+package cryptobackend
+
+import (
+	"runtime"
+
+	"golang.org/x/sys/cpu"
+
+	"github.com/kopia/kopia/repo/encryption"
+	"github.com/kopia/kopia/repo/hashing"
+)
+
+const (
+	// acceleratedHashAlgorithm is faster than hashing.DefaultAlgorithm when SHA-256 is implemented in hardware.
+	acceleratedHashAlgorithm = "HMAC-SHA256-128"
+
+	// softwareEncryptionAlgorithm is faster than encryption.DefaultAlgorithm when AES is not implemented in hardware.
+	softwareEncryptionAlgorithm = "CHACHA20-POLY1305-HMAC-SHA256"
+)
+
+// Info describes detected hardware acceleration and algorithms selected for new repositories.
+type Info struct {
+	Architecture string `json:"arch"`
+
+	// HardwareAES is true when the CPU implements AES instructions (AES-NI on x86).
+	HardwareAES bool `json:"hardwareAES"`
+
+	// HardwareSHA256 is true when the CPU implements SHA-256 instructions.
+	// SHA extensions are not detected on x86, where this is always false.
+	HardwareSHA256 bool `json:"hardwareSHA256"`
+
+	HashAlgorithm       string `json:"hash"`
+	EncryptionAlgorithm string `json:"encryption"`
+}
+
+// Detect returns information about hardware acceleration available on the current machine
+// along with the selected algorithms.
+func Detect() Info {
+	return selectAlgorithms(Info{
+		Architecture:   runtime.GOARCH,
+		HardwareAES:    cpu.X86.HasAES || cpu.ARM64.HasAES || cpu.S390X.HasAES,
+		HardwareSHA256: cpu.ARM64.HasSHA2 || cpu.S390X.HasSHA256,
+	})
+}
+
+// HashAlgorithm returns the hash algorithm to use for new repositories on the current machine.
+func HashAlgorithm() string {
+	return Detect().HashAlgorithm
+}
+
+// EncryptionAlgorithm returns the encryption algorithm to use for new repositories on the current machine.
+func EncryptionAlgorithm() string {
+	return Detect().EncryptionAlgorithm
+}
+
+func selectAlgorithms(i Info) Info {
+	i.HashAlgorithm = hashing.DefaultAlgorithm
+	if i.HardwareSHA256 {
+		i.HashAlgorithm = acceleratedHashAlgorithm
+	}
+
+	i.EncryptionAlgorithm = encryption.DefaultAlgorithm
+	if !i.HardwareAES {
+		i.EncryptionAlgorithm = softwareEncryptionAlgorithm
+	}
+
+	return i
+}
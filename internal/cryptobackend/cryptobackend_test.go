@@ -0,0 +1,52 @@
+package cryptobackend
+
+import (
+	"runtime"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/gather"
+	"github.com/kopia/kopia/repo/encryption"
+	"github.com/kopia/kopia/repo/hashing"
+)
+
+func TestSelectAlgorithms(t *testing.T) {
+	cases := []struct {
+		aes, sha       bool
+		wantHash       string
+		wantEncryption string
+	}{
+		{true, false, hashing.DefaultAlgorithm, encryption.DefaultAlgorithm},
+		{true, true, acceleratedHashAlgorithm, encryption.DefaultAlgorithm},
+		{false, false, hashing.DefaultAlgorithm, softwareEncryptionAlgorithm},
+		{false, true, acceleratedHashAlgorithm, softwareEncryptionAlgorithm},
+	}
+
+	for _, tc := range cases {
+		got := selectAlgorithms(Info{HardwareAES: tc.aes, HardwareSHA256: tc.sha})
+		require.Equal(t, tc.wantHash, got.HashAlgorithm, "aes=%v sha=%v", tc.aes, tc.sha)
+		require.Equal(t, tc.wantEncryption, got.EncryptionAlgorithm, "aes=%v sha=%v", tc.aes, tc.sha)
+	}
+}
+
+func TestDetect(t *testing.T) {
+	i := Detect()
+
+	require.Equal(t, runtime.GOARCH, i.Architecture)
+	require.Contains(t, hashing.SupportedAlgorithms(), i.HashAlgorithm)
+	require.True(t, slices.Contains(encryption.SupportedAlgorithms(false), i.EncryptionAlgorithm))
+	require.Equal(t, i.HashAlgorithm, HashAlgorithm())
+	require.Equal(t, i.EncryptionAlgorithm, EncryptionAlgorithm())
+}
+
+func TestFastestHashAlgorithm(t *testing.T) {
+	h := FastestHashAlgorithm()
+
+	require.Contains(t, hashing.SupportedAlgorithms(), h)
+
+	hf, err := hashing.CreateHashFunc(benchmarkHashParameters(h))
+	require.NoError(t, err)
+	require.Len(t, hf(nil, gather.FromSlice([]byte{1, 2, 3})), benchmarkDigestBytes)
+}
@@ -50,6 +50,8 @@ type chunkAllocator struct {
 	// +checklocks:mu
 	maxFreeListSize int
 	// +checklocks:mu
+	defaultMaxFreeListSize int
+	// +checklocks:mu
 	freeListHighWaterMark int
 	// +checklocks:mu
 	allocHighWaterMark int
@@ -167,6 +169,36 @@ func (a *chunkAllocator) dumpStats(ctx context.Context, prefix string) {
 	}
 }
 
+// setFreeListBudget limits the number of bytes retained in the free list to the provided value, never
+// exceeding the default free list size and always allowing at least one chunk. Zero restores the default.
+func (a *chunkAllocator) setFreeListBudget(n int64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.defaultMaxFreeListSize == 0 {
+		a.defaultMaxFreeListSize = a.maxFreeListSize
+	}
+
+	a.maxFreeListSize = a.defaultMaxFreeListSize
+
+	if n > 0 {
+		a.maxFreeListSize = max(1, min(a.defaultMaxFreeListSize, int(n/int64(a.chunkSize))))
+	}
+
+	if len(a.freeList) > a.maxFreeListSize {
+		clear(a.freeList[a.maxFreeListSize:])
+		a.freeList = a.freeList[0:a.maxFreeListSize]
+	}
+}
+
+// SetFreeListBudget limits the total amount of memory retained by free lists of the buffer allocators
+// to approximately the provided number of bytes. Zero restores the defaults.
+func SetFreeListBudget(n int64) {
+	defaultAllocator.setFreeListBudget(n / 2)           //nolint:mnd
+	typicalContiguousAllocator.setFreeListBudget(n / 4) //nolint:mnd
+	maxContiguousAllocator.setFreeListBudget(n / 4)     //nolint:mnd
+}
+
 // DumpStats logs the allocator statistics.
 func DumpStats(ctx context.Context) {
 	defaultAllocator.dumpStats(ctx, "default")
@@ -111,3 +111,38 @@ func TestTrackAllocation(t *testing.T) {
 	require.Contains(t, log.String(), `"chunksAlive":0`)
 	require.NotContains(t, log.String(), "leaked chunk")
 }
+
+func TestChunkAllocatorFreeListBudget(t *testing.T) {
+	all := &chunkAllocator{
+		chunkSize:       100,
+		maxFreeListSize: 10,
+	}
+
+	var chunks [][]byte
+
+	for range 10 {
+		chunks = append(chunks, all.allocChunk())
+	}
+
+	for _, ch := range chunks {
+		all.releaseChunk(ch)
+	}
+
+	require.Len(t, all.freeList, 10)
+
+	// budget for 3 chunks trims the free list.
+	all.setFreeListBudget(350)
+	require.Len(t, all.freeList, 3)
+	require.Equal(t, 3, all.maxFreeListSize)
+
+	// at least one chunk is always allowed.
+	all.setFreeListBudget(1)
+	require.Equal(t, 1, all.maxFreeListSize)
+
+	// budget never exceeds the default.
+	all.setFreeListBudget(1e9)
+	require.Equal(t, 10, all.maxFreeListSize)
+
+	all.setFreeListBudget(0)
+	require.Equal(t, 10, all.maxFreeListSize)
+}
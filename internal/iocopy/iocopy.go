@@ -6,7 +6,7 @@
 	"sync"
 )
 
-// BufSize is the size (in bytes) of the shared copy buffers Kopia uses to copy data.
+// BufSize is the default size (in bytes) of the shared copy buffers Kopia uses to copy data.
 const BufSize = 65536
 
 var (
@@ -14,15 +14,31 @@
 
 	// +checklocks:mu
 	buffers [][]byte //nolint:gochecknoglobals
+
+	// +checklocks:mu
+	bufSize = BufSize //nolint:gochecknoglobals
 )
 
+// SetBufferSize changes the size of buffers returned by GetBuffer(), zero restores the default.
+func SetBufferSize(n int) {
+	if n <= 0 {
+		n = BufSize
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	bufSize = n
+	buffers = nil
+}
+
 // GetBuffer allocates new temporary buffer suitable for copying data.
 func GetBuffer() []byte {
 	mu.Lock()
 	defer mu.Unlock()
 
 	if len(buffers) == 0 {
-		return make([]byte, BufSize)
+		return make([]byte, bufSize)
 	}
 
 	var b []byte
@@ -38,6 +54,11 @@ func ReleaseBuffer(b []byte) {
 	mu.Lock()
 	defer mu.Unlock()
 
+	if len(b) != bufSize {
+		// buffer size has changed since the buffer was allocated.
+		return
+	}
+
 	buffers = append(buffers, b)
 }
 
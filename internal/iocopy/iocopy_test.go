@@ -35,6 +35,26 @@ func TestReleaseBuffer(t *testing.T) {
 	require.Equal(t, &buf[0], &buf2[0], "Buffer was not recycled after ReleaseBuffer")
 }
 
+func TestSetBufferSize(t *testing.T) {
+	defer iocopy.SetBufferSize(0)
+
+	iocopy.SetBufferSize(4096)
+
+	buf := iocopy.GetBuffer()
+	require.Len(t, buf, 4096)
+	iocopy.ReleaseBuffer(buf)
+
+	iocopy.SetBufferSize(0)
+
+	// buffers of the previous size are not reused.
+	buf2 := iocopy.GetBuffer()
+	require.Len(t, buf2, iocopy.BufSize)
+	iocopy.ReleaseBuffer(buf)
+	iocopy.ReleaseBuffer(buf2)
+
+	require.Len(t, iocopy.GetBuffer(), iocopy.BufSize)
+}
+
 func TestCopy(t *testing.T) {
 	src := strings.NewReader(testBuf)
 	dst := &bytes.Buffer{}
@@ -42,6 +42,7 @@ type loggingFlags struct {
 	contentLogDirMaxAge         time.Duration
 	contentLogDirMaxTotalSizeMB float64
 	logFileMaxSegmentSize       int
+	logFileMaxSegmentAge        time.Duration
 	logLevel                    string
 	fileLogLevel                string
 	fileLogLocalTimezone        bool
@@ -64,14 +65,15 @@ func (c *loggingFlags) setup(cliApp *cli.App, app *kingpin.Application) {
 	app.Flag("log-dir-max-age", "Maximum age of log files to retain").Envar(cliApp.EnvName("KOPIA_LOG_DIR_MAX_AGE")).Hidden().Default("720h").DurationVar(&c.logDirMaxAge)
 	app.Flag("log-dir-max-total-size-mb", "Maximum total size of log files to retain").Envar(cliApp.EnvName("KOPIA_LOG_DIR_MAX_SIZE_MB")).Hidden().Default("1000").Float64Var(&c.logDirMaxTotalSizeMB)
 	app.Flag("max-log-file-segment-size", "Maximum size of a single log file segment").Envar(cliApp.EnvName("KOPIA_LOG_FILE_MAX_SEGMENT_SIZE")).Default("50000000").Hidden().IntVar(&c.logFileMaxSegmentSize)
+	app.Flag("max-log-file-segment-age", "Maximum age of a single log file segment, 0 means unlimited").Envar(cliApp.EnvName("KOPIA_LOG_FILE_MAX_SEGMENT_AGE")).Default("0").Hidden().DurationVar(&c.logFileMaxSegmentAge)
 	app.Flag("wait-for-log-sweep", "Wait for log sweep before program exit").Default("true").Hidden().BoolVar(&c.waitForLogSweep)
 	app.Flag("content-log-dir-max-files", "Maximum number of content log files to retain").Envar(cliApp.EnvName("KOPIA_CONTENT_LOG_DIR_MAX_FILES")).Default("5000").Hidden().IntVar(&c.contentLogDirMaxFiles)
 	app.Flag("content-log-dir-max-age", "Maximum age of content log files to retain").Envar(cliApp.EnvName("KOPIA_CONTENT_LOG_DIR_MAX_AGE")).Default("720h").Hidden().DurationVar(&c.contentLogDirMaxAge)
 	app.Flag("content-log-dir-max-total-size-mb", "Maximum total size of log files to retain").Envar(cliApp.EnvName("KOPIA_CONTENT_LOG_DIR_MAX_SIZE_MB")).Hidden().Default("1000").Float64Var(&c.contentLogDirMaxTotalSizeMB)
-	app.Flag("log-level", "Console log level").Default("info").EnumVar(&c.logLevel, logLevels...)
+	app.Flag("log-level", "Console log level").Envar(cliApp.EnvName("KOPIA_LOG_LEVEL")).Default("info").EnumVar(&c.logLevel, logLevels...)
 	app.Flag("json-log-console", "JSON log file").Hidden().BoolVar(&c.jsonLogConsole)
 	app.Flag("json-log-file", "JSON log file").Hidden().BoolVar(&c.jsonLogFile)
-	app.Flag("file-log-level", "File log level").Default("debug").EnumVar(&c.fileLogLevel, logLevels...)
+	app.Flag("file-log-level", "File log level").Envar(cliApp.EnvName("KOPIA_FILE_LOG_LEVEL")).Default("debug").EnumVar(&c.fileLogLevel, logLevels...)
 	app.Flag("file-log-local-tz", "When logging to a file, use local timezone").Hidden().Envar(cliApp.EnvName("KOPIA_FILE_LOG_LOCAL_TZ")).BoolVar(&c.fileLogLocalTimezone)
 	app.Flag("force-color", "Force color output").Hidden().Envar(cliApp.EnvName("KOPIA_FORCE_COLOR")).BoolVar(&c.forceColor)
 	app.Flag("disable-color", "Disable color output").Hidden().Envar(cliApp.EnvName("KOPIA_DISABLE_COLOR")).BoolVar(&c.disableColor)
@@ -228,6 +230,7 @@ func (c *loggingFlags) setupLogFileBasedLogger(now time.Time, subdir, suffix, lo
 		logFileBaseName: logFileBaseName,
 		symlinkName:     symlinkName,
 		maxSegmentSize:  c.logFileMaxSegmentSize,
+		maxSegmentAge:   c.logFileMaxSegmentAge,
 		startSweep: func() {
 			sweepLogWG.Add(1)
 
@@ -389,6 +392,12 @@ type onDemandFile struct {
 	// +checklocks:mu
 	maxSegmentSize int
 
+	// +checklocks:mu
+	maxSegmentAge time.Duration // zero means segments are never rotated based on age
+
+	// +checklocks:mu
+	currentSegmentStartTime time.Time
+
 	// +checklocks:mu
 	currentSegmentFilename string
 
@@ -444,6 +453,11 @@ func (w *onDemandFile) Write(b []byte) (int, error) {
 		w.closeSegmentAndSweepLocked()
 	}
 
+	// close current file if it's been open for too long.
+	if w.f != nil && w.maxSegmentAge > 0 && clock.Now().Sub(w.currentSegmentStartTime) > w.maxSegmentAge {
+		w.closeSegmentAndSweepLocked()
+	}
+
 	// open file if we don't have it yet
 	if w.f == nil {
 		var baseName, ext string
@@ -460,6 +474,7 @@ func (w *onDemandFile) Write(b []byte) (int, error) {
 		w.currentSegmentFilename = fmt.Sprintf("%s.%d%s", baseName, w.segmentCounter, ext)
 		w.segmentCounter++
 		w.currentSegmentSize = 0
+		w.currentSegmentStartTime = clock.Now()
 
 		lf := filepath.Join(w.logDir, w.currentSegmentFilename)
 
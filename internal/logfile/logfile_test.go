@@ -145,6 +145,34 @@ func TestLogFileRotation(t *testing.T) {
 	}
 }
 
+func TestLogFileRotationByAge(t *testing.T) {
+	runner := testenv.NewInProcRunner(t)
+	runner.CustomizeApp = logfile.Attach
+
+	env := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, runner)
+	env.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", env.RepoDir)
+
+	tmpLogDir := testutil.TempDirectory(t)
+
+	// with very short segment age, practically every log entry ends up in its own segment.
+	env.RunAndExpectSuccess(t, "snap", "ls",
+		"--log-level=error", "--file-log-level=debug",
+		"--max-log-file-segment-age=1ns", "--log-dir", tmpLogDir, "--log-dir-max-files=0", "--log-dir-max-age=0")
+
+	entries, err := os.ReadDir(filepath.Join(tmpLogDir, "cli-logs"))
+	require.NoError(t, err)
+
+	var gotEntryCount int
+
+	for _, ent := range entries {
+		if ent.Type().IsRegular() {
+			gotEntryCount++
+		}
+	}
+
+	require.Greater(t, gotEntryCount, 1)
+}
+
 func TestLogFileMaxTotalSize(t *testing.T) {
 	t.Parallel()
 
@@ -5,41 +5,113 @@
 //
 //nolint:gochecknoglobals,mnd
 var Counters = NewMapping(map[string]int{
-	"blob_download_full_blob_bytes":                1,
-	"blob_download_partial_blob_bytes":             2,
-	"blob_errors[method:Close]":                    3,
-	"blob_errors[method:DeleteBlob]":               4,
-	"blob_errors[method:FlushCaches]":              5,
-	"blob_errors[method:GetBlob]":                  6,
-	"blob_errors[method:GetCapacity]":              7,
-	"blob_errors[method:GetMetadata]":              8,
-	"blob_errors[method:ListBlobs]":                9,
-	"blob_errors[method:PutBlob]":                  10,
-	"blob_list_items":                              11,
-	"blob_upload_bytes":                            12,
-	"content_after_compression_bytes":              13,
-	"content_compressible_bytes":                   14,
-	"content_compression_attempted_bytes":          15,
-	"content_compression_attempted_duration_nanos": 16,
-	"content_compression_savings_bytes":            17,
-	"content_decompressed_bytes":                   18,
-	"content_decompressed_duration_nanos":          19,
-	"content_decrypted_bytes":                      20,
-	"content_decrypted_duration_nanos":             21,
-	"content_deduplicated":                         22,
-	"content_deduplicated_bytes":                   23,
-	"content_encrypted_bytes":                      24,
-	"content_encrypted_duration_nanos":             25,
-	"content_get_error_count":                      26,
-	"content_get_not_found_count":                  27,
-	"content_hashed_bytes":                         28,
-	"content_hashed_duration_nanos":                29,
-	"content_non_compressible_bytes":               30,
-	"content_read_bytes":                           31,
-	"content_read_duration_nanos":                  32,
-	"content_uploaded_bytes":                       33,
-	"content_write_bytes":                          34,
-	"content_write_duration_nanos":                 35,
+	"blob_download_full_blob_bytes":                                         1,
+	"blob_download_partial_blob_bytes":                                      2,
+	"blob_errors[method:Close]":                                             3,
+	"blob_errors[method:DeleteBlob]":                                        4,
+	"blob_errors[method:FlushCaches]":                                       5,
+	"blob_errors[method:GetBlob]":                                           6,
+	"blob_errors[method:GetCapacity]":                                       7,
+	"blob_errors[method:GetMetadata]":                                       8,
+	"blob_errors[method:ListBlobs]":                                         9,
+	"blob_errors[method:PutBlob]":                                           10,
+	"blob_list_items":                                                       11,
+	"blob_upload_bytes":                                                     12,
+	"content_after_compression_bytes":                                       13,
+	"content_compressible_bytes":                                            14,
+	"content_compression_attempted_bytes":                                   15,
+	"content_compression_attempted_duration_nanos":                          16,
+	"content_compression_savings_bytes":                                     17,
+	"content_decompressed_bytes":                                            18,
+	"content_decompressed_duration_nanos":                                   19,
+	"content_decrypted_bytes":                                               20,
+	"content_decrypted_duration_nanos":                                      21,
+	"content_deduplicated":                                                  22,
+	"content_deduplicated_bytes":                                            23,
+	"content_encrypted_bytes":                                               24,
+	"content_encrypted_duration_nanos":                                      25,
+	"content_get_error_count":                                               26,
+	"content_get_not_found_count":                                           27,
+	"content_hashed_bytes":                                                  28,
+	"content_hashed_duration_nanos":                                         29,
+	"content_non_compressible_bytes":                                        30,
+	"content_read_bytes":                                                    31,
+	"content_read_duration_nanos":                                           32,
+	"content_uploaded_bytes":                                                33,
+	"content_write_bytes":                                                   34,
+	"content_write_duration_nanos":                                          35,
+	"blob_errors_by_class[class:access_denied;method:Close]":                36,
+	"blob_errors_by_class[class:access_denied;method:DeleteBlob]":           37,
+	"blob_errors_by_class[class:access_denied;method:ExtendBlobRetention]":  38,
+	"blob_errors_by_class[class:access_denied;method:FlushCaches]":          39,
+	"blob_errors_by_class[class:access_denied;method:GetBlob]":              40,
+	"blob_errors_by_class[class:access_denied;method:GetCapacity]":          41,
+	"blob_errors_by_class[class:access_denied;method:GetMetadata]":          42,
+	"blob_errors_by_class[class:access_denied;method:ListBlobs]":            43,
+	"blob_errors_by_class[class:access_denied;method:PutBlob]":              44,
+	"blob_errors_by_class[class:already_exists;method:Close]":               45,
+	"blob_errors_by_class[class:already_exists;method:DeleteBlob]":          46,
+	"blob_errors_by_class[class:already_exists;method:ExtendBlobRetention]": 47,
+	"blob_errors_by_class[class:already_exists;method:FlushCaches]":         48,
+	"blob_errors_by_class[class:already_exists;method:GetBlob]":             49,
+	"blob_errors_by_class[class:already_exists;method:GetCapacity]":         50,
+	"blob_errors_by_class[class:already_exists;method:GetMetadata]":         51,
+	"blob_errors_by_class[class:already_exists;method:ListBlobs]":           52,
+	"blob_errors_by_class[class:already_exists;method:PutBlob]":             53,
+	"blob_errors_by_class[class:canceled;method:Close]":                     54,
+	"blob_errors_by_class[class:canceled;method:DeleteBlob]":                55,
+	"blob_errors_by_class[class:canceled;method:ExtendBlobRetention]":       56,
+	"blob_errors_by_class[class:canceled;method:FlushCaches]":               57,
+	"blob_errors_by_class[class:canceled;method:GetBlob]":                   58,
+	"blob_errors_by_class[class:canceled;method:GetCapacity]":               59,
+	"blob_errors_by_class[class:canceled;method:GetMetadata]":               60,
+	"blob_errors_by_class[class:canceled;method:ListBlobs]":                 61,
+	"blob_errors_by_class[class:canceled;method:PutBlob]":                   62,
+	"blob_errors_by_class[class:invalid_range;method:Close]":                63,
+	"blob_errors_by_class[class:invalid_range;method:DeleteBlob]":           64,
+	"blob_errors_by_class[class:invalid_range;method:ExtendBlobRetention]":  65,
+	"blob_errors_by_class[class:invalid_range;method:FlushCaches]":          66,
+	"blob_errors_by_class[class:invalid_range;method:GetBlob]":              67,
+	"blob_errors_by_class[class:invalid_range;method:GetCapacity]":          68,
+	"blob_errors_by_class[class:invalid_range;method:GetMetadata]":          69,
+	"blob_errors_by_class[class:invalid_range;method:ListBlobs]":            70,
+	"blob_errors_by_class[class:invalid_range;method:PutBlob]":              71,
+	"blob_errors_by_class[class:not_found;method:Close]":                    72,
+	"blob_errors_by_class[class:not_found;method:DeleteBlob]":               73,
+	"blob_errors_by_class[class:not_found;method:ExtendBlobRetention]":      74,
+	"blob_errors_by_class[class:not_found;method:FlushCaches]":              75,
+	"blob_errors_by_class[class:not_found;method:GetBlob]":                  76,
+	"blob_errors_by_class[class:not_found;method:GetCapacity]":              77,
+	"blob_errors_by_class[class:not_found;method:GetMetadata]":              78,
+	"blob_errors_by_class[class:not_found;method:ListBlobs]":                79,
+	"blob_errors_by_class[class:not_found;method:PutBlob]":                  80,
+	"blob_errors_by_class[class:other;method:Close]":                        81,
+	"blob_errors_by_class[class:other;method:DeleteBlob]":                   82,
+	"blob_errors_by_class[class:other;method:ExtendBlobRetention]":          83,
+	"blob_errors_by_class[class:other;method:FlushCaches]":                  84,
+	"blob_errors_by_class[class:other;method:GetBlob]":                      85,
+	"blob_errors_by_class[class:other;method:GetCapacity]":                  86,
+	"blob_errors_by_class[class:other;method:GetMetadata]":                  87,
+	"blob_errors_by_class[class:other;method:ListBlobs]":                    88,
+	"blob_errors_by_class[class:other;method:PutBlob]":                      89,
+	"blob_errors_by_class[class:unsupported;method:Close]":                  90,
+	"blob_errors_by_class[class:unsupported;method:DeleteBlob]":             91,
+	"blob_errors_by_class[class:unsupported;method:ExtendBlobRetention]":    92,
+	"blob_errors_by_class[class:unsupported;method:FlushCaches]":            93,
+	"blob_errors_by_class[class:unsupported;method:GetBlob]":                94,
+	"blob_errors_by_class[class:unsupported;method:GetCapacity]":            95,
+	"blob_errors_by_class[class:unsupported;method:GetMetadata]":            96,
+	"blob_errors_by_class[class:unsupported;method:ListBlobs]":              97,
+	"blob_errors_by_class[class:unsupported;method:PutBlob]":                98,
+	"blob_operations[method:Close]":                                         99,
+	"blob_operations[method:DeleteBlob]":                                    100,
+	"blob_operations[method:ExtendBlobRetention]":                           101,
+	"blob_operations[method:FlushCaches]":                                   102,
+	"blob_operations[method:GetBlob]":                                       103,
+	"blob_operations[method:GetCapacity]":                                   104,
+	"blob_operations[method:GetMetadata]":                                   105,
+	"blob_operations[method:ListBlobs]":                                     106,
+	"blob_operations[method:PutBlob]":                                       107,
 	// add new items here, use consecutive values
 })
 
@@ -56,6 +128,9 @@
 	"blob_storage_latency[method:GetMetadata]":     7,
 	"blob_storage_latency[method:ListBlobs]":       8,
 	"blob_storage_latency[method:PutBlob]":         9,
+	"snapshot_duration[result:error]":              10,
+	"snapshot_duration[result:incomplete]":         11,
+	"snapshot_duration[result:success]":            12,
 	// add new items here, use consecutive values
 })
 
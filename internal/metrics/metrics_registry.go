@@ -3,6 +3,7 @@
 
 import (
 	"context"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -158,9 +159,21 @@ func labelsSuffix(l map[string]string) string {
 	}
 
 	var params []string
-	for k, v := range l {
-		params = append(params, k+":"+v)
+	for _, k := range sortedLabelNames(l) {
+		params = append(params, k+":"+l[k])
 	}
 
 	return "[" + strings.Join(params, ";") + "]"
 }
+
+// sortedLabelNames returns the names of the provided labels in a stable order.
+func sortedLabelNames(l map[string]string) []string {
+	names := make([]string, 0, len(l))
+	for k := range l {
+		names = append(names, k)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
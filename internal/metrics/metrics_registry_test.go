@@ -45,3 +45,15 @@ func TestMetricEmitter_NotNil(t *testing.T) {
 		"SIZE-DISTRIBUTION\t{\"name\":\"s1\",\"counters\":[0,0,1,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0],\"cnt\":1,\"sum\":333,\"min\":333,\"avg\":333,\"max\":333}",
 	}, lines)
 }
+
+func TestMetricLabelsOrder(t *testing.T) {
+	r := metrics.NewRegistry()
+
+	for range 10 {
+		r.CounterInt64("multi_label_counter", "h1", map[string]string{"b": "2", "a": "1", "c": "3"}).Add(1)
+	}
+
+	require.Equal(t, map[string]int64{
+		"multi_label_counter[a:1;b:2;c:3]": 10,
+	}, r.Snapshot(false).Counters)
+}
@@ -108,6 +108,32 @@ type Thresholds[T constraints.Float | constraints.Integer] struct {
 	"_ns",
 }
 
+// LongOperationThresholds is a set of thresholds that can represent durations of long-running
+// operations, such as snapshots, from 1s to 24h.
+//
+//nolint:gochecknoglobals
+var LongOperationThresholds = &Thresholds[time.Duration]{
+	[]time.Duration{
+		1 * time.Second,
+		5 * time.Second,
+		15 * time.Second,
+		30 * time.Second,
+
+		1 * time.Minute,
+		5 * time.Minute,
+		15 * time.Minute,
+		30 * time.Minute,
+
+		1 * time.Hour,
+		2 * time.Hour,
+		4 * time.Hour,
+		8 * time.Hour,
+		24 * time.Hour,
+	},
+	1e9, // export to Prometheus as seconds
+	"_seconds",
+}
+
 func bucketForThresholds[T constraints.Integer | constraints.Float](thresholds []T, d T) int {
 	l, r := 0, len(thresholds)-1
 
@@ -4,8 +4,11 @@
 	"context"
 	"encoding/base64"
 	"os"
+	"strings"
 
 	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/atomicfile"
 )
 
 // File is a Strategy that persists the base64-encoded password in a file next to repository config file.
@@ -41,8 +44,12 @@ func (filePasswordStorage) PersistPassword(ctx context.Context, configFile, pass
 	fn := passwordFileName(configFile)
 	log(ctx).Debugf("Saving password to file %v.", fn)
 
-	//nolint:wrapcheck
-	return os.WriteFile(fn, []byte(base64.StdEncoding.EncodeToString([]byte(password))), passwordFileMode)
+	// write atomically so that concurrent invocations never observe a partially-written password.
+	if err := atomicfile.Write(fn, strings.NewReader(base64.StdEncoding.EncodeToString([]byte(password)))); err != nil {
+		return errors.Wrap(err, "error writing password file")
+	}
+
+	return errors.Wrap(os.Chmod(fn, passwordFileMode), "error setting password file permissions")
 }
 
 func (filePasswordStorage) DeletePassword(ctx context.Context, configFile string) error {
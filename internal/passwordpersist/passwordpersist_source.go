@@ -0,0 +1,114 @@
+package passwordpersist
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Prefixes and names of supported password sources.
+const (
+	SourceKeyring    = "keyring"
+	SourceFilePrefix = "file:"
+	SourceEnvPrefix  = "env:"
+)
+
+// Source returns a read-only Strategy that fetches the password from the provided source, which can be one of:
+//
+//	keyring     - OS-specific keyring (macOS Keychain, Windows Credential Manager, libsecret)
+//	file:PATH   - contents of the given key file
+//	env:NAME    - value of the given environment variable
+func Source(spec string) (Strategy, error) {
+	switch {
+	case spec == SourceKeyring:
+		return Keyring(), nil
+
+	case strings.HasPrefix(spec, SourceFilePrefix) && len(spec) > len(SourceFilePrefix):
+		return keyFileSource{strings.TrimPrefix(spec, SourceFilePrefix)}, nil
+
+	case strings.HasPrefix(spec, SourceEnvPrefix) && len(spec) > len(SourceEnvPrefix):
+		return envSource{strings.TrimPrefix(spec, SourceEnvPrefix)}, nil
+
+	default:
+		return nil, errors.Errorf("invalid password source %q, must be %q, %q or %q", spec, SourceKeyring, SourceFilePrefix+"PATH", SourceEnvPrefix+"NAME")
+	}
+}
+
+// ResolveSource validates the provided password source and returns it in the form suitable for persisting
+// in the connection configuration, with the path of a key file made absolute, so that the connection
+// keeps working regardless of the working directory.
+func ResolveSource(spec string) (string, error) {
+	if _, err := Source(spec); err != nil {
+		return "", err
+	}
+
+	p, ok := strings.CutPrefix(spec, SourceFilePrefix)
+	if !ok {
+		return spec, nil
+	}
+
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to resolve key file path")
+	}
+
+	return SourceFilePrefix + abs, nil
+}
+
+// keyFileSource reads the password from a user-managed key file.
+type keyFileSource struct {
+	path string
+}
+
+func (s keyFileSource) GetPassword(ctx context.Context, _ string) (string, error) {
+	b, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return "", ErrPasswordNotFound
+	}
+
+	if err != nil {
+		return "", errors.Wrap(err, "error reading key file")
+	}
+
+	log(ctx).Debugf("password retrieved from key file %v", s.path)
+
+	return strings.TrimSpace(string(b)), nil
+}
+
+func (keyFileSource) PersistPassword(_ context.Context, _, _ string) error {
+	// the password is already available from the key file.
+	return nil
+}
+
+func (keyFileSource) DeletePassword(_ context.Context, _ string) error {
+	// the key file is managed by the user, leave it alone.
+	return nil
+}
+
+// envSource reads the password from an environment variable.
+type envSource struct {
+	name string
+}
+
+func (s envSource) GetPassword(ctx context.Context, _ string) (string, error) {
+	v, ok := os.LookupEnv(s.name)
+	if !ok || v == "" {
+		return "", ErrPasswordNotFound
+	}
+
+	log(ctx).Debugf("password retrieved from environment variable %v", s.name)
+
+	return strings.TrimSpace(v), nil
+}
+
+func (envSource) PersistPassword(_ context.Context, _, _ string) error {
+	// the password is already available from the environment.
+	return nil
+}
+
+func (envSource) DeletePassword(_ context.Context, _ string) error {
+	return nil
+}
@@ -0,0 +1,37 @@
+package passwordpersist_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/passwordpersist"
+)
+
+func TestResolveSource(t *testing.T) {
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+
+	absKeyFile := filepath.Join(t.TempDir(), "kopia.key")
+
+	cases := map[string]string{
+		"keyring":            "keyring",
+		"env:KOPIA_KEY":      "env:KOPIA_KEY",
+		"file:" + absKeyFile: "file:" + absKeyFile,
+		"file:kopia.key":     "file:" + filepath.Join(wd, "kopia.key"),
+		"file:../kopia.key":  "file:" + filepath.Join(filepath.Dir(wd), "kopia.key"),
+	}
+
+	for spec, want := range cases {
+		got, err := passwordpersist.ResolveSource(spec)
+		require.NoError(t, err, spec)
+		require.Equal(t, want, got, spec)
+	}
+
+	for _, spec := range []string{"", "file:", "env:", "no-such-source"} {
+		_, err := passwordpersist.ResolveSource(spec)
+		require.Error(t, err, spec)
+	}
+}
@@ -3,10 +3,12 @@
 
 import (
 	"context"
+	"math/rand"
 	"time"
 
 	"github.com/pkg/errors"
 
+	"github.com/kopia/kopia/internal/clock"
 	"github.com/kopia/kopia/repo/logging"
 )
 
@@ -24,23 +26,78 @@
 // IsRetriableFunc is a function that determines whether an error is retriable.
 type IsRetriableFunc func(err error) bool
 
+// Policy determines how many times and how often an operation is retried.
+// Zero fields other than Jitter are taken from DefaultPolicy().
+type Policy struct {
+	// MaxAttempts is the maximum number of attempts, a negative value retries forever.
+	MaxAttempts int
+
+	// InitialDelay is the delay before the first retry, subsequent delays grow exponentially up to MaxDelay.
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+
+	// Jitter is the fraction of each delay (between 0 and 1) that is randomized to avoid synchronized retries.
+	Jitter float64
+}
+
+// DefaultPolicy returns the policy used by WithExponentialBackoff.
+func DefaultPolicy() Policy {
+	return Policy{
+		MaxAttempts:  maxAttempts,
+		InitialDelay: retryInitialSleepAmount,
+		MaxDelay:     retryMaxSleepAmount,
+	}
+}
+
+// withDefaults returns the policy with zero fields replaced by their default values.
+func (p Policy) withDefaults() Policy {
+	def := DefaultPolicy()
+
+	if p.MaxAttempts == 0 {
+		p.MaxAttempts = def.MaxAttempts
+	}
+
+	if p.InitialDelay == 0 {
+		p.InitialDelay = def.InitialDelay
+	}
+
+	if p.MaxDelay == 0 {
+		p.MaxDelay = def.MaxDelay
+	}
+
+	return p
+}
+
+// WithPolicy runs the provided attempt until it succeeds, retrying on all errors that are
+// deemed retriable by the provided function according to the provided policy.
+func WithPolicy[T any](ctx context.Context, p Policy, desc string, attempt func() (T, error), isRetriableError IsRetriableFunc) (T, error) {
+	return internalRetry(ctx, desc, attempt, isRetriableError, p.withDefaults(), retryExponent)
+}
+
 // WithExponentialBackoff runs the provided attempt until it succeeds, retrying on all errors that are
 // deemed retriable by the provided function. The delay between retries grows exponentially up to
 // a certain limit.
 func WithExponentialBackoff[T any](ctx context.Context, desc string, attempt func() (T, error), isRetriableError IsRetriableFunc) (T, error) {
-	return internalRetry(ctx, desc, attempt, isRetriableError, retryInitialSleepAmount, retryMaxSleepAmount, maxAttempts, retryExponent)
+	return internalRetry(ctx, desc, attempt, isRetriableError, DefaultPolicy(), retryExponent)
 }
 
 // WithExponentialBackoffMaxRetries is the same as WithExponentialBackoff,
 // additionally it allows customizing the max number of retries before giving
 // up (count parameter). A negative value for count would run this forever.
 func WithExponentialBackoffMaxRetries[T any](ctx context.Context, count int, desc string, attempt func() (T, error), isRetriableError IsRetriableFunc) (T, error) {
-	return internalRetry(ctx, desc, attempt, isRetriableError, retryInitialSleepAmount, retryMaxSleepAmount, count, retryExponent)
+	p := DefaultPolicy()
+	p.MaxAttempts = count
+
+	return internalRetry(ctx, desc, attempt, isRetriableError, p, retryExponent)
 }
 
 // Periodically runs the provided attempt until it succeeds, waiting given fixed amount between attempts.
 func Periodically[T any](ctx context.Context, interval time.Duration, count int, desc string, attempt func() (T, error), isRetriableError IsRetriableFunc) (T, error) {
-	return internalRetry(ctx, desc, attempt, isRetriableError, interval, interval, count, 1)
+	return internalRetry(ctx, desc, attempt, isRetriableError, Policy{
+		MaxAttempts:  count,
+		InitialDelay: interval,
+		MaxDelay:     interval,
+	}, 1)
 }
 
 // PeriodicallyNoValue runs the provided attempt until it succeeds, waiting given fixed amount between attempts.
@@ -55,8 +112,9 @@ func PeriodicallyNoValue(ctx context.Context, interval time.Duration, count int,
 // internalRetry runs the provided attempt until it succeeds, retrying on all errors that are
 // deemed retriable by the provided function. The delay between retries grows exponentially up to
 // a certain limit.
-func internalRetry[T any](ctx context.Context, desc string, attempt func() (T, error), isRetriableError IsRetriableFunc, initial, maxSleep time.Duration, count int, factor float64) (T, error) {
-	sleepAmount := initial
+func internalRetry[T any](ctx context.Context, desc string, attempt func() (T, error), isRetriableError IsRetriableFunc, p Policy, factor float64) (T, error) {
+	sleepAmount := p.InitialDelay
+	count := p.MaxAttempts
 
 	var (
 		lastError error
@@ -82,18 +140,34 @@ func internalRetry[T any](ctx context.Context, desc string, attempt func() (T, e
 			return v, err
 		}
 
-		log(ctx).Debugf("got error %v when %v (#%v), sleeping for %v before retrying", err, desc, i, sleepAmount)
-		time.Sleep(sleepAmount)
+		d := withJitter(sleepAmount, p.Jitter)
+
+		log(ctx).Debugf("got error %v when %v (#%v), sleeping for %v before retrying", err, desc, i, d)
+
+		if !clock.SleepInterruptibly(ctx, d) {
+			//nolint:wrapcheck
+			return defaultT, ctx.Err()
+		}
+
 		sleepAmount = time.Duration(float64(sleepAmount) * factor)
 
-		if sleepAmount > maxSleep {
-			sleepAmount = maxSleep
+		if sleepAmount > p.MaxDelay {
+			sleepAmount = p.MaxDelay
 		}
 	}
 
 	return defaultT, errors.Wrapf(lastError, "unable to complete %v despite %v retries", desc, i)
 }
 
+// withJitter returns the provided delay reduced by a random amount up to the given fraction of it.
+func withJitter(d time.Duration, jitter float64) time.Duration {
+	if jitter <= 0 {
+		return d
+	}
+
+	return d - time.Duration(float64(d)*min(jitter, 1)*rand.Float64()) //nolint:gosec
+}
+
 // WithExponentialBackoffNoValue is a shorthand for WithExponentialBackoff except the
 // attempt function does not return any value.
 func WithExponentialBackoffNoValue(ctx context.Context, desc string, attempt func() error, isRetriableError IsRetriableFunc) error {
@@ -11,7 +11,10 @@
 	"github.com/kopia/kopia/internal/testlogging"
 )
 
-var errRetriable = errors.New("retriable")
+var (
+	errRetriable    = errors.New("retriable")
+	errNonRetriable = errors.New("non-retriable")
+)
 
 func isRetriable(e error) bool {
 	return errors.Is(e, errRetriable)
@@ -72,3 +75,65 @@ func TestRetryContextCancel(t *testing.T) {
 		return errRetriable
 	}, isRetriable))
 }
+
+func TestRetryContextCancelWhileSleeping(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(testlogging.Context(t))
+	defer cancel()
+
+	t0 := time.Now()
+
+	_, err := Periodically(ctx, time.Hour, 3, "canceled while sleeping", func() (int, error) {
+		cancel()
+		return 0, errRetriable
+	}, isRetriable)
+
+	require.ErrorIs(t, err, context.Canceled)
+	require.Less(t, time.Since(t0), time.Minute)
+}
+
+func TestWithPolicyDefaults(t *testing.T) {
+	t.Parallel()
+
+	ctx := testlogging.Context(t)
+
+	cnt := 0
+
+	// zero policy still attempts the operation and retries it.
+	got, err := WithPolicy(ctx, Policy{InitialDelay: time.Millisecond}, "zero-policy", func() (int, error) {
+		cnt++
+		if cnt < 3 {
+			return 0, errRetriable
+		}
+
+		return 5, nil
+	}, isRetriable)
+
+	require.NoError(t, err)
+	require.Equal(t, 5, got)
+	require.Equal(t, 3, cnt)
+
+	_, err = WithPolicy(ctx, Policy{}, "zero-policy-failure", func() (int, error) {
+		return 0, errNonRetriable
+	}, isRetriable)
+	require.ErrorIs(t, err, errNonRetriable)
+
+	def := DefaultPolicy()
+	require.Equal(t, def, Policy{}.withDefaults())
+	require.Equal(t, 2, Policy{MaxAttempts: 2}.withDefaults().MaxAttempts)
+}
+
+func TestWithJitter(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, time.Second, withJitter(time.Second, 0))
+
+	for range 100 {
+		d := withJitter(time.Second, 0.25)
+		require.LessOrEqual(t, d, time.Second)
+		require.GreaterOrEqual(t, d, 750*time.Millisecond)
+
+		require.GreaterOrEqual(t, withJitter(time.Second, 5), time.Duration(0))
+	}
+}
@@ -7,6 +7,7 @@
 	"fmt"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/kopia/kopia/internal/clock"
@@ -29,13 +30,17 @@ type Item struct {
 // Scheduler manages triggering of arbitrary events by periodically determining the first
 // of a set of upcoming events and waiting until it's due and invoking the trigger function.
 type Scheduler struct {
-	TimeNow func() time.Time
-	Debug   bool
+	TimeNow      func() time.Time
+	Debug        bool
+	MaxSleepTime time.Duration
 
 	refreshRequested chan string
 	getItems         GetItemsFunc
 	closed           chan struct{}
 	wg               sync.WaitGroup
+
+	// time (in unix nanoseconds) when upcoming items were last evaluated.
+	lastHeartbeat atomic.Int64
 }
 
 // Options the scheduler.
@@ -43,6 +48,11 @@ type Options struct {
 	TimeNow        func() time.Time
 	Debug          bool
 	RefreshChannel chan string
+
+	// MaxSleepTime is the maximum amount of time to wait before re-evaluating upcoming items.
+	// Timers do not advance while the computer is asleep, so waking up periodically ensures that
+	// items which became due during sleep are triggered shortly after resuming.
+	MaxSleepTime time.Duration
 }
 
 // Start runs a new scheduler that will call getItems() to get the list of items to schedule.
@@ -53,14 +63,22 @@ func Start(ctx context.Context, getItems GetItemsFunc, opts Options) *Scheduler
 		timeNow = clock.Now
 	}
 
+	maxSleepTime := opts.MaxSleepTime
+	if maxSleepTime <= 0 {
+		maxSleepTime = defaultMaxSleepTime
+	}
+
 	s := &Scheduler{
 		TimeNow:          timeNow,
 		refreshRequested: opts.RefreshChannel,
 		closed:           make(chan struct{}),
 		getItems:         getItems,
 		Debug:            opts.Debug,
+		MaxSleepTime:     maxSleepTime,
 	}
 
+	s.lastHeartbeat.Store(timeNow().UnixNano())
+
 	s.wg.Add(1)
 
 	go func() {
@@ -72,7 +90,10 @@ func Start(ctx context.Context, getItems GetItemsFunc, opts Options) *Scheduler
 	return s
 }
 
-const sleepTimeWhenNoUpcomingSnapshots = 8 * time.Hour
+const (
+	sleepTimeWhenNoUpcomingSnapshots = 8 * time.Hour
+	defaultMaxSleepTime              = 1 * time.Minute
+)
 
 func (s *Scheduler) upcomingItems(ctx context.Context, now time.Time) (nextTriggerTime time.Time, toTrigger []Item) {
 	allsm := s.getItems(ctx, now)
@@ -108,6 +129,12 @@ func sleepTimeOrDefault(now, t time.Time, def time.Duration) time.Duration {
 	return t.Sub(now)
 }
 
+// LastHeartbeat returns the time when the scheduler last evaluated upcoming items. This happens at least
+// every MaxSleepTime, so a heartbeat much older than that indicates that the scheduler is stuck.
+func (s *Scheduler) LastHeartbeat() time.Time {
+	return time.Unix(0, s.lastHeartbeat.Load())
+}
+
 // Stop stops the scheduler.
 func (s *Scheduler) Stop() {
 	close(s.closed)
@@ -121,11 +148,19 @@ func (s *Scheduler) run(ctx context.Context) {
 		now := s.TimeNow()
 		nextTriggerTime, toTrigger := s.upcomingItems(ctx, now)
 
+		s.lastHeartbeat.Store(s.TimeNow().UnixNano())
+
 		sleepTimeUntilNextTrigger := sleepTimeOrDefault(now, nextTriggerTime, sleepTimeWhenNoUpcomingSnapshots)
 		if sleepTimeUntilNextTrigger < 0 {
 			sleepTimeUntilNextTrigger = 0
 		}
 
+		if sleepTimeUntilNextTrigger > s.MaxSleepTime {
+			// wake up early and re-evaluate based on the wall clock, nothing is due yet.
+			sleepTimeUntilNextTrigger = s.MaxSleepTime
+			toTrigger = nil
+		}
+
 		if s.Debug && sleepTimeUntilNextTrigger > 0 {
 			log(ctx).Debugf("sleeping for %v until %v (%v)",
 				sleepTimeUntilNextTrigger,
@@ -182,3 +182,49 @@ func TestTriggerNames(t *testing.T) {
 		require.Equal(t, tc.want, scheduler.TriggerNames(tc.items))
 	}
 }
+
+func TestSchedulerCatchesUpAfterSleep(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	ft := faketime.NewClockTimeWithOffset(baseTime.Sub(clock.Now()))
+	ch := make(chan string, 10)
+
+	// the item is due in one hour, which a timer would only notice after an hour
+	// of non-sleeping time.
+	it1 := scheduler.Item{Description: "it1", NextTime: baseTime.Add(time.Hour)}
+
+	var triggered atomic.Bool
+
+	it1.Trigger = func() {
+		if !triggered.Swap(true) {
+			ch <- "it1"
+		}
+	}
+
+	s := scheduler.Start(ctx, func(ctx context.Context, now time.Time) []scheduler.Item {
+		return []scheduler.Item{it1}
+	}, scheduler.Options{
+		TimeNow:      ft.NowFunc(),
+		MaxSleepTime: 50 * time.Millisecond,
+	})
+
+	defer s.Stop()
+
+	select {
+	case v := <-ch:
+		t.Fatalf("unexpected item: %v", v)
+
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	// simulate wall clock jump after the computer resumes from sleep.
+	ft.Advance(2 * time.Hour)
+
+	select {
+	case v := <-ch:
+		require.Equal(t, "it1", v)
+
+	case <-time.After(5 * time.Second):
+		t.Fatalf("missed item was not triggered after resuming")
+	}
+}
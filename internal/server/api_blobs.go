@@ -0,0 +1,231 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/gather"
+	"github.com/kopia/kopia/internal/serverapi"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/blob"
+)
+
+// SetupBlobProxyHandlers registers handlers exposing the underlying repository storage,
+// which allows clients using 'kopia-server' storage to access the repository without
+// having credentials to the actual storage.
+//
+// Access to blobs bypasses all repository ACLs, so the handlers are only available
+// to the server control user.
+func (s *Server) SetupBlobProxyHandlers(m *mux.Router) {
+	// listing and blob contents are streamed instead of being buffered as JSON responses.
+	m.HandleFunc("/api/v1/blobs", s.requireAuth(csrfTokenNotRequired, handleBlobList)).Methods(http.MethodGet)
+	m.HandleFunc("/api/v1/blobs/{blobID}", s.requireAuth(csrfTokenNotRequired, handleBlobGet)).Methods(http.MethodGet)
+	m.HandleFunc("/api/v1/blobs/{blobID}", s.requireAuth(csrfTokenNotRequired, handleBlobPut)).Methods(http.MethodPut)
+	m.HandleFunc("/api/v1/blobs/{blobID}", s.handleServerControlAPI(handleBlobDelete)).Methods(http.MethodDelete)
+	m.HandleFunc("/api/v1/blobs/{blobID}/metadata", s.handleServerControlAPI(handleBlobGetMetadata)).Methods(http.MethodGet)
+	m.HandleFunc("/api/v1/storage/capacity", s.handleServerControlAPI(handleStorageCapacity)).Methods(http.MethodGet)
+}
+
+func proxiedStorage(rc requestContext) (blob.Storage, *apiError) {
+	dr, ok := rc.rep.(repo.DirectRepositoryWriter)
+	if !ok {
+		return nil, requestError(serverapi.ErrorStorageConnection, "repository does not support direct storage access")
+	}
+
+	return dr.BlobStorage(), nil
+}
+
+func blobError(err error) *apiError {
+	switch {
+	case errors.Is(err, blob.ErrBlobNotFound):
+		return notFoundError("blob not found")
+
+	case errors.Is(err, blob.ErrInvalidRange):
+		return &apiError{http.StatusRequestedRangeNotSatisfiable, serverapi.ErrorMalformedRequest, "invalid range"}
+
+	default:
+		return internalServerError(err)
+	}
+}
+
+// streamingBlobStorage returns the proxied storage for streaming handlers, writing an error
+// response and returning nil if the request is not allowed.
+func streamingBlobStorage(ctx context.Context, rc requestContext) blob.Storage {
+	if !requireServerControlUser(ctx, rc) {
+		writeStreamingError(rc, accessDeniedError())
+		return nil
+	}
+
+	if rc.rep == nil {
+		writeStreamingError(rc, requestError(serverapi.ErrorNotConnected, "not connected"))
+		return nil
+	}
+
+	st, aerr := proxiedStorage(rc)
+	if aerr != nil {
+		writeStreamingError(rc, aerr)
+		return nil
+	}
+
+	return st
+}
+
+func writeStreamingError(rc requestContext, aerr *apiError) {
+	rc.w.Header().Set("Content-Type", "application/json")
+	rc.w.WriteHeader(aerr.httpErrorCode)
+
+	_ = json.NewEncoder(rc.w).Encode(&serverapi.ErrorResponse{
+		Code:  aerr.apiErrorCode,
+		Error: aerr.message,
+	})
+}
+
+// handleBlobList streams blob metadata as newline-delimited JSON as blobs are being listed,
+// terminated by an item indicating success or failure.
+func handleBlobList(ctx context.Context, rc requestContext) {
+	st := streamingBlobStorage(ctx, rc)
+	if st == nil {
+		return
+	}
+
+	rc.w.Header().Set("Content-Type", "application/x-ndjson")
+	rc.w.WriteHeader(http.StatusOK)
+
+	enc := json.NewEncoder(rc.w)
+
+	err := st.ListBlobs(ctx, blob.ID(rc.queryParam("prefix")), func(bm blob.Metadata) error {
+		return errors.Wrap(enc.Encode(&serverapi.BlobListItem{Blob: &bm}), "error writing response")
+	})
+
+	last := &serverapi.BlobListItem{Done: true}
+	if err != nil {
+		log(ctx).Errorf("error listing blobs: %v", err)
+
+		last = &serverapi.BlobListItem{Error: err.Error()}
+	}
+
+	if err := enc.Encode(last); err != nil {
+		log(ctx).Errorf("error writing response: %v", err)
+	}
+}
+
+func handleBlobGet(ctx context.Context, rc requestContext) {
+	st := streamingBlobStorage(ctx, rc)
+	if st == nil {
+		return
+	}
+
+	offset, length := int64(0), int64(-1)
+
+	if p := rc.queryParam("offset"); p != "" {
+		v, err := strconv.ParseInt(p, 10, 64)
+		if err != nil {
+			writeStreamingError(rc, requestError(serverapi.ErrorMalformedRequest, "invalid offset"))
+			return
+		}
+
+		offset = v
+	}
+
+	if p := rc.queryParam("length"); p != "" {
+		v, err := strconv.ParseInt(p, 10, 64)
+		if err != nil {
+			writeStreamingError(rc, requestError(serverapi.ErrorMalformedRequest, "invalid length"))
+			return
+		}
+
+		length = v
+	}
+
+	var data gather.WriteBuffer
+	defer data.Close()
+
+	if err := st.GetBlob(ctx, blob.ID(rc.muxVar("blobID")), offset, length, &data); err != nil {
+		writeStreamingError(rc, blobError(err))
+		return
+	}
+
+	rc.w.Header().Set("Content-Type", "application/octet-stream")
+	rc.w.Header().Set("Content-Length", strconv.Itoa(data.Length()))
+	rc.w.WriteHeader(http.StatusOK)
+
+	if _, err := data.Bytes().WriteTo(rc.w); err != nil {
+		log(ctx).Errorf("error writing response: %v", err)
+	}
+}
+
+func handleBlobGetMetadata(ctx context.Context, rc requestContext) (interface{}, *apiError) {
+	st, aerr := proxiedStorage(rc)
+	if aerr != nil {
+		return nil, aerr
+	}
+
+	bm, err := st.GetMetadata(ctx, blob.ID(rc.muxVar("blobID")))
+	if err != nil {
+		return nil, blobError(err)
+	}
+
+	return bm, nil
+}
+
+// handleBlobPut reads the binary request body into a chunked buffer without requiring it to be
+// contiguous in memory and writes it to the storage.
+func handleBlobPut(ctx context.Context, rc requestContext) {
+	st := streamingBlobStorage(ctx, rc)
+	if st == nil {
+		return
+	}
+
+	var data gather.WriteBuffer
+	defer data.Close()
+
+	if _, err := io.Copy(&data, rc.req.Body); err != nil {
+		writeStreamingError(rc, requestError(serverapi.ErrorMalformedRequest, "error reading request body"))
+		return
+	}
+
+	if err := st.PutBlob(ctx, blob.ID(rc.muxVar("blobID")), data.Bytes(), blob.PutOptions{}); err != nil {
+		writeStreamingError(rc, blobError(err))
+		return
+	}
+
+	rc.w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(rc.w).Encode(&serverapi.Empty{})
+}
+
+func handleBlobDelete(ctx context.Context, rc requestContext) (interface{}, *apiError) {
+	st, aerr := proxiedStorage(rc)
+	if aerr != nil {
+		return nil, aerr
+	}
+
+	if err := st.DeleteBlob(ctx, blob.ID(rc.muxVar("blobID"))); err != nil {
+		return nil, blobError(err)
+	}
+
+	return &serverapi.Empty{}, nil
+}
+
+func handleStorageCapacity(ctx context.Context, rc requestContext) (interface{}, *apiError) {
+	st, aerr := proxiedStorage(rc)
+	if aerr != nil {
+		return nil, aerr
+	}
+
+	c, err := st.GetCapacity(ctx)
+	if errors.Is(err, blob.ErrNotAVolume) {
+		return nil, notFoundError("storage is not a volume")
+	}
+
+	if err != nil {
+		return nil, internalServerError(err)
+	}
+
+	return c, nil
+}
@@ -0,0 +1,168 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/kopia/kopia/internal/clock"
+	"github.com/kopia/kopia/internal/serverapi"
+	"github.com/kopia/kopia/internal/uitask"
+)
+
+const (
+	eventStreamPollInterval      = 1 * time.Second
+	eventStreamKeepAliveInterval = 15 * time.Second
+)
+
+// eventStreamState keeps track of the last state delivered to a single event stream client,
+// so that only changes are sent.
+type eventStreamState struct {
+	tasks   map[string][]byte
+	sources map[string][]byte
+}
+
+// handleEventStream returns a handler which streams task, source and error events as server-sent events
+// (https://html.spec.whatwg.org/multipage/server-sent-events.html) until the client disconnects or the
+// server shuts down.
+//
+// Upon connecting the client receives the current state of all known tasks and sources, followed by
+// incremental events as they change.
+func handleEventStream(isAuthorized isAuthorizedFunc) func(ctx context.Context, rc requestContext) {
+	return func(ctx context.Context, rc requestContext) {
+		if !isAuthorized(ctx, rc) {
+			http.Error(rc.w, "access denied", http.StatusForbidden)
+			return
+		}
+
+		flusher, ok := rc.w.(http.Flusher)
+		if !ok {
+			http.Error(rc.w, "streaming not supported", http.StatusInternalServerError)
+			return
+		}
+
+		rc.w.Header().Set("Content-Type", "text/event-stream")
+		rc.w.Header().Set("Cache-Control", "no-cache")
+		rc.w.Header().Set("X-Accel-Buffering", "no")
+		rc.w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		st := &eventStreamState{
+			tasks:   map[string][]byte{},
+			sources: map[string][]byte{},
+		}
+
+		initial := true
+		lastWrite := clock.Now()
+
+		for {
+			n, err := st.sendChanges(rc, initial)
+			if err != nil {
+				log(ctx).Debugf("event stream closed: %v", err)
+				return
+			}
+
+			initial = false
+
+			switch {
+			case n > 0:
+				lastWrite = clock.Now()
+
+			case clock.Now().Sub(lastWrite) >= eventStreamKeepAliveInterval:
+				// comment lines are ignored by clients but keep proxies from closing idle connections.
+				if _, err := fmt.Fprint(rc.w, ": keep-alive\n\n"); err != nil {
+					return
+				}
+
+				lastWrite = clock.Now()
+			}
+
+			flusher.Flush()
+
+			select {
+			case <-ctx.Done():
+				return
+
+			case <-rc.srv.eventStreamsClosed():
+				return
+
+			case <-time.After(eventStreamPollInterval):
+			}
+		}
+	}
+}
+
+// sendChanges writes events for all tasks and sources whose state has changed since the last call
+// and returns the number of events written.
+func (st *eventStreamState) sendChanges(rc requestContext, initial bool) (int, error) {
+	var events []serverapi.Event
+
+	tasks := rc.srv.taskManager().ListTasks()
+
+	// deliver tasks in the order they were started.
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].StartTime.Before(tasks[j].StartTime)
+	})
+
+	for i := range tasks {
+		t := tasks[i]
+
+		if !st.changed(st.tasks, t.TaskID, t) {
+			continue
+		}
+
+		events = append(events, serverapi.Event{Type: serverapi.EventTypeTask, Task: &t})
+
+		// report failures that happened while the stream was open.
+		if !initial && t.Status == uitask.StatusFailed {
+			events = append(events, serverapi.Event{Type: serverapi.EventTypeError, Task: &t, Error: t.ErrorMessage})
+		}
+	}
+
+	for src, sm := range rc.srv.snapshotAllSourceManagers() {
+		ss := sm.Status()
+
+		if st.changed(st.sources, src.String(), ss) {
+			events = append(events, serverapi.Event{Type: serverapi.EventTypeSource, Source: ss})
+		}
+	}
+
+	for _, ev := range events {
+		if err := writeServerSentEvent(rc.w, ev); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(events), nil
+}
+
+// changed returns true if the JSON representation of the provided value differs from
+// the one previously recorded for the key and records the new one.
+func (st *eventStreamState) changed(last map[string][]byte, key string, v any) bool {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return false
+	}
+
+	if string(last[key]) == string(b) {
+		return false
+	}
+
+	last[key] = b
+
+	return true
+}
+
+func writeServerSentEvent(w http.ResponseWriter, ev serverapi.Event) error {
+	b, err := json.Marshal(ev)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	_, err = fmt.Fprintf(w, "event: %v\ndata: %s\n\n", ev.Type, b)
+
+	return err //nolint:wrapcheck
+}
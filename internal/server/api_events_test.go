@@ -0,0 +1,175 @@
+package server_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/apiclient"
+	"github.com/kopia/kopia/internal/mockfs"
+	"github.com/kopia/kopia/internal/repotesting"
+	"github.com/kopia/kopia/internal/serverapi"
+	"github.com/kopia/kopia/internal/servertesting"
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/internal/uitask"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/manifest"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/restore"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+)
+
+func TestEventStream(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+
+	si1 := env.LocalPathSourceInfo("/dummy/path")
+
+	var id11 manifest.ID
+
+	require.NoError(t, repo.WriteSession(ctx, env.Repository, repo.WriteSessionOptions{Purpose: "Test"}, func(ctx context.Context, w repo.RepositoryWriter) error {
+		dir1 := mockfs.NewDirectory()
+		dir1.AddFile("file1", []byte{1, 2, 3}, 0o644)
+
+		man11, err := snapshotfs.NewUploader(w).Upload(ctx, dir1, nil, si1)
+		require.NoError(t, err)
+		id11, err = snapshot.SaveSnapshot(ctx, w, man11)
+		require.NoError(t, err)
+
+		return nil
+	}))
+
+	srvInfo := servertesting.StartServer(t, env, false)
+
+	cli, err := apiclient.NewKopiaAPIClient(apiclient.Options{
+		BaseURL:  srvInfo.BaseURL,
+		Username: servertesting.TestUIUsername,
+		Password: servertesting.TestUIPassword,
+	})
+	require.NoError(t, err)
+	require.NoError(t, cli.FetchCSRFTokenForTesting(ctx))
+
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(streamCtx, http.MethodGet, srvInfo.BaseURL+"/api/v1/events", http.NoBody)
+	require.NoError(t, err)
+
+	resp, err := cli.HTTPClient.Do(req)
+	require.NoError(t, err)
+
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	events := make(chan serverapi.Event, 100)
+
+	go func() {
+		defer close(events)
+
+		var eventType string
+
+		s := bufio.NewScanner(resp.Body)
+		for s.Scan() {
+			line := s.Text()
+
+			if v, ok := strings.CutPrefix(line, "event: "); ok {
+				eventType = v
+			}
+
+			if v, ok := strings.CutPrefix(line, "data: "); ok {
+				var ev serverapi.Event
+				if json.Unmarshal([]byte(v), &ev) == nil && ev.Type == eventType {
+					events <- ev
+				}
+			}
+		}
+	}()
+
+	// successful restore.
+	restoreTask, err := serverapi.Restore(ctx, cli, &serverapi.RestoreRequest{
+		Root:    string(id11),
+		Options: restore.Options{RestoreDirEntryAtDepth: math.MaxInt32},
+		Filesystem: &restore.FilesystemOutput{
+			TargetPath:      testutil.TempDirectory(t),
+			SkipOwners:      true,
+			SkipPermissions: true,
+		},
+	})
+	require.NoError(t, err)
+
+	ev := waitForEvent(t, events, func(ev serverapi.Event) bool {
+		return ev.Type == serverapi.EventTypeTask && ev.Task.TaskID == restoreTask.TaskID && ev.Task.Status.IsFinished()
+	})
+	require.Equal(t, uitask.StatusSuccess, ev.Task.Status)
+	require.Equal(t, "Restore", ev.Task.Kind)
+
+	// restore into a path underneath a regular file, which fails.
+	blockingFile := filepath.Join(testutil.TempDirectory(t), "file")
+	require.NoError(t, os.WriteFile(blockingFile, nil, 0o600))
+
+	failingTask, err := serverapi.Restore(ctx, cli, &serverapi.RestoreRequest{
+		Root:    string(id11),
+		Options: restore.Options{RestoreDirEntryAtDepth: math.MaxInt32},
+		Filesystem: &restore.FilesystemOutput{
+			TargetPath:      filepath.Join(blockingFile, "subdir"),
+			SkipOwners:      true,
+			SkipPermissions: true,
+		},
+	})
+	require.NoError(t, err)
+
+	ev = waitForEvent(t, events, func(ev serverapi.Event) bool {
+		return ev.Type == serverapi.EventTypeError && ev.Task.TaskID == failingTask.TaskID
+	})
+	require.NotEmpty(t, ev.Error)
+}
+
+func TestEventStreamAccessDenied(t *testing.T) {
+	_, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+	srvInfo := servertesting.StartServer(t, env, false)
+
+	cli, err := apiclient.NewKopiaAPIClient(apiclient.Options{
+		BaseURL:  srvInfo.BaseURL,
+		Username: servertesting.TestUIUsername,
+		Password: servertesting.TestUIPassword,
+	})
+	require.NoError(t, err)
+
+	// UI user is not allowed to use the server control API.
+	resp, err := cli.HTTPClient.Get(srvInfo.BaseURL + "/api/v1/control/events") //nolint:noctx
+	require.NoError(t, err)
+
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
+
+func waitForEvent(t *testing.T, events <-chan serverapi.Event, match func(ev serverapi.Event) bool) serverapi.Event {
+	t.Helper()
+
+	timeout := time.After(30 * time.Second)
+
+	for {
+		select {
+		case ev, ok := <-events:
+			require.True(t, ok, "event stream closed")
+
+			if match(ev) {
+				return ev
+			}
+
+		case <-timeout:
+			t.Fatal("timed out waiting for event")
+		}
+	}
+}
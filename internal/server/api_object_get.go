@@ -3,6 +3,7 @@
 import (
 	"context"
 	"errors"
+	"mime"
 	"net/http"
 	"time"
 
@@ -31,6 +32,15 @@ func handleObjectGet(ctx context.Context, rc requestContext) {
 		return
 	}
 
+	if err != nil {
+		log(ctx).Errorf("error opening object %v: %v", oid, err)
+		http.Error(rc.w, "error opening object", http.StatusInternalServerError)
+
+		return
+	}
+
+	defer obj.Close() //nolint:errcheck
+
 	if snapshotfs.IsDirectoryID(oid) {
 		rc.w.Header().Set("Content-Type", "application/json")
 	}
@@ -38,7 +48,8 @@ func handleObjectGet(ctx context.Context, rc requestContext) {
 	fname := oid.String()
 	if p := rc.queryParam("fname"); p != "" {
 		fname = p
-		rc.w.Header().Set("Content-Disposition", "attachment; filename=\""+p+"\"")
+		// FormatMediaType takes care of quoting and encoding non-ASCII file names.
+		rc.w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": p}))
 	}
 
 	mtime := clock.Now()
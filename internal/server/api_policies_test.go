@@ -79,11 +79,10 @@ func TestPolicies(t *testing.T) {
 		wantSchedulingError             string
 	}{
 		{
-			si:                       si0,
-			wantCompressorName:       compression.Name("none"),
-			wantNeverCompress:        nil,
-			wantCompressorNameSource: policy.GlobalPolicySourceInfo,
-			wantNeverCompressSource:  policy.GlobalPolicySourceInfo,
+			si:                      si0,
+			wantCompressorName:      compression.Name(""), // repository default
+			wantNeverCompress:       nil,
+			wantNeverCompressSource: policy.GlobalPolicySourceInfo,
 		},
 		{
 			si:                       si1,
@@ -142,11 +141,10 @@ func TestPolicies(t *testing.T) {
 			wantUpcomingSnapshotTimesLength: 3,
 		},
 		{
-			si:                       si0,
-			wantCompressorName:       compression.Name("none"),
-			wantNeverCompress:        nil,
-			wantCompressorNameSource: policy.GlobalPolicySourceInfo,
-			wantNeverCompressSource:  policy.GlobalPolicySourceInfo,
+			si:                      si0,
+			wantCompressorName:      compression.Name(""), // repository default
+			wantNeverCompress:       nil,
+			wantNeverCompressSource: policy.GlobalPolicySourceInfo,
 			updates: &policy.Policy{
 				SchedulingPolicy: policy.SchedulingPolicy{
 					Cron: []string{"invalid"},
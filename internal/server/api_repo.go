@@ -8,11 +8,13 @@
 
 	"github.com/pkg/errors"
 
+	"github.com/kopia/kopia/internal/cryptobackend"
 	"github.com/kopia/kopia/internal/gather"
 	"github.com/kopia/kopia/internal/passwordpersist"
 	"github.com/kopia/kopia/internal/serverapi"
 	"github.com/kopia/kopia/repo"
 	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/blob/plugin"
 	"github.com/kopia/kopia/repo/blob/throttling"
 	"github.com/kopia/kopia/repo/compression"
 	"github.com/kopia/kopia/repo/ecc"
@@ -41,7 +43,7 @@ func handleRepoStatus(ctx context.Context, rc requestContext) (interface{}, *api
 		// this gets potentially stale parameters
 		mp := contentFormat.GetCachedMutableParameters()
 
-		return &serverapi.StatusResponse{
+		st := &serverapi.StatusResponse{
 			Connected:                  true,
 			ConfigFile:                 dr.ConfigFilename(),
 			FormatVersion:              mp.Version,
@@ -54,7 +56,13 @@ func handleRepoStatus(ctx context.Context, rc requestContext) (interface{}, *api
 			Storage:                    dr.BlobReader().ConnectionInfo().Type,
 			ClientOptions:              dr.ClientOptions(),
 			SupportsContentCompression: dr.ContentReader().SupportsContentCompression(),
-		}, nil
+		}
+
+		if cp, err := dr.BlobVolume().GetCapacity(ctx); err == nil {
+			st.Capacity = &cp
+		}
+
+		return st, nil
 	}
 
 	type remoteRepository interface {
@@ -82,6 +90,10 @@ func maybeDecodeToken(req *serverapi.ConnectRepositoryRequest) *apiError {
 			return requestError(serverapi.ErrorInvalidToken, "invalid token: "+err.Error())
 		}
 
+		if err := plugin.VerifyTokenConnectionInfo(ci); err != nil {
+			return requestError(serverapi.ErrorInvalidToken, "invalid token: "+err.Error())
+		}
+
 		req.Storage = ci
 		if password != "" {
 			req.Password = password
@@ -241,7 +253,11 @@ func handleRepoSetDescription(ctx context.Context, rc requestContext) (interface
 }
 
 func handleRepoSupportedAlgorithms(ctx context.Context, _ requestContext) (interface{}, *apiError) {
+	cb := cryptobackend.Detect()
+
 	res := &serverapi.SupportedAlgorithmsResponse{
+		CryptoBackend: cb,
+
 		DefaultHashAlgorithm:    hashing.DefaultAlgorithm,
 		SupportedHashAlgorithms: toAlgorithmInfo(hashing.SupportedAlgorithms(), neverDeprecated),
 
@@ -8,6 +8,7 @@
 
 	"github.com/pkg/errors"
 
+	"github.com/kopia/kopia/internal/auditlog"
 	"github.com/kopia/kopia/internal/serverapi"
 	"github.com/kopia/kopia/internal/uitask"
 	"github.com/kopia/kopia/snapshot/restore"
@@ -111,6 +112,13 @@ func handleRestore(ctx context.Context, rc requestContext) (interface{}, *apiErr
 			ctrl.ReportCounters(restoreCounters(st))
 		}
 
+		rc.audit(ctx, auditlog.Event{
+			Action:  auditlog.ActionRestore,
+			Source:  req.Root,
+			Details: description,
+			Error:   auditlog.ErrorString(err),
+		})
+
 		return errors.Wrap(err, "error restoring")
 	})
 
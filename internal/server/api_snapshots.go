@@ -3,10 +3,12 @@
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/url"
 
 	"github.com/pkg/errors"
 
+	"github.com/kopia/kopia/internal/auditlog"
 	"github.com/kopia/kopia/internal/serverapi"
 	"github.com/kopia/kopia/repo"
 	"github.com/kopia/kopia/repo/manifest"
@@ -117,12 +119,30 @@ func handleDeleteSnapshots(ctx context.Context, rc requestContext) (interface{},
 		// if source deletion failed, refresh the repository to rediscover the source
 		rc.srv.Refresh()
 
+		rc.audit(ctx, deleteSnapshotsAuditEvent(&req, err))
+
 		return nil, internalServerError(err)
 	}
 
+	rc.audit(ctx, deleteSnapshotsAuditEvent(&req, nil))
+
 	return &serverapi.Empty{}, nil
 }
 
+func deleteSnapshotsAuditEvent(req *serverapi.DeleteSnapshotsRequest, err error) auditlog.Event {
+	details := fmt.Sprintf("snapshots: %v", req.SnapshotManifestIDs)
+	if req.DeleteSourceAndPolicy {
+		details = "all snapshots and policy"
+	}
+
+	return auditlog.Event{
+		Action:  auditlog.ActionDeleteSnapshots,
+		Source:  req.SourceInfo.String(),
+		Details: details,
+		Error:   auditlog.ErrorString(err),
+	}
+}
+
 func handleEditSnapshots(ctx context.Context, rc requestContext) (interface{}, *apiError) {
 	var req serverapi.EditSnapshotsRequest
 
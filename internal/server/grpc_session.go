@@ -3,6 +3,7 @@
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"runtime"
 	"strings"
@@ -12,18 +13,21 @@
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/propagation"
 	"golang.org/x/sync/semaphore"
+	"golang.org/x/time/rate"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 
+	"github.com/kopia/kopia/internal/auditlog"
 	"github.com/kopia/kopia/internal/auth"
 	"github.com/kopia/kopia/internal/gather"
 	"github.com/kopia/kopia/internal/grpcapi"
 	"github.com/kopia/kopia/repo"
 	"github.com/kopia/kopia/repo/compression"
 	"github.com/kopia/kopia/repo/content"
+	"github.com/kopia/kopia/repo/hashing"
 	"github.com/kopia/kopia/repo/manifest"
 	"github.com/kopia/kopia/repo/object"
 	"github.com/kopia/kopia/snapshot"
@@ -36,6 +40,14 @@ type grpcServerState struct {
 	grpcapi.UnimplementedKopiaRepositoryServer
 
 	sem *semaphore.Weighted
+
+	uploadLimitersMutex sync.Mutex
+	uploadLimiters      map[string]*rate.Limiter // per-user upload rate limiters shared across sessions
+
+	storageUsageMutex sync.Mutex
+	storageUsage      map[string]*userStorageUsage // per-user storage usage shared across sessions
+
+	activeSessions sync.WaitGroup
 }
 
 // send sends the provided session response with the provided request ID.
@@ -76,6 +88,9 @@ func (s *Server) authenticateGRPCSession(ctx context.Context, rep repo.Repositor
 func (s *Server) Session(srv grpcapi.KopiaRepository_SessionServer) error {
 	ctx := srv.Context()
 
+	s.grpcServerState.activeSessions.Add(1)
+	defer s.grpcServerState.activeSessions.Done()
+
 	s.serverMutex.RLock()
 	dr, ok := s.rep.(repo.DirectRepository)
 	s.serverMutex.RUnlock()
@@ -86,6 +101,12 @@ func (s *Server) Session(srv grpcapi.KopiaRepository_SessionServer) error {
 
 	usernameAtHostname, err := s.authenticateGRPCSession(ctx, dr)
 	if err != nil {
+		s.auditLog().Emit(ctx, auditlog.Event{
+			Action: auditlog.ActionAccessDenied,
+			Remote: peerAddress(ctx),
+			Error:  auditlog.ErrorString(err),
+		})
+
 		return err
 	}
 
@@ -100,14 +121,26 @@ func (s *Server) Session(srv grpcapi.KopiaRepository_SessionServer) error {
 	}
 
 	log(ctx).Infof("starting session for user %q from %v", usernameAtHostname, p.Addr)
+
+	audit := grpcSessionAudit{s.auditLog(), usernameAtHostname, p.Addr.String()}
+	audit.emit(ctx, auditlog.Event{Action: auditlog.ActionConnect})
 	defer log(ctx).Infof("session ended for user %q from %v", usernameAtHostname, p.Addr)
 
+	limits, err := s.newSessionLimits(ctx, dr, usernameAtHostname)
+	if err != nil {
+		log(ctx).Errorf("unable to determine session limits: %v", err)
+		return status.Errorf(codes.Unavailable, "unable to determine session limits")
+	}
+
 	opt, err := s.handleInitialSessionHandshake(srv, dr)
 	if err != nil {
 		log(ctx).Errorf("session handshake error: %v", err)
 		return err
 	}
 
+	// changes made in the session are attributed to the authenticated user, not the server.
+	opt.AuditUser = usernameAtHostname
+
 	//nolint:wrapcheck
 	return repo.DirectWriteSession(ctx, dr, opt, func(ctx context.Context, dw repo.DirectRepositoryWriter) error {
 		// channel to which workers will be sending errors, only holds 1 slot and sends are non-blocking.
@@ -131,7 +164,7 @@ func (s *Server) Session(srv grpcapi.KopiaRepository_SessionServer) error {
 			go func() {
 				defer s.grpcServerState.sem.Release(1)
 
-				handleSessionRequest(ctx, dw, authz, usernameAtHostname, req, func(resp *grpcapi.SessionResponse) {
+				handleSessionRequest(ctx, dw, authz, audit, limits, req, func(resp *grpcapi.SessionResponse) {
 					if err := s.send(srv, req.GetRequestId(), resp); err != nil {
 						select {
 						case lastErr <- err:
@@ -148,7 +181,43 @@ func (s *Server) Session(srv grpcapi.KopiaRepository_SessionServer) error {
 
 var tracer = otel.Tracer("kopia/grpc")
 
-func handleSessionRequest(ctx context.Context, dw repo.DirectRepositoryWriter, authz auth.AuthorizationInfo, usernameAtHostname string, req *grpcapi.SessionRequest, respond func(*grpcapi.SessionResponse)) {
+// grpcSessionAudit emits audit events on behalf of the user authenticated for a gRPC session.
+type grpcSessionAudit struct {
+	log    *auditlog.Logger
+	user   string
+	remote string
+}
+
+func (a grpcSessionAudit) emit(ctx context.Context, ev auditlog.Event) {
+	ev.User = a.user
+	ev.Remote = a.remote
+
+	a.log.Emit(ctx, ev)
+}
+
+// snapshotSourceFromLabels returns the snapshot source described by the provided manifest labels
+// or false if the labels do not describe a snapshot manifest.
+func snapshotSourceFromLabels(labels map[string]string) (snapshot.SourceInfo, bool) {
+	if labels[manifest.TypeLabelKey] != snapshot.ManifestType {
+		return snapshot.SourceInfo{}, false
+	}
+
+	return snapshot.SourceInfo{
+		Host:     labels[snapshot.HostnameLabel],
+		UserName: labels[snapshot.UsernameLabel],
+		Path:     labels[snapshot.PathLabel],
+	}, true
+}
+
+func peerAddress(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok {
+		return p.Addr.String()
+	}
+
+	return ""
+}
+
+func handleSessionRequest(ctx context.Context, dw repo.DirectRepositoryWriter, authz auth.AuthorizationInfo, audit grpcSessionAudit, limits *sessionLimits, req *grpcapi.SessionRequest, respond func(*grpcapi.SessionResponse)) {
 	if req.GetTraceContext() != nil {
 		var tc propagation.TraceContext
 		ctx = tc.Extract(ctx, propagation.MapCarrier(req.GetTraceContext()))
@@ -162,7 +231,7 @@ func handleSessionRequest(ctx context.Context, dw repo.DirectRepositoryWriter, a
 		respond(handleGetContentRequest(ctx, dw, authz, inner.GetContent))
 
 	case *grpcapi.SessionRequest_WriteContent:
-		respond(handleWriteContentRequest(ctx, dw, authz, inner.WriteContent))
+		respond(handleWriteContentRequest(ctx, dw, authz, limits, inner.WriteContent))
 
 	case *grpcapi.SessionRequest_Flush:
 		respond(handleFlushRequest(ctx, dw, authz, inner.Flush))
@@ -171,19 +240,19 @@ func handleSessionRequest(ctx context.Context, dw repo.DirectRepositoryWriter, a
 		respond(handleGetManifestRequest(ctx, dw, authz, inner.GetManifest))
 
 	case *grpcapi.SessionRequest_PutManifest:
-		respond(handlePutManifestRequest(ctx, dw, authz, inner.PutManifest))
+		respond(handlePutManifestRequest(ctx, dw, authz, audit, inner.PutManifest))
 
 	case *grpcapi.SessionRequest_FindManifests:
 		handleFindManifestsRequest(ctx, dw, authz, inner.FindManifests, respond)
 
 	case *grpcapi.SessionRequest_DeleteManifest:
-		respond(handleDeleteManifestRequest(ctx, dw, authz, inner.DeleteManifest))
+		respond(handleDeleteManifestRequest(ctx, dw, authz, audit, inner.DeleteManifest))
 
 	case *grpcapi.SessionRequest_PrefetchContents:
 		respond(handlePrefetchContentsRequest(ctx, dw, authz, inner.PrefetchContents))
 
 	case *grpcapi.SessionRequest_ApplyRetentionPolicy:
-		respond(handleApplyRetentionPolicyRequest(ctx, dw, authz, usernameAtHostname, inner.ApplyRetentionPolicy))
+		respond(handleApplyRetentionPolicyRequest(ctx, dw, authz, audit, inner.ApplyRetentionPolicy))
 
 	case *grpcapi.SessionRequest_InitializeSession:
 		respond(errorResponse(errors.Errorf("InitializeSession must be the first request in a session")))
@@ -256,7 +325,7 @@ func handleGetContentRequest(ctx context.Context, dw repo.DirectRepositoryWriter
 	}
 }
 
-func handleWriteContentRequest(ctx context.Context, dw repo.DirectRepositoryWriter, authz auth.AuthorizationInfo, req *grpcapi.WriteContentRequest) *grpcapi.SessionResponse {
+func handleWriteContentRequest(ctx context.Context, dw repo.DirectRepositoryWriter, authz auth.AuthorizationInfo, limits *sessionLimits, req *grpcapi.WriteContentRequest) *grpcapi.SessionResponse {
 	ctx, span := tracer.Start(ctx, "GRPCSession.WriteContent")
 	defer span.End()
 
@@ -269,7 +338,42 @@ func handleWriteContentRequest(ctx context.Context, dw repo.DirectRepositoryWrit
 		return accessDeniedResponse()
 	}
 
-	contentID, err := dw.ContentManager().WriteContent(ctx, gather.FromSlice(req.GetData()), content.IDPrefix(req.GetPrefix()), compression.HeaderID(req.GetCompression()))
+	data := gather.FromSlice(req.GetData())
+	prefix := content.IDPrefix(req.GetPrefix())
+
+	// deduplicated contents do not count towards the quota.
+	isNew := true
+
+	if limits != nil {
+		var hashOutput [hashing.MaxHashSize]byte
+
+		if cid, err := content.IDFromHash(prefix, dw.FormatManager().HashFunc()(hashOutput[:0], data)); err == nil {
+			if ci, err := dw.ContentInfo(ctx, cid); err == nil && !ci.Deleted {
+				isNew = false
+			}
+		}
+	}
+
+	if isNew {
+		if err := limits.beforeWrite(ctx, len(req.GetData())); err != nil {
+			return errorResponse(err)
+		}
+	}
+
+	contentID, err := dw.ContentManager().WriteContent(ctx, data, prefix, compression.HeaderID(req.GetCompression()))
+
+	if isNew && limits != nil {
+		var stored int64
+
+		if err == nil {
+			if ci, cerr := dw.ContentInfo(ctx, contentID); cerr == nil {
+				stored = int64(ci.PackedLength)
+			}
+		}
+
+		limits.afterWrite(len(req.GetData()), stored)
+	}
+
 	if err != nil {
 		return errorResponse(err)
 	}
@@ -325,7 +429,7 @@ func handleGetManifestRequest(ctx context.Context, dw repo.DirectRepositoryWrite
 	}
 }
 
-func handlePutManifestRequest(ctx context.Context, dw repo.DirectRepositoryWriter, authz auth.AuthorizationInfo, req *grpcapi.PutManifestRequest) *grpcapi.SessionResponse {
+func handlePutManifestRequest(ctx context.Context, dw repo.DirectRepositoryWriter, authz auth.AuthorizationInfo, audit grpcSessionAudit, req *grpcapi.PutManifestRequest) *grpcapi.SessionResponse {
 	ctx, span := tracer.Start(ctx, "GRPCSession.PutManifest")
 	defer span.End()
 
@@ -334,6 +438,17 @@ func handlePutManifestRequest(ctx context.Context, dw repo.DirectRepositoryWrite
 	}
 
 	manifestID, err := dw.PutManifest(ctx, req.GetLabels(), json.RawMessage(req.GetJsonData()))
+
+	// snapshots created by clients are only visible to the server as snapshot manifests.
+	if src, ok := snapshotSourceFromLabels(req.GetLabels()); ok {
+		audit.emit(ctx, auditlog.Event{
+			Action:  auditlog.ActionSnapshot,
+			Source:  src.String(),
+			Details: fmt.Sprintf("manifest: %v", manifestID),
+			Error:   auditlog.ErrorString(err),
+		})
+	}
+
 	if err != nil {
 		return errorResponse(err)
 	}
@@ -393,7 +508,7 @@ func handleFindManifestsRequest(ctx context.Context, dw repo.DirectRepositoryWri
 	})
 }
 
-func handleDeleteManifestRequest(ctx context.Context, dw repo.DirectRepositoryWriter, authz auth.AuthorizationInfo, req *grpcapi.DeleteManifestRequest) *grpcapi.SessionResponse {
+func handleDeleteManifestRequest(ctx context.Context, dw repo.DirectRepositoryWriter, authz auth.AuthorizationInfo, audit grpcSessionAudit, req *grpcapi.DeleteManifestRequest) *grpcapi.SessionResponse {
 	ctx, span := tracer.Start(ctx, "GRPCSession.DeleteManifest")
 	defer span.End()
 
@@ -408,7 +523,18 @@ func handleDeleteManifestRequest(ctx context.Context, dw repo.DirectRepositoryWr
 		return accessDeniedResponse()
 	}
 
-	if err := dw.DeleteManifest(ctx, manifest.ID(req.GetManifestId())); err != nil {
+	err = dw.DeleteManifest(ctx, manifest.ID(req.GetManifestId()))
+
+	if src, ok := snapshotSourceFromLabels(em.Labels); ok {
+		audit.emit(ctx, auditlog.Event{
+			Action:  auditlog.ActionDeleteSnapshots,
+			Source:  src.String(),
+			Details: fmt.Sprintf("snapshots: [%v]", req.GetManifestId()),
+			Error:   auditlog.ErrorString(err),
+		})
+	}
+
+	if err != nil {
 		return errorResponse(err)
 	}
 
@@ -443,13 +569,13 @@ func handlePrefetchContentsRequest(ctx context.Context, rep repo.Repository, aut
 	}
 }
 
-func handleApplyRetentionPolicyRequest(ctx context.Context, rep repo.RepositoryWriter, authz auth.AuthorizationInfo, usernameAtHostname string, req *grpcapi.ApplyRetentionPolicyRequest) *grpcapi.SessionResponse {
+func handleApplyRetentionPolicyRequest(ctx context.Context, rep repo.RepositoryWriter, authz auth.AuthorizationInfo, audit grpcSessionAudit, req *grpcapi.ApplyRetentionPolicyRequest) *grpcapi.SessionResponse {
 	ctx, span := tracer.Start(ctx, "GRPCSession.ApplyRetentionPolicy")
 	defer span.End()
 
-	parts := strings.Split(usernameAtHostname, "@")
+	parts := strings.Split(audit.user, "@")
 	if len(parts) != 2 { //nolint:mnd
-		return errorResponse(errors.Errorf("invalid username@hostname: %q", usernameAtHostname))
+		return errorResponse(errors.Errorf("invalid username@hostname: %q", audit.user))
 	}
 
 	username := parts[0]
@@ -466,11 +592,23 @@ func handleApplyRetentionPolicyRequest(ctx context.Context, rep repo.RepositoryW
 		return accessDeniedResponse()
 	}
 
-	manifestIDs, err := policy.ApplyRetentionPolicy(ctx, rep, snapshot.SourceInfo{
+	src := snapshot.SourceInfo{
 		Host:     hostname,
 		UserName: username,
 		Path:     req.GetSourcePath(),
-	}, req.GetReallyDelete())
+	}
+
+	manifestIDs, err := policy.ApplyRetentionPolicy(ctx, rep, src, req.GetReallyDelete())
+
+	if req.GetReallyDelete() && (err != nil || len(manifestIDs) > 0) {
+		audit.emit(ctx, auditlog.Event{
+			Action:  auditlog.ActionDeleteSnapshots,
+			Source:  src.String(),
+			Details: fmt.Sprintf("retention policy, snapshots: %v", manifestIDs),
+			Error:   auditlog.ErrorString(err),
+		})
+	}
+
 	if err != nil {
 		return errorResponse(err)
 	}
@@ -505,6 +643,8 @@ func errorResponse(err error) *grpcapi.SessionResponse {
 		errorCode = grpcapi.ErrorResponse_MANIFEST_NOT_FOUND
 	case errors.Is(err, object.ErrObjectNotFound):
 		errorCode = grpcapi.ErrorResponse_OBJECT_NOT_FOUND
+	case errors.Is(err, repo.ErrStorageQuotaExceeded):
+		errorCode = grpcapi.ErrorResponse_QUOTA_EXCEEDED
 	default:
 		errorCode = grpcapi.ErrorResponse_UNKNOWN_ERROR
 	}
@@ -559,6 +699,7 @@ func (s *Server) handleInitialSessionHandshake(srv grpcapi.KopiaRepository_Sessi
 					HmacSecret:                 dr.ContentReader().ContentFormat().GetHmacSecret(),
 					Splitter:                   dr.ObjectFormat().Splitter,
 					SupportsContentCompression: scc,
+					DefaultCompression:         string(dr.ObjectFormat().DefaultCompression),
 				},
 			},
 		},
@@ -0,0 +1,230 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/time/rate"
+
+	"github.com/kopia/kopia/internal/clock"
+	"github.com/kopia/kopia/internal/units"
+	"github.com/kopia/kopia/internal/user"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+)
+
+// storageUsageRefreshInterval is the interval after which the cached storage usage of a user
+// is recalculated from the repository when the user opens a new session, which accounts for
+// snapshots deleted in the meantime.
+const storageUsageRefreshInterval = time.Hour
+
+// userStorageUsage is the storage used by a single user, shared by all sessions of the user.
+type userStorageUsage struct {
+	// bytes stored in the repository on behalf of the user, initially the packed size of unique
+	// contents of all snapshots of the user's sources, incremented by the packed size of each
+	// new content written by any session of the user.
+	usedBytes atomic.Int64
+
+	// mu serializes recalculation of the usage.
+	mu sync.Mutex
+	// +checklocks:mu
+	calculatedAt time.Time
+}
+
+// sessionLimits enforces per-user storage quota and upload bandwidth limits for a single GRPC session.
+type sessionLimits struct {
+	usernameAtHostname string
+
+	quotaBytes int64             // 0 == unlimited
+	usage      *userStorageUsage // nil when quotaBytes == 0
+
+	uploadLimiter *rate.Limiter // nil == unlimited
+}
+
+// beforeWrite is invoked before a new content of the provided number of bytes is written on behalf
+// of the user, it waits until the upload rate allows it and reserves the provided number of bytes
+// towards the quota, failing if the reservation would exceed it. Since the stored size is only known
+// after the content has been compressed, the reservation must be settled with afterWrite().
+func (l *sessionLimits) beforeWrite(ctx context.Context, n int) error {
+	if l == nil {
+		return nil
+	}
+
+	if l.usage != nil {
+		if used := l.usage.usedBytes.Add(int64(n)); used > l.quotaBytes {
+			l.usage.usedBytes.Add(-int64(n))
+
+			return errors.Wrapf(repo.ErrStorageQuotaExceeded, "user %v uses %v of %v", l.usernameAtHostname, units.BytesString(used-int64(n)), units.BytesString(l.quotaBytes))
+		}
+	}
+
+	if l.uploadLimiter != nil {
+		// the limiter can't hand out more than its burst at once, so wait in burst-sized chunks.
+		for remaining := n; remaining > 0; remaining -= l.uploadLimiter.Burst() {
+			if err := l.uploadLimiter.WaitN(ctx, min(remaining, l.uploadLimiter.Burst())); err != nil {
+				l.afterWrite(n, 0)
+
+				return errors.Wrap(err, "upload rate limit")
+			}
+		}
+	}
+
+	return nil
+}
+
+// afterWrite settles the reservation of the provided number of bytes made by beforeWrite() with
+// the number of bytes actually stored, which is zero if the write has failed.
+func (l *sessionLimits) afterWrite(reservedBytes int, storedBytes int64) {
+	if l == nil || l.usage == nil {
+		return
+	}
+
+	l.usage.usedBytes.Add(storedBytes - int64(reservedBytes))
+}
+
+// uploadLimiterForUser returns the upload rate limiter shared by all sessions of the provided user.
+func (s *grpcServerState) uploadLimiterForUser(usernameAtHostname string, bytesPerSecond int64) *rate.Limiter {
+	s.uploadLimitersMutex.Lock()
+	defer s.uploadLimitersMutex.Unlock()
+
+	if l := s.uploadLimiters[usernameAtHostname]; l != nil {
+		if l.Limit() != rate.Limit(bytesPerSecond) {
+			l.SetLimit(rate.Limit(bytesPerSecond))
+			l.SetBurst(int(bytesPerSecond))
+		}
+
+		return l
+	}
+
+	if s.uploadLimiters == nil {
+		s.uploadLimiters = map[string]*rate.Limiter{}
+	}
+
+	l := rate.NewLimiter(rate.Limit(bytesPerSecond), int(bytesPerSecond))
+	s.uploadLimiters[usernameAtHostname] = l
+
+	return l
+}
+
+// newSessionLimits returns the limits configured in the profile of the provided user or nil
+// if the user has no limits.
+func (s *Server) newSessionLimits(ctx context.Context, rep repo.Repository, usernameAtHostname string) (*sessionLimits, error) {
+	up, err := user.GetUserProfile(ctx, rep, usernameAtHostname)
+	if errors.Is(err, user.ErrUserNotFound) {
+		// users authenticated by other means, e.g. htpasswd file have no limits.
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to get user profile")
+	}
+
+	if up.StorageQuotaBytes <= 0 && up.MaxUploadBytesPerSecond <= 0 {
+		return nil, nil
+	}
+
+	l := &sessionLimits{
+		usernameAtHostname: usernameAtHostname,
+		quotaBytes:         max(up.StorageQuotaBytes, 0),
+	}
+
+	if up.MaxUploadBytesPerSecond > 0 {
+		l.uploadLimiter = s.grpcServerState.uploadLimiterForUser(usernameAtHostname, up.MaxUploadBytesPerSecond)
+	}
+
+	if l.quotaBytes > 0 {
+		usage, err := s.grpcServerState.storageUsageForUser(ctx, rep, usernameAtHostname)
+		if err != nil {
+			return nil, err
+		}
+
+		l.usage = usage
+	}
+
+	return l, nil
+}
+
+// storageUsageForUser returns the storage usage shared by all sessions of the provided user,
+// calculating it when the user opens the first session and recalculating it when it is older
+// than storageUsageRefreshInterval.
+func (s *grpcServerState) storageUsageForUser(ctx context.Context, rep repo.Repository, usernameAtHostname string) (*userStorageUsage, error) {
+	s.storageUsageMutex.Lock()
+
+	u := s.storageUsage[usernameAtHostname]
+	if u == nil {
+		if s.storageUsage == nil {
+			s.storageUsage = map[string]*userStorageUsage{}
+		}
+
+		u = &userStorageUsage{}
+		s.storageUsage[usernameAtHostname] = u
+	}
+
+	s.storageUsageMutex.Unlock()
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if !u.calculatedAt.IsZero() && clock.Now().Sub(u.calculatedAt) < storageUsageRefreshInterval {
+		return u, nil
+	}
+
+	used, err := storageUsedByUser(ctx, rep, usernameAtHostname)
+	if err != nil {
+		return nil, err
+	}
+
+	// writes of concurrent sessions which were not flushed yet are not included in the recalculated usage,
+	// which is acceptable since this only happens once per refresh interval.
+	u.usedBytes.Store(used)
+	u.calculatedAt = clock.Now()
+
+	return u, nil
+}
+
+// storageUsedByUser returns the number of bytes stored in unique contents of all snapshots
+// of sources owned by the provided user.
+func storageUsedByUser(ctx context.Context, rep repo.Repository, usernameAtHostname string) (int64, error) {
+	username, hostname, _ := strings.Cut(usernameAtHostname, "@")
+
+	sources, err := snapshot.ListSources(ctx, rep)
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to list sources")
+	}
+
+	var manifests []*snapshot.Manifest
+
+	for _, src := range sources {
+		if src.UserName != username || src.Host != hostname {
+			continue
+		}
+
+		snaps, err := snapshot.ListSnapshots(ctx, rep, src)
+		if err != nil {
+			return 0, errors.Wrapf(err, "unable to list snapshots of %v", src)
+		}
+
+		manifests = append(manifests, snaps...)
+	}
+
+	if len(manifests) == 0 {
+		return 0, nil
+	}
+
+	var total int64
+
+	// the running total after the last snapshot includes contents shared between snapshots only once.
+	if err := snapshotfs.CalculateStorageStats(ctx, rep, manifests, func(m *snapshot.Manifest) error {
+		total = m.StorageStats.RunningTotal.PackedContentBytes
+		return nil
+	}); err != nil {
+		return 0, errors.Wrap(err, "unable to calculate storage usage")
+	}
+
+	return total, nil
+}
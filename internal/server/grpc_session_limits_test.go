@@ -0,0 +1,41 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/repo"
+)
+
+func TestSessionLimitsQuotaSharedByUser(t *testing.T) {
+	ctx := context.Background()
+
+	usage := &userStorageUsage{}
+	usage.usedBytes.Store(900)
+
+	// two sessions of the same user share the usage.
+	l1 := &sessionLimits{usernameAtHostname: "foo@bar", quotaBytes: 1000, usage: usage}
+	l2 := &sessionLimits{usernameAtHostname: "foo@bar", quotaBytes: 1000, usage: usage}
+
+	require.NoError(t, l1.beforeWrite(ctx, 80))
+
+	// the reservation of the first session counts against the second one and a failed
+	// reservation is rolled back.
+	require.ErrorIs(t, l2.beforeWrite(ctx, 30), repo.ErrStorageQuotaExceeded)
+	require.EqualValues(t, 980, usage.usedBytes.Load())
+
+	// the reservation is settled with the stored size after compression.
+	l1.afterWrite(80, 50)
+	require.EqualValues(t, 950, usage.usedBytes.Load())
+
+	require.NoError(t, l2.beforeWrite(ctx, 30))
+
+	// failed writes release the reservation.
+	l2.afterWrite(30, 0)
+	require.EqualValues(t, 950, usage.usedBytes.Load())
+
+	require.ErrorIs(t, l1.beforeWrite(ctx, 51), repo.ErrStorageQuotaExceeded)
+	require.NoError(t, l1.beforeWrite(ctx, 50))
+}
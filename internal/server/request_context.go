@@ -7,6 +7,7 @@
 
 	"github.com/gorilla/mux"
 
+	"github.com/kopia/kopia/internal/auditlog"
 	"github.com/kopia/kopia/internal/auth"
 	"github.com/kopia/kopia/internal/mount"
 	"github.com/kopia/kopia/internal/uitask"
@@ -25,6 +26,7 @@ type serverInterface interface {
 	getOptions() *Options
 	snapshotAllSourceManagers() map[snapshot.SourceInfo]*sourceManager
 	taskManager() *uitask.Manager
+	eventStreamsClosed() <-chan struct{}
 	Refresh()
 	getMountController(ctx context.Context, rep repo.Repository, oid object.ID, createIfNotFound bool) (mount.Controller, error)
 	deleteMount(oid object.ID)
@@ -44,6 +46,9 @@ type requestContext struct {
 	body []byte
 	rep  repo.Repository
 	srv  serverInterface
+
+	// user is the authenticated user making the request, empty when authentication is disabled.
+	user string
 }
 
 func (r *requestContext) muxVar(s string) string {
@@ -53,3 +58,11 @@ func (r *requestContext) muxVar(s string) string {
 func (r *requestContext) queryParam(s string) string {
 	return r.req.URL.Query().Get(s)
 }
+
+// audit emits the provided audit event on behalf of the user making the request.
+func (r *requestContext) audit(ctx context.Context, ev auditlog.Event) {
+	ev.User = r.user
+	ev.Remote = r.req.RemoteAddr
+
+	r.srv.getOptions().AuditLog.Emit(ctx, ev)
+}
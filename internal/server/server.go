@@ -12,6 +12,7 @@
 	"net/url"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
@@ -19,6 +20,7 @@
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
 
+	"github.com/kopia/kopia/internal/auditlog"
 	"github.com/kopia/kopia/internal/auth"
 	"github.com/kopia/kopia/internal/clock"
 	"github.com/kopia/kopia/internal/ctxutil"
@@ -48,6 +50,9 @@
 	kopiaAuthCookieTTL      = 1 * time.Minute
 	kopiaAuthCookieAudience = "kopia"
 	kopiaAuthCookieIssuer   = "kopia-server"
+
+	// how long the scheduler heartbeat may be overdue before the server is no longer considered live.
+	schedulerLivenessTimeout = 5 * time.Minute
 )
 
 type csrfTokenOption int
@@ -100,15 +105,23 @@ type Server struct {
 	// channel to which we can post to trigger scheduler re-evaluation.
 	schedulerRefresh chan string
 
+	// closed when event streams should be terminated.
+	eventStreamsDone      chan struct{}
+	closeEventStreamsOnce sync.Once
+
 	// +checklocks:serverMutex
 	sched *scheduler.Scheduler
 
+	// scheduler whose heartbeat is checked by CheckLiveness, readable without the server lock.
+	livenessSched atomic.Pointer[scheduler.Scheduler]
+
 	nextRefreshTimeLock sync.Mutex
 
 	// +checklocks:nextRefreshTimeLock
 	nextRefreshTime time.Time
 
 	grpcServerState
+	storageNotificationState
 }
 
 // SetupHTMLUIAPIHandlers registers API requests required by the HTMLUI.
@@ -154,6 +167,7 @@ func (s *Server) SetupHTMLUIAPIHandlers(m *mux.Router) {
 	m.HandleFunc("/api/v1/ui-preferences", s.handleUIPossiblyNotConnected(handleGetUIPreferences)).Methods(http.MethodGet)
 	m.HandleFunc("/api/v1/ui-preferences", s.handleUIPossiblyNotConnected(handleSetUIPreferences)).Methods(http.MethodPut)
 
+	m.HandleFunc("/api/v1/events", s.requireAuth(csrfTokenNotRequired, handleEventStream(requireUIUser))).Methods(http.MethodGet)
 	m.HandleFunc("/api/v1/tasks-summary", s.handleUIPossiblyNotConnected(handleTaskSummary)).Methods(http.MethodGet)
 	m.HandleFunc("/api/v1/tasks", s.handleUIPossiblyNotConnected(handleTaskList)).Methods(http.MethodGet)
 	m.HandleFunc("/api/v1/tasks/{taskID}", s.handleUIPossiblyNotConnected(handleTaskInfo)).Methods(http.MethodGet)
@@ -165,6 +179,7 @@ func (s *Server) SetupHTMLUIAPIHandlers(m *mux.Router) {
 func (s *Server) SetupControlAPIHandlers(m *mux.Router) {
 	// server control API, requires authentication as `server-control` and no CSRF token.
 	m.HandleFunc("/api/v1/control/sources", s.handleServerControlAPI(handleSourcesList)).Methods(http.MethodGet)
+	m.HandleFunc("/api/v1/control/snapshots", s.handleServerControlAPI(handleListSnapshots)).Methods(http.MethodGet)
 	m.HandleFunc("/api/v1/control/status", s.handleServerControlAPIPossiblyNotConnected(handleRepoStatus)).Methods(http.MethodGet)
 	m.HandleFunc("/api/v1/control/flush", s.handleServerControlAPI(handleFlush)).Methods(http.MethodPost)
 	m.HandleFunc("/api/v1/control/refresh", s.handleServerControlAPI(handleRefresh)).Methods(http.MethodPost)
@@ -175,9 +190,15 @@ func (s *Server) SetupControlAPIHandlers(m *mux.Router) {
 	m.HandleFunc("/api/v1/control/resume-source", s.handleServerControlAPI(handleResume)).Methods(http.MethodPost)
 	m.HandleFunc("/api/v1/control/throttle", s.handleServerControlAPI(handleRepoGetThrottle)).Methods(http.MethodGet)
 	m.HandleFunc("/api/v1/control/throttle", s.handleServerControlAPI(handleRepoSetThrottle)).Methods(http.MethodPut)
+	m.HandleFunc("/api/v1/control/events", s.requireAuth(csrfTokenNotRequired, handleEventStream(requireServerControlUser))).Methods(http.MethodGet)
+	m.HandleFunc("/api/v1/control/tasks", s.handleServerControlAPIPossiblyNotConnected(handleTaskList)).Methods(http.MethodGet)
+	m.HandleFunc("/api/v1/control/tasks/{taskID}", s.handleServerControlAPIPossiblyNotConnected(handleTaskInfo)).Methods(http.MethodGet)
+	m.HandleFunc("/api/v1/control/tasks/{taskID}/logs", s.handleServerControlAPIPossiblyNotConnected(handleTaskLogs)).Methods(http.MethodGet)
+	m.HandleFunc("/api/v1/control/tasks/{taskID}/cancel", s.handleServerControlAPIPossiblyNotConnected(handleTaskCancel)).Methods(http.MethodPost)
 }
 
-func isAuthenticated(rc requestContext) bool {
+// isAuthenticated verifies the credentials presented with the request and records the authenticated user in rc.
+func isAuthenticated(rc *requestContext) bool {
 	authn := rc.srv.getAuthenticator()
 	if authn == nil {
 		return true
@@ -195,6 +216,8 @@ func isAuthenticated(rc requestContext) bool {
 		if rc.srv.isAuthCookieValid(username, c.Value) {
 			// found a short-term JWT cookie that matches given username, trust it.
 			// this avoids potentially expensive password hashing inside the authenticator.
+			rc.user = username
+
 			return true
 		}
 	}
@@ -206,6 +229,8 @@ func isAuthenticated(rc requestContext) bool {
 		return false
 	}
 
+	rc.user = username
+
 	now := clock.Now()
 
 	ac, err := rc.srv.generateShortTermAuthCookie(username, now)
@@ -280,12 +305,30 @@ func (s *Server) taskManager() *uitask.Manager {
 	return s.taskmgr
 }
 
+func (s *Server) eventStreamsClosed() <-chan struct{} {
+	return s.eventStreamsDone
+}
+
+// WaitForSessions waits until all GRPC sessions have finished, it must be invoked after shutting down
+// the HTTP server and before closing the repository since sessions keep using it until they finish.
+func (s *Server) WaitForSessions() {
+	s.grpcServerState.activeSessions.Wait()
+}
+
+// CloseEventStreams terminates all event streams, it must be invoked when shutting down
+// the HTTP server since otherwise long-lived streams would prevent graceful shutdown.
+func (s *Server) CloseEventStreams() {
+	s.closeEventStreamsOnce.Do(func() {
+		close(s.eventStreamsDone)
+	})
+}
+
 func (s *Server) requireAuth(checkCSRFToken csrfTokenOption, f func(ctx context.Context, rc requestContext)) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		rc := s.captureRequestContext(w, r)
 
 		//nolint:contextcheck
-		if !isAuthenticated(rc) {
+		if !isAuthenticated(&rc) {
 			return
 		}
 
@@ -406,6 +449,30 @@ func (s *Server) refreshAsync() {
 	go s.Refresh()
 }
 
+// CheckLiveness returns an error if the server is shutting down or if the scheduler of the connected
+// repository has stopped making progress. It deliberately does not take the server lock, which is held
+// for the duration of long operations such as connecting to or disconnecting from a repository, during
+// which the server is still alive.
+func (s *Server) CheckLiveness(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return errors.Wrap(err, "server is shutting down")
+	}
+
+	select {
+	case <-s.eventStreamsClosed():
+		return errors.New("server is shutting down")
+	default:
+	}
+
+	if sched := s.livenessSched.Load(); sched != nil {
+		if age := clock.Now().Sub(sched.LastHeartbeat()); age > sched.MaxSleepTime+schedulerLivenessTimeout {
+			return errors.Errorf("scheduler has not made progress in %v", age.Truncate(time.Second))
+		}
+	}
+
+	return nil
+}
+
 // Refresh refreshes the state of the server in response to external signal (e.g. SIGHUP).
 func (s *Server) Refresh() {
 	s.serverMutex.Lock()
@@ -548,6 +615,7 @@ func (s *Server) SetRepository(ctx context.Context, rep repo.Repository) error {
 		go s.sched.Stop()
 
 		s.sched = nil
+		s.livenessSched.Store(nil)
 
 		s.unmountAllLocked(ctx)
 
@@ -580,6 +648,12 @@ func (s *Server) SetRepository(ctx context.Context, rep repo.Repository) error {
 	}
 
 	if dr, ok := s.rep.(repo.DirectRepository); ok {
+		if s.options.TakeMaintenanceOwnership {
+			if err := takeMaintenanceOwnership(ctx, dr); err != nil {
+				log(ctx).Errorf("%v", err)
+			}
+		}
+
 		s.maint = startMaintenanceManager(ctx, dr, s, s.options.MinMaintenanceInterval)
 	} else {
 		s.maint = nil
@@ -590,6 +664,7 @@ func (s *Server) SetRepository(ctx context.Context, rep repo.Repository) error {
 		Debug:          s.options.DebugScheduler,
 		RefreshChannel: s.schedulerRefresh,
 	})
+	s.livenessSched.Store(s.sched)
 
 	return nil
 }
@@ -744,7 +819,7 @@ func (s *Server) ServeStaticFiles(m *mux.Router, fs http.FileSystem) {
 		rc := s.captureRequestContext(w, r)
 
 		//nolint:contextcheck
-		if !isAuthenticated(rc) {
+		if !isAuthenticated(&rc) {
 			return
 		}
 
@@ -781,23 +856,28 @@ func (s *Server) ServeStaticFiles(m *mux.Router, fs http.FileSystem) {
 
 // Options encompasses all API server options.
 type Options struct {
-	ConfigFile             string
-	ConnectOptions         *repo.ConnectOptions
-	RefreshInterval        time.Duration
-	MaxConcurrency         int
-	Authenticator          auth.Authenticator
-	Authorizer             auth.Authorizer
-	PasswordPersist        passwordpersist.Strategy
-	AuthCookieSigningKey   string
-	LogRequests            bool
-	UIUser                 string // name of the user allowed to access the UI API
-	UIPreferencesFile      string // name of the JSON file storing UI preferences
-	ServerControlUser      string // name of the user allowed to access the server control API
-	DisableCSRFTokenChecks bool
-	PersistentLogs         bool
-	UITitlePrefix          string
-	DebugScheduler         bool
-	MinMaintenanceInterval time.Duration
+	ConfigFile               string
+	ConnectOptions           *repo.ConnectOptions
+	RefreshInterval          time.Duration
+	MaxConcurrency           int
+	Authenticator            auth.Authenticator
+	Authorizer               auth.Authorizer
+	PasswordPersist          passwordpersist.Strategy
+	AuthCookieSigningKey     string
+	LogRequests              bool
+	UIUser                   string // name of the user allowed to access the UI API
+	UIPreferencesFile        string // name of the JSON file storing UI preferences
+	ServerControlUser        string // name of the user allowed to access the server control API
+	DisableCSRFTokenChecks   bool
+	PersistentLogs           bool
+	UITitlePrefix            string
+	DebugScheduler           bool
+	MinMaintenanceInterval   time.Duration
+	TakeMaintenanceOwnership bool             // become the owner of repository maintenance when the repository is opened
+	AuditLog                 *auditlog.Logger // optional destination for audit events
+
+	StorageNotificationToken     string // secret token required to deliver storage change notifications, disabled when empty
+	StorageNotificationKeyPrefix string // prefix of object keys of repository blobs in storage change notifications
 }
 
 // InitRepositoryFunc is a function that attempts to connect to/open repository.
@@ -925,14 +1005,26 @@ func(ctx context.Context, ctrl uitask.Controller) error {
 		}), "snapshot task")
 }
 
+func (s *Server) auditLog() *auditlog.Logger {
+	return s.options.AuditLog
+}
+
 func (s *Server) runMaintenanceTask(ctx context.Context, dr repo.DirectRepository) error {
-	return errors.Wrap(s.taskmgr.Run(ctx, "Maintenance", "Periodic maintenance", func(ctx context.Context, _ uitask.Controller) error {
+	err := s.taskmgr.Run(ctx, "Maintenance", "Periodic maintenance", func(ctx context.Context, _ uitask.Controller) error {
 		return repo.DirectWriteSession(ctx, dr, repo.WriteSessionOptions{
 			Purpose: "periodicMaintenance",
 		}, func(ctx context.Context, w repo.DirectRepositoryWriter) error {
 			return snapshotmaintenance.Run(ctx, w, maintenance.ModeAuto, false, maintenance.SafetyFull)
 		})
-	}), "unable to run maintenance")
+	})
+
+	s.auditLog().Emit(ctx, auditlog.Event{
+		Action:  auditlog.ActionMaintenance,
+		Details: string(maintenance.ModeAuto),
+		Error:   auditlog.ErrorString(err),
+	})
+
+	return errors.Wrap(err, "unable to run maintenance")
 }
 
 // +checklocksread:s.serverMutex
@@ -1022,8 +1114,10 @@ func (s *Server) getSchedulerItems(ctx context.Context, now time.Time) []schedul
 		if nst, ok := sm.getNextSnapshotTime(); ok {
 			result = append(result, scheduler.Item{
 				Description: fmt.Sprintf("snapshot %q", sm.src.Path),
-				Trigger:     sm.scheduleSnapshotNow,
-				NextTime:    nst,
+				Trigger: func() {
+					sm.scheduledSnapshotDue(ctx, nst)
+				},
+				NextTime: nst,
 			})
 		} else {
 			log(ctx).Debugf("no snapshot scheduled for %v %v %v", sm.src, nst, now)
@@ -1069,6 +1163,7 @@ func New(ctx context.Context, options *Options) (*Server, error) {
 		authCookieSigningKey: []byte(options.AuthCookieSigningKey),
 		nextRefreshTime:      clock.Now().Add(options.RefreshInterval),
 		schedulerRefresh:     make(chan string, 1),
+		eventStreamsDone:     make(chan struct{}),
 	}
 
 	s.parallelSnapshotsChanged = sync.NewCond(&s.parallelSnapshotsMutex)
@@ -67,9 +67,7 @@ func requireUIUser(ctx context.Context, rc requestContext) bool {
 		return false
 	}
 
-	user, _, _ := rc.req.BasicAuth()
-
-	return user == rc.srv.getOptions().UIUser
+	return rc.user == rc.srv.getOptions().UIUser
 }
 
 func requireServerControlUser(ctx context.Context, rc requestContext) bool {
@@ -81,9 +79,7 @@ func requireServerControlUser(ctx context.Context, rc requestContext) bool {
 		return false
 	}
 
-	user, _, _ := rc.req.BasicAuth()
-
-	return user == rc.srv.getOptions().ServerControlUser
+	return rc.user == rc.srv.getOptions().ServerControlUser
 }
 
 func anyAuthenticatedUser(ctx context.Context, _ requestContext) bool {
@@ -0,0 +1,55 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/clock"
+	"github.com/kopia/kopia/internal/scheduler"
+	"github.com/kopia/kopia/internal/testlogging"
+)
+
+func TestCheckLivenessDoesNotWaitForServerLock(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	s := &Server{eventStreamsDone: make(chan struct{})}
+
+	// long-running operations such as connecting to a repository hold the server lock.
+	s.serverMutex.Lock()
+	defer s.serverMutex.Unlock()
+
+	require.NoError(t, s.CheckLiveness(ctx))
+
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	require.Error(t, s.CheckLiveness(cctx))
+
+	s.CloseEventStreams()
+	require.Error(t, s.CheckLiveness(ctx))
+}
+
+func TestCheckLivenessSchedulerHeartbeat(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	s := &Server{eventStreamsDone: make(chan struct{})}
+
+	noItems := func(context.Context, time.Time) []scheduler.Item { return nil }
+
+	sched := scheduler.Start(ctx, noItems, scheduler.Options{})
+	defer sched.Stop()
+
+	s.livenessSched.Store(sched)
+	require.NoError(t, s.CheckLiveness(ctx))
+
+	// scheduler whose last heartbeat is long overdue is reported as stuck.
+	stuck := scheduler.Start(ctx, noItems, scheduler.Options{
+		TimeNow: func() time.Time { return clock.Now().Add(-time.Hour) },
+	})
+	defer stuck.Stop()
+
+	s.livenessSched.Store(stuck)
+	require.ErrorContains(t, s.CheckLiveness(ctx), "scheduler has not made progress")
+}
@@ -154,3 +154,32 @@ func startMaintenanceManager(
 
 	return &m
 }
+
+// takeMaintenanceOwnership makes the user running the server the owner of repository maintenance,
+// so that maintenance is scheduled centrally by the server and clients connected directly to
+// the repository no longer run it themselves.
+func takeMaintenanceOwnership(ctx context.Context, dr repo.DirectRepository) error {
+	if dr.ClientOptions().ReadOnly {
+		return nil
+	}
+
+	return errors.Wrap(repo.DirectWriteSession(ctx, dr, repo.WriteSessionOptions{
+		Purpose: "TakeMaintenanceOwnership",
+	}, func(ctx context.Context, w repo.DirectRepositoryWriter) error {
+		p, err := maintenance.GetParams(ctx, w)
+		if err != nil {
+			return errors.Wrap(err, "unable to get maintenance parameters")
+		}
+
+		owner := w.ClientOptions().UsernameAtHost()
+		if p.Owner == owner {
+			return nil
+		}
+
+		log(ctx).Infof("taking over maintenance ownership from %q to %q", p.Owner, owner)
+
+		p.Owner = owner
+
+		return errors.Wrap(maintenance.SetParams(ctx, w, p), "unable to set maintenance parameters")
+	}), "unable to take maintenance ownership")
+}
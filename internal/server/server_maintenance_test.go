@@ -93,3 +93,27 @@ func TestServerMaintenance(t *testing.T) {
 	// after a failure next maintenance time should be deferred by a minute.
 	require.Greater(t, mm.nextMaintenanceTime().Sub(clock.Now()), 50*time.Second)
 }
+
+func TestTakeMaintenanceOwnership(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+
+	require.NoError(t, repo.DirectWriteSession(ctx, env.RepositoryWriter, repo.WriteSessionOptions{}, func(ctx context.Context, dw repo.DirectRepositoryWriter) error {
+		return maintenance.SetParams(ctx, dw, &maintenance.Params{
+			Owner: "some-client@some-host",
+		})
+	}))
+
+	nmt, err := maintenance.TimeToAttemptNextMaintenance(ctx, env.RepositoryWriter)
+	require.NoError(t, err)
+	require.True(t, nmt.IsZero(), "maintenance is not owned by the server")
+
+	require.NoError(t, takeMaintenanceOwnership(ctx, env.RepositoryWriter))
+	env.MustReopen(t)
+
+	p, err := maintenance.GetParams(ctx, env.Repository)
+	require.NoError(t, err)
+	require.Equal(t, env.Repository.ClientOptions().UsernameAtHost(), p.Owner)
+
+	// taking ownership again is a no-op.
+	require.NoError(t, takeMaintenanceOwnership(ctx, env.RepositoryWriter))
+}
@@ -0,0 +1,262 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/ctxutil"
+	"github.com/kopia/kopia/internal/storagenotify"
+	"github.com/kopia/kopia/internal/uitask"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/content"
+)
+
+const maxStorageNotificationSize = 1 << 20
+
+type storageNotificationState struct {
+	snsCertificates storagenotify.CertificateCache
+
+	deletedPacksMutex sync.Mutex
+	// +checklocks:deletedPacksMutex
+	pendingDeletedPacks map[blob.ID]struct{} // deleted packs waiting to be verified
+	// +checklocks:deletedPacksMutex
+	deletedPacksVerifyRunning bool
+}
+
+// SetupStorageNotificationHandlers registers the endpoint receiving bucket change notifications
+// (S3 events delivered directly or through SNS, GCS Pub/Sub push messages).
+//
+// Notification services can't use the server credentials, so the endpoint is authenticated
+// using a secret token passed in the 'token' query parameter. Messages delivered by SNS
+// must additionally carry a valid SNS signature.
+func (s *Server) SetupStorageNotificationHandlers(m *mux.Router) {
+	m.HandleFunc("/api/v1/storage-notifications", s.handleStorageNotification).Methods(http.MethodPost)
+}
+
+func (s *Server) handleStorageNotification(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	want := s.options.StorageNotificationToken
+	if want == "" || subtle.ConstantTimeCompare([]byte(r.URL.Query().Get("token")), []byte(want)) != 1 {
+		http.Error(w, "access denied", http.StatusForbidden)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxStorageNotificationSize))
+	if err != nil {
+		http.Error(w, "error reading request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := storagenotify.VerifySNSSignature(ctx, body, s.snsCertificates.Fetch); err != nil {
+		log(ctx).Errorf("rejecting storage notification: %v", err)
+		http.Error(w, "invalid signature", http.StatusForbidden)
+
+		return
+	}
+
+	n, err := storagenotify.Parse(body, s.options.StorageNotificationKeyPrefix)
+	if err != nil {
+		log(ctx).Debugf("invalid storage notification: %v", err)
+		http.Error(w, "invalid notification", http.StatusBadRequest)
+
+		return
+	}
+
+	if n.SubscribeURL != "" {
+		if err := confirmSNSSubscription(ctx, n.SubscribeURL); err != nil {
+			log(ctx).Errorf("unable to confirm storage notification subscription: %v", err)
+			http.Error(w, "unable to confirm subscription", http.StatusInternalServerError)
+
+			return
+		}
+
+		log(ctx).Info("Confirmed storage notification subscription.")
+	}
+
+	s.processStorageChanges(ctxutil.Detach(ctx), n.Changes)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// confirmSNSSubscription fetches the subscription confirmation URL, which must point at Amazon SNS.
+func confirmSNSSubscription(ctx context.Context, subscribeURL string) error {
+	u, err := url.Parse(subscribeURL)
+	if err != nil {
+		return errors.Wrap(err, "invalid subscribe URL")
+	}
+
+	if u.Scheme != "https" || !strings.HasSuffix(u.Hostname(), ".amazonaws.com") {
+		return errors.Errorf("refusing to confirm subscription using %v", u.Host)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), http.NoBody)
+	if err != nil {
+		return errors.Wrap(err, "unable to create request")
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "unable to confirm subscription")
+	}
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("unexpected status code %v", resp.Status)
+	}
+
+	return nil
+}
+
+// processStorageChanges reacts to blobs having been added or deleted by parties other than this server:
+// deletion of pack blobs triggers verification that no live contents were stored in them, all other changes
+// (e.g. new indexes written by other clients) trigger a refresh.
+func (s *Server) processStorageChanges(ctx context.Context, changes []storagenotify.Change) {
+	var (
+		deletedPacks []blob.ID
+		needRefresh  bool
+	)
+
+	for _, c := range changes {
+		log(ctx).Debugf("storage change: %v %v", c.Kind, c.BlobID)
+
+		if isPackBlobID(c.BlobID) {
+			if c.Kind == storagenotify.BlobDeleted {
+				deletedPacks = append(deletedPacks, c.BlobID)
+			}
+
+			continue
+		}
+
+		needRefresh = true
+	}
+
+	if needRefresh {
+		s.refreshAsync()
+	}
+
+	if len(deletedPacks) > 0 {
+		s.scheduleDeletedPacksVerification(ctx, deletedPacks)
+	}
+}
+
+// scheduleDeletedPacksVerification adds the provided packs to the set of packs pending verification and
+// starts a verification task unless one is already running, in which case the running task picks them up
+// when it finishes its current batch. This way a burst of notifications results in at most one pending run.
+func (s *Server) scheduleDeletedPacksVerification(ctx context.Context, packs []blob.ID) {
+	s.deletedPacksMutex.Lock()
+	defer s.deletedPacksMutex.Unlock()
+
+	if s.pendingDeletedPacks == nil {
+		s.pendingDeletedPacks = map[blob.ID]struct{}{}
+	}
+
+	for _, p := range packs {
+		s.pendingDeletedPacks[p] = struct{}{}
+	}
+
+	if s.deletedPacksVerifyRunning {
+		return
+	}
+
+	s.deletedPacksVerifyRunning = true
+
+	//nolint:errcheck
+	go s.taskmgr.Run(ctx, "Verify", "Verify contents of deleted pack blobs", func(ctx context.Context, _ uitask.Controller) error {
+		var firstErr error
+
+		for {
+			batch := s.takePendingDeletedPacks()
+			if len(batch) == 0 {
+				return firstErr
+			}
+
+			if err := s.verifyDeletedPacks(ctx, batch); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	})
+}
+
+// takePendingDeletedPacks returns and clears the packs pending verification, marking
+// the verification task as finished when there are none.
+func (s *Server) takePendingDeletedPacks() []blob.ID {
+	s.deletedPacksMutex.Lock()
+	defer s.deletedPacksMutex.Unlock()
+
+	if len(s.pendingDeletedPacks) == 0 {
+		s.deletedPacksVerifyRunning = false
+		return nil
+	}
+
+	var result []blob.ID
+
+	for p := range s.pendingDeletedPacks {
+		result = append(result, p)
+	}
+
+	clear(s.pendingDeletedPacks)
+
+	return result
+}
+
+func isPackBlobID(id blob.ID) bool {
+	return slices.ContainsFunc(content.PackBlobIDPrefixes, func(prefix blob.ID) bool {
+		return strings.HasPrefix(string(id), string(prefix))
+	})
+}
+
+// verifyDeletedPacks returns an error if any contents referenced by the current indexes are stored
+// in the provided pack blobs, which means the packs were removed outside of maintenance.
+func (s *Server) verifyDeletedPacks(ctx context.Context, packs []blob.ID) error {
+	s.serverMutex.RLock()
+	rep := s.rep
+	s.serverMutex.RUnlock()
+
+	dr, ok := rep.(repo.DirectRepository)
+	if !ok {
+		return nil
+	}
+
+	if err := dr.Refresh(ctx); err != nil {
+		return errors.Wrap(err, "unable to refresh repository")
+	}
+
+	deleted := make(map[blob.ID]struct{}, len(packs))
+	for _, p := range packs {
+		deleted[p] = struct{}{}
+	}
+
+	missing := map[blob.ID]int{}
+
+	if err := dr.ContentReader().IterateContents(ctx, content.IterateOptions{}, func(ci content.Info) error {
+		if _, ok := deleted[ci.PackBlobID]; ok {
+			missing[ci.PackBlobID]++
+		}
+
+		return nil
+	}); err != nil {
+		return errors.Wrap(err, "unable to iterate contents")
+	}
+
+	if len(missing) == 0 {
+		return nil
+	}
+
+	for id, cnt := range missing {
+		log(ctx).Errorf("pack blob %v holding %v contents was deleted outside of kopia", id, cnt)
+	}
+
+	return errors.Errorf("%v deleted pack blobs hold contents still in use", len(missing))
+}
@@ -0,0 +1,111 @@
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/apiclient"
+	"github.com/kopia/kopia/internal/repotesting"
+	"github.com/kopia/kopia/internal/server"
+	"github.com/kopia/kopia/internal/servertesting"
+	"github.com/kopia/kopia/internal/uitask"
+	"github.com/kopia/kopia/repo/blob"
+)
+
+const testNotificationToken = "secret-token"
+
+func TestStorageNotifications(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+
+	oid := mustWriteObject(ctx, t, env.RepositoryWriter, []byte("hello world"))
+	require.NoError(t, env.RepositoryWriter.Flush(ctx))
+
+	cid, _, ok := oid.ContentID()
+	require.True(t, ok)
+
+	ci, err := env.RepositoryWriter.ContentInfo(ctx, cid)
+	require.NoError(t, err)
+
+	si := servertesting.StartServerWithOptions(t, env, false, func(o *server.Options) {
+		o.StorageNotificationToken = testNotificationToken
+	})
+
+	cli, err := apiclient.NewKopiaAPIClient(apiclient.Options{
+		BaseURL:  si.BaseURL,
+		Username: servertesting.TestUIUsername,
+		Password: servertesting.TestUIPassword,
+	})
+	require.NoError(t, err)
+	require.NoError(t, cli.FetchCSRFTokenForTesting(ctx))
+
+	// wrong token.
+	require.Equal(t, http.StatusForbidden, postStorageNotification(t, si.BaseURL, "wrong-token", pubSubNotification(t, "OBJECT_DELETE", ci.PackBlobID)))
+
+	// malformed notification.
+	require.Equal(t, http.StatusBadRequest, postStorageNotification(t, si.BaseURL, testNotificationToken, "not-json"))
+
+	// deletion of pack that holds no contents.
+	require.Equal(t, http.StatusNoContent, postStorageNotification(t, si.BaseURL, testNotificationToken, pubSubNotification(t, "OBJECT_DELETE", "pnosuchpack")))
+
+	task := waitForTask(t, cli, mustWaitForNewVerifyTask(t, cli, "").TaskID, 30*time.Second)
+	require.Equal(t, uitask.StatusSuccess, task.Status)
+
+	// deletion of pack holding live contents.
+	require.Equal(t, http.StatusNoContent, postStorageNotification(t, si.BaseURL, testNotificationToken, pubSubNotification(t, "OBJECT_DELETE", ci.PackBlobID)))
+
+	task = waitForTask(t, cli, mustWaitForNewVerifyTask(t, cli, task.TaskID).TaskID, 30*time.Second)
+	require.Equal(t, uitask.StatusFailed, task.Status)
+	require.Contains(t, task.ErrorMessage, "1 deleted pack blobs hold contents still in use")
+}
+
+func pubSubNotification(t *testing.T, eventType string, id blob.ID) string {
+	t.Helper()
+
+	b, err := json.Marshal(map[string]any{
+		"message": map[string]any{
+			"attributes": map[string]string{
+				"eventType": eventType,
+				"objectId":  string(id),
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	return string(b)
+}
+
+func postStorageNotification(t *testing.T, baseURL, token, body string) int {
+	t.Helper()
+
+	resp, err := http.Post(baseURL+"/api/v1/storage-notifications?token="+token, "application/json", strings.NewReader(body)) //nolint:noctx
+	require.NoError(t, err)
+
+	defer resp.Body.Close()
+
+	return resp.StatusCode
+}
+
+// mustWaitForNewVerifyTask waits for a verification task other than the provided one to be started.
+func mustWaitForNewVerifyTask(t *testing.T, cli *apiclient.KopiaAPIClient, previousTaskID string) uitask.Info {
+	t.Helper()
+
+	var result uitask.Info
+
+	require.Eventually(t, func() bool {
+		for _, task := range mustListTasks(t, cli) {
+			if task.Kind == "Verify" && task.TaskID != previousTaskID {
+				result = task
+				return true
+			}
+		}
+
+		return false
+	}, 30*time.Second, 100*time.Millisecond)
+
+	return result
+}
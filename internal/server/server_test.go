@@ -1,9 +1,12 @@
 package server_test
 
 import (
+	"bytes"
 	"context"
 	"io"
+	"mime"
 	"net/http"
+	"net/url"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -15,6 +18,7 @@
 	"github.com/kopia/kopia/internal/servertesting"
 	"github.com/kopia/kopia/internal/testutil"
 	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/compression"
 	"github.com/kopia/kopia/repo/content"
 	"github.com/kopia/kopia/repo/manifest"
 	"github.com/kopia/kopia/repo/object"
@@ -52,6 +56,40 @@ func TestServer(t *testing.T) {
 	remoteRepositoryTest(ctx, t, rep)
 }
 
+func TestServerDefaultCompression(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant, repotesting.Options{
+		NewRepositoryOptions: func(nro *repo.NewRepositoryOptions) {
+			nro.ObjectFormat.DefaultCompression = "zstd"
+		},
+	})
+	apiServerInfo := servertesting.StartServer(t, env, true)
+
+	rep, err := servertesting.ConnectAndOpenAPIServer(t, ctx, apiServerInfo, repo.ClientOptions{
+		Username: servertesting.TestUsername,
+		Hostname: servertesting.TestHostname,
+	}, content.CachingOptions{}, servertesting.TestPassword, &repo.Options{})
+	require.NoError(t, err)
+
+	defer rep.Close(ctx)
+
+	var oid object.ID
+
+	require.NoError(t, repo.WriteSession(ctx, rep, repo.WriteSessionOptions{Purpose: "test"}, func(ctx context.Context, w repo.RepositoryWriter) error {
+		oid = mustWriteObject(ctx, t, w, bytes.Repeat([]byte("compressible"), 1000))
+		return nil
+	}))
+
+	cid, _, ok := oid.ContentID()
+	require.True(t, ok)
+
+	env.MustReopen(t)
+
+	// objects written by the client without an explicit compressor use the repository default.
+	ci, err := env.RepositoryWriter.ContentInfo(ctx, cid)
+	require.NoError(t, err)
+	require.Equal(t, compression.ByName["zstd"].HeaderID(), ci.CompressionHeaderID)
+}
+
 func TestGRPCServer_AuthenticationError(t *testing.T) {
 	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
 	apiServerInfo := servertesting.StartServer(t, env, true)
@@ -154,6 +192,40 @@ func TestServerUIAccessDeniedToRemoteUser(t *testing.T) {
 	}
 }
 
+func TestServerObjectDownload(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+	si := servertesting.StartServer(t, env, true)
+
+	oid := mustWriteObject(ctx, t, env.RepositoryWriter, []byte("hello world"))
+	require.NoError(t, env.RepositoryWriter.Flush(ctx))
+
+	uiUserClient, err := apiclient.NewKopiaAPIClient(apiclient.Options{
+		BaseURL:                             si.BaseURL,
+		TrustedServerCertificateFingerprint: si.TrustedServerCertificateFingerprint,
+		Username:                            servertesting.TestUIUsername,
+		Password:                            servertesting.TestUIPassword,
+	})
+	require.NoError(t, err)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, si.BaseURL+"/api/v1/objects/"+oid.String()+"?fname="+url.QueryEscape(`some "file".txt`), http.NoBody)
+	require.NoError(t, err)
+
+	resp, err := uiUserClient.HTTPClient.Do(req)
+	require.NoError(t, err)
+
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	_, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition"))
+	require.NoError(t, err)
+	require.Equal(t, `some "file".txt`, params["filename"])
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, "hello world", string(body))
+}
+
 //nolint:thelper
 func remoteRepositoryTest(ctx context.Context, t *testing.T, rep repo.Repository) {
 	mustListSnapshotCount(ctx, t, rep, 0)
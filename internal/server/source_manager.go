@@ -2,6 +2,7 @@
 
 import (
 	"context"
+	"reflect"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -10,10 +11,13 @@
 
 	"github.com/kopia/kopia/fs"
 	"github.com/kopia/kopia/fs/localfs"
+	"github.com/kopia/kopia/internal/auditlog"
 	"github.com/kopia/kopia/internal/clock"
 	"github.com/kopia/kopia/internal/ctxutil"
 	"github.com/kopia/kopia/internal/serverapi"
 	"github.com/kopia/kopia/internal/uitask"
+	"github.com/kopia/kopia/notification"
+	"github.com/kopia/kopia/notification/healthcheck"
 	"github.com/kopia/kopia/repo"
 	"github.com/kopia/kopia/snapshot"
 	"github.com/kopia/kopia/snapshot/policy"
@@ -22,6 +26,7 @@
 
 const (
 	failedSnapshotRetryInterval = 5 * time.Minute
+	missedSnapshotThreshold     = 30 * time.Minute // how late a scheduled snapshot may start before it's reported as missed
 	refreshTimeout              = 30 * time.Second // max amount of time to refresh a single source
 	oneDay                      = 24 * time.Hour
 )
@@ -29,6 +34,7 @@
 type sourceManagerServerInterface interface {
 	runSnapshotTask(ctx context.Context, src snapshot.SourceInfo, inner func(ctx context.Context, ctrl uitask.Controller) error) error
 	refreshScheduler(reason string)
+	auditLog() *auditlog.Logger
 }
 
 // sourceManager manages the state machine of each source
@@ -60,6 +66,12 @@ type sourceManager struct {
 	// +checklocks:sourceMutex
 	nextSnapshotTime *time.Time
 	// +checklocks:sourceMutex
+	cyclePreviousSnapshotTime fs.UTCTimestamp // previous snapshot time the current scheduling cycle is based on
+	// +checklocks:sourceMutex
+	cyclePolicy policy.SchedulingPolicy // scheduling policy the current scheduling cycle is based on
+	// +checklocks:sourceMutex
+	cycleSnapshotTime *time.Time // next snapshot time with jitter applied, computed once per scheduling cycle
+	// +checklocks:sourceMutex
 	lastSnapshot *snapshot.Manifest
 	// +checklocks:sourceMutex
 	lastCompleteSnapshot *snapshot.Manifest
@@ -194,7 +206,27 @@ func (s *sourceManager) runLocal(ctx context.Context) {
 
 				log(ctx).Debugw("snapshotting", "source", s.src)
 
-				if err := s.server.runSnapshotTask(ctx, s.src, s.snapshotInternal); err != nil {
+				s.sourceMutex.RLock()
+				hcURL := s.pol.HealthCheckURL
+				s.sourceMutex.RUnlock()
+
+				healthcheck.Start(ctx, hcURL)
+				notification.SendForSource(ctx, s.rep, s.src, notification.SnapshotStarted(s.src))
+
+				err := s.server.runSnapshotTask(ctx, s.src, s.snapshotInternal)
+
+				healthcheck.Finish(ctx, hcURL, err)
+
+				s.server.auditLog().Emit(ctx, auditlog.Event{
+					Action: auditlog.ActionSnapshot,
+					Source: s.src.String(),
+					Error:  auditlog.ErrorString(err),
+				})
+
+				notification.SendForSource(ctx, s.rep, s.src, notification.SnapshotResult(s.src, err))
+				notification.CheckFreeSpace(ctx, s.rep)
+
+				if err != nil {
 					log(ctx).Errorf("snapshot error: %v", err)
 
 					s.backoffBeforeNextSnapshot()
@@ -239,6 +271,16 @@ func (s *sourceManager) scheduleSnapshotNow() {
 	}
 }
 
+// scheduledSnapshotDue is invoked by the scheduler when the snapshot scheduled at the provided time is due.
+func (s *sourceManager) scheduledSnapshotDue(ctx context.Context, scheduled time.Time) {
+	if late := clock.Now().Sub(scheduled); late > missedSnapshotThreshold {
+		log(ctx).Warnf("scheduled snapshot of %v is %v late", s.src, late.Truncate(time.Second))
+		notification.SendForSource(ctx, s.rep, s.src, notification.SnapshotMissed(s.src, scheduled, late))
+	}
+
+	s.scheduleSnapshotNow()
+}
+
 func (s *sourceManager) upload(ctx context.Context) serverapi.SourceActionResponse {
 	log(ctx).Infof("upload triggered via API: %v", s.src)
 	s.scheduleSnapshotNow()
@@ -391,8 +433,8 @@ func (s *sourceManager) snapshotInternal(ctx context.Context, ctrl uitask.Contro
 	})
 }
 
-// +checklocksread:s.sourceMutex
-func (s *sourceManager) findClosestNextSnapshotTimeReadLocked() *time.Time {
+// +checklocks:s.sourceMutex
+func (s *sourceManager) findClosestNextSnapshotTimeLocked() *time.Time {
 	var previousSnapshotTime fs.UTCTimestamp
 	if lcs := s.lastCompleteSnapshot; lcs != nil {
 		previousSnapshotTime = lcs.StartTime
@@ -403,12 +445,34 @@ func (s *sourceManager) findClosestNextSnapshotTimeReadLocked() *time.Time {
 		previousSnapshotTime = s.lastAttemptedSnapshotTime
 	}
 
-	t, ok := s.pol.NextSnapshotTime(previousSnapshotTime.ToTime(), clock.Now())
+	// reuse the time computed for this cycle, otherwise refreshing the status would pick
+	// a new jittered time or run the snapshot immediately once the nominal time has passed.
+	if s.cycleSnapshotTime != nil && s.cyclePreviousSnapshotTime == previousSnapshotTime && reflect.DeepEqual(s.cyclePolicy, s.pol) {
+		t := *s.cycleSnapshotTime
+		return &t
+	}
+
+	s.cycleSnapshotTime = nil
+
+	now := clock.Now()
+
+	t, ok := s.pol.NextSnapshotTime(previousSnapshotTime.ToTime(), now)
 	if !ok {
 		return nil
 	}
 
-	return &t
+	// spread out scheduled snapshots, but don't delay snapshots that are already due.
+	if t.After(now) {
+		t = s.pol.ApplyJitter(t, s.src.String())
+	}
+
+	s.cyclePreviousSnapshotTime = previousSnapshotTime
+	s.cyclePolicy = s.pol
+	s.cycleSnapshotTime = &t
+
+	result := t
+
+	return &result
 }
 
 func (s *sourceManager) refreshStatus(ctx context.Context) {
@@ -452,8 +516,9 @@ func (s *sourceManager) refreshStatus(ctx context.Context) {
 
 	if s.paused {
 		s.nextSnapshotTime = nil
+		s.cycleSnapshotTime = nil
 	} else {
-		s.nextSnapshotTime = s.findClosestNextSnapshotTimeReadLocked()
+		s.nextSnapshotTime = s.findClosestNextSnapshotTimeLocked()
 	}
 }
 
@@ -0,0 +1,55 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/internal/clock"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/policy"
+)
+
+func TestFindClosestNextSnapshotTimeOncePerCycle(t *testing.T) {
+	s := &sourceManager{
+		src: snapshot.SourceInfo{Host: "host", UserName: "user", Path: "/path"},
+	}
+
+	s.sourceMutex.Lock()
+	defer s.sourceMutex.Unlock()
+
+	s.pol.SetInterval(time.Hour)
+	s.pol.SetJitter(30 * time.Minute)
+
+	// the nominal snapshot time has passed, so each computation would return the current time.
+	s.lastAttemptedSnapshotTime = fs.UTCTimestamp(clock.Now().Add(-2 * time.Hour).UnixNano())
+
+	t1 := s.findClosestNextSnapshotTimeLocked()
+	require.NotNil(t, t1)
+
+	time.Sleep(10 * time.Millisecond)
+
+	t2 := s.findClosestNextSnapshotTimeLocked()
+	require.NotNil(t, t2)
+	require.Equal(t, *t1, *t2)
+
+	// a new snapshot attempt starts a new cycle.
+	s.lastAttemptedSnapshotTime = fs.UTCTimestamp(clock.Now().UnixNano())
+
+	t3 := s.findClosestNextSnapshotTimeLocked()
+	require.NotNil(t, t3)
+	require.True(t, t3.After(*t1))
+	require.Equal(t, *t3, *s.findClosestNextSnapshotTimeLocked())
+
+	// so does changing the scheduling policy.
+	s.pol.SetJitter(0)
+
+	t4 := s.findClosestNextSnapshotTimeLocked()
+	require.NotNil(t, t4)
+	require.False(t, t4.After(s.lastAttemptedSnapshotTime.ToTime().Add(time.Hour)))
+
+	s.pol = policy.SchedulingPolicy{Manual: true}
+	require.Nil(t, s.findClosestNextSnapshotTimeLocked())
+}
@@ -6,6 +6,7 @@
 	"time"
 
 	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/internal/cryptobackend"
 	"github.com/kopia/kopia/internal/uitask"
 	"github.com/kopia/kopia/repo"
 	"github.com/kopia/kopia/repo/blob"
@@ -32,6 +33,7 @@ type StatusResponse struct {
 	Storage                    string         `json:"storage,omitempty"`
 	APIServerURL               string         `json:"apiServerURL,omitempty"`
 	SupportsContentCompression bool           `json:"supportsContentCompression"`
+	Capacity                   *blob.Capacity `json:"capacity,omitempty"`
 
 	repo.ClientOptions
 
@@ -76,6 +78,15 @@ type PoliciesResponse struct {
 // Empty represents empty request/response.
 type Empty struct{}
 
+// BlobListItem is a single line of the newline-delimited JSON stream returned when listing blobs.
+// The stream is terminated by an item with Done or Error set, which allows clients to detect
+// truncated listings.
+type BlobListItem struct {
+	Blob  *blob.Metadata `json:"blob,omitempty"`
+	Done  bool           `json:"done,omitempty"`
+	Error string         `json:"error,omitempty"`
+}
+
 // APIErrorCode indicates machine-readable error code returned in API responses.
 type APIErrorCode string
 
@@ -150,6 +161,9 @@ type SupportedAlgorithmsResponse struct {
 	SupportedECCAlgorithms         []AlgorithmInfo `json:"ecc"`
 	SupportedSplitterAlgorithms    []AlgorithmInfo `json:"splitter"`
 	SupportedCompressionAlgorithms []AlgorithmInfo `json:"compression"`
+
+	// CryptoBackend describes hardware acceleration and the hash and encryption selected when automatic selection is requested.
+	CryptoBackend cryptobackend.Info `json:"cryptoBackend"`
 }
 
 // CreateSnapshotSourceRequest contains request to create snapshot source and optionally create first snapshot.
@@ -232,6 +246,22 @@ type TaskListResponse struct {
 	Tasks []uitask.Info `json:"tasks"`
 }
 
+// Types of events delivered by the server event stream.
+const (
+	EventTypeTask   = "task"   // task was started, made progress or finished
+	EventTypeSource = "source" // source status or upload progress has changed
+	EventTypeError  = "error"  // task has failed
+)
+
+// Event is a single notification delivered by the server event stream (/api/v1/events),
+// formatted as a server-sent event whose name is the event type and whose data is the JSON-encoded Event.
+type Event struct {
+	Type   string        `json:"type"`
+	Task   *uitask.Info  `json:"task,omitempty"`
+	Source *SourceStatus `json:"source,omitempty"`
+	Error  string        `json:"error,omitempty"`
+}
+
 // TaskLogResponse contains a task log.
 type TaskLogResponse struct {
 	Logs []json.RawMessage `json:"logs"` // formatted as uitask.LogEntry
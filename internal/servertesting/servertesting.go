@@ -36,9 +36,16 @@
 func StartServer(t *testing.T, env *repotesting.Environment, tls bool) *repo.APIServerInfo {
 	t.Helper()
 
+	return StartServerWithOptions(t, env, tls, nil)
+}
+
+// StartServerWithOptions starts a test server whose options have been customized by the provided function and returns APIServerInfo.
+func StartServerWithOptions(t *testing.T, env *repotesting.Environment, tls bool, customize func(o *server.Options)) *repo.APIServerInfo {
+	t.Helper()
+
 	ctx := testlogging.Context(t)
 
-	s, err := server.New(ctx, &server.Options{
+	opts := &server.Options{
 		ConfigFile:      env.ConfigFile(),
 		PasswordPersist: passwordpersist.File(),
 		Authorizer:      auth.LegacyAuthorizer(),
@@ -49,7 +56,13 @@ func StartServer(t *testing.T, env *repotesting.Environment, tls bool) *repo.API
 		RefreshInterval:   1 * time.Minute,
 		UIUser:            TestUIUsername,
 		UIPreferencesFile: filepath.Join(testutil.TempDirectory(t), "ui-pref.json"),
-	})
+	}
+
+	if customize != nil {
+		customize(opts)
+	}
+
+	s, err := server.New(ctx, opts)
 
 	require.NoError(t, err)
 
@@ -65,6 +78,8 @@ func StartServer(t *testing.T, env *repotesting.Environment, tls bool) *repo.API
 	m := mux.NewRouter()
 	s.SetupHTMLUIAPIHandlers(m)
 	s.SetupControlAPIHandlers(m)
+	s.SetupStorageNotificationHandlers(m)
+	s.SetupBlobProxyHandlers(m)
 	s.ServeStaticFiles(m, server.AssetFile())
 
 	hs := httptest.NewUnstartedServer(s.GRPCRouterHandler(m))
@@ -82,6 +97,7 @@ func StartServer(t *testing.T, env *repotesting.Environment, tls bool) *repo.API
 	asi.LocalCacheKeyDerivationAlgorithm = repo.DefaultServerRepoCacheKeyDerivationAlgorithm
 
 	t.Cleanup(hs.Close)
+	t.Cleanup(s.CloseEventStreams)
 
 	return asi
 }
@@ -0,0 +1,158 @@
+package snapshotbench
+
+import (
+	"bufio"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// Corpus identifies a kind of synthetic dataset.
+type Corpus string
+
+// Supported corpora.
+const (
+	CorpusManySmallFiles      Corpus = "many-small-files"
+	CorpusLargeCompressible   Corpus = "large-compressible"
+	CorpusLargeIncompressible Corpus = "large-incompressible"
+)
+
+// Corpora lists all supported corpora.
+//
+//nolint:gochecknoglobals
+var Corpora = []Corpus{CorpusManySmallFiles, CorpusLargeCompressible, CorpusLargeIncompressible}
+
+const (
+	smallFileMaxSize   = 16 << 10
+	smallFilesPerDir   = 100
+	largeFileMaxSize   = 64 << 20
+	generateBufferSize = 1 << 20
+
+	dirPermissions  = 0o700
+	filePermissions = 0o600
+)
+
+// words used to produce compressible text.
+//
+//nolint:gochecknoglobals
+var words = []string{
+	"alpha", "bravo", "charlie", "delta", "echo", "foxtrot", "golf", "hotel", "india", "juliet",
+	"kilo", "lima", "mike", "november", "oscar", "papa", "quebec", "romeo", "sierra", "tango",
+	"uniform", "victor", "whiskey", "xray", "yankee", "zulu",
+}
+
+// CorpusStats describes generated dataset.
+type CorpusStats struct {
+	FileCount  int
+	TotalBytes int64
+}
+
+// Generate writes a reproducible dataset of the provided kind and approximate total size to the provided
+// directory, which is created if it does not exist. The same seed always produces identical data.
+func Generate(dir string, corpus Corpus, totalSize, seed int64) (CorpusStats, error) {
+	var stats CorpusStats
+
+	rnd := rand.New(rand.NewSource(seed)) //nolint:gosec
+
+	var (
+		maxFileSize int64
+		fill        func(w *bufio.Writer, length int64) error
+	)
+
+	switch corpus {
+	case CorpusManySmallFiles:
+		maxFileSize = smallFileMaxSize
+		fill = func(w *bufio.Writer, length int64) error { return fillRandom(rnd, w, length) }
+
+	case CorpusLargeCompressible:
+		maxFileSize = largeFileMaxSize
+		fill = func(w *bufio.Writer, length int64) error { return fillText(rnd, w, length) }
+
+	case CorpusLargeIncompressible:
+		maxFileSize = largeFileMaxSize
+		fill = func(w *bufio.Writer, length int64) error { return fillRandom(rnd, w, length) }
+
+	default:
+		return stats, errors.Errorf("unknown corpus: %q", corpus)
+	}
+
+	for stats.TotalBytes < totalSize {
+		length := min(totalSize-stats.TotalBytes, maxFileSize)
+		if corpus == CorpusManySmallFiles {
+			length = min(length, 1+rnd.Int63n(maxFileSize))
+		}
+
+		subdir := filepath.Join(dir, fmt.Sprintf("d%04d", stats.FileCount/smallFilesPerDir))
+		if err := os.MkdirAll(subdir, dirPermissions); err != nil {
+			return stats, errors.Wrap(err, "unable to create directory")
+		}
+
+		if err := writeFile(filepath.Join(subdir, fmt.Sprintf("f%06d", stats.FileCount)), length, fill); err != nil {
+			return stats, err
+		}
+
+		stats.FileCount++
+		stats.TotalBytes += length
+	}
+
+	return stats, nil
+}
+
+func writeFile(fname string, length int64, fill func(w *bufio.Writer, length int64) error) error {
+	f, err := os.OpenFile(fname, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, filePermissions) //nolint:gosec
+	if err != nil {
+		return errors.Wrap(err, "unable to create file")
+	}
+
+	defer f.Close() //nolint:errcheck
+
+	w := bufio.NewWriterSize(f, generateBufferSize)
+
+	if err := fill(w, length); err != nil {
+		return errors.Wrapf(err, "error writing %v", fname)
+	}
+
+	if err := w.Flush(); err != nil {
+		return errors.Wrapf(err, "error writing %v", fname)
+	}
+
+	return errors.Wrapf(f.Close(), "error closing %v", fname)
+}
+
+func fillRandom(rnd *rand.Rand, w *bufio.Writer, length int64) error {
+	buf := make([]byte, min(length, generateBufferSize))
+
+	for length > 0 {
+		n := min(length, int64(len(buf)))
+
+		rnd.Read(buf[:n])
+
+		if _, err := w.Write(buf[:n]); err != nil {
+			return errors.Wrap(err, "write error")
+		}
+
+		length -= n
+	}
+
+	return nil
+}
+
+func fillText(rnd *rand.Rand, w *bufio.Writer, length int64) error {
+	for length > 0 {
+		s := words[rnd.Intn(len(words))] + " "
+		if int64(len(s)) > length {
+			s = s[:length]
+		}
+
+		if _, err := w.WriteString(s); err != nil {
+			return errors.Wrap(err, "write error")
+		}
+
+		length -= int64(len(s))
+	}
+
+	return nil
+}
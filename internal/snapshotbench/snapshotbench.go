@@ -0,0 +1,205 @@
+// Package snapshotbench measures end-to-end snapshot and restore throughput over reproducible synthetic datasets.
+package snapshotbench
+
+import (
+	"context"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/fs/localfs"
+	"github.com/kopia/kopia/internal/timetrack"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/blob/filesystem"
+	"github.com/kopia/kopia/repo/compression"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/policy"
+	"github.com/kopia/kopia/snapshot/restore"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+)
+
+const (
+	benchmarkPassword      = "benchmark-password"
+	defaultRestoreParallel = 8
+)
+
+// Options controls the benchmark.
+type Options struct {
+	Corpus      Corpus
+	TotalSize   int64
+	Seed        int64
+	Compression compression.Name
+
+	// RestoreParallel is the restore parallelism, 0 == default.
+	RestoreParallel int
+}
+
+// Result contains the result of a single benchmark run.
+type Result struct {
+	FileCount        int
+	TotalBytes       int64
+	RepositoryBytes  int64
+	SnapshotDuration time.Duration
+	RestoreDuration  time.Duration
+}
+
+// SnapshotThroughput returns the snapshot throughput in bytes per second.
+func (r Result) SnapshotThroughput() float64 {
+	return throughput(r.TotalBytes, r.SnapshotDuration)
+}
+
+// RestoreThroughput returns the restore throughput in bytes per second.
+func (r Result) RestoreThroughput() float64 {
+	return throughput(r.TotalBytes, r.RestoreDuration)
+}
+
+func throughput(n int64, dur time.Duration) float64 {
+	if dur <= 0 {
+		return 0
+	}
+
+	return float64(n) / dur.Seconds()
+}
+
+// Run generates the dataset described by the options in a subdirectory of workDir and measures
+// snapshot and restore throughput using a new repository in another subdirectory of workDir.
+func Run(ctx context.Context, workDir string, opt Options) (Result, error) {
+	sourceDir := filepath.Join(workDir, "source")
+
+	if _, err := Generate(sourceDir, opt.Corpus, opt.TotalSize, opt.Seed); err != nil {
+		return Result{}, errors.Wrap(err, "unable to generate corpus")
+	}
+
+	return Measure(ctx, sourceDir, filepath.Join(workDir, "run"), opt)
+}
+
+// Measure snapshots sourceDir into a new filesystem repository created under workDir, restores it to
+// another directory under workDir and returns the timings. workDir must not contain a previous run.
+// Options describing the corpus are ignored.
+func Measure(ctx context.Context, sourceDir, workDir string, opt Options) (Result, error) {
+	var res Result
+
+	repoDir := filepath.Join(workDir, "repo")
+	restoreDir := filepath.Join(workDir, "restore")
+	configFile := filepath.Join(workDir, "repository.config")
+
+	st, err := filesystem.New(ctx, &filesystem.Options{Path: repoDir}, true)
+	if err != nil {
+		return res, errors.Wrap(err, "unable to create storage")
+	}
+
+	if err = repo.Initialize(ctx, st, &repo.NewRepositoryOptions{}, benchmarkPassword); err != nil {
+		return res, errors.Wrap(err, "unable to initialize repository")
+	}
+
+	if err = repo.Connect(ctx, configFile, st, benchmarkPassword, nil); err != nil {
+		return res, errors.Wrap(err, "unable to connect to repository")
+	}
+
+	rep, err := repo.Open(ctx, configFile, benchmarkPassword, nil)
+	if err != nil {
+		return res, errors.Wrap(err, "unable to open repository")
+	}
+
+	defer rep.Close(ctx) //nolint:errcheck
+
+	man, err := snapshotDirectory(ctx, rep, sourceDir, opt.Compression, &res)
+	if err != nil {
+		return res, err
+	}
+
+	if err = restoreSnapshot(ctx, rep, man, restoreDir, opt.RestoreParallel, &res); err != nil {
+		return res, err
+	}
+
+	if err = st.ListBlobs(ctx, "", func(bm blob.Metadata) error {
+		res.RepositoryBytes += bm.Length
+		return nil
+	}); err != nil {
+		return res, errors.Wrap(err, "unable to list blobs")
+	}
+
+	return res, nil
+}
+
+func snapshotDirectory(ctx context.Context, rep repo.Repository, sourceDir string, comp compression.Name, res *Result) (*snapshot.Manifest, error) {
+	source, err := localfs.Directory(sourceDir)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to open source directory")
+	}
+
+	policyTree := policy.BuildTree(map[string]*policy.Policy{
+		".": {
+			CompressionPolicy: policy.CompressionPolicy{
+				CompressorName: comp,
+			},
+		},
+	}, policy.DefaultPolicy)
+
+	var man *snapshot.Manifest
+
+	timer := timetrack.StartTimer()
+
+	if err := repo.WriteSession(ctx, rep, repo.WriteSessionOptions{Purpose: "benchmark"}, func(ctx context.Context, w repo.RepositoryWriter) error {
+		var uerr error
+
+		man, uerr = snapshotfs.NewUploader(w).Upload(ctx, source, policyTree, snapshot.SourceInfo{Path: sourceDir})
+
+		return errors.Wrap(uerr, "upload error")
+	}); err != nil {
+		return nil, errors.Wrap(err, "unable to snapshot")
+	}
+
+	res.SnapshotDuration = timer.Elapsed()
+	res.FileCount = int(man.Stats.TotalFileCount)
+	res.TotalBytes = man.Stats.TotalFileSize
+
+	return man, nil
+}
+
+func restoreSnapshot(ctx context.Context, rep repo.Repository, man *snapshot.Manifest, restoreDir string, parallel int, res *Result) error {
+	if err := os.MkdirAll(restoreDir, dirPermissions); err != nil {
+		return errors.Wrap(err, "unable to create restore directory")
+	}
+
+	root, err := snapshotfs.SnapshotRoot(rep, man)
+	if err != nil {
+		return errors.Wrap(err, "unable to open snapshot root")
+	}
+
+	out := &restore.FilesystemOutput{
+		TargetPath:           restoreDir,
+		OverwriteDirectories: true,
+		SkipOwners:           true,
+	}
+
+	if err = out.Init(ctx); err != nil {
+		return errors.Wrap(err, "unable to initialize restore output")
+	}
+
+	if parallel <= 0 {
+		parallel = defaultRestoreParallel
+	}
+
+	timer := timetrack.StartTimer()
+
+	st, err := restore.Entry(ctx, rep, out, root, restore.Options{
+		Parallel:               parallel,
+		RestoreDirEntryAtDepth: math.MaxInt32,
+	})
+	if err != nil {
+		return errors.Wrap(err, "unable to restore")
+	}
+
+	res.RestoreDuration = timer.Elapsed()
+
+	if st.RestoredTotalFileSize != res.TotalBytes {
+		return errors.Errorf("restored %v bytes, expected %v", st.RestoredTotalFileSize, res.TotalBytes)
+	}
+
+	return nil
+}
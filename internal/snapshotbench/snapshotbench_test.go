@@ -0,0 +1,111 @@
+package snapshotbench_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/snapshotbench"
+	"github.com/kopia/kopia/internal/testlogging"
+	"github.com/kopia/kopia/internal/testutil"
+)
+
+func TestGenerateIsReproducible(t *testing.T) {
+	for _, corpus := range snapshotbench.Corpora {
+		t.Run(string(corpus), func(t *testing.T) {
+			dir1 := testutil.TempDirectory(t)
+			dir2 := testutil.TempDirectory(t)
+
+			cs1, err := snapshotbench.Generate(dir1, corpus, 100000, 1)
+			require.NoError(t, err)
+			require.EqualValues(t, 100000, cs1.TotalBytes)
+
+			cs2, err := snapshotbench.Generate(dir2, corpus, 100000, 1)
+			require.NoError(t, err)
+			require.Equal(t, cs1, cs2)
+
+			require.Equal(t, readTree(t, dir1), readTree(t, dir2))
+		})
+	}
+}
+
+func TestGenerateUnknownCorpus(t *testing.T) {
+	_, err := snapshotbench.Generate(testutil.TempDirectory(t), "no-such-corpus", 1000, 1)
+	require.ErrorContains(t, err, "unknown corpus")
+}
+
+func TestRun(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	for _, corpus := range snapshotbench.Corpora {
+		t.Run(string(corpus), func(t *testing.T) {
+			res, err := snapshotbench.Run(ctx, testutil.TempDirectory(t), snapshotbench.Options{
+				Corpus:      corpus,
+				TotalSize:   200000,
+				Seed:        1,
+				Compression: "zstd",
+			})
+			require.NoError(t, err)
+			require.EqualValues(t, 200000, res.TotalBytes)
+			require.Positive(t, res.FileCount)
+			require.Positive(t, res.RepositoryBytes)
+			require.Positive(t, res.SnapshotThroughput())
+			require.Positive(t, res.RestoreThroughput())
+		})
+	}
+}
+
+func BenchmarkSnapshotRestore(b *testing.B) {
+	ctx := testlogging.Context(b)
+
+	for _, corpus := range snapshotbench.Corpora {
+		b.Run(string(corpus), func(b *testing.B) {
+			sourceDir := testutil.TempDirectory(b)
+
+			cs, err := snapshotbench.Generate(sourceDir, corpus, 64<<20, 1)
+			require.NoError(b, err)
+
+			b.SetBytes(cs.TotalBytes)
+			b.ResetTimer()
+
+			var snapshotMBps, restoreMBps float64
+
+			for i := range b.N {
+				res, err := snapshotbench.Measure(ctx, sourceDir, filepath.Join(testutil.TempDirectory(b), fmt.Sprintf("run%v", i)), snapshotbench.Options{})
+				require.NoError(b, err)
+
+				snapshotMBps += res.SnapshotThroughput() / 1e6
+				restoreMBps += res.RestoreThroughput() / 1e6
+			}
+
+			b.ReportMetric(snapshotMBps/float64(b.N), "snapshot-MB/s")
+			b.ReportMetric(restoreMBps/float64(b.N), "restore-MB/s")
+		})
+	}
+}
+
+func readTree(t *testing.T, dir string) map[string][]byte {
+	t.Helper()
+
+	result := map[string][]byte{}
+
+	require.NoError(t, filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		result[rel], err = os.ReadFile(path) //nolint:gosec
+
+		return err
+	}))
+
+	return result
+}
@@ -0,0 +1,211 @@
+package storagenotify
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha1" //nolint:gosec
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	maxSigningCertSize      = 64 << 10
+	signingCertFetchTimeout = 30 * time.Second
+)
+
+// ErrInvalidSignature is returned when the signature of an SNS message can't be verified.
+var ErrInvalidSignature = errors.New("invalid SNS message signature")
+
+// signing certificates are only accepted from SNS endpoints.
+var snsCertHostRegexp = regexp.MustCompile(`^sns\.[a-z0-9\-]+\.amazonaws\.com(\.cn)?$`)
+
+// CertificateFetcher returns the certificate at the provided URL.
+type CertificateFetcher func(ctx context.Context, certURL string) (*x509.Certificate, error)
+
+type signedSNSMessage struct {
+	Type             string  `json:"Type"`
+	MessageID        string  `json:"MessageId"`
+	Token            string  `json:"Token"`
+	TopicArn         string  `json:"TopicArn"`
+	Subject          *string `json:"Subject"`
+	Message          string  `json:"Message"`
+	SubscribeURL     string  `json:"SubscribeURL"`
+	Timestamp        string  `json:"Timestamp"`
+	SignatureVersion string  `json:"SignatureVersion"`
+	Signature        string  `json:"Signature"`
+	SigningCertURL   string  `json:"SigningCertURL"`
+}
+
+// stringToSign returns the canonical form of the message signed by SNS.
+func (m *signedSNSMessage) stringToSign() string {
+	var sb strings.Builder
+
+	add := func(k, v string) {
+		sb.WriteString(k + "\n" + v + "\n")
+	}
+
+	add("Message", m.Message)
+	add("MessageId", m.MessageID)
+
+	if m.Type == "Notification" {
+		if m.Subject != nil {
+			add("Subject", *m.Subject)
+		}
+	} else {
+		add("SubscribeURL", m.SubscribeURL)
+	}
+
+	add("Timestamp", m.Timestamp)
+
+	if m.Type != "Notification" {
+		add("Token", m.Token)
+	}
+
+	add("TopicArn", m.TopicArn)
+	add("Type", m.Type)
+
+	return sb.String()
+}
+
+// VerifySNSSignature verifies the signature of a message delivered by Amazon SNS using the certificate
+// referenced by the message. Notifications in other formats are not signed and are accepted as is.
+func VerifySNSSignature(ctx context.Context, payload []byte, fetch CertificateFetcher) error {
+	var top map[string]json.RawMessage
+
+	// malformed notifications are rejected by Parse().
+	if err := json.Unmarshal(payload, &top); err != nil {
+		return nil //nolint:nilerr
+	}
+
+	if _, ok := top["Type"]; !ok {
+		return nil
+	}
+
+	var m signedSNSMessage
+
+	if err := json.Unmarshal(payload, &m); err != nil {
+		return errors.Wrap(err, "malformed SNS message")
+	}
+
+	var (
+		hash   crypto.Hash
+		digest []byte
+	)
+
+	switch m.SignatureVersion {
+	case "1":
+		h := sha1.Sum([]byte(m.stringToSign())) //nolint:gosec
+		hash, digest = crypto.SHA1, h[:]
+	case "2":
+		h := sha256.Sum256([]byte(m.stringToSign()))
+		hash, digest = crypto.SHA256, h[:]
+	default:
+		return errors.Wrapf(ErrInvalidSignature, "unsupported signature version %q", m.SignatureVersion)
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(m.Signature)
+	if err != nil {
+		return errors.Wrap(ErrInvalidSignature, "malformed signature")
+	}
+
+	u, err := url.Parse(m.SigningCertURL)
+	if err != nil || u.Scheme != "https" || !snsCertHostRegexp.MatchString(u.Hostname()) {
+		return errors.Wrapf(ErrInvalidSignature, "untrusted signing certificate URL %q", m.SigningCertURL)
+	}
+
+	cert, err := fetch(ctx, u.String())
+	if err != nil {
+		return errors.Wrap(err, "unable to get signing certificate")
+	}
+
+	pub, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return errors.Wrap(ErrInvalidSignature, "signing certificate does not have an RSA key")
+	}
+
+	if err := rsa.VerifyPKCS1v15(pub, hash, digest, sig); err != nil {
+		return errors.Wrap(ErrInvalidSignature, err.Error())
+	}
+
+	return nil
+}
+
+// CertificateCache fetches SNS signing certificates over HTTPS and caches them by URL.
+type CertificateCache struct {
+	Client *http.Client // nil == default client with a timeout
+
+	mu sync.Mutex
+	// +checklocks:mu
+	certs map[string]*x509.Certificate
+}
+
+// Fetch implements CertificateFetcher.
+func (c *CertificateCache) Fetch(ctx context.Context, certURL string) (*x509.Certificate, error) {
+	c.mu.Lock()
+	cert := c.certs[certURL]
+	c.mu.Unlock()
+
+	if cert != nil {
+		return cert, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, certURL, http.NoBody)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create request")
+	}
+
+	client := c.Client
+	if client == nil {
+		client = &http.Client{Timeout: signingCertFetchTimeout}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to fetch certificate")
+	}
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unexpected status code %v", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSigningCertSize))
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read certificate")
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("certificate is not PEM-encoded")
+	}
+
+	cert, err = x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse certificate")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.certs == nil {
+		c.certs = map[string]*x509.Certificate{}
+	}
+
+	c.certs[certURL] = cert
+
+	return cert, nil
+}
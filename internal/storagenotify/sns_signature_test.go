@@ -0,0 +1,91 @@
+package storagenotify_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"maps"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/storagenotify"
+	"github.com/kopia/kopia/internal/testlogging"
+)
+
+const testSigningCertURL = "https://sns.us-east-1.amazonaws.com/SimpleNotificationService-test.pem"
+
+func TestVerifySNSSignature(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "sns.amazonaws.com"},
+		NotBefore:    time.Unix(0, 0),
+		NotAfter:     time.Unix(0, 0).Add(100 * 365 * 24 * time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	fetch := func(_ context.Context, certURL string) (*x509.Certificate, error) {
+		require.Equal(t, testSigningCertURL, certURL)
+		return cert, nil
+	}
+
+	msg := map[string]string{
+		"Type":             "Notification",
+		"MessageId":        "message-id",
+		"TopicArn":         "arn:aws:sns:us-east-1:123456789012:topic",
+		"Message":          s3EventPayload,
+		"Timestamp":        "2026-01-01T00:00:00.000Z",
+		"SignatureVersion": "2",
+		"SigningCertURL":   testSigningCertURL,
+	}
+
+	digest := sha256.Sum256([]byte("Message\n" + msg["Message"] + "\nMessageId\n" + msg["MessageId"] + "\nTimestamp\n" + msg["Timestamp"] + "\nTopicArn\n" + msg["TopicArn"] + "\nType\nNotification\n"))
+
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	require.NoError(t, err)
+
+	msg["Signature"] = base64.StdEncoding.EncodeToString(sig)
+
+	marshal := func(m map[string]string) []byte {
+		b, err := json.Marshal(m)
+		require.NoError(t, err)
+
+		return b
+	}
+
+	require.NoError(t, storagenotify.VerifySNSSignature(ctx, marshal(msg), fetch))
+
+	// notifications in other formats are not signed.
+	require.NoError(t, storagenotify.VerifySNSSignature(ctx, []byte(s3EventPayload), fetch))
+
+	tampered := maps.Clone(msg)
+	tampered["Message"] = `{"Records":[]}`
+	require.ErrorIs(t, storagenotify.VerifySNSSignature(ctx, marshal(tampered), fetch), storagenotify.ErrInvalidSignature)
+
+	untrusted := maps.Clone(msg)
+	untrusted["SigningCertURL"] = "https://example.com/cert.pem"
+	require.ErrorIs(t, storagenotify.VerifySNSSignature(ctx, marshal(untrusted), fetch), storagenotify.ErrInvalidSignature)
+
+	unsigned := maps.Clone(msg)
+	delete(unsigned, "Signature")
+	delete(unsigned, "SignatureVersion")
+	require.ErrorIs(t, storagenotify.VerifySNSSignature(ctx, marshal(unsigned), fetch), storagenotify.ErrInvalidSignature)
+}
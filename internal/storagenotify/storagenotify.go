@@ -0,0 +1,175 @@
+// Package storagenotify parses bucket change notifications delivered over HTTP by cloud storage providers,
+// so that out-of-band additions and deletions of repository blobs can be detected.
+//
+// Supported formats:
+//   - S3 event notifications delivered directly (e.g. MinIO webhook targets),
+//   - S3 event notifications delivered through Amazon SNS HTTP/HTTPS subscriptions,
+//   - Google Cloud Storage notifications delivered through Pub/Sub push subscriptions.
+//
+// Signatures of messages delivered through SNS are checked by VerifySNSSignature.
+package storagenotify
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo/blob"
+)
+
+// ChangeKind describes the kind of change to a blob.
+type ChangeKind string
+
+// Supported change kinds.
+const (
+	BlobAdded   ChangeKind = "added"
+	BlobDeleted ChangeKind = "deleted"
+)
+
+// Change describes a single change to a blob in the repository bucket.
+type Change struct {
+	Kind   ChangeKind `json:"kind"`
+	BlobID blob.ID    `json:"blobID"`
+}
+
+// Notification is the result of parsing a single notification message.
+type Notification struct {
+	Changes []Change
+
+	// SubscribeURL is set when the message is an SNS subscription confirmation request,
+	// the subscription becomes active after the URL has been fetched.
+	SubscribeURL string
+}
+
+type s3Event struct {
+	Records []struct {
+		EventName string `json:"eventName"`
+		S3        struct {
+			Object struct {
+				Key string `json:"key"`
+			} `json:"object"`
+		} `json:"s3"`
+	} `json:"Records"`
+}
+
+type snsMessage struct {
+	Type         string `json:"Type"`
+	Message      string `json:"Message"`
+	SubscribeURL string `json:"SubscribeURL"`
+}
+
+type pubSubPushMessage struct {
+	Message *struct {
+		Attributes map[string]string `json:"attributes"`
+	} `json:"message"`
+}
+
+// Parse parses the notification in any of the supported formats. Objects whose keys don't start
+// with the provided prefix are ignored and the prefix is stripped to form blob IDs.
+func Parse(payload []byte, keyPrefix string) (*Notification, error) {
+	// field names are matched case-insensitively by encoding/json, so determine the format
+	// based on exact top-level keys first: SNS uses "Type", Pub/Sub uses "message".
+	var top map[string]json.RawMessage
+
+	if err := json.Unmarshal(payload, &top); err != nil {
+		return nil, errors.Wrap(err, "malformed notification")
+	}
+
+	if _, ok := top["Type"]; ok {
+		return parseSNS(payload, keyPrefix)
+	}
+
+	if _, ok := top["message"]; ok {
+		var ps pubSubPushMessage
+
+		if err := json.Unmarshal(payload, &ps); err != nil || ps.Message == nil {
+			return nil, errors.New("malformed Pub/Sub message")
+		}
+
+		return parsePubSubPush(ps.Message.Attributes, keyPrefix), nil
+	}
+
+	return parseS3Event(payload, keyPrefix)
+}
+
+func parseSNS(payload []byte, keyPrefix string) (*Notification, error) {
+	var sns snsMessage
+
+	if err := json.Unmarshal(payload, &sns); err != nil {
+		return nil, errors.Wrap(err, "malformed SNS message")
+	}
+
+	switch sns.Type {
+	case "SubscriptionConfirmation":
+		return &Notification{SubscribeURL: sns.SubscribeURL}, nil
+
+	case "Notification":
+		return parseS3Event([]byte(sns.Message), keyPrefix)
+
+	default:
+		return &Notification{}, nil
+	}
+}
+
+func parseS3Event(payload []byte, keyPrefix string) (*Notification, error) {
+	var ev s3Event
+
+	if err := json.Unmarshal(payload, &ev); err != nil {
+		return nil, errors.Wrap(err, "malformed S3 event")
+	}
+
+	n := &Notification{}
+
+	for _, r := range ev.Records {
+		// keys in S3 events are URL-encoded.
+		key, err := url.QueryUnescape(r.S3.Object.Key)
+		if err != nil {
+			return nil, errors.Wrapf(err, "malformed object key %q", r.S3.Object.Key)
+		}
+
+		var kind ChangeKind
+
+		switch {
+		case strings.HasPrefix(r.EventName, "ObjectCreated:"):
+			kind = BlobAdded
+		case strings.HasPrefix(r.EventName, "ObjectRemoved:"), strings.HasPrefix(r.EventName, "LifecycleExpiration:"):
+			kind = BlobDeleted
+		default:
+			continue
+		}
+
+		n.add(kind, key, keyPrefix)
+	}
+
+	return n, nil
+}
+
+func parsePubSubPush(attrs map[string]string, keyPrefix string) *Notification {
+	n := &Notification{}
+
+	// deletion and archival of a generation that has been replaced by a new one is not a deletion of the blob.
+	if attrs["overwrittenByGeneration"] != "" {
+		return n
+	}
+
+	switch attrs["eventType"] {
+	case "OBJECT_FINALIZE":
+		n.add(BlobAdded, attrs["objectId"], keyPrefix)
+
+	case "OBJECT_DELETE", "OBJECT_ARCHIVE":
+		n.add(BlobDeleted, attrs["objectId"], keyPrefix)
+	}
+
+	return n
+}
+
+func (n *Notification) add(kind ChangeKind, key, keyPrefix string) {
+	id, ok := strings.CutPrefix(key, keyPrefix)
+	if !ok || id == "" {
+		return
+	}
+
+	n.Changes = append(n.Changes, Change{Kind: kind, BlobID: blob.ID(id)})
+}
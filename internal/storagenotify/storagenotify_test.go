@@ -0,0 +1,102 @@
+package storagenotify_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/storagenotify"
+)
+
+const s3EventPayload = `{"Records":[
+	{"eventName":"ObjectCreated:Put","s3":{"object":{"key":"repo%2Fxn0_abc"}}},
+	{"eventName":"ObjectRemoved:Delete","s3":{"object":{"key":"repo/p0123"}}},
+	{"eventName":"LifecycleExpiration:Delete","s3":{"object":{"key":"repo/q0456"}}},
+	{"eventName":"ObjectRestore:Completed","s3":{"object":{"key":"repo/p0789"}}},
+	{"eventName":"ObjectCreated:Put","s3":{"object":{"key":"other/p0123"}}}
+]}`
+
+func TestParseS3Event(t *testing.T) {
+	n, err := storagenotify.Parse([]byte(s3EventPayload), "repo/")
+	require.NoError(t, err)
+	require.Empty(t, n.SubscribeURL)
+	require.Equal(t, []storagenotify.Change{
+		{Kind: storagenotify.BlobAdded, BlobID: "xn0_abc"},
+		{Kind: storagenotify.BlobDeleted, BlobID: "p0123"},
+		{Kind: storagenotify.BlobDeleted, BlobID: "q0456"},
+	}, n.Changes)
+}
+
+func TestParseSNS(t *testing.T) {
+	b, err := json.Marshal(map[string]string{
+		"Type":    "Notification",
+		"Message": s3EventPayload,
+	})
+	require.NoError(t, err)
+
+	n, err := storagenotify.Parse(b, "repo/")
+	require.NoError(t, err)
+	require.Len(t, n.Changes, 3)
+
+	n, err = storagenotify.Parse([]byte(`{"Type":"SubscriptionConfirmation","SubscribeURL":"https://sns.us-east-1.amazonaws.com/?Action=ConfirmSubscription"}`), "")
+	require.NoError(t, err)
+	require.Equal(t, "https://sns.us-east-1.amazonaws.com/?Action=ConfirmSubscription", n.SubscribeURL)
+	require.Empty(t, n.Changes)
+
+	// S3 test event sent when configuring notifications.
+	n, err = storagenotify.Parse([]byte(`{"Service":"Amazon S3","Event":"s3:TestEvent"}`), "")
+	require.NoError(t, err)
+	require.Empty(t, n.Changes)
+}
+
+func TestParsePubSubPush(t *testing.T) {
+	cases := []struct {
+		attrs map[string]string
+		want  []storagenotify.Change
+	}{
+		{
+			attrs: map[string]string{"eventType": "OBJECT_FINALIZE", "objectId": "p0123"},
+			want:  []storagenotify.Change{{Kind: storagenotify.BlobAdded, BlobID: "p0123"}},
+		},
+		{
+			attrs: map[string]string{"eventType": "OBJECT_DELETE", "objectId": "p0123"},
+			want:  []storagenotify.Change{{Kind: storagenotify.BlobDeleted, BlobID: "p0123"}},
+		},
+		{
+			attrs: map[string]string{"eventType": "OBJECT_ARCHIVE", "objectId": "p0123"},
+			want:  []storagenotify.Change{{Kind: storagenotify.BlobDeleted, BlobID: "p0123"}},
+		},
+		{
+			// generation replaced by a new one.
+			attrs: map[string]string{"eventType": "OBJECT_DELETE", "objectId": "p0123", "overwrittenByGeneration": "123"},
+		},
+		{
+			attrs: map[string]string{"eventType": "OBJECT_METADATA_UPDATE", "objectId": "p0123"},
+		},
+	}
+
+	for _, tc := range cases {
+		b, err := json.Marshal(map[string]any{
+			"message": map[string]any{
+				"attributes": tc.attrs,
+				"data":       "",
+				"messageId":  "1",
+			},
+			"subscription": "projects/p/subscriptions/s",
+		})
+		require.NoError(t, err)
+
+		n, err := storagenotify.Parse(b, "")
+		require.NoError(t, err)
+		require.Equal(t, tc.want, n.Changes, "%v", tc.attrs)
+	}
+}
+
+func TestParseMalformed(t *testing.T) {
+	_, err := storagenotify.Parse([]byte("not-json"), "")
+	require.Error(t, err)
+
+	_, err = storagenotify.Parse([]byte(`{"Records":[{"eventName":"ObjectCreated:Put","s3":{"object":{"key":"%zz"}}}]}`), "")
+	require.Error(t, err)
+}
@@ -30,6 +30,13 @@ type Profile struct {
 	Username            string `json:"username"`
 	PasswordHashVersion int    `json:"passwordHashVersion,omitempty"`
 	PasswordHash        []byte `json:"passwordHash"`
+
+	// StorageQuotaBytes limits the amount of data the user may store through the repository server, 0 == unlimited.
+	// Usage is measured in bytes stored in the repository after deduplication, compression and encryption.
+	StorageQuotaBytes int64 `json:"storageQuotaBytes,omitempty"`
+
+	// MaxUploadBytesPerSecond limits the rate at which the user may upload data through the repository server, 0 == unlimited.
+	MaxUploadBytesPerSecond int64 `json:"maxUploadBytesPerSecond,omitempty"`
 }
 
 // SetPassword changes the password for a user profile.
@@ -0,0 +1,95 @@
+// Package kopialib provides a small, stable facade for applications embedding Kopia.
+//
+// The facade covers the common lifecycle of a backup application:
+//
+//   - a repository is created in storage once using Initialize() and then connected to using Connect(),
+//   - Open() returns a Repository, which is an open connection to the repository,
+//   - a SnapshotManager creates, lists, restores and deletes snapshots in the repository.
+//
+// All operations accept a context and only take the arguments they need.
+// Applications needing more control should use the repo and snapshot packages directly.
+package kopialib
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/blob/filesystem"
+)
+
+// Repository is an open repository.
+type Repository interface {
+	// Snapshots returns the manager of snapshots stored in the repository.
+	Snapshots() SnapshotManager
+
+	// Close closes the repository and releases associated resources.
+	Close(ctx context.Context) error
+}
+
+// Initialize creates a new repository in the provided storage protected by the provided password.
+// The storage remains owned by the caller.
+func Initialize(ctx context.Context, st blob.Storage, password string) error {
+	return errors.Wrap(repo.Initialize(ctx, st, &repo.NewRepositoryOptions{}, password), "unable to initialize repository")
+}
+
+// Connect writes the configuration file which allows the repository in the provided storage to be opened with Open().
+// The storage remains owned by the caller.
+func Connect(ctx context.Context, st blob.Storage, configFile, password string) error {
+	return errors.Wrap(repo.Connect(ctx, configFile, st, password, &repo.ConnectOptions{}), "unable to connect to repository")
+}
+
+// InitializeFilesystem creates a new repository in the provided local directory, which is created if it does not exist.
+func InitializeFilesystem(ctx context.Context, path, password string) error {
+	return withFilesystemStorage(ctx, path, func(st blob.Storage) error {
+		return Initialize(ctx, st, password)
+	})
+}
+
+// ConnectFilesystem writes the configuration file which allows the repository in the provided local directory
+// to be opened with Open().
+func ConnectFilesystem(ctx context.Context, path, configFile, password string) error {
+	return withFilesystemStorage(ctx, path, func(st blob.Storage) error {
+		return Connect(ctx, st, configFile, password)
+	})
+}
+
+// withFilesystemStorage invokes the provided callback with storage in the local directory and closes it afterwards.
+func withFilesystemStorage(ctx context.Context, path string, cb func(st blob.Storage) error) (err error) {
+	st, err := filesystem.New(ctx, &filesystem.Options{Path: path}, true)
+	if err != nil {
+		return errors.Wrap(err, "unable to open filesystem storage")
+	}
+
+	defer func() {
+		if cerr := st.Close(ctx); cerr != nil && err == nil {
+			err = errors.Wrap(cerr, "unable to close filesystem storage")
+		}
+	}()
+
+	return cb(st)
+}
+
+type repository struct {
+	rep repo.Repository
+}
+
+func (r *repository) Snapshots() SnapshotManager {
+	return &snapshotManager{rep: r.rep}
+}
+
+func (r *repository) Close(ctx context.Context) error {
+	return errors.Wrap(r.rep.Close(ctx), "unable to close repository")
+}
+
+// Open opens the repository using the configuration file written by Connect().
+func Open(ctx context.Context, configFile, password string) (Repository, error) {
+	rep, err := repo.Open(ctx, configFile, password, &repo.Options{})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to open repository")
+	}
+
+	return &repository{rep: rep}, nil
+}
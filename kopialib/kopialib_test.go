@@ -0,0 +1,66 @@
+package kopialib_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testlogging"
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/kopialib"
+	"github.com/kopia/kopia/snapshot"
+)
+
+func TestSnapshotLifecycle(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	const password = "some-password"
+
+	tmp := testutil.TempDirectory(t)
+	configFile := filepath.Join(tmp, "kopia.config")
+	source := filepath.Join(tmp, "source")
+	target := filepath.Join(tmp, "target")
+
+	require.NoError(t, os.MkdirAll(source, 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(source, "file.txt"), []byte("hello"), 0o600))
+
+	repoDir := filepath.Join(tmp, "repo")
+
+	require.NoError(t, kopialib.InitializeFilesystem(ctx, repoDir, password))
+	require.NoError(t, kopialib.ConnectFilesystem(ctx, repoDir, configFile, password))
+
+	rep, err := kopialib.Open(ctx, configFile, password)
+	require.NoError(t, err)
+
+	defer rep.Close(ctx)
+
+	src := snapshot.SourceInfo{Host: "host", UserName: "user", Path: source}
+
+	man, err := rep.Snapshots().Create(ctx, src)
+	require.NoError(t, err)
+
+	sources, err := rep.Snapshots().Sources(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []snapshot.SourceInfo{src}, sources)
+
+	snaps, err := rep.Snapshots().List(ctx, src)
+	require.NoError(t, err)
+	require.Len(t, snaps, 1)
+	require.Equal(t, man.ID, snaps[0].ID)
+
+	st, err := rep.Snapshots().Restore(ctx, man.ID, target)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), st.RestoredFileCount)
+
+	got, err := os.ReadFile(filepath.Join(target, "file.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "hello", string(got))
+
+	require.NoError(t, rep.Snapshots().Delete(ctx, man.ID))
+
+	snaps, err = rep.Snapshots().List(ctx, src)
+	require.NoError(t, err)
+	require.Empty(t, snaps)
+}
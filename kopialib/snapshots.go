@@ -0,0 +1,112 @@
+package kopialib
+
+import (
+	"context"
+	"math"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/fs/localfs"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/manifest"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/policy"
+	"github.com/kopia/kopia/snapshot/restore"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+)
+
+// SnapshotManager manages snapshots of local directories.
+type SnapshotManager interface {
+	// Create snapshots the local path identified by the source and saves the resulting snapshot.
+	Create(ctx context.Context, src snapshot.SourceInfo) (*snapshot.Manifest, error)
+
+	// Sources returns all sources which have snapshots.
+	Sources(ctx context.Context) ([]snapshot.SourceInfo, error)
+
+	// List returns snapshots of the provided source.
+	List(ctx context.Context, src snapshot.SourceInfo) ([]*snapshot.Manifest, error)
+
+	// Restore restores the snapshot with the provided ID to a local directory.
+	Restore(ctx context.Context, id manifest.ID, targetPath string) (restore.Stats, error)
+
+	// Delete deletes the snapshot with the provided ID.
+	Delete(ctx context.Context, id manifest.ID) error
+}
+
+type snapshotManager struct {
+	rep repo.Repository
+}
+
+func (m *snapshotManager) Create(ctx context.Context, src snapshot.SourceInfo) (*snapshot.Manifest, error) {
+	entry, err := localfs.NewEntry(src.Path)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read source")
+	}
+
+	var man *snapshot.Manifest
+
+	err = repo.WriteSession(ctx, m.rep, repo.WriteSessionOptions{Purpose: "kopialib:create"}, func(ctx context.Context, w repo.RepositoryWriter) error {
+		policyTree, err := policy.TreeForSource(ctx, w, src)
+		if err != nil {
+			return errors.Wrap(err, "unable to get policy")
+		}
+
+		man, err = snapshotfs.NewUploader(w).Upload(ctx, entry, policyTree, src)
+		if err != nil {
+			return errors.Wrap(err, "upload error")
+		}
+
+		_, err = snapshot.SaveSnapshot(ctx, w, man)
+
+		return errors.Wrap(err, "unable to save snapshot")
+	})
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+
+	return man, nil
+}
+
+func (m *snapshotManager) Sources(ctx context.Context) ([]snapshot.SourceInfo, error) {
+	//nolint:wrapcheck
+	return snapshot.ListSources(ctx, m.rep)
+}
+
+func (m *snapshotManager) List(ctx context.Context, src snapshot.SourceInfo) ([]*snapshot.Manifest, error) {
+	//nolint:wrapcheck
+	return snapshot.ListSnapshots(ctx, m.rep, src)
+}
+
+func (m *snapshotManager) Restore(ctx context.Context, id manifest.ID, targetPath string) (restore.Stats, error) {
+	man, err := snapshot.LoadSnapshot(ctx, m.rep, id)
+	if err != nil {
+		return restore.Stats{}, errors.Wrap(err, "unable to load snapshot")
+	}
+
+	root, err := snapshotfs.SnapshotRoot(m.rep, man)
+	if err != nil {
+		return restore.Stats{}, errors.Wrap(err, "unable to get snapshot root")
+	}
+
+	output := &restore.FilesystemOutput{
+		TargetPath:           targetPath,
+		OverwriteDirectories: true,
+	}
+
+	if err := output.Init(ctx); err != nil {
+		return restore.Stats{}, errors.Wrap(err, "unable to initialize restore output")
+	}
+
+	st, err := restore.Entry(ctx, m.rep, output, root, restore.Options{
+		RestoreDirEntryAtDepth: math.MaxInt32,
+	})
+
+	return st, errors.Wrap(err, "restore error")
+}
+
+func (m *snapshotManager) Delete(ctx context.Context, id manifest.ID) error {
+	//nolint:wrapcheck
+	return repo.WriteSession(ctx, m.rep, repo.WriteSessionOptions{Purpose: "kopialib:delete"}, func(ctx context.Context, w repo.RepositoryWriter) error {
+		return snapshot.DeleteSnapshot(ctx, w, id)
+	})
+}
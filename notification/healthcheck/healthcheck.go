@@ -0,0 +1,65 @@
+// Package healthcheck reports snapshot progress to healthchecks.io-compatible ping URLs,
+// so that snapshots that silently stop running can be detected externally.
+package healthcheck
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kopia/kopia/repo/logging"
+)
+
+const pingTimeout = 10 * time.Second
+
+var log = logging.Module("healthcheck")
+
+// Start reports that a snapshot has started.
+func Start(ctx context.Context, pingURL string) {
+	if pingURL == "" {
+		return
+	}
+
+	ping(ctx, strings.TrimSuffix(pingURL, "/")+"/start", "")
+}
+
+// Finish reports that a snapshot has finished, successfully or with the provided error.
+func Finish(ctx context.Context, pingURL string, err error) {
+	if pingURL == "" {
+		return
+	}
+
+	if err != nil {
+		ping(ctx, strings.TrimSuffix(pingURL, "/")+"/fail", err.Error())
+		return
+	}
+
+	ping(ctx, strings.TrimSuffix(pingURL, "/"), "")
+}
+
+// ping sends a single ping, failures are logged but otherwise ignored.
+func ping(ctx context.Context, u, body string) {
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, strings.NewReader(body))
+	if err != nil {
+		log(ctx).Warnf("invalid health check URL: %v", err)
+		return
+	}
+
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log(ctx).Warnf("unable to ping health check URL: %v", err)
+		return
+	}
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		log(ctx).Warnf("health check URL returned error: %v", resp.Status)
+	}
+}
@@ -0,0 +1,40 @@
+package healthcheck_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testlogging"
+	"github.com/kopia/kopia/notification/healthcheck"
+)
+
+func TestHealthCheck(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	var pings []string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		pings = append(pings, r.URL.Path+" "+string(b))
+	}))
+	defer srv.Close()
+
+	healthcheck.Start(ctx, srv.URL+"/uuid")
+	healthcheck.Finish(ctx, srv.URL+"/uuid/", nil)
+	healthcheck.Finish(ctx, srv.URL+"/uuid", errors.New("some error"))
+
+	// no URL, no pings
+	healthcheck.Start(ctx, "")
+	healthcheck.Finish(ctx, "", nil)
+
+	require.Equal(t, []string{
+		"/uuid/start ",
+		"/uuid ",
+		"/uuid/fail some error",
+	}, pings)
+}
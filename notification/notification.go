@@ -0,0 +1,182 @@
+// Package notification sends notifications about repository events to configured notification profiles.
+package notification
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/units"
+	"github.com/kopia/kopia/notification/notifyprofile"
+	"github.com/kopia/kopia/notification/sender"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/logging"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/policy"
+)
+
+const (
+	// lowSpacePercent is the percentage of free storage space below which a warning is sent.
+	lowSpacePercent = 10
+
+	// maxDeliveryTime is the upper bound on the time spent delivering a single notification.
+	maxDeliveryTime = 5 * time.Minute
+)
+
+var log = logging.Module("notification")
+
+// pendingDeliveries tracks notifications being delivered in the background.
+//
+//nolint:gochecknoglobals
+var pendingDeliveries sync.WaitGroup
+
+// Wait waits until all notifications sent so far have been delivered.
+func Wait() {
+	pendingDeliveries.Wait()
+}
+
+// Send delivers the message to all notification profiles whose minimum severity is satisfied.
+// Delivery happens in the background, use Wait() to wait for it to complete. Delivery failures are
+// logged but not returned, since notifications must never delay or fail the operation being reported.
+func Send(ctx context.Context, rep repo.Repository, msg *sender.Message) {
+	sendToProfiles(ctx, rep, msg, nil)
+}
+
+// SendForSource delivers the message about the provided source, honoring the notification profiles
+// selected by the effective policy of the source.
+func SendForSource(ctx context.Context, rep repo.Repository, src snapshot.SourceInfo, msg *sender.Message) {
+	pol, _, _, err := policy.GetEffectivePolicy(ctx, rep, src)
+	if err != nil {
+		log(ctx).Warnf("unable to get effective policy for %v: %v", src, err)
+		return
+	}
+
+	sendToProfiles(ctx, rep, msg, pol.SchedulingPolicy.NotificationProfiles)
+}
+
+// sendToProfiles delivers the message to the named profiles or all profiles if none are named.
+func sendToProfiles(ctx context.Context, rep repo.Repository, msg *sender.Message, names []string) {
+	profiles, err := notifyprofile.ListProfiles(ctx, rep)
+	if err != nil {
+		log(ctx).Warnf("unable to list notification profiles: %v", err)
+		return
+	}
+
+	for _, p := range profiles {
+		if msg.Severity < p.MinSeverity {
+			continue
+		}
+
+		if len(names) > 0 && !slices.Contains(names, p.ProfileName) {
+			continue
+		}
+
+		if p.RunsCommand() && !rep.ClientOptions().EnableActions {
+			log(ctx).Debugf("not sending notification to %q because actions have been disabled for this client", p.ProfileName)
+			continue
+		}
+
+		s, err := p.Sender()
+		if err != nil {
+			log(ctx).Warnf("invalid notification profile %q: %v", p.ProfileName, err)
+			continue
+		}
+
+		pendingDeliveries.Add(1)
+
+		go func(profileName string) {
+			defer pendingDeliveries.Done()
+
+			// deliver even when the operation being reported is canceled.
+			dctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), maxDeliveryTime)
+			defer cancel()
+
+			if err := s.Send(dctx, msg); err != nil {
+				log(ctx).Warnf("unable to send notification to %q: %v", profileName, err)
+			}
+		}(p.ProfileName)
+	}
+}
+
+// CheckFreeSpace sends a warning when the storage volume holding the repository is almost full.
+// Storage providers which are not volumes are ignored.
+func CheckFreeSpace(ctx context.Context, rep repo.Repository) {
+	dr, ok := rep.(repo.DirectRepository)
+	if !ok {
+		return
+	}
+
+	c, err := dr.BlobVolume().GetCapacity(ctx)
+	if err != nil {
+		if !errors.Is(err, blob.ErrNotAVolume) {
+			log(ctx).Debugf("unable to get storage capacity: %v", err)
+		}
+
+		return
+	}
+
+	if c.SizeB == 0 || c.FreeB*100 >= c.SizeB*lowSpacePercent {
+		return
+	}
+
+	Send(ctx, rep, LowSpace(c))
+}
+
+// SnapshotStarted returns the message reporting that a snapshot of the provided source has started.
+func SnapshotStarted(src snapshot.SourceInfo) *sender.Message {
+	return &sender.Message{
+		Subject:  fmt.Sprintf("Snapshot of %v started", src),
+		Body:     fmt.Sprintf("Snapshot of %v has started.", src),
+		Severity: sender.SeverityInfo,
+	}
+}
+
+// SnapshotResult returns the message describing the outcome of a snapshot of the provided source.
+func SnapshotResult(src snapshot.SourceInfo, err error) *sender.Message {
+	if err != nil {
+		return &sender.Message{
+			Subject:  fmt.Sprintf("Snapshot of %v failed", src),
+			Body:     fmt.Sprintf("Snapshot of %v failed: %v", src, err),
+			Severity: sender.SeverityError,
+		}
+	}
+
+	return &sender.Message{
+		Subject:  fmt.Sprintf("Snapshot of %v succeeded", src),
+		Body:     fmt.Sprintf("Snapshot of %v completed successfully.", src),
+		Severity: sender.SeveritySuccess,
+	}
+}
+
+// SnapshotMissed returns the message reporting that a scheduled snapshot did not run on time.
+func SnapshotMissed(src snapshot.SourceInfo, scheduled time.Time, late time.Duration) *sender.Message {
+	return &sender.Message{
+		Subject:  fmt.Sprintf("Missed scheduled snapshot of %v", src),
+		Body:     fmt.Sprintf("Snapshot of %v was scheduled for %v but did not start until %v later.", src, scheduled.Format(time.RFC3339), late.Truncate(time.Second)),
+		Severity: sender.SeverityWarning,
+	}
+}
+
+// VerificationFailed returns the message reporting that snapshot verification has failed.
+func VerificationFailed(err error) *sender.Message {
+	return &sender.Message{
+		Subject:  "Snapshot verification failed",
+		Body:     fmt.Sprintf("Snapshot verification failed: %v", err),
+		Severity: sender.SeverityError,
+	}
+}
+
+// LowSpace returns the message reporting that the repository storage is running out of space.
+func LowSpace(c blob.Capacity) *sender.Message {
+	return &sender.Message{
+		Subject: "Repository storage is running low on space",
+		Body: fmt.Sprintf("Repository storage has %v available out of %v.",
+			units.BytesString(int64(c.FreeB)), units.BytesString(int64(c.SizeB))),
+		Severity: sender.SeverityWarning,
+	}
+}
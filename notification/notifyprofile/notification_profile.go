@@ -0,0 +1,149 @@
+// Package notifyprofile manages notification profiles stored in the repository.
+package notifyprofile
+
+import (
+	"context"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/notification/sender"
+	"github.com/kopia/kopia/notification/sender/command"
+	"github.com/kopia/kopia/notification/sender/email"
+	"github.com/kopia/kopia/notification/sender/webhook"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/manifest"
+)
+
+// ManifestType is the type of the manifest used to store notification profiles.
+const ManifestType = "notificationProfile"
+
+const profileNameKey = "profile"
+
+// ErrNotFound is returned when a profile is not found.
+var ErrNotFound = errors.New("notification profile not found")
+
+// Config is the configuration of a single notification profile.
+type Config struct {
+	ProfileName string          `json:"profile"`
+	MinSeverity sender.Severity `json:"minSeverity"`
+
+	Webhook *webhook.Options `json:"webhook,omitempty"`
+	Email   *email.Options   `json:"email,omitempty"`
+	Command *command.Options `json:"command,omitempty"`
+}
+
+// RunsCommand returns true if the profile delivers notifications by running a local command,
+// which like snapshot actions is only allowed on clients with actions enabled.
+func (c *Config) RunsCommand() bool {
+	return c.Command != nil
+}
+
+// Sender returns the sender for the profile.
+func (c *Config) Sender() (sender.Sender, error) {
+	switch {
+	case c.Webhook != nil:
+		return webhook.NewSender(*c.Webhook) //nolint:wrapcheck
+
+	case c.Email != nil:
+		return email.NewSender(*c.Email) //nolint:wrapcheck
+
+	case c.Command != nil:
+		return command.NewSender(*c.Command) //nolint:wrapcheck
+
+	default:
+		return nil, errors.Errorf("notification profile %q has no delivery method", c.ProfileName)
+	}
+}
+
+func labelsForProfileName(name string) map[string]string {
+	return map[string]string{
+		manifest.TypeLabelKey: ManifestType,
+		profileNameKey:        name,
+	}
+}
+
+// GetProfile returns the notification profile with the provided name or ErrNotFound.
+func GetProfile(ctx context.Context, rep repo.Repository, name string) (*Config, error) {
+	md, err := rep.FindManifests(ctx, labelsForProfileName(name))
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to find notification profile")
+	}
+
+	if len(md) == 0 {
+		return nil, ErrNotFound
+	}
+
+	var c Config
+
+	if _, err := rep.GetManifest(ctx, manifest.PickLatestID(md), &c); err != nil {
+		return nil, errors.Wrap(err, "unable to load notification profile")
+	}
+
+	return &c, nil
+}
+
+// ListProfiles returns all notification profiles sorted by name.
+func ListProfiles(ctx context.Context, rep repo.Repository) ([]*Config, error) {
+	md, err := rep.FindManifests(ctx, map[string]string{
+		manifest.TypeLabelKey: ManifestType,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list notification profiles")
+	}
+
+	var result []*Config
+
+	for _, m := range md {
+		var c Config
+
+		if _, err := rep.GetManifest(ctx, m.ID, &c); err != nil {
+			return nil, errors.Wrapf(err, "unable to load notification profile %v", m.ID)
+		}
+
+		result = append(result, &c)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ProfileName < result[j].ProfileName
+	})
+
+	return result, nil
+}
+
+// SaveProfile saves the notification profile, replacing any existing profile with the same name.
+func SaveProfile(ctx context.Context, rep repo.RepositoryWriter, c *Config) error {
+	if c.ProfileName == "" {
+		return errors.New("profile name must be provided")
+	}
+
+	if _, err := c.Sender(); err != nil {
+		return errors.Wrap(err, "invalid notification profile")
+	}
+
+	if _, err := rep.ReplaceManifests(ctx, labelsForProfileName(c.ProfileName), c); err != nil {
+		return errors.Wrap(err, "unable to save notification profile")
+	}
+
+	return nil
+}
+
+// DeleteProfile deletes the notification profile with the provided name.
+func DeleteProfile(ctx context.Context, rep repo.RepositoryWriter, name string) error {
+	md, err := rep.FindManifests(ctx, labelsForProfileName(name))
+	if err != nil {
+		return errors.Wrap(err, "unable to find notification profile")
+	}
+
+	if len(md) == 0 {
+		return ErrNotFound
+	}
+
+	for _, m := range md {
+		if err := rep.DeleteManifest(ctx, m.ID); err != nil {
+			return errors.Wrap(err, "unable to delete notification profile")
+		}
+	}
+
+	return nil
+}
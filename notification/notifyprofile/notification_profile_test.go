@@ -0,0 +1,66 @@
+package notifyprofile_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/repotesting"
+	"github.com/kopia/kopia/notification/notifyprofile"
+	"github.com/kopia/kopia/notification/sender"
+	"github.com/kopia/kopia/notification/sender/webhook"
+)
+
+func TestNotificationProfiles(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+
+	profiles, err := notifyprofile.ListProfiles(ctx, env.RepositoryWriter)
+	require.NoError(t, err)
+	require.Empty(t, profiles)
+
+	_, err = notifyprofile.GetProfile(ctx, env.RepositoryWriter, "no-such-profile")
+	require.ErrorIs(t, err, notifyprofile.ErrNotFound)
+
+	// no delivery method
+	require.Error(t, notifyprofile.SaveProfile(ctx, env.RepositoryWriter, &notifyprofile.Config{ProfileName: "p1"}))
+
+	// no name
+	require.Error(t, notifyprofile.SaveProfile(ctx, env.RepositoryWriter, &notifyprofile.Config{
+		Webhook: &webhook.Options{Endpoint: "http://localhost:1234"},
+	}))
+
+	require.NoError(t, notifyprofile.SaveProfile(ctx, env.RepositoryWriter, &notifyprofile.Config{
+		ProfileName: "p2",
+		Webhook:     &webhook.Options{Endpoint: "http://localhost:1234"},
+	}))
+	require.NoError(t, notifyprofile.SaveProfile(ctx, env.RepositoryWriter, &notifyprofile.Config{
+		ProfileName: "p1",
+		MinSeverity: sender.SeverityWarning,
+		Webhook:     &webhook.Options{Endpoint: "http://localhost:1235"},
+	}))
+
+	// replace existing profile
+	require.NoError(t, notifyprofile.SaveProfile(ctx, env.RepositoryWriter, &notifyprofile.Config{
+		ProfileName: "p1",
+		MinSeverity: sender.SeverityError,
+		Webhook:     &webhook.Options{Endpoint: "http://localhost:1236"},
+	}))
+
+	profiles, err = notifyprofile.ListProfiles(ctx, env.RepositoryWriter)
+	require.NoError(t, err)
+	require.Len(t, profiles, 2)
+	require.Equal(t, "p1", profiles[0].ProfileName)
+	require.Equal(t, "p2", profiles[1].ProfileName)
+
+	p1, err := notifyprofile.GetProfile(ctx, env.RepositoryWriter, "p1")
+	require.NoError(t, err)
+	require.Equal(t, sender.SeverityError, p1.MinSeverity)
+	require.Equal(t, "http://localhost:1236", p1.Webhook.Endpoint)
+
+	require.NoError(t, notifyprofile.DeleteProfile(ctx, env.RepositoryWriter, "p1"))
+	require.ErrorIs(t, notifyprofile.DeleteProfile(ctx, env.RepositoryWriter, "p1"), notifyprofile.ErrNotFound)
+
+	profiles, err = notifyprofile.ListProfiles(ctx, env.RepositoryWriter)
+	require.NoError(t, err)
+	require.Len(t, profiles, 1)
+}
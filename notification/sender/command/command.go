@@ -0,0 +1,66 @@
+// Package command implements a notification sender that delivers messages by running a local command.
+package command
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/notification/sender"
+)
+
+const defaultTimeout = time.Minute
+
+// Options defines command sender options.
+type Options struct {
+	Command        string   `json:"command"`
+	Arguments      []string `json:"args,omitempty"`
+	TimeoutSeconds int      `json:"timeoutSeconds,omitempty"`
+}
+
+type commandSender struct {
+	opt Options
+}
+
+// Send runs the command passing message subject and severity in environment variables
+// and the message body on standard input.
+func (p *commandSender) Send(ctx context.Context, msg *sender.Message) error {
+	timeout := defaultTimeout
+	if p.opt.TimeoutSeconds > 0 {
+		timeout = time.Duration(p.opt.TimeoutSeconds) * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	c := exec.CommandContext(ctx, p.opt.Command, p.opt.Arguments...) //nolint:gosec
+	c.Env = append(os.Environ(),
+		"KOPIA_NOTIFICATION_SUBJECT="+msg.Subject,
+		"KOPIA_NOTIFICATION_SEVERITY="+msg.Severity.String(),
+	)
+	c.Stdin = strings.NewReader(msg.Body)
+
+	if out, err := c.CombinedOutput(); err != nil {
+		return errors.Wrapf(err, "notification command failed: %s", strings.TrimSpace(string(out)))
+	}
+
+	return nil
+}
+
+func (p *commandSender) Summary() string {
+	return fmt.Sprintf("Command %v", strings.Join(append([]string{p.opt.Command}, p.opt.Arguments...), " "))
+}
+
+// NewSender returns new command sender.
+func NewSender(opt Options) (sender.Sender, error) {
+	if opt.Command == "" {
+		return nil, errors.New("command must be provided")
+	}
+
+	return &commandSender{opt: opt}, nil
+}
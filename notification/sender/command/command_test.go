@@ -0,0 +1,45 @@
+package command_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testlogging"
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/notification/sender"
+	"github.com/kopia/kopia/notification/sender/command"
+)
+
+func TestCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires a POSIX shell")
+	}
+
+	ctx := testlogging.Context(t)
+	out := filepath.Join(testutil.TempDirectory(t), "out.txt")
+
+	s, err := command.NewSender(command.Options{
+		Command:   "sh",
+		Arguments: []string{"-c", `echo "$KOPIA_NOTIFICATION_SEVERITY $KOPIA_NOTIFICATION_SUBJECT" > "$0"; cat >> "$0"`, out},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, s.Send(ctx, &sender.Message{Subject: "some subject", Body: "some body", Severity: sender.SeverityWarning}))
+
+	b, err := os.ReadFile(out)
+	require.NoError(t, err)
+	require.Equal(t, "warning some subject\nsome body", string(b))
+
+	failing, err := command.NewSender(command.Options{Command: "sh", Arguments: []string{"-c", "echo oops; exit 1"}})
+	require.NoError(t, err)
+
+	err = failing.Send(ctx, &sender.Message{Subject: "x"})
+	require.ErrorContains(t, err, "oops")
+
+	_, err = command.NewSender(command.Options{})
+	require.Error(t, err)
+}
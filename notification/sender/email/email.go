@@ -0,0 +1,189 @@
+// Package email implements a notification sender that delivers messages using SMTP.
+package email
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/clock"
+	"github.com/kopia/kopia/notification/sender"
+)
+
+const (
+	defaultSMTPPort = 587
+
+	// defaultTimeout is the maximum time allowed for delivering a single email.
+	defaultTimeout = time.Minute
+)
+
+// Options defines email sender options.
+type Options struct {
+	SMTPServer   string `json:"smtpServer"`
+	SMTPPort     int    `json:"smtpPort,omitempty"`
+	SMTPUsername string `json:"smtpUsername,omitempty"`
+	SMTPPassword string `json:"smtpPassword,omitempty"`
+	From         string `json:"from"`
+	To           string `json:"to"`
+}
+
+type emailSender struct {
+	opt Options
+}
+
+func (p *emailSender) Send(ctx context.Context, msg *sender.Message) error {
+	var auth smtp.Auth
+
+	if p.opt.SMTPUsername != "" {
+		auth = smtp.PlainAuth("", p.opt.SMTPUsername, p.opt.SMTPPassword, p.opt.SMTPServer)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	addr := net.JoinHostPort(p.opt.SMTPServer, strconv.Itoa(p.opt.SMTPPort))
+
+	var d net.Dialer
+
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return errors.Wrap(err, "unable to connect to SMTP server")
+	}
+
+	defer conn.Close() //nolint:errcheck
+
+	// bound the entire SMTP conversation by the context deadline and abort it on cancellation.
+	if dl, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(dl) //nolint:errcheck
+	}
+
+	stop := context.AfterFunc(ctx, func() {
+		conn.SetDeadline(clock.Now()) //nolint:errcheck
+	})
+	defer stop()
+
+	if err := p.sendMail(conn, auth, formatMessage(p.opt.From, p.opt.To, msg)); err != nil {
+		return errors.Wrap(err, "unable to send email")
+	}
+
+	return nil
+}
+
+// sendMail is equivalent to smtp.SendMail() over an already established connection.
+func (p *emailSender) sendMail(conn net.Conn, auth smtp.Auth, body []byte) error {
+	c, err := smtp.NewClient(conn, p.opt.SMTPServer)
+	if err != nil {
+		return errors.Wrap(err, "SMTP handshake error")
+	}
+
+	defer c.Close() //nolint:errcheck
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: p.opt.SMTPServer, MinVersion: tls.VersionTLS12}); err != nil {
+			return errors.Wrap(err, "STARTTLS error")
+		}
+	}
+
+	if auth != nil {
+		if err := c.Auth(auth); err != nil {
+			return errors.Wrap(err, "authentication error")
+		}
+	}
+
+	if err := c.Mail(p.opt.From); err != nil {
+		return errors.Wrap(err, "MAIL error")
+	}
+
+	for _, r := range p.recipients() {
+		if err := c.Rcpt(r); err != nil {
+			return errors.Wrapf(err, "RCPT error for %v", r)
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return errors.Wrap(err, "DATA error")
+	}
+
+	if _, err := w.Write(body); err != nil {
+		return errors.Wrap(err, "error writing message")
+	}
+
+	if err := w.Close(); err != nil {
+		return errors.Wrap(err, "error finishing message")
+	}
+
+	return errors.Wrap(c.Quit(), "QUIT error")
+}
+
+func (p *emailSender) recipients() []string {
+	var result []string
+
+	for _, r := range strings.Split(p.opt.To, ",") {
+		if r = strings.TrimSpace(r); r != "" {
+			result = append(result, r)
+		}
+	}
+
+	return result
+}
+
+func formatMessage(from, to string, msg *sender.Message) []byte {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "From: %v\r\n", from)
+	fmt.Fprintf(&sb, "To: %v\r\n", to)
+	fmt.Fprintf(&sb, "Subject: %v\r\n", headerValue(msg.Subject))
+	sb.WriteString("MIME-Version: 1.0\r\n")
+	sb.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n")
+	sb.WriteString("\r\n")
+	sb.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	sb.WriteString("\r\n")
+
+	return []byte(sb.String())
+}
+
+// headerValue makes the provided text safe to use as a header value. Control characters, such as CR/LF
+// in source paths which would otherwise allow injecting additional headers, are replaced with spaces
+// and non-ASCII text is encoded.
+func headerValue(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return ' '
+		}
+
+		return r
+	}, s)
+
+	return mime.QEncoding.Encode("utf-8", s)
+}
+
+func (p *emailSender) Summary() string {
+	return fmt.Sprintf("SMTP server: %q, Mail from: %q Mail to: %q", p.opt.SMTPServer, p.opt.From, p.opt.To)
+}
+
+// NewSender returns new email sender.
+func NewSender(opt Options) (sender.Sender, error) {
+	if opt.SMTPServer == "" {
+		return nil, errors.New("SMTP server must be provided")
+	}
+
+	if opt.From == "" || opt.To == "" {
+		return nil, errors.New("sender and recipient addresses must be provided")
+	}
+
+	if opt.SMTPPort == 0 {
+		opt.SMTPPort = defaultSMTPPort
+	}
+
+	return &emailSender{opt: opt}, nil
+}
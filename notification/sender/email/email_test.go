@@ -0,0 +1,123 @@
+package email
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/notification/sender"
+)
+
+func TestFormatMessage(t *testing.T) {
+	require.Equal(t,
+		"From: a@example.com\r\n"+
+			"To: b@example.com, c@example.com\r\n"+
+			"Subject: some subject\r\n"+
+			"MIME-Version: 1.0\r\n"+
+			"Content-Type: text/plain; charset=\"utf-8\"\r\n"+
+			"\r\n"+
+			"line1\r\nline2\r\n",
+		string(formatMessage("a@example.com", "b@example.com, c@example.com", &sender.Message{
+			Subject: "some subject",
+			Body:    "line1\nline2",
+		})))
+}
+
+func TestFormatMessageSubjectHeader(t *testing.T) {
+	formatSubject := func(subject string) string {
+		msg := string(formatMessage("a@example.com", "b@example.com", &sender.Message{Subject: subject}))
+
+		// headers end at the first empty line.
+		headers, _, ok := strings.Cut(msg, "\r\n\r\n")
+		require.True(t, ok)
+
+		var result string
+
+		for _, h := range strings.Split(headers, "\r\n") {
+			name, value, ok := strings.Cut(h, ": ")
+			require.True(t, ok, h)
+			require.NotEqual(t, "Bcc", name)
+
+			if name == "Subject" {
+				result = value
+			}
+		}
+
+		return result
+	}
+
+	// control characters in source paths can't inject additional headers.
+	require.Equal(t, "Snapshot of /tmp/a  Bcc: x@example.com failed", formatSubject("Snapshot of /tmp/a\r\nBcc: x@example.com failed"))
+	require.Equal(t, "Snapshot of /tmp/a b failed", formatSubject("Snapshot of /tmp/a\tb failed"))
+
+	// non-ASCII text is encoded.
+	require.Equal(t, "=?utf-8?q?Snapshot_of_/tmp/=C5=BC_failed?=", formatSubject("Snapshot of /tmp/\u017c failed"))
+}
+
+func TestNewSender(t *testing.T) {
+	_, err := NewSender(Options{From: "a@example.com", To: "b@example.com"})
+	require.Error(t, err)
+
+	_, err = NewSender(Options{SMTPServer: "smtp.example.com", To: "b@example.com"})
+	require.Error(t, err)
+
+	s, err := NewSender(Options{SMTPServer: "smtp.example.com", From: "a@example.com", To: "b@example.com, c@example.com"})
+	require.NoError(t, err)
+
+	es := s.(*emailSender)
+	require.Equal(t, defaultSMTPPort, es.opt.SMTPPort)
+	require.Equal(t, []string{"b@example.com", "c@example.com"}, es.recipients())
+}
+
+func TestSendHonorsContext(t *testing.T) {
+	// server which accepts connections but never responds.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	defer l.Close()
+
+	go func() {
+		var conns []net.Conn
+
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				for _, c := range conns {
+					c.Close()
+				}
+
+				return
+			}
+
+			conns = append(conns, c)
+		}
+	}()
+
+	s, err := NewSender(Options{
+		SMTPServer: "127.0.0.1",
+		SMTPPort:   l.Addr().(*net.TCPAddr).Port,
+		From:       "a@example.com",
+		To:         "b@example.com",
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- s.Send(ctx, &sender.Message{Subject: "x", Body: "y"})
+	}()
+
+	select {
+	case err := <-done:
+		require.Error(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("Send did not honor context deadline")
+	}
+}
@@ -0,0 +1,71 @@
+// Package sender defines the common interface implemented by notification senders.
+package sender
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// Severity represents the severity of a notification message.
+type Severity int32
+
+// Supported severities.
+const (
+	SeverityInfo    Severity = -10
+	SeveritySuccess Severity = 0
+	SeverityWarning Severity = 10
+	SeverityError   Severity = 20
+)
+
+//nolint:gochecknoglobals
+var severityNames = map[Severity]string{
+	SeverityInfo:    "info",
+	SeveritySuccess: "success",
+	SeverityWarning: "warning",
+	SeverityError:   "error",
+}
+
+// SeverityNames returns the names of all supported severities in increasing order.
+func SeverityNames() []string {
+	return []string{
+		severityNames[SeverityInfo],
+		severityNames[SeveritySuccess],
+		severityNames[SeverityWarning],
+		severityNames[SeverityError],
+	}
+}
+
+// ParseSeverity parses the name of the severity.
+func ParseSeverity(s string) (Severity, error) {
+	for sev, name := range severityNames {
+		if name == s {
+			return sev, nil
+		}
+	}
+
+	return SeveritySuccess, errors.Errorf("unknown severity %q", s)
+}
+
+func (s Severity) String() string {
+	if n, ok := severityNames[s]; ok {
+		return n
+	}
+
+	return "unknown"
+}
+
+// Message represents a notification message.
+type Message struct {
+	Subject  string   `json:"subject"`
+	Body     string   `json:"body"`
+	Severity Severity `json:"severity"`
+}
+
+// Sender sends notification messages.
+type Sender interface {
+	Send(ctx context.Context, msg *Message) error
+
+	// Summary returns human-readable description of the sender.
+	Summary() string
+}
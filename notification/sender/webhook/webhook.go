@@ -0,0 +1,139 @@
+// Package webhook implements a notification sender that delivers messages using HTTP requests.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/notification/sender"
+)
+
+// defaultTimeout is the maximum time allowed for a webhook request, in addition to the deadline of the context.
+const defaultTimeout = 30 * time.Second
+
+// Supported payload formats.
+const (
+	FormatJSON    = "json"
+	FormatText    = "text"
+	FormatSlack   = "slack"
+	FormatDiscord = "discord"
+)
+
+// SupportedFormats returns the list of supported payload formats.
+func SupportedFormats() []string {
+	return []string{FormatJSON, FormatText, FormatSlack, FormatDiscord}
+}
+
+// Options defines webhook sender options.
+type Options struct {
+	Endpoint string   `json:"endpoint"`
+	Method   string   `json:"method,omitempty"`
+	Format   string   `json:"format,omitempty"`
+	Headers  []string `json:"headers,omitempty"`
+}
+
+type webhookSender struct {
+	opt Options
+
+	httpClient *http.Client
+}
+
+func (p *webhookSender) Send(ctx context.Context, msg *sender.Message) error {
+	body, contentType, err := p.payload(msg)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, p.opt.Method, p.opt.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "error preparing webhook request")
+	}
+
+	req.Header.Set("Content-Type", contentType)
+
+	for _, h := range p.opt.Headers {
+		name, value, ok := strings.Cut(h, ":")
+		if !ok {
+			return errors.Errorf("invalid header %q, must be 'Name: value'", h)
+		}
+
+		req.Header.Set(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "error sending webhook request")
+	}
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return errors.Errorf("webhook returned error status: %v", resp.Status)
+	}
+
+	return nil
+}
+
+func (p *webhookSender) payload(msg *sender.Message) (body []byte, contentType string, err error) {
+	var v interface{}
+
+	switch p.opt.Format {
+	case FormatText:
+		return []byte(msg.Subject + "\n\n" + msg.Body), "text/plain; charset=utf-8", nil
+
+	case FormatSlack:
+		v = map[string]string{"text": fmt.Sprintf("*%v*\n%v", msg.Subject, msg.Body)}
+
+	case FormatDiscord:
+		v = map[string]string{"content": fmt.Sprintf("**%v**\n%v", msg.Subject, msg.Body)}
+
+	case FormatJSON, "":
+		v = struct {
+			Subject  string `json:"subject"`
+			Body     string `json:"body"`
+			Severity string `json:"severity"`
+		}{msg.Subject, msg.Body, msg.Severity.String()}
+
+	default:
+		return nil, "", errors.Errorf("unsupported webhook format %q", p.opt.Format)
+	}
+
+	body, err = json.Marshal(v)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "unable to serialize webhook payload")
+	}
+
+	return body, "application/json", nil
+}
+
+func (p *webhookSender) Summary() string {
+	return fmt.Sprintf("Webhook %v %v", p.opt.Method, p.opt.Endpoint)
+}
+
+// NewSender returns new webhook sender.
+func NewSender(opt Options) (sender.Sender, error) {
+	if opt.Endpoint == "" {
+		return nil, errors.New("webhook endpoint must be provided")
+	}
+
+	if opt.Method == "" {
+		opt.Method = http.MethodPost
+	}
+
+	if opt.Format != "" && !slices.Contains(SupportedFormats(), opt.Format) {
+		return nil, errors.Errorf("unsupported webhook format %q", opt.Format)
+	}
+
+	return &webhookSender{
+		opt:        opt,
+		httpClient: &http.Client{Timeout: defaultTimeout},
+	}, nil
+}
@@ -0,0 +1,91 @@
+package webhook_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testlogging"
+	"github.com/kopia/kopia/notification/sender"
+	"github.com/kopia/kopia/notification/sender/webhook"
+)
+
+func TestWebhook(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	var (
+		gotMethod      string
+		gotContentType string
+		gotHeader      string
+		gotBody        []byte
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod = r.Method
+		gotContentType = r.Header.Get("Content-Type")
+		gotHeader = r.Header.Get("X-Token")
+		gotBody, _ = io.ReadAll(r.Body)
+
+		if r.URL.Path == "/fail" {
+			http.Error(w, "failure", http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	msg := &sender.Message{Subject: "some subject", Body: "some body", Severity: sender.SeverityError}
+
+	cases := []struct {
+		format          string
+		wantContentType string
+		wantBody        string
+	}{
+		{"", "application/json", `{"subject":"some subject","body":"some body","severity":"error"}`},
+		{webhook.FormatJSON, "application/json", `{"subject":"some subject","body":"some body","severity":"error"}`},
+		{webhook.FormatSlack, "application/json", `{"text":"*some subject*\nsome body"}`},
+		{webhook.FormatDiscord, "application/json", `{"content":"**some subject**\nsome body"}`},
+		{webhook.FormatText, "text/plain; charset=utf-8", "some subject\n\nsome body"},
+	}
+
+	for _, tc := range cases {
+		s, err := webhook.NewSender(webhook.Options{
+			Endpoint: srv.URL,
+			Format:   tc.format,
+			Headers:  []string{"X-Token: secret"},
+		})
+		require.NoError(t, err)
+		require.NoError(t, s.Send(ctx, msg))
+
+		require.Equal(t, http.MethodPost, gotMethod)
+		require.Equal(t, tc.wantContentType, gotContentType)
+		require.Equal(t, "secret", gotHeader)
+
+		if tc.wantContentType == "application/json" {
+			require.True(t, json.Valid(gotBody))
+		}
+
+		require.Equal(t, tc.wantBody, string(gotBody))
+	}
+
+	s, err := webhook.NewSender(webhook.Options{Endpoint: srv.URL, Method: http.MethodPut})
+	require.NoError(t, err)
+	require.NoError(t, s.Send(ctx, msg))
+	require.Equal(t, http.MethodPut, gotMethod)
+
+	s, err = webhook.NewSender(webhook.Options{Endpoint: srv.URL + "/fail"})
+	require.NoError(t, err)
+	require.Error(t, s.Send(ctx, msg))
+
+	s, err = webhook.NewSender(webhook.Options{Endpoint: srv.URL, Headers: []string{"invalid"}})
+	require.NoError(t, err)
+	require.Error(t, s.Send(ctx, msg))
+
+	_, err = webhook.NewSender(webhook.Options{Endpoint: srv.URL, Format: "no-such-format"})
+	require.Error(t, err)
+
+	_, err = webhook.NewSender(webhook.Options{})
+	require.Error(t, err)
+}
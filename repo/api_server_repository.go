@@ -2,17 +2,43 @@
 
 import (
 	"context"
+	"crypto/tls"
 
 	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/tlsutil"
 )
 
 // APIServerInfo is remote repository configuration stored in local configuration.
 type APIServerInfo struct {
 	BaseURL                             string `json:"url"`
 	TrustedServerCertificateFingerprint string `json:"serverCertFingerprint"`
+	ClientCertificateFile               string `json:"clientCertFile,omitempty"`
+	ClientKeyFile                       string `json:"clientKeyFile,omitempty"`
 	LocalCacheKeyDerivationAlgorithm    string `json:"localCacheKeyDerivationAlgorithm,omitempty"`
 }
 
+// clientTLSConfig returns the TLS configuration used to connect to the server, which presents
+// the client certificate if one is configured.
+func (si *APIServerInfo) clientTLSConfig() (*tls.Config, error) {
+	tc := &tls.Config{} //nolint:gosec
+
+	if si.TrustedServerCertificateFingerprint != "" {
+		tc = tlsutil.TLSConfigTrustingSingleCertificate(si.TrustedServerCertificateFingerprint)
+	}
+
+	if si.ClientCertificateFile != "" || si.ClientKeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(si.ClientCertificateFile, si.ClientKeyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to load client certificate")
+		}
+
+		tc.Certificates = []tls.Certificate{cert}
+	}
+
+	return tc, nil
+}
+
 // ConnectAPIServer sets up repository connection to a particular API server.
 func ConnectAPIServer(ctx context.Context, configFile string, si *APIServerInfo, password string, opt *ConnectOptions) error {
 	lc := LocalConfig{
@@ -24,7 +50,7 @@ func ConnectAPIServer(ctx context.Context, configFile string, si *APIServerInfo,
 		return errors.Wrap(err, "unable to set up caching")
 	}
 
-	if err := lc.writeToFile(configFile); err != nil {
+	if err := lc.writeToFile(ctx, configFile); err != nil {
 		return errors.Wrap(err, "unable to write config file")
 	}
 
@@ -0,0 +1,364 @@
+package repo
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo/format"
+	"github.com/kopia/kopia/repo/manifest"
+)
+
+// AuditLogManifestType is the type of manifests that store audit log records.
+const AuditLogManifestType = "auditlog"
+
+const (
+	auditLogSeqLabel = "seq"
+	auditLogKeyLen   = 32
+)
+
+// Actions recorded in the audit log.
+const (
+	AuditActionEnable           = "enable-audit-log"
+	AuditActionPutManifest      = "put-manifest"
+	AuditActionDeleteManifest   = "delete-manifest"
+	AuditActionChangePassword   = format.CredentialChangePassword
+	AuditActionAddKeySlot       = format.CredentialChangeAddKeySlot
+	AuditActionRemoveKeySlot    = format.CredentialChangeRemoveKeySlot
+	AuditActionSetKeySlotPasswd = format.CredentialChangeKeySlotPassword
+	AuditActionPrune            = "prune-audit-log"
+)
+
+// ErrAuditLogTampered is returned when the audit log chain fails verification.
+var ErrAuditLogTampered = errors.New("audit log has been tampered with")
+
+//nolint:gochecknoglobals
+var auditLogKeyPurpose = []byte("audit-log")
+
+// AuditRecord describes a single mutation of the repository.
+//
+// Each record includes the HMAC of the record preceding it, so removing or modifying
+// any record other than the most recent one breaks the chain.
+type AuditRecord struct {
+	Seq      int64     `json:"seq"`
+	Time     time.Time `json:"time"`
+	User     string    `json:"user"`
+	Action   string    `json:"action"`
+	ItemID   string    `json:"itemID,omitempty"`
+	ItemType string    `json:"itemType,omitempty"`
+	Prev     []byte    `json:"prev,omitempty"`
+	HMAC     []byte    `json:"hmac"`
+}
+
+func (ar *AuditRecord) computeHMAC(key []byte) []byte {
+	tmp := *ar
+	tmp.HMAC = nil
+
+	b, _ := json.Marshal(tmp) //nolint:errchkjson
+
+	h := hmac.New(sha256.New, key)
+	h.Write(b) //nolint:errcheck
+
+	return h.Sum(nil)
+}
+
+// auditLogState tracks the tip of the audit log chain for a single write session.
+type auditLogState struct {
+	mu sync.Mutex
+
+	// +checklocks:mu
+	loaded bool
+	// +checklocks:mu
+	head *AuditRecord
+}
+
+// reset forgets the tip of the chain, which is reloaded on next append.
+func (s *auditLogState) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.loaded = false
+	s.head = nil
+}
+
+// auditItem describes a single action to be recorded in the audit log.
+type auditItem struct {
+	action   string
+	itemID   string
+	itemType string
+}
+
+// AppendAuditRecord appends a record describing the provided action to the audit log.
+// Records are only written once the audit log has been enabled by appending AuditActionEnable.
+func (r *directRepository) AppendAuditRecord(ctx context.Context, action, itemID, itemType string) error {
+	return r.appendAuditRecords(ctx, auditItem{action, itemID, itemType})
+}
+
+// appendAuditRecords appends records describing the provided items to the audit log, either all
+// records are appended or none of them.
+func (r *directRepository) appendAuditRecords(ctx context.Context, items ...auditItem) error {
+	r.audit.mu.Lock()
+	defer r.audit.mu.Unlock()
+
+	if !r.audit.loaded {
+		head, err := findAuditLogHead(ctx, r)
+		if err != nil {
+			return err
+		}
+
+		r.audit.head = head
+		r.audit.loaded = true
+	}
+
+	if len(items) == 0 || r.audit.head == nil && items[0].action != AuditActionEnable {
+		return nil
+	}
+
+	user := r.auditUser
+	if user == "" {
+		user = r.cliOpts.UsernameAtHost()
+	}
+
+	key := r.DeriveKey(auditLogKeyPurpose, auditLogKeyLen)
+	head := r.audit.head
+
+	var written []manifest.ID
+
+	for _, it := range items {
+		ar := &AuditRecord{
+			Time:     r.Time().UTC(),
+			User:     user,
+			Action:   it.action,
+			ItemID:   it.itemID,
+			ItemType: it.itemType,
+		}
+
+		if head != nil {
+			ar.Seq = head.Seq + 1
+			ar.Prev = head.HMAC
+		}
+
+		ar.HMAC = ar.computeHMAC(key)
+
+		id, err := r.mmgr.Put(ctx, map[string]string{
+			manifest.TypeLabelKey: AuditLogManifestType,
+			auditLogSeqLabel:      strconv.FormatInt(ar.Seq, 10),
+		}, ar)
+		if err != nil {
+			r.mmgr.RestorePending(nil, written...)
+
+			return errors.Wrap(err, "unable to write audit record")
+		}
+
+		written = append(written, id)
+		head = ar
+	}
+
+	r.audit.head = head
+
+	return nil
+}
+
+// recordCredentialChange appends and flushes the audit record of a credential change, which is invoked by
+// the format manager before the change is committed, so that no credential change goes unrecorded.
+func (r *directRepository) recordCredentialChange(ctx context.Context, action, keySlot string) error {
+	return DirectWriteSession(ctx, r, WriteSessionOptions{
+		Purpose: "audit:" + action,
+	}, func(ctx context.Context, w DirectRepositoryWriter) error {
+		return w.AppendAuditRecord(ctx, action, keySlot, "")
+	})
+}
+
+// PruneAuditLog removes audit records older than the provided time, except for the most recent one,
+// and returns the number of removed records. The chain remains verifiable, because a signed record
+// stating the sequence number of the oldest retained record is appended before removal.
+func (r *directRepository) PruneAuditLog(ctx context.Context, olderThan time.Time) (int, error) {
+	entries, err := r.mmgr.Find(ctx, map[string]string{manifest.TypeLabelKey: AuditLogManifestType})
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to find audit records")
+	}
+
+	var (
+		headSeq int64 = -1
+		cutoff  int64
+	)
+
+	seqs := map[manifest.ID]int64{}
+
+	for _, e := range entries {
+		seq, err := strconv.ParseInt(e.Labels[auditLogSeqLabel], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		seqs[e.ID] = seq
+		headSeq = max(headSeq, seq)
+	}
+
+	for _, e := range entries {
+		ar := &AuditRecord{}
+		if _, err := r.mmgr.Get(ctx, e.ID, ar); err != nil {
+			return 0, errors.Wrapf(err, "unable to read audit record %v", e.ID)
+		}
+
+		// records are removed up to the newest one which is older than the cutoff time.
+		if ar.Time.Before(olderThan) && ar.Seq < headSeq {
+			cutoff = max(cutoff, ar.Seq+1)
+		}
+	}
+
+	if cutoff == 0 {
+		return 0, nil
+	}
+
+	if err := r.AppendAuditRecord(ctx, AuditActionPrune, strconv.FormatInt(cutoff, 10), ""); err != nil {
+		return 0, err
+	}
+
+	removed := 0
+
+	for id, seq := range seqs {
+		if seq >= cutoff {
+			continue
+		}
+
+		if err := r.mmgr.Delete(ctx, id); err != nil {
+			return removed, errors.Wrapf(err, "unable to remove audit record %v", id)
+		}
+
+		removed++
+	}
+
+	return removed, nil
+}
+
+// findAuditLogHead returns the audit record with the highest sequence number or nil if the audit log is not enabled.
+func findAuditLogHead(ctx context.Context, rep Repository) (*AuditRecord, error) {
+	entries, err := rep.FindManifests(ctx, map[string]string{manifest.TypeLabelKey: AuditLogManifestType})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to find audit records")
+	}
+
+	var (
+		headID  manifest.ID
+		headSeq int64 = -1
+	)
+
+	for _, e := range entries {
+		seq, err := strconv.ParseInt(e.Labels[auditLogSeqLabel], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		if seq > headSeq {
+			headID, headSeq = e.ID, seq
+		}
+	}
+
+	if headID == "" {
+		return nil, nil
+	}
+
+	ar := &AuditRecord{}
+	if _, err := rep.GetManifest(ctx, headID, ar); err != nil {
+		return nil, errors.Wrap(err, "unable to read audit record")
+	}
+
+	return ar, nil
+}
+
+// ReadAuditLog returns all audit records ordered by sequence number and time.
+func ReadAuditLog(ctx context.Context, rep Repository) ([]*AuditRecord, error) {
+	entries, err := rep.FindManifests(ctx, map[string]string{manifest.TypeLabelKey: AuditLogManifestType})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to find audit records")
+	}
+
+	result := make([]*AuditRecord, 0, len(entries))
+
+	for _, e := range entries {
+		ar := &AuditRecord{}
+		if _, err := rep.GetManifest(ctx, e.ID, ar); err != nil {
+			return nil, errors.Wrapf(err, "unable to read audit record %v", e.ID)
+		}
+
+		result = append(result, ar)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Seq != result[j].Seq {
+			return result[i].Seq < result[j].Seq
+		}
+
+		return result[i].Time.Before(result[j].Time)
+	})
+
+	return result, nil
+}
+
+// VerifyAuditLog verifies the integrity of the provided audit records, which must be ordered
+// by sequence number, using the key derived from the repository master key.
+//
+// Concurrent writers may append records to the same tip of the chain, so the chain is allowed to fork,
+// but every record must be authentic and must link to an existing predecessor, unless it has been removed
+// by PruneAuditLog. Returns the sequence numbers at which the chain forks, which should be reviewed,
+// since a fork may also result from a writer that was rolled back to an earlier state.
+func VerifyAuditLog(rep DirectRepository, records []*AuditRecord) ([]int64, error) {
+	key := rep.DeriveKey(auditLogKeyPurpose, auditLogKeyLen)
+	seqByHMAC := map[string]int64{}
+	recordsBySeq := map[int64]int{}
+
+	var (
+		oldestRetained int64
+		forks          []int64
+	)
+
+	for _, ar := range records {
+		if !hmac.Equal(ar.computeHMAC(key), ar.HMAC) {
+			return nil, errors.Wrapf(ErrAuditLogTampered, "record %v has invalid signature", ar.Seq)
+		}
+
+		if ar.Action == AuditActionPrune {
+			v, err := strconv.ParseInt(ar.ItemID, 10, 64)
+			if err != nil {
+				return nil, errors.Wrapf(ErrAuditLogTampered, "invalid prune record %v", ar.Seq)
+			}
+
+			oldestRetained = max(oldestRetained, v)
+		}
+	}
+
+	for _, ar := range records {
+		switch {
+		case ar.Seq == 0:
+			if len(ar.Prev) != 0 {
+				return nil, errors.Wrapf(ErrAuditLogTampered, "initial record has a predecessor")
+			}
+
+		case ar.Seq <= oldestRetained:
+			// predecessors of the oldest retained record have been pruned.
+
+		default:
+			prevSeq, ok := seqByHMAC[string(ar.Prev)]
+			if !ok || prevSeq != ar.Seq-1 {
+				return nil, errors.Wrapf(ErrAuditLogTampered, "predecessor of record %v is missing", ar.Seq)
+			}
+		}
+
+		seqByHMAC[string(ar.HMAC)] = ar.Seq
+
+		recordsBySeq[ar.Seq]++
+		if recordsBySeq[ar.Seq] == 2 { //nolint:mnd
+			forks = append(forks, ar.Seq)
+		}
+	}
+
+	return forks, nil
+}
@@ -0,0 +1,327 @@
+package repo_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/crypto"
+	"github.com/kopia/kopia/internal/repotesting"
+	"github.com/kopia/kopia/internal/testlogging"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/manifest"
+)
+
+func TestAuditLog(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+	w := env.RepositoryWriter
+
+	labels := map[string]string{manifest.TypeLabelKey: "item"}
+
+	// mutations are not recorded until the audit log is enabled.
+	_, err := w.PutManifest(ctx, labels, "before")
+	require.NoError(t, err)
+
+	records, err := repo.ReadAuditLog(ctx, w)
+	require.NoError(t, err)
+	require.Empty(t, records)
+
+	require.NoError(t, w.AppendAuditRecord(ctx, repo.AuditActionEnable, "", ""))
+
+	id1, err := w.PutManifest(ctx, labels, "first")
+	require.NoError(t, err)
+	require.NoError(t, w.Flush(ctx))
+
+	env.MustReopen(t)
+	w = env.RepositoryWriter
+
+	id2, err := w.PutManifest(ctx, labels, "second")
+	require.NoError(t, err)
+	require.NoError(t, w.DeleteManifest(ctx, id1))
+	require.NoError(t, w.AppendAuditRecord(ctx, repo.AuditActionChangePassword, "", ""))
+	require.NoError(t, w.Flush(ctx))
+
+	records, err = repo.ReadAuditLog(ctx, w)
+	require.NoError(t, err)
+	require.Len(t, records, 5)
+
+	var actions []string
+
+	for i, ar := range records {
+		require.EqualValues(t, i, ar.Seq)
+		require.Equal(t, w.ClientOptions().UsernameAtHost(), ar.User)
+
+		actions = append(actions, ar.Action)
+	}
+
+	require.Equal(t, []string{
+		repo.AuditActionEnable,
+		repo.AuditActionPutManifest,
+		repo.AuditActionPutManifest,
+		repo.AuditActionDeleteManifest,
+		repo.AuditActionChangePassword,
+	}, actions)
+	require.Equal(t, string(id1), records[1].ItemID)
+	require.Equal(t, "item", records[1].ItemType)
+	require.Equal(t, string(id2), records[2].ItemID)
+	require.Equal(t, string(id1), records[3].ItemID)
+
+	forks, err := repo.VerifyAuditLog(w, records)
+	require.NoError(t, err)
+	require.Empty(t, forks)
+
+	// audit records are append-only.
+	auditEntries, err := w.FindManifests(ctx, map[string]string{manifest.TypeLabelKey: repo.AuditLogManifestType})
+	require.NoError(t, err)
+	require.Len(t, auditEntries, 5)
+	require.Error(t, w.DeleteManifest(ctx, auditEntries[0].ID))
+
+	_, err = w.PutManifest(ctx, map[string]string{manifest.TypeLabelKey: repo.AuditLogManifestType}, records[0])
+	require.Error(t, err)
+}
+
+func TestAuditLogTampering(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+	w := env.RepositoryWriter
+
+	require.NoError(t, w.AppendAuditRecord(ctx, repo.AuditActionEnable, "", ""))
+
+	for range 3 {
+		_, err := w.PutManifest(ctx, map[string]string{manifest.TypeLabelKey: "item"}, "x")
+		require.NoError(t, err)
+	}
+
+	load := func() []*repo.AuditRecord {
+		records, err := repo.ReadAuditLog(testlogging.Context(t), w)
+		require.NoError(t, err)
+		require.Len(t, records, 4)
+
+		return records
+	}
+
+	_, err := repo.VerifyAuditLog(w, load())
+	require.NoError(t, err)
+
+	modified := load()
+	modified[2].ItemID = "other"
+	_, err = repo.VerifyAuditLog(w, modified)
+	require.ErrorIs(t, err, repo.ErrAuditLogTampered)
+
+	removed := load()
+	removed = append(removed[:1], removed[2:]...)
+	_, err = repo.VerifyAuditLog(w, removed)
+	require.ErrorIs(t, err, repo.ErrAuditLogTampered)
+
+	// records from a different repository are not accepted.
+	_, other := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+	_, err = repo.VerifyAuditLog(other.RepositoryWriter, load())
+	require.ErrorIs(t, err, repo.ErrAuditLogTampered)
+}
+
+func TestAuditLogSessionUserAndForks(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+
+	require.NoError(t, env.RepositoryWriter.AppendAuditRecord(ctx, repo.AuditActionEnable, "", ""))
+	require.NoError(t, env.RepositoryWriter.Flush(ctx))
+
+	dr := env.Repository.(repo.DirectRepository)
+
+	// two concurrent sessions append to the same tip of the chain.
+	var sessions []repo.DirectRepositoryWriter
+
+	for _, user := range []string{"alice@remote", "bob@remote"} {
+		_, w, err := dr.NewDirectWriter(ctx, repo.WriteSessionOptions{Purpose: "test", AuditUser: user})
+		require.NoError(t, err)
+
+		defer w.Close(ctx)
+
+		sessions = append(sessions, w)
+	}
+
+	for _, w := range sessions {
+		_, err := w.PutManifest(ctx, map[string]string{manifest.TypeLabelKey: "item"}, "x")
+		require.NoError(t, err)
+	}
+
+	for _, w := range sessions {
+		require.NoError(t, w.Flush(ctx))
+	}
+
+	env.MustReopen(t)
+
+	records, err := repo.ReadAuditLog(ctx, env.RepositoryWriter)
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+
+	users := []string{records[1].User, records[2].User}
+	require.ElementsMatch(t, []string{"alice@remote", "bob@remote"}, users)
+
+	forks, err := repo.VerifyAuditLog(env.RepositoryWriter, records)
+	require.NoError(t, err)
+	require.Equal(t, []int64{1}, forks)
+}
+
+func TestAuditLogPrune(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+	w := env.RepositoryWriter
+
+	require.NoError(t, w.AppendAuditRecord(ctx, repo.AuditActionEnable, "", ""))
+
+	for range 2 {
+		_, err := w.PutManifest(ctx, map[string]string{manifest.TypeLabelKey: "item"}, "x")
+		require.NoError(t, err)
+	}
+
+	// nothing is older than the cutoff.
+	n, err := w.PruneAuditLog(ctx, w.Time().Add(-time.Hour))
+	require.NoError(t, err)
+	require.Zero(t, n)
+
+	// all records except the most recent one are removed and a prune record is appended.
+	n, err = w.PruneAuditLog(ctx, w.Time().Add(time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	require.NoError(t, w.Flush(ctx))
+
+	records, err := repo.ReadAuditLog(ctx, w)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	require.EqualValues(t, 2, records[0].Seq)
+	require.Equal(t, repo.AuditActionPrune, records[1].Action)
+
+	_, err = repo.VerifyAuditLog(w, records)
+	require.NoError(t, err)
+
+	// new records continue the chain.
+	_, err = w.PutManifest(ctx, map[string]string{manifest.TypeLabelKey: "item"}, "y")
+	require.NoError(t, err)
+
+	records, err = repo.ReadAuditLog(ctx, w)
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+
+	_, err = repo.VerifyAuditLog(w, records)
+	require.NoError(t, err)
+
+	// removing the prune record is detected.
+	_, err = repo.VerifyAuditLog(w, append(records[:1], records[2:]...))
+	require.ErrorIs(t, err, repo.ErrAuditLogTampered)
+}
+
+func TestAuditLogCredentialChanges(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+	w := env.RepositoryWriter
+
+	require.NoError(t, w.AppendAuditRecord(ctx, repo.AuditActionEnable, "", ""))
+	require.NoError(t, w.Flush(ctx))
+
+	// credential changes made directly through the format manager are recorded and flushed
+	// before they are committed.
+	require.NoError(t, w.FormatManager().AddKeySlot(ctx, "extra", "extra-password"))
+	require.NoError(t, w.FormatManager().RemoveKeySlot(ctx, "extra"))
+
+	env.MustReopen(t)
+
+	records, err := repo.ReadAuditLog(ctx, env.RepositoryWriter)
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+
+	require.Equal(t, repo.AuditActionAddKeySlot, records[1].Action)
+	require.Equal(t, "extra", records[1].ItemID)
+	require.Equal(t, repo.AuditActionRemoveKeySlot, records[2].Action)
+
+	forks, err := repo.VerifyAuditLog(env.RepositoryWriter, records)
+	require.NoError(t, err)
+	require.Empty(t, forks)
+}
+
+func TestAuditLogImportManifests(t *testing.T) {
+	ctx, src := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+
+	var srcIDs []string
+
+	for _, v := range []string{"a", "b"} {
+		id, err := src.RepositoryWriter.PutManifest(ctx, map[string]string{manifest.TypeLabelKey: "item"}, v)
+		require.NoError(t, err)
+
+		srcIDs = append(srcIDs, string(id))
+	}
+
+	opt := manifest.ArchiveOptions{Password: "archive-password", KeyDerivationAlgorithm: crypto.ScryptAlgorithmWithParams(1<<14, 8, 1)}
+
+	var buf bytes.Buffer
+
+	_, err := src.RepositoryWriter.ExportManifests(ctx, &buf, opt)
+	require.NoError(t, err)
+
+	_, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+	w := env.RepositoryWriter
+
+	require.NoError(t, w.AppendAuditRecord(ctx, repo.AuditActionEnable, "", ""))
+
+	n, err := w.ImportManifests(ctx, bytes.NewReader(buf.Bytes()), opt)
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+
+	records, err := repo.ReadAuditLog(ctx, w)
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+
+	// each imported manifest gets its own record.
+	var importedIDs []string
+
+	for _, ar := range records[1:] {
+		require.Equal(t, repo.AuditActionPutManifest, ar.Action)
+		require.Equal(t, "item", ar.ItemType)
+
+		importedIDs = append(importedIDs, ar.ItemID)
+	}
+
+	require.ElementsMatch(t, srcIDs, importedIDs)
+
+	forks, err := repo.VerifyAuditLog(w, records)
+	require.NoError(t, err)
+	require.Empty(t, forks)
+}
+
+func TestAuditLogImportManifestsFromAuditedRepository(t *testing.T) {
+	ctx, src := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+
+	require.NoError(t, src.RepositoryWriter.AppendAuditRecord(ctx, repo.AuditActionEnable, "", ""))
+
+	_, err := src.RepositoryWriter.PutManifest(ctx, map[string]string{manifest.TypeLabelKey: "item"}, "a")
+	require.NoError(t, err)
+
+	opt := manifest.ArchiveOptions{Password: "archive-password", KeyDerivationAlgorithm: crypto.ScryptAlgorithmWithParams(1<<14, 8, 1)}
+
+	var buf bytes.Buffer
+
+	// audit records of the source repository are not exported.
+	n, err := src.RepositoryWriter.ExportManifests(ctx, &buf, opt)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	_, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+	w := env.RepositoryWriter
+
+	n, err = w.ImportManifests(ctx, bytes.NewReader(buf.Bytes()), opt)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	// auditing was never enabled in the destination, so no records are written.
+	records, err := repo.ReadAuditLog(ctx, w)
+	require.NoError(t, err)
+	require.Empty(t, records)
+
+	require.NoError(t, w.AppendAuditRecord(ctx, repo.AuditActionEnable, "", ""))
+
+	records, err = repo.ReadAuditLog(ctx, w)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+
+	forks, err := repo.VerifyAuditLog(w, records)
+	require.NoError(t, err)
+	require.Empty(t, forks)
+}
@@ -4,6 +4,7 @@
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"strings"
 	"time"
 
@@ -126,6 +127,12 @@ func translateError(err error) error {
 			return blob.ErrBlobNotFound
 		case string(bloberror.InvalidRange):
 			return blob.ErrInvalidRange
+		case string(bloberror.ServerBusy):
+			return errors.WithMessagef(blob.ErrThrottled, "%v", err)
+		}
+
+		if re.StatusCode == http.StatusForbidden {
+			return errors.WithMessagef(blob.ErrAccessDenied, "%v", err)
 		}
 	}
 
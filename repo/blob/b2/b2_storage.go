@@ -139,6 +139,12 @@ func translateError(err error) error {
 
 		case http.StatusRequestedRangeNotSatisfiable:
 			return blob.ErrInvalidRange
+
+		case http.StatusForbidden:
+			return errors.WithMessagef(blob.ErrAccessDenied, "%v", err)
+
+		case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+			return errors.WithMessagef(blob.ErrThrottled, "%v", err)
 		}
 	}
 
@@ -198,6 +204,11 @@ func (s *b2Storage) ListBlobs(ctx context.Context, prefix blob.ID, callback func
 	nextFile := ""
 
 	for {
+		// the B2 client does not accept a context, so check for cancellation between pages.
+		if err := ctx.Err(); err != nil {
+			return err //nolint:wrapcheck
+		}
+
 		resp, err := s.bucket.ListFileNamesWithPrefix(nextFile, maxFileQuery, fullPrefix, "")
 		if err != nil {
 			//nolint:wrapcheck
@@ -0,0 +1,299 @@
+// Package faulty implements a wrapper around Storage that injects errors, latency and partial writes.
+//
+// Faults can be programmed for individual methods using AddFault() or injected randomly according to Options.
+// All randomness is derived from Options.Seed, so a given sequence of calls against a wrapper
+// produces the same faults every time, which makes it suitable for deterministic tests of
+// retry, verification and repair logic.
+package faulty
+
+import (
+	"bytes"
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/fault"
+	"github.com/kopia/kopia/internal/gather"
+	"github.com/kopia/kopia/repo/blob"
+)
+
+// Method identifies a storage method into which faults can be injected.
+type Method = fault.Method
+
+// Fault describes the behavior of a fault programmed using AddFault() or AddFaults().
+type Fault = fault.Fault
+
+// NewFault creates a new fault that can be programmed using AddFaults().
+func NewFault() *Fault {
+	return fault.New()
+}
+
+// Supported faulty methods.
+const (
+	MethodGetBlob Method = iota
+	MethodGetMetadata
+	MethodPutBlob
+	MethodDeleteBlob
+	MethodListBlobs
+	MethodListBlobsItem
+	MethodClose
+	MethodFlushCaches
+	MethodGetCapacity
+)
+
+// ErrInjected is the default error returned for randomly injected faults.
+var ErrInjected = errors.New("injected fault")
+
+// Options controls which faults are injected randomly.
+type Options struct {
+	// Seed initializes the random number generator that decides which calls fail.
+	Seed int64
+
+	// ErrorRate is the probability (0..1) that any individual storage call fails with Err.
+	ErrorRate float64
+
+	// PartialWriteRate is the probability (0..1) that PutBlob only writes a prefix of the data
+	// to the underlying storage and then fails with Err.
+	PartialWriteRate float64
+
+	// Latency is the delay added before each storage call.
+	Latency time.Duration
+
+	// Err is the error returned by failed calls, defaults to ErrInjected.
+	// Note that the retrying wrapper treats errors it does not recognize as retriable.
+	Err error
+}
+
+// Stats contains counters of randomly injected faults.
+type Stats struct {
+	Calls         int
+	Errors        int
+	PartialWrites int
+}
+
+// Storage is a blob.Storage with fault injection.
+type Storage struct {
+	base blob.Storage
+
+	*fault.Set
+
+	opt Options
+
+	mu sync.Mutex
+	// +checklocks:mu
+	rnd *rand.Rand
+	// +checklocks:mu
+	stats Stats
+}
+
+// Stats returns the counters of randomly injected faults.
+func (s *Storage) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.stats
+}
+
+// roll returns true with the provided probability.
+func (s *Storage) roll(probability float64, counter *int) bool {
+	if probability <= 0 {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.rnd.Float64() >= probability {
+		return false
+	}
+
+	*counter++
+
+	return true
+}
+
+// prefixLength returns a random length in the [0, n) range.
+func (s *Storage) prefixLength(n int) int {
+	if n <= 0 {
+		return 0
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.rnd.Intn(n)
+}
+
+func (s *Storage) injectedError() error {
+	err := s.opt.Err
+	if err == nil {
+		err = ErrInjected
+	}
+
+	return err
+}
+
+// before is called before each call to the underlying storage, it returns the programmed fault for the method
+// if any, otherwise sleeps for the configured latency and returns a random error if the call should fail.
+func (s *Storage) before(ctx context.Context, method Method, args ...interface{}) (bool, error) {
+	if ok, err := s.GetNextFault(ctx, method, args...); ok {
+		return true, err
+	}
+
+	s.mu.Lock()
+	s.stats.Calls++
+	s.mu.Unlock()
+
+	if s.opt.Latency > 0 {
+		select {
+		case <-ctx.Done():
+			return true, errors.Wrap(ctx.Err(), "context canceled")
+		case <-time.After(s.opt.Latency):
+		}
+	}
+
+	if s.roll(s.opt.ErrorRate, &s.stats.Errors) {
+		return true, s.injectedError()
+	}
+
+	return false, nil
+}
+
+// IsReadOnly implements blob.Storage.
+func (s *Storage) IsReadOnly() bool {
+	return s.base.IsReadOnly()
+}
+
+// GetCapacity implements blob.Volume.
+func (s *Storage) GetCapacity(ctx context.Context) (blob.Capacity, error) {
+	if ok, err := s.before(ctx, MethodGetCapacity); ok {
+		return blob.Capacity{}, err
+	}
+
+	//nolint:wrapcheck
+	return s.base.GetCapacity(ctx)
+}
+
+// GetBlob implements blob.Storage.
+func (s *Storage) GetBlob(ctx context.Context, id blob.ID, offset, length int64, output blob.OutputBuffer) error {
+	if ok, err := s.before(ctx, MethodGetBlob, id, offset, length); ok {
+		return err
+	}
+
+	//nolint:wrapcheck
+	return s.base.GetBlob(ctx, id, offset, length, output)
+}
+
+// GetMetadata implements blob.Storage.
+func (s *Storage) GetMetadata(ctx context.Context, id blob.ID) (blob.Metadata, error) {
+	if ok, err := s.before(ctx, MethodGetMetadata, id); ok {
+		return blob.Metadata{}, err
+	}
+
+	//nolint:wrapcheck
+	return s.base.GetMetadata(ctx, id)
+}
+
+// PutBlob implements blob.Storage.
+func (s *Storage) PutBlob(ctx context.Context, id blob.ID, data blob.Bytes, opts blob.PutOptions) error {
+	if ok, err := s.before(ctx, MethodPutBlob, id); ok {
+		return err
+	}
+
+	if s.roll(s.opt.PartialWriteRate, &s.stats.PartialWrites) {
+		var b bytes.Buffer
+
+		if _, err := data.WriteTo(&b); err != nil {
+			return errors.Wrap(err, "error reading blob data")
+		}
+
+		partial := b.Bytes()[0:s.prefixLength(b.Len())]
+
+		if err := s.base.PutBlob(ctx, id, gather.FromSlice(partial), opts); err != nil {
+			return errors.Wrap(err, "error writing partial blob")
+		}
+
+		return s.injectedError()
+	}
+
+	//nolint:wrapcheck
+	return s.base.PutBlob(ctx, id, data, opts)
+}
+
+// DeleteBlob implements blob.Storage.
+func (s *Storage) DeleteBlob(ctx context.Context, id blob.ID) error {
+	if ok, err := s.before(ctx, MethodDeleteBlob, id); ok {
+		return err
+	}
+
+	//nolint:wrapcheck
+	return s.base.DeleteBlob(ctx, id)
+}
+
+// ListBlobs implements blob.Storage.
+func (s *Storage) ListBlobs(ctx context.Context, prefix blob.ID, callback func(blob.Metadata) error) error {
+	if ok, err := s.before(ctx, MethodListBlobs, prefix); ok {
+		return err
+	}
+
+	//nolint:wrapcheck
+	return s.base.ListBlobs(ctx, prefix, func(bm blob.Metadata) error {
+		if ok, err := s.GetNextFault(ctx, MethodListBlobsItem, prefix); ok {
+			return err
+		}
+
+		return callback(bm)
+	})
+}
+
+// Close implements blob.Storage.
+func (s *Storage) Close(ctx context.Context) error {
+	if ok, err := s.GetNextFault(ctx, MethodClose); ok {
+		return err
+	}
+
+	//nolint:wrapcheck
+	return s.base.Close(ctx)
+}
+
+// ConnectionInfo implements blob.Storage.
+func (s *Storage) ConnectionInfo() blob.ConnectionInfo {
+	return s.base.ConnectionInfo()
+}
+
+// DisplayName implements blob.Storage.
+func (s *Storage) DisplayName() string {
+	return s.base.DisplayName()
+}
+
+// FlushCaches implements blob.Storage.
+func (s *Storage) FlushCaches(ctx context.Context) error {
+	if ok, err := s.before(ctx, MethodFlushCaches); ok {
+		return err
+	}
+
+	//nolint:wrapcheck
+	return s.base.FlushCaches(ctx)
+}
+
+// ExtendBlobRetention implements blob.Storage.
+func (s *Storage) ExtendBlobRetention(ctx context.Context, b blob.ID, opts blob.ExtendOptions) error {
+	//nolint:wrapcheck
+	return s.base.ExtendBlobRetention(ctx, b, opts)
+}
+
+// NewWrapper returns a Storage wrapper that injects faults according to the provided options.
+// Additional faults can be programmed for individual methods using AddFault().
+func NewWrapper(wrapped blob.Storage, opt Options) *Storage {
+	return &Storage{
+		base: wrapped,
+		Set:  fault.NewSet(),
+		opt:  opt,
+		rnd:  rand.New(rand.NewSource(opt.Seed)), //nolint:gosec
+	}
+}
+
+var _ blob.Storage = (*Storage)(nil)
@@ -0,0 +1,116 @@
+package faulty_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/gather"
+	"github.com/kopia/kopia/internal/testlogging"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/blob/faulty"
+	"github.com/kopia/kopia/repo/blob/memory"
+	"github.com/kopia/kopia/repo/blob/retrying"
+)
+
+func TestFaultyStorageDeterministic(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	run := func() []bool {
+		st := faulty.NewWrapper(memory.New(memory.Options{}), faulty.Options{Seed: 42, ErrorRate: 0.3})
+
+		var results []bool
+
+		for range 100 {
+			results = append(results, st.PutBlob(ctx, "a", gather.FromSlice([]byte{1}), blob.PutOptions{}) == nil)
+		}
+
+		require.Equal(t, 100, st.Stats().Calls)
+		require.Positive(t, st.Stats().Errors)
+		require.Less(t, st.Stats().Errors, 100)
+
+		return results
+	}
+
+	require.Equal(t, run(), run())
+}
+
+func TestFaultyStorageCustomError(t *testing.T) {
+	ctx := testlogging.Context(t)
+	myErr := errors.New("my error")
+
+	st := faulty.NewWrapper(memory.New(memory.Options{}), faulty.Options{ErrorRate: 1, Err: myErr})
+
+	_, err := st.GetMetadata(ctx, "a")
+	require.ErrorIs(t, err, myErr)
+
+	require.ErrorIs(t, faulty.NewWrapper(memory.New(memory.Options{}), faulty.Options{ErrorRate: 1}).DeleteBlob(ctx, "a"), faulty.ErrInjected)
+}
+
+func TestFaultyStoragePartialWrites(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	base := memory.New(memory.Options{})
+	st := faulty.NewWrapper(base, faulty.Options{PartialWriteRate: 1})
+
+	data := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+
+	require.ErrorIs(t, st.PutBlob(ctx, "a", gather.FromSlice(data), blob.PutOptions{}), faulty.ErrInjected)
+	require.Equal(t, 1, st.Stats().PartialWrites)
+
+	var tmp gather.WriteBuffer
+	defer tmp.Close()
+
+	require.NoError(t, base.GetBlob(ctx, "a", 0, -1, &tmp))
+	require.Less(t, tmp.Length(), len(data))
+	require.Equal(t, data[0:tmp.Length()], tmp.ToByteSlice())
+}
+
+func TestFaultyStorageLatency(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	st := faulty.NewWrapper(memory.New(memory.Options{}), faulty.Options{Latency: time.Hour})
+
+	cctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+
+	require.ErrorIs(t, st.DeleteBlob(cctx, "a"), context.DeadlineExceeded)
+}
+
+func TestFaultyStorageWithRetries(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	st := retrying.NewWrapperWithPolicy(
+		faulty.NewWrapper(memory.New(memory.Options{}), faulty.Options{Seed: 1, ErrorRate: 0.5}),
+		retrying.Policy{MaxAttempts: 20, InitialDelay: time.Millisecond, MaxDelay: time.Millisecond})
+
+	for range 20 {
+		require.NoError(t, st.PutBlob(ctx, "a", gather.FromSlice([]byte{1, 2, 3}), blob.PutOptions{}))
+	}
+}
+
+func TestFaultyStorageProgrammedFaults(t *testing.T) {
+	ctx := testlogging.Context(t)
+	myErr := errors.New("my error")
+
+	st := faulty.NewWrapper(memory.New(memory.Options{}), faulty.Options{})
+	st.AddFault(faulty.MethodPutBlob).ErrorInstead(myErr).Repeat(1)
+
+	require.ErrorIs(t, st.PutBlob(ctx, "a", gather.FromSlice([]byte{1}), blob.PutOptions{}), myErr)
+	require.ErrorIs(t, st.PutBlob(ctx, "a", gather.FromSlice([]byte{1}), blob.PutOptions{}), myErr)
+	require.NoError(t, st.PutBlob(ctx, "a", gather.FromSlice([]byte{1}), blob.PutOptions{}))
+	require.Equal(t, 3, st.NumCalls(faulty.MethodPutBlob))
+
+	// programmed faults are not counted as random calls.
+	require.Equal(t, faulty.Stats{Calls: 1}, st.Stats())
+	st.VerifyAllFaultsExercised(t)
+
+	// faults can be constructed up front and added in bulk.
+	st.AddFaults(faulty.MethodDeleteBlob, faulty.NewFault().ErrorInstead(myErr))
+	require.ErrorIs(t, st.DeleteBlob(ctx, "a"), myErr)
+	require.NoError(t, st.DeleteBlob(ctx, "a"))
+	st.VerifyAllFaultsExercised(t)
+}
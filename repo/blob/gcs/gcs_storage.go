@@ -89,6 +89,10 @@ func translateError(err error) error {
 			return blob.ErrInvalidRange
 		case http.StatusPreconditionFailed:
 			return blob.ErrBlobAlreadyExists
+		case http.StatusForbidden:
+			return errors.WithMessagef(blob.ErrAccessDenied, "%v", ae)
+		case http.StatusTooManyRequests:
+			return errors.WithMessagef(blob.ErrThrottled, "%v", ae)
 		}
 	}
 
@@ -459,6 +459,18 @@ func retryNotFound(err error) bool {
 	return errors.Is(err, blob.ErrBlobNotFound)
 }
 
+// isRateLimitError returns true if the error is one of the 403 errors Google Drive returns when rate limits are exceeded.
+func isRateLimitError(ae *googleapi.Error) bool {
+	for _, e := range ae.Errors {
+		switch e.Reason {
+		case "rateLimitExceeded", "userRateLimitExceeded":
+			return true
+		}
+	}
+
+	return false
+}
+
 func translateError(err error) error {
 	var ae *googleapi.Error
 
@@ -470,6 +482,14 @@ func translateError(err error) error {
 			return errors.WithMessagef(blob.ErrInvalidRange, "%v", ae)
 		case http.StatusPreconditionFailed:
 			return errors.WithMessagef(blob.ErrBlobAlreadyExists, "%v", ae)
+		case http.StatusForbidden:
+			if isRateLimitError(ae) {
+				return errors.WithMessagef(blob.ErrThrottled, "%v", ae)
+			}
+
+			return errors.WithMessagef(blob.ErrAccessDenied, "%v", ae)
+		case http.StatusTooManyRequests:
+			return errors.WithMessagef(blob.ErrThrottled, "%v", ae)
 		}
 	}
 
@@ -0,0 +1,15 @@
+package kopiaserver
+
+import (
+	"github.com/kopia/kopia/repo/blob/throttling"
+)
+
+// Options defines options for storage accessed through Kopia server.
+type Options struct {
+	URL                                 string `json:"url"`
+	Username                            string `json:"username"`
+	Password                            string `json:"password"                                      kopia:"sensitive"`
+	TrustedServerCertificateFingerprint string `json:"trustedServerCertificateFingerprint,omitempty"`
+
+	throttling.Limits
+}
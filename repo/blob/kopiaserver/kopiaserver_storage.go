@@ -0,0 +1,214 @@
+// Package kopiaserver implements Storage which accesses blobs of a repository
+// through the blob proxy API of Kopia server.
+package kopiaserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/apiclient"
+	"github.com/kopia/kopia/internal/serverapi"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/blob/retrying"
+)
+
+const kopiaServerStorageType = "kopia-server"
+
+type kopiaServerStorage struct {
+	Options
+	blob.DefaultProviderImplementation
+
+	cli *apiclient.KopiaAPIClient
+}
+
+func blobURL(id blob.ID) string {
+	return "blobs/" + url.PathEscape(string(id))
+}
+
+func translateError(err error) error {
+	var hse apiclient.HTTPStatusError
+
+	if errors.As(err, &hse) {
+		switch hse.HTTPStatusCode {
+		case http.StatusNotFound:
+			return errors.WithMessagef(blob.ErrBlobNotFound, "%v", err)
+		case http.StatusRequestedRangeNotSatisfiable:
+			return errors.WithMessagef(blob.ErrInvalidRange, "%v", err)
+		case http.StatusUnauthorized:
+			return errors.WithMessagef(blob.ErrInvalidCredentials, "%v", err)
+		case http.StatusForbidden:
+			return errors.WithMessagef(blob.ErrAccessDenied, "%v", err)
+		}
+	}
+
+	return err
+}
+
+func (s *kopiaServerStorage) GetBlob(ctx context.Context, id blob.ID, offset, length int64, output blob.OutputBuffer) error {
+	output.Reset()
+
+	if offset < 0 {
+		return blob.ErrInvalidRange
+	}
+
+	u := blobURL(id)
+	if length >= 0 {
+		u += fmt.Sprintf("?offset=%v&length=%v", offset, length)
+	}
+
+	body, err := s.cli.GetStream(ctx, u)
+	if err != nil {
+		return translateError(err)
+	}
+
+	defer body.Close() //nolint:errcheck
+
+	if _, err := io.Copy(output, body); err != nil {
+		return errors.Wrap(err, "error reading blob")
+	}
+
+	//nolint:wrapcheck
+	return blob.EnsureLengthExactly(output.Length(), length)
+}
+
+func (s *kopiaServerStorage) GetMetadata(ctx context.Context, id blob.ID) (blob.Metadata, error) {
+	var bm blob.Metadata
+
+	if err := s.cli.Get(ctx, blobURL(id)+"/metadata", nil, &bm); err != nil {
+		return blob.Metadata{}, translateError(err)
+	}
+
+	return bm, nil
+}
+
+// ListBlobs invokes the callback as blob metadata is streamed from the server, without buffering
+// the entire listing.
+func (s *kopiaServerStorage) ListBlobs(ctx context.Context, prefix blob.ID, callback func(blob.Metadata) error) error {
+	body, err := s.cli.GetStream(ctx, "blobs?prefix="+url.QueryEscape(string(prefix)))
+	if err != nil {
+		return translateError(err)
+	}
+
+	defer body.Close() //nolint:errcheck
+
+	dec := json.NewDecoder(body)
+
+	for {
+		var item serverapi.BlobListItem
+
+		if err := dec.Decode(&item); err != nil {
+			// the listing must be terminated by an explicit item, otherwise it was truncated.
+			return errors.Wrap(err, "error reading blob list")
+		}
+
+		switch {
+		case item.Error != "":
+			return errors.Errorf("error listing blobs: %v", item.Error)
+
+		case item.Done:
+			return nil
+
+		case item.Blob != nil:
+			if err := callback(*item.Blob); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *kopiaServerStorage) PutBlob(ctx context.Context, id blob.ID, data blob.Bytes, opts blob.PutOptions) error {
+	switch {
+	case opts.HasRetentionOptions():
+		return errors.Wrap(blob.ErrUnsupportedPutBlobOption, "blob-retention")
+	case opts.DoNotRecreate:
+		return errors.Wrap(blob.ErrUnsupportedPutBlobOption, "do-not-recreate")
+	}
+
+	if !opts.SetModTime.IsZero() {
+		return blob.ErrSetTimeUnsupported
+	}
+
+	rd := data.Reader()
+	defer rd.Close() //nolint:errcheck
+
+	if err := s.cli.PutStream(ctx, blobURL(id), rd, int64(data.Length()), nil); err != nil {
+		return translateError(err)
+	}
+
+	if opts.GetModTime != nil {
+		bm, err := s.GetMetadata(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		*opts.GetModTime = bm.Timestamp
+	}
+
+	return nil
+}
+
+func (s *kopiaServerStorage) DeleteBlob(ctx context.Context, id blob.ID) error {
+	err := translateError(s.cli.Delete(ctx, blobURL(id), nil, nil, nil))
+	if errors.Is(err, blob.ErrBlobNotFound) {
+		return nil
+	}
+
+	return err
+}
+
+// GetCapacity returns the capacity of the storage used by the server, if it's a volume.
+func (s *kopiaServerStorage) GetCapacity(ctx context.Context) (blob.Capacity, error) {
+	var c blob.Capacity
+
+	if err := translateError(s.cli.Get(ctx, "storage/capacity", nil, &c)); err != nil {
+		if errors.Is(err, blob.ErrBlobNotFound) {
+			// servers return not found when the storage is not a volume.
+			return blob.Capacity{}, blob.ErrNotAVolume
+		}
+
+		return blob.Capacity{}, err
+	}
+
+	return c, nil
+}
+
+func (s *kopiaServerStorage) ConnectionInfo() blob.ConnectionInfo {
+	return blob.ConnectionInfo{
+		Type:   kopiaServerStorageType,
+		Config: &s.Options,
+	}
+}
+
+func (s *kopiaServerStorage) DisplayName() string {
+	return fmt.Sprintf("Kopia Server: %v", s.URL)
+}
+
+// New creates new storage which accesses blobs through Kopia server at the provided URL.
+func New(ctx context.Context, opts *Options, isCreate bool) (blob.Storage, error) {
+	_ = isCreate
+
+	cli, err := apiclient.NewKopiaAPIClient(apiclient.Options{
+		BaseURL:                             opts.URL,
+		Username:                            opts.Username,
+		Password:                            opts.Password,
+		TrustedServerCertificateFingerprint: opts.TrustedServerCertificateFingerprint,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create API client")
+	}
+
+	return retrying.NewWrapper(&kopiaServerStorage{
+		Options: *opts,
+		cli:     cli,
+	}), nil
+}
+
+func init() {
+	blob.AddSupportedStorage(kopiaServerStorageType, Options{}, New)
+}
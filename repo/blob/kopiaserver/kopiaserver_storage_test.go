@@ -0,0 +1,134 @@
+package kopiaserver_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/auth"
+	"github.com/kopia/kopia/internal/blobtesting"
+	"github.com/kopia/kopia/internal/gather"
+	"github.com/kopia/kopia/internal/repotesting"
+	"github.com/kopia/kopia/internal/server"
+	"github.com/kopia/kopia/internal/servertesting"
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/blob/kopiaserver"
+	"github.com/kopia/kopia/repo/format"
+)
+
+const (
+	testControlUsername = "control-user"
+	testControlPassword = "control-password"
+)
+
+func TestMain(m *testing.M) { testutil.MyTestMain(m) }
+
+func TestKopiaServerStorage(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+
+	asi := servertesting.StartServerWithOptions(t, env, true, func(o *server.Options) {
+		o.Authenticator = auth.CombineAuthenticators(o.Authenticator, auth.AuthenticateSingleUser(testControlUsername, testControlPassword))
+		o.ServerControlUser = testControlUsername
+	})
+
+	opt := &kopiaserver.Options{
+		URL:                                 asi.BaseURL,
+		Username:                            testControlUsername,
+		Password:                            testControlPassword,
+		TrustedServerCertificateFingerprint: asi.TrustedServerCertificateFingerprint,
+	}
+
+	st, err := kopiaserver.New(ctx, opt, false)
+	require.NoError(t, err)
+
+	defer st.Close(ctx)
+
+	blobtesting.AssertConnectionInfoRoundTrips(ctx, t, st)
+
+	// format blob is visible through the proxy.
+	var want gather.WriteBuffer
+	defer want.Close()
+
+	require.NoError(t, env.RootStorage().GetBlob(ctx, format.KopiaRepositoryBlobID, 0, -1, &want))
+	blobtesting.AssertGetBlob(ctx, t, st, format.KopiaRepositoryBlobID, want.ToByteSlice())
+
+	blobtesting.AssertGetBlobNotFound(ctx, t, st, "xno-such-blob")
+	blobtesting.AssertGetMetadataNotFound(ctx, t, st, "xno-such-blob")
+
+	// write through the proxy, read from the underlying storage.
+	require.NoError(t, st.PutBlob(ctx, "xtest-blob", gather.FromSlice([]byte{1, 2, 3, 4, 5}), blob.PutOptions{}))
+	blobtesting.AssertGetBlob(ctx, t, env.RootStorage(), "xtest-blob", []byte{1, 2, 3, 4, 5})
+	blobtesting.AssertGetBlob(ctx, t, st, "xtest-blob", []byte{1, 2, 3, 4, 5})
+	blobtesting.AssertListResults(ctx, t, st, "xtest", "xtest-blob")
+
+	// listing and large blobs are streamed.
+	var listed []blob.ID
+
+	for i := range 100 {
+		id := blob.ID(fmt.Sprintf("ylist-%03v", i))
+		listed = append(listed, id)
+
+		require.NoError(t, env.RootStorage().PutBlob(ctx, id, gather.FromSlice([]byte{byte(i)}), blob.PutOptions{}))
+	}
+
+	blobtesting.AssertListResults(ctx, t, st, "ylist-", listed...)
+
+	large := bytes.Repeat([]byte{1, 2, 3, 4, 5, 6, 7}, 1<<20)
+	require.NoError(t, st.PutBlob(ctx, "xlarge-blob", gather.FromSlice(large), blob.PutOptions{}))
+	blobtesting.AssertGetBlob(ctx, t, st, "xlarge-blob", large)
+
+	bm, err := st.GetMetadata(ctx, "xtest-blob")
+	require.NoError(t, err)
+	require.EqualValues(t, 5, bm.Length)
+
+	require.NoError(t, st.DeleteBlob(ctx, "xtest-blob"))
+	require.NoError(t, st.DeleteBlob(ctx, "xtest-blob"))
+	blobtesting.AssertGetBlobNotFound(ctx, t, env.RootStorage(), "xtest-blob")
+
+	// capacity of the underlying storage is reported through the proxy.
+	wantCapacity, wantErr := env.RootStorage().GetCapacity(ctx)
+	gotCapacity, err := st.GetCapacity(ctx)
+
+	if errors.Is(wantErr, blob.ErrNotAVolume) {
+		require.ErrorIs(t, err, blob.ErrNotAVolume)
+	} else {
+		require.NoError(t, wantErr)
+		require.NoError(t, err)
+		require.Equal(t, wantCapacity, gotCapacity)
+	}
+
+	// the repository can be connected to through the proxy.
+	configFile := t.TempDir() + "/proxy.config"
+	require.NoError(t, repo.Connect(ctx, configFile, st, env.Password, nil))
+
+	r, err := repo.Open(ctx, configFile, env.Password, nil)
+	require.NoError(t, err)
+	require.NoError(t, r.Close(ctx))
+
+	// users other than the server control user can't access blobs.
+	uiStorage, err := kopiaserver.New(ctx, &kopiaserver.Options{
+		URL:                                 asi.BaseURL,
+		Username:                            servertesting.TestUIUsername,
+		Password:                            servertesting.TestUIPassword,
+		TrustedServerCertificateFingerprint: asi.TrustedServerCertificateFingerprint,
+	}, false)
+	require.NoError(t, err)
+
+	_, err = uiStorage.GetMetadata(ctx, format.KopiaRepositoryBlobID)
+	require.ErrorIs(t, err, blob.ErrAccessDenied)
+
+	badPassword, err := kopiaserver.New(ctx, &kopiaserver.Options{
+		URL:                                 asi.BaseURL,
+		Username:                            testControlUsername,
+		Password:                            "wrong",
+		TrustedServerCertificateFingerprint: asi.TrustedServerCertificateFingerprint,
+	}, false)
+	require.NoError(t, err)
+
+	blobtesting.AssertInvalidCredentials(ctx, t, badPassword, format.KopiaRepositoryBlobID)
+}
@@ -7,6 +7,8 @@
 	"sync/atomic"
 
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/kopia/kopia/internal/timetrack"
 	"github.com/kopia/kopia/repo/blob"
@@ -40,7 +42,7 @@ func (s *loggingStorage) endConcurrency() {
 }
 
 func (s *loggingStorage) GetBlob(ctx context.Context, id blob.ID, offset, length int64, output blob.OutputBuffer) error {
-	ctx, span := tracer.Start(ctx, "GetBlob")
+	ctx, span := tracer.Start(ctx, "GetBlob", trace.WithAttributes(attribute.String("blobID", string(id))))
 	defer span.End()
 
 	s.beginConcurrency()
@@ -87,7 +89,7 @@ func (s *loggingStorage) IsReadOnly() bool {
 }
 
 func (s *loggingStorage) GetMetadata(ctx context.Context, id blob.ID) (blob.Metadata, error) {
-	ctx, span := tracer.Start(ctx, "GetMetadata")
+	ctx, span := tracer.Start(ctx, "GetMetadata", trace.WithAttributes(attribute.String("blobID", string(id))))
 	defer span.End()
 
 	s.beginConcurrency()
@@ -109,7 +111,7 @@ func (s *loggingStorage) GetMetadata(ctx context.Context, id blob.ID) (blob.Meta
 }
 
 func (s *loggingStorage) PutBlob(ctx context.Context, id blob.ID, data blob.Bytes, opts blob.PutOptions) error {
-	ctx, span := tracer.Start(ctx, "PutBlob")
+	ctx, span := tracer.Start(ctx, "PutBlob", trace.WithAttributes(attribute.String("blobID", string(id))))
 	defer span.End()
 
 	s.beginConcurrency()
@@ -131,7 +133,7 @@ func (s *loggingStorage) PutBlob(ctx context.Context, id blob.ID, data blob.Byte
 }
 
 func (s *loggingStorage) DeleteBlob(ctx context.Context, id blob.ID) error {
-	ctx, span := tracer.Start(ctx, "DeleteBlob")
+	ctx, span := tracer.Start(ctx, "DeleteBlob", trace.WithAttributes(attribute.String("blobID", string(id))))
 	defer span.End()
 
 	s.beginConcurrency()
@@ -151,7 +153,7 @@ func (s *loggingStorage) DeleteBlob(ctx context.Context, id blob.ID) error {
 }
 
 func (s *loggingStorage) ListBlobs(ctx context.Context, prefix blob.ID, callback func(blob.Metadata) error) error {
-	ctx, span := tracer.Start(ctx, "ListBlobs")
+	ctx, span := tracer.Start(ctx, "ListBlobs", trace.WithAttributes(attribute.String("prefix", string(prefix))))
 	defer span.End()
 
 	s.beginConcurrency()
@@ -216,7 +218,7 @@ func (s *loggingStorage) FlushCaches(ctx context.Context) error {
 }
 
 func (s *loggingStorage) ExtendBlobRetention(ctx context.Context, b blob.ID, opts blob.ExtendOptions) error {
-	ctx, span := tracer.Start(ctx, "ExtendBlobRetention")
+	ctx, span := tracer.Start(ctx, "ExtendBlobRetention", trace.WithAttributes(attribute.String("blobID", string(b))))
 	defer span.End()
 
 	s.beginConcurrency()
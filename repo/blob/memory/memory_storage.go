@@ -0,0 +1,258 @@
+// Package memory implements an in-memory Storage, primarily useful for tests.
+package memory
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/clock"
+	"github.com/kopia/kopia/repo/blob"
+)
+
+const memoryStorageType = "memory"
+
+// DataMap is a map of blob ID to their contents.
+type DataMap map[blob.ID][]byte
+
+// Options defines options for in-memory storage.
+type Options struct {
+	// MaxSize is the maximum total number of bytes the storage can hold, zero or negative means unlimited.
+	MaxSize int64 `json:"maxSize,omitempty"`
+
+	// TimeNow overrides the function used to assign blob timestamps.
+	TimeNow func() time.Time `json:"-"`
+}
+
+type memoryStorage struct {
+	blob.DefaultProviderImplementation
+
+	mu sync.RWMutex
+	// +checklocks:mu
+	data DataMap
+	// +checklocks:mu
+	modTime map[blob.ID]time.Time
+	// +checklocks:mu
+	totalBytes int64
+
+	maxSize int64
+	timeNow func() time.Time
+}
+
+func (s *memoryStorage) GetCapacity(ctx context.Context) (blob.Capacity, error) {
+	if s.maxSize <= 0 {
+		return blob.Capacity{}, blob.ErrNotAVolume
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return blob.Capacity{
+		SizeB: uint64(s.maxSize),
+		FreeB: uint64(s.maxSize - s.totalBytes),
+	}, nil
+}
+
+func (s *memoryStorage) GetBlob(ctx context.Context, id blob.ID, offset, length int64, output blob.OutputBuffer) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	output.Reset()
+
+	data, ok := s.data[id]
+	if !ok {
+		return blob.ErrBlobNotFound
+	}
+
+	if length < 0 {
+		if _, err := output.Write(data); err != nil {
+			return errors.Wrap(err, "error writing data to output")
+		}
+
+		return nil
+	}
+
+	if offset < 0 || offset > int64(len(data)) {
+		return errors.Wrapf(blob.ErrInvalidRange, "invalid offset: %v", offset)
+	}
+
+	data = data[offset:]
+	if length > int64(len(data)) {
+		return errors.Wrapf(blob.ErrInvalidRange, "invalid length: %v", length)
+	}
+
+	if _, err := output.Write(data[0:length]); err != nil {
+		return errors.Wrap(err, "error writing data to output")
+	}
+
+	return nil
+}
+
+func (s *memoryStorage) GetMetadata(ctx context.Context, id blob.ID) (blob.Metadata, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	data, ok := s.data[id]
+	if !ok {
+		return blob.Metadata{}, blob.ErrBlobNotFound
+	}
+
+	return blob.Metadata{
+		BlobID:    id,
+		Length:    int64(len(data)),
+		Timestamp: s.modTime[id],
+	}, nil
+}
+
+func (s *memoryStorage) PutBlob(ctx context.Context, id blob.ID, data blob.Bytes, opts blob.PutOptions) error {
+	if opts.HasRetentionOptions() {
+		return errors.Wrap(blob.ErrUnsupportedPutBlobOption, "blob-retention")
+	}
+
+	var b bytes.Buffer
+
+	if _, err := data.WriteTo(&b); err != nil {
+		return errors.Wrap(err, "error reading blob data")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.data[id]; exists && opts.DoNotRecreate {
+		return errors.Wrap(blob.ErrBlobAlreadyExists, string(id))
+	}
+
+	if s.maxSize > 0 && s.totalBytes-int64(len(s.data[id]))+int64(b.Len()) > s.maxSize {
+		return errors.Errorf("exceeded limit, unable to add %v bytes, currently using %v/%v", b.Len(), s.totalBytes, s.maxSize)
+	}
+
+	if !opts.SetModTime.IsZero() {
+		s.modTime[id] = opts.SetModTime
+	} else {
+		s.modTime[id] = s.timeNow()
+	}
+
+	s.totalBytes -= int64(len(s.data[id]))
+	s.data[id] = b.Bytes()
+	s.totalBytes += int64(b.Len())
+
+	if opts.GetModTime != nil {
+		*opts.GetModTime = s.modTime[id]
+	}
+
+	return nil
+}
+
+func (s *memoryStorage) DeleteBlob(ctx context.Context, id blob.ID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.totalBytes -= int64(len(s.data[id]))
+	delete(s.data, id)
+	delete(s.modTime, id)
+
+	return nil
+}
+
+func (s *memoryStorage) ListBlobs(ctx context.Context, prefix blob.ID, callback func(blob.Metadata) error) error {
+	s.mu.RLock()
+
+	keys := []blob.ID{}
+
+	for k := range s.data {
+		if strings.HasPrefix(string(k), string(prefix)) {
+			keys = append(keys, k)
+		}
+	}
+
+	s.mu.RUnlock()
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i] < keys[j]
+	})
+
+	// the callback may modify the storage, so look up each blob again and skip blobs deleted in the meantime.
+	for _, k := range keys {
+		s.mu.RLock()
+		v, ok := s.data[k]
+		ts := s.modTime[k]
+		s.mu.RUnlock()
+
+		if !ok {
+			continue
+		}
+
+		if err := callback(blob.Metadata{
+			BlobID:    k,
+			Length:    int64(len(v)),
+			Timestamp: ts,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *memoryStorage) TouchBlob(ctx context.Context, id blob.ID, threshold time.Duration) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if v, ok := s.modTime[id]; ok {
+		n := s.timeNow()
+		if n.Sub(v) >= threshold {
+			s.modTime[id] = n
+		}
+	}
+
+	return s.modTime[id], nil
+}
+
+func (s *memoryStorage) ConnectionInfo() blob.ConnectionInfo {
+	return blob.ConnectionInfo{
+		Type:   memoryStorageType,
+		Config: &Options{MaxSize: s.maxSize},
+	}
+}
+
+func (s *memoryStorage) DisplayName() string {
+	return "Memory"
+}
+
+// New returns a new empty Storage that keeps all blobs in memory.
+// The contents are discarded when the storage is garbage-collected.
+func New(opt Options) blob.Storage {
+	return NewWithData(DataMap{}, nil, opt)
+}
+
+// NewWithData returns a Storage backed by the provided maps of blob contents and modification times,
+// which are modified in place and must not be accessed concurrently with the storage.
+func NewWithData(data DataMap, modTime map[blob.ID]time.Time, opt Options) blob.Storage {
+	if modTime == nil {
+		modTime = map[blob.ID]time.Time{}
+	}
+
+	timeNow := opt.TimeNow
+	if timeNow == nil {
+		timeNow = clock.Now
+	}
+
+	var totalBytes int64
+
+	for _, v := range data {
+		totalBytes += int64(len(v))
+	}
+
+	return &memoryStorage{
+		data:       data,
+		modTime:    modTime,
+		totalBytes: totalBytes,
+		maxSize:    opt.MaxSize,
+		timeNow:    timeNow,
+	}
+}
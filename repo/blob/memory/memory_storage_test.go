@@ -0,0 +1,52 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/blobtesting"
+	"github.com/kopia/kopia/internal/gather"
+	"github.com/kopia/kopia/internal/testlogging"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/blob/memory"
+)
+
+func TestMemoryStorage(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	blobtesting.VerifyStorage(ctx, t, memory.New(memory.Options{}), blob.PutOptions{})
+}
+
+func TestMemoryStorageDoNotRecreate(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	st := memory.New(memory.Options{})
+
+	require.NoError(t, st.PutBlob(ctx, "a", gather.FromSlice([]byte{1}), blob.PutOptions{DoNotRecreate: true}))
+	require.ErrorIs(t, st.PutBlob(ctx, "a", gather.FromSlice([]byte{2}), blob.PutOptions{DoNotRecreate: true}), blob.ErrBlobAlreadyExists)
+	blobtesting.AssertGetBlob(ctx, t, st, "a", []byte{1})
+}
+
+func TestMemoryStorageMaxSize(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	st := memory.New(memory.Options{MaxSize: 10})
+
+	require.NoError(t, st.PutBlob(ctx, "a", gather.FromSlice([]byte{1, 2, 3, 4, 5, 6}), blob.PutOptions{}))
+	require.Error(t, st.PutBlob(ctx, "b", gather.FromSlice([]byte{1, 2, 3, 4, 5, 6}), blob.PutOptions{}))
+
+	// overwriting does not count the old contents against the limit.
+	require.NoError(t, st.PutBlob(ctx, "a", gather.FromSlice([]byte{1, 2, 3, 4, 5, 6, 7, 8}), blob.PutOptions{}))
+
+	c, err := st.GetCapacity(ctx)
+	require.NoError(t, err)
+	require.Equal(t, blob.Capacity{SizeB: 10, FreeB: 2}, c)
+
+	require.NoError(t, st.DeleteBlob(ctx, "a"))
+	require.NoError(t, st.PutBlob(ctx, "b", gather.FromSlice([]byte{1, 2, 3, 4, 5, 6}), blob.PutOptions{}))
+
+	_, err = memory.New(memory.Options{}).GetCapacity(ctx)
+	require.True(t, errors.Is(err, blob.ErrNotAVolume))
+}
@@ -0,0 +1,99 @@
+// Package parallellist implements wrapper around blob.Storage that lists blobs with well-known prefixes
+// by fanning out over hexadecimal sub-prefixes in parallel, which speeds up listing on backends where
+// listing a large number of blobs is slow.
+package parallellist
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/kopia/kopia/repo/blob"
+)
+
+// DefaultPrefixes are the prefixes of blobs whose IDs are followed by hexadecimal digits, which
+// can be safely listed by fanning out over '0'..'f' sub-prefixes.
+//
+//nolint:gochecknoglobals
+var DefaultPrefixes = []blob.ID{"p", "q", "n"}
+
+const hexDigits = 16
+
+type parallelList struct {
+	blob.Storage
+
+	parallelism int
+	prefixes    []blob.ID
+}
+
+func (s *parallelList) ListBlobs(ctx context.Context, prefix blob.ID, callback func(blob.Metadata) error) error {
+	if !slices.Contains(s.prefixes, prefix) {
+		return s.Storage.ListBlobs(ctx, prefix, callback) //nolint:wrapcheck
+	}
+
+	result := make(chan blob.Metadata, s.parallelism)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	eg, ctx := errgroup.WithContext(ctx)
+	eg.SetLimit(s.parallelism)
+
+	var producers errgroup.Group
+
+	// start listing sub-prefixes in parallel
+	producers.Go(func() error {
+		defer close(result)
+
+		for i := range hexDigits {
+			subPrefix := prefix + blob.ID(fmt.Sprintf("%x", i))
+
+			eg.Go(func() error {
+				return s.Storage.ListBlobs(ctx, subPrefix, func(bm blob.Metadata) error {
+					select {
+					case result <- bm:
+						return nil
+					case <-ctx.Done():
+						return ctx.Err()
+					}
+				})
+			})
+		}
+
+		return eg.Wait() //nolint:wrapcheck
+	})
+
+	// invoke the callback on the current goroutine until it fails
+	for bm := range result {
+		if err := callback(bm); err != nil {
+			cancel()
+
+			// drain the channel so that producers can exit.
+			for range result {
+			}
+
+			producers.Wait() //nolint:errcheck
+
+			return err
+		}
+	}
+
+	return errors.Wrapf(producers.Wait(), "error listing %v", prefix)
+}
+
+// NewWrapper returns a Storage wrapper that lists blobs with the provided prefixes using the provided parallelism.
+// Parallelism of 1 or less returns the original storage.
+func NewWrapper(wrapped blob.Storage, parallelism int, prefixes []blob.ID) blob.Storage {
+	if parallelism <= 1 {
+		return wrapped
+	}
+
+	if prefixes == nil {
+		prefixes = DefaultPrefixes
+	}
+
+	return &parallelList{wrapped, parallelism, prefixes}
+}
@@ -0,0 +1,84 @@
+package parallellist_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/blobtesting"
+	"github.com/kopia/kopia/internal/clock"
+	"github.com/kopia/kopia/internal/gather"
+	"github.com/kopia/kopia/internal/testlogging"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/blob/parallellist"
+)
+
+func TestParallelList(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	base := blobtesting.NewMapStorage(blobtesting.DataMap{}, nil, clock.Now)
+
+	for i := range 100 {
+		for _, prefix := range []string{"p", "q", "xn0_"} {
+			require.NoError(t, base.PutBlob(ctx, blob.ID(fmt.Sprintf("%v%032x", prefix, i*7919)), gather.FromSlice([]byte{1}), blob.PutOptions{}))
+		}
+	}
+
+	require.NoError(t, base.PutBlob(ctx, "kopia.repository", gather.FromSlice([]byte{1}), blob.PutOptions{}))
+
+	st := parallellist.NewWrapper(base, 4, nil)
+
+	for _, prefix := range []blob.ID{"", "p", "q", "xn", "p00"} {
+		want, err := blob.ListAllBlobs(ctx, base, prefix)
+		require.NoError(t, err)
+
+		got, err := blob.ListAllBlobs(ctx, st, prefix)
+		require.NoError(t, err)
+
+		require.ElementsMatch(t, want, got, "prefix %q", prefix)
+	}
+}
+
+func TestParallelListVerifyStorage(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	st := parallellist.NewWrapper(blobtesting.NewMapStorage(blobtesting.DataMap{}, nil, clock.Now), 4, nil)
+
+	blobtesting.VerifyStorage(ctx, t, st, blob.PutOptions{})
+}
+
+func TestParallelListCallbackError(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	base := blobtesting.NewMapStorage(blobtesting.DataMap{}, nil, clock.Now)
+
+	for i := range 1000 {
+		require.NoError(t, base.PutBlob(ctx, blob.ID(fmt.Sprintf("p%032x", i*7919)), gather.FromSlice([]byte{1}), blob.PutOptions{}))
+	}
+
+	st := parallellist.NewWrapper(base, 4, nil)
+
+	errStop := errors.New("stop")
+
+	cnt := 0
+
+	require.ErrorIs(t, st.ListBlobs(ctx, "p", func(bm blob.Metadata) error {
+		cnt++
+
+		if cnt == 10 {
+			return errStop
+		}
+
+		return nil
+	}), errStop)
+
+	require.Equal(t, 10, cnt)
+}
+
+func TestParallelListDisabled(t *testing.T) {
+	base := blobtesting.NewMapStorage(blobtesting.DataMap{}, nil, clock.Now)
+
+	require.Equal(t, base, parallellist.NewWrapper(base, 1, nil))
+}
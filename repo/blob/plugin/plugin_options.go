@@ -0,0 +1,42 @@
+package plugin
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo/blob"
+)
+
+// ErrUntrustedExecution is returned when connection information from a connection token specifies
+// which program to run as the storage plugin or its environment.
+var ErrUntrustedExecution = errors.New("connection token specifies storage plugin executable or environment")
+
+// Options defines options for plugin-provided storage.
+type Options struct {
+	Name       string          `json:"name"`                 // plugin name, the executable is kopia-storage-<name>
+	Executable string          `json:"executable,omitempty"` // explicit path to the plugin executable
+	Args       []string        `json:"args,omitempty"`       // additional plugin arguments
+	Env        []string        `json:"env,omitempty"`        // additional plugin environment variables (key=value)
+	Config     json.RawMessage `json:"config,omitempty"`     // plugin-specific configuration passed in "init" request
+
+	StartupTimeout int `json:"startupTimeout,omitempty"` // seconds to wait for the plugin to initialize
+}
+
+// VerifyTokenConnectionInfo returns ErrUntrustedExecution if the provided connection information, decoded
+// from a connection token, selects the plugin executable by path or sets its environment. Tokens are
+// commonly shared, so accepting those settings would let whoever produced the token run arbitrary
+// programs on this machine.
+func VerifyTokenConnectionInfo(ci blob.ConnectionInfo) error {
+	o, ok := ci.Config.(*Options)
+	if !ok {
+		return nil
+	}
+
+	if o.Executable != "" || len(o.Env) > 0 || strings.ContainsAny(o.Name, `/\`) {
+		return ErrUntrustedExecution
+	}
+
+	return nil
+}
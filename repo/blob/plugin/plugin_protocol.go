@@ -0,0 +1,198 @@
+// Package plugin implements blob storage provided by external plugin executables, which allows
+// third parties to ship storage backends as separate binaries.
+//
+// A plugin named NAME is an executable called kopia-storage-NAME found in PATH (or at an explicitly
+// configured location). Kopia starts the plugin and exchanges JSON messages with it over stdin/stdout,
+// one message per line, while anything the plugin writes to stderr is logged.
+//
+// Each request has the form {"id":N,"method":"...","params":{...}} and each response has the form
+// {"id":N,"result":{...}} or {"id":N,"error":{"code":"...","message":"..."}}. Multiple requests can be
+// in flight at the same time and responses may be sent in any order. Binary data is base64-encoded.
+//
+// The first request is always "init", followed by "getBlob", "getMetadata", "putBlob", "deleteBlob",
+// "listBlobs" and "getCapacity" requests and finally "close", after which the plugin should exit.
+//
+// Listings are returned in pages: when the result of "listBlobs" has a non-empty continuation token,
+// the next page is requested by sending "listBlobs" with that token. When the caller is no longer
+// interested in a request or in the remaining pages of a listing, it sends "cancel", whose response
+// can be ignored.
+//
+// Plugins written in Go can use Serve() to implement the protocol on top of any blob.Storage.
+package plugin
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo/blob"
+)
+
+// ProtocolVersion is the version of the plugin protocol.
+const ProtocolVersion = 1
+
+// Protocol methods.
+const (
+	MethodInit        = "init"
+	MethodGetBlob     = "getBlob"
+	MethodGetMetadata = "getMetadata"
+	MethodPutBlob     = "putBlob"
+	MethodDeleteBlob  = "deleteBlob"
+	MethodListBlobs   = "listBlobs"
+	MethodGetCapacity = "getCapacity"
+	MethodCancel      = "cancel"
+	MethodClose       = "close"
+)
+
+// Error codes mapped to well-known blob errors.
+const (
+	ErrorCodeNotFound              = "NOT_FOUND"
+	ErrorCodeAlreadyExists         = "ALREADY_EXISTS"
+	ErrorCodeInvalidRange          = "INVALID_RANGE"
+	ErrorCodeUnsupportedPutOption  = "UNSUPPORTED_PUT_OPTION"
+	ErrorCodeSetTimeUnsupported    = "SET_TIME_UNSUPPORTED"
+	ErrorCodeUnsupportedObjectLock = "UNSUPPORTED_OBJECT_LOCK"
+	ErrorCodeNotAVolume            = "NOT_A_VOLUME"
+	ErrorCodeInvalidCredentials    = "INVALID_CREDENTIALS"
+	ErrorCodeAccessDenied          = "ACCESS_DENIED"
+	ErrorCodeThrottled             = "THROTTLED"
+	ErrorCodeUnsupportedMethod     = "UNSUPPORTED_METHOD"
+	ErrorCodeUnsupportedProtocol   = "UNSUPPORTED_PROTOCOL"
+	ErrorCodeOther                 = "OTHER"
+)
+
+//nolint:gochecknoglobals
+var wellKnownErrors = map[string]error{
+	ErrorCodeNotFound:              blob.ErrBlobNotFound,
+	ErrorCodeAlreadyExists:         blob.ErrBlobAlreadyExists,
+	ErrorCodeInvalidRange:          blob.ErrInvalidRange,
+	ErrorCodeUnsupportedPutOption:  blob.ErrUnsupportedPutBlobOption,
+	ErrorCodeSetTimeUnsupported:    blob.ErrSetTimeUnsupported,
+	ErrorCodeUnsupportedObjectLock: blob.ErrUnsupportedObjectLock,
+	ErrorCodeNotAVolume:            blob.ErrNotAVolume,
+	ErrorCodeInvalidCredentials:    blob.ErrInvalidCredentials,
+	ErrorCodeAccessDenied:          blob.ErrAccessDenied,
+	ErrorCodeThrottled:             blob.ErrThrottled,
+}
+
+type request struct {
+	ID     int64           `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	ID     int64           `json:"id"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *ProtocolError  `json:"error,omitempty"`
+}
+
+// ProtocolError is an error reported by the plugin.
+type ProtocolError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *ProtocolError) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+// toError converts the protocol error to an error that wraps the corresponding well-known blob error, if any.
+func (e *ProtocolError) toError() error {
+	if known := wellKnownErrors[e.Code]; known != nil {
+		return errors.Wrap(known, e.Message)
+	}
+
+	return e
+}
+
+// protocolErrorFromError returns protocol error representing the provided error.
+func protocolErrorFromError(err error) *ProtocolError {
+	for code, known := range wellKnownErrors {
+		if errors.Is(err, known) {
+			return &ProtocolError{Code: code, Message: err.Error()}
+		}
+	}
+
+	var pe *ProtocolError
+	if errors.As(err, &pe) {
+		return pe
+	}
+
+	return &ProtocolError{Code: ErrorCodeOther, Message: err.Error()}
+}
+
+// InitParams are the parameters of the "init" request.
+type InitParams struct {
+	ProtocolVersion int             `json:"protocolVersion"`
+	Config          json.RawMessage `json:"config,omitempty"`
+	IsCreate        bool            `json:"isCreate"`
+}
+
+// InitResult is the result of the "init" request.
+type InitResult struct {
+	ProtocolVersion int    `json:"protocolVersion"`
+	DisplayName     string `json:"displayName,omitempty"`
+	ReadOnly        bool   `json:"readOnly,omitempty"`
+}
+
+// BlobParams are the parameters of requests operating on a single blob.
+type BlobParams struct {
+	BlobID blob.ID `json:"blobID"`
+}
+
+// GetBlobParams are the parameters of the "getBlob" request.
+type GetBlobParams struct {
+	BlobID blob.ID `json:"blobID"`
+	Offset int64   `json:"offset"`
+	Length int64   `json:"length"` // -1 == entire blob
+}
+
+// GetBlobResult is the result of the "getBlob" request.
+type GetBlobResult struct {
+	Data []byte `json:"data"`
+}
+
+// PutBlobParams are the parameters of the "putBlob" request.
+type PutBlobParams struct {
+	BlobID          blob.ID            `json:"blobID"`
+	Data            []byte             `json:"data"`
+	DoNotRecreate   bool               `json:"doNotRecreate,omitempty"`
+	SetModTime      *time.Time         `json:"setModTime,omitempty"`
+	RetentionMode   blob.RetentionMode `json:"retentionMode,omitempty"`
+	RetentionPeriod time.Duration      `json:"retentionPeriod,omitempty"`
+}
+
+// PutBlobResult is the result of the "putBlob" request.
+type PutBlobResult struct {
+	ModTime *time.Time `json:"modTime,omitempty"`
+}
+
+// ListBlobsParams are the parameters of the "listBlobs" request.
+type ListBlobsParams struct {
+	Prefix blob.ID `json:"prefix"`
+
+	// ContinuationToken is empty when starting a new listing or a token returned with the previous page.
+	ContinuationToken string `json:"continuationToken,omitempty"`
+
+	// MaxResults is the maximum number of blobs returned in a page, the plugin decides when 0.
+	MaxResults int `json:"maxResults,omitempty"`
+}
+
+// ListBlobsResult is the result of the "listBlobs" request.
+type ListBlobsResult struct {
+	Blobs []blob.Metadata `json:"blobs"`
+
+	// ContinuationToken is set when more blobs are available.
+	ContinuationToken string `json:"continuationToken,omitempty"`
+}
+
+// CancelParams are the parameters of the "cancel" request.
+type CancelParams struct {
+	// RequestID is the ID of the in-flight request to cancel.
+	RequestID int64 `json:"requestID,omitempty"`
+
+	// ContinuationToken identifies the listing whose remaining pages are no longer needed.
+	ContinuationToken string `json:"continuationToken,omitempty"`
+}
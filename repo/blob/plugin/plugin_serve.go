@@ -0,0 +1,380 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/gather"
+	"github.com/kopia/kopia/repo/blob"
+)
+
+// OpenFunc opens the storage provided by the plugin using plugin-specific configuration.
+type OpenFunc func(ctx context.Context, config json.RawMessage, isCreate bool) (blob.Storage, error)
+
+const defaultListPageSize = 1000
+
+// server implements the plugin side of the protocol on top of blob.Storage.
+type server struct {
+	open OpenFunc
+
+	writeMu sync.Mutex
+	enc     *json.Encoder
+
+	mu sync.Mutex
+	// +checklocks:mu
+	st blob.Storage
+	// +checklocks:mu
+	inflight map[int64]context.CancelFunc
+	// +checklocks:mu
+	listings map[string]*listing
+	// +checklocks:mu
+	nextListingID int
+}
+
+// listing is a blob listing in progress, whose results are returned in pages.
+type listing struct {
+	results chan blob.Metadata // closed when the listing completes
+	err     error              // valid after results has been closed
+	cancel  context.CancelFunc
+}
+
+// Serve implements the plugin protocol, reading requests from the provided reader and writing responses
+// to the provided writer (normally stdin and stdout of the plugin process) until the "close" request
+// is received or the input ends.
+func Serve(ctx context.Context, in io.Reader, out io.Writer, open OpenFunc) error {
+	s := &server{
+		open:     open,
+		enc:      json.NewEncoder(out),
+		inflight: map[int64]context.CancelFunc{},
+		listings: map[string]*listing{},
+	}
+
+	dec := json.NewDecoder(bufio.NewReader(in))
+
+	var wg sync.WaitGroup
+
+	defer wg.Wait()
+
+	for {
+		var req request
+
+		if err := dec.Decode(&req); err != nil {
+			if errors.Is(err, io.EOF) {
+				return s.closeStorage(ctx)
+			}
+
+			return errors.Wrap(err, "invalid request")
+		}
+
+		switch req.Method {
+		case MethodInit:
+			// initialization is handled synchronously, so that no other requests are processed before it completes.
+			res, err := s.handleInit(ctx, req.Params)
+			s.respond(req.ID, res, err)
+
+		case MethodCancel:
+			// cancellation is handled synchronously, so that it can't be delayed by other requests.
+			s.respond(req.ID, nil, s.handleCancel(req.Params))
+
+		case MethodClose:
+			wg.Wait()
+
+			err := s.closeStorage(ctx)
+			s.respond(req.ID, nil, err)
+
+			return err
+
+		default:
+			wg.Add(1)
+
+			reqctx, cancel := context.WithCancel(ctx)
+
+			s.mu.Lock()
+			s.inflight[req.ID] = cancel
+			s.mu.Unlock()
+
+			go func() {
+				defer wg.Done()
+
+				defer func() {
+					s.mu.Lock()
+					delete(s.inflight, req.ID)
+					s.mu.Unlock()
+
+					cancel()
+				}()
+
+				res, err := s.handle(reqctx, ctx, req.Method, req.Params)
+				s.respond(req.ID, res, err)
+			}()
+		}
+	}
+}
+
+func (s *server) respond(id int64, result any, err error) {
+	resp := &response{ID: id}
+
+	if err != nil {
+		resp.Error = protocolErrorFromError(err)
+	} else if result != nil {
+		b, merr := json.Marshal(result)
+		if merr != nil {
+			resp.Error = protocolErrorFromError(merr)
+		}
+
+		resp.Result = b
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	s.enc.Encode(resp) //nolint:errcheck
+}
+
+func (s *server) storage() (blob.Storage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.st == nil {
+		return nil, errors.New("storage not initialized")
+	}
+
+	return s.st, nil
+}
+
+func (s *server) handleCancel(params json.RawMessage) error {
+	var p CancelParams
+
+	if err := json.Unmarshal(params, &p); err != nil {
+		return errors.Wrap(err, "malformed cancel request")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if cancel := s.inflight[p.RequestID]; cancel != nil {
+		cancel()
+	}
+
+	if l := s.listings[p.ContinuationToken]; l != nil {
+		l.cancel()
+		delete(s.listings, p.ContinuationToken)
+	}
+
+	return nil
+}
+
+// listBlobsPage returns the next page of the listing identified by the continuation token, starting
+// a new listing whose lifetime is bound to the server context if the token is empty.
+func (s *server) listBlobsPage(ctx, serverCtx context.Context, st blob.Storage, p *ListBlobsParams) (*ListBlobsResult, error) {
+	s.mu.Lock()
+
+	token := p.ContinuationToken
+	l := s.listings[token]
+
+	if token == "" {
+		listCtx, cancel := context.WithCancel(serverCtx)
+
+		l = &listing{results: make(chan blob.Metadata), cancel: cancel}
+
+		s.nextListingID++
+		token = strconv.Itoa(s.nextListingID)
+		s.listings[token] = l
+
+		go func() {
+			defer close(l.results)
+
+			l.err = st.ListBlobs(listCtx, p.Prefix, func(bm blob.Metadata) error {
+				select {
+				case l.results <- bm:
+					return nil
+				case <-listCtx.Done():
+					return listCtx.Err()
+				}
+			})
+		}()
+	}
+
+	s.mu.Unlock()
+
+	if l == nil {
+		return nil, errors.Errorf("invalid continuation token %q", token)
+	}
+
+	pageSize := p.MaxResults
+	if pageSize <= 0 {
+		pageSize = defaultListPageSize
+	}
+
+	res := &ListBlobsResult{Blobs: []blob.Metadata{}}
+
+	for len(res.Blobs) < pageSize {
+		select {
+		case bm, ok := <-l.results:
+			if !ok {
+				s.mu.Lock()
+				delete(s.listings, token)
+				s.mu.Unlock()
+
+				return res, l.err
+			}
+
+			res.Blobs = append(res.Blobs, bm)
+
+		case <-ctx.Done():
+			// the results collected so far are lost and the client may not even know the token,
+			// so the listing can't be continued.
+			s.mu.Lock()
+			l.cancel()
+			delete(s.listings, token)
+			s.mu.Unlock()
+
+			return nil, errors.Wrap(ctx.Err(), "listBlobs")
+		}
+	}
+
+	res.ContinuationToken = token
+
+	return res, nil
+}
+
+func (s *server) closeStorage(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for token, l := range s.listings {
+		l.cancel()
+		delete(s.listings, token)
+	}
+
+	if s.st == nil {
+		return nil
+	}
+
+	err := s.st.Close(ctx)
+	s.st = nil
+
+	return errors.Wrap(err, "error closing storage")
+}
+
+func (s *server) handleInit(ctx context.Context, params json.RawMessage) (any, error) {
+	var p InitParams
+
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, errors.Wrap(err, "malformed init request")
+	}
+
+	if p.ProtocolVersion != ProtocolVersion {
+		return nil, &ProtocolError{Code: ErrorCodeUnsupportedProtocol, Message: "unsupported protocol version"}
+	}
+
+	st, err := s.open(ctx, p.Config, p.IsCreate)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.st = st
+	s.mu.Unlock()
+
+	return &InitResult{
+		ProtocolVersion: ProtocolVersion,
+		DisplayName:     st.DisplayName(),
+		ReadOnly:        st.IsReadOnly(),
+	}, nil
+}
+
+// handle handles a single request, ctx is canceled when the request is canceled while serverCtx
+// is used for state outliving the request, such as listings.
+//
+//nolint:gocyclo
+func (s *server) handle(ctx, serverCtx context.Context, method string, params json.RawMessage) (any, error) {
+	st, err := s.storage()
+	if err != nil {
+		return nil, err
+	}
+
+	switch method {
+	case MethodGetBlob:
+		var p GetBlobParams
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, errors.Wrap(err, "malformed request")
+		}
+
+		var buf gather.WriteBuffer
+		defer buf.Close()
+
+		if err := st.GetBlob(ctx, p.BlobID, p.Offset, p.Length, &buf); err != nil {
+			return nil, err //nolint:wrapcheck
+		}
+
+		return &GetBlobResult{Data: buf.ToByteSlice()}, nil
+
+	case MethodGetMetadata:
+		var p BlobParams
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, errors.Wrap(err, "malformed request")
+		}
+
+		return st.GetMetadata(ctx, p.BlobID) //nolint:wrapcheck
+
+	case MethodPutBlob:
+		var p PutBlobParams
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, errors.Wrap(err, "malformed request")
+		}
+
+		var modTime time.Time
+
+		opts := blob.PutOptions{
+			DoNotRecreate:   p.DoNotRecreate,
+			GetModTime:      &modTime,
+			RetentionMode:   p.RetentionMode,
+			RetentionPeriod: p.RetentionPeriod,
+		}
+
+		if p.SetModTime != nil {
+			opts.SetModTime = *p.SetModTime
+		}
+
+		if err := st.PutBlob(ctx, p.BlobID, gather.FromSlice(p.Data), opts); err != nil {
+			return nil, err //nolint:wrapcheck
+		}
+
+		res := &PutBlobResult{}
+		if !modTime.IsZero() {
+			res.ModTime = &modTime
+		}
+
+		return res, nil
+
+	case MethodDeleteBlob:
+		var p BlobParams
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, errors.Wrap(err, "malformed request")
+		}
+
+		return nil, st.DeleteBlob(ctx, p.BlobID) //nolint:wrapcheck
+
+	case MethodListBlobs:
+		var p ListBlobsParams
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, errors.Wrap(err, "malformed request")
+		}
+
+		return s.listBlobsPage(ctx, serverCtx, st, &p)
+
+	case MethodGetCapacity:
+		return st.GetCapacity(ctx) //nolint:wrapcheck
+
+	default:
+		return nil, &ProtocolError{Code: ErrorCodeUnsupportedMethod, Message: "unsupported method: " + method}
+	}
+}
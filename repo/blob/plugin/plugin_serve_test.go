@@ -0,0 +1,206 @@
+package plugin_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/blobtesting"
+	"github.com/kopia/kopia/internal/gather"
+	"github.com/kopia/kopia/internal/testlogging"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/blob/plugin"
+)
+
+// protocolClient sends raw protocol requests to Serve() running in the background.
+type protocolClient struct {
+	t      *testing.T
+	enc    *json.Encoder
+	dec    *json.Decoder
+	nextID int64
+}
+
+func (c *protocolClient) call(method string, params, result any) *plugin.ProtocolError {
+	c.t.Helper()
+
+	c.nextID++
+
+	p, err := json.Marshal(params)
+	require.NoError(c.t, err)
+
+	require.NoError(c.t, c.enc.Encode(map[string]any{"id": c.nextID, "method": method, "params": json.RawMessage(p)}))
+
+	var resp struct {
+		ID     int64                 `json:"id"`
+		Result json.RawMessage       `json:"result"`
+		Error  *plugin.ProtocolError `json:"error"`
+	}
+
+	require.NoError(c.t, c.dec.Decode(&resp))
+	require.Equal(c.t, c.nextID, resp.ID)
+
+	if resp.Error == nil && result != nil {
+		require.NoError(c.t, json.Unmarshal(resp.Result, result))
+	}
+
+	return resp.Error
+}
+
+func startServe(t *testing.T, st blob.Storage) *protocolClient {
+	t.Helper()
+
+	ctx := testlogging.Context(t)
+
+	reqReader, reqWriter := io.Pipe()
+	respReader, respWriter := io.Pipe()
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- plugin.Serve(ctx, reqReader, respWriter, func(context.Context, json.RawMessage, bool) (blob.Storage, error) {
+			return st, nil
+		})
+	}()
+
+	t.Cleanup(func() {
+		reqWriter.Close()
+		require.NoError(t, <-done)
+	})
+
+	c := &protocolClient{t: t, enc: json.NewEncoder(reqWriter), dec: json.NewDecoder(bufio.NewReader(respReader))}
+
+	var ir plugin.InitResult
+
+	require.Nil(t, c.call(plugin.MethodInit, plugin.InitParams{ProtocolVersion: plugin.ProtocolVersion}, &ir))
+
+	return c
+}
+
+func TestServeListBlobsPaging(t *testing.T) {
+	ctx := testlogging.Context(t)
+	st := blobtesting.NewMapStorage(blobtesting.DataMap{}, nil, nil)
+
+	for i := range 5 {
+		require.NoError(t, st.PutBlob(ctx, blob.ID(fmt.Sprintf("b%v", i)), gather.FromSlice([]byte{1}), blob.PutOptions{}))
+	}
+
+	c := startServe(t, st)
+
+	var (
+		got   []blob.ID
+		pages int
+	)
+
+	params := plugin.ListBlobsParams{MaxResults: 2}
+
+	for {
+		var res plugin.ListBlobsResult
+
+		require.Nil(t, c.call(plugin.MethodListBlobs, params, &res))
+
+		pages++
+
+		for _, bm := range res.Blobs {
+			got = append(got, bm.BlobID)
+		}
+
+		if res.ContinuationToken == "" {
+			break
+		}
+
+		params.ContinuationToken = res.ContinuationToken
+	}
+
+	require.ElementsMatch(t, []blob.ID{"b0", "b1", "b2", "b3", "b4"}, got)
+	require.Equal(t, 3, pages)
+
+	// abandon a listing after the first page.
+	var res plugin.ListBlobsResult
+
+	require.Nil(t, c.call(plugin.MethodListBlobs, plugin.ListBlobsParams{MaxResults: 2}, &res))
+	require.NotEmpty(t, res.ContinuationToken)
+	require.Nil(t, c.call(plugin.MethodCancel, plugin.CancelParams{ContinuationToken: res.ContinuationToken}, nil))
+
+	// the listing can't be continued.
+	require.NotNil(t, c.call(plugin.MethodListBlobs, plugin.ListBlobsParams{ContinuationToken: res.ContinuationToken}, &res))
+}
+
+// blockingStorage blocks GetBlob until its context is canceled.
+type blockingStorage struct {
+	blob.Storage
+}
+
+func (s blockingStorage) GetBlob(ctx context.Context, _ blob.ID, _, _ int64, _ blob.OutputBuffer) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestServeCancel(t *testing.T) {
+	c := startServe(t, blockingStorage{blobtesting.NewMapStorage(blobtesting.DataMap{}, nil, nil)})
+
+	c.nextID++
+	getBlobID := c.nextID
+
+	require.NoError(t, c.enc.Encode(map[string]any{"id": getBlobID, "method": plugin.MethodGetBlob, "params": plugin.GetBlobParams{BlobID: "b1", Length: -1}}))
+
+	// the cancel response is sent first, followed by the response to the canceled request.
+	require.Nil(t, c.call(plugin.MethodCancel, plugin.CancelParams{RequestID: getBlobID}, nil))
+
+	var resp struct {
+		ID    int64                 `json:"id"`
+		Error *plugin.ProtocolError `json:"error"`
+	}
+
+	require.NoError(t, c.dec.Decode(&resp))
+	require.Equal(t, getBlobID, resp.ID)
+	require.NotNil(t, resp.Error)
+	require.Contains(t, resp.Error.Message, "context canceled")
+}
+
+// blockingListStorage blocks ListBlobs until its context is canceled.
+type blockingListStorage struct {
+	blob.Storage
+
+	listDone chan struct{}
+}
+
+func (s blockingListStorage) ListBlobs(ctx context.Context, _ blob.ID, _ func(blob.Metadata) error) error {
+	defer close(s.listDone)
+
+	<-ctx.Done()
+
+	return ctx.Err()
+}
+
+func TestServeCancelFirstListBlobsPage(t *testing.T) {
+	st := blockingListStorage{blobtesting.NewMapStorage(blobtesting.DataMap{}, nil, nil), make(chan struct{})}
+	c := startServe(t, st)
+
+	c.nextID++
+	listID := c.nextID
+
+	require.NoError(t, c.enc.Encode(map[string]any{"id": listID, "method": plugin.MethodListBlobs, "params": plugin.ListBlobsParams{}}))
+	require.Nil(t, c.call(plugin.MethodCancel, plugin.CancelParams{RequestID: listID}, nil))
+
+	var resp struct {
+		ID    int64                 `json:"id"`
+		Error *plugin.ProtocolError `json:"error"`
+	}
+
+	require.NoError(t, c.dec.Decode(&resp))
+	require.Equal(t, listID, resp.ID)
+	require.NotNil(t, resp.Error)
+
+	// the client never received the continuation token, so the listing must be stopped by the server.
+	select {
+	case <-st.listDone:
+	case <-time.After(5 * time.Second):
+		t.Fatal("listing was not stopped")
+	}
+}
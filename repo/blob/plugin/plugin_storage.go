@@ -0,0 +1,429 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/gather"
+	"github.com/kopia/kopia/internal/osexec"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/logging"
+)
+
+const (
+	pluginStorageType = "plugin"
+
+	// ExecutablePrefix is the prefix of names of plugin executables.
+	ExecutablePrefix = "kopia-storage-"
+
+	defaultStartupTimeout = 30 * time.Second
+	closeTimeout          = 10 * time.Second
+)
+
+var log = logging.Module("plugin")
+
+var errPluginExited = errors.New("storage plugin has exited")
+
+type pluginStorage struct {
+	blob.DefaultProviderImplementation
+
+	Options
+
+	displayName string
+	readOnly    bool
+
+	cmd       *exec.Cmd
+	processed chan struct{} // closed when the plugin output has been fully processed
+
+	writeMu sync.Mutex
+	// +checklocks:writeMu
+	stdin io.WriteCloser
+
+	mu sync.Mutex
+	// +checklocks:mu
+	nextID int64
+	// +checklocks:mu
+	pending map[int64]chan *response
+	// +checklocks:mu
+	exited bool
+}
+
+func (s *pluginStorage) GetBlob(ctx context.Context, id blob.ID, offset, length int64, output blob.OutputBuffer) error {
+	var res GetBlobResult
+
+	if err := s.call(ctx, MethodGetBlob, GetBlobParams{BlobID: id, Offset: offset, Length: length}, &res); err != nil {
+		return err
+	}
+
+	if length >= 0 && int64(len(res.Data)) != length {
+		return errors.Wrapf(blob.ErrInvalidRange, "plugin returned %v bytes, expected %v", len(res.Data), length)
+	}
+
+	output.Reset()
+
+	_, err := output.Write(res.Data)
+
+	return errors.Wrap(err, "error writing output")
+}
+
+func (s *pluginStorage) GetMetadata(ctx context.Context, id blob.ID) (blob.Metadata, error) {
+	var res blob.Metadata
+
+	err := s.call(ctx, MethodGetMetadata, BlobParams{BlobID: id}, &res)
+
+	return res, err
+}
+
+func (s *pluginStorage) PutBlob(ctx context.Context, id blob.ID, data blob.Bytes, opts blob.PutOptions) error {
+	var buf gather.WriteBuffer
+	defer buf.Close()
+
+	if _, err := data.WriteTo(&buf); err != nil {
+		return errors.Wrap(err, "error reading data")
+	}
+
+	params := PutBlobParams{
+		BlobID:          id,
+		Data:            buf.ToByteSlice(),
+		DoNotRecreate:   opts.DoNotRecreate,
+		RetentionMode:   opts.RetentionMode,
+		RetentionPeriod: opts.RetentionPeriod,
+	}
+
+	if !opts.SetModTime.IsZero() {
+		params.SetModTime = &opts.SetModTime
+	}
+
+	var res PutBlobResult
+
+	if err := s.call(ctx, MethodPutBlob, params, &res); err != nil {
+		return err
+	}
+
+	if opts.GetModTime != nil && res.ModTime != nil {
+		*opts.GetModTime = *res.ModTime
+	}
+
+	return nil
+}
+
+func (s *pluginStorage) DeleteBlob(ctx context.Context, id blob.ID) error {
+	return s.call(ctx, MethodDeleteBlob, BlobParams{BlobID: id}, nil)
+}
+
+func (s *pluginStorage) ListBlobs(ctx context.Context, prefix blob.ID, cb func(bm blob.Metadata) error) error {
+	params := ListBlobsParams{Prefix: prefix}
+
+	for {
+		var res ListBlobsResult
+
+		if err := s.call(ctx, MethodListBlobs, params, &res); err != nil {
+			s.cancelListing(params.ContinuationToken)
+			return err
+		}
+
+		for _, bm := range res.Blobs {
+			if err := cb(bm); err != nil {
+				s.cancelListing(res.ContinuationToken)
+				return err
+			}
+		}
+
+		if res.ContinuationToken == "" {
+			return nil
+		}
+
+		params.ContinuationToken = res.ContinuationToken
+	}
+}
+
+// cancelListing tells the plugin that the remaining pages of the listing are not needed.
+func (s *pluginStorage) cancelListing(token string) {
+	if token != "" {
+		s.notifyCancel(CancelParams{ContinuationToken: token})
+	}
+}
+
+// notifyCancel sends the "cancel" request without waiting for the response.
+func (s *pluginStorage) notifyCancel(params CancelParams) {
+	p, err := json.Marshal(params)
+	if err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	s.nextID++
+	id := s.nextID
+	s.mu.Unlock()
+
+	s.send(&request{ID: id, Method: MethodCancel, Params: p}) //nolint:errcheck
+}
+
+func (s *pluginStorage) GetCapacity(ctx context.Context) (blob.Capacity, error) {
+	var res blob.Capacity
+
+	err := s.call(ctx, MethodGetCapacity, struct{}{}, &res)
+
+	return res, err
+}
+
+func (s *pluginStorage) IsReadOnly() bool {
+	return s.readOnly
+}
+
+func (s *pluginStorage) ConnectionInfo() blob.ConnectionInfo {
+	return blob.ConnectionInfo{
+		Type:   pluginStorageType,
+		Config: &s.Options,
+	}
+}
+
+func (s *pluginStorage) DisplayName() string {
+	if s.displayName != "" {
+		return s.displayName
+	}
+
+	return "Plugin " + s.Name
+}
+
+// Close asks the plugin to exit, killing it if it does not exit in a timely manner.
+func (s *pluginStorage) Close(ctx context.Context) error {
+	closeCtx, cancel := context.WithTimeout(ctx, closeTimeout)
+	defer cancel()
+
+	if err := s.call(closeCtx, MethodClose, struct{}{}, nil); err != nil && !errors.Is(err, errPluginExited) {
+		log(ctx).Debugf("error closing plugin: %v", err)
+	}
+
+	s.writeMu.Lock()
+	s.stdin.Close() //nolint:errcheck
+	s.writeMu.Unlock()
+
+	select {
+	case <-s.processed:
+	case <-closeCtx.Done():
+		s.cmd.Process.Kill() //nolint:errcheck
+	}
+
+	s.cmd.Wait() //nolint:errcheck
+
+	return nil
+}
+
+// call sends the request to the plugin and waits for the response, decoding its result into the provided value.
+func (s *pluginStorage) call(ctx context.Context, method string, params, result any) error {
+	p, err := json.Marshal(params)
+	if err != nil {
+		return errors.Wrap(err, "unable to serialize request")
+	}
+
+	ch := make(chan *response, 1)
+
+	s.mu.Lock()
+	if s.exited {
+		s.mu.Unlock()
+		return errPluginExited
+	}
+
+	s.nextID++
+	id := s.nextID
+	s.pending[id] = ch
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.pending, id)
+		s.mu.Unlock()
+	}()
+
+	if err := s.send(&request{ID: id, Method: method, Params: p}); err != nil {
+		return err
+	}
+
+	select {
+	case resp := <-ch:
+		if resp == nil {
+			return errPluginExited
+		}
+
+		if resp.Error != nil {
+			return errors.Wrap(resp.Error.toError(), method)
+		}
+
+		if result == nil || len(resp.Result) == 0 {
+			return nil
+		}
+
+		return errors.Wrapf(json.Unmarshal(resp.Result, result), "malformed %v response", method)
+
+	case <-ctx.Done():
+		s.notifyCancel(CancelParams{RequestID: id})
+
+		return errors.Wrap(ctx.Err(), method)
+	}
+}
+
+func (s *pluginStorage) send(req *request) error {
+	b, err := json.Marshal(req)
+	if err != nil {
+		return errors.Wrap(err, "unable to serialize request")
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	if _, err := s.stdin.Write(append(b, '\n')); err != nil {
+		return errors.Wrap(errPluginExited, err.Error())
+	}
+
+	return nil
+}
+
+// processResponses delivers responses from the plugin to the waiting callers until the plugin exits.
+func (s *pluginStorage) processResponses(ctx context.Context, stdout io.Reader) {
+	defer close(s.processed)
+
+	dec := json.NewDecoder(bufio.NewReader(stdout))
+
+	for {
+		var resp response
+
+		if err := dec.Decode(&resp); err != nil {
+			if !errors.Is(err, io.EOF) {
+				log(ctx).Errorf("invalid response from storage plugin %v: %v", s.Name, err)
+			}
+
+			break
+		}
+
+		s.mu.Lock()
+		ch := s.pending[resp.ID]
+		s.mu.Unlock()
+
+		if ch != nil {
+			ch <- &resp
+		}
+	}
+
+	// fail all pending and future calls.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.exited = true
+
+	for _, ch := range s.pending {
+		select {
+		case ch <- nil:
+		default: // response already delivered
+		}
+	}
+}
+
+func (s *pluginStorage) logStderr(ctx context.Context, stderr io.Reader) {
+	sc := bufio.NewScanner(stderr)
+
+	for sc.Scan() {
+		log(ctx).Debugf("[%v] %v", s.Name, sc.Text())
+	}
+}
+
+// executablePath returns the path of the plugin executable.
+func executablePath(opt *Options) (string, error) {
+	if opt.Executable != "" {
+		return opt.Executable, nil
+	}
+
+	if opt.Name == "" {
+		return "", errors.New("plugin name or executable must be provided")
+	}
+
+	p, err := exec.LookPath(ExecutablePrefix + opt.Name)
+	if err != nil {
+		return "", errors.Wrapf(err, "storage plugin %q not found", opt.Name)
+	}
+
+	return p, nil
+}
+
+// New starts the storage plugin and returns the storage it provides.
+func New(ctx context.Context, opt *Options, isCreate bool) (blob.Storage, error) {
+	exe, err := executablePath(opt)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command(exe, opt.Args...) //nolint:gosec
+	cmd.Env = append(cmd.Environ(), opt.Env...)
+
+	osexec.DisableInterruptSignal(cmd)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create stdin pipe")
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create stdout pipe")
+	}
+
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create stderr pipe")
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, errors.Wrapf(err, "unable to start storage plugin %v", exe)
+	}
+
+	s := &pluginStorage{
+		Options:   *opt,
+		cmd:       cmd,
+		stdin:     stdin,
+		processed: make(chan struct{}),
+		pending:   map[int64]chan *response{},
+	}
+
+	go s.logStderr(ctx, stderr)
+	go s.processResponses(ctx, stdout)
+
+	startupTimeout := defaultStartupTimeout
+	if opt.StartupTimeout != 0 {
+		startupTimeout = time.Duration(opt.StartupTimeout) * time.Second
+	}
+
+	initCtx, cancel := context.WithTimeout(ctx, startupTimeout)
+	defer cancel()
+
+	var res InitResult
+
+	if err := s.call(initCtx, MethodInit, InitParams{
+		ProtocolVersion: ProtocolVersion,
+		Config:          opt.Config,
+		IsCreate:        isCreate,
+	}, &res); err != nil {
+		s.Close(ctx) //nolint:errcheck
+		return nil, errors.Wrapf(err, "unable to initialize storage plugin %v", exe)
+	}
+
+	if res.ProtocolVersion != ProtocolVersion {
+		s.Close(ctx) //nolint:errcheck
+		return nil, errors.Errorf("storage plugin %v uses unsupported protocol version %v", exe, res.ProtocolVersion)
+	}
+
+	s.displayName = res.DisplayName
+	s.readOnly = res.ReadOnly
+
+	return s, nil
+}
+
+func init() {
+	blob.AddSupportedStorage(pluginStorageType, Options{}, New)
+}
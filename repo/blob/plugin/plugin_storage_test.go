@@ -0,0 +1,140 @@
+package plugin_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/blobtesting"
+	"github.com/kopia/kopia/internal/gather"
+	"github.com/kopia/kopia/internal/testlogging"
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/blob/filesystem"
+	"github.com/kopia/kopia/repo/blob/plugin"
+)
+
+// runAsPluginEnv causes the test binary to act as a storage plugin serving filesystem storage.
+const runAsPluginEnv = "KOPIA_TEST_RUN_AS_STORAGE_PLUGIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(runAsPluginEnv) != "" {
+		if err := plugin.Serve(context.Background(), os.Stdin, os.Stdout, openFilesystemStorage); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		os.Exit(0)
+	}
+
+	os.Exit(m.Run())
+}
+
+func openFilesystemStorage(ctx context.Context, config json.RawMessage, isCreate bool) (blob.Storage, error) {
+	var opt filesystem.Options
+
+	if err := json.Unmarshal(config, &opt); err != nil {
+		return nil, err
+	}
+
+	return filesystem.New(ctx, &opt, isCreate)
+}
+
+func newPluginStorage(t *testing.T) blob.Storage {
+	t.Helper()
+
+	ctx := testlogging.Context(t)
+
+	exe, err := os.Executable()
+	require.NoError(t, err)
+
+	cfg, err := json.Marshal(&filesystem.Options{Path: testutil.TempDirectory(t)})
+	require.NoError(t, err)
+
+	st, err := plugin.New(ctx, &plugin.Options{
+		Name:       "test",
+		Executable: exe,
+		Env:        []string{runAsPluginEnv + "=1"},
+		Config:     cfg,
+	}, true)
+	require.NoError(t, err)
+
+	return st
+}
+
+func TestPluginStorage(t *testing.T) {
+	t.Parallel()
+
+	ctx := testlogging.Context(t)
+	st := newPluginStorage(t)
+
+	blobtesting.VerifyStorage(ctx, t, st, blob.PutOptions{})
+	blobtesting.AssertConnectionInfoRoundTrips(ctx, t, st)
+
+	require.NoError(t, st.Close(ctx))
+}
+
+func TestPluginStorageErrors(t *testing.T) {
+	t.Parallel()
+
+	ctx := testlogging.Context(t)
+	st := newPluginStorage(t)
+
+	var tmp gather.WriteBuffer
+	defer tmp.Close()
+
+	require.ErrorIs(t, st.GetBlob(ctx, "no-such-blob", 0, -1, &tmp), blob.ErrBlobNotFound)
+
+	_, err := st.GetMetadata(ctx, "no-such-blob")
+	require.ErrorIs(t, err, blob.ErrBlobNotFound)
+
+	require.NoError(t, st.Close(ctx))
+
+	// calls after close fail instead of hanging.
+	require.Error(t, st.GetBlob(ctx, "no-such-blob", 0, -1, &tmp))
+}
+
+func TestPluginNotFound(t *testing.T) {
+	t.Parallel()
+
+	ctx := testlogging.Context(t)
+
+	_, err := plugin.New(ctx, &plugin.Options{Name: "no-such-plugin-for-sure"}, false)
+	require.ErrorContains(t, err, `storage plugin "no-such-plugin-for-sure" not found`)
+}
+
+func TestVerifyTokenConnectionInfo(t *testing.T) {
+	cases := []struct {
+		opt     plugin.Options
+		wantErr bool
+	}{
+		{plugin.Options{Name: "foo", Args: []string{"--bar"}}, false},
+		{plugin.Options{Name: "foo", Executable: "/bin/sh"}, true},
+		{plugin.Options{Name: "foo", Env: []string{"LD_PRELOAD=/tmp/x.so"}}, true},
+		{plugin.Options{Name: "foo/../../bin/sh"}, true},
+	}
+
+	for _, tc := range cases {
+		err := plugin.VerifyTokenConnectionInfo(blob.ConnectionInfo{Type: "plugin", Config: &tc.opt})
+		if tc.wantErr {
+			require.ErrorIs(t, err, plugin.ErrUntrustedExecution)
+		} else {
+			require.NoError(t, err)
+		}
+	}
+
+	// a token round-trip produces the same result.
+	opt := &plugin.Options{Name: "foo", Executable: "/bin/sh"}
+
+	tok, err := repo.EncodeToken("", blob.ConnectionInfo{Type: "plugin", Config: opt})
+	require.NoError(t, err)
+
+	ci, _, err := repo.DecodeToken(tok)
+	require.NoError(t, err)
+	require.ErrorIs(t, plugin.VerifyTokenConnectionInfo(ci), plugin.ErrUntrustedExecution)
+}
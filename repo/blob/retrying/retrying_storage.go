@@ -5,46 +5,109 @@
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/kopia/kopia/internal/retry"
 	"github.com/kopia/kopia/repo"
 	"github.com/kopia/kopia/repo/blob"
 )
 
+// Policy configures retries performed by the wrapper.
+// Zero fields other than Jitter and IsRetriable are taken from DefaultPolicy().
+type Policy struct {
+	// MaxAttempts is the maximum number of attempts of each operation, a negative value retries forever.
+	MaxAttempts int
+
+	// InitialDelay is the delay before the first retry, subsequent delays grow exponentially up to MaxDelay.
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+
+	// Jitter is the fraction of each delay (between 0 and 1) that is randomized.
+	Jitter float64
+
+	// IsRetriable optionally determines whether an error is retriable. Errors that are known
+	// to be permanent, such as blob.ErrBlobNotFound, are never retried.
+	IsRetriable func(err error) bool
+}
+
+// DefaultPolicy returns the retry policy used by NewWrapper.
+func DefaultPolicy() Policy {
+	p := retry.DefaultPolicy()
+
+	return Policy{
+		MaxAttempts:  p.MaxAttempts,
+		InitialDelay: p.InitialDelay,
+		MaxDelay:     p.MaxDelay,
+	}
+}
+
 // retryingStorage adds retry loop around all operations of the underlying storage.
 type retryingStorage struct {
 	blob.Storage
+
+	policy      retry.Policy
+	isRetriable retry.IsRetriableFunc
 }
 
 func (s retryingStorage) GetBlob(ctx context.Context, id blob.ID, offset, length int64, output blob.OutputBuffer) error {
-	return retry.WithExponentialBackoffNoValue(ctx, fmt.Sprintf("GetBlob(%v,%v,%v)", id, offset, length), func() error {
+	return s.retryNoValue(ctx, fmt.Sprintf("GetBlob(%v,%v,%v)", id, offset, length), func() error {
 		output.Reset()
 
 		return s.Storage.GetBlob(ctx, id, offset, length, output)
-	}, isRetriable)
+	})
 }
 
 func (s retryingStorage) GetMetadata(ctx context.Context, id blob.ID) (blob.Metadata, error) {
-	return retry.WithExponentialBackoff(ctx, "GetMetadata("+string(id)+")", func() (blob.Metadata, error) {
+	return retry.WithPolicy(ctx, s.policy, "GetMetadata("+string(id)+")", func() (blob.Metadata, error) {
 		return s.Storage.GetMetadata(ctx, id)
-	}, isRetriable)
+	}, s.isRetriable)
 }
 
 func (s retryingStorage) PutBlob(ctx context.Context, id blob.ID, data blob.Bytes, opts blob.PutOptions) error {
-	return retry.WithExponentialBackoffNoValue(ctx, "PutBlob("+string(id)+")", func() error {
+	return s.retryNoValue(ctx, "PutBlob("+string(id)+")", func() error {
 		return s.Storage.PutBlob(ctx, id, data, opts)
-	}, isRetriable)
+	})
 }
 
 func (s retryingStorage) DeleteBlob(ctx context.Context, id blob.ID) error {
-	return retry.WithExponentialBackoffNoValue(ctx, "DeleteBlob("+string(id)+")", func() error {
+	return s.retryNoValue(ctx, "DeleteBlob("+string(id)+")", func() error {
 		return s.Storage.DeleteBlob(ctx, id)
-	}, isRetriable)
+	})
+}
+
+func (s retryingStorage) retryNoValue(ctx context.Context, desc string, attempt func() error) error {
+	_, err := retry.WithPolicy(ctx, s.policy, desc, func() (bool, error) {
+		return true, attempt()
+	}, s.isRetriable)
+
+	return err
 }
 
 // NewWrapper returns a Storage wrapper that adds retry loop around all operations of the underlying storage.
 func NewWrapper(wrapped blob.Storage) blob.Storage {
-	return &retryingStorage{Storage: wrapped}
+	return NewWrapperWithPolicy(wrapped, DefaultPolicy())
+}
+
+// NewWrapperWithPolicy returns a Storage wrapper that retries all operations of the underlying storage
+// according to the provided policy.
+func NewWrapperWithPolicy(wrapped blob.Storage, p Policy) blob.Storage {
+	classify := isRetriable
+	if p.IsRetriable != nil {
+		classify = func(err error) bool {
+			return isRetriable(err) && p.IsRetriable(err)
+		}
+	}
+
+	return &retryingStorage{
+		Storage: wrapped,
+		policy: retry.Policy{
+			MaxAttempts:  p.MaxAttempts,
+			InitialDelay: p.InitialDelay,
+			MaxDelay:     p.MaxDelay,
+			Jitter:       p.Jitter,
+		},
+		isRetriable: classify,
+	}
 }
 
 func isRetriable(err error) bool {
@@ -61,6 +124,9 @@ func isRetriable(err error) bool {
 	case errors.Is(err, blob.ErrInvalidCredentials):
 		return false
 
+	case errors.Is(err, blob.ErrAccessDenied):
+		return false
+
 	case errors.Is(err, blob.ErrUnsupportedPutBlobOption):
 		return false
 
@@ -2,6 +2,7 @@
 
 import (
 	"testing"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
@@ -59,3 +60,75 @@ func TestRetrying(t *testing.T) {
 
 	fs.VerifyAllFaultsExercised(t)
 }
+
+func TestRetryingWithPolicy(t *testing.T) {
+	t.Parallel()
+
+	ctx := testlogging.Context(t)
+
+	someError := errors.New("some error")
+	permanentError := errors.New("permanent error")
+
+	ms := blobtesting.NewMapStorage(blobtesting.DataMap{}, nil, nil)
+	fs := blobtesting.NewFaultyStorage(ms)
+
+	rs := retrying.NewWrapperWithPolicy(fs, retrying.Policy{
+		MaxAttempts:  3,
+		InitialDelay: time.Millisecond,
+		MaxDelay:     time.Millisecond,
+		Jitter:       0.5,
+		IsRetriable: func(err error) bool {
+			return !errors.Is(err, permanentError)
+		},
+	})
+
+	// 2 failures are retried.
+	fs.AddFault(blobtesting.MethodPutBlob).ErrorInstead(someError).Repeat(1)
+	require.NoError(t, rs.PutBlob(ctx, "blob1", gather.FromSlice([]byte{1, 2, 3}), blob.PutOptions{}))
+
+	// 3 failures exhaust the attempts.
+	fs.AddFault(blobtesting.MethodPutBlob).ErrorInstead(someError).Repeat(2)
+	require.ErrorIs(t, rs.PutBlob(ctx, "blob2", gather.FromSlice([]byte{1, 2, 3}), blob.PutOptions{}), someError)
+
+	// errors classified as permanent are not retried.
+	fs.AddFault(blobtesting.MethodDeleteBlob).ErrorInstead(permanentError)
+	require.ErrorIs(t, rs.DeleteBlob(ctx, "blob1"), permanentError)
+
+	// access denied errors are not retried, throttling errors are.
+	fs.AddFault(blobtesting.MethodDeleteBlob).ErrorInstead(errors.WithMessage(blob.ErrAccessDenied, "forbidden"))
+	require.ErrorIs(t, rs.DeleteBlob(ctx, "blob1"), blob.ErrAccessDenied)
+
+	fs.AddFault(blobtesting.MethodPutBlob).ErrorInstead(errors.WithMessage(blob.ErrThrottled, "slow down"))
+	require.NoError(t, rs.PutBlob(ctx, "blob3", gather.FromSlice([]byte{1, 2, 3}), blob.PutOptions{}))
+
+	fs.VerifyAllFaultsExercised(t)
+
+	var tmp gather.WriteBuffer
+	defer tmp.Close()
+
+	require.NoError(t, rs.GetBlob(ctx, "blob1", 0, -1, &tmp))
+	require.ErrorIs(t, rs.GetBlob(ctx, "blob2", 0, -1, &tmp), blob.ErrBlobNotFound)
+}
+
+func TestRetryingWithZeroPolicy(t *testing.T) {
+	t.Parallel()
+
+	ctx := testlogging.Context(t)
+
+	ms := blobtesting.NewMapStorage(blobtesting.DataMap{}, nil, nil)
+	fs := blobtesting.NewFaultyStorage(ms)
+
+	// zero fields of the policy are defaulted, so operations are still executed and retried.
+	rs := retrying.NewWrapperWithPolicy(fs, retrying.Policy{})
+
+	fs.AddFault(blobtesting.MethodPutBlob).ErrorInstead(errors.New("some error"))
+	require.NoError(t, rs.PutBlob(ctx, "blob1", gather.FromSlice([]byte{1, 2, 3}), blob.PutOptions{}))
+
+	fs.VerifyAllFaultsExercised(t)
+
+	var tmp gather.WriteBuffer
+	defer tmp.Close()
+
+	require.NoError(t, ms.GetBlob(ctx, "blob1", 0, -1, &tmp))
+	require.Equal(t, []byte{1, 2, 3}, tmp.ToByteSlice())
+}
@@ -26,6 +26,14 @@ type Options struct {
 	// Region is an optional region to pass in authorization header.
 	Region string `json:"region,omitempty"`
 
+	// UsePathStyle forces path-style bucket addressing (endpoint/bucket), which is required by
+	// some S3-compatible servers, instead of detecting the addressing style from the endpoint.
+	UsePathStyle bool `json:"usePathStyle,omitempty"`
+
+	// MultipartUploadThreshold is the size above which blobs are uploaded using multipart uploads.
+	// Blobs larger than 5GiB, which S3 does not accept in a single request, always use multipart uploads.
+	MultipartUploadThreshold int64 `json:"multipartUploadThreshold,omitempty"`
+
 	throttling.Limits
 
 	// PointInTime specifies a view of the (versioned) store at that time
@@ -26,6 +26,9 @@
 const (
 	s3storageType   = "s3"
 	latestVersionID = ""
+
+	// maxSinglePartUploadSize is the largest object S3 accepts in a single PUT request.
+	maxSinglePartUploadSize int64 = 5 << 30
 )
 
 type s3Storage struct {
@@ -105,6 +108,17 @@ func translateError(err error) error {
 
 		case http.StatusRequestedRangeNotSatisfiable:
 			return blob.ErrInvalidRange
+
+		case http.StatusForbidden:
+			return errors.WithMessagef(blob.ErrAccessDenied, "%v", err)
+
+		case http.StatusTooManyRequests:
+			return errors.WithMessagef(blob.ErrThrottled, "%v", err)
+
+		case http.StatusServiceUnavailable:
+			if me.Code == "SlowDown" {
+				return errors.WithMessagef(blob.ErrThrottled, "%v", err)
+			}
 		}
 	}
 
@@ -172,8 +186,9 @@ func (s *s3Storage) putBlob(ctx context.Context, b blob.ID, data blob.Bytes, opt
 		ContentType: "application/x-kopia",
 		// Kopia already splits snapshot contents into small blobs to improve
 		// upload throughput. There is no need for further splitting
-		// through multipart uploads.
-		DisableMultipart: true,
+		// through multipart uploads, unless the blob is too large
+		// to be uploaded in a single request.
+		DisableMultipart: int64(data.Length()) <= s.multipartUploadThreshold(),
 		// The Content-MD5 header is required for any request to upload an object
 		// with a retention period configured using Amazon S3 Object Lock.
 		// Unconditionally computing the content MD5, potentially incurring
@@ -246,6 +261,14 @@ func (s *s3Storage) ExtendBlobRetention(ctx context.Context, b blob.ID, opts blo
 	return nil
 }
 
+func (s *s3Storage) multipartUploadThreshold() int64 {
+	if s.MultipartUploadThreshold > 0 {
+		return min(s.MultipartUploadThreshold, maxSinglePartUploadSize)
+	}
+
+	return maxSinglePartUploadSize
+}
+
 func (s *s3Storage) getObjectNameString(b blob.ID) string {
 	return s.Prefix + string(b)
 }
@@ -352,6 +375,7 @@ func newStorage(ctx context.Context, opt *Options) (*s3Storage, error) {
 				},
 			},
 			&credentials.EnvAWS{},
+			&credentials.FileAWSCredentials{},
 			&credentials.IAM{
 				Client: &http.Client{
 					Transport: http.DefaultTransport,
@@ -374,6 +398,10 @@ func newStorageWithCredentials(ctx context.Context, creds *credentials.Credentia
 		Region: opt.Region,
 	}
 
+	if opt.UsePathStyle {
+		minioOpts.BucketLookup = minio.BucketLookupPath
+	}
+
 	var err error
 
 	minioOpts.Transport, err = getCustomTransport(opt)
@@ -359,6 +359,43 @@ func TestS3StorageMinio(t *testing.T) {
 	testStorage(t, options, true, blob.PutOptions{})
 }
 
+func TestS3StorageMinioPathStyle(t *testing.T) {
+	t.Parallel()
+	testutil.ProviderTest(t)
+
+	minioEndpoint := startDockerMinioOrSkip(t, testutil.TempDirectory(t))
+
+	options := &Options{
+		Endpoint:                 minioEndpoint,
+		AccessKeyID:              minioRootAccessKeyID,
+		SecretAccessKey:          minioRootSecretAccessKey,
+		BucketName:               minioBucketName,
+		Region:                   minioRegion,
+		DoNotUseTLS:              true,
+		UsePathStyle:             true,
+		MultipartUploadThreshold: 1,
+	}
+
+	createBucket(t, options)
+	testStorage(t, options, true, blob.PutOptions{})
+}
+
+func TestMultipartUploadThreshold(t *testing.T) {
+	t.Parallel()
+
+	cases := map[int64]int64{
+		0:       maxSinglePartUploadSize,
+		-1:      maxSinglePartUploadSize,
+		1 << 20: 1 << 20,
+		1 << 40: maxSinglePartUploadSize,
+	}
+
+	for threshold, want := range cases {
+		s := &s3Storage{Options: Options{MultipartUploadThreshold: threshold}}
+		require.Equal(t, want, s.multipartUploadThreshold(), "threshold %v", threshold)
+	}
+}
+
 func TestS3StorageMinioSelfSignedCert(t *testing.T) {
 	t.Parallel()
 	testutil.ProviderTest(t)
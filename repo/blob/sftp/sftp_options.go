@@ -19,6 +19,7 @@ type Options struct {
 	Password       string `json:"password"                 kopia:"sensitive"`
 	Keyfile        string `json:"keyfile,omitempty"`
 	KeyData        string `json:"keyData,omitempty"        kopia:"sensitive"`
+	KeyPassphrase  string `json:"keyPassphrase,omitempty"  kopia:"sensitive"`
 	KnownHostsFile string `json:"knownHostsFile,omitempty"`
 	KnownHostsData string `json:"knownHostsData,omitempty"`
 
@@ -409,8 +409,22 @@ func getSigner(opt *Options) (ssh.Signer, error) {
 		}
 	}
 
+	if opt.KeyPassphrase != "" {
+		key, err := ssh.ParsePrivateKeyWithPassphrase(privateKeyData, []byte(opt.KeyPassphrase))
+		if err != nil {
+			return nil, errors.Wrap(err, "error parsing encrypted private key")
+		}
+
+		return key, nil
+	}
+
 	key, err := ssh.ParsePrivateKey(privateKeyData)
 	if err != nil {
+		var pme *ssh.PassphraseMissingError
+		if errors.As(err, &pme) {
+			return nil, errors.New("private key is encrypted, key passphrase must be provided")
+		}
+
 		return nil, errors.Wrap(err, "error parsing private key")
 	}
 
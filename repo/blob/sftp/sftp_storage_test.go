@@ -3,6 +3,9 @@
 import (
 	"bytes"
 	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
 	"fmt"
 	"net"
 	"os"
@@ -15,6 +18,7 @@
 
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
 
 	"github.com/kopia/kopia/internal/blobtesting"
 	"github.com/kopia/kopia/internal/clock"
@@ -288,6 +292,35 @@ func TestSFTPStorageRelativeKeyFile(t *testing.T) {
 	require.Contains(t, err.Error(), "key file path must be absolute")
 }
 
+func TestSFTPStorageEncryptedKey(t *testing.T) {
+	t.Parallel()
+
+	kh := filepath.Join(t.TempDir(), "known_hosts")
+	require.NoError(t, os.WriteFile(kh, []byte{}, 0o600))
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	pb, err := ssh.MarshalPrivateKeyWithPassphrase(priv, "", []byte("some-passphrase"))
+	require.NoError(t, err)
+
+	opt := &sftp.Options{
+		Path:           "/upload",
+		Host:           "some-host",
+		Username:       sftpUsernameWithKeyAuth,
+		Port:           22,
+		KeyData:        string(pem.EncodeToMemory(pb)),
+		KnownHostsFile: kh,
+	}
+
+	_, err = sftp.New(testlogging.Context(t), opt, false)
+	require.ErrorContains(t, err, "key passphrase must be provided")
+
+	opt.KeyPassphrase = "wrong-passphrase"
+	_, err = sftp.New(testlogging.Context(t), opt, false)
+	require.ErrorContains(t, err, "error parsing encrypted private key")
+}
+
 func TestSFTPStorageRelativeKnownHostsFile(t *testing.T) {
 	t.Parallel()
 
@@ -51,8 +51,7 @@ func (s *Storage) GetBlob(ctx context.Context, blobID blob.ID, offset, length in
 		return errors.Wrap(err, "error determining sharded path")
 	}
 
-	//nolint:wrapcheck
-	return s.Impl.GetBlobFromPath(ctx, dirPath, filePath, offset, length, output)
+	return translateError(s.Impl.GetBlobFromPath(ctx, dirPath, filePath, offset, length, output))
 }
 
 func (s *Storage) getBlobIDFromFileName(name string) (blob.ID, bool) {
@@ -83,12 +82,14 @@ func (s *Storage) ListBlobs(ctx context.Context, prefix blob.ID, callback func(b
 		select {
 		case <-finished: // already finished
 			return nil
+		case <-ctx.Done():
+			return ctx.Err() //nolint:wrapcheck
 		default:
 		}
 
 		entries, err := s.Impl.ReadDir(ctx, directory)
 		if err != nil {
-			return errors.Wrap(err, "error reading directory")
+			return errors.Wrap(translateError(err), "error reading directory")
 		}
 
 		for _, e := range entries {
@@ -130,6 +131,8 @@ func (s *Storage) ListBlobs(ctx context.Context, prefix blob.ID, callback func(b
 				Timestamp: e.ModTime(),
 			}:
 			case <-finished:
+			case <-ctx.Done():
+				return ctx.Err() //nolint:wrapcheck
 			}
 		}
 
@@ -175,7 +178,7 @@ func (s *Storage) GetMetadata(ctx context.Context, blobID blob.ID) (blob.Metadat
 	m, err := s.Impl.GetMetadataFromPath(ctx, dirPath, filePath)
 	m.BlobID = blobID
 
-	return m, errors.Wrap(err, "error getting metadata")
+	return m, errors.Wrap(translateError(err), "error getting metadata")
 }
 
 // PutBlob implements blob.Storage.
@@ -185,8 +188,7 @@ func (s *Storage) PutBlob(ctx context.Context, blobID blob.ID, data blob.Bytes,
 		return errors.Wrap(err, "error determining sharded path")
 	}
 
-	//nolint:wrapcheck
-	return s.Impl.PutBlobInPath(ctx, dirPath, filePath, data, opts)
+	return translateError(s.Impl.PutBlobInPath(ctx, dirPath, filePath, data, opts))
 }
 
 // DeleteBlob implements blob.Storage.
@@ -196,8 +198,17 @@ func (s *Storage) DeleteBlob(ctx context.Context, blobID blob.ID) error {
 		return errors.Wrap(err, "error determining sharded path")
 	}
 
+	return translateError(s.Impl.DeleteBlobInPath(ctx, dirPath, filePath))
+}
+
+// translateError maps permission errors reported by file-based implementations to blob.ErrAccessDenied.
+func translateError(err error) error {
+	if errors.Is(err, os.ErrPermission) && !errors.Is(err, blob.ErrAccessDenied) {
+		return errors.WithMessagef(blob.ErrAccessDenied, "%v", err)
+	}
+
 	//nolint:wrapcheck
-	return s.Impl.DeleteBlobInPath(ctx, dirPath, filePath)
+	return err
 }
 
 func (s *Storage) getParameters(ctx context.Context) (*Parameters, error) {
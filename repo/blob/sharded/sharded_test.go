@@ -2,6 +2,7 @@
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -91,6 +92,29 @@ func TestShardedFileStorage(t *testing.T) {
 	}
 }
 
+func TestShardedListBlobsCanceled(t *testing.T) {
+	t.Parallel()
+
+	ctx := testlogging.Context(t)
+
+	st, err := filesystem.New(ctx, &filesystem.Options{
+		Path:    testutil.TempDirectory(t),
+		Options: sharded.Options{},
+	}, true)
+	require.NoError(t, err)
+
+	for i := range 10 {
+		require.NoError(t, st.PutBlob(ctx, blob.ID(fmt.Sprintf("blob%v", i)), gather.FromSlice([]byte{1, 2, 3}), blob.PutOptions{}))
+	}
+
+	canceledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+
+	require.ErrorIs(t, st.ListBlobs(canceledCtx, "", func(blob.Metadata) error {
+		return nil
+	}), context.Canceled)
+}
+
 func TestShardedFileStorageShardingMap(t *testing.T) {
 	cases := []struct {
 		desc            string
@@ -0,0 +1,358 @@
+// Package splitblob implements wrapper around blob.Storage that transparently stores blobs larger than
+// a configured size as multiple smaller blobs stitched together by a small manifest, since some
+// storage backends limit object sizes and very large uploads are fragile.
+//
+// A blob with ID X that exceeds the maximum size is stored as blobs named X.chunk-000000, X.chunk-000001, ...
+// followed by a manifest blob named X.chunks, which is written last so that a blob is never visible
+// before all its chunks have been written. Blobs that fit within the limit are stored unchanged and
+// reading them incurs no additional overhead. When both versions of a blob exist, the unsplit blob
+// takes precedence.
+package splitblob
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/gather"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/logging"
+)
+
+var log = logging.Module("splitblob")
+
+const (
+	manifestSuffix = ".chunks"
+	chunkSeparator = ".chunk-"
+)
+
+// manifest describes how a blob is split into chunks.
+type manifest struct {
+	Length    int64 `json:"length"`
+	ChunkSize int64 `json:"chunkSize"`
+}
+
+func (m manifest) chunkCount() int64 {
+	return (m.Length + m.ChunkSize - 1) / m.ChunkSize
+}
+
+func (m manifest) validate() error {
+	if m.Length < 0 || m.ChunkSize <= 0 {
+		return errors.Errorf("invalid manifest: %+v", m)
+	}
+
+	return nil
+}
+
+func manifestID(id blob.ID) blob.ID {
+	return id + manifestSuffix
+}
+
+func chunkID(id blob.ID, n int64) blob.ID {
+	return id + blob.ID(fmt.Sprintf("%v%06d", chunkSeparator, n))
+}
+
+// parseChunkID returns the ID of the blob that the provided chunk ID belongs to.
+func parseChunkID(id blob.ID) (blob.ID, bool) {
+	p := strings.LastIndex(string(id), chunkSeparator)
+	if p < 0 {
+		return "", false
+	}
+
+	// chunk number must follow the separator, this rejects IDs which merely contain it.
+	n := string(id[p+len(chunkSeparator):])
+	if n == "" || strings.Trim(n, "0123456789") != "" {
+		return "", false
+	}
+
+	return id[0:p], true
+}
+
+type splitStorage struct {
+	blob.Storage
+
+	maxSize int64
+}
+
+func (s *splitStorage) GetBlob(ctx context.Context, id blob.ID, offset, length int64, output blob.OutputBuffer) error {
+	err := s.Storage.GetBlob(ctx, id, offset, length, output)
+	if !errors.Is(err, blob.ErrBlobNotFound) {
+		return err //nolint:wrapcheck
+	}
+
+	m, merr := s.readManifest(ctx, id)
+	if errors.Is(merr, blob.ErrBlobNotFound) {
+		return err //nolint:wrapcheck
+	}
+
+	if merr != nil {
+		return merr
+	}
+
+	return s.getChunked(ctx, id, m, offset, length, output)
+}
+
+func (s *splitStorage) getChunked(ctx context.Context, id blob.ID, m manifest, offset, length int64, output blob.OutputBuffer) error {
+	if length < 0 {
+		length = m.Length - offset
+	}
+
+	if offset < 0 || length < 0 || offset+length > m.Length {
+		return errors.Wrapf(blob.ErrInvalidRange, "invalid range %v+%v of %v (length %v)", offset, length, id, m.Length)
+	}
+
+	output.Reset()
+
+	var tmp gather.WriteBuffer
+	defer tmp.Close()
+
+	for length > 0 {
+		n := offset / m.ChunkSize
+		chunkOffset := offset % m.ChunkSize
+		chunkLength := min(length, m.ChunkSize-chunkOffset)
+
+		if err := s.Storage.GetBlob(ctx, chunkID(id, n), chunkOffset, chunkLength, &tmp); err != nil {
+			return errors.Wrapf(err, "error reading chunk %v of %v", n, id)
+		}
+
+		if err := blob.EnsureLengthExactly(tmp.Length(), chunkLength); err != nil {
+			return errors.Wrapf(err, "invalid chunk %v of %v", n, id)
+		}
+
+		if _, err := tmp.Bytes().WriteTo(output); err != nil {
+			return errors.Wrap(err, "error writing output")
+		}
+
+		offset += chunkLength
+		length -= chunkLength
+	}
+
+	return nil
+}
+
+func (s *splitStorage) readManifest(ctx context.Context, id blob.ID) (manifest, error) {
+	var (
+		tmp gather.WriteBuffer
+		m   manifest
+	)
+
+	defer tmp.Close()
+
+	if err := s.Storage.GetBlob(ctx, manifestID(id), 0, -1, &tmp); err != nil {
+		return m, err //nolint:wrapcheck
+	}
+
+	if err := json.Unmarshal(tmp.ToByteSlice(), &m); err != nil {
+		return m, errors.Wrapf(err, "invalid chunk manifest for %v", id)
+	}
+
+	return m, errors.Wrapf(m.validate(), "invalid chunk manifest for %v", id)
+}
+
+func (s *splitStorage) GetMetadata(ctx context.Context, id blob.ID) (blob.Metadata, error) {
+	bm, err := s.Storage.GetMetadata(ctx, id)
+	if !errors.Is(err, blob.ErrBlobNotFound) {
+		return bm, err //nolint:wrapcheck
+	}
+
+	mm, merr := s.Storage.GetMetadata(ctx, manifestID(id))
+	if errors.Is(merr, blob.ErrBlobNotFound) {
+		return bm, err //nolint:wrapcheck
+	}
+
+	if merr != nil {
+		return bm, merr //nolint:wrapcheck
+	}
+
+	m, merr := s.readManifest(ctx, id)
+	if merr != nil {
+		return bm, merr
+	}
+
+	return blob.Metadata{
+		BlobID:    id,
+		Length:    m.Length,
+		Timestamp: mm.Timestamp,
+	}, nil
+}
+
+func (s *splitStorage) PutBlob(ctx context.Context, id blob.ID, data blob.Bytes, opts blob.PutOptions) error {
+	if int64(data.Length()) <= s.maxSize {
+		return s.Storage.PutBlob(ctx, id, data, opts) //nolint:wrapcheck
+	}
+
+	m := manifest{
+		Length:    int64(data.Length()),
+		ChunkSize: s.maxSize,
+	}
+
+	chunkOpts := opts
+	chunkOpts.DoNotRecreate = false
+	chunkOpts.GetModTime = nil
+
+	var tmp gather.WriteBuffer
+	defer tmp.Close()
+
+	r := data.Reader()
+	defer r.Close() //nolint:errcheck
+
+	for n := range m.chunkCount() {
+		buf := tmp.MakeContiguous(int(min(m.ChunkSize, m.Length-n*m.ChunkSize)))
+
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return errors.Wrap(err, "error reading data")
+		}
+
+		if err := s.Storage.PutBlob(ctx, chunkID(id, n), gather.FromSlice(buf), chunkOpts); err != nil {
+			s.deleteChunks(ctx, id, n)
+			return errors.Wrapf(err, "error writing chunk %v of %v", n, id)
+		}
+	}
+
+	mb, err := json.Marshal(m)
+	if err != nil {
+		return errors.Wrap(err, "unable to serialize chunk manifest")
+	}
+
+	if err := s.Storage.PutBlob(ctx, manifestID(id), gather.FromSlice(mb), opts); err != nil {
+		s.deleteChunks(ctx, id, m.chunkCount())
+		return errors.Wrapf(err, "error writing chunk manifest of %v", id)
+	}
+
+	// remove previous unsplit blob with the same ID, if any, since it would take precedence.
+	return errors.Wrapf(s.Storage.DeleteBlob(ctx, id), "error deleting previous version of %v", id)
+}
+
+// deleteChunks deletes the first n chunks of the provided blob on a best-effort basis.
+func (s *splitStorage) deleteChunks(ctx context.Context, id blob.ID, n int64) {
+	for i := range n {
+		if err := s.Storage.DeleteBlob(ctx, chunkID(id, i)); err != nil {
+			log(ctx).Debugf("unable to delete chunk %v of %v: %v", i, id, err)
+		}
+	}
+}
+
+func (s *splitStorage) DeleteBlob(ctx context.Context, id blob.ID) error {
+	if err := s.Storage.DeleteBlob(ctx, id); err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	// most blobs are not split, only look for chunks if the blob has a manifest.
+	if _, err := s.Storage.GetMetadata(ctx, manifestID(id)); err != nil {
+		if errors.Is(err, blob.ErrBlobNotFound) {
+			return nil
+		}
+
+		return errors.Wrapf(err, "error checking chunk manifest of %v", id)
+	}
+
+	// chunks are found by listing rather than from the manifest, which also removes chunks left behind
+	// by an earlier, longer version of the blob.
+	var chunks []blob.ID
+
+	if err := s.Storage.ListBlobs(ctx, id+chunkSeparator, func(bm blob.Metadata) error {
+		if owner, ok := parseChunkID(bm.BlobID); ok && owner == id {
+			chunks = append(chunks, bm.BlobID)
+		}
+
+		return nil
+	}); err != nil {
+		return errors.Wrapf(err, "error listing chunks of %v", id)
+	}
+
+	for _, cid := range chunks {
+		if err := s.Storage.DeleteBlob(ctx, cid); err != nil {
+			return errors.Wrapf(err, "error deleting chunk %v of %v", cid, id)
+		}
+	}
+
+	// delete the manifest last, so that an interrupted delete can be retried.
+	return errors.Wrapf(s.Storage.DeleteBlob(ctx, manifestID(id)), "error deleting chunk manifest of %v", id)
+}
+
+func (s *splitStorage) ExtendBlobRetention(ctx context.Context, id blob.ID, opts blob.ExtendOptions) error {
+	err := s.Storage.ExtendBlobRetention(ctx, id, opts)
+	if !errors.Is(err, blob.ErrBlobNotFound) {
+		return err //nolint:wrapcheck
+	}
+
+	m, merr := s.readManifest(ctx, id)
+	if errors.Is(merr, blob.ErrBlobNotFound) {
+		return err //nolint:wrapcheck
+	}
+
+	if merr != nil {
+		return merr
+	}
+
+	for n := range m.chunkCount() {
+		if err := s.Storage.ExtendBlobRetention(ctx, chunkID(id, n), opts); err != nil {
+			return errors.Wrapf(err, "error extending retention of chunk %v of %v", n, id)
+		}
+	}
+
+	return s.Storage.ExtendBlobRetention(ctx, manifestID(id), opts) //nolint:wrapcheck
+}
+
+func (s *splitStorage) ListBlobs(ctx context.Context, prefix blob.ID, callback func(blob.Metadata) error) error {
+	// split blobs are reported after all other blobs, once the lengths of all their chunks are known.
+	manifests := map[blob.ID]blob.Metadata{}
+	chunkLengths := map[blob.ID]int64{}
+	unsplit := map[blob.ID]struct{}{}
+
+	if err := s.Storage.ListBlobs(ctx, prefix, func(bm blob.Metadata) error {
+		if id, ok := parseChunkID(bm.BlobID); ok {
+			chunkLengths[id] += bm.Length
+			return nil
+		}
+
+		if strings.HasSuffix(string(bm.BlobID), manifestSuffix) {
+			id := bm.BlobID[0 : len(bm.BlobID)-len(manifestSuffix)]
+
+			bm.BlobID = id
+			manifests[id] = bm
+
+			return nil
+		}
+
+		unsplit[bm.BlobID] = struct{}{}
+
+		return callback(bm)
+	}); err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	var ids []blob.ID
+
+	for id := range manifests {
+		ids = append(ids, id)
+	}
+
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	for _, id := range ids {
+		// unsplit blob with the same ID takes precedence and has already been reported.
+		if _, ok := unsplit[id]; ok {
+			continue
+		}
+
+		bm := manifests[id]
+		bm.Length = chunkLengths[id]
+
+		if err := callback(bm); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// NewWrapper returns a Storage wrapper that splits blobs larger than maxSize bytes into chunks.
+func NewWrapper(wrapped blob.Storage, maxSize int64) blob.Storage {
+	return &splitStorage{wrapped, maxSize}
+}
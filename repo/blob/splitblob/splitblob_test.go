@@ -0,0 +1,145 @@
+package splitblob_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/blobtesting"
+	"github.com/kopia/kopia/internal/clock"
+	"github.com/kopia/kopia/internal/gather"
+	"github.com/kopia/kopia/internal/testlogging"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/blob/splitblob"
+)
+
+func TestSplitBlobVerifyStorage(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	for _, maxSize := range []int64{1, 3, 7, 1000} {
+		st := splitblob.NewWrapper(blobtesting.NewMapStorage(blobtesting.DataMap{}, nil, clock.Now), maxSize)
+
+		blobtesting.VerifyStorage(ctx, t, st, blob.PutOptions{})
+	}
+}
+
+func TestSplitBlob(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	data := blobtesting.DataMap{}
+	st := splitblob.NewWrapper(blobtesting.NewMapStorage(data, nil, clock.Now), 10)
+
+	payload := bytes.Repeat([]byte("0123456789abcdef"), 3)
+
+	require.NoError(t, st.PutBlob(ctx, "pbig", gather.FromSlice(payload), blob.PutOptions{}))
+	require.NoError(t, st.PutBlob(ctx, "psmall", gather.FromSlice([]byte("small")), blob.PutOptions{}))
+
+	// 5 chunks plus manifest for the large blob, small blob is stored as-is.
+	require.Len(t, data, 7)
+	require.Contains(t, data, blob.ID("pbig.chunks"))
+	require.Contains(t, data, blob.ID("pbig.chunk-000004"))
+	require.Equal(t, []byte("small"), data["psmall"])
+
+	var tmp gather.WriteBuffer
+	defer tmp.Close()
+
+	require.NoError(t, st.GetBlob(ctx, "pbig", 0, -1, &tmp))
+	require.Equal(t, payload, tmp.ToByteSlice())
+
+	// ranges spanning chunk boundaries
+	for _, r := range [][2]int64{{0, 1}, {9, 2}, {5, 30}, {40, 8}, {47, 1}, {0, 48}, {48, 0}} {
+		require.NoError(t, st.GetBlob(ctx, "pbig", r[0], r[1], &tmp))
+		require.Equal(t, payload[r[0]:r[0]+r[1]], tmp.ToByteSlice(), "range %v", r)
+	}
+
+	require.ErrorIs(t, st.GetBlob(ctx, "pbig", 40, 9, &tmp), blob.ErrInvalidRange)
+
+	bm, err := st.GetMetadata(ctx, "pbig")
+	require.NoError(t, err)
+	require.Equal(t, blob.ID("pbig"), bm.BlobID)
+	require.EqualValues(t, len(payload), bm.Length)
+
+	all, err := blob.ListAllBlobs(ctx, st, "p")
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+
+	sizes := map[blob.ID]int64{}
+	for _, bm := range all {
+		sizes[bm.BlobID] = bm.Length
+	}
+
+	require.Equal(t, map[blob.ID]int64{"pbig": int64(len(payload)), "psmall": 5}, sizes)
+
+	require.NoError(t, st.DeleteBlob(ctx, "pbig"))
+	require.Len(t, data, 1)
+	require.ErrorIs(t, st.GetBlob(ctx, "pbig", 0, -1, &tmp), blob.ErrBlobNotFound)
+
+	_, err = st.GetMetadata(ctx, "pbig")
+	require.ErrorIs(t, err, blob.ErrBlobNotFound)
+}
+
+func TestSplitBlobIncompleteWriteIsInvisible(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	data := blobtesting.DataMap{}
+	st := splitblob.NewWrapper(blobtesting.NewMapStorage(data, nil, clock.Now), 10)
+
+	require.NoError(t, st.PutBlob(ctx, "pbig", gather.FromSlice(make([]byte, 25)), blob.PutOptions{}))
+	delete(data, "pbig.chunks")
+
+	all, err := blob.ListAllBlobs(ctx, st, "")
+	require.NoError(t, err)
+	require.Empty(t, all)
+
+	var tmp gather.WriteBuffer
+	defer tmp.Close()
+
+	require.ErrorIs(t, st.GetBlob(ctx, "pbig", 0, -1, &tmp), blob.ErrBlobNotFound)
+}
+
+func TestSplitBlobDeleteRemovesAllChunks(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	data := blobtesting.DataMap{}
+	st := splitblob.NewWrapper(blobtesting.NewMapStorage(data, nil, clock.Now), 10)
+
+	// shorter version of the blob leaves the last chunks of the previous version behind.
+	require.NoError(t, st.PutBlob(ctx, "pbig", gather.FromSlice(make([]byte, 45)), blob.PutOptions{}))
+	require.NoError(t, st.PutBlob(ctx, "pbig", gather.FromSlice(make([]byte, 25)), blob.PutOptions{}))
+	require.Contains(t, data, blob.ID("pbig.chunk-000004"))
+
+	// chunks of a blob whose ID starts with the same prefix are left alone.
+	require.NoError(t, st.PutBlob(ctx, "pbig.chunk-x", gather.FromSlice(make([]byte, 15)), blob.PutOptions{}))
+
+	// remaining chunks of a blob whose previous delete was interrupted.
+	require.NoError(t, st.PutBlob(ctx, "ppartial", gather.FromSlice(make([]byte, 25)), blob.PutOptions{}))
+	delete(data, "ppartial.chunk-000000")
+
+	require.NoError(t, st.DeleteBlob(ctx, "pbig"))
+	require.NoError(t, st.DeleteBlob(ctx, "ppartial"))
+
+	var remaining []blob.ID
+
+	for id := range data {
+		remaining = append(remaining, id)
+	}
+
+	require.ElementsMatch(t, []blob.ID{"pbig.chunk-x.chunks", "pbig.chunk-x.chunk-000000", "pbig.chunk-x.chunk-000001"}, remaining)
+}
+
+func TestSplitBlobDeleteUnsplitBlobDoesNotList(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	data := blobtesting.DataMap{}
+	fs := blobtesting.NewFaultyStorage(blobtesting.NewMapStorage(data, nil, clock.Now))
+	st := splitblob.NewWrapper(fs, 10)
+
+	require.NoError(t, st.PutBlob(ctx, "psmall", gather.FromSlice(make([]byte, 5)), blob.PutOptions{}))
+
+	fs.AddFault(blobtesting.MethodListBlobs).ErrorInstead(errors.New("unexpected list"))
+
+	require.NoError(t, st.DeleteBlob(ctx, "psmall"))
+	require.Empty(t, data)
+}
@@ -30,6 +30,14 @@
 // authenticating with a storage provider has expired.
 var ErrInvalidCredentials = errors.Errorf(InvalidCredentialsErrStr)
 
+// ErrAccessDenied is returned when the credentials used to access the storage provider
+// don't grant permissions required for the operation.
+var ErrAccessDenied = errors.New("access denied")
+
+// ErrThrottled is returned when the storage provider rejects the request because
+// the request rate or bandwidth limits have been exceeded, such requests should be retried later.
+var ErrThrottled = errors.New("request throttled by storage provider")
+
 // ErrBlobAlreadyExists is returned when attempting to put a blob that already exists.
 var ErrBlobAlreadyExists = errors.New("blob already exists")
 
@@ -70,6 +78,15 @@ type Capacity struct {
 	FreeB uint64 `json:"available"`
 }
 
+// UsedB returns the number of bytes used on the volume.
+func (c Capacity) UsedB() uint64 {
+	if c.FreeB > c.SizeB {
+		return 0
+	}
+
+	return c.SizeB - c.FreeB
+}
+
 // Volume defines disk/volume access API to blob storage.
 type Volume interface {
 	// GetCapacity returns the capacity of a given volume.
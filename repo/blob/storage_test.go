@@ -199,3 +199,9 @@ func TestPutBlobAndGetMetadata(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, fixedTime, bm.Timestamp)
 }
+
+func TestCapacityUsed(t *testing.T) {
+	require.EqualValues(t, 30, blob.Capacity{SizeB: 100, FreeB: 70}.UsedB())
+	require.EqualValues(t, 0, blob.Capacity{SizeB: 100, FreeB: 100}.UsedB())
+	require.EqualValues(t, 0, blob.Capacity{SizeB: 10, FreeB: 20}.UsedB())
+}
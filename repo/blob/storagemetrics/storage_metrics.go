@@ -3,6 +3,7 @@
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/kopia/kopia/internal/metrics"
@@ -10,6 +11,62 @@
 	"github.com/kopia/kopia/repo/blob"
 )
 
+// Names of storage methods, used as values of the "method" label.
+const (
+	methodGetBlob             = "GetBlob"
+	methodGetCapacity         = "GetCapacity"
+	methodGetMetadata         = "GetMetadata"
+	methodPutBlob             = "PutBlob"
+	methodDeleteBlob          = "DeleteBlob"
+	methodExtendBlobRetention = "ExtendBlobRetention"
+	methodListBlobs           = "ListBlobs"
+	methodClose               = "Close"
+	methodFlushCaches         = "FlushCaches"
+)
+
+// Classes of errors, used as values of the "class" label.
+const (
+	errorClassNotFound      = "not_found"
+	errorClassInvalidRange  = "invalid_range"
+	errorClassAlreadyExists = "already_exists"
+	errorClassAccessDenied  = "access_denied"
+	errorClassUnsupported   = "unsupported"
+	errorClassCanceled      = "canceled"
+	errorClassOther         = "other"
+)
+
+//nolint:gochecknoglobals
+var (
+	allMethods = []string{
+		methodGetBlob, methodGetCapacity, methodGetMetadata, methodPutBlob, methodDeleteBlob,
+		methodExtendBlobRetention, methodListBlobs, methodClose, methodFlushCaches,
+	}
+
+	allErrorClasses = []string{
+		errorClassNotFound, errorClassInvalidRange, errorClassAlreadyExists, errorClassAccessDenied,
+		errorClassUnsupported, errorClassCanceled, errorClassOther,
+	}
+)
+
+func classifyError(err error) string {
+	switch {
+	case errors.Is(err, blob.ErrBlobNotFound):
+		return errorClassNotFound
+	case errors.Is(err, blob.ErrInvalidRange):
+		return errorClassInvalidRange
+	case errors.Is(err, blob.ErrBlobAlreadyExists):
+		return errorClassAlreadyExists
+	case errors.Is(err, blob.ErrAccessDenied), errors.Is(err, blob.ErrInvalidCredentials):
+		return errorClassAccessDenied
+	case errors.Is(err, blob.ErrNotAVolume), errors.Is(err, blob.ErrUnsupportedPutBlobOption), errors.Is(err, blob.ErrSetTimeUnsupported):
+		return errorClassUnsupported
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return errorClassCanceled
+	default:
+		return errorClassOther
+	}
+}
+
 type blobMetrics struct {
 	base blob.Storage
 
@@ -29,6 +86,9 @@ type blobMetrics struct {
 	closeDuration               *metrics.Distribution[time.Duration]
 	flushCachesDuration         *metrics.Distribution[time.Duration]
 
+	operations    map[string]*metrics.Counter
+	errorsByClass map[string]map[string]*metrics.Counter // by method and error class
+
 	getBlobErrors             *metrics.Counter
 	getCapacityErrors         *metrics.Counter
 	getMetadataErrors         *metrics.Counter
@@ -40,11 +100,21 @@ type blobMetrics struct {
 	flushCachesErrors         *metrics.Counter
 }
 
+func (s *blobMetrics) recordOperation(method string, err error) {
+	s.operations[method].Add(1)
+
+	if err != nil {
+		s.errorsByClass[method][classifyError(err)].Add(1)
+	}
+}
+
 func (s *blobMetrics) GetBlob(ctx context.Context, id blob.ID, offset, length int64, output blob.OutputBuffer) error {
 	timer := timetrack.StartTimer()
 	err := s.base.GetBlob(ctx, id, offset, length, output)
 	dt := timer.Elapsed()
 
+	s.recordOperation(methodGetBlob, err)
+
 	if length < 0 {
 		s.downloadedBytesFull.Add(int64(output.Length()))
 		s.getBlobFullDuration.Observe(dt)
@@ -66,6 +136,8 @@ func (s *blobMetrics) GetCapacity(ctx context.Context) (blob.Capacity, error) {
 	c, err := s.base.GetCapacity(ctx)
 	dt := timer.Elapsed()
 
+	s.recordOperation(methodGetCapacity, err)
+
 	s.getCapacityDuration.Observe(dt)
 
 	if err != nil {
@@ -85,6 +157,8 @@ func (s *blobMetrics) GetMetadata(ctx context.Context, id blob.ID) (blob.Metadat
 	result, err := s.base.GetMetadata(ctx, id)
 	dt := timer.Elapsed()
 
+	s.recordOperation(methodGetMetadata, err)
+
 	s.getMetadataDuration.Observe(dt)
 
 	if err != nil {
@@ -100,6 +174,8 @@ func (s *blobMetrics) PutBlob(ctx context.Context, id blob.ID, data blob.Bytes,
 	err := s.base.PutBlob(ctx, id, data, opts)
 	dt := timer.Elapsed()
 
+	s.recordOperation(methodPutBlob, err)
+
 	s.putBlobDuration.Observe(dt)
 
 	if err != nil {
@@ -117,6 +193,8 @@ func (s *blobMetrics) DeleteBlob(ctx context.Context, id blob.ID) error {
 	err := s.base.DeleteBlob(ctx, id)
 	dt := timer.Elapsed()
 
+	s.recordOperation(methodDeleteBlob, err)
+
 	s.deleteBlobDuration.Observe(dt)
 
 	if err != nil {
@@ -132,6 +210,8 @@ func (s *blobMetrics) ExtendBlobRetention(ctx context.Context, id blob.ID, opts
 	err := s.base.ExtendBlobRetention(ctx, id, opts)
 	dt := timer.Elapsed()
 
+	s.recordOperation(methodExtendBlobRetention, err)
+
 	s.extendBlobRetentionDuration.Observe(dt)
 
 	if err != nil {
@@ -151,6 +231,8 @@ func (s *blobMetrics) ListBlobs(ctx context.Context, prefix blob.ID, callback fu
 	})
 	dt := timer.Elapsed()
 
+	s.recordOperation(methodListBlobs, err)
+
 	s.listBlobItems.Add(cnt)
 	s.listBlobsDuration.Observe(dt)
 
@@ -167,6 +249,8 @@ func (s *blobMetrics) Close(ctx context.Context) error {
 	err := s.base.Close(ctx)
 	dt := timer.Elapsed()
 
+	s.recordOperation(methodClose, err)
+
 	s.closeDuration.Observe(dt)
 
 	if err != nil {
@@ -190,6 +274,8 @@ func (s *blobMetrics) FlushCaches(ctx context.Context) error {
 	err := s.base.FlushCaches(ctx)
 	dt := timer.Elapsed()
 
+	s.recordOperation(methodFlushCaches, err)
+
 	s.flushCachesDuration.Observe(dt)
 
 	if err != nil {
@@ -219,9 +305,34 @@ func NewWrapper(wrapped blob.Storage, mr *metrics.Registry) blob.Storage {
 		)
 	}
 
+	operations := map[string]*metrics.Counter{}
+	for _, m := range allMethods {
+		operations[m] = mr.CounterInt64(
+			"blob_operations",
+			"Number of storage operations by method",
+			map[string]string{"method": m},
+		)
+	}
+
+	errorsByClass := map[string]map[string]*metrics.Counter{}
+	for _, m := range allMethods {
+		errorsByClass[m] = map[string]*metrics.Counter{}
+
+		for _, c := range allErrorClasses {
+			errorsByClass[m][c] = mr.CounterInt64(
+				"blob_errors_by_class",
+				"Number of storage operation errors by method and error class",
+				map[string]string{"method": m, "class": c},
+			)
+		}
+	}
+
 	return &blobMetrics{
 		base: wrapped,
 
+		operations:    operations,
+		errorsByClass: errorsByClass,
+
 		downloadedBytesPartial: mr.CounterInt64("blob_download_partial_blob_bytes", "Number of bytes downloaded as partial blobs", nil),
 		downloadedBytesFull:    mr.CounterInt64("blob_download_full_blob_bytes", "Number of bytes downloaded as full blobs", nil),
 		uploadedBytes:          mr.CounterInt64("blob_upload_bytes", "Number of bytes uploaded", nil),
@@ -229,21 +340,21 @@ func NewWrapper(wrapped blob.Storage, mr *metrics.Registry) blob.Storage {
 
 		getBlobPartialDuration: durationSummaryForMethod("GetBlob-partial"),
 		getBlobFullDuration:    durationSummaryForMethod("GetBlob-full"),
-		getCapacityDuration:    durationSummaryForMethod("GetCapacity"),
-		getMetadataDuration:    durationSummaryForMethod("GetMetadata"),
-		putBlobDuration:        durationSummaryForMethod("PutBlob"),
-		deleteBlobDuration:     durationSummaryForMethod("DeleteBlob"),
-		listBlobsDuration:      durationSummaryForMethod("ListBlobs"),
-		closeDuration:          durationSummaryForMethod("Close"),
-		flushCachesDuration:    durationSummaryForMethod("FlushCaches"),
-
-		getBlobErrors:     errorCounterForMethod("GetBlob"),
-		getCapacityErrors: errorCounterForMethod("GetCapacity"),
-		getMetadataErrors: errorCounterForMethod("GetMetadata"),
-		putBlobErrors:     errorCounterForMethod("PutBlob"),
-		deleteBlobErrors:  errorCounterForMethod("DeleteBlob"),
-		listBlobsErrors:   errorCounterForMethod("ListBlobs"),
-		closeErrors:       errorCounterForMethod("Close"),
-		flushCachesErrors: errorCounterForMethod("FlushCaches"),
+		getCapacityDuration:    durationSummaryForMethod(methodGetCapacity),
+		getMetadataDuration:    durationSummaryForMethod(methodGetMetadata),
+		putBlobDuration:        durationSummaryForMethod(methodPutBlob),
+		deleteBlobDuration:     durationSummaryForMethod(methodDeleteBlob),
+		listBlobsDuration:      durationSummaryForMethod(methodListBlobs),
+		closeDuration:          durationSummaryForMethod(methodClose),
+		flushCachesDuration:    durationSummaryForMethod(methodFlushCaches),
+
+		getBlobErrors:     errorCounterForMethod(methodGetBlob),
+		getCapacityErrors: errorCounterForMethod(methodGetCapacity),
+		getMetadataErrors: errorCounterForMethod(methodGetMetadata),
+		putBlobErrors:     errorCounterForMethod(methodPutBlob),
+		deleteBlobErrors:  errorCounterForMethod(methodDeleteBlob),
+		listBlobsErrors:   errorCounterForMethod(methodListBlobs),
+		closeErrors:       errorCounterForMethod(methodClose),
+		flushCachesErrors: errorCounterForMethod(methodFlushCaches),
 	}
 }
@@ -1,6 +1,7 @@
 package storagemetrics_test
 
 import (
+	"context"
 	"testing"
 
 	"github.com/pkg/errors"
@@ -286,3 +287,41 @@ func requireCounterValue(t *testing.T, snap metrics.Snapshot, key string, want i
 	require.True(t, ok)
 	require.EqualValues(t, want, v)
 }
+
+func TestStorageMetrics_OperationsAndErrorClasses(t *testing.T) {
+	ctx := testlogging.Context(t)
+	st := blobtesting.NewMapStorage(blobtesting.DataMap{}, nil, nil)
+
+	fs := blobtesting.NewFaultyStorage(st)
+	fs.AddFault(blobtesting.MethodPutBlob).ErrorInstead(blob.ErrAccessDenied)
+
+	mr := metrics.NewRegistry()
+	ms := storagemetrics.NewWrapper(fs, mr)
+
+	var tmp gather.WriteBuffer
+	defer tmp.Close()
+
+	require.ErrorIs(t, ms.PutBlob(ctx, "someBlob", gather.FromSlice([]byte{1}), blob.PutOptions{}), blob.ErrAccessDenied)
+	require.NoError(t, ms.PutBlob(ctx, "someBlob", gather.FromSlice([]byte{1}), blob.PutOptions{}))
+	require.ErrorIs(t, ms.GetBlob(ctx, "noSuchBlob", 0, -1, &tmp), blob.ErrBlobNotFound)
+	require.ErrorIs(t, ms.GetBlob(ctx, "someBlob", 5, 5, &tmp), blob.ErrInvalidRange)
+
+	canceledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+
+	require.ErrorIs(t, ms.ListBlobs(canceledCtx, "", func(bm blob.Metadata) error {
+		return canceledCtx.Err()
+	}), context.Canceled)
+
+	snap := mr.Snapshot(false)
+	requireCounterValue(t, snap, "blob_operations[method:PutBlob]", 2)
+	requireCounterValue(t, snap, "blob_operations[method:GetBlob]", 2)
+	requireCounterValue(t, snap, "blob_operations[method:ListBlobs]", 1)
+	requireCounterValue(t, snap, "blob_operations[method:DeleteBlob]", 0)
+	requireCounterValue(t, snap, "blob_errors_by_class[class:access_denied;method:PutBlob]", 1)
+	requireCounterValue(t, snap, "blob_errors_by_class[class:not_found;method:GetBlob]", 1)
+	requireCounterValue(t, snap, "blob_errors_by_class[class:invalid_range;method:GetBlob]", 1)
+	requireCounterValue(t, snap, "blob_errors_by_class[class:canceled;method:ListBlobs]", 1)
+	requireCounterValue(t, snap, "blob_errors_by_class[class:other;method:GetBlob]", 0)
+	requireCounterValue(t, snap, "blob_errors_by_class[class:not_found;method:GetMetadata]", 0)
+}
@@ -11,6 +11,7 @@ type Options struct {
 	Username                            string `json:"username,omitempty"`
 	Password                            string `json:"password,omitempty"                            kopia:"sensitive"`
 	TrustedServerCertificateFingerprint string `json:"trustedServerCertificateFingerprint,omitempty"`
+	RootCA                              []byte `json:"rootCA,omitempty"`
 	AtomicWrites                        bool   `json:"atomicWrites"`
 
 	sharded.Options
@@ -4,6 +4,8 @@
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"io"
 	"math/rand"
@@ -123,6 +125,12 @@ func (d *davStorageImpl) translateError(err error) error {
 
 		case http.StatusNotFound:
 			return blob.ErrBlobNotFound
+
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return errors.WithMessagef(blob.ErrAccessDenied, "%v", err)
+
+		case http.StatusTooManyRequests:
+			return errors.WithMessagef(blob.ErrThrottled, "%v", err)
 		}
 	}
 
@@ -258,8 +266,20 @@ func New(ctx context.Context, opts *Options, isCreate bool) (blob.Storage, error
 	// Since we're handling encrypted data, there's no point compressing it server-side.
 	cli.SetHeader("Accept-Encoding", "identity")
 
-	if opts.TrustedServerCertificateFingerprint != "" {
+	switch {
+	case opts.TrustedServerCertificateFingerprint != "":
 		cli.SetTransport(tlsutil.TransportTrustingSingleCertificate(opts.TrustedServerCertificateFingerprint))
+
+	case len(opts.RootCA) != 0:
+		rootcas := x509.NewCertPool()
+		if !rootcas.AppendCertsFromPEM(opts.RootCA) {
+			return nil, errors.New("cannot parse provided CA")
+		}
+
+		transport := http.DefaultTransport.(*http.Transport).Clone() //nolint:forcetypeassert
+		transport.TLSClientConfig = &tls.Config{RootCAs: rootcas}    //nolint:gosec
+
+		cli.SetTransport(transport)
 	}
 
 	s := retrying.NewWrapper(&davStorage{
@@ -3,17 +3,20 @@
 import (
 	"bytes"
 	"context"
+	"encoding/pem"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"golang.org/x/net/webdav"
 
 	"github.com/kopia/kopia/internal/blobtesting"
+	"github.com/kopia/kopia/internal/gather"
 	"github.com/kopia/kopia/internal/providervalidation"
 	"github.com/kopia/kopia/internal/testlogging"
 	"github.com/kopia/kopia/internal/testutil"
@@ -145,6 +148,44 @@ func transformMissingPUTs(next http.Handler) http.HandlerFunc {
 }
 
 //nolint:thelper
+func TestWebDAVStorageRootCA(t *testing.T) {
+	t.Parallel()
+
+	ctx := testlogging.Context(t)
+
+	server := httptest.NewTLSServer(basicAuth(&webdav.Handler{
+		FileSystem: webdav.Dir(testutil.TempDirectory(t)),
+		LockSystem: webdav.NewMemLS(),
+	}))
+	defer server.Close()
+
+	opt := &Options{
+		URL:      server.URL,
+		Username: "user",
+		Password: "password",
+	}
+
+	// server certificate is not trusted by default, use short timeout to avoid retrying for a long time.
+	st, err := New(ctx, opt, false)
+	require.NoError(t, err)
+
+	shortCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+
+	require.Error(t, st.PutBlob(shortCtx, "someblob", gather.FromSlice([]byte{1, 2, 3}), blob.PutOptions{}))
+
+	opt.RootCA = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	st, err = New(ctx, opt, false)
+	require.NoError(t, err)
+	require.NoError(t, st.PutBlob(ctx, "someblob", gather.FromSlice([]byte{1, 2, 3}), blob.PutOptions{}))
+
+	opt.RootCA = []byte("invalid")
+
+	_, err = New(ctx, opt, false)
+	require.ErrorContains(t, err, "cannot parse provided CA")
+}
+
 func verifyWebDAVStorage(t *testing.T, url, username, password string, shardSpec []int) {
 	ctx := testlogging.Context(t)
 
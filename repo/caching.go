@@ -24,16 +24,9 @@ func GetCachingOptions(ctx context.Context, configFile string) (*content.Caching
 
 // SetCachingOptions changes caching configuration for a given repository.
 func SetCachingOptions(ctx context.Context, configFile string, opt *content.CachingOptions) error {
-	lc, err := LoadConfigFromFile(configFile)
-	if err != nil {
-		return err
-	}
-
-	if err = setupCachingOptionsWithDefaults(ctx, configFile, lc, opt, nil); err != nil {
-		return errors.Wrap(err, "unable to set up caching")
-	}
-
-	return lc.writeToFile(configFile)
+	return updateConfigFile(ctx, configFile, func(lc *LocalConfig) error {
+		return errors.Wrap(setupCachingOptionsWithDefaults(ctx, configFile, lc, opt, nil), "unable to set up caching")
+	})
 }
 
 func setupCachingOptionsWithDefaults(ctx context.Context, configPath string, lc *LocalConfig, opt *content.CachingOptions, uniqueID []byte) error {
@@ -71,6 +64,7 @@ func setupCachingOptionsWithDefaults(ctx context.Context, configPath string, lc
 	lc.Caching.ContentCacheSizeLimitBytes = opt.ContentCacheSizeLimitBytes
 	lc.Caching.MetadataCacheSizeBytes = opt.MetadataCacheSizeBytes
 	lc.Caching.MetadataCacheSizeLimitBytes = opt.MetadataCacheSizeLimitBytes
+	lc.Caching.BlobCacheSizeBytes = opt.BlobCacheSizeBytes
 	lc.Caching.MaxListCacheDuration = opt.MaxListCacheDuration
 	lc.Caching.MinContentSweepAge = opt.MinContentSweepAge
 	lc.Caching.MinMetadataSweepAge = opt.MinMetadataSweepAge
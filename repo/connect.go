@@ -57,7 +57,7 @@ func Connect(ctx context.Context, configFile string, st blob.Storage, password s
 		return errors.Wrap(err, "unable to set up caching")
 	}
 
-	if err := lc.writeToFile(configFile); err != nil {
+	if err := lc.writeToFile(ctx, configFile); err != nil {
 		return errors.Wrap(err, "unable to write config file")
 	}
 
@@ -102,18 +102,18 @@ func Disconnect(ctx context.Context, configFile string) error {
 		log(ctx).Error("unable to remove maintenance lock file", maintenanceLock)
 	}
 
+	if err := os.RemoveAll(configLockFile(configFile)); err != nil {
+		log(ctx).Error("unable to remove config lock file", configLockFile(configFile))
+	}
+
 	//nolint:wrapcheck
 	return os.Remove(configFile)
 }
 
 // SetClientOptions updates client options stored in the provided configuration file.
 func SetClientOptions(ctx context.Context, configFile string, cliOpt ClientOptions) error {
-	lc, err := LoadConfigFromFile(configFile)
-	if err != nil {
-		return err
-	}
-
-	lc.ClientOptions = cliOpt
-
-	return lc.writeToFile(configFile)
+	return updateConfigFile(ctx, configFile, func(lc *LocalConfig) error {
+		lc.ClientOptions = cliOpt
+		return nil
+	})
 }
@@ -24,6 +24,7 @@ type CachingOptions struct {
 	ContentCacheSizeLimitBytes  int64           `json:"contentCacheSizeLimitBytes,omitempty"`
 	MetadataCacheSizeBytes      int64           `json:"maxMetadataCacheSize,omitempty"`
 	MetadataCacheSizeLimitBytes int64           `json:"metadataCacheSizeLimitBytes,omitempty"`
+	BlobCacheSizeBytes          int64           `json:"maxBlobCacheSize,omitempty"`
 	MaxListCacheDuration        DurationSeconds `json:"maxListCacheDuration,omitempty"`
 	MinMetadataSweepAge         DurationSeconds `json:"minMetadataSweepAge,omitempty"`
 	MinContentSweepAge          DurationSeconds `json:"minContentSweepAge,omitempty"`
@@ -2,6 +2,8 @@
 
 import (
 	"context"
+	cryptorand "crypto/rand"
+	"io"
 	"os"
 	"path/filepath"
 	"sync"
@@ -83,6 +85,7 @@ type SharedManager struct {
 	indexBlobCache    *cache.PersistentCache
 	committedContents *committedContentIndex
 	timeNow           func() time.Time
+	randReader        io.Reader
 
 	// lock to protect the set of committed indexes
 	// shared lock will be acquired when writing new content to allow it to happen in parallel
@@ -329,7 +332,7 @@ func (sm *SharedManager) decryptAndVerify(encrypted gather.Bytes, iv []byte, out
 
 	if err := sm.format.Encryptor().Decrypt(encrypted, iv, output); err != nil {
 		sm.Stats.foundInvalidContent()
-		return errors.Wrap(err, "decrypt")
+		return errors.WithMessagef(ErrChecksumMismatch, "decrypt: %v", err)
 	}
 
 	sm.decryptedBytes.Observe(int64(encrypted.Length()), t0.Elapsed())
@@ -612,10 +615,15 @@ func NewSharedManager(ctx context.Context, st blob.Storage, prov format.Provider
 		opts.TimeNow = clock.Now
 	}
 
+	if opts.RandReader == nil {
+		opts.RandReader = cryptorand.Reader
+	}
+
 	sm := &SharedManager{
 		st:                      st,
 		Stats:                   new(Stats),
 		timeNow:                 opts.TimeNow,
+		randReader:              opts.RandReader,
 		format:                  prov,
 		permissiveCacheLoading:  opts.PermissiveCacheLoading,
 		minPreambleLength:       defaultMinPreambleLength,
@@ -3,8 +3,9 @@
 
 import (
 	"context"
-	cryptorand "crypto/rand"
+	stderrors "errors"
 	"fmt"
+	"io"
 	"math/rand"
 	"sync"
 	"sync/atomic"
@@ -21,6 +22,7 @@
 	"github.com/kopia/kopia/repo/compression"
 	"github.com/kopia/kopia/repo/content/index"
 	"github.com/kopia/kopia/repo/content/indexblob"
+	"github.com/kopia/kopia/repo/ecc"
 	"github.com/kopia/kopia/repo/format"
 	"github.com/kopia/kopia/repo/hashing"
 	"github.com/kopia/kopia/repo/logging"
@@ -63,6 +65,9 @@
 // ErrContentNotFound is returned when content is not found.
 var ErrContentNotFound = errors.New("content not found")
 
+// ErrChecksumMismatch is returned when the content read from storage fails integrity verification.
+var ErrChecksumMismatch = errors.New("content checksum mismatch")
+
 // WriteManager builds content-addressable storage with encryption, deduplication and packaging on top of BLOB store.
 type WriteManager struct {
 	revision            atomic.Int64 // changes on each local write
@@ -96,6 +101,10 @@ type WriteManager struct {
 
 	onUpload func(int64)
 
+	// asyncWriteSlots limits the number of full packs written in the background, nil if packs are written synchronously.
+	asyncWriteSlots chan struct{}
+	asyncWrites     sync.WaitGroup
+
 	*SharedManager
 
 	log logging.Logger
@@ -232,9 +241,17 @@ func (bm *WriteManager) maybeRetryWritingFailedPacksUnlocked(ctx context.Context
 		bm.cond.Wait()
 	}
 
-	// see if we have any packs that have failed previously
-	// retry writing them now.
-	//
+	return bm.retryWritingFailedPacksLocked(ctx)
+}
+
+// retryWritingFailedPacksLocked retries writing all packs that have failed previously.
+// All packs are retried and their errors aggregated, so that a single failure does not
+// prevent remaining packs from being written.
+//
+// +checklocks:bm.mu
+func (bm *WriteManager) retryWritingFailedPacksLocked(ctx context.Context) error {
+	var retryErrors []error
+
 	// we're making a copy of bm.failedPacks since bm.writePackAndAddToIndex()
 	// will remove from it on success.
 	fp := append([]*pendingPackInfo(nil), bm.failedPacks...)
@@ -242,10 +259,14 @@ func (bm *WriteManager) maybeRetryWritingFailedPacksUnlocked(ctx context.Context
 		bm.log.Debugf("retry-write %v", pp.packBlobID)
 
 		if err := bm.writePackAndAddToIndexLocked(ctx, pp); err != nil {
-			return errors.Wrap(err, "error writing previously failed pack")
+			retryErrors = append(retryErrors, errors.Wrapf(err, "pack %v", pp.packBlobID))
 		}
 	}
 
+	if err := stderrors.Join(retryErrors...); err != nil {
+		return errors.Wrap(err, "error writing previously failed packs")
+	}
+
 	return nil
 }
 
@@ -338,6 +359,11 @@ func (bm *WriteManager) addToPackUnlocked(ctx context.Context, contentID ID, dat
 	// at this point we're unlocked so different goroutines can encrypt and
 	// save to storage in parallel.
 	if shouldWrite {
+		if bm.asyncWriteSlots != nil {
+			bm.writePackAsync(ctx, pp)
+			return nil
+		}
+
 		if err := bm.writePackAndAddToIndexUnlocked(ctx, pp); err != nil {
 			return errors.Wrap(err, "unable to write pack")
 		}
@@ -518,6 +544,30 @@ func (bm *WriteManager) writePackAndAddToIndexUnlocked(ctx context.Context, pp *
 	return bm.processWritePackResultLocked(pp, packFileIndex, writeErr)
 }
 
+// writePackAsync writes the provided pack in the background, blocking while the maximum number of packs
+// is already being written. Failed packs are retried by subsequent writes or Flush().
+func (bm *WriteManager) writePackAsync(ctx context.Context, pp *pendingPackInfo) {
+	bm.asyncWriteSlots <- struct{}{}
+	bm.asyncWrites.Add(1)
+
+	go func() {
+		defer func() {
+			<-bm.asyncWriteSlots
+			bm.asyncWrites.Done()
+		}()
+
+		if err := bm.writePackAndAddToIndexUnlocked(context.WithoutCancel(ctx), pp); err != nil {
+			bm.log.Errorf("background write of %v failed, will retry: %v", pp.packBlobID, err)
+		}
+	}()
+}
+
+// WaitForBackgroundWrites waits for all packs being written in the background to finish.
+// Packs that failed to write are not retried, use Flush() to commit them.
+func (bm *WriteManager) WaitForBackgroundWrites() {
+	bm.asyncWrites.Wait()
+}
+
 // +checklocks:bm.mu
 func (bm *WriteManager) writePackAndAddToIndexLocked(ctx context.Context, pp *pendingPackInfo) error {
 	packFileIndex, writeErr := bm.prepareAndWritePackInternal(ctx, pp, bm.onUpload)
@@ -615,20 +665,6 @@ func (bm *WriteManager) Flush(ctx context.Context) error {
 	bm.setFlushingLocked(true)
 	defer bm.setFlushingLocked(false)
 
-	// see if we have any packs that have failed previously
-	// retry writing them now.
-	//
-	// we're making a copy of bm.failedPacks since bm.writePackAndAddToIndex()
-	// will remove from it on success.
-	fp := append([]*pendingPackInfo(nil), bm.failedPacks...)
-	for _, pp := range fp {
-		bm.log.Debugf("retry-write %v", pp.packBlobID)
-
-		if err := bm.writePackAndAddToIndexLocked(ctx, pp); err != nil {
-			return errors.Wrap(err, "error writing previously failed pack")
-		}
-	}
-
 	for len(bm.writingPacks) > 0 {
 		bm.log.Debugf("waiting for %v in-progress packs to finish", len(bm.writingPacks))
 
@@ -636,6 +672,12 @@ func (bm *WriteManager) Flush(ctx context.Context) error {
 		bm.cond.Wait()
 	}
 
+	// see if we have any packs that have failed previously, including those written in the background,
+	// retry writing them now.
+	if err := bm.retryWritingFailedPacksLocked(ctx); err != nil {
+		return err
+	}
+
 	// finish all new pending packs
 	if err := bm.finishAllPacksLocked(ctx); err != nil {
 		return errors.Wrap(err, "error writing pending content")
@@ -679,6 +721,43 @@ func (bm *WriteManager) getContentDataAndInfo(ctx context.Context, contentID ID,
 	return bi, nil
 }
 
+// DamagedShards reads the stored data of the provided content once, verifies that it decrypts correctly
+// and returns the number of damaged error correction shards in it, which are transparently corrected when
+// reading and can be repaired by rewriting the content.
+// Returns zero damaged shards if the repository does not use error correction.
+func (bm *WriteManager) DamagedShards(ctx context.Context, contentID ID) (int, error) {
+	dd, hasECC := bm.format.Encryptor().(ecc.DamageDetector)
+
+	bm.mu.RLock()
+	defer bm.mu.RUnlock()
+
+	pp, bi, err := bm.getContentInfoReadLocked(ctx, contentID)
+	if err != nil {
+		return 0, err
+	}
+
+	var payload gather.WriteBuffer
+	defer payload.Close()
+
+	// with error correction the data must come from the pack blob, since the cached copy may have been repaired.
+	if err := bm.getStoredContentDataReadLocked(ctx, pp, bi, hasECC, &payload); err != nil {
+		return 0, err
+	}
+
+	var output gather.WriteBuffer
+	defer output.Close()
+
+	if err := bm.decryptContentAndVerify(payload.Bytes(), bi, &output); err != nil {
+		return 0, err
+	}
+
+	if !hasECC {
+		return 0, nil
+	}
+
+	return dd.DamagedShards(payload.Bytes()), nil
+}
+
 // UndeleteContent rewrites the content with the given ID if the content exists
 // and is mark deleted. If the content exists and is not marked deleted, this
 // operation is a no-op.
@@ -745,7 +824,7 @@ func (bm *WriteManager) getOrCreatePendingPackInfoLocked(ctx context.Context, pr
 	}
 
 	blobID := make([]byte, packBlobIDLength)
-	if _, err := cryptorand.Read(blobID); err != nil {
+	if _, err := io.ReadFull(bm.randReader, blobID); err != nil {
 		return nil, errors.Wrap(err, "unable to read crypto bytes")
 	}
 
@@ -757,7 +836,7 @@ func (bm *WriteManager) getOrCreatePendingPackInfoLocked(ctx context.Context, pr
 	b.Append(suffix)
 
 	//nolint:gosec
-	if err := writeRandomBytesToBuffer(b, rand.Intn(bm.maxPreambleLength-bm.minPreambleLength+1)+bm.minPreambleLength); err != nil {
+	if err := writeRandomBytesToBuffer(b, bm.randReader, rand.Intn(bm.maxPreambleLength-bm.minPreambleLength+1)+bm.minPreambleLength); err != nil {
 		return nil, errors.Wrap(err, "unable to prepare content preamble")
 	}
 
@@ -949,6 +1028,7 @@ func (bm *WriteManager) MetadataCache() cache.ContentCache {
 // ManagerOptions are the optional parameters for manager creation.
 type ManagerOptions struct {
 	TimeNow                func() time.Time // Time provider
+	RandReader             io.Reader        // Source of randomness for identifiers and padding, defaults to crypto/rand.Reader
 	DisableInternalLog     bool
 	PermissiveCacheLoading bool
 }
@@ -981,9 +1061,10 @@ func NewManagerForTesting(ctx context.Context, st blob.Storage, f format.Provide
 
 // SessionOptions specifies session options.
 type SessionOptions struct {
-	SessionUser string
-	SessionHost string
-	OnUpload    func(int64)
+	SessionUser     string
+	SessionHost     string
+	OnUpload        func(int64)
+	AsyncPackWrites int // maximum number of full packs written in the background, 0 == write synchronously
 }
 
 // NewWriteManager returns a session write manager.
@@ -1010,5 +1091,9 @@ func NewWriteManager(ctx context.Context, sm *SharedManager, options SessionOpti
 
 	wm.cond = sync.NewCond(&wm.mu)
 
+	if options.AsyncPackWrites > 0 {
+		wm.asyncWriteSlots = make(chan struct{}, options.AsyncPackWrites)
+	}
+
 	return wm
 }
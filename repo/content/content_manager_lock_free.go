@@ -3,7 +3,6 @@
 import (
 	"context"
 	"crypto/aes"
-	cryptorand "crypto/rand"
 	"fmt"
 	"io"
 	"strings"
@@ -86,10 +85,10 @@ func (sm *SharedManager) maybeCompressAndEncryptDataForPacking(data gather.Bytes
 	return comp, nil
 }
 
-func writeRandomBytesToBuffer(b *gather.WriteBuffer, count int) error {
+func writeRandomBytesToBuffer(b *gather.WriteBuffer, randReader io.Reader, count int) error {
 	var rnd [defaultPaddingUnit]byte
 
-	if _, err := io.ReadFull(cryptorand.Reader, rnd[0:count]); err != nil {
+	if _, err := io.ReadFull(randReader, rnd[0:count]); err != nil {
 		return errors.Wrap(err, "error getting random bytes")
 	}
 
@@ -108,17 +107,40 @@ func (sm *SharedManager) getContentDataReadLocked(ctx context.Context, pp *pendi
 	var payload gather.WriteBuffer
 	defer payload.Close()
 
+	if err := sm.getStoredContentDataReadLocked(ctx, pp, bi, false, &payload); err != nil {
+		return err
+	}
+
+	return sm.decryptContentAndVerify(payload.Bytes(), bi, output)
+}
+
+// getStoredContentDataReadLocked gets the encrypted content data as stored in the pack.
+// When bypassCache is true the data is always read from the pack blob, since the content cache
+// is keyed by content ID and may hold data from a different pack.
+func (sm *SharedManager) getStoredContentDataReadLocked(ctx context.Context, pp *pendingPackInfo, bi Info, bypassCache bool, payload *gather.WriteBuffer) error {
 	if pp != nil && pp.packBlobID == bi.PackBlobID {
 		// we need to use a lock here in case somebody else writes to the pack at the same time.
-		if err := pp.currentPackData.AppendSectionTo(&payload, int(bi.PackOffset), int(bi.PackedLength)); err != nil {
+		if err := pp.currentPackData.AppendSectionTo(payload, int(bi.PackOffset), int(bi.PackedLength)); err != nil {
 			// should never happen
 			return errors.Wrap(err, "error appending pending content data to buffer")
 		}
-	} else if err := sm.getCacheForContentID(bi.ContentID).GetContent(ctx, contentCacheKeyForInfo(bi), bi.PackBlobID, int64(bi.PackOffset), int64(bi.PackedLength), &payload); err != nil {
+
+		return nil
+	}
+
+	if bypassCache {
+		if err := sm.st.GetBlob(ctx, bi.PackBlobID, int64(bi.PackOffset), int64(bi.PackedLength), payload); err != nil {
+			return errors.Wrapf(err, "error getting content from blob %q", bi.PackBlobID)
+		}
+
+		return nil
+	}
+
+	if err := sm.getCacheForContentID(bi.ContentID).GetContent(ctx, contentCacheKeyForInfo(bi), bi.PackBlobID, int64(bi.PackOffset), int64(bi.PackedLength), payload); err != nil {
 		return errors.Wrapf(err, "error getting cached content from blob %q", bi.PackBlobID)
 	}
 
-	return sm.decryptContentAndVerify(payload.Bytes(), bi, output)
+	return nil
 }
 
 func (sm *SharedManager) preparePackDataContent(mp format.MutableParameters, pp *pendingPackInfo) (index.Builder, error) {
@@ -167,7 +189,7 @@ func (sm *SharedManager) preparePackDataContent(mp format.MutableParameters, pp
 
 	if sm.paddingUnit > 0 {
 		if missing := sm.paddingUnit - (pp.currentPackData.Length() % sm.paddingUnit); missing > 0 {
-			if err := writeRandomBytesToBuffer(pp.currentPackData, missing); err != nil {
+			if err := writeRandomBytesToBuffer(pp.currentPackData, sm.randReader, missing); err != nil {
 				return nil, errors.Wrap(err, "unable to prepare content postamble")
 			}
 		}
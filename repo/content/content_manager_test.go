@@ -7,6 +7,7 @@
 	cryptorand "crypto/rand"
 	"crypto/sha256"
 	"fmt"
+	"maps"
 	"math/rand"
 	"reflect"
 	"strings"
@@ -218,6 +219,35 @@ func (s *contentManagerSuite) TestContentManagerDedupesPendingAndUncommittedCont
 	}
 }
 
+func (s *contentManagerSuite) TestContentManagerDedupesCommittedContents(t *testing.T) {
+	ctx := testlogging.Context(t)
+	data := blobtesting.DataMap{}
+	st := blobtesting.NewMapStorage(data, nil, nil)
+	bm := s.newTestContentManager(t, st)
+
+	var contentIDs []ID
+
+	for i := range 10 {
+		contentIDs = append(contentIDs, writeContentAndVerify(ctx, t, bm, seededRandomData(i, maxPackCapacity/20)))
+	}
+
+	require.NoError(t, bm.Flush(ctx))
+	require.NoError(t, bm.CloseShared(ctx))
+
+	before := maps.Clone(data)
+
+	// writing the same contents using a fresh manager must not upload anything.
+	bm = s.newTestContentManager(t, st)
+	defer bm.CloseShared(ctx)
+
+	for i := range 10 {
+		require.Equal(t, contentIDs[i], writeContentAndVerify(ctx, t, bm, seededRandomData(i, maxPackCapacity/20)))
+	}
+
+	require.NoError(t, bm.Flush(ctx))
+	require.Equal(t, before, data)
+}
+
 func (s *contentManagerSuite) TestContentManagerEmpty(t *testing.T) {
 	ctx := testlogging.Context(t)
 	data := blobtesting.DataMap{}
@@ -345,6 +375,30 @@ func (s *contentManagerSuite) TestContentManagerWriteMultiple(t *testing.T) {
 	}
 }
 
+func (s *contentManagerSuite) TestContentChecksumMismatch(t *testing.T) {
+	ctx := testlogging.Context(t)
+	data := blobtesting.DataMap{}
+	st := blobtesting.NewMapStorage(data, nil, nil)
+
+	bm := s.newTestContentManager(t, st)
+	defer bm.CloseShared(ctx)
+
+	cid := writeContentAndVerify(ctx, t, bm, seededRandomData(1, 100))
+	require.NoError(t, bm.Flush(ctx))
+
+	// corrupt the first byte of the content in its pack blob.
+	ci, err := bm.ContentInfo(ctx, cid)
+	require.NoError(t, err)
+
+	data[ci.PackBlobID][ci.PackOffset] ^= 1
+
+	bm2 := s.newTestContentManager(t, st)
+	defer bm2.CloseShared(ctx)
+
+	_, err = bm2.GetContent(ctx, cid)
+	require.ErrorIs(t, err, ErrChecksumMismatch)
+}
+
 // This is regression test for a bug where we would corrupt data when encryption
 // was done in place and clobbered pending data in memory.
 func (s *contentManagerSuite) TestContentManagerFailedToWritePack(t *testing.T) {
@@ -410,6 +464,55 @@ func (s *contentManagerSuite) TestContentManagerFailedToWritePack(t *testing.T)
 	faulty.VerifyAllFaultsExercised(t)
 }
 
+func (s *contentManagerSuite) TestRetryWritingFailedPacksAggregatesErrors(t *testing.T) {
+	ctx := testlogging.Context(t)
+	data := blobtesting.DataMap{}
+	faulty := blobtesting.NewFaultyStorage(blobtesting.NewMapStorage(data, nil, nil))
+
+	bm := s.newTestContentManager(t, faulty)
+	defer bm.CloseShared(ctx)
+
+	c1, err := bm.WriteContent(ctx, gather.FromSlice(seededRandomData(1, 10)), "", NoCompression)
+	require.NoError(t, err)
+
+	c2, err := bm.WriteContent(ctx, gather.FromSlice(seededRandomData(2, 10)), "k", NoCompression)
+	require.NoError(t, err)
+
+	firstPutErr := errors.New("booboo1")
+	secondPutErr := errors.New("booboo2")
+
+	faulty.AddFault(blobtesting.MethodPutBlob).ErrorInstead(firstPutErr)
+	faulty.AddFault(blobtesting.MethodPutBlob).ErrorInstead(secondPutErr)
+
+	bm.lock()
+
+	// simulate two packs that have previously failed to write.
+	for prefix, pp := range bm.pendingPacks {
+		delete(bm.pendingPacks, prefix)
+		bm.failedPacks = append(bm.failedPacks, pp)
+	}
+
+	require.Len(t, bm.failedPacks, 2)
+
+	// both packs are retried and both errors are reported.
+	err = bm.retryWritingFailedPacksLocked(ctx)
+	require.ErrorIs(t, err, firstPutErr)
+	require.ErrorIs(t, err, secondPutErr)
+	require.Len(t, bm.failedPacks, 2)
+
+	require.NoError(t, bm.retryWritingFailedPacksLocked(ctx))
+	require.Empty(t, bm.failedPacks)
+
+	bm.unlock(ctx)
+
+	require.NoError(t, bm.Flush(ctx))
+
+	verifyContent(ctx, t, bm, c1, seededRandomData(1, 10))
+	verifyContent(ctx, t, bm, c2, seededRandomData(2, 10))
+
+	faulty.VerifyAllFaultsExercised(t)
+}
+
 func (s *contentManagerSuite) TestIndexCompactionDropsContent(t *testing.T) {
 	if s.mutableParameters.EpochParameters.Enabled {
 		t.Skip("dropping index entries not implemented")
@@ -1325,6 +1428,74 @@ func (s *contentManagerSuite) TestHandleWriteErrors(t *testing.T) {
 	}
 }
 
+func (s *contentManagerSuite) TestAsyncPackWrites(t *testing.T) {
+	ctx := testlogging.Context(t)
+	data := blobtesting.DataMap{}
+	st := blobtesting.NewMapStorage(data, nil, nil)
+
+	// one of the background writes fails and must be retried.
+	fs := blobtesting.NewFaultyStorage(st)
+	fs.AddFaults(blobtesting.MethodPutBlob,
+		fault.New().Repeat(1),
+		fault.New().ErrorInstead(errors.New("some write error")))
+
+	base := s.newTestContentManagerWithTweaks(t, fs, nil)
+
+	bm := NewWriteManager(ctx, base.SharedManager, SessionOptions{AsyncPackWrites: 2}, "async")
+	bm.checkInvariantsOnUnlock = true
+
+	var cids []ID
+
+	for i := range 5 {
+		cids = append(cids, writeContentAndVerify(ctx, t, bm, seededRandomData(i, maxPackSize)))
+	}
+
+	require.NoError(t, bm.Flush(ctx))
+	fs.VerifyAllFaultsExercised(t)
+
+	bm2 := s.newTestContentManagerWithTweaks(t, st, nil)
+
+	for i, cid := range cids {
+		verifyContent(ctx, t, bm2, cid, seededRandomData(i, maxPackSize))
+	}
+}
+
+func (s *contentManagerSuite) TestAsyncPackWritesAggregatesErrors(t *testing.T) {
+	ctx := testlogging.Context(t)
+	data := blobtesting.DataMap{}
+	st := blobtesting.NewMapStorage(data, nil, nil)
+
+	// after the session marker is written, both background writes and their first retries fail.
+	fs := blobtesting.NewFaultyStorage(st)
+	fs.AddFaults(blobtesting.MethodPutBlob,
+		fault.New(),
+		fault.New().ErrorInstead(errors.New("some write error")).Repeat(3))
+
+	base := s.newTestContentManagerWithTweaks(t, fs, nil)
+
+	bm := NewWriteManager(ctx, base.SharedManager, SessionOptions{AsyncPackWrites: 2}, "async")
+	bm.checkInvariantsOnUnlock = true
+
+	var cids []ID
+
+	for i := range 2 {
+		cids = append(cids, writeContentAndVerify(ctx, t, bm, seededRandomData(i, maxPackSize)))
+	}
+
+	err := bm.Flush(ctx)
+	require.ErrorContains(t, err, "error writing previously failed packs")
+	require.Equal(t, 2, strings.Count(err.Error(), "some write error"), "%v", err)
+
+	require.NoError(t, bm.Flush(ctx))
+	fs.VerifyAllFaultsExercised(t)
+
+	bm2 := s.newTestContentManagerWithTweaks(t, st, nil)
+
+	for i, cid := range cids {
+		verifyContent(ctx, t, bm2, cid, seededRandomData(i, maxPackSize))
+	}
+}
+
 func (s *contentManagerSuite) TestRewriteNonDeleted(t *testing.T) {
 	const stepBehaviors = 3
 
@@ -2,9 +2,9 @@
 
 import (
 	"context"
-	cryptorand "crypto/rand"
 	"encoding/json"
 	"fmt"
+	"io"
 	"strings"
 	"time"
 
@@ -40,7 +40,7 @@ type SessionInfo struct {
 )
 
 // generateSessionID generates a random session identifier.
-func generateSessionID(now time.Time) (SessionID, error) {
+func generateSessionID(now time.Time, randReader io.Reader) (SessionID, error) {
 	// generate session ID as {random-64-bit}{epoch-number}
 	// where epoch number is roughly the number of months since 2000-01-01
 	// so our 64-bit number only needs to be unique per month.
@@ -48,7 +48,7 @@ func generateSessionID(now time.Time) (SessionID, error) {
 	// second before significant probability of collision while keeping the
 	// session identifiers relatively short.
 	r := make([]byte, sessionIDLength)
-	if _, err := cryptorand.Read(r); err != nil {
+	if _, err := io.ReadFull(randReader, r); err != nil {
 		return "", errors.Wrap(err, "unable to read crypto bytes")
 	}
 
@@ -62,7 +62,7 @@ func (bm *WriteManager) getOrStartSessionLocked(ctx context.Context) (SessionID,
 		return bm.currentSessionInfo.ID, nil
 	}
 
-	id, err := generateSessionID(bm.timeNow())
+	id, err := generateSessionID(bm.timeNow(), bm.randReader)
 	if err != nil {
 		return "", errors.Wrap(err, "unable to generate session ID")
 	}
@@ -1,6 +1,7 @@
 package content
 
 import (
+	cryptorand "crypto/rand"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -12,13 +13,13 @@
 func TestGenerateSessionID(t *testing.T) {
 	n := clock.Now()
 
-	s1, err := generateSessionID(n)
+	s1, err := generateSessionID(n, cryptorand.Reader)
 	require.NoError(t, err)
 
-	s2, err := generateSessionID(n)
+	s2, err := generateSessionID(n, cryptorand.Reader)
 	require.NoError(t, err)
 
-	s3, err := generateSessionID(n)
+	s3, err := generateSessionID(n, cryptorand.Reader)
 	require.NoError(t, err)
 
 	m := map[SessionID]bool{
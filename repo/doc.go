@@ -1,2 +1,12 @@
 // Package repo implements content-addressable Repository on top of BLOB storage.
+//
+// Applications embedding Kopia typically use the following entry points:
+//
+//   - Initialize creates a new repository in the provided blob.Storage,
+//   - Connect writes configuration file that allows the repository to be opened later,
+//   - Open opens the repository using the configuration file and returns Repository,
+//   - WriteSession runs the provided callback with RepositoryWriter and flushes the writes on success.
+//
+// Snapshots are created with snapshotfs.Uploader using the policies from the snapshot/policy package
+// and saved with snapshot.SaveSnapshot. See the package example for a complete walk-through.
 package repo
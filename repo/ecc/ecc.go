@@ -6,6 +6,7 @@
 
 	"github.com/pkg/errors"
 
+	"github.com/kopia/kopia/internal/gather"
 	"github.com/kopia/kopia/repo/encryption"
 )
 
@@ -45,6 +46,14 @@ func CreateAlgorithm(opts *Options) (encryption.Encryptor, error) {
 	return factory(opts)
 }
 
+// DamageDetector is implemented by error correction algorithms which can detect damaged stored data.
+// Damaged data is transparently corrected when decrypting, as long as the damage is not too extensive,
+// but it should be rewritten to restore the original level of redundancy.
+type DamageDetector interface {
+	// DamagedShards returns the number of damaged shards in the stored data.
+	DamagedShards(stored gather.Bytes) int
+}
+
 // Parameters encapsulates all ECC parameters.
 type Parameters interface {
 	GetECCAlgorithm() string
@@ -248,6 +248,21 @@ func (r *ReedSolomonCrcECC) Encrypt(input gather.Bytes, _ []byte, output *gather
 // Decrypt corrects the data from input based on the ECC data.
 // See Encrypt comments for a description of the layout.
 func (r *ReedSolomonCrcECC) Decrypt(input gather.Bytes, _ []byte, output *gather.WriteBuffer) error {
+	_, err := r.decode(input, output)
+
+	return err
+}
+
+// DamagedShards implements DamageDetector.
+func (r *ReedSolomonCrcECC) DamagedShards(input gather.Bytes) int {
+	n, _ := r.decode(input, nil)
+
+	return n
+}
+
+// decode verifies checksums of all shards in the input and reconstructs the original data into output.
+// When output is nil, it only counts damaged shards. Returns the number of damaged shards.
+func (r *ReedSolomonCrcECC) decode(input gather.Bytes, output *gather.WriteBuffer) (int, error) {
 	sizes := r.computeSizesFromStored(input.Length())
 	dataPlusCrcSizeInBlock := sizes.DataShards * (crcSize + sizes.ShardSize)
 	parityPlusCrcSizeInBlock := sizes.ParityShards * (crcSize + sizes.ShardSize)
@@ -277,6 +292,7 @@ func (r *ReedSolomonCrcECC) Decrypt(input gather.Bytes, _ []byte, output *gather
 
 	writeOriginalPos := 0
 	paddingStartPos := len(copied) - parityPlusCrcSizeInBlock*sizes.Blocks
+	damaged := 0
 
 	for b := range sizes.Blocks {
 		for i := range sizes.DataShards {
@@ -293,6 +309,7 @@ func (r *ReedSolomonCrcECC) Decrypt(input gather.Bytes, _ []byte, output *gather
 				if crc != crc32.ChecksumIEEE(shards[i]) {
 					// The data was corrupted, so we need to reconstruct it
 					shards[i] = nil
+					damaged++
 				}
 			}
 		}
@@ -309,16 +326,21 @@ func (r *ReedSolomonCrcECC) Decrypt(input gather.Bytes, _ []byte, output *gather
 			if crc != crc32.ChecksumIEEE(shards[s]) {
 				// The data was corrupted, so we need to reconstruct it
 				shards[s] = nil
+				damaged++
 			}
 		}
 
+		if output == nil {
+			continue
+		}
+
 		if r.Options.DeleteFirstShardForTests {
 			shards[0] = nil
 		}
 
 		err := sizes.enc.ReconstructData(shards)
 		if err != nil {
-			return errors.Wrap(err, "Error computing ECC")
+			return damaged, errors.Wrap(err, "Error computing ECC")
 		}
 
 		startShard := 0
@@ -340,7 +362,7 @@ func (r *ReedSolomonCrcECC) Decrypt(input gather.Bytes, _ []byte, output *gather
 		}
 	}
 
-	return nil
+	return damaged, nil
 }
 
 func readLength(shards [][]byte, sizes *sizesInfo) (originalSize, startShard, startByte int) {
@@ -5,6 +5,7 @@
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/kopia/kopia/internal/gather"
 	"github.com/kopia/kopia/repo/encryption"
 )
 
@@ -175,3 +176,39 @@ func computeFinalFileSizeWithoutPadding(inputSize, parityShards, shardSize, bloc
 	sizePlusLength := lengthSize + inputSize
 	return parityShards*(crcSize+shardSize)*blocks + sizePlusLength + ceilInt(sizePlusLength, shardSize)*crcSize
 }
+
+func Test_RsCrc32_DamagedShards(t *testing.T) {
+	t.Parallel()
+
+	impl, err := CreateAlgorithm(&Options{
+		Algorithm:       AlgorithmReedSolomonWithCrc32,
+		OverheadPercent: 2,
+		MaxShardSize:    1024,
+	})
+	require.NoError(t, err)
+
+	dd, ok := impl.(DamageDetector)
+	require.True(t, ok)
+
+	for _, originalSize := range []int{1, 10 << 10, 1 << 20} {
+		original := make([]byte, originalSize)
+		for i := range original {
+			original[i] = byte(i % 251)
+		}
+
+		var output gather.WriteBuffer
+		defer output.Close()
+
+		require.NoError(t, impl.Encrypt(gather.FromSlice(original), nil, &output))
+
+		stored := output.ToByteSlice()
+		require.Zero(t, dd.DamagedShards(gather.FromSlice(stored)), "size %v", originalSize)
+
+		sizes := impl.(*ReedSolomonCrcECC).computeSizesFromOriginal(originalSize)
+		parity := sizes.ParityShards * (crcSize + sizes.ShardSize) * sizes.Blocks
+
+		flipByte(stored, 0)
+		flipByte(stored, parity+crcSize)
+		require.Equal(t, 2, dd.DamagedShards(gather.FromSlice(stored)), "size %v", originalSize)
+	}
+}
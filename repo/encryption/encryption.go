@@ -19,6 +19,10 @@
 )
 
 // Encryptor performs encryption and decryption of contents of data.
+//
+// Encryption operates on whole contents rather than streams: authenticated ciphers must verify
+// the entire ciphertext before any plaintext can be released, and contents are bounded by the
+// maximum content size, so large objects are processed one content at a time with bounded memory.
 type Encryptor interface {
 	// Encrypt appends the encrypted bytes corresponding to the given plaintext to a given slice.
 	// Must not clobber the input slice and return ciphertext with additional padding and checksum.
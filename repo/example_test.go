@@ -0,0 +1,102 @@
+package repo_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/kopia/kopia/fs/localfs"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/blob/filesystem"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/policy"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+)
+
+// This example shows how an application can create a repository, snapshot a directory and list the snapshots.
+func Example() {
+	ctx := context.Background()
+
+	tmpDir, err := os.MkdirTemp("", "kopia-example")
+	if err != nil {
+		panic(err)
+	}
+
+	defer os.RemoveAll(tmpDir) //nolint:errcheck
+
+	const password = "my-secret-password"
+
+	// initialize repository in a local directory and connect to it.
+	st, err := filesystem.New(ctx, &filesystem.Options{Path: filepath.Join(tmpDir, "repo")}, true)
+	if err != nil {
+		panic(err)
+	}
+
+	if err = repo.Initialize(ctx, st, &repo.NewRepositoryOptions{}, password); err != nil {
+		panic(err)
+	}
+
+	configFile := filepath.Join(tmpDir, "repository.config")
+
+	if err = repo.Connect(ctx, configFile, st, password, &repo.ConnectOptions{}); err != nil {
+		panic(err)
+	}
+
+	rep, err := repo.Open(ctx, configFile, password, &repo.Options{})
+	if err != nil {
+		panic(err)
+	}
+
+	defer rep.Close(ctx) //nolint:errcheck
+
+	// create some data to snapshot.
+	dataDir := filepath.Join(tmpDir, "data")
+
+	if err = os.MkdirAll(dataDir, 0o700); err != nil {
+		panic(err)
+	}
+
+	if err = os.WriteFile(filepath.Join(dataDir, "file.txt"), []byte("hello, world"), 0o600); err != nil {
+		panic(err)
+	}
+
+	src := snapshot.SourceInfo{Host: "example-host", UserName: "example-user", Path: dataDir}
+
+	// snapshot the directory in a write session.
+	err = repo.WriteSession(ctx, rep, repo.WriteSessionOptions{Purpose: "example"}, func(ctx context.Context, w repo.RepositoryWriter) error {
+		entry, err := localfs.NewEntry(dataDir)
+		if err != nil {
+			return err
+		}
+
+		policyTree, err := policy.TreeForSource(ctx, w, src)
+		if err != nil {
+			return err
+		}
+
+		man, err := snapshotfs.NewUploader(w).Upload(ctx, entry, policyTree, src)
+		if err != nil {
+			return err
+		}
+
+		_, err = snapshot.SaveSnapshot(ctx, w, man)
+
+		return err
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	snapshots, err := snapshot.ListSnapshots(ctx, rep, src)
+	if err != nil {
+		panic(err)
+	}
+
+	for _, s := range snapshots {
+		fmt.Println(s.Source.UserName, s.Stats.TotalFileCount, s.Stats.TotalFileSize)
+	}
+
+	// Output:
+	// example-user 1 12
+}
@@ -7,6 +7,7 @@
 
 	"github.com/pkg/errors"
 
+	"github.com/kopia/kopia/internal/feature"
 	"github.com/kopia/kopia/internal/gather"
 	"github.com/kopia/kopia/repo/blob"
 )
@@ -20,6 +21,38 @@
 type BlobStorageConfiguration struct {
 	RetentionMode   blob.RetentionMode `json:"retentionMode,omitempty"`
 	RetentionPeriod time.Duration      `json:"retentionPeriod,omitempty"`
+
+	// MaxBlobSize is the maximum size of a single blob in the storage, larger blobs are split
+	// into multiple chunks (0 == unlimited).
+	MaxBlobSize int64 `json:"maxBlobSize,omitempty"`
+}
+
+// MinMaxBlobSize is the smallest allowed value of BlobStorageConfiguration.MaxBlobSize.
+const MinMaxBlobSize = 1 << 20
+
+// SplitBlobsFeature is the feature required to open repositories that have MaxBlobSize set,
+// older clients would not be able to read blobs that have been split into chunks.
+const SplitBlobsFeature feature.Feature = "split-blobs"
+
+// WithRequiredFeatures returns the provided list of required features with features
+// needed by this configuration added.
+func (r *BlobStorageConfiguration) WithRequiredFeatures(required []feature.Required) []feature.Required {
+	if r.MaxBlobSize <= 0 {
+		return required
+	}
+
+	for _, f := range required {
+		if f.Feature == SplitBlobsFeature {
+			return required
+		}
+	}
+
+	return append(required, feature.Required{
+		Feature: SplitBlobsFeature,
+		IfNotUnderstood: feature.IfNotUnderstood{
+			Message: "The repository splits large blobs into multiple chunks.",
+		},
+	})
 }
 
 // IsRetentionEnabled returns true if retention is enabled on the blob-config
@@ -38,6 +71,10 @@ func (r *BlobStorageConfiguration) Validate() error {
 		return errors.Errorf("invalid retention-period, the minimum required is 1-day and there is no maximum limit")
 	}
 
+	if r.MaxBlobSize < 0 || (r.MaxBlobSize > 0 && r.MaxBlobSize < MinMaxBlobSize) {
+		return errors.Errorf("invalid max-blob-size, the minimum required is %v bytes", MinMaxBlobSize)
+	}
+
 	return nil
 }
 
@@ -51,11 +88,8 @@ func serializeBlobCfgBytes(f *KopiaRepositoryJSON, r BlobStorageConfiguration, f
 	case "NONE":
 		return data, nil
 
-	case aes256GcmEncryption:
-		return encryptRepositoryBlobBytesAes256Gcm(data, formatEncryptionKey, f.UniqueID)
-
 	default:
-		return nil, errors.Errorf("unknown encryption algorithm: '%v'", f.EncryptionAlgorithm)
+		return encryptRepositoryBlobBytes(f.EncryptionAlgorithm, data, formatEncryptionKey, f.UniqueID)
 	}
 }
 
@@ -75,14 +109,11 @@ func deserializeBlobCfgBytes(j *KopiaRepositoryJSON, encryptedBlobCfgBytes, form
 	case "NONE": // do nothing
 		plainText = encryptedBlobCfgBytes
 
-	case aes256GcmEncryption:
-		plainText, err = decryptRepositoryBlobBytesAes256Gcm(encryptedBlobCfgBytes, formatEncryptionKey, j.UniqueID)
+	default:
+		plainText, err = decryptRepositoryBlobBytes(j.EncryptionAlgorithm, encryptedBlobCfgBytes, formatEncryptionKey, j.UniqueID)
 		if err != nil {
-			return BlobStorageConfiguration{}, errors.Errorf("unable to decrypt repository blobcfg blob")
+			return BlobStorageConfiguration{}, errors.Wrap(err, "unable to decrypt repository blobcfg blob")
 		}
-
-	default:
-		return BlobStorageConfiguration{}, errors.Errorf("unknown encryption algorithm: '%v'", j.EncryptionAlgorithm)
 	}
 
 	if err = json.Unmarshal(plainText, &r); err != nil {
@@ -19,6 +19,7 @@
 
 const (
 	aes256GcmEncryption             = "AES256_GCM"
+	chacha20Poly1305Encryption      = "CHACHA20_POLY1305"
 	lengthOfRecoverBlockLength      = 2 // number of bytes used to store recover block length
 	maxChecksummedFormatBytesLength = 65000
 	maxRecoverChunkLength           = 65536
@@ -27,6 +28,19 @@
 	formatBlobEncryptionKeySize     = 32
 )
 
+// SupportedFormatEncryptionAlgorithms returns the supported algorithms for encrypting the format blob.
+func SupportedFormatEncryptionAlgorithms() []string {
+	return []string{aes256GcmEncryption, chacha20Poly1305Encryption}
+}
+
+// ValidateKeyDerivationAlgorithm returns an error if the provided format blob key derivation algorithm is not supported.
+// In addition to SupportedFormatBlobKeyDerivationAlgorithms(), scrypt and argon2id algorithms with tunable parameters
+// are accepted, for example 'scrypt-131072-8-1' or 'argon2id-4-262144-4'.
+func ValidateKeyDerivationAlgorithm(algorithm string) error {
+	//nolint:wrapcheck
+	return crypto.ValidatePBKeyDerivationAlgorithm(algorithm)
+}
+
 // KopiaRepositoryBlobID is the identifier of a BLOB that describes repository format.
 const KopiaRepositoryBlobID = "kopia.repository"
 
@@ -55,6 +69,13 @@ type KopiaRepositoryJSON struct {
 	EncryptionAlgorithm string `json:"encryption"`
 	// encrypted, serialized JSON encryptedRepositoryConfig{}
 	EncryptedFormatBytes []byte `json:"encryptedBlockFormat,omitempty"`
+
+	// KeySlots holds copies of the format encryption key wrapped with additional user passwords.
+	KeySlots []KeySlot `json:"keySlots,omitempty"`
+
+	// PasswordKeySlot holds the format encryption key wrapped with the main repository password.
+	// When present, the format encryption key is random and is not derived from the password.
+	PasswordKeySlot *KeySlot `json:"passwordKeySlot,omitempty"`
 }
 
 // ParseKopiaRepositoryJSON parses the provided byte slice into KopiaRepositoryJSON.
@@ -65,6 +86,11 @@ func ParseKopiaRepositoryJSON(b []byte) (*KopiaRepositoryJSON, error) {
 		return nil, errors.Wrap(err, "invalid format blob")
 	}
 
+	// a JSON null or a blob without the fields below would otherwise be silently accepted.
+	if f == nil || len(f.UniqueID) == 0 || f.EncryptionAlgorithm == "" {
+		return nil, errors.New("invalid format blob: missing unique ID or encryption algorithm")
+	}
+
 	return f, nil
 }
 
@@ -195,8 +221,22 @@ func (f *KopiaRepositoryJSON) WriteKopiaRepositoryBlobWithID(ctx context.Context
 	return nil
 }
 
-func encryptRepositoryBlobBytesAes256Gcm(data, masterKey, repositoryID []byte) ([]byte, error) {
-	res, err := crypto.EncryptAes256Gcm(data, masterKey, repositoryID)
+// encryptRepositoryBlobBytes encrypts the provided data using the specified format encryption algorithm.
+func encryptRepositoryBlobBytes(algorithm string, data, masterKey, repositoryID []byte) ([]byte, error) {
+	var (
+		res []byte
+		err error
+	)
+
+	switch algorithm {
+	case aes256GcmEncryption:
+		res, err = crypto.EncryptAes256Gcm(data, masterKey, repositoryID)
+	case chacha20Poly1305Encryption:
+		res, err = crypto.EncryptChaCha20Poly1305(data, masterKey, repositoryID)
+	default:
+		return nil, errors.Errorf("unknown encryption algorithm: '%v'", algorithm)
+	}
+
 	if err != nil {
 		return nil, errors.Wrap(err, "Failed to encrypt blob")
 	}
@@ -204,8 +244,22 @@ func encryptRepositoryBlobBytesAes256Gcm(data, masterKey, repositoryID []byte) (
 	return res, nil
 }
 
-func decryptRepositoryBlobBytesAes256Gcm(data, masterKey, repositoryID []byte) ([]byte, error) {
-	res, err := crypto.DecryptAes256Gcm(data, masterKey, repositoryID)
+// decryptRepositoryBlobBytes decrypts the provided data using the specified format encryption algorithm.
+func decryptRepositoryBlobBytes(algorithm string, data, masterKey, repositoryID []byte) ([]byte, error) {
+	var (
+		res []byte
+		err error
+	)
+
+	switch algorithm {
+	case aes256GcmEncryption:
+		res, err = crypto.DecryptAes256Gcm(data, masterKey, repositoryID)
+	case chacha20Poly1305Encryption:
+		res, err = crypto.DecryptChaCha20Poly1305(data, masterKey, repositoryID)
+	default:
+		return nil, errors.Errorf("unknown encryption algorithm: '%v'", algorithm)
+	}
+
 	if err != nil {
 		return nil, errors.Wrap(err, "Failed to decrypt blob")
 	}
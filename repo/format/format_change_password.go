@@ -1,43 +1,189 @@
 package format
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 
 	"github.com/pkg/errors"
 
 	"github.com/kopia/kopia/repo/blob"
 )
 
+// Credentials describes how the format blob is protected.
+type Credentials struct {
+	Password string
+
+	// KeyDerivationAlgorithm is the algorithm used to derive the format encryption key from the password.
+	// When empty, the current algorithm is retained.
+	KeyDerivationAlgorithm string
+}
+
 // ChangePassword changes the repository password and rewrites
 // `kopia.repository` & `kopia.blobcfg`.
 func (m *Manager) ChangePassword(ctx context.Context, newPassword string) error {
+	return m.ChangeCredentials(ctx, Credentials{Password: newPassword})
+}
+
+// ChangeCredentials re-derives the format encryption key from the provided credentials and rewrites
+// `kopia.repository` & `kopia.blobcfg`.
+//
+// Contents are encrypted with keys derived from the repository master key, which is stored in the
+// format blob and does not depend on the password, so they don't need to be rewritten.
+//
+// When key slots are in use, the format encryption key is random and is re-wrapped with the new
+// password instead, so that key slots remain valid.
+func (m *Manager) ChangeCredentials(ctx context.Context, creds Credentials) error {
+	return m.changeCredentials(ctx, CredentialChangePassword, "", func(c *pendingCredentialChange) error {
+		if !c.repoConfig.EnablePasswordChange {
+			return errors.Errorf("password changes are not supported for repositories created using Kopia v0.8 or older")
+		}
+
+		if len(c.j.KeySlots) > 0 && c.j.PasswordKeySlot == nil {
+			return errors.Errorf("password cannot be changed while key slots exist, remove them first")
+		}
+
+		if creds.KeyDerivationAlgorithm != "" {
+			if err := ValidateKeyDerivationAlgorithm(creds.KeyDerivationAlgorithm); err != nil {
+				return err
+			}
+
+			c.j.KeyDerivationAlgorithm = creds.KeyDerivationAlgorithm
+		}
+
+		c.password = creds.Password
+
+		if c.j.PasswordKeySlot != nil {
+			pks, err := c.j.newKeySlot(passwordKeySlotName, creds.Password, c.formatEncryptionKey)
+			if err != nil {
+				return err
+			}
+
+			c.j.PasswordKeySlot = &pks
+
+			return nil
+		}
+
+		newFormatEncryptionKey, err := c.j.DeriveFormatEncryptionKeyFromPassword(creds.Password)
+		if err != nil {
+			return errors.Wrap(err, "unable to derive master key")
+		}
+
+		c.formatEncryptionKey = newFormatEncryptionKey
+
+		return nil
+	})
+}
+
+// Credential changes reported to CredentialChangeFunc.
+const (
+	CredentialChangePassword        = "change-password"
+	CredentialChangeAddKeySlot      = "add-key-slot"
+	CredentialChangeRemoveKeySlot   = "remove-key-slot"
+	CredentialChangeKeySlotPassword = "set-key-slot-password"
+)
+
+// CredentialChangeFunc is invoked with one of the CredentialChange* actions and the name of the affected
+// key slot (if any) before a credential change is committed. The change is aborted if it returns an error.
+type CredentialChangeFunc func(ctx context.Context, action, keySlot string) error
+
+// SetCredentialChangeCallback sets the function invoked before each credential change is committed.
+func (m *Manager) SetCredentialChangeCallback(cb CredentialChangeFunc) {
+	m.credentialsMu.Lock()
+	defer m.credentialsMu.Unlock()
+
+	m.onCredentialChange = cb
+}
+
+// pendingCredentialChange holds copies of the format state being modified by a credential change.
+type pendingCredentialChange struct {
+	j                   KopiaRepositoryJSON
+	repoConfig          RepositoryConfig
+	formatEncryptionKey []byte
+	password            string
+}
+
+// changeCredentials applies the provided update to a copy of the format state, invokes the credential change
+// callback and commits the change by rewriting `kopia.repository` & `kopia.blobcfg`.
+//
+// The callback is invoked without holding the lock, since it may need to read the format,
+// so the change is only committed if the format has not been modified in the meantime.
+func (m *Manager) changeCredentials(ctx context.Context, action, keySlot string, update func(c *pendingCredentialChange) error) error {
+	m.credentialsMu.Lock()
+	defer m.credentialsMu.Unlock()
+
+	m.mu.RLock()
+	c := &pendingCredentialChange{
+		j:                   *m.j,
+		repoConfig:          *m.repoConfig,
+		formatEncryptionKey: m.formatEncryptionKey,
+		password:            m.password,
+	}
+	m.mu.RUnlock()
+
+	original, err := json.Marshal(c.j)
+	if err != nil {
+		return errors.Wrap(err, "unable to serialize format")
+	}
+
+	if err := update(c); err != nil {
+		return err
+	}
+
+	if m.onCredentialChange != nil {
+		if err := m.onCredentialChange(ctx, action, keySlot); err != nil {
+			return errors.Wrap(err, "credential change rejected")
+		}
+	}
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	if !m.repoConfig.EnablePasswordChange {
-		return errors.Errorf("password changes are not supported for repositories created using Kopia v0.8 or older")
+	current, err := json.Marshal(m.j)
+	if err != nil {
+		return errors.Wrap(err, "unable to serialize format")
 	}
 
-	newFormatEncryptionKey, err := m.j.DeriveFormatEncryptionKeyFromPassword(newPassword)
-	if err != nil {
-		return errors.Wrap(err, "unable to derive master key")
+	if !bytes.Equal(original, current) {
+		return errors.Errorf("repository format has been changed concurrently, try again")
 	}
 
-	m.formatEncryptionKey = newFormatEncryptionKey
-	m.password = newPassword
+	if err := m.writeFormatAndBlobCfgLocked(ctx, &c.j, &c.repoConfig, c.formatEncryptionKey); err != nil {
+		return err
+	}
+
+	m.password = c.password
 
-	if err := m.j.EncryptRepositoryConfig(m.repoConfig, newFormatEncryptionKey); err != nil {
+	return nil
+}
+
+// writeFormatAndBlobCfgLocked encrypts the repository config and blobcfg using the provided key and
+// writes `kopia.blobcfg` followed by `kopia.repository`, restoring the old blobcfg on failure.
+// On success the manager state is updated to match.
+//
+// +checklocks:m.mu
+func (m *Manager) writeFormatAndBlobCfgLocked(ctx context.Context, newJ *KopiaRepositoryJSON, newRepoConfig *RepositoryConfig, newFormatEncryptionKey []byte) error {
+	if err := newJ.EncryptRepositoryConfig(newRepoConfig, newFormatEncryptionKey); err != nil {
 		return errors.Wrap(err, "unable to encrypt format bytes")
 	}
 
-	if err := m.j.WriteBlobCfgBlob(ctx, m.blobs, m.blobCfgBlob, newFormatEncryptionKey); err != nil {
+	if err := newJ.WriteBlobCfgBlob(ctx, m.blobs, m.blobCfgBlob, newFormatEncryptionKey); err != nil {
 		return errors.Wrap(err, "unable to write blobcfg blob")
 	}
 
-	if err := m.j.WriteKopiaRepositoryBlob(ctx, m.blobs, m.blobCfgBlob); err != nil {
+	if err := newJ.WriteKopiaRepositoryBlob(ctx, m.blobs, m.blobCfgBlob); err != nil {
+		// restore blobcfg encrypted with the old key, so that it matches the format blob.
+		if rerr := m.j.WriteBlobCfgBlob(ctx, m.blobs, m.blobCfgBlob, m.formatEncryptionKey); rerr != nil {
+			log(ctx).Errorf("unable to restore blobcfg blob: %v", rerr)
+		}
+
 		return errors.Wrap(err, "unable to write format blob")
 	}
 
+	m.j = newJ
+	m.repoConfig = newRepoConfig
+	m.formatEncryptionKey = newFormatEncryptionKey
+
 	m.cache.Remove(ctx, []blob.ID{KopiaRepositoryBlobID, KopiaBlobCfgBlobID})
 
 	return nil
@@ -0,0 +1,230 @@
+package format
+
+import (
+	"context"
+	"slices"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/crypto"
+	"github.com/kopia/kopia/internal/feature"
+)
+
+const keySlotSaltSize = 32
+
+// KeySlotsFeature is the feature required to open repositories whose format encryption key is
+// stored in key slots, older clients would derive the wrong key from the password and could
+// drop the key slots when rewriting the format blob.
+const KeySlotsFeature feature.Feature = "key-slots"
+
+// passwordKeySlotName is the name of the slot that wraps the format encryption key with the main repository password.
+const passwordKeySlotName = "password"
+
+// ErrKeySlotNotFound is returned when the requested key slot does not exist.
+var ErrKeySlotNotFound = errors.New("key slot not found")
+
+// KeySlot stores a copy of the format encryption key wrapped with a key derived from
+// the password of an individual user, which allows multiple users to open the repository
+// with their own passwords.
+type KeySlot struct {
+	Name                   string `json:"name"`
+	KeyDerivationAlgorithm string `json:"keyAlgo"`
+	Salt                   []byte `json:"salt"`
+	WrappedKey             []byte `json:"wrappedKey"`
+}
+
+func (f *KopiaRepositoryJSON) newKeySlot(name, password string, formatEncryptionKey []byte) (KeySlot, error) {
+	ks := KeySlot{
+		Name:                   name,
+		KeyDerivationAlgorithm: f.KeyDerivationAlgorithm,
+		Salt:                   randomBytes(keySlotSaltSize),
+	}
+
+	kek, err := crypto.DeriveKeyFromPassword(password, ks.Salt, formatBlobEncryptionKeySize, ks.KeyDerivationAlgorithm)
+	if err != nil {
+		return KeySlot{}, errors.Wrap(err, "unable to derive key slot encryption key")
+	}
+
+	ks.WrappedKey, err = encryptRepositoryBlobBytes(f.EncryptionAlgorithm, formatEncryptionKey, kek, ks.Salt)
+	if err != nil {
+		return KeySlot{}, errors.Wrap(err, "unable to wrap format encryption key")
+	}
+
+	return ks, nil
+}
+
+// unwrap returns the format encryption key stored in the key slot if the password matches.
+func (ks *KeySlot) unwrap(encryptionAlgorithm, password string) ([]byte, error) {
+	kek, err := crypto.DeriveKeyFromPassword(password, ks.Salt, formatBlobEncryptionKeySize, ks.KeyDerivationAlgorithm)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to derive encryption key for key slot %q", ks.Name)
+	}
+
+	key, err := decryptRepositoryBlobBytes(encryptionAlgorithm, ks.WrappedKey, kek, ks.Salt)
+	if err != nil {
+		return nil, ErrInvalidPassword
+	}
+
+	return key, nil
+}
+
+// unwrapFormatEncryptionKey attempts to unwrap the format encryption key from the main password
+// slot or any of the key slots using the provided password.
+func (f *KopiaRepositoryJSON) unwrapFormatEncryptionKey(password string) ([]byte, error) {
+	slots := f.KeySlots
+	if f.PasswordKeySlot != nil {
+		slots = append([]KeySlot{*f.PasswordKeySlot}, slots...)
+	}
+
+	for _, ks := range slots {
+		key, err := ks.unwrap(f.EncryptionAlgorithm, password)
+		if err == nil {
+			return key, nil
+		}
+
+		if !errors.Is(err, ErrInvalidPassword) {
+			return nil, err
+		}
+	}
+
+	return nil, ErrInvalidPassword
+}
+
+// withKeySlotsFeature returns the provided list of required features with KeySlotsFeature added
+// if the format encryption key is stored in key slots.
+func (f *KopiaRepositoryJSON) withKeySlotsFeature(required []feature.Required) []feature.Required {
+	if f.PasswordKeySlot == nil {
+		return required
+	}
+
+	for _, r := range required {
+		if r.Feature == KeySlotsFeature {
+			return required
+		}
+	}
+
+	return append(slices.Clone(required), feature.Required{
+		Feature: KeySlotsFeature,
+		IfNotUnderstood: feature.IfNotUnderstood{
+			Message: "The repository can be opened using multiple passwords stored in key slots.",
+		},
+	})
+}
+
+func (f *KopiaRepositoryJSON) keySlotIndex(name string) int {
+	return slices.IndexFunc(f.KeySlots, func(ks KeySlot) bool { return ks.Name == name })
+}
+
+// KeySlotNames returns the names of key slots defined in the format blob.
+func (m *Manager) KeySlotNames() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var result []string
+
+	for _, ks := range m.j.KeySlots {
+		result = append(result, ks.Name)
+	}
+
+	return result
+}
+
+// AddKeySlot adds a key slot with the provided name that allows the repository to be opened using
+// the provided password. Contents are not re-encrypted.
+//
+// When the first key slot is added, the format encryption key (which used to be derived from the
+// repository password) is replaced with a random key wrapped with the repository password, so that
+// the repository password can later be changed without invalidating key slots. This rewrites
+// `kopia.repository` & `kopia.blobcfg` and marks the repository as requiring KeySlotsFeature.
+func (m *Manager) AddKeySlot(ctx context.Context, name, password string) error {
+	if name == "" {
+		return errors.New("key slot name must not be empty")
+	}
+
+	return m.updateKeySlots(ctx, CredentialChangeAddKeySlot, name, func(j *KopiaRepositoryJSON, formatEncryptionKey []byte) error {
+		if j.keySlotIndex(name) >= 0 {
+			return errors.Errorf("key slot %q already exists", name)
+		}
+
+		ks, err := j.newKeySlot(name, password, formatEncryptionKey)
+		if err != nil {
+			return err
+		}
+
+		j.KeySlots = append(slices.Clone(j.KeySlots), ks)
+
+		return nil
+	})
+}
+
+// ChangeKeySlotPassword changes the password of the provided key slot after verifying that
+// the old password matches.
+func (m *Manager) ChangeKeySlotPassword(ctx context.Context, name, oldPassword, newPassword string) error {
+	return m.updateKeySlots(ctx, CredentialChangeKeySlotPassword, name, func(j *KopiaRepositoryJSON, formatEncryptionKey []byte) error {
+		idx := j.keySlotIndex(name)
+		if idx < 0 {
+			return errors.Wrap(ErrKeySlotNotFound, name)
+		}
+
+		if _, err := j.KeySlots[idx].unwrap(j.EncryptionAlgorithm, oldPassword); err != nil {
+			return errors.Wrapf(err, "unable to verify password of key slot %q", name)
+		}
+
+		ks, err := j.newKeySlot(name, newPassword, formatEncryptionKey)
+		if err != nil {
+			return err
+		}
+
+		j.KeySlots = slices.Clone(j.KeySlots)
+		j.KeySlots[idx] = ks
+
+		return nil
+	})
+}
+
+// RemoveKeySlot removes the key slot with the provided name, so that its password can no longer
+// be used to open the repository.
+//
+// This does not rotate the format encryption key or the repository master key, because the
+// remaining key slots could not be re-wrapped without knowing their passwords. Anyone who was able
+// to open the repository using the removed slot may have retained the keys, so this is not a
+// substitute for revoking access by migrating data to a new repository.
+func (m *Manager) RemoveKeySlot(ctx context.Context, name string) error {
+	return m.updateKeySlots(ctx, CredentialChangeRemoveKeySlot, name, func(j *KopiaRepositoryJSON, _ []byte) error {
+		idx := j.keySlotIndex(name)
+		if idx < 0 {
+			return errors.Wrap(ErrKeySlotNotFound, name)
+		}
+
+		j.KeySlots = slices.Delete(slices.Clone(j.KeySlots), idx, idx+1)
+
+		return nil
+	})
+}
+
+func (m *Manager) updateKeySlots(ctx context.Context, action, name string, update func(j *KopiaRepositoryJSON, formatEncryptionKey []byte) error) error {
+	return m.changeCredentials(ctx, action, name, func(c *pendingCredentialChange) error {
+		if !c.repoConfig.EnablePasswordChange {
+			return errors.Errorf("key slots are not supported for repositories created using Kopia v0.8 or older")
+		}
+
+		if c.j.PasswordKeySlot == nil {
+			if len(c.j.KeySlots) > 0 {
+				return errors.Errorf("key slots without a password key slot are not supported, remove existing key slots first")
+			}
+
+			// switch to a random format encryption key wrapped with the repository password.
+			c.formatEncryptionKey = randomBytes(formatBlobEncryptionKeySize)
+
+			pks, err := c.j.newKeySlot(passwordKeySlotName, c.password, c.formatEncryptionKey)
+			if err != nil {
+				return err
+			}
+
+			c.j.PasswordKeySlot = &pks
+			c.repoConfig.RequiredFeatures = c.j.withKeySlotsFeature(c.repoConfig.RequiredFeatures)
+		}
+
+		return update(&c.j, c.formatEncryptionKey)
+	})
+}
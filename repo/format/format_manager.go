@@ -4,11 +4,13 @@
 	"context"
 	"crypto/rand"
 	"io"
+	"slices"
 	"sync"
 	"time"
 
 	"github.com/pkg/errors"
 
+	"github.com/kopia/kopia/internal/crypto"
 	"github.com/kopia/kopia/internal/feature"
 	"github.com/kopia/kopia/internal/gather"
 	"github.com/kopia/kopia/repo/blob"
@@ -54,6 +56,11 @@ type Manager struct {
 	refreshCounter int
 	// +checklocks:mu
 	ignoreCacheOnFirstRefresh bool
+
+	// credentialsMu serializes credential changes, which invoke onCredentialChange without holding mu.
+	credentialsMu sync.Mutex
+	// +checklocks:credentialsMu
+	onCredentialChange CredentialChangeFunc
 }
 
 func (m *Manager) getOrRefreshFormat(ctx context.Context) (Provider, error) {
@@ -143,7 +150,17 @@ func (m *Manager) refresh(ctx context.Context) error {
 
 	// use old key, if present to avoid deriving it, which is expensive
 	formatEncryptionKey := m.formatEncryptionKey
-	if len(m.formatEncryptionKey) == 0 {
+
+	switch {
+	case len(formatEncryptionKey) != 0:
+	case j.PasswordKeySlot != nil:
+		// the format encryption key is random and wrapped with the password or one of the key slots.
+		formatEncryptionKey, err = j.unwrapFormatEncryptionKey(m.password)
+		if err != nil {
+			return err
+		}
+
+	default:
 		formatEncryptionKey, err = j.DeriveFormatEncryptionKeyFromPassword(m.password)
 		if err != nil {
 			return errors.Wrap(err, "derive format encryption key")
@@ -151,10 +168,24 @@ func (m *Manager) refresh(ctx context.Context) error {
 	}
 
 	repoConfig, err := j.decryptRepositoryConfig(formatEncryptionKey)
-	if err != nil {
+	if errors.Is(err, crypto.ErrDecryptionFailed) && len(j.KeySlots) > 0 && j.PasswordKeySlot == nil && len(m.formatEncryptionKey) == 0 {
+		// the password may belong to one of the key slots.
+		formatEncryptionKey, err = j.unwrapFormatEncryptionKey(m.password)
+		if err != nil {
+			return err
+		}
+
+		repoConfig, err = j.decryptRepositoryConfig(formatEncryptionKey)
+	}
+
+	if errors.Is(err, crypto.ErrDecryptionFailed) {
 		return ErrInvalidPassword
 	}
 
+	if err != nil {
+		return errors.Wrap(err, "unable to read repository format")
+	}
+
 	var blobCfg BlobStorageConfiguration
 
 	if b2, _, err2 := m.readAndCacheRepositoryBlobBytes(ctx, KopiaBlobCfgBlobID); err2 == nil {
@@ -450,12 +481,20 @@ func Initialize(ctx context.Context, st blob.Storage, formatBlob *KopiaRepositor
 		formatBlob.EncryptionAlgorithm = DefaultFormatEncryption
 	}
 
+	if !slices.Contains(SupportedFormatEncryptionAlgorithms(), formatBlob.EncryptionAlgorithm) {
+		return errors.Errorf("unsupported format blob encryption algorithm: '%v'", formatBlob.EncryptionAlgorithm)
+	}
+
 	// In legacy versions, the KeyDerivationAlgorithm may not be present in the
 	// KopiaRepositoryJson. In those cases default to using Scrypt.
 	if formatBlob.KeyDerivationAlgorithm == "" {
 		formatBlob.KeyDerivationAlgorithm = DefaultKeyDerivationAlgorithm
 	}
 
+	if err := ValidateKeyDerivationAlgorithm(formatBlob.KeyDerivationAlgorithm); err != nil {
+		return err
+	}
+
 	if len(formatBlob.UniqueID) == 0 {
 		formatBlob.UniqueID = randomBytes(UniqueIDLengthBytes)
 	}
@@ -2,6 +2,7 @@
 
 import (
 	"bytes"
+	"context"
 	"testing"
 	"time"
 
@@ -10,6 +11,7 @@
 	"github.com/stretchr/testify/require"
 
 	"github.com/kopia/kopia/internal/blobtesting"
+	"github.com/kopia/kopia/internal/crypto"
 	"github.com/kopia/kopia/internal/epoch"
 	"github.com/kopia/kopia/internal/faketime"
 	"github.com/kopia/kopia/internal/feature"
@@ -170,6 +172,35 @@ func TestInitialize(t *testing.T) {
 		format.ErrAlreadyInitialized)
 }
 
+func TestInitializeWithFormatEncryption(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	for _, alg := range format.SupportedFormatEncryptionAlgorithms() {
+		t.Run(alg, func(t *testing.T) {
+			st := blobtesting.NewMapStorage(blobtesting.DataMap{}, nil, nil)
+
+			require.NoError(t, format.Initialize(ctx, st, &format.KopiaRepositoryJSON{EncryptionAlgorithm: alg}, rc, format.BlobStorageConfiguration{
+				MaxBlobSize: 2 << 20,
+			}, "some-password"))
+
+			// the algorithm is detected from the format blob when opening.
+			mgr, err := format.NewManagerWithCache(ctx, st, cacheDuration, "some-password", time.Now, format.NewMemoryBlobCache(time.Now))
+			require.NoError(t, err)
+
+			require.Equal(t, cf.HMACSecret, mgr.GetHmacSecret())
+			require.EqualValues(t, 2<<20, mustGetBlobStorageConfiguration(t, mgr).MaxBlobSize)
+
+			_, err = format.NewManagerWithCache(ctx, st, cacheDuration, "wrong-password", time.Now, format.NewMemoryBlobCache(time.Now))
+			require.Error(t, err)
+		})
+	}
+
+	st := blobtesting.NewMapStorage(blobtesting.DataMap{}, nil, nil)
+	require.ErrorContains(t,
+		format.Initialize(ctx, st, &format.KopiaRepositoryJSON{EncryptionAlgorithm: "NO_SUCH_ALGORITHM"}, rc, format.BlobStorageConfiguration{}, "some-password"),
+		"unsupported format blob encryption algorithm")
+}
+
 func TestInitializeWithRetention(t *testing.T) {
 	ctx := testlogging.Context(t)
 
@@ -367,6 +398,226 @@ func TestChangePassword(t *testing.T) {
 	require.ErrorIs(t, err, format.ErrInvalidPassword)
 }
 
+func TestChangeCredentials(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	nowFunc := time.Now
+	blobCache := format.NewMemoryBlobCache(nowFunc)
+
+	cf2 := cf
+	cf2.Version = format.FormatVersion3
+	cf2.EnablePasswordChange = true
+
+	rc2 := &format.RepositoryConfig{
+		ContentFormat: cf2,
+		UpgradeLock:   uli,
+	}
+
+	st := blobtesting.NewMapStorage(blobtesting.DataMap{}, nil, nil)
+	fst := blobtesting.NewFaultyStorage(st)
+	require.NoError(t, format.Initialize(ctx, fst, &format.KopiaRepositoryJSON{KeyDerivationAlgorithm: crypto.ScryptAlgorithm}, rc2, format.BlobStorageConfiguration{}, "some-password"))
+
+	mgr, err := format.NewManagerWithCache(ctx, fst, cacheDuration, "some-password", nowFunc, blobCache)
+	require.NoError(t, err)
+
+	require.ErrorContains(t, mgr.ChangeCredentials(ctx, format.Credentials{
+		Password:               "new-password",
+		KeyDerivationAlgorithm: "no-such-algorithm",
+	}), "unsupported key derivation algorithm")
+
+	// failure to write the format blob leaves the repository readable with the old password.
+	fst.AddFault(blobtesting.MethodPutBlob)
+	fst.AddFault(blobtesting.MethodPutBlob).ErrorInstead(errSomeError)
+	require.ErrorIs(t, mgr.ChangeCredentials(ctx, format.Credentials{Password: "new-password"}), errSomeError)
+
+	_, err = format.NewManagerWithCache(ctx, fst, cacheDuration, "some-password", nowFunc, format.NewMemoryBlobCache(nowFunc))
+	require.NoError(t, err)
+
+	require.NoError(t, mgr.ChangeCredentials(ctx, format.Credentials{
+		Password:               "new-password",
+		KeyDerivationAlgorithm: crypto.Pbkdf2Algorithm,
+	}))
+
+	j, err := format.ParseKopiaRepositoryJSON(mustGetBytes(t, st, format.KopiaRepositoryBlobID))
+	require.NoError(t, err)
+	require.Equal(t, crypto.Pbkdf2Algorithm, j.KeyDerivationAlgorithm)
+
+	_, err = format.NewManagerWithCache(ctx, fst, cacheDuration, "some-password", nowFunc, format.NewMemoryBlobCache(nowFunc))
+	require.ErrorIs(t, err, format.ErrInvalidPassword)
+
+	mgr2, err := format.NewManagerWithCache(ctx, fst, cacheDuration, "new-password", nowFunc, format.NewMemoryBlobCache(nowFunc))
+	require.NoError(t, err)
+
+	// the master key is retained, so contents remain readable.
+	require.Equal(t, cf2.MasterKey, mgr2.GetMasterKey())
+	require.Equal(t, cf2.HMACSecret, mgr2.GetHmacSecret())
+
+	// switch to argon2id with tuned parameters, which are recorded in the format blob.
+	argonAlg := crypto.Argon2idAlgorithmWithParams(1, 8192, 2)
+
+	require.NoError(t, mgr2.ChangeCredentials(ctx, format.Credentials{
+		Password:               "new-password",
+		KeyDerivationAlgorithm: argonAlg,
+	}))
+
+	j, err = format.ParseKopiaRepositoryJSON(mustGetBytes(t, st, format.KopiaRepositoryBlobID))
+	require.NoError(t, err)
+	require.Equal(t, argonAlg, j.KeyDerivationAlgorithm)
+
+	mgr3, err := format.NewManagerWithCache(ctx, fst, cacheDuration, "new-password", nowFunc, format.NewMemoryBlobCache(nowFunc))
+	require.NoError(t, err)
+	require.Equal(t, cf2.MasterKey, mgr3.GetMasterKey())
+}
+
+func TestKeySlots(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	nowFunc := time.Now
+
+	cf2 := cf
+	cf2.Version = format.FormatVersion3
+	cf2.EnablePasswordChange = true
+
+	rc2 := &format.RepositoryConfig{
+		ContentFormat: cf2,
+		UpgradeLock:   uli,
+	}
+
+	st := blobtesting.NewMapStorage(blobtesting.DataMap{}, nil, nil)
+	require.NoError(t, format.Initialize(ctx, st, &format.KopiaRepositoryJSON{}, rc2, format.BlobStorageConfiguration{}, "some-password"))
+
+	mgr, err := format.NewManagerWithCache(ctx, st, cacheDuration, "some-password", nowFunc, format.NewMemoryBlobCache(nowFunc))
+	require.NoError(t, err)
+
+	require.NoError(t, mgr.AddKeySlot(ctx, "alice", "alice-password"))
+	require.NoError(t, mgr.AddKeySlot(ctx, "bob", "bob-password"))
+	require.ErrorContains(t, mgr.AddKeySlot(ctx, "bob", "other-password"), "already exists")
+	require.Equal(t, []string{"alice", "bob"}, mgr.KeySlotNames())
+
+	// older clients must not be able to open the repository and drop the key slots.
+	require.Contains(t, featureNames(mustGetRequiredFeatures(t, mgr)), format.KeySlotsFeature)
+
+	// changing the repository password keeps the key slots valid.
+	require.NoError(t, mgr.ChangePassword(ctx, "new-password"))
+
+	_, err = format.NewManagerWithCache(ctx, st, cacheDuration, "some-password", nowFunc, format.NewMemoryBlobCache(nowFunc))
+	require.ErrorIs(t, err, format.ErrInvalidPassword)
+
+	// each user can open the repository with their own password and gets the same master key.
+	for _, pass := range []string{"new-password", "alice-password", "bob-password"} {
+		mgr2, err := format.NewManagerWithCache(ctx, st, cacheDuration, pass, nowFunc, format.NewMemoryBlobCache(nowFunc))
+		require.NoError(t, err, pass)
+		require.Equal(t, cf2.MasterKey, mgr2.GetMasterKey())
+		require.Equal(t, []string{"alice", "bob"}, mgr2.KeySlotNames())
+	}
+
+	_, err = format.NewManagerWithCache(ctx, st, cacheDuration, "wrong-password", nowFunc, format.NewMemoryBlobCache(nowFunc))
+	require.ErrorIs(t, err, format.ErrInvalidPassword)
+
+	// a manager opened using a key slot can manage other key slots.
+	aliceMgr, err := format.NewManagerWithCache(ctx, st, cacheDuration, "alice-password", nowFunc, format.NewMemoryBlobCache(nowFunc))
+	require.NoError(t, err)
+
+	require.ErrorIs(t, aliceMgr.ChangeKeySlotPassword(ctx, "bob", "wrong-password", "new-bob-password"), format.ErrInvalidPassword)
+	require.NoError(t, aliceMgr.ChangeKeySlotPassword(ctx, "bob", "bob-password", "new-bob-password"))
+	require.ErrorIs(t, aliceMgr.ChangeKeySlotPassword(ctx, "carol", "carol-password", "new-carol-password"), format.ErrKeySlotNotFound)
+
+	_, err = format.NewManagerWithCache(ctx, st, cacheDuration, "bob-password", nowFunc, format.NewMemoryBlobCache(nowFunc))
+	require.ErrorIs(t, err, format.ErrInvalidPassword)
+
+	_, err = format.NewManagerWithCache(ctx, st, cacheDuration, "new-bob-password", nowFunc, format.NewMemoryBlobCache(nowFunc))
+	require.NoError(t, err)
+
+	require.NoError(t, aliceMgr.RemoveKeySlot(ctx, "bob"))
+	require.ErrorIs(t, aliceMgr.RemoveKeySlot(ctx, "bob"), format.ErrKeySlotNotFound)
+	require.Equal(t, []string{"alice"}, aliceMgr.KeySlotNames())
+
+	_, err = format.NewManagerWithCache(ctx, st, cacheDuration, "new-bob-password", nowFunc, format.NewMemoryBlobCache(nowFunc))
+	require.ErrorIs(t, err, format.ErrInvalidPassword)
+
+	require.NoError(t, aliceMgr.RemoveKeySlot(ctx, "alice"))
+	require.Empty(t, aliceMgr.KeySlotNames())
+
+	mgr2, err := format.NewManagerWithCache(ctx, st, cacheDuration, "new-password", nowFunc, format.NewMemoryBlobCache(nowFunc))
+	require.NoError(t, err)
+	require.Equal(t, cf2.MasterKey, mgr2.GetMasterKey())
+}
+
+func TestCredentialChangeCallback(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	nowFunc := time.Now
+
+	cf2 := cf
+	cf2.Version = format.FormatVersion3
+	cf2.EnablePasswordChange = true
+
+	rc2 := &format.RepositoryConfig{
+		ContentFormat: cf2,
+		UpgradeLock:   uli,
+	}
+
+	st := blobtesting.NewMapStorage(blobtesting.DataMap{}, nil, nil)
+	require.NoError(t, format.Initialize(ctx, st, &format.KopiaRepositoryJSON{}, rc2, format.BlobStorageConfiguration{}, "some-password"))
+
+	mgr, err := format.NewManagerWithCache(ctx, st, cacheDuration, "some-password", nowFunc, format.NewMemoryBlobCache(nowFunc))
+	require.NoError(t, err)
+
+	var (
+		changes    []string
+		rejectWith error
+	)
+
+	mgr.SetCredentialChangeCallback(func(ctx context.Context, action, keySlot string) error {
+		// the callback can read the format, which has not been changed yet.
+		require.NotContains(t, mgr.KeySlotNames(), "bob")
+
+		if rejectWith != nil {
+			return rejectWith
+		}
+
+		changes = append(changes, action+":"+keySlot)
+
+		return nil
+	})
+
+	// the change is not committed when the callback fails.
+	rejectWith = errSomeError
+	require.ErrorIs(t, mgr.AddKeySlot(ctx, "bob", "bob-password"), errSomeError)
+	require.ErrorIs(t, mgr.ChangePassword(ctx, "new-password"), errSomeError)
+	require.Empty(t, mgr.KeySlotNames())
+
+	_, err = format.NewManagerWithCache(ctx, st, cacheDuration, "some-password", nowFunc, format.NewMemoryBlobCache(nowFunc))
+	require.NoError(t, err)
+
+	rejectWith = nil
+
+	// the callback is not invoked for changes that are invalid.
+	require.ErrorIs(t, mgr.RemoveKeySlot(ctx, "bob"), format.ErrKeySlotNotFound)
+
+	require.NoError(t, mgr.AddKeySlot(ctx, "alice", "alice-password"))
+	require.NoError(t, mgr.ChangeKeySlotPassword(ctx, "alice", "alice-password", "new-alice-password"))
+	require.NoError(t, mgr.RemoveKeySlot(ctx, "alice"))
+	require.NoError(t, mgr.ChangePassword(ctx, "new-password"))
+
+	require.Equal(t, []string{
+		format.CredentialChangeAddKeySlot + ":alice",
+		format.CredentialChangeKeySlotPassword + ":alice",
+		format.CredentialChangeRemoveKeySlot + ":alice",
+		format.CredentialChangePassword + ":",
+	}, changes)
+}
+
+func featureNames(rf []feature.Required) []feature.Feature {
+	var result []feature.Feature
+
+	for _, r := range rf {
+		result = append(result, r.Feature)
+	}
+
+	return result
+}
+
 func TestFormatManagerValidDuration(t *testing.T) {
 	cases := map[time.Duration]time.Duration{
 		-1:               15 * time.Minute,
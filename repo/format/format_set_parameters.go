@@ -28,7 +28,7 @@ func (m *Manager) SetParameters(
 	}
 
 	m.repoConfig.ContentFormat.MutableParameters = mp
-	m.repoConfig.RequiredFeatures = requiredFeatures
+	m.repoConfig.RequiredFeatures = m.j.withKeySlotsFeature(requiredFeatures)
 
 	if err := m.j.EncryptRepositoryConfig(m.repoConfig, m.formatEncryptionKey); err != nil {
 		return errors.Errorf("unable to encrypt format bytes")
@@ -1,6 +1,11 @@
 package format
 
+import "github.com/kopia/kopia/repo/compression"
+
 // ObjectFormat describes the format of objects in a repository.
 type ObjectFormat struct {
 	Splitter string `json:"splitter,omitempty"` // splitter used to break objects into pieces of content
+
+	// DefaultCompression is the compressor applied to objects written without an explicit compressor.
+	DefaultCompression compression.Name `json:"defaultCompression,omitempty"`
 }
@@ -25,44 +25,49 @@ type EncryptedRepositoryConfig struct {
 
 // decryptRepositoryConfig decrypts RepositoryConfig stored in EncryptedFormatBytes.
 func (f *KopiaRepositoryJSON) decryptRepositoryConfig(masterKey []byte) (*RepositoryConfig, error) {
-	switch f.EncryptionAlgorithm {
-	case aes256GcmEncryption:
-		plainText, err := decryptRepositoryBlobBytesAes256Gcm(f.EncryptedFormatBytes, masterKey, f.UniqueID)
-		if err != nil {
-			return nil, errors.Errorf("unable to decrypt repository format")
-		}
-
-		var erc EncryptedRepositoryConfig
-		if err := json.Unmarshal(plainText, &erc); err != nil {
-			return nil, errors.Wrap(err, "invalid repository format")
-		}
-
-		return &erc.Format, nil
-
-	default:
-		return nil, errors.Errorf("unknown encryption algorithm: '%v'", f.EncryptionAlgorithm)
+	plainText, err := decryptRepositoryBlobBytes(f.EncryptionAlgorithm, f.EncryptedFormatBytes, masterKey, f.UniqueID)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to decrypt repository format")
 	}
+
+	var erc EncryptedRepositoryConfig
+	if err := json.Unmarshal(plainText, &erc); err != nil {
+		return nil, errors.Wrap(err, "invalid repository format")
+	}
+
+	if err := erc.Format.validateStructure(); err != nil {
+		return nil, errors.Wrap(err, "invalid repository format")
+	}
+
+	return &erc.Format, nil
 }
 
-// EncryptRepositoryConfig encrypts the provided repository config and stores it in EncryptedFormatBytes.
-func (f *KopiaRepositoryJSON) EncryptRepositoryConfig(format *RepositoryConfig, masterKey []byte) error {
-	switch f.EncryptionAlgorithm {
-	case aes256GcmEncryption:
-		data, err := json.Marshal(&EncryptedRepositoryConfig{Format: *format})
-		if err != nil {
-			return errors.Wrap(err, "can't marshal format to JSON")
-		}
+// validateStructure ensures that the fields required to interpret the repository are present.
+func (r *RepositoryConfig) validateStructure() error {
+	if r.Hash == "" {
+		return errors.New("missing hash algorithm")
+	}
 
-		data, err = encryptRepositoryBlobBytesAes256Gcm(data, masterKey, f.UniqueID)
-		if err != nil {
-			return errors.Wrap(err, "failed to encrypt format JSON")
-		}
+	if r.Encryption == "" {
+		return errors.New("missing encryption algorithm")
+	}
 
-		f.EncryptedFormatBytes = data
+	return nil
+}
 
-		return nil
+// EncryptRepositoryConfig encrypts the provided repository config and stores it in EncryptedFormatBytes.
+func (f *KopiaRepositoryJSON) EncryptRepositoryConfig(format *RepositoryConfig, masterKey []byte) error {
+	data, err := json.Marshal(&EncryptedRepositoryConfig{Format: *format})
+	if err != nil {
+		return errors.Wrap(err, "can't marshal format to JSON")
+	}
 
-	default:
-		return errors.Errorf("unknown encryption algorithm: '%v'", f.EncryptionAlgorithm)
+	data, err = encryptRepositoryBlobBytes(f.EncryptionAlgorithm, data, masterKey, f.UniqueID)
+	if err != nil {
+		return errors.Wrap(err, "failed to encrypt format JSON")
 	}
+
+	f.EncryptedFormatBytes = data
+
+	return nil
 }
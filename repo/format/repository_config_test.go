@@ -0,0 +1,71 @@
+package format
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/crypto"
+)
+
+func TestDecryptRepositoryConfigValidation(t *testing.T) {
+	masterKey := []byte("0123456789abcdef0123456789abcdef")
+
+	f := &KopiaRepositoryJSON{
+		UniqueID:            []byte("unique-id"),
+		EncryptionAlgorithm: aes256GcmEncryption,
+	}
+
+	encryptPayload := func(t *testing.T, payload string) {
+		t.Helper()
+
+		var err error
+
+		f.EncryptedFormatBytes, err = encryptRepositoryBlobBytes(aes256GcmEncryption, []byte(payload), masterKey, f.UniqueID)
+		require.NoError(t, err)
+	}
+
+	encryptPayload(t, `{"format":{"hash":"BLAKE2B-256-128","encryption":"AES256-GCM-HMAC-SHA256"}}`)
+
+	rc, err := f.decryptRepositoryConfig(masterKey)
+	require.NoError(t, err)
+	require.Equal(t, "BLAKE2B-256-128", rc.Hash)
+
+	_, err = f.decryptRepositoryConfig([]byte("some-other-key"))
+	require.ErrorIs(t, err, crypto.ErrDecryptionFailed)
+
+	full := f.EncryptedFormatBytes
+
+	f.EncryptedFormatBytes = full[:10]
+	_, err = f.decryptRepositoryConfig(masterKey)
+	require.ErrorIs(t, err, crypto.ErrPayloadTooShort)
+
+	f.EncryptedFormatBytes = nil
+	_, err = f.decryptRepositoryConfig(masterKey)
+	require.ErrorIs(t, err, crypto.ErrPayloadTooShort)
+
+	for _, payload := range []string{`null`, `{}`, `{"format":{}}`, `{"format":{"hash":"BLAKE2B-256-128"}}`, `[1,2,3]`, `{"format":`} {
+		encryptPayload(t, payload)
+
+		_, err = f.decryptRepositoryConfig(masterKey)
+		require.ErrorContains(t, err, "invalid repository format", "payload %v", payload)
+		require.NotErrorIs(t, err, crypto.ErrDecryptionFailed)
+	}
+}
+
+func TestParseKopiaRepositoryJSONValidation(t *testing.T) {
+	valid, err := json.Marshal(&KopiaRepositoryJSON{
+		UniqueID:            []byte("unique-id"),
+		EncryptionAlgorithm: aes256GcmEncryption,
+	})
+	require.NoError(t, err)
+
+	_, err = ParseKopiaRepositoryJSON(valid)
+	require.NoError(t, err)
+
+	for _, payload := range []string{``, `null`, `{}`, `{"uniqueID":"AAAA"}`, `{"encryption":"AES256_GCM"}`, `{"uniqueID":`} {
+		_, err := ParseKopiaRepositoryJSON([]byte(payload))
+		require.ErrorContains(t, err, "invalid format blob", "payload %q", payload)
+	}
+}
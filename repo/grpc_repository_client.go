@@ -7,6 +7,7 @@
 	"net"
 	"net/url"
 	"runtime"
+	"strings"
 	"sync"
 	"time"
 
@@ -22,7 +23,6 @@
 	"github.com/kopia/kopia/internal/gather"
 	apipb "github.com/kopia/kopia/internal/grpcapi"
 	"github.com/kopia/kopia/internal/retry"
-	"github.com/kopia/kopia/internal/tlsutil"
 	"github.com/kopia/kopia/repo/blob"
 	"github.com/kopia/kopia/repo/compression"
 	"github.com/kopia/kopia/repo/content"
@@ -615,8 +615,16 @@ func (r *grpcInnerSession) contentInfo(ctx context.Context, contentID content.ID
 	return content.Info{}, errNoSessionResponse()
 }
 
+// ErrStorageQuotaExceeded is returned when the repository server rejects a write because the user has exceeded their storage quota.
+var ErrStorageQuotaExceeded = errors.New("storage quota exceeded")
+
 func errorFromSessionResponse(rr *apipb.ErrorResponse) error {
 	switch rr.GetCode() {
+	case apipb.ErrorResponse_CLIENT_ERROR:
+		return errors.New(rr.GetMessage())
+	case apipb.ErrorResponse_QUOTA_EXCEEDED:
+		// the message already ends with the text of ErrStorageQuotaExceeded.
+		return errors.Wrap(ErrStorageQuotaExceeded, strings.TrimSuffix(rr.GetMessage(), ": "+ErrStorageQuotaExceeded.Error()))
 	case apipb.ErrorResponse_MANIFEST_NOT_FOUND:
 		return manifest.ErrNotFound
 	case apipb.ErrorResponse_OBJECT_NOT_FOUND:
@@ -821,14 +829,13 @@ func (c grpcCreds) RequireTransportSecurity() bool {
 // openGRPCAPIRepository opens the Repository based on remote GRPC server.
 // The APIServerInfo must have the address of the repository as 'https://host:port'
 func openGRPCAPIRepository(ctx context.Context, si *APIServerInfo, password string, par *immutableServerRepositoryParameters) (Repository, error) {
-	var transportCreds credentials.TransportCredentials
-
-	if si.TrustedServerCertificateFingerprint != "" {
-		transportCreds = credentials.NewTLS(tlsutil.TLSConfigTrustingSingleCertificate(si.TrustedServerCertificateFingerprint))
-	} else {
-		transportCreds = credentials.NewClientTLSFromCert(nil, "")
+	tc, err := si.clientTLSConfig()
+	if err != nil {
+		return nil, err
 	}
 
+	transportCreds := credentials.NewTLS(tc)
+
 	uri, err := baseURLToURI(si.BaseURL)
 	if err != nil {
 		return nil, errors.Wrap(err, "parsing base URL")
@@ -973,7 +980,8 @@ func newGRPCAPIRepositoryForConnection(
 		rr.h = hf
 
 		rr.objectFormat = format.ObjectFormat{
-			Splitter: p.GetSplitter(),
+			Splitter:           p.GetSplitter(),
+			DefaultCompression: compression.Name(p.GetDefaultCompression()),
 		}
 
 		rr.serverSupportsContentCompression = p.GetSupportsContentCompression()
@@ -10,6 +10,7 @@
 	"github.com/pkg/errors"
 
 	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/compression"
 	"github.com/kopia/kopia/repo/content"
 	"github.com/kopia/kopia/repo/ecc"
 	"github.com/kopia/kopia/repo/encryption"
@@ -41,7 +42,12 @@ type NewRepositoryOptions struct {
 	ObjectFormat                      format.ObjectFormat  `json:"objectFormat"` // object format
 	RetentionMode                     blob.RetentionMode   `json:"retentionMode,omitempty"`
 	RetentionPeriod                   time.Duration        `json:"retentionPeriod,omitempty"`
+	MaxBlobSize                       int64                `json:"maxBlobSize,omitempty"`
 	FormatBlockKeyDerivationAlgorithm string               `json:"formatBlockKeyDerivationAlgorithm,omitempty"`
+	FormatBlockEncryptionAlgorithm    string               `json:"formatBlockEncryptionAlgorithm,omitempty"`
+
+	// RandReader is the source of randomness for the unique ID and keys, defaults to crypto/rand.Reader.
+	RandReader io.Reader `json:"-"`
 }
 
 // Initialize creates initial repository data structures in the specified storage with given credentials.
@@ -58,6 +64,8 @@ func Initialize(ctx context.Context, st blob.Storage, opt *NewRepositoryOptions,
 		return errors.Wrap(err, "invalid parameters")
 	}
 
+	repoConfig.RequiredFeatures = blobcfg.WithRequiredFeatures(repoConfig.RequiredFeatures)
+
 	//nolint:wrapcheck
 	return format.Initialize(ctx, st, formatBlob, repoConfig, blobcfg, password)
 }
@@ -68,8 +76,8 @@ func formatBlobFromOptions(opt *NewRepositoryOptions) *format.KopiaRepositoryJSO
 		BuildInfo:              BuildInfo,
 		BuildVersion:           BuildVersion,
 		KeyDerivationAlgorithm: opt.FormatBlockKeyDerivationAlgorithm,
-		UniqueID:               applyDefaultRandomBytes(opt.UniqueID, format.UniqueIDLengthBytes),
-		EncryptionAlgorithm:    format.DefaultFormatEncryption,
+		UniqueID:               applyDefaultRandomBytes(opt.RandReader, opt.UniqueID, format.UniqueIDLengthBytes),
+		EncryptionAlgorithm:    opt.FormatBlockEncryptionAlgorithm,
 	}
 }
 
@@ -77,6 +85,7 @@ func blobCfgBlobFromOptions(opt *NewRepositoryOptions) format.BlobStorageConfigu
 	return format.BlobStorageConfiguration{
 		RetentionMode:   opt.RetentionMode,
 		RetentionPeriod: opt.RetentionPeriod,
+		MaxBlobSize:     opt.MaxBlobSize,
 	}
 }
 
@@ -101,8 +110,8 @@ func repositoryObjectFormatFromOptions(opt *NewRepositoryOptions) (*format.Repos
 			Encryption:         applyDefaultString(opt.BlockFormat.Encryption, encryption.DefaultAlgorithm),
 			ECC:                applyDefaultString(opt.BlockFormat.ECC, ecc.DefaultAlgorithm),
 			ECCOverheadPercent: applyDefaultIntRange(opt.BlockFormat.ECCOverheadPercent, 0, 100), //nolint:mnd
-			HMACSecret:         applyDefaultRandomBytes(opt.BlockFormat.HMACSecret, hmacSecretLength),
-			MasterKey:          applyDefaultRandomBytes(opt.BlockFormat.MasterKey, masterKeyLength),
+			HMACSecret:         applyDefaultRandomBytes(opt.RandReader, opt.BlockFormat.HMACSecret, hmacSecretLength),
+			MasterKey:          applyDefaultRandomBytes(opt.RandReader, opt.BlockFormat.MasterKey, masterKeyLength),
 			MutableParameters: format.MutableParameters{
 				Version:         fv,
 				MaxPackSize:     applyDefaultInt(opt.BlockFormat.MaxPackSize, 20<<20), //nolint:mnd
@@ -112,7 +121,8 @@ func repositoryObjectFormatFromOptions(opt *NewRepositoryOptions) (*format.Repos
 			EnablePasswordChange: opt.BlockFormat.EnablePasswordChange,
 		},
 		ObjectFormat: format.ObjectFormat{
-			Splitter: applyDefaultString(opt.ObjectFormat.Splitter, splitter.DefaultAlgorithm),
+			Splitter:           applyDefaultString(opt.ObjectFormat.Splitter, splitter.DefaultAlgorithm),
+			DefaultCompression: opt.ObjectFormat.DefaultCompression,
 		},
 	}
 
@@ -129,6 +139,17 @@ func repositoryObjectFormatFromOptions(opt *NewRepositoryOptions) (*format.Repos
 		return nil, errors.Wrap(err, "error resolving format version")
 	}
 
+	if err := splitter.ValidateName(f.Splitter); err != nil {
+		return nil, errors.Wrap(err, "invalid splitter")
+	}
+
+	switch c := f.DefaultCompression; {
+	case c == "none":
+		f.DefaultCompression = ""
+	case c != "" && compression.ByName[c] == nil:
+		return nil, errors.Errorf("unsupported default compression %q", c)
+	}
+
 	return f, nil
 }
 
@@ -158,16 +179,20 @@ func applyDefaultString(v, def string) string {
 	return v
 }
 
-func randomBytes(n int) []byte {
+func randomBytes(r io.Reader, n int) []byte {
+	if r == nil {
+		r = rand.Reader
+	}
+
 	b := make([]byte, n)
-	io.ReadFull(rand.Reader, b) //nolint:errcheck
+	io.ReadFull(r, b) //nolint:errcheck
 
 	return b
 }
 
-func applyDefaultRandomBytes(b []byte, n int) []byte {
+func applyDefaultRandomBytes(r io.Reader, b []byte, n int) []byte {
 	if b == nil {
-		return randomBytes(n)
+		return randomBytes(r, n)
 	}
 
 	return b
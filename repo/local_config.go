@@ -8,6 +8,7 @@
 	"path/filepath"
 	"time"
 
+	"github.com/gofrs/flock"
 	"github.com/pkg/errors"
 
 	"github.com/kopia/kopia/internal/atomicfile"
@@ -20,6 +21,14 @@
 
 const configDirMode = 0o700
 
+const (
+	// configFileLockTimeout is the maximum time to wait for other processes to finish updating the config file.
+	configFileLockTimeout = 30 * time.Second
+
+	// configFileLockRetryInterval is the interval between attempts to acquire the config file lock.
+	configFileLockRetryInterval = 50 * time.Millisecond
+)
+
 // ErrCannotWriteToRepoConnectionWithPermissiveCacheLoading error to indicate.
 var ErrCannotWriteToRepoConnectionWithPermissiveCacheLoading = errors.Errorf("cannot write to repo connection with permissive cache loading")
 
@@ -38,7 +47,14 @@ type ClientOptions struct {
 
 	FormatBlobCacheDuration time.Duration `json:"formatBlobCacheDuration,omitempty"`
 
+	// ListParallelism enables listing of pack and index blobs over hexadecimal sub-prefixes in parallel.
+	ListParallelism int `json:"listParallelism,omitempty"`
+
 	Throttling *throttling.Limits `json:"throttlingLimits,omitempty"`
+
+	// PasswordSource specifies where the repository password is read from when opening the repository,
+	// see passwordpersist.Source() for supported values. When empty, the persisted password is used.
+	PasswordSource string `json:"passwordSource,omitempty"`
 }
 
 // ApplyDefaults returns a copy of ClientOptions with defaults filled out.
@@ -101,8 +117,73 @@ type LocalConfig struct {
 	ClientOptions
 }
 
-// writeToFile writes the config to a given file.
-func (lc *LocalConfig) writeToFile(filename string) error {
+// configLockFile returns the name of the file used to lock the provided config file.
+func configLockFile(configFile string) string {
+	return configFile + ".lock"
+}
+
+// lockConfigFile acquires an advisory lock guarding updates to the provided config file
+// and returns a function that releases it.
+func lockConfigFile(ctx context.Context, configFile string) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(configFile), configDirMode); err != nil {
+		return nil, errors.Wrap(err, "unable to create config directory")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, configFileLockTimeout)
+	defer cancel()
+
+	l := flock.New(configLockFile(configFile))
+
+	ok, err := l.TryLockContext(ctx, configFileLockRetryInterval)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to lock config file")
+	}
+
+	if !ok {
+		return nil, errors.Errorf("unable to lock config file %v", configFile)
+	}
+
+	return func() {
+		l.Unlock() //nolint:errcheck
+	}, nil
+}
+
+// updateConfigFile loads the config from a given file, applies the provided changes and writes it back
+// while holding the config file lock, so that concurrent updates by other processes are not lost.
+func updateConfigFile(ctx context.Context, configFile string, update func(lc *LocalConfig) error) error {
+	unlock, err := lockConfigFile(ctx, configFile)
+	if err != nil {
+		return err
+	}
+
+	defer unlock()
+
+	lc, err := LoadConfigFromFile(configFile)
+	if err != nil {
+		return err
+	}
+
+	if err := update(lc); err != nil {
+		return err
+	}
+
+	return lc.writeToFileLocked(configFile)
+}
+
+// writeToFile writes the config to a given file while holding the config file lock.
+func (lc *LocalConfig) writeToFile(ctx context.Context, filename string) error {
+	unlock, err := lockConfigFile(ctx, filename)
+	if err != nil {
+		return err
+	}
+
+	defer unlock()
+
+	return lc.writeToFileLocked(filename)
+}
+
+// writeToFileLocked writes the config to a given file, the caller must hold the config file lock.
+func (lc *LocalConfig) writeToFileLocked(filename string) error {
 	lc2 := *lc
 
 	if lc.Caching != nil {
@@ -4,12 +4,16 @@
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/kopia/kopia/internal/ospath"
+	"github.com/kopia/kopia/internal/testlogging"
 	"github.com/kopia/kopia/internal/testutil"
 	"github.com/kopia/kopia/repo/content"
 )
@@ -24,7 +28,7 @@ func TestLocalConfig_withCaching(t *testing.T) {
 	}
 
 	cfgFile := filepath.Join(td, "repository.config")
-	require.NoError(t, originalLC.writeToFile(cfgFile))
+	require.NoError(t, originalLC.writeToFile(testlogging.Context(t), cfgFile))
 
 	rawLC := LocalConfig{}
 	mustParseJSONFile(t, cfgFile, &rawLC)
@@ -47,7 +51,7 @@ func TestLocalConfig_noCaching(t *testing.T) {
 	originalLC := &LocalConfig{}
 
 	cfgFile := filepath.Join(td, "repository.config")
-	require.NoError(t, originalLC.writeToFile(cfgFile))
+	require.NoError(t, originalLC.writeToFile(testlogging.Context(t), cfgFile))
 
 	rawLC := LocalConfig{}
 	mustParseJSONFile(t, cfgFile, &rawLC)
@@ -66,6 +70,38 @@ func TestLocalConfig_notFound(t *testing.T) {
 	}
 }
 
+func TestLocalConfig_concurrentUpdates(t *testing.T) {
+	ctx := testlogging.Context(t)
+	td := testutil.TempDirectory(t)
+
+	cfgFile := filepath.Join(td, "repository.config")
+	require.NoError(t, (&LocalConfig{}).writeToFile(ctx, cfgFile))
+
+	const numUpdates = 20
+
+	var wg sync.WaitGroup
+
+	for range numUpdates {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			assert.NoError(t, updateConfigFile(ctx, cfgFile, func(lc *LocalConfig) error {
+				lc.Description += "x"
+				return nil
+			}))
+		}()
+	}
+
+	wg.Wait()
+
+	// no update must be lost.
+	lc, err := LoadConfigFromFile(cfgFile)
+	require.NoError(t, err)
+	require.Equal(t, strings.Repeat("x", numUpdates), lc.Description)
+}
+
 func mustParseJSONFile(t *testing.T, fname string, o interface{}) {
 	t.Helper()
 
@@ -22,7 +22,7 @@ type DeleteUnreferencedBlobsOptions struct {
 	NotAfterTime time.Time
 }
 
-// DeleteUnreferencedBlobs deletes o was created after maintenance startederenced by index entries.
+// DeleteUnreferencedBlobs deletes old blobs that are no longer referenced by index entries.
 //
 //nolint:gocyclo,funlen
 func DeleteUnreferencedBlobs(ctx context.Context, rep repo.DirectRepositoryWriter, opt DeleteUnreferencedBlobsOptions, safety SafetyParameters) (int, error) {
@@ -106,9 +106,13 @@ func DeleteUnreferencedBlobs(ctx context.Context, rep repo.DirectRepositoryWrite
 			}
 		}
 
-		unreferenced.Add(bm.Length)
+		if cnt, sum := unreferenced.Add(bm.Length); cnt%100 == 0 {
+			log(ctx).Infof("  found %v unreferenced blobs (%v)", cnt, units.BytesString(sum))
+		}
 
-		if !opt.DryRun {
+		if opt.DryRun {
+			log(ctx).Debugf("  would delete unreferenced blob %v (%v bytes)", bm.BlobID, bm.Length)
+		} else {
 			unused <- bm
 		}
 
@@ -120,7 +124,12 @@ func DeleteUnreferencedBlobs(ctx context.Context, rep repo.DirectRepositoryWrite
 	close(unused)
 
 	unreferencedCount, unreferencedSize := unreferenced.Approximate()
-	log(ctx).Debugf("Found %v blobs to delete (%v)", unreferencedCount, units.BytesString(unreferencedSize))
+
+	if opt.DryRun {
+		log(ctx).Infof("Found %v unreferenced blobs (%v) that would be deleted.", unreferencedCount, units.BytesString(unreferencedSize))
+	} else {
+		log(ctx).Infof("Found %v unreferenced blobs (%v) to delete.", unreferencedCount, units.BytesString(unreferencedSize))
+	}
 
 	// wait for all delete workers to finish.
 	if err := eg.Wait(); err != nil {
@@ -131,9 +140,9 @@ func DeleteUnreferencedBlobs(ctx context.Context, rep repo.DirectRepositoryWrite
 		return int(unreferencedCount), nil
 	}
 
-	del, cnt := deleted.Approximate()
+	deletedCount, deletedSize := deleted.Approximate()
 
-	log(ctx).Infof("Deleted total %v unreferenced blobs (%v)", del, units.BytesString(cnt))
+	log(ctx).Infof("Deleted total %v unreferenced blobs, reclaimed %v.", deletedCount, units.BytesString(deletedSize))
 
-	return int(del), nil
+	return int(deletedCount), nil
 }
@@ -1,6 +1,7 @@
 package maintenance_test
 
 import (
+	"bytes"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/json"
@@ -23,6 +24,7 @@
 	"github.com/kopia/kopia/repo/content"
 	"github.com/kopia/kopia/repo/encryption"
 	"github.com/kopia/kopia/repo/format"
+	"github.com/kopia/kopia/repo/logging"
 	"github.com/kopia/kopia/repo/maintenance"
 	"github.com/kopia/kopia/repo/object"
 )
@@ -86,11 +88,26 @@ func (s *formatSpecificTestSuite) TestDeleteUnreferencedBlobs(t *testing.T) {
 		SessionExpirationAge: 4 * 24 * time.Hour,
 	}
 
+	// dry run reports new blobs but does not delete them
+	var logs bytes.Buffer
+
+	n, err := maintenance.DeleteUnreferencedBlobs(logging.WithLogger(ctx, logging.ToWriter(&logs)), env.RepositoryWriter, maintenance.DeleteUnreferencedBlobsOptions{DryRun: true}, maintenance.SafetyNone)
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	require.Contains(t, logs.String(), "Found 2 unreferenced blobs (6 B) that would be deleted.")
+
+	verifyBlobExists(t, env.RepositoryWriter.BlobStorage(), extraBlobID1)
+	verifyBlobExists(t, env.RepositoryWriter.BlobStorage(), extraBlobID2)
+
 	// new blobs will be deleted
-	if _, err = maintenance.DeleteUnreferencedBlobs(ctx, env.RepositoryWriter, maintenance.DeleteUnreferencedBlobsOptions{}, maintenance.SafetyNone); err != nil {
+	logs.Reset()
+
+	if _, err = maintenance.DeleteUnreferencedBlobs(logging.WithLogger(ctx, logging.ToWriter(&logs)), env.RepositoryWriter, maintenance.DeleteUnreferencedBlobsOptions{}, maintenance.SafetyNone); err != nil {
 		t.Fatal(err)
 	}
 
+	require.Contains(t, logs.String(), "Deleted total 2 unreferenced blobs, reclaimed 6 B.")
+
 	verifyBlobNotFound(t, env.RepositoryWriter.BlobStorage(), extraBlobID1)
 	verifyBlobNotFound(t, env.RepositoryWriter.BlobStorage(), extraBlobID2)
 
@@ -25,6 +25,7 @@ type RewriteContentsOptions struct {
 	PackPrefix     blob.ID
 	ShortPacks     bool
 	FormatVersion  int
+	Damaged        bool // rewrite contents with damaged error correction shards (requires downloading all contents)
 	DryRun         bool
 }
 
@@ -115,7 +116,11 @@ func RewriteContents(ctx context.Context, rep repo.DirectRepositoryWriter, opt *
 
 	wg.Wait()
 
-	log(ctx).Infof("Total bytes rewritten %v", units.BytesString(totalBytes))
+	if opt.DryRun {
+		log(ctx).Infof("Total bytes that would be rewritten %v", units.BytesString(totalBytes))
+	} else {
+		log(ctx).Infof("Total bytes rewritten %v", units.BytesString(totalBytes))
+	}
 
 	if failedCount == 0 {
 		//nolint:wrapcheck
@@ -147,6 +152,10 @@ func getContentToRewrite(ctx context.Context, rep repo.DirectRepository, opt *Re
 		if opt.FormatVersion != 0 {
 			findContentWithFormatVersion(ctx, rep, ch, opt)
 		}
+
+		if opt.Damaged {
+			findDamagedContent(ctx, rep, ch, opt)
+		}
 	}()
 
 	return ch
@@ -179,6 +188,34 @@ func(b content.Info) error {
 		})
 }
 
+func findDamagedContent(ctx context.Context, rep repo.DirectRepository, ch chan contentInfoOrError, opt *RewriteContentsOptions) {
+	err := rep.ContentReader().IterateContents(
+		ctx,
+		content.IterateOptions{
+			Range: opt.ContentIDRange,
+		},
+		func(b content.Info) error {
+			if !strings.HasPrefix(string(b.PackBlobID), string(opt.PackPrefix)) {
+				return nil
+			}
+
+			n, err := rep.ContentReader().DamagedShards(ctx, b.ContentID)
+			if err != nil {
+				return errors.Wrapf(err, "unable to verify content %v", b.ContentID)
+			}
+
+			if n > 0 {
+				log(ctx).Infof("Content %v in pack %v has %v damaged shards.", b.ContentID, b.PackBlobID, n)
+				ch <- contentInfoOrError{Info: b}
+			}
+
+			return nil
+		})
+	if err != nil {
+		ch <- contentInfoOrError{err: err}
+	}
+}
+
 func findContentInShortPacks(ctx context.Context, rep repo.DirectRepository, ch chan contentInfoOrError, threshold int64, opt *RewriteContentsOptions) {
 	var prefixes []blob.ID
 
@@ -0,0 +1,77 @@
+package maintenance
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/units"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/content"
+	"github.com/kopia/kopia/repo/content/index"
+)
+
+// Preview reports the contents that would be rewritten and blobs that would be deleted by maintenance
+// in the provided mode, without modifying the repository.
+//
+// Unlike Run, Preview does not require maintenance ownership and does not update the maintenance
+// schedule. Tasks that only reorganize indexes and epoch markers are not previewed.
+func Preview(ctx context.Context, rep repo.DirectRepositoryWriter, mode Mode, safety SafetyParameters) error {
+	var (
+		rewriteOpt = &RewriteContentsOptions{ShortPacks: true, DryRun: true}
+		blobPrefix blob.ID
+	)
+
+	switch mode {
+	case ModeQuick:
+		rewriteOpt.ContentIDRange = index.AllPrefixedIDs
+		rewriteOpt.PackPrefix = content.PackBlobIDPrefixSpecial
+		blobPrefix = content.PackBlobIDPrefixSpecial
+
+	case ModeFull:
+		rewriteOpt.ContentIDRange = index.AllIDs
+
+	default:
+		return errors.Errorf("unknown mode %q", mode)
+	}
+
+	log(ctx).Infof("Previewing %v maintenance, the repository will not be modified.", mode)
+
+	if err := RewriteContents(ctx, rep, rewriteOpt, safety); err != nil {
+		return errors.Wrap(err, "error previewing content rewrite")
+	}
+
+	if _, err := DeleteUnreferencedBlobs(ctx, rep, DeleteUnreferencedBlobsOptions{
+		NotAfterTime: rep.Time(),
+		Prefix:       blobPrefix,
+		DryRun:       true,
+	}, safety); err != nil {
+		return errors.Wrap(err, "error previewing deletion of unreferenced blobs")
+	}
+
+	p, err := GetParams(ctx, rep)
+	if err != nil {
+		return errors.Wrap(err, "unable to get maintenance parameters")
+	}
+
+	logOpt := p.LogRetention.OrDefault()
+	logOpt.DryRun = true
+
+	logs, err := CleanupLogs(ctx, rep, logOpt)
+	if err != nil {
+		return errors.Wrap(err, "error previewing log cleanup")
+	}
+
+	var logBytes int64
+
+	for _, bm := range logs {
+		log(ctx).Debugf("would delete log %v (%v bytes)", bm.BlobID, bm.Length)
+
+		logBytes += bm.Length
+	}
+
+	log(ctx).Infof("Found %v logs (%v) that would be deleted.", len(logs), units.BytesString(logBytes))
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package maintenance_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/repotesting"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/maintenance"
+	"github.com/kopia/kopia/repo/object"
+)
+
+func (s *formatSpecificTestSuite) TestPreviewDoesNotModifyRepository(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, s.formatVersion)
+
+	w := env.RepositoryWriter.NewObjectWriter(ctx, object.WriterOptions{})
+	io.WriteString(w, "hello world!")
+	w.Result()
+	w.Close()
+
+	require.NoError(t, env.RepositoryWriter.Flush(ctx))
+
+	const extraBlobID blob.ID = "pdeadbeef1"
+
+	mustPutDummyBlob(t, env.RepositoryWriter.BlobStorage(), extraBlobID)
+
+	blobsBefore, err := blob.ListAllBlobs(ctx, env.RepositoryWriter.BlobStorage(), "")
+	require.NoError(t, err)
+
+	for _, mode := range []maintenance.Mode{maintenance.ModeQuick, maintenance.ModeFull} {
+		require.NoError(t, maintenance.Preview(ctx, env.RepositoryWriter, mode, maintenance.SafetyNone))
+	}
+
+	require.Error(t, maintenance.Preview(ctx, env.RepositoryWriter, "no-such-mode", maintenance.SafetyNone))
+
+	blobsAfter, err := blob.ListAllBlobs(ctx, env.RepositoryWriter.BlobStorage(), "")
+	require.NoError(t, err)
+	require.ElementsMatch(t, blobsBefore, blobsAfter)
+
+	// the same blob is deleted when not previewing.
+	n, err := maintenance.DeleteUnreferencedBlobs(ctx, env.RepositoryWriter, maintenance.DeleteUnreferencedBlobsOptions{}, maintenance.SafetyNone)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	verifyBlobNotFound(t, env.RepositoryWriter.BlobStorage(), extraBlobID)
+}
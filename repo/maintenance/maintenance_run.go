@@ -8,6 +8,9 @@
 
 	"github.com/gofrs/flock"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/kopia/kopia/internal/clock"
 	"github.com/kopia/kopia/internal/epoch"
@@ -17,7 +20,10 @@
 	"github.com/kopia/kopia/repo/logging"
 )
 
-var log = logging.Module("maintenance")
+var (
+	log    = logging.Module("maintenance")
+	tracer = otel.Tracer("kopia/maintenance")
+)
 
 const maxClockSkew = 5 * time.Minute
 
@@ -239,6 +245,9 @@ func checkClockSkewBounds(rp RunParameters) error {
 
 // Run performs maintenance activities for a repository.
 func Run(ctx context.Context, runParams RunParameters, safety SafetyParameters) error {
+	ctx, span := tracer.Start(ctx, "RunMaintenance", trace.WithAttributes(attribute.String("mode", string(runParams.Mode))))
+	defer span.End()
+
 	switch runParams.Mode {
 	case ModeQuick:
 		return runQuickMaintenance(ctx, runParams, safety)
@@ -0,0 +1,239 @@
+package manifest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"slices"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/crypto"
+)
+
+const (
+	// ArchiveFormatVersion is the version of archives produced by Export.
+	ArchiveFormatVersion = 1
+
+	archiveSaltLength = 32
+	archiveKeyLength  = 32
+)
+
+// ErrUnsupportedArchiveVersion is returned when importing an archive produced by a newer version of kopia.
+var ErrUnsupportedArchiveVersion = errors.New("unsupported manifest archive version")
+
+// ArchiveOptions provides the credentials used to protect manifest archive.
+type ArchiveOptions struct {
+	Password string
+
+	// KeyDerivationAlgorithm is only used by Export, Import uses the algorithm stored in the archive.
+	KeyDerivationAlgorithm string
+
+	// VerifyEntry is only used by Import and is invoked for each entry in the archive before any
+	// entries are imported. Returning an error aborts the import, which allows callers to ensure that
+	// objects referenced by the imported manifests exist in the destination repository.
+	VerifyEntry func(ctx context.Context, md *EntryMetadata, payload json.RawMessage) error
+
+	// SkipTypes lists manifest types which are neither exported nor imported.
+	SkipTypes []string
+}
+
+// archive is the on-disk representation of manifest archive.
+type archive struct {
+	Version                int    `json:"version"`
+	KeyDerivationAlgorithm string `json:"keyAlgo"`
+	Salt                   []byte `json:"salt"`
+	EncryptedEntries       []byte `json:"encryptedEntries"`
+}
+
+// Export writes all manifest entries, including uncommitted ones, to the provided writer
+// as an encrypted archive which can be imported into the same or a different repository.
+// Deleted entries are not exported. Returns the number of exported entries.
+func (m *Manager) Export(ctx context.Context, w io.Writer, opt ArchiveOptions) (int, error) {
+	if opt.KeyDerivationAlgorithm == "" {
+		return 0, errors.Errorf("key derivation algorithm must be provided")
+	}
+
+	committed, err := m.committed.findCommittedEntries(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	var man manifest
+
+	m.mu.Lock()
+
+	for id, e := range committed {
+		if m.pendingEntries[id] == nil && !e.Deleted && !slices.Contains(opt.SkipTypes, e.Labels[TypeLabelKey]) {
+			man.Entries = append(man.Entries, e)
+		}
+	}
+
+	for _, e := range m.pendingEntries {
+		if !e.Deleted && !slices.Contains(opt.SkipTypes, e.Labels[TypeLabelKey]) {
+			man.Entries = append(man.Entries, e)
+		}
+	}
+
+	m.mu.Unlock()
+
+	var buf bytes.Buffer
+
+	gz := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(gz).Encode(man); err != nil {
+		return 0, errors.Wrap(err, "unable to serialize manifests")
+	}
+
+	if err := gz.Close(); err != nil {
+		return 0, errors.Wrap(err, "unable to compress manifests")
+	}
+
+	a := archive{
+		Version:                ArchiveFormatVersion,
+		KeyDerivationAlgorithm: opt.KeyDerivationAlgorithm,
+		Salt:                   make([]byte, archiveSaltLength),
+	}
+
+	if _, err := io.ReadFull(m.randReader, a.Salt); err != nil {
+		return 0, errors.Wrap(err, "unable to generate salt")
+	}
+
+	key, err := crypto.DeriveKeyFromPassword(opt.Password, a.Salt, archiveKeyLength, a.KeyDerivationAlgorithm)
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to derive archive key")
+	}
+
+	a.EncryptedEntries, err = crypto.EncryptAes256Gcm(buf.Bytes(), key, a.Salt)
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to encrypt manifests")
+	}
+
+	if err := json.NewEncoder(w).Encode(a); err != nil {
+		return 0, errors.Wrap(err, "unable to write archive")
+	}
+
+	return len(man.Entries), nil
+}
+
+// Import reads manifest entries from an archive produced by Export and adds them as pending entries,
+// preserving their IDs, labels and modification times. Entries which already exist with the same
+// or newer modification time are skipped. Returns the number of imported entries.
+//
+// All entries are validated using ArchiveOptions.VerifyEntry before any of them is imported,
+// so a failed verification leaves the manager unchanged.
+//
+// The caller must Flush() the manager to persist imported entries.
+func (m *Manager) Import(ctx context.Context, r io.Reader, opt ArchiveOptions) (int, error) {
+	imported, _, err := m.ImportPending(ctx, r, opt)
+
+	return len(imported), err
+}
+
+// ImportPending is like Import but returns metadata of the imported entries together with
+// the pending state captured before they were imported, which can be passed to RestorePending
+// to roll the import back.
+func (m *Manager) ImportPending(ctx context.Context, r io.Reader, opt ArchiveOptions) ([]*EntryMetadata, PendingState, error) {
+	var a archive
+
+	if err := json.NewDecoder(r).Decode(&a); err != nil {
+		return nil, nil, errors.Wrap(err, "unable to read archive")
+	}
+
+	if a.Version != ArchiveFormatVersion {
+		return nil, nil, errors.Wrapf(ErrUnsupportedArchiveVersion, "version %v", a.Version)
+	}
+
+	key, err := crypto.DeriveKeyFromPassword(opt.Password, a.Salt, archiveKeyLength, a.KeyDerivationAlgorithm)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "unable to derive archive key")
+	}
+
+	plainText, err := crypto.DecryptAes256Gcm(a.EncryptedEntries, key, a.Salt)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "unable to decrypt archive")
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(plainText))
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "unable to decompress archive")
+	}
+
+	defer gz.Close() //nolint:errcheck
+
+	man, err := decodeManifestArray(gz)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "unable to parse archive")
+	}
+
+	var toImport []*manifestEntry
+
+	for _, e := range man.Entries {
+		if e.Deleted {
+			continue
+		}
+
+		if e.Labels[TypeLabelKey] == "" {
+			return nil, nil, errors.Errorf("manifest %v is missing 'type' label", e.ID)
+		}
+
+		if slices.Contains(opt.SkipTypes, e.Labels[TypeLabelKey]) {
+			continue
+		}
+
+		if opt.VerifyEntry != nil {
+			md := &EntryMetadata{
+				ID:      e.ID,
+				Length:  len(e.Content),
+				Labels:  e.Labels,
+				ModTime: e.ModTime,
+			}
+
+			if err := opt.VerifyEntry(ctx, md, e.Content); err != nil {
+				return nil, nil, errors.Wrapf(err, "unable to verify manifest %v", e.ID)
+			}
+		}
+
+		toImport = append(toImport, e)
+	}
+
+	var imported []*EntryMetadata
+
+	prev := PendingState{}
+
+	for _, e := range toImport {
+		existing, err := m.committed.getCommittedEntryOrNil(ctx, e.ID)
+		if err != nil {
+			m.RestorePending(prev)
+
+			return nil, nil, err
+		}
+
+		m.mu.Lock()
+
+		p := m.pendingEntries[e.ID]
+		if p != nil {
+			existing = p
+		}
+
+		if existing == nil || e.ModTime.After(existing.ModTime) {
+			if _, ok := prev[e.ID]; !ok {
+				prev[e.ID] = p
+			}
+
+			m.pendingEntries[e.ID] = e
+
+			imported = append(imported, &EntryMetadata{
+				ID:      e.ID,
+				Length:  len(e.Content),
+				Labels:  e.Labels,
+				ModTime: e.ModTime,
+			})
+		}
+
+		m.mu.Unlock()
+	}
+
+	return imported, prev, nil
+}
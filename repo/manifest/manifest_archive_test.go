@@ -0,0 +1,144 @@
+package manifest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/blobtesting"
+	"github.com/kopia/kopia/internal/crypto"
+	"github.com/kopia/kopia/internal/testlogging"
+)
+
+func TestManifestExportImport(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	opt := ArchiveOptions{
+		Password:               "archive-password",
+		KeyDerivationAlgorithm: crypto.ScryptAlgorithmWithParams(1<<14, 8, 1),
+	}
+
+	src := newManagerForTesting(ctx, t, blobtesting.DataMap{}, ManagerOptions{})
+
+	labels1 := map[string]string{"type": "item", "color": "red"}
+	labels2 := map[string]string{"type": "item", "color": "blue"}
+
+	id1 := addAndVerify(ctx, t, src, labels1, map[string]int{"foo": 1})
+	id2 := addAndVerify(ctx, t, src, labels2, map[string]int{"bar": 2})
+	id3 := addAndVerify(ctx, t, src, labels2, map[string]int{"baz": 3})
+
+	require.NoError(t, src.Flush(ctx))
+	require.NoError(t, src.Delete(ctx, id3))
+
+	// pending entries are exported, deleted ones are not.
+	id4 := addAndVerify(ctx, t, src, labels1, map[string]int{"qux": 4})
+
+	var buf bytes.Buffer
+
+	n, err := src.Export(ctx, &buf, opt)
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+	require.NotContains(t, buf.String(), "red", "archive must be encrypted")
+
+	archiveBytes := buf.Bytes()
+
+	dst := newManagerForTesting(ctx, t, blobtesting.DataMap{}, ManagerOptions{})
+
+	_, err = dst.Import(ctx, bytes.NewReader(archiveBytes), ArchiveOptions{Password: "wrong-password"})
+	require.ErrorIs(t, err, crypto.ErrDecryptionFailed)
+
+	n, err = dst.Import(ctx, bytes.NewReader(archiveBytes), ArchiveOptions{Password: opt.Password})
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+	require.NoError(t, dst.Flush(ctx))
+
+	verifyItem(ctx, t, dst, id1, labels1, map[string]int{"foo": 1})
+	verifyItem(ctx, t, dst, id2, labels2, map[string]int{"bar": 2})
+	verifyItem(ctx, t, dst, id4, labels1, map[string]int{"qux": 4})
+	verifyItemNotFound(ctx, t, dst, id3)
+	verifyMatches(ctx, t, dst, labels1, []ID{id1, id4})
+
+	for _, id := range []ID{id1, id2, id4} {
+		srcMD, err := src.GetMetadata(ctx, id)
+		require.NoError(t, err)
+
+		dstMD, err := dst.GetMetadata(ctx, id)
+		require.NoError(t, err)
+		require.True(t, srcMD.ModTime.Equal(dstMD.ModTime))
+	}
+
+	// importing again is a no-op.
+	n, err = dst.Import(ctx, bytes.NewReader(archiveBytes), opt)
+	require.NoError(t, err)
+	require.Equal(t, 0, n)
+
+	// failed verification of any entry prevents all entries from being imported.
+	dst2 := newManagerForTesting(ctx, t, blobtesting.DataMap{}, ManagerOptions{})
+	errVerify := errors.New("verification failed")
+
+	_, err = dst2.Import(ctx, bytes.NewReader(archiveBytes), ArchiveOptions{
+		Password: opt.Password,
+		VerifyEntry: func(_ context.Context, md *EntryMetadata, _ json.RawMessage) error {
+			if md.ID == id2 {
+				return errVerify
+			}
+
+			return nil
+		},
+	})
+	require.ErrorIs(t, err, errVerify)
+	require.NoError(t, dst2.Flush(ctx))
+	verifyItemNotFound(ctx, t, dst2, id1)
+	verifyItemNotFound(ctx, t, dst2, id2)
+
+	_, err = dst.Import(ctx, bytes.NewReader([]byte(`{"version":999}`)), opt)
+	require.ErrorIs(t, err, ErrUnsupportedArchiveVersion)
+
+	_, err = src.Export(ctx, &buf, ArchiveOptions{Password: opt.Password})
+	require.Error(t, err)
+}
+
+func TestManifestExportImportSkipTypes(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	opt := ArchiveOptions{
+		Password:               "archive-password",
+		KeyDerivationAlgorithm: crypto.ScryptAlgorithmWithParams(1<<14, 8, 1),
+	}
+
+	src := newManagerForTesting(ctx, t, blobtesting.DataMap{}, ManagerOptions{})
+
+	itemLabels := map[string]string{"type": "item"}
+	otherLabels := map[string]string{"type": "other"}
+
+	id1 := addAndVerify(ctx, t, src, itemLabels, map[string]int{"foo": 1})
+	id2 := addAndVerify(ctx, t, src, otherLabels, map[string]int{"bar": 2})
+
+	var buf bytes.Buffer
+
+	// skipped types are not exported.
+	n, err := src.Export(ctx, &buf, ArchiveOptions{Password: opt.Password, KeyDerivationAlgorithm: opt.KeyDerivationAlgorithm, SkipTypes: []string{"other"}})
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	buf.Reset()
+
+	n, err = src.Export(ctx, &buf, opt)
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+
+	// skipped types present in the archive are not imported.
+	dst := newManagerForTesting(ctx, t, blobtesting.DataMap{}, ManagerOptions{})
+
+	n, err = dst.Import(ctx, bytes.NewReader(buf.Bytes()), ArchiveOptions{Password: opt.Password, SkipTypes: []string{"other"}})
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.NoError(t, dst.Flush(ctx))
+
+	verifyItem(ctx, t, dst, id1, itemLabels, map[string]int{"foo": 1})
+	verifyItemNotFound(ctx, t, dst, id2)
+}
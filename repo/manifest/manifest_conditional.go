@@ -0,0 +1,118 @@
+package manifest
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// ErrConflict is returned when a conditional write conflicts with a concurrent modification
+// of the same manifests, either by this or by another repository client. Callers should re-read
+// the manifests, merge or re-apply their changes and retry.
+var ErrConflict = errors.New("manifest modified concurrently")
+
+// precondition describes the set of committed manifests matching the labels which a conditional write was based on.
+type precondition struct {
+	labels    map[string]string
+	committed map[ID]bool
+}
+
+// CompareAndReplace replaces all manifests matching the provided labels with a new one, provided that
+// the set of manifests currently matching the labels is exactly the expected one, otherwise it returns ErrConflict.
+// Passing empty expected list only succeeds if no manifests match the labels.
+//
+// The check and the replacement are performed atomically with respect to other changes made using this manager.
+// Because other clients may modify the same manifests before the changes are committed, the condition is
+// checked again against the latest committed state when the manager is flushed and Flush() returns ErrConflict
+// if it no longer holds, in which case all pending changes are discarded and none of them are written.
+func (m *Manager) CompareAndReplace(ctx context.Context, labels map[string]string, payload interface{}, expected []ID) (ID, error) {
+	if labels[TypeLabelKey] == "" {
+		return "", errors.Errorf("'type' label is required")
+	}
+
+	e, err := m.newEntry(labels, payload)
+	if err != nil {
+		return "", err
+	}
+
+	committed, err := m.committed.findCommittedEntries(ctx, labels)
+	if err != nil {
+		return "", err
+	}
+
+	expectedSet := map[ID]bool{}
+	for _, id := range expected {
+		expectedSet[id] = true
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	current := m.findLocked(committed, labels)
+
+	if len(current) != len(expectedSet) {
+		return "", errors.Wrapf(ErrConflict, "expected %v manifests, found %v", len(expectedSet), len(current))
+	}
+
+	for _, md := range current {
+		if !expectedSet[md.ID] {
+			return "", errors.Wrapf(ErrConflict, "unexpected manifest %v", md.ID)
+		}
+	}
+
+	for id := range expectedSet {
+		m.pendingEntries[id] = &manifestEntry{
+			ID:      id,
+			ModTime: e.ModTime,
+			Deleted: true,
+		}
+	}
+
+	m.pendingEntries[e.ID] = e
+
+	p := precondition{
+		labels:    copyLabels(labels),
+		committed: map[ID]bool{},
+	}
+
+	for cid := range committed {
+		p.committed[cid] = true
+	}
+
+	m.preconditions = append(m.preconditions, p)
+
+	return e.ID, nil
+}
+
+// verifyPreconditionsLocked refreshes committed state and verifies that manifests which conditional writes were based on
+// have not been modified by another client.
+//
+// +checklocks:m.mu
+func (m *Manager) verifyPreconditionsLocked(ctx context.Context) error {
+	if len(m.preconditions) == 0 {
+		return nil
+	}
+
+	if err := m.b.Refresh(ctx); err != nil {
+		return errors.Wrap(err, "unable to refresh committed manifests")
+	}
+
+	for _, p := range m.preconditions {
+		committed, err := m.committed.findCommittedEntries(ctx, p.labels)
+		if err != nil {
+			return err
+		}
+
+		if len(committed) != len(p.committed) {
+			return errors.Wrapf(ErrConflict, "manifests matching %v", p.labels)
+		}
+
+		for id := range committed {
+			if !p.committed[id] {
+				return errors.Wrapf(ErrConflict, "manifests matching %v", p.labels)
+			}
+		}
+	}
+
+	return nil
+}
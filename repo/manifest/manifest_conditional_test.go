@@ -0,0 +1,112 @@
+package manifest
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/blobtesting"
+	"github.com/kopia/kopia/internal/testlogging"
+)
+
+func TestCompareAndReplace(t *testing.T) {
+	ctx := testlogging.Context(t)
+	data := blobtesting.DataMap{}
+	labels := map[string]string{"type": "item", "name": "x"}
+
+	mgr := newManagerForTesting(ctx, t, data, ManagerOptions{})
+
+	// create only if not exists.
+	id1, err := mgr.CompareAndReplace(ctx, labels, map[string]int{"v": 1}, nil)
+	require.NoError(t, err)
+
+	_, err = mgr.CompareAndReplace(ctx, labels, map[string]int{"v": 2}, nil)
+	require.ErrorIs(t, err, ErrConflict)
+
+	// multiple replacements within the same session.
+	id2, err := mgr.CompareAndReplace(ctx, labels, map[string]int{"v": 2}, []ID{id1})
+	require.NoError(t, err)
+
+	_, err = mgr.CompareAndReplace(ctx, labels, map[string]int{"v": 3}, []ID{id1})
+	require.ErrorIs(t, err, ErrConflict)
+
+	require.NoError(t, mgr.Flush(ctx))
+	require.NoError(t, mgr.b.Flush(ctx))
+	verifyMatches(ctx, t, mgr, labels, []ID{id2})
+
+	// two clients replace the same manifest concurrently, only the first one to flush succeeds.
+	mgr1 := newManagerForTesting(ctx, t, data, ManagerOptions{})
+	mgr2 := newManagerForTesting(ctx, t, data, ManagerOptions{})
+
+	id3, err := mgr1.CompareAndReplace(ctx, labels, map[string]int{"v": 3}, []ID{id2})
+	require.NoError(t, err)
+
+	_, err = mgr2.CompareAndReplace(ctx, labels, map[string]int{"v": 4}, []ID{id2})
+	require.NoError(t, err)
+
+	require.NoError(t, mgr1.Flush(ctx))
+	require.NoError(t, mgr1.b.Flush(ctx))
+	require.ErrorIs(t, mgr2.Flush(ctx), ErrConflict)
+
+	// the conflicting changes have been discarded, so the manager can be flushed and reused.
+	require.NoError(t, mgr2.Flush(ctx))
+	require.NoError(t, mgr2.b.Flush(ctx))
+
+	otherLabels := map[string]string{"type": "item", "name": "y"}
+	otherID := addAndVerify(ctx, t, mgr2, otherLabels, map[string]int{"v": 1})
+	require.NoError(t, mgr2.Flush(ctx))
+	require.NoError(t, mgr2.b.Flush(ctx))
+
+	mgr3 := newManagerForTesting(ctx, t, data, ManagerOptions{})
+	verifyMatches(ctx, t, mgr3, labels, []ID{id3})
+	verifyMatches(ctx, t, mgr3, otherLabels, []ID{otherID})
+	verifyItem(ctx, t, mgr3, id3, labels, map[string]int{"v": 3})
+
+	// retry based on the latest state succeeds.
+	id4, err := mgr3.CompareAndReplace(ctx, labels, map[string]int{"v": 4}, []ID{id3})
+	require.NoError(t, err)
+	require.NoError(t, mgr3.Flush(ctx))
+	require.NoError(t, mgr3.b.Flush(ctx))
+
+	verifyMatches(ctx, t, newManagerForTesting(ctx, t, data, ManagerOptions{}), labels, []ID{id4})
+}
+
+func TestCompareAndReplaceConcurrent(t *testing.T) {
+	ctx := testlogging.Context(t)
+	labels := map[string]string{"type": "item", "name": "x"}
+
+	mgr := newManagerForTesting(ctx, t, blobtesting.DataMap{}, ManagerOptions{})
+
+	id0, err := mgr.CompareAndReplace(ctx, labels, map[string]int{"v": 0}, nil)
+	require.NoError(t, err)
+
+	const numWorkers = 10
+
+	var (
+		wg        sync.WaitGroup
+		succeeded atomic.Int32
+	)
+
+	for i := range numWorkers {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if _, err := mgr.CompareAndReplace(ctx, labels, map[string]int{"v": i + 1}, []ID{id0}); err == nil {
+				succeeded.Add(1)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	// exactly one of the replacements based on the same state succeeds.
+	require.EqualValues(t, 1, succeeded.Load())
+
+	matches, err := mgr.Find(ctx, labels)
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+}
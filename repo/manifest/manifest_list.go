@@ -0,0 +1,100 @@
+package manifest
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// ListOptions controls the behavior of ListEntries.
+type ListOptions struct {
+	// IDPrefix limits the results to manifests whose IDs start with the provided prefix.
+	IDPrefix ID
+
+	// Limit is the maximum number of entries to return, zero means no limit.
+	Limit int
+
+	// ContinuationToken resumes the listing after the last entry returned by a previous call.
+	ContinuationToken string
+
+	// MaxPayloadSize causes payloads of manifests not larger than the provided number of bytes to be returned,
+	// which avoids separate calls to Get() for small items. Zero means payloads are not returned.
+	MaxPayloadSize int
+}
+
+// ListEntry describes a single manifest returned by ListEntries.
+type ListEntry struct {
+	EntryMetadata
+
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// ListResult is a single page of results returned by ListEntries.
+type ListResult struct {
+	Entries []*ListEntry `json:"entries"`
+
+	// ContinuationToken is non-empty if there are more entries to be returned.
+	ContinuationToken string `json:"continuationToken,omitempty"`
+}
+
+// ListEntries returns a page of manifests matching all provided labels ordered by ID.
+func (m *Manager) ListEntries(ctx context.Context, labels map[string]string, opts ListOptions) (*ListResult, error) {
+	committedMatches, err := m.committed.findCommittedEntries(ctx, labels)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var matches []*manifestEntry
+
+	include := func(e *manifestEntry) {
+		if e.Deleted || !strings.HasPrefix(string(e.ID), string(opts.IDPrefix)) {
+			return
+		}
+
+		if opts.ContinuationToken != "" && string(e.ID) <= opts.ContinuationToken {
+			return
+		}
+
+		matches = append(matches, e)
+	}
+
+	for _, e := range findEntriesMatchingLabels(m.pendingEntries, labels) {
+		include(e)
+	}
+
+	for _, e := range committedMatches {
+		if m.pendingEntries[e.ID] != nil {
+			// ignore committed that are also in pending
+			continue
+		}
+
+		include(e)
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].ID < matches[j].ID
+	})
+
+	result := &ListResult{}
+
+	if opts.Limit > 0 && len(matches) > opts.Limit {
+		matches = matches[:opts.Limit]
+		result.ContinuationToken = string(matches[len(matches)-1].ID)
+	}
+
+	for _, e := range matches {
+		le := &ListEntry{EntryMetadata: *cloneEntryMetadata(e)}
+
+		if len(e.Content) <= opts.MaxPayloadSize {
+			le.Payload = append(json.RawMessage(nil), e.Content...)
+		}
+
+		result.Entries = append(result.Entries, le)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,96 @@
+package manifest
+
+import (
+	"encoding/json"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/blobtesting"
+	"github.com/kopia/kopia/internal/testlogging"
+)
+
+func TestListEntries(t *testing.T) {
+	ctx := testlogging.Context(t)
+	data := blobtesting.DataMap{}
+
+	mgr := newManagerForTesting(ctx, t, data, ManagerOptions{})
+
+	var ids []ID
+
+	for i := range 10 {
+		id, err := mgr.Put(ctx, map[string]string{"type": "item"}, map[string]int{"v": i})
+		require.NoError(t, err)
+
+		ids = append(ids, id)
+	}
+
+	_, err := mgr.Put(ctx, map[string]string{"type": "other"}, map[string]string{"large": "some-long-payload"})
+	require.NoError(t, err)
+
+	// half of the entries are committed, the rest is pending.
+	require.NoError(t, mgr.Flush(ctx))
+	require.NoError(t, mgr.b.Flush(ctx))
+
+	for i := 10; i < 15; i++ {
+		id, err := mgr.Put(ctx, map[string]string{"type": "item"}, map[string]int{"v": i})
+		require.NoError(t, err)
+
+		ids = append(ids, id)
+	}
+
+	require.NoError(t, mgr.Delete(ctx, ids[0]))
+	ids = ids[1:]
+
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	var (
+		got   []ID
+		token string
+		pages int
+	)
+
+	for {
+		res, err := mgr.ListEntries(ctx, map[string]string{"type": "item"}, ListOptions{
+			Limit:             4,
+			ContinuationToken: token,
+			MaxPayloadSize:    100,
+		})
+		require.NoError(t, err)
+
+		pages++
+
+		for _, e := range res.Entries {
+			got = append(got, e.ID)
+
+			var v map[string]int
+
+			require.NoError(t, json.Unmarshal(e.Payload, &v))
+			require.Len(t, v, 1)
+		}
+
+		if res.ContinuationToken == "" {
+			break
+		}
+
+		token = res.ContinuationToken
+	}
+
+	require.Equal(t, ids, got)
+	require.Equal(t, 4, pages)
+
+	// payloads larger than the limit are not returned.
+	res, err := mgr.ListEntries(ctx, map[string]string{"type": "other"}, ListOptions{MaxPayloadSize: 10})
+	require.NoError(t, err)
+	require.Len(t, res.Entries, 1)
+	require.Nil(t, res.Entries[0].Payload)
+	require.Positive(t, res.Entries[0].Length)
+
+	// filter by ID prefix.
+	res, err = mgr.ListEntries(ctx, nil, ListOptions{IDPrefix: ids[3]})
+	require.NoError(t, err)
+	require.Len(t, res.Entries, 1)
+	require.Equal(t, ids[3], res.Entries[0].ID)
+	require.Empty(t, res.ContinuationToken)
+}
@@ -6,6 +6,7 @@
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
+	"io"
 	"sort"
 	"sync"
 	"time"
@@ -48,6 +49,7 @@ type contentManager interface {
 	DisableIndexFlush(ctx context.Context)
 	EnableIndexFlush(ctx context.Context)
 	Flush(ctx context.Context) error
+	Refresh(ctx context.Context) error
 	IsReadOnly() bool
 }
 
@@ -62,9 +64,13 @@ type Manager struct {
 	// +checklocks:mu
 	pendingEntries map[ID]*manifestEntry
 
+	// +checklocks:mu
+	preconditions []precondition
+
 	committed *committedManifestManager
 
-	timeNow func() time.Time // Time provider
+	timeNow    func() time.Time // Time provider
+	randReader io.Reader        // Source of randomness for manifest IDs
 }
 
 // Put serializes the provided payload to JSON and persists it. Returns unique identifier that represents the manifest.
@@ -73,28 +79,36 @@ func (m *Manager) Put(ctx context.Context, labels map[string]string, payload int
 		return "", errors.Errorf("'type' label is required")
 	}
 
+	e, err := m.newEntry(labels, payload)
+	if err != nil {
+		return "", err
+	}
+
+	m.mu.Lock()
+	m.pendingEntries[e.ID] = e
+	m.mu.Unlock()
+
+	return e.ID, nil
+}
+
+// newEntry creates a new manifest entry with a random ID and the provided labels and serialized payload.
+func (m *Manager) newEntry(labels map[string]string, payload interface{}) (*manifestEntry, error) {
 	random := make([]byte, manifestIDLength)
-	if _, err := rand.Read(random); err != nil {
-		return "", errors.Wrap(err, "can't initialize randomness")
+	if _, err := io.ReadFull(m.randReader, random); err != nil {
+		return nil, errors.Wrap(err, "can't initialize randomness")
 	}
 
 	b, err := json.Marshal(payload)
 	if err != nil {
-		return "", errors.Wrap(err, "marshal error")
+		return nil, errors.Wrap(err, "marshal error")
 	}
 
-	e := &manifestEntry{
+	return &manifestEntry{
 		ID:      ID(hex.EncodeToString(random)),
 		ModTime: m.timeNow().UTC(),
 		Labels:  copyLabels(labels),
 		Content: b,
-	}
-
-	m.mu.Lock()
-	m.pendingEntries[e.ID] = e
-	m.mu.Unlock()
-
-	return e.ID, nil
+	}, nil
 }
 
 // GetMetadata returns metadata about provided manifest item or ErrNotFound if the item can't be found.
@@ -167,6 +181,13 @@ func (m *Manager) Find(ctx context.Context, labels map[string]string) ([]*EntryM
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	return m.findLocked(committedMatches, labels), nil
+}
+
+// findLocked returns metadata for pending and provided committed manifests matching the labels.
+//
+// +checklocks:m.mu
+func (m *Manager) findLocked(committedMatches map[ID]*manifestEntry, labels map[string]string) []*EntryMetadata {
 	var matches []*EntryMetadata
 
 	for _, e := range findEntriesMatchingLabels(m.pendingEntries, labels) {
@@ -186,7 +207,7 @@ func (m *Manager) Find(ctx context.Context, labels map[string]string) ([]*EntryM
 		return matches[i].ModTime.Before(matches[j].ModTime)
 	})
 
-	return matches, nil
+	return matches
 }
 
 func cloneEntryMetadata(e *manifestEntry) *EntryMetadata {
@@ -214,9 +235,23 @@ func (m *Manager) Flush(ctx context.Context) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	_, err := m.committed.commitEntries(ctx, m.pendingEntries)
+	if err := m.verifyPreconditionsLocked(ctx); err != nil {
+		if errors.Is(err, ErrConflict) {
+			// none of the pending changes will be written, discard them so that the manager can be reused.
+			m.pendingEntries = map[ID]*manifestEntry{}
+			m.preconditions = nil
+		}
+
+		return err
+	}
 
-	return err
+	if _, err := m.committed.commitEntries(ctx, m.pendingEntries); err != nil {
+		return err
+	}
+
+	m.preconditions = nil
+
+	return nil
 }
 
 func mustSucceed(e error) {
@@ -248,6 +283,43 @@ func (m *Manager) Delete(ctx context.Context, id ID) error {
 	return nil
 }
 
+// PendingState holds pending changes to a set of manifests captured by CapturePending.
+type PendingState map[ID]*manifestEntry
+
+// CapturePending captures pending changes to the provided manifests, so that they can be restored
+// using RestorePending if a subsequent operation needs to be rolled back.
+func (m *Manager) CapturePending(ids ...ID) PendingState {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s := PendingState{}
+
+	for _, id := range ids {
+		s[id] = m.pendingEntries[id]
+	}
+
+	return s
+}
+
+// RestorePending restores pending changes to the manifests captured in the provided state and discards
+// pending changes to the additional manifests that did not exist when the state was captured.
+func (m *Manager) RestorePending(s PendingState, discard ...ID) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, e := range s {
+		if e == nil {
+			delete(m.pendingEntries, id)
+		} else {
+			m.pendingEntries[id] = e
+		}
+	}
+
+	for _, id := range discard {
+		delete(m.pendingEntries, id)
+	}
+}
+
 // Compact performs compaction of manifest contents.
 func (m *Manager) Compact(ctx context.Context) error {
 	return m.committed.compact(ctx)
@@ -287,6 +359,7 @@ func copyLabels(m map[string]string) map[string]string {
 // ManagerOptions are optional parameters for Manager creation.
 type ManagerOptions struct {
 	TimeNow                 func() time.Time // Time provider
+	RandReader              io.Reader        // Source of randomness, defaults to crypto/rand.Reader
 	AutoCompactionThreshold int
 }
 
@@ -299,6 +372,11 @@ func NewManager(ctx context.Context, b contentManager, options ManagerOptions, m
 		timeNow = clock.Now
 	}
 
+	randReader := options.RandReader
+	if randReader == nil {
+		randReader = rand.Reader
+	}
+
 	autoCompactionThreshold := options.AutoCompactionThreshold
 	if autoCompactionThreshold == 0 {
 		autoCompactionThreshold = autoCompactionContentCountDefault
@@ -308,6 +386,7 @@ func NewManager(ctx context.Context, b contentManager, options ManagerOptions, m
 		b:              b,
 		pendingEntries: map[ID]*manifestEntry{},
 		timeNow:        timeNow,
+		randReader:     randReader,
 		committed:      newCommittedManager(b, autoCompactionThreshold),
 	}
 
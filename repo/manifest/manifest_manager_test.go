@@ -3,6 +3,7 @@
 import (
 	"context"
 	"encoding/json"
+	"math/rand"
 	"reflect"
 	"sort"
 	"strings"
@@ -488,3 +489,22 @@ func TestManifestAutoCompactionWithReadOnly(t *testing.T) {
 	_, err = mgr.Find(ctx, map[string]string{"color": "red"})
 	require.NoError(t, err, "forcing reload of manifest manager")
 }
+
+func TestManifestIDsUseInjectedRandomness(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	var ids []ID
+
+	for range 2 {
+		mgr := newManagerForTesting(ctx, t, blobtesting.DataMap{}, ManagerOptions{
+			RandReader: rand.New(rand.NewSource(1)),
+		})
+
+		id, err := mgr.Put(ctx, map[string]string{"type": "foo"}, "payload")
+		require.NoError(t, err)
+
+		ids = append(ids, id)
+	}
+
+	require.Equal(t, ids[0], ids[1])
+}
@@ -19,10 +19,15 @@
 // ErrObjectNotFound is returned when an object cannot be found.
 var ErrObjectNotFound = errors.New("object not found")
 
+// compressionNone is the compressor name that explicitly disables compression.
+const compressionNone compression.Name = "none"
+
 // Reader allows reading, seeking, getting the length of and closing of a repository object.
+// Seeking and ReadAt() only fetch the chunks covering the requested range.
 type Reader interface {
 	io.Reader
 	io.Seeker
+	io.ReaderAt
 	io.Closer
 	Length() int64
 }
@@ -69,7 +74,7 @@ func (om *Manager) NewWriter(ctx context.Context, opt WriterOptions) Writer {
 
 	w.description = opt.Description
 	w.prefix = opt.Prefix
-	w.compressor = compression.ByName[opt.Compressor]
+	w.compressor = compression.ByName[om.compressorName(opt.Compressor)]
 	w.totalLength = 0
 	w.currentPosition = 0
 
@@ -237,5 +242,20 @@ func NewObjectManager(ctx context.Context, bm contentManager, f format.ObjectFor
 
 	om.newDefaultSplitter = os
 
+	if c := f.DefaultCompression; c != "" && c != compressionNone && compression.ByName[c] == nil {
+		return nil, errors.Errorf("unsupported default compression %q", c)
+	}
+
 	return om, nil
 }
+
+// compressorName returns the name of the compressor to use given the one requested by the writer,
+// falling back to repository default when not specified. "none" disables compression even if
+// the repository has a default compressor.
+func (om *Manager) compressorName(requested compression.Name) compression.Name {
+	if requested == "" {
+		return om.Format.DefaultCompression
+	}
+
+	return requested
+}
@@ -12,6 +12,7 @@
 	"runtime"
 	"runtime/debug"
 	"sync"
+	"sync/atomic"
 	"testing"
 
 	"github.com/pkg/errors"
@@ -255,6 +256,48 @@ func TestCompression_ContentCompressionDisabled(t *testing.T) {
 	require.True(t, isCompressed) // oid will indicate compression
 }
 
+func TestCompression_DefaultCompression(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	cmap := map[content.ID]compression.HeaderID{}
+	fcm := &fakeContentManager{
+		data:                       map[content.ID][]byte{},
+		supportsContentCompression: true,
+		compresionIDs:              cmap,
+	}
+
+	om, err := NewObjectManager(ctx, fcm, format.ObjectFormat{
+		Splitter:           "FIXED-1M",
+		DefaultCompression: "zstd",
+	}, nil)
+	require.NoError(t, err)
+
+	writeAndGetCompressor := func(comp compression.Name) compression.HeaderID {
+		w := om.NewWriter(ctx, WriterOptions{Compressor: comp})
+		w.Write(bytes.Repeat([]byte{1, 2, 3, 4}, 1000))
+		oid, err := w.Result()
+		require.NoError(t, err)
+
+		cid, _, ok := oid.ContentID()
+		require.True(t, ok)
+
+		return cmap[cid]
+	}
+
+	// repository default is used when the writer does not specify a compressor.
+	require.Equal(t, compression.ByName["zstd"].HeaderID(), writeAndGetCompressor(""))
+	require.Equal(t, compression.ByName["gzip"].HeaderID(), writeAndGetCompressor("gzip"))
+
+	// "none" disables compression.
+	cmap = map[content.ID]compression.HeaderID{}
+	fcm.compresionIDs = cmap
+
+	require.Equal(t, compression.HeaderID(0), writeAndGetCompressor("none"))
+
+	_, err = NewObjectManager(ctx, fcm, format.ObjectFormat{DefaultCompression: "no-such-compressor"}, nil)
+	require.ErrorContains(t, err, "unsupported default compression")
+}
+
 func TestWriterCompleteChunkInTwoWrites(t *testing.T) {
 	ctx := testlogging.Context(t)
 	_, _, om := setupTest(t, nil)
@@ -858,6 +901,16 @@ func verify(ctx context.Context, t *testing.T, cr contentReader, objectID ID, ex
 			if !bytes.Equal(expected, got) {
 				t.Errorf("incorrect data read for %v: expected: %x, got: %x", testCaseID, expected, got)
 			}
+
+			gotAt := make([]byte, sampleSize)
+
+			if n, err := reader.ReadAt(gotAt, int64(seekOffset)); err != nil || n != sampleSize {
+				t.Errorf("invalid ReadAt data: n=%v, expected=%v, err:%v", n, sampleSize, err)
+			}
+
+			if !bytes.Equal(expected, gotAt) {
+				t.Errorf("incorrect data read using ReadAt for %v: expected: %x, got: %x", testCaseID, expected, gotAt)
+			}
 		}
 	}
 }
@@ -913,6 +966,100 @@ func TestSeek(t *testing.T) {
 	}
 }
 
+type countingContentReader struct {
+	contentReader
+
+	getContentCount atomic.Int32
+}
+
+func (c *countingContentReader) GetContent(ctx context.Context, contentID content.ID) ([]byte, error) {
+	c.getContentCount.Add(1)
+
+	return c.contentReader.GetContent(ctx, contentID)
+}
+
+func TestReadAt(t *testing.T) {
+	ctx := testlogging.Context(t)
+	_, fcm, om := setupTest(t, nil)
+
+	const chunkSize = 1 << 20
+
+	randomData := make([]byte, 5*chunkSize)
+	cryptorand.Read(randomData)
+
+	writer := om.NewWriter(ctx, WriterOptions{})
+	_, err := writer.Write(randomData)
+	require.NoError(t, err)
+
+	objectID, err := writer.Result()
+	require.NoError(t, err)
+
+	cr := &countingContentReader{contentReader: fcm}
+
+	r, err := Open(ctx, cr, objectID)
+	require.NoError(t, err)
+
+	defer r.Close()
+
+	cr.getContentCount.Store(0)
+
+	// only the chunk covering the range is fetched.
+	buf := make([]byte, 10)
+	n, err := r.ReadAt(buf, 2*chunkSize+500)
+	require.NoError(t, err)
+	require.Equal(t, 10, n)
+	require.Equal(t, randomData[2*chunkSize+500:2*chunkSize+510], buf)
+	require.EqualValues(t, 1, cr.getContentCount.Load())
+
+	// range spanning chunk boundary.
+	cr.getContentCount.Store(0)
+	n, err = r.ReadAt(buf, chunkSize-5)
+	require.NoError(t, err)
+	require.Equal(t, 10, n)
+	require.Equal(t, randomData[chunkSize-5:chunkSize+5], buf)
+	require.EqualValues(t, 2, cr.getContentCount.Load())
+
+	// short read at the end of the object.
+	n, err = r.ReadAt(buf, int64(len(randomData))-3)
+	require.ErrorIs(t, err, io.EOF)
+	require.Equal(t, 3, n)
+	require.Equal(t, randomData[len(randomData)-3:], buf[:3])
+
+	n, err = r.ReadAt(buf, int64(len(randomData)))
+	require.ErrorIs(t, err, io.EOF)
+	require.Equal(t, 0, n)
+
+	_, err = r.ReadAt(buf, -1)
+	require.Error(t, err)
+
+	// ReadAt does not affect the current position.
+	n, err = r.Read(buf)
+	require.NoError(t, err)
+	require.Equal(t, 10, n)
+	require.Equal(t, randomData[:10], buf)
+
+	// concurrent reads.
+	var eg errgroup.Group
+
+	for i := range 5 {
+		eg.Go(func() error {
+			b := make([]byte, 1000)
+
+			if _, err := r.ReadAt(b, int64(i*chunkSize+100)); err != nil {
+				return err
+			}
+
+			if !bytes.Equal(randomData[i*chunkSize+100:i*chunkSize+1100], b) {
+				return errors.Errorf("invalid data read at chunk %v", i)
+			}
+
+			return nil
+		})
+	}
+
+	require.NoError(t, eg.Wait())
+}
+
 func TestWriterFlushFailure_OnWrite(t *testing.T) {
 	_, fcm, om := setupTest(t, nil)
 
@@ -8,6 +8,7 @@
 
 	"github.com/pkg/errors"
 
+	"github.com/kopia/kopia/internal/gather"
 	"github.com/kopia/kopia/repo/compression"
 	"github.com/kopia/kopia/repo/content"
 )
@@ -49,6 +50,9 @@ type objectReader struct {
 	currentChunkIndex    int    // Index of current chunk in the seek table
 	currentChunkData     []byte // Current chunk data
 	currentChunkPosition int    // Read position in the current chunk
+
+	// chunkBuf holds pooled memory backing currentChunkData, reused for all chunks of the object.
+	chunkBuf gather.WriteBuffer
 }
 
 func (r *objectReader) Read(buffer []byte) (int, error) {
@@ -106,24 +110,70 @@ func (r *objectReader) Read(buffer []byte) (int, error) {
 func (r *objectReader) openCurrentChunk() error {
 	st := r.seekTable[r.currentChunkIndex]
 
-	rd, err := openAndAssertLength(r.ctx, r.cr, st.Object, st.Length)
+	b, err := r.readChunk(st, &r.chunkBuf)
 	if err != nil {
 		return err
 	}
 
-	defer rd.Close() //nolint:errcheck
-
-	b := make([]byte, st.Length)
-	if _, err := io.ReadFull(rd, b); err != nil {
-		return errors.Wrap(err, "error reading chunk")
-	}
-
 	r.currentChunkData = b
 	r.currentChunkPosition = 0
 
 	return nil
 }
 
+// readChunk returns the contents of the provided chunk, avoiding per-chunk allocations where possible.
+// The returned slice may be backed by buf and is only valid until the next call using the same buffer.
+func (r *objectReader) readChunk(st IndirectObjectEntry, buf *gather.WriteBuffer) ([]byte, error) {
+	contentID, compressed, ok := st.Object.ContentID()
+	if !ok {
+		// nested indirect object, read it into pooled buffer.
+		rd, err := openAndAssertLength(r.ctx, r.cr, st.Object, st.Length)
+		if err != nil {
+			return nil, err
+		}
+
+		defer rd.Close() //nolint:errcheck
+
+		b := buf.MakeContiguous(int(st.Length))
+		if _, err := io.ReadFull(rd, b); err != nil {
+			return nil, errors.Wrap(err, "error reading chunk")
+		}
+
+		return b, nil
+	}
+
+	payload, err := r.cr.GetContent(r.ctx, contentID)
+	if errors.Is(err, content.ErrContentNotFound) {
+		return nil, errors.Wrapf(ErrObjectNotFound, "content %v not found", contentID)
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "unexpected content error")
+	}
+
+	if compressed {
+		// decompress directly into pooled buffer of the expected size.
+		out := bytes.NewBuffer(buf.MakeContiguous(int(st.Length))[:0])
+
+		if err = compression.DecompressByHeader(out, bytes.NewReader(payload)); err != nil {
+			return nil, errors.Wrap(err, "decompression error")
+		}
+
+		payload = out.Bytes()
+	}
+
+	if int64(len(payload)) != st.Length {
+		return nil, errors.Errorf("unexpected chunk length %v, expected %v", len(payload), st.Length)
+	}
+
+	if payload == nil {
+		// empty chunks must still be non-nil to be considered open.
+		payload = []byte{}
+	}
+
+	return payload, nil
+}
+
 func (r *objectReader) closeCurrentChunk() {
 	r.currentChunkData = nil
 }
@@ -192,7 +242,48 @@ func (r *objectReader) Seek(offset int64, whence int) (int64, error) {
 	return r.currentPosition, nil
 }
 
+// ReadAt reads len(p) bytes starting at the provided offset, fetching only the chunks which overlap
+// the requested range. It does not change the current read position and is safe for concurrent use.
+func (r *objectReader) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.Errorf("invalid offset %v", off)
+	}
+
+	// chunks read by ReadAt use a separate buffer, so that concurrent calls don't interfere with each other.
+	var buf gather.WriteBuffer
+	defer buf.Close()
+
+	n := 0
+
+	for n < len(p) && off < r.totalLength {
+		index, err := r.findChunkIndexForOffset(off)
+		if err != nil {
+			return n, errors.Wrapf(err, "invalid read at %v", off)
+		}
+
+		st := r.seekTable[index]
+
+		data, err := r.readChunk(st, &buf)
+		if err != nil {
+			return n, err
+		}
+
+		copied := copy(p[n:], data[off-st.Start:])
+		n += copied
+		off += int64(copied)
+	}
+
+	if n < len(p) {
+		return n, io.EOF
+	}
+
+	return n, nil
+}
+
 func (r *objectReader) Close() error {
+	r.currentChunkData = nil
+	r.chunkBuf.Close()
+
 	return nil
 }
 
@@ -314,7 +405,7 @@ func newRawReader(ctx context.Context, cr contentReader, objectID ID, assertLeng
 }
 
 type readerWithData struct {
-	io.ReadSeeker
+	*bytes.Reader
 	length int64
 }
 
@@ -328,7 +419,7 @@ func (rwd *readerWithData) Length() int64 {
 
 func newObjectReaderWithData(data []byte) Reader {
 	return &readerWithData{
-		ReadSeeker: bytes.NewReader(data),
-		length:     int64(len(data)),
+		Reader: bytes.NewReader(data),
+		length: int64(len(data)),
 	}
 }
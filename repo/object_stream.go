@@ -0,0 +1,31 @@
+package repo
+
+import (
+	"context"
+	"io"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/iocopy"
+	"github.com/kopia/kopia/repo/object"
+)
+
+// WriteObjectFromReader writes the data read from the provided reader as a new object and returns its ID.
+//
+// The data is split into contents that are compressed and encrypted independently as they are read,
+// so the object is never buffered in memory in full. Use OpenObject() to read it back in the same fashion.
+func WriteObjectFromReader(ctx context.Context, rep RepositoryWriter, r io.Reader, opt object.WriterOptions) (object.ID, error) {
+	w := rep.NewObjectWriter(ctx, opt)
+	defer w.Close() //nolint:errcheck
+
+	if _, err := iocopy.Copy(w, r); err != nil {
+		return object.EmptyID, errors.Wrap(err, "error writing object")
+	}
+
+	oid, err := w.Result()
+	if err != nil {
+		return object.EmptyID, errors.Wrap(err, "error writing object")
+	}
+
+	return oid, nil
+}
@@ -3,6 +3,7 @@
 import (
 	"context"
 	"encoding/json"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -21,7 +22,9 @@
 	"github.com/kopia/kopia/repo/blob"
 	"github.com/kopia/kopia/repo/blob/beforeop"
 	loggingwrapper "github.com/kopia/kopia/repo/blob/logging"
+	"github.com/kopia/kopia/repo/blob/parallellist"
 	"github.com/kopia/kopia/repo/blob/readonly"
+	"github.com/kopia/kopia/repo/blob/splitblob"
 	"github.com/kopia/kopia/repo/blob/storagemetrics"
 	"github.com/kopia/kopia/repo/blob/throttling"
 	"github.com/kopia/kopia/repo/content"
@@ -46,6 +49,8 @@
 var supportedFeatures = []feature.Feature{
 	"index-v1",
 	"index-v2",
+	format.SplitBlobsFeature,
+	format.KeySlotsFeature,
 }
 
 // throttlingWindow is the duration window during which the throttling token bucket fully replenishes.
@@ -67,10 +72,13 @@
 type Options struct {
 	TraceStorage        bool                       // Logs all storage access using provided Printf-style function
 	TimeNowFunc         func() time.Time           // Time provider
+	RandReader          io.Reader                  // Source of randomness for identifiers, defaults to crypto/rand.Reader
 	DisableInternalLog  bool                       // Disable internal log
 	UpgradeOwnerID      string                     // Owner-ID of any upgrade in progress, when this is not set the access may be restricted
 	DoNotWaitForUpgrade bool                       // Disable the exponential forever backoff on an upgrade lock.
 	BeforeFlush         []RepositoryWriterCallback // list of callbacks to invoke before every flush
+	OpenTimeout         time.Duration              // Maximum time to wait for the repository to open, zero means no limit
+	ReadOnly            bool                       // Open the repository in read-only mode regardless of connection configuration
 
 	OnFatalError func(err error) // function to invoke when repository encounters a fatal error, usually invokes os.Exit
 
@@ -88,6 +96,12 @@ type Options struct {
 // is undergoing upgrade that requires exclusive access.
 var ErrRepositoryUnavailableDueToUpgradeInProgress = errors.Errorf("repository upgrade in progress")
 
+// ErrReadOnly is returned when attempting to write to a repository opened in read-only mode.
+var ErrReadOnly = readonly.ErrReadonly
+
+// ErrOpenTimeout is returned when the repository could not be opened within Options.OpenTimeout.
+var ErrOpenTimeout = errors.New("timed out opening repository")
+
 // Open opens a Repository specified in the configuration file.
 func Open(ctx context.Context, configFile, password string, options *Options) (rep Repository, err error) {
 	ctx, span := tracer.Start(ctx, "OpenRepository")
@@ -103,6 +117,10 @@ func Open(ctx context.Context, configFile, password string, options *Options) (r
 		options = &Options{}
 	}
 
+	if options.OpenTimeout > 0 {
+		return openWithTimeout(ctx, configFile, password, options)
+	}
+
 	if options.OnFatalError == nil {
 		options.OnFatalError = func(err error) {
 			log(ctx).Errorf("FATAL: %v", err)
@@ -120,6 +138,10 @@ func Open(ctx context.Context, configFile, password string, options *Options) (r
 		return nil, err
 	}
 
+	if options.ReadOnly {
+		lc.ReadOnly = true
+	}
+
 	if lc.PermissiveCacheLoading && !lc.ReadOnly {
 		return nil, ErrCannotWriteToRepoConnectionWithPermissiveCacheLoading
 	}
@@ -131,6 +153,61 @@ func Open(ctx context.Context, configFile, password string, options *Options) (r
 	return openDirect(ctx, configFile, lc, password, options)
 }
 
+// openWithTimeout opens the repository in a separate goroutine and gives up after options.OpenTimeout,
+// canceling the context passed to the storage so that any in-flight operations are aborted.
+func openWithTimeout(ctx context.Context, configFile, password string, options *Options) (Repository, error) {
+	type openResult struct {
+		rep Repository
+		err error
+	}
+
+	opt := *options
+	opt.OpenTimeout = 0
+
+	// the context is retained by the repository, so it can only be canceled when opening fails.
+	openCtx, cancel := context.WithCancel(ctx)
+	timer := time.AfterFunc(options.OpenTimeout, cancel)
+
+	result := make(chan openResult, 1)
+
+	go func() {
+		rep, err := Open(openCtx, configFile, password, &opt)
+		result <- openResult{rep, err}
+	}()
+
+	select {
+	case r := <-result:
+		if timer.Stop() {
+			return r.rep, r.err
+		}
+
+		// timer fired concurrently with open completing.
+		closeRepositoryIfOpened(ctx, r.rep, r.err)
+
+	case <-openCtx.Done():
+		go func() {
+			r := <-result
+			closeRepositoryIfOpened(context.WithoutCancel(ctx), r.rep, r.err)
+		}()
+	}
+
+	if ctx.Err() != nil {
+		return nil, errors.Wrap(ctx.Err(), "error opening repository")
+	}
+
+	return nil, errors.Wrapf(ErrOpenTimeout, "repository did not open within %v", options.OpenTimeout)
+}
+
+func closeRepositoryIfOpened(ctx context.Context, rep Repository, err error) {
+	if err != nil {
+		return
+	}
+
+	if cerr := rep.Close(ctx); cerr != nil {
+		log(ctx).Debugf("error closing repository after timeout: %v", cerr)
+	}
+}
+
 func getContentCacheOrNil(ctx context.Context, si *APIServerInfo, opt *content.CachingOptions, password string, mr *metrics.Registry, timeNow func() time.Time) (*cache.PersistentCache, error) {
 	opt = opt.CloneOrDefault()
 
@@ -167,6 +244,25 @@ func getContentCacheOrNil(ctx context.Context, si *APIServerInfo, opt *content.C
 	return pc, nil
 }
 
+// getBlobCacheOrNil returns the persistent cache of full pack blobs or nil if not configured.
+func getBlobCacheOrNil(ctx context.Context, opt *content.CachingOptions, mr *metrics.Registry, timeNow func() time.Time) (*cache.PersistentCache, error) {
+	cs, err := cache.NewStorageOrNil(ctx, opt.CacheDirectory, opt.BlobCacheSizeBytes, "blobs")
+	if cs == nil {
+		// this may be (nil, nil) or (nil, err)
+		return nil, errors.Wrap(err, "error opening storage")
+	}
+
+	pc, err := cache.NewPersistentCache(ctx, "blob-cache", cs, cacheprot.ChecksumProtection(opt.HMACSecret), cache.SweepSettings{
+		MaxSizeBytes: opt.BlobCacheSizeBytes,
+		MinSweepAge:  opt.MinContentSweepAge.DurationOrDefault(content.DefaultDataCacheSweepAge),
+	}, mr, timeNow)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to open persistent cache")
+	}
+
+	return pc, nil
+}
+
 // openAPIServer connects remote repository over Kopia API.
 func openAPIServer(ctx context.Context, si *APIServerInfo, cliOpts ClientOptions, cachingOptions *content.CachingOptions, password string, options *Options) (Repository, error) {
 	cachingOptions = cachingOptions.CloneOrDefault()
@@ -197,7 +293,15 @@ func(ctx context.Context) error {
 		beforeFlush:      options.BeforeFlush,
 	}
 
-	return openGRPCAPIRepository(ctx, si, password, par)
+	rep, err := openGRPCAPIRepository(ctx, si, password, par)
+	if err != nil {
+		// release the content cache and metrics when the connection could not be established.
+		closer.Close(ctx) //nolint:errcheck
+
+		return nil, err
+	}
+
+	return rep, nil
 }
 
 // openDirect opens the repository that directly manipulates blob storage..
@@ -219,6 +323,8 @@ func openDirect(ctx context.Context, configFile string, lc *LocalConfig, passwor
 		st = readonly.NewWrapper(st)
 	}
 
+	st = parallellist.NewWrapper(st, lc.ListParallelism, nil)
+
 	cliOpts := lc.ApplyDefaults(ctx, "Repository in "+st.DisplayName())
 
 	r, err := openWithConfig(ctx, st, cliOpts, password, options, lc.Caching, configFile)
@@ -237,6 +343,7 @@ func openWithConfig(ctx context.Context, st blob.Storage, cliOpts ClientOptions,
 	cacheOpts = cacheOpts.CloneOrDefault()
 	cmOpts := &content.ManagerOptions{
 		TimeNow:                defaultTime(options.TimeNowFunc),
+		RandReader:             options.RandReader,
 		DisableInternalLog:     options.DisableInternalLog,
 		PermissiveCacheLoading: cliOpts.PermissiveCacheLoading,
 	}
@@ -272,14 +379,11 @@ func openWithConfig(ctx context.Context, st blob.Storage, cliOpts ClientOptions,
 	}
 
 	throttler.OnUpdate(func(l throttling.Limits) error {
-		lc2, err2 := LoadConfigFromFile(configFile)
-		if err2 != nil {
-			return err2
-		}
-
-		lc2.Throttling = &l
-
-		return lc2.writeToFile(configFile)
+		// limits may be updated long after the repository has been opened and ctx canceled.
+		return updateConfigFile(context.WithoutCancel(ctx), configFile, func(lc2 *LocalConfig) error {
+			lc2.Throttling = &l
+			return nil
+		})
 	})
 
 	blobcfg, err := fmgr.BlobCfgBlob(ctx)
@@ -291,6 +395,17 @@ func openWithConfig(ctx context.Context, st blob.Storage, cliOpts ClientOptions,
 		st = wrapLockingStorage(st, blobcfg)
 	}
 
+	if blobcfg.MaxBlobSize > 0 {
+		st = splitblob.NewWrapper(st, blobcfg.MaxBlobSize)
+	}
+
+	blobCache, err := getBlobCacheOrNil(ctx, cacheOpts, mr, cmOpts.TimeNow)
+	if err != nil {
+		return nil, errors.Wrap(err, "error opening blob cache")
+	}
+
+	st = cache.NewBlobCachingWrapper(st, blobCache, []blob.ID{content.PackBlobIDPrefixRegular, content.PackBlobIDPrefixSpecial})
+
 	_, err = retry.WithExponentialBackoffMaxRetries(ctx, -1, "wait for upgrade", func() (interface{}, error) {
 		uli, err := fmgr.UpgradeLockIntent(ctx)
 		if err != nil {
@@ -336,7 +451,7 @@ func openWithConfig(ctx context.Context, st blob.Storage, cliOpts ClientOptions,
 		return nil, errors.Wrap(ferr, "unable to open object manager")
 	}
 
-	manifests, ferr := manifest.NewManager(ctx, cm, manifest.ManagerOptions{TimeNow: cmOpts.TimeNow}, mr)
+	manifests, ferr := manifest.NewManager(ctx, cm, manifest.ManagerOptions{TimeNow: cmOpts.TimeNow, RandReader: cmOpts.RandReader}, mr)
 	if ferr != nil {
 		return nil, errors.Wrap(ferr, "unable to open manifests")
 	}
@@ -344,6 +459,10 @@ func openWithConfig(ctx context.Context, st blob.Storage, cliOpts ClientOptions,
 	closer := newRefCountedCloser(
 		scm.CloseShared,
 		dw.Wait,
+		func(ctx context.Context) error {
+			blobCache.Close(ctx)
+			return nil
+		},
 		mr.Close,
 		st.Close,
 	)
@@ -358,6 +477,7 @@ func openWithConfig(ctx context.Context, st blob.Storage, cliOpts ClientOptions,
 			cachingOptions:   *cacheOpts,
 			fmgr:             fmgr,
 			timeNow:          cmOpts.TimeNow,
+			randReader:       cmOpts.RandReader,
 			cliOpts:          cliOpts,
 			configFile:       configFile,
 			nextWriterID:     new(int32),
@@ -368,6 +488,8 @@ func openWithConfig(ctx context.Context, st blob.Storage, cliOpts ClientOptions,
 		},
 	}
 
+	fmgr.SetCredentialChangeCallback(dr.recordCredentialChange)
+
 	return dr, nil
 }
 
@@ -0,0 +1,94 @@
+package repo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/blobtesting"
+	"github.com/kopia/kopia/internal/feature"
+	"github.com/kopia/kopia/internal/testlogging"
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/repo/content"
+	"github.com/kopia/kopia/repo/format"
+	"github.com/kopia/kopia/repo/object"
+)
+
+func TestOpenSplitBlobsRequiresFeature(t *testing.T) {
+	ctx := testlogging.Context(t)
+	st := blobtesting.NewMapStorage(blobtesting.DataMap{}, nil, nil)
+
+	require.NoError(t, Initialize(ctx, st, &NewRepositoryOptions{
+		MaxBlobSize: format.MinMaxBlobSize,
+	}, "password"))
+
+	configFile := filepath.Join(testutil.TempDirectory(t), "repository.config")
+
+	dr, err := openWithConfig(ctx, st, ClientOptions{}, "password", &Options{}, nil, configFile)
+	require.NoError(t, err)
+
+	rf, err := dr.FormatManager().RequiredFeatures(ctx)
+	require.NoError(t, err)
+	require.Len(t, rf, 1)
+	require.Equal(t, format.SplitBlobsFeature, rf[0].Feature)
+	require.False(t, rf[0].IfNotUnderstood.Warn)
+	require.NoError(t, dr.Close(ctx))
+
+	// simulate a client that predates split blobs.
+	oldFeatures := supportedFeatures
+	supportedFeatures = []feature.Feature{"index-v1", "index-v2"}
+
+	t.Cleanup(func() { supportedFeatures = oldFeatures })
+
+	_, err = openWithConfig(ctx, st, ClientOptions{}, "password", &Options{}, nil, configFile)
+	require.ErrorContains(t, err, "does not support feature 'split-blobs'")
+}
+
+func TestOpenWithBlobCache(t *testing.T) {
+	ctx := testlogging.Context(t)
+	data := blobtesting.DataMap{}
+	st := blobtesting.NewMapStorage(data, nil, nil)
+
+	require.NoError(t, Initialize(ctx, st, &NewRepositoryOptions{}, "password"))
+
+	td := testutil.TempDirectory(t)
+	configFile := filepath.Join(td, "repository.config")
+	cacheOpts := &content.CachingOptions{
+		CacheDirectory:         filepath.Join(td, "cache"),
+		ContentCacheSizeBytes:  1 << 20,
+		MetadataCacheSizeBytes: 1 << 20,
+		BlobCacheSizeBytes:     1 << 20,
+	}
+
+	dr, err := openWithConfig(ctx, st, ClientOptions{}, "password", &Options{}, cacheOpts, configFile)
+	require.NoError(t, err)
+
+	_, w, err := dr.NewWriter(ctx, WriteSessionOptions{})
+	require.NoError(t, err)
+
+	ow := w.NewObjectWriter(ctx, object.WriterOptions{})
+	_, err = ow.Write([]byte("hello world"))
+	require.NoError(t, err)
+
+	oid, err := ow.Result()
+	require.NoError(t, err)
+	require.NoError(t, ow.Close())
+	require.NoError(t, w.Flush(ctx))
+	require.NoError(t, w.Close(ctx))
+	require.NoError(t, dr.Close(ctx))
+
+	dr, err = openWithConfig(ctx, st, ClientOptions{}, "password", &Options{}, cacheOpts, configFile)
+	require.NoError(t, err)
+
+	defer dr.Close(ctx) //nolint:errcheck
+
+	r, err := dr.OpenObject(ctx, oid)
+	require.NoError(t, err)
+	r.Close()
+
+	entries, err := os.ReadDir(filepath.Join(cacheOpts.CacheDirectory, "blobs"))
+	require.NoError(t, err)
+	require.NotEmpty(t, entries)
+}
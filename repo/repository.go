@@ -3,6 +3,8 @@
 import (
 	"context"
 	"fmt"
+	"io"
+	"slices"
 	"sync/atomic"
 	"time"
 
@@ -81,6 +83,8 @@ type DirectRepository interface {
 	Token(password string) (string, error)
 	Throttler() throttling.SettableThrottler
 	DisableIndexRefresh()
+	ExportManifests(ctx context.Context, w io.Writer, opt manifest.ArchiveOptions) (int, error)
+	ListManifests(ctx context.Context, labels map[string]string, opt manifest.ListOptions) (*manifest.ListResult, error)
 }
 
 // DirectRepositoryWriter provides low-level write access to the repository.
@@ -89,6 +93,10 @@ type DirectRepositoryWriter interface {
 	DirectRepository
 	BlobStorage() blob.Storage
 	ContentManager() *content.WriteManager
+	ImportManifests(ctx context.Context, r io.Reader, opt manifest.ArchiveOptions) (int, error)
+	AppendAuditRecord(ctx context.Context, action, itemID, itemType string) error
+	PruneAuditLog(ctx context.Context, olderThan time.Time) (int, error)
+	CompareAndReplaceManifests(ctx context.Context, labels map[string]string, payload interface{}, expected []manifest.ID) (manifest.ID, error)
 	// SetParameters(ctx context.Context, m format.MutableParameters, blobcfg format.BlobStorageConfiguration, requiredFeatures []feature.Required) error
 	// ChangePassword(ctx context.Context, newPassword string) error
 	// GetUpgradeLockIntent(ctx context.Context) (*format.UpgradeLockIntent, error)
@@ -102,6 +110,7 @@ type immutableDirectRepositoryParameters struct {
 	cachingOptions  content.CachingOptions
 	cliOpts         ClientOptions
 	timeNow         func() time.Time
+	randReader      io.Reader
 	fmgr            *format.Manager
 	nextWriterID    *int32
 	throttler       throttling.SettableThrottler
@@ -134,6 +143,9 @@ type directRepository struct {
 	mmgr  *manifest.Manager
 	sm    *content.SharedManager
 
+	audit     auditLogState
+	auditUser string // user recorded in the audit log, defaults to the user of the connection.
+
 	afterFlush []RepositoryWriterCallback
 }
 
@@ -210,8 +222,24 @@ func (r *directRepository) GetManifest(ctx context.Context, id manifest.ID, data
 
 // PutManifest saves the given manifest payload with a set of labels.
 func (r *directRepository) PutManifest(ctx context.Context, labels map[string]string, payload interface{}) (manifest.ID, error) {
-	//nolint:wrapcheck
-	return r.mmgr.Put(ctx, labels, payload)
+	if labels[manifest.TypeLabelKey] == AuditLogManifestType {
+		return "", errors.Errorf("audit records can only be written using AppendAuditRecord")
+	}
+
+	id, err := r.mmgr.Put(ctx, labels, payload)
+	if err != nil {
+		//nolint:wrapcheck
+		return "", err
+	}
+
+	if err := r.AppendAuditRecord(ctx, AuditActionPutManifest, string(id), labels[manifest.TypeLabelKey]); err != nil {
+		// roll back, so that the change is not persisted without its audit record.
+		r.mmgr.RestorePending(nil, id)
+
+		return "", err
+	}
+
+	return id, nil
 }
 
 // ReplaceManifests saves the given manifest payload with a set of labels and replaces any previous manifests with the same labels.
@@ -219,16 +247,120 @@ func (r *directRepository) ReplaceManifests(ctx context.Context, labels map[stri
 	return replaceManifestsHelper(ctx, r, labels, payload)
 }
 
+// CompareAndReplaceManifests replaces manifests matching the provided labels with a new one, provided that
+// the set of matching manifests is exactly the expected one. Returns manifest.ErrConflict if the manifests
+// have been modified, in which case Flush() also fails if another client modified them before the changes were committed.
+func (r *directRepository) CompareAndReplaceManifests(ctx context.Context, labels map[string]string, payload interface{}, expected []manifest.ID) (manifest.ID, error) {
+	if labels[manifest.TypeLabelKey] == AuditLogManifestType {
+		return "", errors.Errorf("audit records can only be written using AppendAuditRecord")
+	}
+
+	prev := r.mmgr.CapturePending(expected...)
+
+	id, err := r.mmgr.CompareAndReplace(ctx, labels, payload, expected)
+	if err != nil {
+		//nolint:wrapcheck
+		return "", err
+	}
+
+	var items []auditItem
+
+	for _, old := range expected {
+		items = append(items, auditItem{AuditActionDeleteManifest, string(old), labels[manifest.TypeLabelKey]})
+	}
+
+	items = append(items, auditItem{AuditActionPutManifest, string(id), labels[manifest.TypeLabelKey]})
+
+	if err := r.appendAuditRecords(ctx, items...); err != nil {
+		// roll back, so that the change is not persisted without its audit records.
+		r.mmgr.RestorePending(prev, id)
+
+		return "", err
+	}
+
+	return id, nil
+}
+
 // FindManifests returns metadata for manifests matching given set of labels.
 func (r *directRepository) FindManifests(ctx context.Context, labels map[string]string) ([]*manifest.EntryMetadata, error) {
 	//nolint:wrapcheck
 	return r.mmgr.Find(ctx, labels)
 }
 
+// ListManifests returns a page of manifests matching given set of labels, optionally including their payloads.
+func (r *directRepository) ListManifests(ctx context.Context, labels map[string]string, opt manifest.ListOptions) (*manifest.ListResult, error) {
+	//nolint:wrapcheck
+	return r.mmgr.ListEntries(ctx, labels, opt)
+}
+
 // DeleteManifest deletes the manifest with a given ID.
 func (r *directRepository) DeleteManifest(ctx context.Context, id manifest.ID) error {
+	md, err := r.mmgr.GetMetadata(ctx, id)
+	if err != nil {
+		if errors.Is(err, manifest.ErrNotFound) {
+			// deleting non-existent manifest is a no-op.
+			return nil
+		}
+
+		//nolint:wrapcheck
+		return err
+	}
+
+	if md.Labels[manifest.TypeLabelKey] == AuditLogManifestType {
+		return errors.Errorf("audit records cannot be deleted")
+	}
+
+	prev := r.mmgr.CapturePending(id)
+
+	if err := r.mmgr.Delete(ctx, id); err != nil {
+		//nolint:wrapcheck
+		return err
+	}
+
+	if err := r.AppendAuditRecord(ctx, AuditActionDeleteManifest, string(id), md.Labels[manifest.TypeLabelKey]); err != nil {
+		// roll back, so that the change is not persisted without its audit record.
+		r.mmgr.RestorePending(prev)
+
+		return err
+	}
+
+	return nil
+}
+
+// ExportManifests writes all manifests to the provided writer as an encrypted archive.
+func (r *directRepository) ExportManifests(ctx context.Context, w io.Writer, opt manifest.ArchiveOptions) (int, error) {
+	// audit log records are signed and chained to the repository they were written in.
+	opt.SkipTypes = append(slices.Clone(opt.SkipTypes), AuditLogManifestType)
+
 	//nolint:wrapcheck
-	return r.mmgr.Delete(ctx, id)
+	return r.mmgr.Export(ctx, w, opt)
+}
+
+// ImportManifests adds manifests from an archive produced by ExportManifests.
+func (r *directRepository) ImportManifests(ctx context.Context, rd io.Reader, opt manifest.ArchiveOptions) (int, error) {
+	// never import foreign audit log records, they would become the head of this repository's audit chain.
+	opt.SkipTypes = append(slices.Clone(opt.SkipTypes), AuditLogManifestType)
+
+	imported, prev, err := r.mmgr.ImportPending(ctx, rd, opt)
+	if err != nil {
+		//nolint:wrapcheck
+		return 0, err
+	}
+
+	var items []auditItem
+
+	for _, md := range imported {
+		items = append(items, auditItem{AuditActionPutManifest, string(md.ID), md.Labels[manifest.TypeLabelKey]})
+	}
+
+	if err := r.appendAuditRecords(ctx, items...); err != nil {
+		// roll back, so that the imported manifests are not persisted without their audit records.
+		r.mmgr.RestorePending(prev)
+
+		return 0, err
+	}
+
+	return len(imported), nil
 }
 
 // PrefetchContents brings the requested objects into the cache.
@@ -274,13 +406,15 @@ func (r *directRepository) NewDirectWriter(ctx context.Context, opt WriteSession
 	writeManagerID := fmt.Sprintf("writer-%v:%v", atomic.AddInt32(r.nextWriterID, 1), opt.Purpose)
 
 	cmgr := content.NewWriteManager(ctx, r.sm, content.SessionOptions{
-		SessionUser: r.cliOpts.Username,
-		SessionHost: r.cliOpts.Hostname,
-		OnUpload:    opt.OnUpload,
+		SessionUser:     r.cliOpts.Username,
+		SessionHost:     r.cliOpts.Hostname,
+		OnUpload:        opt.OnUpload,
+		AsyncPackWrites: opt.AsyncPackWrites,
 	}, writeManagerID)
 
 	mmgr, err := manifest.NewManager(ctx, cmgr, manifest.ManagerOptions{
-		TimeNow: r.timeNow,
+		TimeNow:    r.timeNow,
+		RandReader: r.randReader,
 	}, r.metricsRegistry)
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "error creating manifest manager")
@@ -298,6 +432,7 @@ func (r *directRepository) NewDirectWriter(ctx context.Context, opt WriteSession
 		omgr:                                omgr,
 		mmgr:                                mmgr,
 		sm:                                  r.sm,
+		auditUser:                           opt.AuditUser,
 	}
 
 	w.addRef()
@@ -312,6 +447,11 @@ func (r *directRepository) Flush(ctx context.Context) error {
 	}
 
 	if err := r.mmgr.Flush(ctx); err != nil {
+		if errors.Is(err, manifest.ErrConflict) {
+			// pending audit records have been discarded along with other manifests.
+			r.audit.reset()
+		}
+
 		return errors.Wrap(err, "error flushing manifests")
 	}
 
@@ -326,6 +466,13 @@ func (r *directRepository) Flush(ctx context.Context) error {
 	return nil
 }
 
+// Close waits for packs still being written in the background and releases the reference to the repository.
+func (r *directRepository) Close(ctx context.Context) error {
+	r.cmgr.WaitForBackgroundWrites()
+
+	return r.refCountedCloser.Close(ctx)
+}
+
 // Metrics provides access to metrics registry.
 func (r *directRepository) Metrics() *metrics.Registry {
 	return r.metricsRegistry
@@ -384,9 +531,11 @@ func (r *directRepository) OnSuccessfulFlush(callback RepositoryWriterCallback)
 
 // WriteSessionOptions describes options for a write session.
 type WriteSessionOptions struct {
-	Purpose        string
-	FlushOnFailure bool        // whether to flush regardless of write session result.
-	OnUpload       func(int64) // function to invoke after completing each upload in the session.
+	Purpose         string
+	FlushOnFailure  bool        // whether to flush regardless of write session result.
+	OnUpload        func(int64) // function to invoke after completing each upload in the session.
+	AsyncPackWrites int         // maximum number of full packs uploaded in the background, 0 == upload synchronously.
+	AuditUser       string      // user@host recorded in the audit log for changes made in the session.
 }
 
 // WriteSession executes the provided callback in a repository writer created for the purpose and flushes writes.
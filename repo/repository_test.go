@@ -3,17 +3,22 @@
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"fmt"
 	"io"
 	"math/rand"
+	"path/filepath"
 	"runtime/debug"
 	"strings"
 	"sync/atomic"
 	"testing"
+	"testing/iotest"
 	"time"
 
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
 
+	"github.com/kopia/kopia/internal/blobtesting"
 	"github.com/kopia/kopia/internal/cache"
 	"github.com/kopia/kopia/internal/crypto"
 	"github.com/kopia/kopia/internal/epoch"
@@ -29,6 +34,7 @@
 	"github.com/kopia/kopia/repo/content"
 	"github.com/kopia/kopia/repo/content/indexblob"
 	"github.com/kopia/kopia/repo/format"
+	"github.com/kopia/kopia/repo/manifest"
 	"github.com/kopia/kopia/repo/object"
 )
 
@@ -537,6 +543,76 @@ func(ctx context.Context, id blob.ID) error {
 		"unexpected error when checking for format blob: unexpected error")
 }
 
+func TestMaxBlobSizeSplitsLargeBlobs(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant, repotesting.Options{
+		NewRepositoryOptions: func(n *repo.NewRepositoryOptions) {
+			n.MaxBlobSize = format.MinMaxBlobSize
+		},
+	})
+
+	data := make([]byte, 3*format.MinMaxBlobSize)
+	rand.Read(data)
+
+	oid := writeObject(ctx, t, env.RepositoryWriter, data, "large")
+	require.NoError(t, env.RepositoryWriter.Flush(ctx))
+
+	packs, err := blob.ListAllBlobs(ctx, env.RootStorage(), content.PackBlobIDPrefixRegular)
+	require.NoError(t, err)
+
+	var chunked int
+
+	for _, bm := range packs {
+		require.LessOrEqual(t, bm.Length, int64(format.MinMaxBlobSize))
+
+		if strings.HasSuffix(string(bm.BlobID), ".chunks") {
+			chunked++
+		}
+	}
+
+	require.Positive(t, chunked)
+
+	env.MustReopen(t)
+	verify(ctx, t, env.RepositoryWriter, oid, data, "large")
+
+	// splitting can't be configured below the minimum.
+	require.ErrorContains(t, repo.Initialize(ctx, blobtesting.NewMapStorage(blobtesting.DataMap{}, nil, nil), &repo.NewRepositoryOptions{
+		MaxBlobSize: 1000,
+	}, env.Password), "invalid max-blob-size")
+}
+
+func TestWriteObjectFromReader(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant, repotesting.Options{})
+
+	const length = 20 << 20
+
+	h := sha256.New()
+
+	oid, err := repo.WriteObjectFromReader(ctx, env.RepositoryWriter, io.TeeReader(io.LimitReader(rand.New(rand.NewSource(1)), length), h), object.WriterOptions{})
+	require.NoError(t, err)
+	require.NoError(t, env.RepositoryWriter.Flush(ctx))
+
+	// large objects are stored as multiple contents.
+	_, isIndex := oid.IndexObjectID()
+	require.True(t, isIndex)
+
+	r, err := env.RepositoryWriter.OpenObject(ctx, oid)
+	require.NoError(t, err)
+
+	defer r.Close()
+
+	h2 := sha256.New()
+
+	n, err := io.Copy(h2, r)
+	require.NoError(t, err)
+	require.EqualValues(t, length, n)
+	require.Equal(t, h.Sum(nil), h2.Sum(nil))
+
+	errRead := errors.New("read error")
+
+	_, err = repo.WriteObjectFromReader(ctx, env.RepositoryWriter, iotest.ErrReader(errRead), object.WriterOptions{})
+	require.ErrorIs(t, err, errRead)
+}
+
 func TestInitializeWithNoRetention(t *testing.T) {
 	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant, repotesting.Options{})
 
@@ -640,6 +716,35 @@ func TestWriteSessionFlushOnSuccess(t *testing.T) {
 	require.EqualValues(t, 2, afterFlushCount.Load())
 }
 
+func TestWriteSessionAsyncPackWrites(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant, repotesting.Options{})
+
+	var oids []object.ID
+
+	var inputs [][]byte
+
+	require.NoError(t, repo.WriteSession(ctx, env.Repository, repo.WriteSessionOptions{
+		AsyncPackWrites: 2,
+	}, func(ctx context.Context, w repo.RepositoryWriter) error {
+		// 40 MB of incompressible data fills at least one full pack, which is written in the background.
+		for i := range 4 {
+			data := make([]byte, 10<<20)
+			rand.New(rand.NewSource(int64(i))).Read(data)
+
+			inputs = append(inputs, data)
+			oids = append(oids, writeObject(ctx, t, w, data, fmt.Sprintf("async-%v", i)))
+		}
+
+		return nil
+	}))
+
+	env.MustReopen(t)
+
+	for i, oid := range oids {
+		verify(ctx, t, env.Repository, oid, inputs[i], fmt.Sprintf("async-%v", i))
+	}
+}
+
 func TestWriteSessionFlushOnSuccessClient(t *testing.T) {
 	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant, repotesting.Options{})
 
@@ -945,3 +1050,112 @@ func mustParseObjectID(t *testing.T, s string) object.ID {
 
 	return id
 }
+
+func TestOpenTimeout(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	var hang atomic.Bool
+
+	st := repotesting.NewReconnectableStorage(t, beforeop.NewUniformWrapper(
+		blobtesting.NewMapStorage(blobtesting.DataMap{}, nil, nil),
+		func(ctx context.Context) error {
+			if hang.Load() {
+				<-ctx.Done()
+				return ctx.Err()
+			}
+
+			return nil
+		}))
+
+	require.NoError(t, repo.Initialize(ctx, st, nil, "password"))
+
+	configFile := filepath.Join(testutil.TempDirectory(t), "kopia.config")
+	require.NoError(t, repo.Connect(ctx, configFile, st, "password", &repo.ConnectOptions{}))
+
+	r, err := repo.Open(ctx, configFile, "password", &repo.Options{OpenTimeout: time.Minute})
+	require.NoError(t, err)
+	require.NoError(t, r.Close(ctx))
+
+	hang.Store(true)
+
+	t0 := time.Now()
+	_, err = repo.Open(ctx, configFile, "password", &repo.Options{OpenTimeout: 100 * time.Millisecond})
+	require.ErrorIs(t, err, repo.ErrOpenTimeout)
+	require.Less(t, time.Since(t0), 10*time.Second)
+}
+
+func TestOpenReadOnly(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	data := blobtesting.DataMap{}
+	st := repotesting.NewReconnectableStorage(t, blobtesting.NewMapStorage(data, nil, nil))
+
+	require.NoError(t, repo.Initialize(ctx, st, nil, "password"))
+
+	configFile := filepath.Join(testutil.TempDirectory(t), "kopia.config")
+	require.NoError(t, repo.Connect(ctx, configFile, st, "password", &repo.ConnectOptions{}))
+
+	blobCount := len(data)
+
+	r, err := repo.Open(ctx, configFile, "password", &repo.Options{ReadOnly: true})
+	require.NoError(t, err)
+
+	defer r.Close(ctx)
+
+	require.True(t, r.ClientOptions().ReadOnly)
+
+	_, w, err := r.NewWriter(ctx, repo.WriteSessionOptions{Purpose: "test"})
+	require.NoError(t, err)
+
+	defer w.Close(ctx)
+
+	ow := w.NewObjectWriter(ctx, object.WriterOptions{})
+	_, err = ow.Write([]byte{1, 2, 3})
+	require.NoError(t, err)
+
+	_, err = ow.Result()
+	require.ErrorIs(t, err, repo.ErrReadOnly)
+	require.Len(t, data, blobCount)
+
+	// read-only mode is not persisted in the configuration.
+	lc, err := repo.LoadConfigFromFile(configFile)
+	require.NoError(t, err)
+	require.False(t, lc.ReadOnly)
+}
+
+func TestInitializeWithInjectedRandomness(t *testing.T) {
+	newEnv := func() *repotesting.Environment {
+		_, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant, repotesting.Options{
+			NewRepositoryOptions: func(n *repo.NewRepositoryOptions) {
+				n.RandReader = rand.New(rand.NewSource(1))
+			},
+		})
+
+		return env
+	}
+
+	env1 := newEnv()
+	env2 := newEnv()
+
+	require.Equal(t, env1.RepositoryWriter.UniqueID(), env2.RepositoryWriter.UniqueID())
+	require.Equal(t,
+		env1.RepositoryWriter.ContentReader().ContentFormat().GetMasterKey(),
+		env2.RepositoryWriter.ContentReader().ContentFormat().GetMasterKey())
+}
+
+func TestWriterManifestsUseInjectedRandomness(t *testing.T) {
+	newManifestID := func() manifest.ID {
+		ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant, repotesting.Options{
+			OpenOptions: func(o *repo.Options) {
+				o.RandReader = rand.New(rand.NewSource(1))
+			},
+		})
+
+		id, err := env.RepositoryWriter.PutManifest(ctx, map[string]string{"type": "test"}, "payload")
+		require.NoError(t, err)
+
+		return id
+	}
+
+	require.Equal(t, newManifestID(), newManifestID())
+}
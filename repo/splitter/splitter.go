@@ -80,9 +80,37 @@ func SupportedAlgorithms() []string {
 	"DYNAMIC": newBuzHash32SplitterFactory(splitterSize4MB),
 }
 
+// Register registers a splitter factory with a given name, which allows external packages to provide
+// additional splitters. It must be called before repositories using the splitter are opened.
+func Register(name string, f Factory) {
+	splitterFactories[name] = f
+}
+
 // GetFactory gets splitter factory with a specified name or nil if not found.
+// In addition to predefined splitters, content-defined splitters with custom segment sizes
+// can be specified using names returned by CustomDynamicName.
 func GetFactory(name string) Factory {
-	return splitterFactories[name]
+	if f := splitterFactories[name]; f != nil {
+		return f
+	}
+
+	f, err := parseCustomDynamicName(name)
+	if err != nil {
+		return nil
+	}
+
+	return f
+}
+
+// ValidateName returns an error if the provided splitter name is not supported.
+func ValidateName(name string) error {
+	if splitterFactories[name] != nil {
+		return nil
+	}
+
+	_, err := parseCustomDynamicName(name)
+
+	return err
 }
 
 // DefaultAlgorithm is the name of the splitter used by default for new repositories.
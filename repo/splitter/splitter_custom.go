@@ -0,0 +1,96 @@
+package splitter
+
+import (
+	"math/bits"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	customSplitterPrefix = "DYNAMIC-"
+
+	// maxCustomSplitterSize limits the maximum segment size of custom splitters, since
+	// object writers buffer entire segments in memory.
+	maxCustomSplitterSize = 32 << 20
+)
+
+// CustomDynamicName returns the name of a content-defined splitter using the provided rolling
+// hash ("BUZHASH" or "RABINKARP") and minimum, average and maximum segment sizes.
+// The returned name can be passed to GetFactory or used as repository or policy splitter.
+func CustomDynamicName(hash string, minSize, avgSize, maxSize int) string {
+	return customSplitterPrefix + formatSize(avgSize) + "-" + hash + "-" + formatSize(minSize) + "-" + formatSize(maxSize)
+}
+
+// parseCustomDynamicName parses names in the form DYNAMIC-<avg>-<hash>-<min>-<max>,
+// such as DYNAMIC-1M-BUZHASH-256K-8M and returns the corresponding factory.
+func parseCustomDynamicName(name string) (Factory, error) {
+	parts := strings.Split(strings.TrimPrefix(name, customSplitterPrefix), "-")
+	if !strings.HasPrefix(name, customSplitterPrefix) || len(parts) != 4 { //nolint:mnd
+		return nil, errors.Errorf("invalid splitter name %q", name)
+	}
+
+	avgSize, err := parseSize(parts[0])
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid average size")
+	}
+
+	minSize, err := parseSize(parts[2])
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid minimum size")
+	}
+
+	maxSize, err := parseSize(parts[3])
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid maximum size")
+	}
+
+	if bits.OnesCount(uint(avgSize)) != 1 {
+		return nil, errors.Errorf("average size must be a power of two, got %v", avgSize)
+	}
+
+	if minSize < splitterSlidingWindowSize || minSize >= avgSize || avgSize >= maxSize || maxSize > maxCustomSplitterSize {
+		return nil, errors.Errorf("invalid segment sizes min=%v avg=%v max=%v", minSize, avgSize, maxSize)
+	}
+
+	switch parts[1] {
+	case "BUZHASH":
+		return newBuzHash32SplitterFactoryWithSizes(minSize, avgSize, maxSize), nil
+	case "RABINKARP":
+		return newRabinKarp64SplitterFactoryWithSizes(minSize, avgSize, maxSize), nil
+	default:
+		return nil, errors.Errorf("unsupported rolling hash %q", parts[1])
+	}
+}
+
+func parseSize(s string) (int, error) {
+	mult := 1
+
+	switch {
+	case strings.HasSuffix(s, "K"):
+		mult = 1 << 10
+		s = strings.TrimSuffix(s, "K")
+	case strings.HasSuffix(s, "M"):
+		mult = 1 << 20
+		s = strings.TrimSuffix(s, "M")
+	}
+
+	v, err := strconv.Atoi(s)
+	if err != nil || v <= 0 {
+		return 0, errors.Errorf("invalid size %q", s)
+	}
+
+	return v * mult, nil
+}
+
+func formatSize(v int) string {
+	switch {
+	case v%(1<<20) == 0:
+		return strconv.Itoa(v>>20) + "M" //nolint:mnd
+	case v%(1<<10) == 0:
+		return strconv.Itoa(v>>10) + "K" //nolint:mnd
+	default:
+		return strconv.Itoa(v)
+	}
+}
@@ -6,6 +6,8 @@
 	"math/rand"
 	"testing"
 
+	"github.com/stretchr/testify/require"
+
 	"github.com/kopia/kopia/internal/testutil"
 )
 
@@ -209,3 +211,112 @@ func getSplitPointsRandomSlices(data []byte, s Splitter) (minSplit, maxSplit, co
 
 	return minSplit, maxSplit, count
 }
+
+func TestCustomDynamicSplitter(t *testing.T) {
+	for _, name := range []string{
+		CustomDynamicName("BUZHASH", 1<<10, 4<<10, 64<<10),
+		CustomDynamicName("RABINKARP", 1<<10, 4<<10, 64<<10),
+	} {
+		if err := ValidateName(name); err != nil {
+			t.Fatalf("invalid name %v: %v", name, err)
+		}
+
+		s := GetFactory(name)()
+
+		if got, want := s.MaxSegmentSize(), 64<<10; got != want {
+			t.Errorf("unexpected max segment size of %v: %v, want %v", name, got, want)
+		}
+
+		data := make([]byte, 5000000)
+		rand.New(rand.NewSource(5)).Read(data)
+
+		minSplit, maxSplit, count := getSplitPoints(data, s)
+		if minSplit < 1<<10 || maxSplit > 64<<10 || count == 0 {
+			t.Errorf("unexpected splits for %v: min=%v max=%v count=%v", name, minSplit, maxSplit, count)
+		}
+
+		// inserting bytes at the beginning only affects the first few segments.
+		s.Reset()
+		original := splitIntoSegments(data, s)
+
+		s.Reset()
+		shifted := splitIntoSegments(append([]byte{1, 2, 3}, data...), s)
+
+		if got := countCommonSegments(original, shifted); got < len(original)-3 {
+			t.Errorf("too few common segments for %v after shift: %v out of %v", name, got, len(original))
+		}
+	}
+
+	if got, want := CustomDynamicName("BUZHASH", 256<<10, 1<<20, 8<<20), "DYNAMIC-1M-BUZHASH-256K-8M"; got != want {
+		t.Errorf("unexpected name %v, want %v", got, want)
+	}
+
+	for _, invalid := range []string{
+		"DYNAMIC-1M-BUZHASH-256K",
+		"DYNAMIC-1M-NOSUCHHASH-256K-8M",
+		"DYNAMIC-3M-BUZHASH-256K-8M",
+		"DYNAMIC-1M-BUZHASH-2M-8M",
+		"DYNAMIC-1M-BUZHASH-256K-512K",
+		"DYNAMIC-1M-BUZHASH-256K-64M",
+		"DYNAMIC-1M-BUZHASH-256X-8M",
+	} {
+		if ValidateName(invalid) == nil {
+			t.Errorf("unexpected success validating %v", invalid)
+		}
+
+		if GetFactory(invalid) != nil {
+			t.Errorf("unexpected factory for %v", invalid)
+		}
+	}
+}
+
+func splitIntoSegments(data []byte, s Splitter) []string {
+	var result []string
+
+	for len(data) > 0 {
+		n := s.NextSplitPoint(data)
+		if n < 0 {
+			result = append(result, string(data))
+			break
+		}
+
+		result = append(result, string(data[0:n]))
+		data = data[n:]
+	}
+
+	return result
+}
+
+func countCommonSegments(a, b []string) int {
+	m := map[string]bool{}
+	for _, v := range a {
+		m[v] = true
+	}
+
+	count := 0
+
+	for _, v := range b {
+		if m[v] {
+			count++
+		}
+	}
+
+	return count
+}
+
+func TestRegister(t *testing.T) {
+	const name = "TEST-FIXED-3K"
+
+	require.Nil(t, GetFactory(name))
+
+	Register(name, Fixed(3000))
+	t.Cleanup(func() { delete(splitterFactories, name) })
+
+	require.NoError(t, ValidateName(name))
+	require.Contains(t, SupportedAlgorithms(), name)
+
+	s := GetFactory(name)()
+	defer s.Close()
+
+	require.Equal(t, 3000, s.MaxSegmentSize())
+}
@@ -247,6 +247,16 @@ func UpdateSnapshot(ctx context.Context, rep repo.RepositoryWriter, m *Manifest)
 	return nil
 }
 
+// DeleteSnapshot deletes the snapshot manifest with a given ID.
+// Returns ErrSnapshotNotFound if the snapshot does not exist and an error if the manifest is not a snapshot.
+func DeleteSnapshot(ctx context.Context, rep repo.RepositoryWriter, manifestID manifest.ID) error {
+	if _, err := LoadSnapshot(ctx, rep, manifestID); err != nil {
+		return err
+	}
+
+	return errors.Wrap(rep.DeleteManifest(ctx, manifestID), "error deleting manifest")
+}
+
 func entryIDs(entries []*manifest.EntryMetadata) []manifest.ID {
 	var ids []manifest.ID
 	for _, e := range entries {
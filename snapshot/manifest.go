@@ -3,6 +3,7 @@
 import (
 	"context"
 	"encoding/json"
+	"maps"
 	"sort"
 	"strconv"
 
@@ -127,6 +128,12 @@ type DirEntry struct {
 	GroupID     uint32               `json:"gid,omitempty"`
 	ObjectID    object.ID            `json:"obj,omitempty"`
 	DirSummary  *fs.DirectorySummary `json:"summ,omitempty"`
+
+	// SecurityDescriptor is the Windows security descriptor of the entry in SDDL format.
+	SecurityDescriptor string `json:"sd,omitempty"`
+
+	// ExtendedAttributes holds extended attributes of the entry keyed by name.
+	ExtendedAttributes map[string][]byte `json:"xattr,omitempty"`
 }
 
 // Clone returns a clone of the entry.
@@ -139,6 +146,10 @@ func (e *DirEntry) Clone() *DirEntry {
 		e2.DirSummary = &s2
 	}
 
+	if e.ExtendedAttributes != nil {
+		e2.ExtendedAttributes = maps.Clone(e.ExtendedAttributes)
+	}
+
 	return &e2
 }
 
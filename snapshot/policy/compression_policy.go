@@ -30,20 +30,18 @@ type CompressionPolicyDefinition struct {
 }
 
 // CompressorForFile returns compression name to be used for compressing a given file according to policy, using attributes such as name or size.
+// Files excluded from compression by the policy and policies that explicitly set "none" get "none", which also
+// overrides repository default compression, while an empty name means the repository default applies.
 func (p *CompressionPolicy) CompressorForFile(e fs.Entry) compression.Name {
 	ext := filepath.Ext(e.Name())
 	size := e.Size()
 
-	if p.CompressorName == "none" {
-		return ""
-	}
-
 	if v := p.MinSize; v > 0 && size < v {
-		return ""
+		return "none"
 	}
 
 	if v := p.MaxSize; v > 0 && size > v {
-		return ""
+		return "none"
 	}
 
 	if len(p.OnlyCompress) > 0 && isInSortedSlice(ext, p.OnlyCompress) {
@@ -51,7 +49,7 @@ func (p *CompressionPolicy) CompressorForFile(e fs.Entry) compression.Name {
 	}
 
 	if isInSortedSlice(ext, p.NeverCompress) {
-		return ""
+		return "none"
 	}
 
 	return p.CompressorName
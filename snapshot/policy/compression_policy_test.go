@@ -0,0 +1,38 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/mockfs"
+	"github.com/kopia/kopia/repo/compression"
+)
+
+func TestCompressorForFile(t *testing.T) {
+	small := mockfs.NewFile("small.txt", []byte("x"), 0o600)
+	large := mockfs.NewFile("large.txt", make([]byte, 100), 0o600)
+	zip := mockfs.NewFile("archive.zip", make([]byte, 100), 0o600)
+
+	cases := []struct {
+		policy CompressionPolicy
+		file   *mockfs.File
+		want   compression.Name
+	}{
+		// unset compressor uses repository default.
+		{CompressionPolicy{}, large, ""},
+		{defaultCompressionPolicy, large, ""},
+
+		// explicit "none" disables compression even if the repository has a default.
+		{CompressionPolicy{CompressorName: "none"}, large, "none"},
+
+		{CompressionPolicy{CompressorName: "zstd"}, large, "zstd"},
+		{CompressionPolicy{CompressorName: "zstd", MinSize: 10}, small, "none"},
+		{CompressionPolicy{CompressorName: "zstd", NeverCompress: []string{".zip"}}, zip, "none"},
+		{CompressionPolicy{NeverCompress: []string{".zip"}}, zip, "none"},
+	}
+
+	for _, tc := range cases {
+		require.Equal(t, tc.want, tc.policy.CompressorForFile(tc.file), "%+v %v", tc.policy, tc.file.Name())
+	}
+}
@@ -25,16 +25,13 @@ func ApplyRetentionPolicy(ctx context.Context, rep repo.RepositoryWriter, source
 		}
 	}
 
-	snapshots, err := snapshot.ListSnapshots(ctx, rep, sourceInfo)
-	if err != nil {
-		return nil, errors.Wrap(err, "error listing snapshots")
-	}
-
-	toDelete, err := getExpiredSnapshots(ctx, rep, snapshots)
+	report, err := EvaluateRetentionPolicy(ctx, rep, sourceInfo)
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to compute snapshots to delete")
 	}
 
+	toDelete := report.ExpiredIDs()
+
 	if reallyDelete {
 		for _, manifestID := range toDelete {
 			if err := rep.DeleteManifest(ctx, manifestID); err != nil {
@@ -46,41 +43,63 @@ func ApplyRetentionPolicy(ctx context.Context, rep repo.RepositoryWriter, source
 	return toDelete, nil
 }
 
-func getExpiredSnapshots(ctx context.Context, rep repo.Repository, snapshots []*snapshot.Manifest) ([]manifest.ID, error) {
-	var toDelete []manifest.ID
+// RetentionReport describes the result of evaluating retention policy for a set of snapshots.
+// RetentionReasons and Pins of kept snapshots explain why they are retained.
+type RetentionReport struct {
+	Kept    []*snapshot.Manifest
+	Expired []*snapshot.Manifest
+}
+
+// ExpiredIDs returns the IDs of expired snapshots.
+func (r *RetentionReport) ExpiredIDs() []manifest.ID {
+	var ids []manifest.ID
+
+	for _, s := range r.Expired {
+		ids = append(ids, s.ID)
+	}
+
+	return ids
+}
+
+// EvaluateRetentionPolicy evaluates retention policy for snapshots of a given source without deleting anything.
+func EvaluateRetentionPolicy(ctx context.Context, rep repo.Repository, sourceInfo snapshot.SourceInfo) (*RetentionReport, error) {
+	snapshots, err := snapshot.ListSnapshots(ctx, rep, sourceInfo)
+	if err != nil {
+		return nil, errors.Wrap(err, "error listing snapshots")
+	}
+
+	report := &RetentionReport{}
 
 	for _, snapshotGroup := range snapshot.GroupBySource(snapshots) {
-		td, err := getExpiredSnapshotsForSource(ctx, rep, snapshotGroup)
-		if err != nil {
+		if err := evaluateRetentionForSource(ctx, rep, snapshotGroup, report); err != nil {
 			return nil, err
 		}
-
-		toDelete = append(toDelete, td...)
 	}
 
-	return toDelete, nil
+	return report, nil
 }
 
-func getExpiredSnapshotsForSource(ctx context.Context, rep repo.Repository, snapshots []*snapshot.Manifest) ([]manifest.ID, error) {
+func evaluateRetentionForSource(ctx context.Context, rep repo.Repository, snapshots []*snapshot.Manifest, report *RetentionReport) error {
 	src := snapshots[0].Source
 
 	pol, _, _, err := GetEffectivePolicy(ctx, rep, src)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	pol.RetentionPolicy.ComputeRetentionReasons(snapshots)
 
-	var toDelete []manifest.ID
-
 	for _, s := range snapshots {
 		if len(s.RetentionReasons) == 0 && len(s.Pins) == 0 {
 			log(ctx).Debugf("  deleting %v", s.StartTime)
-			toDelete = append(toDelete, s.ID)
+
+			report.Expired = append(report.Expired, s)
 		} else {
 			log(ctx).Debugf("  keeping %v retention: [%v] pins: [%v]", s.StartTime.ToTime(), strings.Join(s.RetentionReasons, ","), strings.Join(s.Pins, ","))
+
+			report.Kept = append(report.Kept, s)
 		}
 	}
 
-	return toDelete, nil
+	return nil
 }
@@ -0,0 +1,69 @@
+package policy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/internal/clock"
+	"github.com/kopia/kopia/internal/repotesting"
+	"github.com/kopia/kopia/repo/manifest"
+	"github.com/kopia/kopia/snapshot"
+)
+
+func TestEvaluateAndApplyRetentionPolicy(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+
+	src := snapshot.SourceInfo{Host: "host-a", UserName: "myuser", Path: "/some/path"}
+
+	require.NoError(t, SetPolicy(ctx, env.RepositoryWriter, src, &Policy{
+		RetentionPolicy: RetentionPolicy{
+			KeepLatest:  newOptionalInt(2),
+			KeepHourly:  newOptionalInt(0),
+			KeepDaily:   newOptionalInt(0),
+			KeepWeekly:  newOptionalInt(0),
+			KeepMonthly: newOptionalInt(0),
+			KeepAnnual:  newOptionalInt(0),
+		},
+	}))
+
+	base := clock.Now().Add(-time.Hour)
+
+	var ids []manifest.ID
+
+	for i := range 4 {
+		id, err := snapshot.SaveSnapshot(ctx, env.RepositoryWriter, &snapshot.Manifest{
+			Source:    src,
+			StartTime: fs.UTCTimestampFromTime(base.Add(time.Duration(i) * time.Minute)),
+			EndTime:   fs.UTCTimestampFromTime(base.Add(time.Duration(i)*time.Minute + time.Second)),
+		})
+		require.NoError(t, err)
+
+		ids = append(ids, id)
+	}
+
+	report, err := EvaluateRetentionPolicy(ctx, env.RepositoryWriter, src)
+	require.NoError(t, err)
+
+	require.ElementsMatch(t, []manifest.ID{ids[0], ids[1]}, report.ExpiredIDs())
+	require.Len(t, report.Kept, 2)
+
+	for _, s := range report.Kept {
+		require.NotEmpty(t, s.RetentionReasons)
+	}
+
+	// evaluation does not delete anything.
+	snapshots, err := snapshot.ListSnapshots(ctx, env.RepositoryWriter, src)
+	require.NoError(t, err)
+	require.Len(t, snapshots, 4)
+
+	deleted, err := ApplyRetentionPolicy(ctx, env.RepositoryWriter, src, true)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []manifest.ID{ids[0], ids[1]}, deleted)
+
+	snapshots, err = snapshot.ListSnapshots(ctx, env.RepositoryWriter, src)
+	require.NoError(t, err)
+	require.Len(t, snapshots, 2)
+}
@@ -177,6 +177,27 @@ func SetPolicy(ctx context.Context, rep repo.RepositoryWriter, si snapshot.Sourc
 	return nil
 }
 
+// SetPolicyIfUnchanged sets the policy on a given source, provided that the policy manifests defined
+// for the source have the expected IDs (see Policy.ID()), which is empty if the policy was not defined.
+// Returns manifest.ErrConflict if the policy has been modified concurrently.
+func SetPolicyIfUnchanged(ctx context.Context, rep repo.DirectRepositoryWriter, si snapshot.SourceInfo, pol *Policy, expected []manifest.ID) error {
+	if err := ValidatePolicy(si, pol); err != nil {
+		return errors.Wrap(err, "failed to validate policy")
+	}
+
+	if si.Path != "" {
+		if err := validatePolicyPath(si.Path); err != nil {
+			return errors.Wrap(err, "invalid policy path")
+		}
+	}
+
+	if _, err := rep.CompareAndReplaceManifests(ctx, LabelsForSource(si), pol, expected); err != nil {
+		return errors.Wrap(err, "error writing policy manifest")
+	}
+
+	return nil
+}
+
 // RemovePolicy removes the policy for a given source.
 func RemovePolicy(ctx context.Context, rep repo.RepositoryWriter, si snapshot.SourceInfo) error {
 	md, err := rep.FindManifests(ctx, LabelsForSource(si))
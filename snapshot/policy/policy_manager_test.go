@@ -9,6 +9,8 @@
 	"github.com/stretchr/testify/require"
 
 	"github.com/kopia/kopia/internal/repotesting"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/manifest"
 	"github.com/kopia/kopia/snapshot"
 )
 
@@ -477,3 +479,48 @@ func TestPolicySetInvalidPath_Invalid(t *testing.T) {
 		require.Error(t, validatePolicyPath(v), v)
 	}
 }
+
+func TestSetPolicyIfUnchanged(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+
+	sourceInfo := snapshot.SourceInfo{Host: "host-a"}
+	pol := &Policy{
+		RetentionPolicy: RetentionPolicy{
+			KeepDaily: newOptionalInt(44),
+		},
+	}
+
+	require.NoError(t, SetPolicyIfUnchanged(ctx, env.RepositoryWriter, sourceInfo, pol, nil))
+	require.ErrorIs(t, SetPolicyIfUnchanged(ctx, env.RepositoryWriter, sourceInfo, pol, nil), manifest.ErrConflict)
+	require.NoError(t, env.RepositoryWriter.Flush(ctx))
+
+	r1, ok := env.MustOpenAnother(t).(repo.DirectRepositoryWriter)
+	require.True(t, ok)
+
+	r2, ok := env.MustOpenAnother(t).(repo.DirectRepositoryWriter)
+	require.True(t, ok)
+
+	defined, err := GetDefinedPolicy(ctx, r1, sourceInfo)
+	require.NoError(t, err)
+
+	expected := []manifest.ID{manifest.ID(defined.ID())}
+
+	require.NoError(t, SetPolicyIfUnchanged(ctx, r1, sourceInfo, &Policy{
+		RetentionPolicy: RetentionPolicy{
+			KeepDaily: newOptionalInt(33),
+		},
+	}, expected))
+
+	require.NoError(t, SetPolicyIfUnchanged(ctx, r2, sourceInfo, &Policy{
+		RetentionPolicy: RetentionPolicy{
+			KeepDaily: newOptionalInt(22),
+		},
+	}, expected))
+
+	require.NoError(t, r1.Flush(ctx))
+	require.ErrorIs(t, r2.Flush(ctx), manifest.ErrConflict)
+
+	pi, err := GetDefinedPolicy(ctx, env.MustOpenAnother(t), sourceInfo)
+	require.NoError(t, err)
+	require.EqualValues(t, 33, *pi.RetentionPolicy.KeepDaily)
+}
@@ -9,9 +9,8 @@
 	// defaultActionsPolicy is the default actions policy.
 	defaultActionsPolicy = ActionsPolicy{}
 
-	defaultCompressionPolicy = CompressionPolicy{
-		CompressorName: "none",
-	}
+	// defaultCompressionPolicy is the default compression policy, which uses repository default compression.
+	defaultCompressionPolicy = CompressionPolicy{}
 
 	defaultSplitterPolicy = SplitterPolicy{}
 
@@ -4,8 +4,10 @@
 	"cmp"
 	"context"
 	"fmt"
+	"hash/fnv"
 	"reflect"
 	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -67,6 +69,11 @@ type SchedulingPolicy struct {
 	Manual             bool          `json:"manual,omitempty"`
 	Cron               []string      `json:"cron,omitempty"`
 	RunMissed          *OptionalBool `json:"runMissed,omitempty"`
+	HealthCheckURL     string        `json:"healthCheckURL,omitempty"`
+	JitterSeconds      int64         `json:"jitterSeconds,omitempty"`
+
+	// NotificationProfiles restricts notifications about the source to the named profiles, all profiles when empty.
+	NotificationProfiles []string `json:"notificationProfiles,omitempty"`
 }
 
 // SchedulingPolicyDefinition specifies which policy definition provided the value of a particular field.
@@ -76,6 +83,10 @@ type SchedulingPolicyDefinition struct {
 	Cron            snapshot.SourceInfo `json:"cron,omitempty"`
 	Manual          snapshot.SourceInfo `json:"manual,omitempty"`
 	RunMissed       snapshot.SourceInfo `json:"runMissed,omitempty"`
+	HealthCheckURL  snapshot.SourceInfo `json:"healthCheckURL,omitempty"`
+	JitterSeconds   snapshot.SourceInfo `json:"jitterSeconds,omitempty"`
+
+	NotificationProfiles snapshot.SourceInfo `json:"notificationProfiles,omitempty"`
 }
 
 // defaultRunMissed is the value for RunMissed.
@@ -91,6 +102,32 @@ func (p *SchedulingPolicy) SetInterval(d time.Duration) {
 	p.IntervalSeconds = int64(d.Seconds())
 }
 
+// Jitter returns the maximum random delay of scheduled snapshots or zero if not specified.
+func (p *SchedulingPolicy) Jitter() time.Duration {
+	return time.Duration(p.JitterSeconds) * time.Second
+}
+
+// SetJitter sets the maximum random delay of scheduled snapshots (zero disables).
+func (p *SchedulingPolicy) SetJitter(d time.Duration) {
+	p.JitterSeconds = int64(d.Seconds())
+}
+
+// ApplyJitter delays the provided scheduled snapshot time by a pseudo-random amount smaller than Jitter().
+// The delay is derived from the provided key and the scheduled time, so repeated computations
+// of the same schedule for the same source produce the same result.
+func (p *SchedulingPolicy) ApplyJitter(t time.Time, key string) time.Time {
+	j := p.Jitter()
+	if j <= 0 {
+		return t
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(key))                             //nolint:errcheck
+	h.Write([]byte(strconv.FormatInt(t.Unix(), 10))) //nolint:errcheck
+
+	return t.Add(time.Duration(h.Sum64() % uint64(j))) //nolint:gosec
+}
+
 // NextSnapshotTime computes next snapshot time given previous
 // snapshot time and current wall clock time.
 func (p *SchedulingPolicy) NextSnapshotTime(previousSnapshotTime, now time.Time) (time.Time, bool) {
@@ -242,6 +279,9 @@ func (p *SchedulingPolicy) Merge(src SchedulingPolicy, def *SchedulingPolicyDefi
 
 	mergeBool(&p.Manual, src.Manual, &def.Manual, si)
 	mergeOptionalBool(&p.RunMissed, src.RunMissed, &def.RunMissed, si)
+	mergeString(&p.HealthCheckURL, src.HealthCheckURL, &def.HealthCheckURL, si)
+	mergeInt64(&p.JitterSeconds, src.JitterSeconds, &def.JitterSeconds, si)
+	mergeStringList(&p.NotificationProfiles, src.NotificationProfiles, &def.NotificationProfiles, si)
 }
 
 // IsManualSnapshot returns the SchedulingPolicy manual value from the given policy tree.
@@ -272,7 +312,11 @@ func SetManual(ctx context.Context, rep repo.RepositoryWriter, sourceInfo snapsh
 
 // ValidateSchedulingPolicy returns an error if manual field is set along with scheduling fields.
 func ValidateSchedulingPolicy(p SchedulingPolicy) error {
-	if p.Manual && !reflect.DeepEqual(p, SchedulingPolicy{Manual: true}) {
+	// notification profiles don't affect when snapshots are taken and can be combined with manual snapshots.
+	schedule := p
+	schedule.NotificationProfiles = nil
+
+	if p.Manual && !reflect.DeepEqual(schedule, SchedulingPolicy{Manual: true}) {
 		return errors.New("invalid scheduling policy: manual cannot be combined with other scheduling policies")
 	}
 
@@ -328,3 +328,31 @@ func TestSortAndDedupeTimesOfDay(t *testing.T) {
 		})
 	}
 }
+
+func TestApplyJitter(t *testing.T) {
+	t0 := time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	var p policy.SchedulingPolicy
+
+	require.Equal(t, t0, p.ApplyJitter(t0, "some-source"))
+
+	p.SetJitter(10 * time.Minute)
+	require.Equal(t, 10*time.Minute, p.Jitter())
+
+	distinct := map[time.Time]bool{}
+
+	for i := range 20 {
+		key := fmt.Sprintf("source-%v", i)
+
+		got := p.ApplyJitter(t0, key)
+		require.False(t, got.Before(t0))
+		require.True(t, got.Before(t0.Add(10*time.Minute)))
+
+		// same key and time always produce the same result.
+		require.Equal(t, got, p.ApplyJitter(t0, key))
+
+		distinct[got] = true
+	}
+
+	require.Greater(t, len(distinct), 1)
+}
@@ -3,6 +3,7 @@
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
@@ -95,6 +96,10 @@ type FilesystemOutput struct {
 	// instead.
 	OverwriteFiles bool `json:"overwriteFiles"`
 
+	// When set to true, files that already exist are left intact and restored files are written
+	// next to them using a new name. Takes precedence over OverwriteFiles.
+	RenameExistingFiles bool `json:"renameExistingFiles,omitempty"`
+
 	// If a symlink already exists, remove it and create a new one. When set to
 	// false, the copier does not modify existing symlinks and will return an
 	// error instead.
@@ -143,7 +148,7 @@ func (o *FilesystemOutput) Parallelizable() bool {
 
 // BeginDirectory implements restore.Output interface.
 func (o *FilesystemOutput) BeginDirectory(ctx context.Context, relativePath string, _ fs.Directory) error {
-	path := filepath.Join(o.TargetPath, filepath.FromSlash(relativePath))
+	path := o.outputPath(relativePath)
 
 	if err := o.createDirectory(ctx, path); err != nil {
 		return errors.Wrap(err, "error creating directory")
@@ -154,7 +159,7 @@ func (o *FilesystemOutput) BeginDirectory(ctx context.Context, relativePath stri
 
 // FinishDirectory implements restore.Output interface.
 func (o *FilesystemOutput) FinishDirectory(ctx context.Context, relativePath string, e fs.Directory) error {
-	path := filepath.Join(o.TargetPath, filepath.FromSlash(relativePath))
+	path := o.outputPath(relativePath)
 	if err := o.setAttributes(path, e, os.FileMode(0)); err != nil {
 		return errors.Wrap(err, "error setting attributes")
 	}
@@ -177,7 +182,20 @@ func (o *FilesystemOutput) Close(ctx context.Context) error {
 // WriteFile implements restore.Output interface.
 func (o *FilesystemOutput) WriteFile(ctx context.Context, relativePath string, f fs.File, progressCb FileWriteProgress) error {
 	log(ctx).Debugf("WriteFile %v (%v bytes) %v, %v", filepath.Join(o.TargetPath, relativePath), f.Size(), f.Mode(), f.ModTime())
-	path := filepath.Join(o.TargetPath, filepath.FromSlash(relativePath))
+	path := o.outputPath(relativePath)
+
+	if o.RenameExistingFiles {
+		newPath, err := nonConflictingPath(path)
+		if err != nil {
+			return err
+		}
+
+		if newPath != path {
+			log(ctx).Debugf("Restoring %v as %v", path, newPath)
+		}
+
+		path = newPath
+	}
 
 	if err := o.copyFileContent(ctx, path, f, progressCb); err != nil {
 		return errors.Wrap(err, "error creating file")
@@ -192,7 +210,7 @@ func (o *FilesystemOutput) WriteFile(ctx context.Context, relativePath string, f
 
 // FileExists implements restore.Output interface.
 func (o *FilesystemOutput) FileExists(ctx context.Context, relativePath string, e fs.File) bool {
-	st, err := os.Lstat(filepath.Join(o.TargetPath, relativePath))
+	st, err := os.Lstat(o.outputPath(relativePath))
 	if err != nil {
 		return false
 	}
@@ -224,7 +242,7 @@ func (o *FilesystemOutput) CreateSymlink(ctx context.Context, relativePath strin
 
 	log(ctx).Debugf("CreateSymlink %v => %v, time %v", filepath.Join(o.TargetPath, relativePath), targetPath, e.ModTime())
 
-	path := filepath.Join(o.TargetPath, filepath.FromSlash(relativePath))
+	path := o.outputPath(relativePath)
 
 	switch st, err := os.Lstat(path); {
 	case os.IsNotExist(err): // Proceed to symlink creation
@@ -263,7 +281,7 @@ func fileIsSymlink(st os.FileInfo) bool {
 //
 //nolint:revive
 func (o *FilesystemOutput) SymlinkExists(ctx context.Context, relativePath string, e fs.Symlink) bool {
-	st, err := os.Lstat(filepath.Join(o.TargetPath, relativePath))
+	st, err := os.Lstat(o.outputPath(relativePath))
 	if err != nil {
 		return false
 	}
@@ -271,6 +289,12 @@ func (o *FilesystemOutput) SymlinkExists(ctx context.Context, relativePath strin
 	return (st.Mode() & os.ModeType) == os.ModeSymlink
 }
 
+// outputPath returns the local path for the provided relative path, which on Windows
+// uses the \\?\ form when needed to handle long paths and reserved names.
+func (o *FilesystemOutput) outputPath(relativePath string) string {
+	return atomicfile.MaybePrefixLongFilenameOnWindows(filepath.Join(o.TargetPath, filepath.FromSlash(relativePath)))
+}
+
 // setAttributes sets permission, modification time and user/group ids
 // on targetPath. modclear will clear the specified FileMod bits. Pass 0
 // to not clear any.
@@ -309,6 +333,14 @@ func (o *FilesystemOutput) setAttributes(targetPath string, e fs.Entry, modclear
 		}
 	}
 
+	if err = o.maybeIgnorePermissionError(o.setSecurityDescriptor(targetPath, e)); err != nil {
+		return errors.Wrap(err, "could not change security descriptor of "+targetPath)
+	}
+
+	if err = o.maybeIgnorePermissionError(o.setExtendedAttributes(targetPath, e)); err != nil {
+		return errors.Wrap(err, "could not set extended attributes of "+targetPath)
+	}
+
 	if o.shouldUpdateTimes(le, e) {
 		if err = o.maybeIgnorePermissionError(osChtimes(targetPath, e.ModTime(), e.ModTime())); err != nil {
 			return errors.Wrap(err, "could not change mod time on "+targetPath)
@@ -447,6 +479,23 @@ func (o *FilesystemOutput) copyFileContent(ctx context.Context, targetPath strin
 	return write(targetPath, wr, f.Size(), o.copier)
 }
 
+// nonConflictingPath returns the provided path if it does not exist, otherwise returns
+// a new path in the same directory with a numeric suffix appended that does not exist.
+func nonConflictingPath(path string) (string, error) {
+	candidate := path
+
+	for i := 1; ; i++ {
+		switch _, err := os.Lstat(atomicfile.MaybePrefixLongFilenameOnWindows(candidate)); {
+		case os.IsNotExist(err):
+			return candidate, nil
+		case err != nil:
+			return "", errors.Wrap(err, "failed to stat "+candidate)
+		}
+
+		candidate = fmt.Sprintf("%v.restored-%v", path, i)
+	}
+}
+
 func isEmptyDirectory(name string) (bool, error) {
 	f, err := os.Open(name) //nolint:gosec
 	if err != nil {
@@ -0,0 +1,15 @@
+//go:build !windows
+// +build !windows
+
+package restore
+
+import (
+	"github.com/kopia/kopia/fs"
+)
+
+// setSecurityDescriptor is a no-op since security descriptors are only supported on Windows.
+//
+//nolint:revive
+func (o *FilesystemOutput) setSecurityDescriptor(targetPath string, e fs.Entry) error {
+	return nil
+}
@@ -0,0 +1,64 @@
+package restore
+
+import (
+	"github.com/pkg/errors"
+	"golang.org/x/sys/windows"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/internal/atomicfile"
+)
+
+// setSecurityDescriptor applies the security descriptor captured in the snapshot to the restored entry.
+// The owner and group are restored unless SkipOwners is set and the DACL is restored unless SkipPermissions is set.
+func (o *FilesystemOutput) setSecurityDescriptor(targetPath string, e fs.Entry) error {
+	sde, ok := e.(fs.EntryWithSecurityDescriptor)
+	if !ok || isSymlink(e) {
+		return nil
+	}
+
+	sddl, err := sde.SecurityDescriptor()
+	if err != nil || sddl == "" {
+		return err //nolint:wrapcheck
+	}
+
+	sd, err := windows.SecurityDescriptorFromString(sddl)
+	if err != nil {
+		return errors.Wrap(err, "invalid security descriptor")
+	}
+
+	var (
+		info  windows.SECURITY_INFORMATION
+		owner *windows.SID
+		group *windows.SID
+		dacl  *windows.ACL
+	)
+
+	if !o.SkipOwners {
+		if owner, _, err = sd.Owner(); err == nil && owner != nil {
+			info |= windows.OWNER_SECURITY_INFORMATION
+		}
+
+		if group, _, err = sd.Group(); err == nil && group != nil {
+			info |= windows.GROUP_SECURITY_INFORMATION
+		}
+	}
+
+	if !o.SkipPermissions {
+		if dacl, _, err = sd.DACL(); err == nil && dacl != nil {
+			info |= windows.DACL_SECURITY_INFORMATION
+
+			if control, _, cerr := sd.Control(); cerr == nil && control&windows.SE_DACL_PROTECTED != 0 {
+				info |= windows.PROTECTED_DACL_SECURITY_INFORMATION
+			} else {
+				info |= windows.UNPROTECTED_DACL_SECURITY_INFORMATION
+			}
+		}
+	}
+
+	if info == 0 {
+		return nil
+	}
+
+	//nolint:wrapcheck
+	return windows.SetNamedSecurityInfo(atomicfile.MaybePrefixLongFilenameOnWindows(targetPath), windows.SE_FILE_OBJECT, info, owner, group, dacl, nil)
+}
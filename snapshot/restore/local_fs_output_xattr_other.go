@@ -0,0 +1,15 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package restore
+
+import (
+	"github.com/kopia/kopia/fs"
+)
+
+// setExtendedAttributes is a no-op since extended attributes are not supported on this platform.
+//
+//nolint:revive
+func (o *FilesystemOutput) setExtendedAttributes(targetPath string, e fs.Entry) error {
+	return nil
+}
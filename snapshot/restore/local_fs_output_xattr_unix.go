@@ -0,0 +1,40 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package restore
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+
+	"github.com/kopia/kopia/fs"
+)
+
+// setExtendedAttributes applies the extended attributes captured in the snapshot to the restored entry.
+// Attributes are silently skipped when the target filesystem does not support them.
+func (o *FilesystemOutput) setExtendedAttributes(targetPath string, e fs.Entry) error {
+	xe, ok := e.(fs.EntryWithExtendedAttributes)
+	if !ok || isSymlink(e) {
+		return nil
+	}
+
+	attrs, err := xe.ExtendedAttributes()
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	for name, value := range attrs {
+		if err := unix.Lsetxattr(targetPath, name, value, 0); err != nil {
+			if errors.Is(err, unix.ENOTSUP) || errors.Is(err, unix.EOPNOTSUPP) {
+				return nil
+			}
+
+			// keep the error recognizable by os.IsPermission() so that it can be ignored.
+			return os.NewSyscallError("lsetxattr "+name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package restore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/internal/mockfs"
+)
+
+type fileWithExtendedAttributes struct {
+	fs.File
+
+	attrs map[string][]byte
+}
+
+func (f fileWithExtendedAttributes) ExtendedAttributes() (map[string][]byte, error) {
+	return f.attrs, nil
+}
+
+func TestSetExtendedAttributes(t *testing.T) {
+	fname := filepath.Join(t.TempDir(), "f1")
+	require.NoError(t, os.WriteFile(fname, []byte{1, 2, 3}, 0o600))
+
+	if err := unix.Setxattr(fname, "user.kopia-probe", []byte("x"), 0); err != nil {
+		if errors.Is(err, unix.ENOTSUP) || errors.Is(err, unix.EOPNOTSUPP) || errors.Is(err, unix.EPERM) {
+			t.Skipf("extended attributes not supported: %v", err)
+		}
+
+		require.NoError(t, err)
+	}
+
+	f := mockfs.NewDirectory().AddFile("f1", []byte{1, 2, 3}, 0o644)
+
+	o := &FilesystemOutput{}
+	require.NoError(t, o.setExtendedAttributes(fname, fileWithExtendedAttributes{
+		File:  f,
+		attrs: map[string][]byte{"user.kopia-test": []byte("some-value")},
+	}))
+
+	buf := make([]byte, 100)
+
+	n, err := unix.Getxattr(fname, "user.kopia-test", buf)
+	require.NoError(t, err)
+	require.Equal(t, "some-value", string(buf[:n]))
+
+	// entries without extended attributes are left alone.
+	require.NoError(t, o.setExtendedAttributes(fname, f))
+}
@@ -94,6 +94,11 @@ type Options struct {
 	RestoreDirEntryAtDepth int32 `json:"restoreDirEntryAtDepth"`
 	MinSizeForPlaceholder  int32 `json:"minSizeForPlaceholder"`
 
+	// Include and Exclude are patterns in .gitignore syntax relative to the restore root
+	// that select a subset of entries to restore.
+	Include []string `json:"include,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
+
 	ProgressCallback ProgressCallback `json:"-"`
 	Cancel           chan struct{}    `json:"-"` // channel that can be externally closed to signal cancellation
 }
@@ -102,8 +107,14 @@ type Options struct {
 //
 //nolint:revive
 func Entry(ctx context.Context, rep repo.Repository, output Output, rootEntry fs.Entry, options Options) (Stats, error) {
+	filter, err := newEntryFilter(options.Include, options.Exclude)
+	if err != nil {
+		return Stats{}, err
+	}
+
 	c := copier{
 		output:           output,
+		filter:           filter,
 		shallowoutput:    makeShallowFilesystemOutput(output, options),
 		q:                parallelwork.NewQueue(),
 		incremental:      options.Incremental,
@@ -147,6 +158,7 @@ type copier struct {
 	stats         statsInternal
 	output        Output
 	shallowoutput Output
+	filter        *entryFilter
 	q             *parallelwork.Queue
 	incremental   bool
 	ignoreErrors  bool
@@ -284,11 +296,21 @@ func (c *copier) copyDirectory(ctx context.Context, d fs.Directory, targetPath s
 }
 
 func (c *copier) copyDirectoryContent(ctx context.Context, d fs.Directory, targetPath string, currentdepth, maxdepth int32, onCompletion parallelwork.CallbackFunc) error {
-	entries, err := fs.GetAllEntries(ctx, d)
+	allEntries, err := fs.GetAllEntries(ctx, d)
 	if err != nil {
 		return errors.Wrap(err, "error reading directory")
 	}
 
+	entries := make([]fs.Entry, 0, len(allEntries))
+
+	for _, e := range allEntries {
+		if c.filter.shouldRestore(path.Join(targetPath, e.Name()), e) {
+			entries = append(entries, e)
+		} else {
+			log(ctx).Debugf("not restoring %v because of include/exclude rules", path.Join(targetPath, e.Name()))
+		}
+	}
+
 	if len(entries) == 0 {
 		return onCompletion()
 	}
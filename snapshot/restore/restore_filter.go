@@ -0,0 +1,77 @@
+package restore
+
+import (
+	"path"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/internal/wcmatch"
+)
+
+// entryFilter decides which entries get restored based on include/exclude patterns
+// in .gitignore syntax, matched against paths relative to the restore root.
+type entryFilter struct {
+	include []*wcmatch.WildcardMatcher
+	exclude []*wcmatch.WildcardMatcher
+}
+
+func newEntryFilter(include, exclude []string) (*entryFilter, error) {
+	if len(include) == 0 && len(exclude) == 0 {
+		return nil, nil
+	}
+
+	f := &entryFilter{}
+
+	for _, p := range include {
+		m, err := wcmatch.NewWildcardMatcher(p)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid include pattern %q", p)
+		}
+
+		f.include = append(f.include, m)
+	}
+
+	for _, p := range exclude {
+		m, err := wcmatch.NewWildcardMatcher(p)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid exclude pattern %q", p)
+		}
+
+		f.exclude = append(f.exclude, m)
+	}
+
+	return f, nil
+}
+
+// shouldRestore returns true if the entry at a given relative path should be restored.
+// Excluded directories are skipped along with all their contents. When include patterns
+// are provided, directories are always traversed and files are restored only if they
+// or any of their parent directories match one of the patterns.
+func (f *entryFilter) shouldRestore(relativePath string, e fs.Entry) bool {
+	if f == nil {
+		return true
+	}
+
+	p := path.Join("/", relativePath)
+
+	for _, m := range f.exclude {
+		if m.Match(p, e.IsDir()) {
+			return false
+		}
+	}
+
+	if len(f.include) == 0 || e.IsDir() {
+		return true
+	}
+
+	for isDir := false; p != "/"; p, isDir = path.Dir(p), true {
+		for _, m := range f.include {
+			if m.Match(p, isDir) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,49 @@
+package restore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/mockfs"
+)
+
+func TestEntryFilter(t *testing.T) {
+	dir := mockfs.NewDirectory()
+	file := dir.AddFile("f", []byte{1}, 0o644)
+
+	cases := []struct {
+		include, exclude []string
+		relativePath     string
+		isDir            bool
+		want             bool
+	}{
+		{nil, nil, "a/b.docx", false, true},
+		{[]string{"**/*.docx"}, nil, "a/b.docx", false, true},
+		{[]string{"**/*.docx"}, nil, "b.docx", false, true},
+		{[]string{"**/*.docx"}, nil, "a/b.txt", false, false},
+		{[]string{"**/*.docx"}, nil, "a", true, true},
+		{[]string{"/docs"}, nil, "docs/sub/b.txt", false, true},
+		{[]string{"/docs"}, nil, "other/b.txt", false, false},
+		{nil, []string{"cache/**"}, "cache/x", false, false},
+		{nil, []string{"/cache"}, "cache", true, false},
+		{nil, []string{"/cache"}, "other/cache", true, true},
+		{nil, []string{"*.tmp"}, "a/b.tmp", false, false},
+		{[]string{"**/*.docx"}, []string{"cache/**"}, "cache/a.docx", false, false},
+	}
+
+	for _, tc := range cases {
+		f, err := newEntryFilter(tc.include, tc.exclude)
+		require.NoError(t, err)
+
+		var got bool
+
+		if tc.isDir {
+			got = f.shouldRestore(tc.relativePath, dir)
+		} else {
+			got = f.shouldRestore(tc.relativePath, file)
+		}
+
+		require.Equal(t, tc.want, got, "include=%v exclude=%v path=%v", tc.include, tc.exclude, tc.relativePath)
+	}
+}
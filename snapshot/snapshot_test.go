@@ -87,6 +87,14 @@ func TestSnapshotsAPI(t *testing.T) {
 	updated3, err := snapshot.LoadSnapshot(ctx, env.RepositoryWriter, manifest3.ID)
 	require.NoError(t, err)
 	require.Equal(t, updated3, manifest3)
+
+	require.NoError(t, snapshot.DeleteSnapshot(ctx, env.RepositoryWriter, id1))
+	require.ErrorIs(t, snapshot.DeleteSnapshot(ctx, env.RepositoryWriter, id1), snapshot.ErrSnapshotNotFound)
+	verifySnapshotManifestIDs(t, env.RepositoryWriter, &src1, []manifest.ID{id2})
+
+	nonSnapshotID, err := env.RepositoryWriter.PutManifest(ctx, map[string]string{"type": "not-a-snapshot"}, manifest1)
+	require.NoError(t, err)
+	require.ErrorContains(t, snapshot.DeleteSnapshot(ctx, env.RepositoryWriter, nonSnapshotID), "manifest is not a snapshot")
 }
 
 func verifySnapshotManifestIDs(t *testing.T, rep repo.Repository, src *snapshot.SourceInfo, expected []manifest.ID) {
@@ -76,6 +76,14 @@ func (e *repositoryEntry) Device() fs.DeviceInfo {
 	return fs.DeviceInfo{}
 }
 
+func (e *repositoryEntry) SecurityDescriptor() (string, error) {
+	return e.metadata.SecurityDescriptor, nil
+}
+
+func (e *repositoryEntry) ExtendedAttributes() (map[string][]byte, error) {
+	return e.metadata.ExtendedAttributes, nil
+}
+
 func (e *repositoryEntry) DirEntry() *snapshot.DirEntry {
 	return e.metadata
 }
@@ -33,6 +33,8 @@ type Verifier struct {
 
 	queued    atomic.Int32
 	processed atomic.Int32
+	failed    atomic.Int32
+	readBytes atomic.Int64
 
 	fileWorkQueue chan verifyFileWorkItem
 	rep           repo.Repository
@@ -62,6 +64,7 @@ func (v *Verifier) VerifyFile(ctx context.Context, oid object.ID, entryPath stri
 
 	defer func() {
 		v.processed.Add(1)
+		v.reportProgress()
 	}()
 
 	contentIDs, err := v.rep.VerifyObject(ctx, oid)
@@ -106,9 +109,19 @@ func (v *Verifier) verifyObject(ctx context.Context, e fs.Entry, oid object.ID,
 		v.processed.Add(1)
 	}
 
+	v.reportProgress()
+
 	return nil
 }
 
+func (v *Verifier) reportProgress() {
+	if v.opts.Progress == nil {
+		return
+	}
+
+	v.opts.Progress.SetCounters(v.queued.Load(), v.processed.Load(), v.failed.Load(), v.readBytes.Load())
+}
+
 func (v *Verifier) readEntireObject(ctx context.Context, oid object.ID, path string) error {
 	verifierLog(ctx).Debugf("reading object %v %v", oid, path)
 
@@ -119,7 +132,10 @@ func (v *Verifier) readEntireObject(ctx context.Context, oid object.ID, path str
 	}
 	defer r.Close() //nolint:errcheck
 
-	return errors.Wrap(iocopy.JustCopy(io.Discard, r), "unable to read data")
+	n, err := iocopy.Copy(io.Discard, r)
+	v.readBytes.Add(n)
+
+	return errors.Wrap(err, "unable to read data")
 }
 
 // VerifierOptions provides options for the verifier.
@@ -129,6 +145,9 @@ type VerifierOptions struct {
 	Parallelism        int
 	MaxErrors          int
 	BlobMap            map[blob.ID]blob.Metadata
+
+	// Progress, when provided, receives verification counters as objects are processed.
+	Progress VerifierProgress
 }
 
 // InParallel starts parallel verification and invokes the provided function which can
@@ -158,6 +177,7 @@ func (v *Verifier) InParallel(ctx context.Context, enqueue func(tw *TreeWalker)
 				}
 
 				if err := v.VerifyFile(ctx, wi.oid, wi.entryPath); err != nil {
+					v.failed.Add(1)
 					tw.ReportError(ctx, wi.entryPath, err)
 				}
 			}
@@ -170,6 +190,11 @@ func (v *Verifier) InParallel(ctx context.Context, enqueue func(tw *TreeWalker)
 	v.workersWG.Wait()
 	v.fileWorkQueue = nil
 
+	if v.opts.Progress != nil {
+		v.reportProgress()
+		v.opts.Progress.Flush()
+	}
+
 	if err != nil {
 		return err
 	}
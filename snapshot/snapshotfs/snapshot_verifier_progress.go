@@ -0,0 +1,7 @@
+package snapshotfs
+
+// VerifierProgress is invoked by Verifier to report status of snapshot verification.
+type VerifierProgress interface {
+	SetCounters(queuedCount, processedCount, failedCount int32, readBytes int64)
+	Flush()
+}
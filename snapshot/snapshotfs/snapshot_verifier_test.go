@@ -3,6 +3,7 @@
 import (
 	"context"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/pkg/errors"
@@ -76,6 +77,27 @@ func TestSnapshotVerifier(t *testing.T) {
 		}))
 	})
 
+	t.Run("Progress", func(t *testing.T) {
+		prog := &testVerifierProgress{}
+
+		v := snapshotfs.NewVerifier(ctx, te2, snapshotfs.VerifierOptions{
+			VerifyFilesPercent: 100,
+			Progress:           prog,
+		})
+
+		require.NoError(t, v.InParallel(ctx, func(tw *snapshotfs.TreeWalker) error {
+			tw.Process(ctx, snapshotfs.DirectoryEntry(te.Repository, obj1, nil), ".")
+			return nil
+		}))
+
+		// root directory and 3 files, each 3 bytes long.
+		require.Equal(t, int32(4), prog.queued)
+		require.Equal(t, int32(4), prog.processed)
+		require.Equal(t, int32(0), prog.failed)
+		require.Equal(t, int64(9), prog.readBytes)
+		require.Equal(t, 1, prog.flushCount)
+	})
+
 	t.Run("FullFileReadsAndBlobMap", func(t *testing.T) {
 		// full verification with file reads
 		opts := snapshotfs.VerifierOptions{
@@ -166,3 +188,25 @@ func TestSnapshotVerifier(t *testing.T) {
 		}), "encountered 3 errors")
 	})
 }
+
+type testVerifierProgress struct {
+	mu sync.Mutex
+
+	queued, processed, failed int32
+	readBytes                 int64
+	flushCount                int
+}
+
+func (p *testVerifierProgress) SetCounters(queuedCount, processedCount, failedCount int32, readBytes int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.queued, p.processed, p.failed, p.readBytes = queuedCount, processedCount, failedCount, readBytes
+}
+
+func (p *testVerifierProgress) Flush() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.flushCount++
+}
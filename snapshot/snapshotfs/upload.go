@@ -23,6 +23,7 @@
 	"github.com/kopia/kopia/fs"
 	"github.com/kopia/kopia/fs/ignorefs"
 	"github.com/kopia/kopia/internal/iocopy"
+	"github.com/kopia/kopia/internal/metrics"
 	"github.com/kopia/kopia/internal/timetrack"
 	"github.com/kopia/kopia/internal/workshare"
 	"github.com/kopia/kopia/repo"
@@ -259,7 +260,7 @@ func (u *Uploader) uploadFileData(ctx context.Context, parentCheckpointRegistry
 			return nil, nil
 		}
 
-		return newDirEntry(f, fname, checkpointID)
+		return newDirEntry(ctx, f, fname, checkpointID)
 	})
 
 	defer parentCheckpointRegistry.removeCheckpointCallback(fname)
@@ -285,7 +286,7 @@ func (u *Uploader) uploadFileData(ctx context.Context, parentCheckpointRegistry
 		return nil, errors.Wrap(err, "unable to get result")
 	}
 
-	de, err := newDirEntry(f, fname, r)
+	de, err := newDirEntry(ctx, f, fname, r)
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to create dir entry")
 	}
@@ -326,7 +327,7 @@ func (u *Uploader) uploadSymlinkInternal(ctx context.Context, relativePath strin
 		return nil, errors.Wrap(err, "unable to get result")
 	}
 
-	de, err := newDirEntry(f, f.Name(), r)
+	de, err := newDirEntry(ctx, f, f.Name(), r)
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to create dir entry")
 	}
@@ -372,7 +373,7 @@ func (u *Uploader) uploadStreamingFileInternal(ctx context.Context, relativePath
 		return nil, errors.Wrap(err, "unable to get result")
 	}
 
-	de, err := newDirEntry(f, f.Name(), r)
+	de, err := newDirEntry(ctx, f, f.Name(), r)
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to create dir entry")
 	}
@@ -431,8 +432,8 @@ func (u *Uploader) copyWithProgress(dst io.Writer, src io.Reader) (int64, error)
 }
 
 // newDirEntryWithSummary makes DirEntry objects for directory Entries that need a DirectorySummary.
-func newDirEntryWithSummary(d fs.Entry, oid object.ID, summ *fs.DirectorySummary) (*snapshot.DirEntry, error) {
-	de, err := newDirEntry(d, d.Name(), oid)
+func newDirEntryWithSummary(ctx context.Context, d fs.Entry, oid object.ID, summ *fs.DirectorySummary) (*snapshot.DirEntry, error) {
+	de, err := newDirEntry(ctx, d, d.Name(), oid)
 	if err != nil {
 		return nil, err
 	}
@@ -443,7 +444,7 @@ func newDirEntryWithSummary(d fs.Entry, oid object.ID, summ *fs.DirectorySummary
 }
 
 // newDirEntry makes DirEntry objects for any type of Entry.
-func newDirEntry(md fs.Entry, fname string, oid object.ID) (*snapshot.DirEntry, error) {
+func newDirEntry(ctx context.Context, md fs.Entry, fname string, oid object.ID) (*snapshot.DirEntry, error) {
 	var entryType snapshot.EntryType
 
 	switch md := md.(type) {
@@ -457,7 +458,7 @@ func newDirEntry(md fs.Entry, fname string, oid object.ID) (*snapshot.DirEntry,
 		return nil, errors.Errorf("invalid entry type %T", md)
 	}
 
-	return &snapshot.DirEntry{
+	de := &snapshot.DirEntry{
 		Name:        fname,
 		Type:        entryType,
 		Permissions: snapshot.Permissions(md.Mode() & fs.ModBits),
@@ -466,23 +467,43 @@ func newDirEntry(md fs.Entry, fname string, oid object.ID) (*snapshot.DirEntry,
 		UserID:      md.Owner().UserID,
 		GroupID:     md.Owner().GroupID,
 		ObjectID:    oid,
-	}, nil
+	}
+
+	if sde, ok := md.(fs.EntryWithSecurityDescriptor); ok {
+		// failure to read the security descriptor does not prevent the entry from being snapshotted.
+		if sd, err := sde.SecurityDescriptor(); err != nil {
+			uploadLog(ctx).Warnf("unable to get security descriptor of %v: %v", fname, err)
+		} else {
+			de.SecurityDescriptor = sd
+		}
+	}
+
+	if xe, ok := md.(fs.EntryWithExtendedAttributes); ok {
+		// failure to read extended attributes does not prevent the entry from being snapshotted.
+		if attrs, err := xe.ExtendedAttributes(); err != nil {
+			uploadLog(ctx).Warnf("unable to get extended attributes of %v: %v", fname, err)
+		} else {
+			de.ExtendedAttributes = attrs
+		}
+	}
+
+	return de, nil
 }
 
 // newCachedDirEntry makes DirEntry objects for entries that are also in
 // previous snapshots. It ensures file sizes are populated correctly for
 // StreamingFiles.
-func newCachedDirEntry(md, cached fs.Entry, fname string) (*snapshot.DirEntry, error) {
+func newCachedDirEntry(ctx context.Context, md, cached fs.Entry, fname string) (*snapshot.DirEntry, error) {
 	hoid, ok := cached.(object.HasObjectID)
 	if !ok {
 		return nil, errors.New("cached entry does not implement HasObjectID")
 	}
 
 	if _, ok := md.(fs.StreamingFile); ok {
-		return newDirEntry(cached, fname, hoid.ObjectID())
+		return newDirEntry(ctx, cached, fname, hoid.ObjectID())
 	}
 
-	return newDirEntry(md, fname, hoid.ObjectID())
+	return newDirEntry(ctx, md, fname, hoid.ObjectID())
 }
 
 // uploadFileWithCheckpointing uploads the specified File to the repository.
@@ -497,7 +518,7 @@ func (u *Uploader) uploadFileWithCheckpointing(ctx context.Context, relativePath
 		return nil, err
 	}
 
-	return newDirEntryWithSummary(file, res.ObjectID, &fs.DirectorySummary{
+	return newDirEntryWithSummary(ctx, file, res.ObjectID, &fs.DirectorySummary{
 		TotalFileCount: 1,
 		TotalFileSize:  res.FileSize,
 		MaxModTime:     res.ModTime,
@@ -853,7 +874,7 @@ func (u *Uploader) processSingle(
 			atomic.AddInt64(&u.stats.TotalFileSize, cachedEntry.Size())
 			u.Progress.CachedFile(entryRelativePath, cachedEntry.Size())
 
-			cachedDirEntry, err := newCachedDirEntry(entry, cachedEntry, entry.Name())
+			cachedDirEntry, err := newCachedDirEntry(ctx, entry, cachedEntry, entry.Name())
 
 			u.Progress.FinishedFile(entryRelativePath, err)
 
@@ -1162,7 +1183,7 @@ func uploadDirInternal(
 			return nil, errors.Wrap(err, "error writing dir manifest")
 		}
 
-		return newDirEntryWithSummary(directory, oid, checkpointManifest.Summary)
+		return newDirEntryWithSummary(ctx, directory, oid, checkpointManifest.Summary)
 	})
 	defer thisCheckpointRegistry.removeCheckpointCallback(directory.Name())
 
@@ -1177,7 +1198,7 @@ func uploadDirInternal(
 		return nil, errors.Wrapf(err, "error writing dir manifest: %v", directory.Name())
 	}
 
-	return newDirEntryWithSummary(directory, oid, dirManifest.Summary)
+	return newDirEntryWithSummary(ctx, directory, oid, dirManifest.Summary)
 }
 
 func (u *Uploader) reportErrorAndMaybeCancel(err error, isIgnored bool, dmb *DirManifestBuilder, entryRelativePath string) {
@@ -1241,6 +1262,48 @@ func (u *Uploader) Upload(
 	policyTree *policy.Tree,
 	sourceInfo snapshot.SourceInfo,
 	previousManifests ...*snapshot.Manifest,
+) (*snapshot.Manifest, error) {
+	timer := timetrack.StartTimer()
+
+	man, err := u.upload(ctx, source, policyTree, sourceInfo, previousManifests...)
+
+	u.recordSnapshotDuration(timer.Elapsed(), man, err)
+
+	return man, err
+}
+
+// recordSnapshotDuration records the duration of a snapshot in the repository metrics, if available.
+func (u *Uploader) recordSnapshotDuration(dt time.Duration, man *snapshot.Manifest, err error) {
+	mp, ok := u.repo.(interface {
+		Metrics() *metrics.Registry
+	})
+	if !ok {
+		return
+	}
+
+	result := "success"
+
+	switch {
+	case err != nil:
+		result = "error"
+	case man.IncompleteReason != "":
+		result = "incomplete"
+	}
+
+	mp.Metrics().DurationDistribution(
+		"snapshot_duration",
+		"Duration of snapshots by result",
+		metrics.LongOperationThresholds,
+		map[string]string{"result": result},
+	).Observe(dt)
+}
+
+func (u *Uploader) upload(
+	ctx context.Context,
+	source fs.Entry,
+	policyTree *policy.Tree,
+	sourceInfo snapshot.SourceInfo,
+	previousManifests ...*snapshot.Manifest,
 ) (*snapshot.Manifest, error) {
 	ctx, span := uploadTracer.Start(ctx, "Upload")
 	defer span.End()
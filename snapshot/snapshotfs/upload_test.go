@@ -31,6 +31,7 @@
 	"github.com/kopia/kopia/internal/blobtesting"
 	"github.com/kopia/kopia/internal/clock"
 	"github.com/kopia/kopia/internal/faketime"
+	"github.com/kopia/kopia/internal/metrics"
 	"github.com/kopia/kopia/internal/mockfs"
 	"github.com/kopia/kopia/internal/repotesting"
 	"github.com/kopia/kopia/internal/testlogging"
@@ -245,6 +246,32 @@ func TestUpload_TopLevelDirectoryReadFailure(t *testing.T) {
 	require.Nil(t, s)
 }
 
+func TestUpload_RecordsSnapshotDuration(t *testing.T) {
+	ctx := testlogging.Context(t)
+	th := newUploadTestHarness(ctx, t)
+
+	defer th.cleanup()
+
+	u := NewUploader(th.repo)
+
+	policyTree := policy.BuildTree(nil, policy.DefaultPolicy)
+
+	_, err := u.Upload(ctx, th.sourceDir, policyTree, snapshot.SourceInfo{})
+	require.NoError(t, err)
+
+	th.sourceDir.FailReaddir(errTest)
+
+	_, err = u.Upload(ctx, th.sourceDir, policyTree, snapshot.SourceInfo{})
+	require.ErrorIs(t, err, errTest)
+
+	snap := th.repo.(interface {
+		Metrics() *metrics.Registry
+	}).Metrics().Snapshot(false)
+
+	require.EqualValues(t, 1, snap.DurationDistributions["snapshot_duration[result:success]"].Count)
+	require.EqualValues(t, 1, snap.DurationDistributions["snapshot_duration[result:error]"].Count)
+}
+
 func TestUploadDoesNotReportProgressForIgnoredFilesTwice(t *testing.T) {
 	ctx := testlogging.Context(t)
 	th := newUploadTestHarness(ctx, t)
@@ -1634,3 +1661,72 @@ func verifyLogDetails(t *testing.T, desc string, wantDetailKeys []string, keysAn
 	sort.Strings(wantDetailKeys)
 	require.Equal(t, wantDetailKeys, gotDetailKeys, "invalid details for "+desc)
 }
+
+type fileWithSecurityDescriptor struct {
+	fs.File
+
+	sd  string
+	err error
+}
+
+func (f fileWithSecurityDescriptor) SecurityDescriptor() (string, error) {
+	return f.sd, f.err
+}
+
+func TestNewDirEntrySecurityDescriptor(t *testing.T) {
+	f := mockfs.NewDirectory().AddFile("f1", []byte{1, 2, 3}, 0o644)
+
+	ctx := testlogging.Context(t)
+
+	de, err := newDirEntry(ctx, f, "f1", object.EmptyID)
+	require.NoError(t, err)
+	require.Empty(t, de.SecurityDescriptor)
+
+	const sddl = "O:BAG:SYD:(A;;FA;;;SY)(A;;FA;;;BA)"
+
+	de, err = newDirEntry(ctx, fileWithSecurityDescriptor{File: f, sd: sddl}, "f1", object.EmptyID)
+	require.NoError(t, err)
+	require.Equal(t, sddl, de.SecurityDescriptor)
+
+	// entry is still created when its security descriptor can't be read.
+	de, err = newDirEntry(ctx, fileWithSecurityDescriptor{File: f, sd: sddl, err: errors.New("some error")}, "f1", object.EmptyID)
+	require.NoError(t, err)
+	require.Empty(t, de.SecurityDescriptor)
+}
+
+type fileWithExtendedAttributes struct {
+	fs.File
+
+	attrs map[string][]byte
+	err   error
+}
+
+func (f fileWithExtendedAttributes) ExtendedAttributes() (map[string][]byte, error) {
+	return f.attrs, f.err
+}
+
+func TestNewDirEntryExtendedAttributes(t *testing.T) {
+	f := mockfs.NewDirectory().AddFile("f1", []byte{1, 2, 3}, 0o644)
+	ctx := testlogging.Context(t)
+
+	attrs := map[string][]byte{"user.some-attr": []byte("some-value")}
+
+	de, err := newDirEntry(ctx, fileWithExtendedAttributes{File: f, attrs: attrs}, "f1", object.EmptyID)
+	require.NoError(t, err)
+	require.Equal(t, attrs, de.ExtendedAttributes)
+
+	// extended attributes are preserved in the directory listing.
+	re := EntryFromDirEntry(nil, de)
+
+	xe, ok := re.(fs.EntryWithExtendedAttributes)
+	require.True(t, ok)
+
+	got, err := xe.ExtendedAttributes()
+	require.NoError(t, err)
+	require.Equal(t, attrs, got)
+
+	// entry is still created when its extended attributes can't be read.
+	de, err = newDirEntry(ctx, fileWithExtendedAttributes{File: f, attrs: attrs, err: errors.New("some error")}, "f1", object.EmptyID)
+	require.NoError(t, err)
+	require.Nil(t, de.ExtendedAttributes)
+}
@@ -77,7 +77,7 @@ func Run(ctx context.Context, rep repo.DirectRepositoryWriter, gcDelete bool, sa
 	var st Stats
 
 	err := maintenance.ReportRun(ctx, rep, maintenance.TaskSnapshotGarbageCollection, nil, func() error {
-		if err := runInternal(ctx, rep, gcDelete, safety, maintenanceStartTime, &st); err != nil {
+		if err := runInternal(ctx, rep, gcDelete, false, safety, maintenanceStartTime, &st); err != nil {
 			return err
 		}
 
@@ -98,7 +98,25 @@ func Run(ctx context.Context, rep repo.DirectRepositoryWriter, gcDelete bool, sa
 	return st, errors.Wrap(err, "error running snapshot gc")
 }
 
-func runInternal(ctx context.Context, rep repo.DirectRepositoryWriter, gcDelete bool, safety maintenance.SafetyParameters, maintenanceStartTime time.Time, st *Stats) error {
+// Preview performs the same analysis as Run without modifying the repository and reports
+// contents that would be deleted or recovered.
+func Preview(ctx context.Context, rep repo.DirectRepositoryWriter, safety maintenance.SafetyParameters, maintenanceStartTime time.Time) (Stats, error) {
+	var st Stats
+
+	if err := runInternal(ctx, rep, false, true, safety, maintenanceStartTime, &st); err != nil {
+		return st, errors.Wrap(err, "error running snapshot gc preview")
+	}
+
+	l := log(ctx)
+
+	l.Infof("GC would delete %v unused contents (%v)", st.UnusedCount, units.BytesString(st.UnusedBytes))
+	l.Infof("GC would recover %v deleted contents that are still in use (%v)", st.UndeletedCount, units.BytesString(st.UndeletedBytes))
+	l.Infof("GC found %v unused contents that are too recent to delete (%v)", st.TooRecentCount, units.BytesString(st.TooRecentBytes))
+
+	return st, nil
+}
+
+func runInternal(ctx context.Context, rep repo.DirectRepositoryWriter, gcDelete, dryRun bool, safety maintenance.SafetyParameters, maintenanceStartTime time.Time, st *Stats) error {
 	var unused, inUse, system, tooRecent, undeleted stats.CountSum
 
 	used, serr := bigmap.NewSet(ctx)
@@ -125,8 +143,10 @@ func runInternal(ctx context.Context, rep repo.DirectRepositoryWriter, gcDelete
 
 		if used.Contains(ci.ContentID.Append(cidbuf[:0])) {
 			if ci.Deleted {
-				if err := rep.ContentManager().UndeleteContent(ctx, ci.ContentID); err != nil {
-					return errors.Wrapf(err, "Could not undelete referenced content: %v", ci)
+				if !dryRun {
+					if err := rep.ContentManager().UndeleteContent(ctx, ci.ContentID); err != nil {
+						return errors.Wrapf(err, "Could not undelete referenced content: %v", ci)
+					}
 				}
 
 				undeleted.Add(int64(ci.PackedLength))
@@ -176,5 +196,9 @@ func runInternal(ctx context.Context, rep repo.DirectRepositoryWriter, gcDelete
 		return errors.Wrap(err, "error iterating contents")
 	}
 
+	if dryRun {
+		return nil
+	}
+
 	return errors.Wrap(rep.Flush(ctx), "flush error")
 }
@@ -11,6 +11,19 @@
 	"github.com/kopia/kopia/snapshot/snapshotgc"
 )
 
+// Preview reports what the complete snapshot and repository maintenance would delete or rewrite,
+// without modifying the repository.
+func Preview(ctx context.Context, dr repo.DirectRepositoryWriter, mode maintenance.Mode, safety maintenance.SafetyParameters) error {
+	if mode == maintenance.ModeFull {
+		if _, err := snapshotgc.Preview(ctx, dr, safety, dr.Time()); err != nil {
+			return errors.Wrap(err, "snapshot GC failure")
+		}
+	}
+
+	//nolint:wrapcheck
+	return maintenance.Preview(ctx, dr, mode, safety)
+}
+
 // Run runs the complete snapshot and repository maintenance.
 func Run(ctx context.Context, dr repo.DirectRepositoryWriter, mode maintenance.Mode, force bool, safety maintenance.SafetyParameters) error {
 	//nolint:wrapcheck
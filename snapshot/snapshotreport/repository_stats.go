@@ -0,0 +1,218 @@
+package snapshotreport
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/content"
+	"github.com/kopia/kopia/repo/manifest"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+)
+
+// RepositoryStatsOptions controls computation of repository statistics.
+type RepositoryStatsOptions struct {
+	// When set, the amount of data attributable to each snapshot is computed,
+	// which requires walking all snapshots and can be slow.
+	SnapshotSizes bool
+}
+
+// RepositoryStats describes storage usage of the entire repository.
+type RepositoryStats struct {
+	// all blobs in the storage, including indexes and unreferenced pack blobs.
+	BlobCount int64 `json:"blobCount"`
+	BlobBytes int64 `json:"blobBytes"`
+
+	// unique (deduplicated) contents before and after compression and encryption.
+	ContentCount       int64 `json:"contentCount"`
+	UniqueBytes        int64 `json:"uniqueBytes"`
+	StoredContentBytes int64 `json:"storedContentBytes"`
+
+	// total size of files across all snapshots.
+	SnapshotCount int   `json:"snapshotCount"`
+	LogicalBytes  int64 `json:"logicalBytes"`
+
+	// DeduplicationRatio is LogicalBytes/UniqueBytes and CompressionRatio is UniqueBytes/StoredContentBytes.
+	DeduplicationRatio float64 `json:"deduplicationRatio"`
+	CompressionRatio   float64 `json:"compressionRatio"`
+
+	ContentSizeHistogram []SizeBucket `json:"contentSizeHistogram"`
+
+	// Growth is the amount of currently stored content data by the month in which it was written.
+	Growth []GrowthPoint `json:"growth"`
+
+	// Snapshots is only available when RepositoryStatsOptions.SnapshotSizes is set.
+	Snapshots []*SnapshotSize `json:"snapshots,omitempty"`
+}
+
+// SizeBucket represents a single bucket of content size histogram, containing contents
+// whose stored size is in [MinSize, MaxSize).
+type SizeBucket struct {
+	MinSize   int64 `json:"minSize"`
+	MaxSize   int64 `json:"maxSize"`
+	Count     int64 `json:"count"`
+	TotalSize int64 `json:"totalSize"`
+}
+
+// GrowthPoint describes the amount of content data written in a single month, which is still stored.
+type GrowthPoint struct {
+	Month      time.Time `json:"month"`
+	AddedBytes int64     `json:"addedBytes"`
+	TotalBytes int64     `json:"totalBytes"`
+}
+
+// SnapshotSize describes the amount of data attributed to a single snapshot,
+// which is the data first introduced by that snapshot among snapshots of the same source.
+type SnapshotSize struct {
+	ID               manifest.ID         `json:"id"`
+	Source           snapshot.SourceInfo `json:"source"`
+	StartTime        time.Time           `json:"startTime"`
+	LogicalBytes     int64               `json:"logicalBytes"`
+	NewOriginalBytes int64               `json:"newOriginalBytes"`
+	NewStoredBytes   int64               `json:"newStoredBytes"`
+}
+
+// numSizeBuckets is the number of power-of-ten buckets in the content size histogram, starting at 10 bytes.
+const numSizeBuckets = 8
+
+// ComputeRepositoryStats computes storage usage statistics of the provided repository.
+func ComputeRepositoryStats(ctx context.Context, rep repo.DirectRepository, opt RepositoryStatsOptions) (*RepositoryStats, error) {
+	s := &RepositoryStats{}
+
+	if err := rep.BlobReader().ListBlobs(ctx, "", func(bm blob.Metadata) error {
+		s.BlobCount++
+		s.BlobBytes += bm.Length
+
+		return nil
+	}); err != nil {
+		return nil, errors.Wrap(err, "error listing blobs")
+	}
+
+	if err := s.computeContentStats(ctx, rep); err != nil {
+		return nil, err
+	}
+
+	sources, err := snapshot.ListSources(ctx, rep)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list sources")
+	}
+
+	for _, src := range sources {
+		snaps, err := snapshot.ListSnapshots(ctx, rep, src)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to list snapshots of %v", src)
+		}
+
+		snaps = snapshot.SortByTime(snaps, false)
+
+		for _, m := range snaps {
+			s.SnapshotCount++
+			s.LogicalBytes += m.Stats.TotalFileSize
+		}
+
+		if opt.SnapshotSizes && len(snaps) > 0 {
+			if err := s.computeSnapshotSizes(ctx, rep, src, snaps); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if s.UniqueBytes > 0 {
+		s.DeduplicationRatio = float64(s.LogicalBytes) / float64(s.UniqueBytes)
+	}
+
+	if s.StoredContentBytes > 0 {
+		s.CompressionRatio = float64(s.UniqueBytes) / float64(s.StoredContentBytes)
+	}
+
+	return s, nil
+}
+
+func (s *RepositoryStats) computeContentStats(ctx context.Context, rep repo.DirectRepository) error {
+	var minSize int64
+
+	for i, maxSize := 0, int64(10); i < numSizeBuckets; i, maxSize = i+1, maxSize*10 { //nolint:mnd
+		s.ContentSizeHistogram = append(s.ContentSizeHistogram, SizeBucket{MinSize: minSize, MaxSize: maxSize})
+		minSize = maxSize
+	}
+
+	addedByMonth := map[time.Time]int64{}
+
+	if err := rep.ContentReader().IterateContents(ctx, content.IterateOptions{}, func(ci content.Info) error {
+		packed := int64(ci.PackedLength)
+
+		s.ContentCount++
+		s.UniqueBytes += int64(ci.OriginalLength)
+		s.StoredContentBytes += packed
+
+		for i := range s.ContentSizeHistogram {
+			if b := &s.ContentSizeHistogram[i]; packed >= b.MinSize && (packed < b.MaxSize || i == len(s.ContentSizeHistogram)-1) {
+				b.Count++
+				b.TotalSize += packed
+
+				break
+			}
+		}
+
+		t := ci.Timestamp().UTC()
+		addedByMonth[time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)] += packed
+
+		return nil
+	}); err != nil {
+		return errors.Wrap(err, "error iterating contents")
+	}
+
+	if len(addedByMonth) == 0 {
+		return nil
+	}
+
+	// produce a continuous series of months, so that periods without growth are visible.
+	var first, last time.Time
+
+	for m := range addedByMonth {
+		if first.IsZero() || m.Before(first) {
+			first = m
+		}
+
+		if m.After(last) {
+			last = m
+		}
+	}
+
+	var total int64
+
+	for m := first; !m.After(last); m = m.AddDate(0, 1, 0) {
+		total += addedByMonth[m]
+
+		s.Growth = append(s.Growth, GrowthPoint{
+			Month:      m,
+			AddedBytes: addedByMonth[m],
+			TotalBytes: total,
+		})
+	}
+
+	return nil
+}
+
+func (s *RepositoryStats) computeSnapshotSizes(ctx context.Context, rep repo.Repository, src snapshot.SourceInfo, snaps []*snapshot.Manifest) error {
+	if err := snapshotfs.CalculateStorageStats(ctx, rep, snaps, func(m *snapshot.Manifest) error {
+		s.Snapshots = append(s.Snapshots, &SnapshotSize{
+			ID:               m.ID,
+			Source:           m.Source,
+			StartTime:        m.StartTime.ToTime(),
+			LogicalBytes:     m.Stats.TotalFileSize,
+			NewOriginalBytes: m.StorageStats.NewData.OriginalContentBytes,
+			NewStoredBytes:   m.StorageStats.NewData.PackedContentBytes,
+		})
+
+		return nil
+	}); err != nil {
+		return errors.Wrapf(err, "unable to calculate storage stats of %v", src)
+	}
+
+	return nil
+}
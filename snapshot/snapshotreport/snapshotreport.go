@@ -0,0 +1,234 @@
+// Package snapshotreport generates per-source summaries of snapshot history over a time range,
+// suitable for capacity planning and chargeback.
+package snapshotreport
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+)
+
+// Options controls the contents of the report.
+type Options struct {
+	// Sources to include in the report, all sources when empty.
+	Sources []snapshot.SourceInfo
+
+	// Snapshots that started in [StartTime, EndTime) are included, zero values are unbounded.
+	StartTime time.Time
+	EndTime   time.Time
+
+	// When set, the amount of new data added by each snapshot is computed,
+	// which requires walking all snapshots of each source and can be slow.
+	StorageStats bool
+}
+
+// SourceSummary summarizes snapshots of a single source in the report time range.
+type SourceSummary struct {
+	Source snapshot.SourceInfo `json:"source"`
+
+	SnapshotCount  int `json:"snapshots"`
+	FailureCount   int `json:"failures"`   // incomplete snapshots and snapshots with errors
+	FileErrorCount int `json:"fileErrors"` // total number of errors encountered across all snapshots
+
+	FirstSnapshotTime time.Time `json:"firstSnapshotTime"`
+	LastSnapshotTime  time.Time `json:"lastSnapshotTime"`
+
+	TotalDuration   time.Duration `json:"totalDuration"`
+	AverageDuration time.Duration `json:"averageDuration"`
+	MaxDuration     time.Duration `json:"maxDuration"`
+
+	// size of the most recent snapshot in the range.
+	LastSnapshotSize int64 `json:"lastSnapshotSize"`
+
+	// new data added to the repository by snapshots in the range, only available when Options.StorageStats is set.
+	NewBytes         *int64 `json:"newBytes,omitempty"`
+	NewOriginalBytes *int64 `json:"newOriginalBytes,omitempty"`
+}
+
+// Generate returns the summary of snapshots of each source in the requested time range,
+// sources with no snapshots in the range are omitted.
+func Generate(ctx context.Context, rep repo.Repository, opt Options) ([]*SourceSummary, error) {
+	sources := opt.Sources
+
+	if len(sources) == 0 {
+		all, err := snapshot.ListSources(ctx, rep)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to list sources")
+		}
+
+		sources = all
+	}
+
+	var result []*SourceSummary
+
+	for _, src := range sources {
+		snaps, err := snapshot.ListSnapshots(ctx, rep, src)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to list snapshots of %v", src)
+		}
+
+		s, err := summarize(ctx, rep, src, snapshot.SortByTime(snaps, false), opt)
+		if err != nil {
+			return nil, err
+		}
+
+		if s.SnapshotCount > 0 {
+			result = append(result, s)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Source.String() < result[j].Source.String()
+	})
+
+	return result, nil
+}
+
+func inRange(m *snapshot.Manifest, opt Options) bool {
+	st := m.StartTime.ToTime()
+
+	if !opt.StartTime.IsZero() && st.Before(opt.StartTime) {
+		return false
+	}
+
+	if !opt.EndTime.IsZero() && !st.Before(opt.EndTime) {
+		return false
+	}
+
+	return true
+}
+
+// summarize computes the summary of the provided snapshots, sorted by time.
+func summarize(ctx context.Context, rep repo.Repository, src snapshot.SourceInfo, snaps []*snapshot.Manifest, opt Options) (*SourceSummary, error) {
+	s := &SourceSummary{Source: src}
+
+	for _, m := range snaps {
+		if !inRange(m, opt) {
+			continue
+		}
+
+		d := m.EndTime.Sub(m.StartTime)
+
+		if s.SnapshotCount == 0 {
+			s.FirstSnapshotTime = m.StartTime.ToTime()
+		}
+
+		s.SnapshotCount++
+		s.LastSnapshotTime = m.StartTime.ToTime()
+		s.LastSnapshotSize = m.Stats.TotalFileSize
+		s.TotalDuration += d
+		s.MaxDuration = max(s.MaxDuration, d)
+		s.FileErrorCount += int(m.Stats.ErrorCount)
+
+		if m.IncompleteReason != "" || m.Stats.ErrorCount > 0 {
+			s.FailureCount++
+		}
+	}
+
+	if s.SnapshotCount == 0 {
+		return s, nil
+	}
+
+	s.AverageDuration = s.TotalDuration / time.Duration(s.SnapshotCount)
+
+	if opt.StorageStats {
+		if err := computeNewBytes(ctx, rep, s, snaps, opt); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// computeNewBytes computes the amount of new data added by snapshots in range, which requires
+// processing all earlier snapshots of the source, since the data may have been present before.
+func computeNewBytes(ctx context.Context, rep repo.Repository, s *SourceSummary, snaps []*snapshot.Manifest, opt Options) error {
+	var relevant []*snapshot.Manifest
+
+	for _, m := range snaps {
+		if opt.EndTime.IsZero() || m.StartTime.ToTime().Before(opt.EndTime) {
+			relevant = append(relevant, m)
+		}
+	}
+
+	var packed, original int64
+
+	if err := snapshotfs.CalculateStorageStats(ctx, rep, relevant, func(m *snapshot.Manifest) error {
+		if inRange(m, opt) {
+			packed += m.StorageStats.NewData.PackedContentBytes
+			original += m.StorageStats.NewData.OriginalContentBytes
+		}
+
+		return nil
+	}); err != nil {
+		return errors.Wrapf(err, "unable to calculate storage stats of %v", s.Source)
+	}
+
+	s.NewBytes = &packed
+	s.NewOriginalBytes = &original
+
+	return nil
+}
+
+// WriteJSON writes the report as JSON.
+func WriteJSON(w io.Writer, summaries []*SourceSummary) error {
+	if summaries == nil {
+		summaries = []*SourceSummary{}
+	}
+
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+
+	return errors.Wrap(e.Encode(summaries), "unable to write JSON")
+}
+
+// WriteCSV writes the report as CSV with a header row, durations are in seconds and sizes in bytes.
+func WriteCSV(w io.Writer, summaries []*SourceSummary) error {
+	cw := csv.NewWriter(w)
+
+	//nolint:errcheck
+	cw.Write([]string{
+		"host", "user", "path",
+		"snapshots", "failures", "file_errors",
+		"first_snapshot_time", "last_snapshot_time",
+		"total_duration_seconds", "average_duration_seconds", "max_duration_seconds",
+		"last_snapshot_size_bytes", "new_bytes", "new_original_bytes",
+	})
+
+	for _, s := range summaries {
+		//nolint:errcheck
+		cw.Write([]string{
+			s.Source.Host, s.Source.UserName, s.Source.Path,
+			strconv.Itoa(s.SnapshotCount), strconv.Itoa(s.FailureCount), strconv.Itoa(s.FileErrorCount),
+			s.FirstSnapshotTime.UTC().Format(time.RFC3339), s.LastSnapshotTime.UTC().Format(time.RFC3339),
+			secondsString(s.TotalDuration), secondsString(s.AverageDuration), secondsString(s.MaxDuration),
+			strconv.FormatInt(s.LastSnapshotSize, 10), optionalInt64String(s.NewBytes), optionalInt64String(s.NewOriginalBytes),
+		})
+	}
+
+	cw.Flush()
+
+	return errors.Wrap(cw.Error(), "unable to write CSV")
+}
+
+func secondsString(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', 3, 64) //nolint:mnd
+}
+
+func optionalInt64String(v *int64) string {
+	if v == nil {
+		return ""
+	}
+
+	return strconv.FormatInt(*v, 10)
+}
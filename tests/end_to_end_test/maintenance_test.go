@@ -55,6 +55,13 @@ func (s *formatSpecificTestSuite) TestFullMaintenance(t *testing.T) {
 		t.Fatalf("full maintenance is not expected to change any blobs due to safety margins (got %v, was %v)", got, originalBlobs)
 	}
 
+	// dry run with --safety=none reports but does not delete anything
+	e.RunAndExpectSuccess(t, "maintenance", "run", "--full", "--safety=none", "--dry-run", "--disable-internal-log")
+
+	if got := e.RunAndExpectSuccess(t, "blob", "list", "--data-only"); len(got) != len(originalBlobs) {
+		t.Fatalf("maintenance dry run is not expected to change any blobs (got %v, was %v)", got, originalBlobs)
+	}
+
 	// now rerun with --safety=none
 	e.RunAndExpectSuccess(t, "maintenance", "run", "--full", "--safety=none", "--disable-internal-log")
 	e.RunAndExpectSuccess(t, "maintenance", "info")
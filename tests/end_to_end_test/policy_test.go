@@ -3,6 +3,8 @@
 import (
 	"testing"
 
+	"github.com/stretchr/testify/require"
+
 	"github.com/kopia/kopia/internal/testutil"
 	"github.com/kopia/kopia/repo/content"
 	"github.com/kopia/kopia/repo/manifest"
@@ -52,3 +54,30 @@ func TestDefaultGlobalPolicy(t *testing.T) {
 		t.Fatalf("unexpected number of policies %v, want %v", got, want)
 	}
 }
+
+func TestPolicyShowDefinedOnly(t *testing.T) {
+	t.Parallel()
+
+	runner := testenv.NewInProcRunner(t)
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, runner)
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+
+	srcdir := testutil.TempDirectory(t)
+
+	// no policy defined yet, but effective policy is always available.
+	e.RunAndExpectSuccess(t, "policy", "show", srcdir)
+	e.RunAndExpectFailure(t, "policy", "show", "--no-effective", srcdir)
+
+	e.RunAndExpectSuccess(t, "policy", "set", srcdir, "--keep-latest=7")
+
+	var defined, effective policy.Policy
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "policy", "show", "--no-effective", "--json", srcdir), &defined)
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "policy", "show", "--json", srcdir), &effective)
+
+	require.Equal(t, policy.OptionalInt(7), *defined.RetentionPolicy.KeepLatest)
+	require.Nil(t, defined.RetentionPolicy.KeepDaily)
+	require.Equal(t, policy.OptionalInt(7), *effective.RetentionPolicy.KeepLatest)
+	require.NotNil(t, effective.RetentionPolicy.KeepDaily)
+}
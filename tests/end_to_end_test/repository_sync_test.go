@@ -1,9 +1,18 @@
 package endtoend_test
 
 import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/stretchr/testify/require"
+
 	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/blob/filesystem"
 	"github.com/kopia/kopia/tests/clitestutil"
 	"github.com/kopia/kopia/tests/testenv"
 )
@@ -55,3 +64,85 @@ func TestRepositorySync(t *testing.T) {
 	// syncing to the directory should fail because it contains incompatible format blob.
 	e2.RunAndExpectFailure(t, "repo", "sync-to", "filesystem", "--path", dir2)
 }
+
+func TestRepositorySyncWatch(t *testing.T) {
+	t.Parallel()
+
+	runner := testenv.NewInProcRunner(t)
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, runner)
+
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+	e.RunAndExpectSuccess(t, "snapshot", "create", sharedTestDataDir1)
+
+	dir2 := testutil.TempDirectory(t)
+	dir3 := testutil.TempDirectory(t)
+
+	tok, err := repo.EncodeToken("", blob.ConnectionInfo{
+		Type:   "filesystem",
+		Config: &filesystem.Options{Path: dir3},
+	})
+	require.NoError(t, err)
+
+	tokenFile := filepath.Join(testutil.TempDirectory(t), "replica.token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte(tok), 0o600))
+
+	runWatch := func(extraArgs ...string) string {
+		var lines []string
+
+		wait, interrupt := e.RunAndProcessStderrInt(t, func(line string) bool {
+			lines = append(lines, line)
+
+			return !strings.Contains(line, "Next synchronization in")
+		}, append([]string{"repo", "sync-to", "filesystem", "--path", dir2, "--replica-token-file", tokenFile, "--watch-interval=1h"}, extraArgs...)...)
+
+		interrupt(os.Interrupt)
+		require.NoError(t, wait())
+
+		return strings.Join(lines, "\n")
+	}
+
+	out := runWatch()
+	require.Contains(t, out, "Synchronization pass 1 of Filesystem: "+dir2)
+	require.Contains(t, out, "Synchronization pass 1 of Filesystem: "+dir3)
+	require.NotContains(t, out, "conflicts")
+
+	// modify the contents of a pack blob in the second replica without changing its length.
+	var packFile string
+
+	require.NoError(t, filepath.WalkDir(dir3, func(path string, d fs.DirEntry, err error) error {
+		// blob files are sharded into subdirectories named after the prefix of the blob ID.
+		if err == nil && !d.IsDir() && strings.HasPrefix(strings.TrimPrefix(path, dir3+string(filepath.Separator)), "p") {
+			packFile = path
+		}
+
+		return err
+	}))
+	require.NotEmpty(t, packFile)
+
+	data, err := os.ReadFile(packFile)
+	require.NoError(t, err)
+
+	data[0] ^= 1
+	require.NoError(t, os.WriteFile(packFile, data, 0o600))
+
+	// by default only the lengths are compared.
+	out = runWatch()
+	require.NotContains(t, out, "conflicts")
+
+	out = runWatch("--compare-contents")
+	require.Contains(t, out, "differs between source and destination")
+	require.Contains(t, out, "Synchronization pass 1 of Filesystem: "+dir3+" finished with conflicts")
+
+	// a change of length is detected without downloading the contents.
+	require.NoError(t, os.WriteFile(packFile, append(data, 0), 0o600))
+
+	out = runWatch()
+	require.Contains(t, out, "differs between source and destination")
+	require.Contains(t, out, "Synchronization pass 1 of Filesystem: "+dir3+" finished with conflicts")
+
+	// the first replica is in sync.
+	e.RunAndExpectSuccess(t, "repo", "connect", "filesystem", "--path", dir2)
+	require.Len(t, clitestutil.ListSnapshotsAndExpectSuccess(t, e), 1)
+}
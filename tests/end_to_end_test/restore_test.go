@@ -922,3 +922,67 @@ func TestRestoreByPathWithoutTarget(t *testing.T) {
 	// Defaults to latest snapshot time
 	e.RunAndExpectSuccess(t, "restore", srcdir)
 }
+
+func TestRestoreWithIncludeExclude(t *testing.T) {
+	t.Parallel()
+
+	runner := testenv.NewInProcRunner(t)
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, runner)
+
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+
+	srcdir := testutil.TempDirectory(t)
+
+	for _, f := range []string{"a.docx", "a.txt", "sub/b.docx", "sub/b.txt", "cache/c.docx"} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(srcdir, f)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(srcdir, f), []byte(f), 0o644))
+	}
+
+	e.RunAndExpectSuccess(t, "snapshot", "create", srcdir)
+
+	restoreDir := testutil.TempDirectory(t)
+	e.RunAndExpectSuccess(t, "restore", srcdir, restoreDir, "--include=**/*.docx", "--exclude=cache/**")
+
+	for f, wantExists := range map[string]bool{
+		"a.docx":       true,
+		"a.txt":        false,
+		"sub/b.docx":   true,
+		"sub/b.txt":    false,
+		"cache/c.docx": false,
+	} {
+		_, err := os.Stat(filepath.Join(restoreDir, f))
+		require.Equal(t, wantExists, err == nil, f)
+	}
+}
+
+func TestRestoreRenameExistingFiles(t *testing.T) {
+	t.Parallel()
+
+	runner := testenv.NewInProcRunner(t)
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, runner)
+
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+
+	srcdir := testutil.TempDirectory(t)
+	require.NoError(t, os.WriteFile(filepath.Join(srcdir, "a.txt"), []byte("restored"), 0o644))
+
+	e.RunAndExpectSuccess(t, "snapshot", "create", srcdir)
+
+	restoreDir := testutil.TempDirectory(t)
+	require.NoError(t, os.WriteFile(filepath.Join(restoreDir, "a.txt"), []byte("existing"), 0o644))
+
+	e.RunAndExpectSuccess(t, "restore", srcdir, restoreDir, "--rename-existing-files")
+	e.RunAndExpectSuccess(t, "restore", srcdir, restoreDir, "--rename-existing-files")
+
+	for f, want := range map[string]string{
+		"a.txt":            "existing",
+		"a.txt.restored-1": "restored",
+		"a.txt.restored-2": "restored",
+	} {
+		got, err := os.ReadFile(filepath.Join(restoreDir, f))
+		require.NoError(t, err)
+		require.Equal(t, want, string(got), f)
+	}
+}
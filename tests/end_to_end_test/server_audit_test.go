@@ -0,0 +1,123 @@
+package endtoend_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/apiclient"
+	"github.com/kopia/kopia/internal/auditlog"
+	"github.com/kopia/kopia/internal/serverapi"
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/repo/manifest"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/tests/clitestutil"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestServerAuditLog(t *testing.T) {
+	t.Parallel()
+
+	serverEnvironment := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	defer serverEnvironment.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	serverEnvironment.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", serverEnvironment.RepoDir, "--override-hostname=foo", "--override-username=foo")
+	serverEnvironment.RunAndExpectSuccess(t, "server", "users", "add", "foo@bar", "--user-password", "baz")
+
+	auditFile := filepath.Join(testutil.TempDirectory(t), "audit.json")
+
+	var sp testutil.ServerParameters
+
+	wait, kill := serverEnvironment.RunAndProcessStderr(t, sp.ProcessOutput,
+		"server", "start",
+		"--address=localhost:0",
+		"--server-control-username=admin-user",
+		"--server-control-password=admin-pwd",
+		"--server-username=ui-user",
+		"--server-password=ui-pwd",
+		"--tls-generate-cert",
+		"--tls-generate-rsa-key-size=2048", // use shorter key size to speed up generation
+		"--audit-log-file", auditFile,
+	)
+
+	clientEnvironment := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	delete(clientEnvironment.Environment, "KOPIA_PASSWORD")
+
+	clientEnvironment.RunAndExpectFailure(t, "repo", "connect", "server",
+		"--url", sp.BaseURL+"/",
+		"--server-cert-fingerprint", sp.SHA256Fingerprint,
+		"--override-username", "foo",
+		"--override-hostname", "bar",
+		"--password", "wrong",
+	)
+
+	clientEnvironment.RunAndExpectSuccess(t, "repo", "connect", "server",
+		"--url", sp.BaseURL+"/",
+		"--server-cert-fingerprint", sp.SHA256Fingerprint,
+		"--override-username", "foo",
+		"--override-hostname", "bar",
+		"--password", "baz",
+	)
+
+	// snapshots created and deleted by repository clients are audited as the client user.
+	clientEnvironment.RunAndExpectSuccess(t, "snapshot", "create", sharedTestDataDir1)
+	clientEnvironment.RunAndExpectSuccess(t, "snapshot", "create", sharedTestDataDir1)
+
+	sources := clitestutil.ListSnapshotsAndExpectSuccess(t, clientEnvironment)
+	require.Len(t, sources, 1)
+	require.Len(t, sources[0].Snapshots, 2)
+
+	clientEnvironment.RunAndExpectSuccess(t, "snapshot", "delete", sources[0].Snapshots[0].SnapshotID, "--delete")
+	clientEnvironment.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	// snapshots deleted through the UI API are audited as the authenticated UI user.
+	cli, err := apiclient.NewKopiaAPIClient(apiclient.Options{
+		BaseURL:                             sp.BaseURL,
+		TrustedServerCertificateFingerprint: sp.SHA256Fingerprint,
+		Username:                            "ui-user",
+		Password:                            "ui-pwd",
+	})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	require.NoError(t, cli.FetchCSRFTokenForTesting(ctx))
+
+	// let the server discover the source created by the client.
+	require.NoError(t, cli.Post(ctx, "refresh", &serverapi.Empty{}, &serverapi.Empty{}))
+	require.NoError(t, cli.Post(ctx, "snapshots/delete", &serverapi.DeleteSnapshotsRequest{
+		SourceInfo:          snapshot.SourceInfo{UserName: "foo", Host: "bar", Path: sources[0].Path},
+		SnapshotManifestIDs: []manifest.ID{manifest.ID(sources[0].Snapshots[1].SnapshotID)},
+	}, &serverapi.Empty{}))
+
+	kill()
+	wait()
+
+	f, err := os.Open(auditFile)
+	require.NoError(t, err)
+
+	defer f.Close()
+
+	actionsByUser := map[string][]string{}
+
+	for dec := json.NewDecoder(f); dec.More(); {
+		var ev auditlog.Event
+
+		require.NoError(t, dec.Decode(&ev))
+		require.NotEmpty(t, ev.Remote)
+
+		actionsByUser[ev.User] = append(actionsByUser[ev.User], ev.Action)
+	}
+
+	require.Contains(t, actionsByUser[""], auditlog.ActionAccessDenied)
+	require.Contains(t, actionsByUser["foo@bar"], auditlog.ActionConnect)
+	require.Contains(t, actionsByUser["foo@bar"], auditlog.ActionSnapshot)
+	require.Contains(t, actionsByUser["foo@bar"], auditlog.ActionDeleteSnapshots)
+	require.Equal(t, []string{auditlog.ActionDeleteSnapshots}, actionsByUser["ui-user"])
+}
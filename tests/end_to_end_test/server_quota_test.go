@@ -0,0 +1,74 @@
+package endtoend_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/internal/user"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestServerUserStorageQuota(t *testing.T) {
+	t.Parallel()
+
+	serverEnvironment := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	defer serverEnvironment.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	serverEnvironment.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", serverEnvironment.RepoDir, "--override-hostname=foo", "--override-username=foo")
+	serverEnvironment.RunAndExpectSuccess(t, "server", "users", "add", "foo@bar", "--user-password", "baz", "--storage-quota=10KB")
+	serverEnvironment.RunAndExpectSuccess(t, "server", "users", "add", "another@bar", "--user-password", "baz", "--max-upload-speed=100MB")
+	serverEnvironment.RunAndExpectFailure(t, "server", "users", "set", "another@bar", "--storage-quota=lots")
+
+	var foo, another user.Profile
+
+	testutil.MustParseJSONLines(t, serverEnvironment.RunAndExpectSuccess(t, "server", "users", "info", "foo@bar"), &foo)
+	require.EqualValues(t, 10<<10, foo.StorageQuotaBytes)
+	require.Zero(t, foo.MaxUploadBytesPerSecond)
+
+	testutil.MustParseJSONLines(t, serverEnvironment.RunAndExpectSuccess(t, "server", "users", "info", "another@bar"), &another)
+	require.Zero(t, another.StorageQuotaBytes)
+	require.EqualValues(t, 100<<20, another.MaxUploadBytesPerSecond)
+
+	var sp testutil.ServerParameters
+
+	wait, kill := serverEnvironment.RunAndProcessStderr(t, sp.ProcessOutput,
+		"server", "start",
+		"--address=localhost:0",
+		"--server-control-username=admin-user",
+		"--server-control-password=admin-pwd",
+		"--tls-generate-cert",
+		"--tls-generate-rsa-key-size=2048", // use shorter key size to speed up generation
+	)
+
+	defer wait()
+	defer kill()
+
+	for _, username := range []string{"foo", "another"} {
+		clientEnvironment := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+		delete(clientEnvironment.Environment, "KOPIA_PASSWORD")
+
+		clientEnvironment.RunAndExpectSuccess(t, "repo", "connect", "server",
+			"--url", sp.BaseURL+"/",
+			"--server-cert-fingerprint", sp.SHA256Fingerprint,
+			"--override-username", username,
+			"--override-hostname", "bar",
+			"--password", "baz",
+		)
+
+		if username == "foo" {
+			// foo@bar has exceeded the quota.
+			_, stderr := clientEnvironment.RunAndExpectFailure(t, "snapshot", "create", sharedTestDataDir1)
+			require.Contains(t, strings.Join(stderr, "\n"), "storage quota exceeded")
+		} else {
+			// another@bar only has upload speed limit.
+			clientEnvironment.RunAndExpectSuccess(t, "snapshot", "create", sharedTestDataDir1)
+		}
+
+		clientEnvironment.RunAndExpectSuccess(t, "repo", "disconnect")
+	}
+}
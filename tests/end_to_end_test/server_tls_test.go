@@ -0,0 +1,172 @@
+package endtoend_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/apiclient"
+	"github.com/kopia/kopia/internal/clock"
+	"github.com/kopia/kopia/internal/serverapi"
+	"github.com/kopia/kopia/internal/testlogging"
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/internal/tlsutil"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestServerTLSClientCertificateAuth(t *testing.T) {
+	t.Parallel()
+
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+	e.RunAndExpectSuccess(t, "server", "users", "add", "foo@bar", "--user-password", "baz")
+
+	caCert, caKey := mustCreateCertificate(t, nil, nil)
+	clientCert, clientKey := mustCreateCertificate(t, caCert, caKey)
+
+	caFile := filepath.Join(testutil.TempDirectory(t), "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caCert.Raw}), 0o600))
+
+	// ACME can't be combined with other certificate sources.
+	e.RunAndExpectFailure(t, "server", "start", "--address=localhost:0", "--tls-generate-cert", "--tls-acme-domain=example.com")
+
+	var sp testutil.ServerParameters
+
+	wait, kill := e.RunAndProcessStderr(t, sp.ProcessOutput,
+		"server", "start",
+		"--address=localhost:0",
+		"--server-control-username=admin-user",
+		"--server-control-password=admin-pwd",
+		"--tls-generate-cert",
+		"--tls-generate-rsa-key-size=2048", // use shorter key size to speed up generation
+		"--tls-client-ca-file", caFile,
+	)
+
+	defer wait()
+	defer kill()
+
+	get := func(certs ...tls.Certificate) (*http.Response, error) {
+		tc := tlsutil.TLSConfigTrustingSingleCertificate(sp.SHA256Fingerprint)
+		tc.Certificates = certs
+
+		cli := &http.Client{Transport: &http.Transport{TLSClientConfig: tc}}
+		defer cli.CloseIdleConnections()
+
+		//nolint:noctx
+		return cli.Get(sp.BaseURL + "/api/v1/control/status")
+	}
+
+	// no client certificate - handshake fails.
+	_, err := get()
+	require.Error(t, err)
+
+	resp, err := get(tls.Certificate{Certificate: [][]byte{clientCert.Raw}, PrivateKey: clientKey})
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	// TLS handshake succeeded, the request itself is rejected due to missing credentials.
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	// the API client presents the configured client certificates.
+	cli, err := apiclient.NewKopiaAPIClient(apiclient.Options{
+		BaseURL:                             sp.BaseURL,
+		TrustedServerCertificateFingerprint: sp.SHA256Fingerprint,
+		Username:                            "admin-user",
+		Password:                            "admin-pwd",
+		ClientCertificates:                  []tls.Certificate{{Certificate: [][]byte{clientCert.Raw}, PrivateKey: clientKey}},
+	})
+	require.NoError(t, err)
+
+	var status serverapi.StatusResponse
+
+	require.NoError(t, cli.Get(testlogging.Context(t), "control/status", nil, &status))
+
+	// repository clients present the client certificate provided when connecting.
+	certDir := testutil.TempDirectory(t)
+	clientCertFile := filepath.Join(certDir, "client.pem")
+	clientKeyFile := filepath.Join(certDir, "client.key")
+
+	require.NoError(t, tlsutil.WriteCertificateToFile(clientCertFile, clientCert))
+	require.NoError(t, tlsutil.WritePrivateKeyToFile(clientKeyFile, clientKey))
+
+	clientEnvironment := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	delete(clientEnvironment.Environment, "KOPIA_PASSWORD")
+
+	connectArgs := []string{
+		"repo", "connect", "server",
+		"--url", sp.BaseURL + "/",
+		"--server-cert-fingerprint", sp.SHA256Fingerprint,
+		"--override-username", "foo",
+		"--override-hostname", "bar",
+		"--password", "baz",
+	}
+
+	clientEnvironment.RunAndExpectFailure(t, connectArgs...)
+	clientEnvironment.RunAndExpectFailure(t, append(connectArgs, "--tls-client-cert", clientCertFile)...)
+	clientEnvironment.RunAndExpectSuccess(t, append(connectArgs, "--tls-client-cert", clientCertFile, "--tls-client-key", clientKeyFile)...)
+
+	// the certificate is persisted in the connection configuration.
+	clientEnvironment.RunAndExpectSuccess(t, "snapshot", "create", sharedTestDataDir1)
+	clientEnvironment.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	// server control commands present the client certificate as well.
+	statusArgs := []string{
+		"server", "status",
+		"--address", sp.BaseURL,
+		"--server-cert-fingerprint", sp.SHA256Fingerprint,
+		"--server-control-username", "admin-user",
+		"--server-control-password", "admin-pwd",
+	}
+
+	e.RunAndExpectFailure(t, statusArgs...)
+	e.RunAndExpectFailure(t, append(statusArgs, "--tls-client-cert", clientCertFile)...)
+	e.RunAndExpectSuccess(t, append(statusArgs, "--tls-client-cert", clientCertFile, "--tls-client-key", clientKeyFile)...)
+}
+
+// mustCreateCertificate creates a CA certificate when parent is nil or client certificate signed by the parent otherwise.
+func mustCreateCertificate(t *testing.T, parent *x509.Certificate, parentKey *rsa.PrivateKey) (*x509.Certificate, *rsa.PrivateKey) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(clock.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "kopia-test-client"},
+		NotBefore:    clock.Now().Add(-time.Hour),
+		NotAfter:     clock.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	if parent == nil {
+		template.Subject.CommonName = "kopia-test-ca"
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), parentKey)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return cert, key
+}
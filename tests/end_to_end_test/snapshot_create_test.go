@@ -86,6 +86,53 @@ func TestSnapshotCreate(t *testing.T) {
 	require.Len(t, manifests, 6)
 }
 
+func TestSnapshotCreateForceRehash(t *testing.T) {
+	t.Parallel()
+
+	runner := testenv.NewInProcRunner(t)
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, runner)
+
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+
+	var man1, man2, man3 snapshot.Manifest
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "snapshot", "create", sharedTestDataDir2, "--json", "--json-verbose"), &man1)
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "snapshot", "create", sharedTestDataDir2, "--json", "--json-verbose"), &man2)
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "snapshot", "create", sharedTestDataDir2, "--json", "--json-verbose", "--force-rehash"), &man3)
+
+	// unchanged files are reused from the previous snapshot.
+	require.NotZero(t, man2.Stats.CachedFiles)
+	require.Zero(t, man2.Stats.NonCachedFiles)
+
+	// all files are re-hashed when forced.
+	require.Zero(t, man3.Stats.CachedFiles)
+	require.Equal(t, man2.Stats.CachedFiles, man3.Stats.NonCachedFiles)
+	require.Equal(t, man1.RootEntry.ObjectID, man3.RootEntry.ObjectID)
+}
+
+func TestSnapshotCreateAsyncPackUploads(t *testing.T) {
+	t.Parallel()
+
+	runner := testenv.NewInProcRunner(t)
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, runner)
+
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+
+	var man1, man2 snapshot.Manifest
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "snapshot", "create", sharedTestDataDir2, "--json", "--async-pack-uploads=3"), &man1)
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "snapshot", "create", sharedTestDataDir2, "--json", "--force-rehash"), &man2)
+
+	// the same contents are produced regardless of how packs are uploaded.
+	require.Equal(t, man1.RootEntry.ObjectID, man2.RootEntry.ObjectID)
+
+	e.RunAndExpectSuccess(t, "snapshot", "verify", "--verify-files-percent=100")
+}
+
 func TestTagging(t *testing.T) {
 	t.Parallel()
 
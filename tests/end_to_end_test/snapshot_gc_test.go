@@ -70,6 +70,10 @@ func (s *formatSpecificTestSuite) TestSnapshotGC(t *testing.T) {
 	// make sure we are not too quick
 	time.Sleep(2 * time.Second)
 
+	// contents are not old enough to be collected with the overridden grace period.
+	e.RunAndExpectSuccess(t, "maintenance", "run", "--full", "--safety=none", "--safety-gc-min-content-age=1h")
+	e.RunAndVerifyOutputLineCount(t, expectedContentCount, "content", "list")
+
 	// garbage-collect for real, this time without age limit
 	e.RunAndExpectSuccess(t, "maintenance", "run", "--full", "--safety=none")
 
@@ -52,6 +52,13 @@ func (s *formatSpecificTestSuite) TestSnapshotMigrate(t *testing.T) {
 
 	dstenv.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", dstenv.RepoDir)
 
+	// dry run does not migrate anything
+	dstPolicyCount := len(dstenv.RunAndExpectSuccess(t, "policy", "list"))
+
+	dstenv.RunAndExpectSuccess(t, "snapshot", "migrate", "--source-config", filepath.Join(e.ConfigDir, ".kopia.config"), "--all", "--overwrite-policies", "--dry-run")
+	dstenv.RunAndVerifyOutputLineCount(t, 0, "snapshot", "list", ".", "-a")
+	dstenv.RunAndVerifyOutputLineCount(t, dstPolicyCount, "policy", "list")
+
 	dstenv.RunAndExpectSuccess(t, "snapshot", "migrate", "--source-config", filepath.Join(e.ConfigDir, ".kopia.config"), "--all", "--parallel=5", "--overwrite-policies")
 	dstenv.RunAndVerifyOutputLineCount(t, sourceSnapshotCount, "snapshot", "list", ".", "-a")
 	dstenv.RunAndVerifyOutputLineCount(t, sourcePolicyCount, "policy", "list")